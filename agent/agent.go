@@ -0,0 +1,199 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/server"
+)
+
+// Agent renders templates that reference secrets to files, and re-renders them when the secrets change
+type Agent struct {
+	logger       *logrus.Logger
+	secrets      SecretGetter
+	pollInterval time.Duration
+	templates    []*agentTemplate
+	exec         *ExecOpts
+	reloadSignal os.Signal
+}
+
+type agentTemplate struct {
+	opts     TemplateOpts
+	perms    os.FileMode
+	rendered []byte
+}
+
+// passCache fetches each secret once per render pass, so templates sharing a secret see the same version of it
+type passCache struct {
+	SecretGetter
+	mu      sync.Mutex
+	secrets map[server.GetSecretRequest]*common.Secret
+}
+
+func (c *passCache) GetSecret(ctx context.Context, req *server.GetSecretRequest) (*common.Secret, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	secret, ok := c.secrets[*req]
+	if ok {
+		return secret, nil
+	}
+	secret, err := c.SecretGetter.GetSecret(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	c.secrets[*req] = secret
+	return secret, nil
+}
+
+func NewAgent(logger *logrus.Logger, secrets SecretGetter, opts AgentOpts) (*Agent, error) {
+	a := &Agent{
+		logger:       logger,
+		secrets:      secrets,
+		pollInterval: time.Duration(opts.PollSeconds) * time.Second,
+		exec:         opts.Exec,
+	}
+	for _, templateOpts := range opts.Templates {
+		if templateOpts.Source == "" || templateOpts.Destination == "" {
+			return nil, common.NewInitializationError("agent", "Expected source and destination for every template")
+		}
+		perms, err := templateOpts.FileMode()
+		if err != nil {
+			return nil, err
+		}
+		// an unchanged file left by a previous run isn't a change
+		rendered, _ := ioutil.ReadFile(templateOpts.Destination)
+		a.templates = append(a.templates, &agentTemplate{opts: templateOpts, perms: perms, rendered: rendered})
+	}
+	if a.exec != nil && a.exec.ReloadSignal != "" {
+		sig, err := parseSignal(a.exec.ReloadSignal)
+		if err != nil {
+			return nil, err
+		}
+		a.reloadSignal = sig
+	}
+	return a, nil
+}
+
+// render renders every template, writing the ones whose output changed. It returns the number of changed files.
+func (a *Agent) render(ctx context.Context) (int, error) {
+	secrets := &passCache{SecretGetter: a.secrets, secrets: make(map[server.GetSecretRequest]*common.Secret)}
+	changed := 0
+	var firstErr error
+	for _, t := range a.templates {
+		didChange, err := a.renderOne(ctx, secrets, t)
+		if err != nil {
+			// one broken template shouldn't stop the others from updating
+			a.logger.Errorf("Error rendering %s: %v", t.opts.Source, err)
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		if didChange {
+			changed++
+		}
+	}
+	return changed, firstErr
+}
+
+func (a *Agent) renderOne(ctx context.Context, secrets SecretGetter, t *agentTemplate) (bool, error) {
+	text, err := ioutil.ReadFile(t.opts.Source)
+	if err != nil {
+		return false, err
+	}
+	rendered, err := renderTemplate(ctx, secrets, t.opts.Source, string(text))
+	if err != nil {
+		return false, err
+	}
+	if t.rendered != nil && bytes.Equal(rendered, t.rendered) {
+		return false, nil
+	}
+	err = writeFile(t.opts.Destination, rendered, t.perms)
+	if err != nil {
+		return false, err
+	}
+	t.rendered = rendered
+	a.logger.Infof("Rendered %s to %s", t.opts.Source, t.opts.Destination)
+
+	if t.opts.Command != "" {
+		output, err := exec.CommandContext(ctx, "sh", "-c", t.opts.Command).CombinedOutput()
+		if err != nil {
+			a.logger.Errorf("Error running command for %s: %v: %s", t.opts.Destination, err, output)
+		}
+	}
+	return true, nil
+}
+
+func (a *Agent) startChild() (*exec.Cmd, <-chan error, error) {
+	if a.exec == nil || len(a.exec.Command) == 0 {
+		return nil, nil, nil
+	}
+	cmd := exec.Command(a.exec.Command[0], a.exec.Command[1:]...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	err := cmd.Start()
+	if err != nil {
+		return nil, nil, common.NewInitializationError("agent", "Unable to start %s: %v", a.exec.Command[0], err)
+	}
+	a.logger.Infof("Started %s with pid %d", a.exec.Command[0], cmd.Process.Pid)
+	exited := make(chan error, 1)
+	go func() {
+		exited <- cmd.Wait()
+	}()
+	return cmd, exited, nil
+}
+
+// Run renders every template, starts the child command if one is configured, then re-renders on every poll until
+// ctx is canceled or the child exits. The first render must succeed, so the child never starts without its files.
+func (a *Agent) Run(ctx context.Context) error {
+	_, err := a.render(ctx)
+	if err != nil {
+		return err
+	}
+	child, exited, err := a.startChild()
+	if err != nil {
+		return err
+	}
+
+	timer := time.NewTicker(a.pollInterval)
+	defer timer.Stop()
+	for true {
+		select {
+		case <-ctx.Done():
+			a.logger.Debug("Context canceled. Closing agent")
+			if child != nil {
+				child.Process.Signal(os.Interrupt)
+				<-exited
+			}
+			return nil
+		case err := <-exited:
+			if err != nil {
+				return fmt.Errorf("Child process exited: %v", err)
+			}
+			return nil
+		case <-timer.C:
+			changed, err := a.render(ctx)
+			if err != nil {
+				a.logger.Errorf("Error in render: %v", err)
+			}
+			if changed > 0 && child != nil && a.reloadSignal != nil {
+				a.logger.Infof("Sending %s to pid %d", a.exec.ReloadSignal, child.Process.Pid)
+				err = child.Process.Signal(a.reloadSignal)
+				if err != nil {
+					a.logger.Errorf("Error sending %s: %v", a.exec.ReloadSignal, err)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,78 @@
+package agent
+
+import (
+	"io/ioutil"
+	"os"
+	"strconv"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+const defaultFilePerms = 0600
+
+type TemplateOpts struct {
+	// Source is the path of a text/template file
+	Source string `yaml:"source"`
+	// Destination is where the rendered file is written
+	Destination string `yaml:"destination"`
+	// Perms is the octal file mode of the rendered file. Defaults to 0600.
+	Perms string `yaml:"perms"`
+	// Command is run with sh -c after the file is re-rendered
+	Command string `yaml:"command"`
+}
+
+// FileMode parses Perms
+func (o TemplateOpts) FileMode() (os.FileMode, error) {
+	if o.Perms == "" {
+		return defaultFilePerms, nil
+	}
+	perms, err := strconv.ParseUint(o.Perms, 8, 32)
+	if err != nil || perms > 0777 {
+		return 0, common.NewInitializationError("agent-options", "Template %s has invalid perms %s", o.Source, o.Perms)
+	}
+	return os.FileMode(perms), nil
+}
+
+type ExecOpts struct {
+	// Command is started once every template has rendered, and stopped when the agent exits
+	Command []string `yaml:"command"`
+	// ReloadSignal is sent to the command when any template is re-rendered, e.g. SIGHUP. No signal is sent if empty.
+	ReloadSignal string `yaml:"reloadSignal"`
+}
+
+type AgentOpts struct {
+	Addr         string `yaml:"addr"`
+	ClientId     string `yaml:"clientId"`
+	ClientSecret string `yaml:"clientSecret"`
+	// ClientSecretEnvVar names an environment variable to read the client secret from, instead of the config file
+	ClientSecretEnvVar string         `yaml:"clientSecretEnvVar"`
+	LoggerType         string         `yaml:"loggerType"`
+	Env                string         `yaml:"env"`
+	PollSeconds        int            `yaml:"pollSeconds"`
+	Templates          []TemplateOpts `yaml:"templates"`
+	Exec               *ExecOpts      `yaml:"exec"`
+}
+
+func ReadOpts(filename string) (AgentOpts, error) {
+	raw, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return AgentOpts{}, common.NewInitializationError("agent-options", "Unable to read agent options for file, %s, with error: %v", filename, err)
+	}
+	var opts AgentOpts
+	err = yaml.Unmarshal(raw, &opts)
+	if err != nil {
+		return AgentOpts{}, common.NewInitializationError("agent-options", "Unable to unmarshal file, %s, with error: %v", filename, err)
+	}
+	if opts.ClientSecretEnvVar != "" {
+		opts.ClientSecret = os.Getenv(opts.ClientSecretEnvVar)
+	}
+	if opts.PollSeconds <= 0 {
+		opts.PollSeconds = 60
+	}
+	if len(opts.Templates) == 0 {
+		return AgentOpts{}, common.NewInitializationError("agent-options", "Expected at least one template in %s", filename)
+	}
+	return opts, nil
+}
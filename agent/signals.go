@@ -0,0 +1,28 @@
+//go:build !windows
+// +build !windows
+
+package agent
+
+import (
+	"os"
+	"syscall"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+var signals = map[string]os.Signal{
+	"SIGHUP":  syscall.SIGHUP,
+	"SIGINT":  syscall.SIGINT,
+	"SIGQUIT": syscall.SIGQUIT,
+	"SIGTERM": syscall.SIGTERM,
+	"SIGUSR1": syscall.SIGUSR1,
+	"SIGUSR2": syscall.SIGUSR2,
+}
+
+func parseSignal(name string) (os.Signal, error) {
+	sig, ok := signals[name]
+	if !ok {
+		return nil, common.NewInitializationError("agent", "Unknown reload signal %s", name)
+	}
+	return sig, nil
+}
@@ -0,0 +1,12 @@
+package agent
+
+import (
+	"os"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// Windows processes can't be sent reload signals
+func parseSignal(name string) (os.Signal, error) {
+	return nil, common.NewInitializationError("agent", "Reload signals are not supported on windows. Got %s", name)
+}
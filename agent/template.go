@@ -0,0 +1,102 @@
+package agent
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"text/template"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/server"
+)
+
+// SecretGetter fetches secrets. It is implemented by *client.Client.
+type SecretGetter interface {
+	GetSecret(ctx context.Context, req *server.GetSecretRequest) (*common.Secret, error)
+}
+
+// renderTemplate executes a template, resolving secrets with secrets. Templates can call:
+//
+//	{{ secret "name" }}                 the current value of a secret, or the content of a file secret
+//	{{ secretVersion "name" 2 }}        the value of a specific version of a secret
+//	{{ secretField "name" "field" }}    one field of the current version of a multi-field secret
+func renderTemplate(ctx context.Context, secrets SecretGetter, name, text string) ([]byte, error) {
+	getValue := func(secretName string, version int) (string, error) {
+		secret, err := secrets.GetSecret(ctx, &server.GetSecretRequest{Name: secretName, Version: version})
+		if err != nil {
+			return "", err
+		}
+		switch secret.ValueType {
+		case common.SECRET_VALUE_TYPE_FIELDS:
+			return "", fmt.Errorf("secret %s has fields. Use secretField to read one of them", secretName)
+		case common.SECRET_VALUE_TYPE_BINARY:
+			// file secrets come back base64 encoded, but are rendered as their content
+			data, err := base64.StdEncoding.DecodeString(secret.Value)
+			if err != nil {
+				return "", fmt.Errorf("secret %s has an invalid file value: %v", secretName, err)
+			}
+			return string(data), nil
+		}
+		return secret.Value, nil
+	}
+	getField := func(secretName, field string) (string, error) {
+		// fields are read from the whole secret, so they share the pass cache with secret
+		secret, err := secrets.GetSecret(ctx, &server.GetSecretRequest{Name: secretName})
+		if err != nil {
+			return "", err
+		}
+		value, ok := secret.Fields[field]
+		if !ok {
+			return "", fmt.Errorf("secret %s has no field %s", secretName, field)
+		}
+		return value, nil
+	}
+	funcs := template.FuncMap{
+		"secret": func(secretName string) (string, error) {
+			return getValue(secretName, 0)
+		},
+		"secretVersion": getValue,
+		"secretField":   getField,
+	}
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return nil, common.NewInvalidParamsError("renderTemplate", "Unable to parse template %s: %v", name, err)
+	}
+	var buf bytes.Buffer
+	err = tmpl.Execute(&buf, nil)
+	if err != nil {
+		return nil, common.NewInvalidParamsError("renderTemplate", "Unable to render template %s: %v", name, err)
+	}
+	return buf.Bytes(), nil
+}
+
+// writeFile atomically replaces path with data. The file is created with perms before any data is written to it, so
+// the rendered secrets are never readable with looser permissions.
+func writeFile(path string, data []byte, perms os.FileMode) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err != nil {
+		return err
+	}
+	tmpPath := tmp.Name()
+	defer os.Remove(tmpPath)
+
+	err = tmp.Chmod(perms)
+	if err == nil {
+		_, err = tmp.Write(data)
+	}
+	if err == nil {
+		err = tmp.Sync()
+	}
+	closeErr := tmp.Close()
+	if err != nil {
+		return err
+	}
+	if closeErr != nil {
+		return closeErr
+	}
+	return os.Rename(tmpPath, path)
+}
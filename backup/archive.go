@@ -0,0 +1,158 @@
+package backup
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/hex"
+	"encoding/json"
+	"io/ioutil"
+	"time"
+
+	"golang.org/x/crypto/scrypt"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+const (
+	ARCHIVE_FORMAT_VERSION = 5
+	KDF_SCRYPT             = "scrypt"
+
+	// scrypt parameters recommended for interactive use in 2017, which take ~100ms
+	scryptN       = 32768
+	scryptR       = 8
+	scryptP       = 1
+	saltSize      = 16
+	minPassphrase = 12
+)
+
+// ArchiveTable holds every row of a core database table, as a JSON array of objects keyed by column
+type ArchiveTable struct {
+	Name string          `json:"name"`
+	Rows json.RawMessage `json:"rows"`
+}
+
+// Archive is a consistent snapshot of the core database and the data keys of every secret version, database role and
+// transit key version in it. The data keys are unwrapped, so an archive can be restored under a different key encryption key.
+type Archive struct {
+	FormatVersion    int                       `json:"format_version"`
+	ServerVersion    string                    `json:"server_version"`
+	CreatedAt        time.Time                 `json:"created_at"`
+	Tables           []*ArchiveTable           `json:"tables"`
+	EncryptedSecrets []*common.EncryptedSecret `json:"encrypted_secrets"`
+}
+
+// sealedArchive is the on-disk format: a gzipped JSON Archive, encrypted with AES-GCM under a key derived from the
+// operator's passphrase
+type sealedArchive struct {
+	FormatVersion int    `json:"format_version"`
+	Kdf           string `json:"kdf"`
+	ScryptN       int    `json:"scrypt_n"`
+	ScryptR       int    `json:"scrypt_r"`
+	ScryptP       int    `json:"scrypt_p"`
+	Salt          string `json:"salt"`
+	Nonce         string `json:"nonce"`
+	Ciphertext    []byte `json:"ciphertext"`
+}
+
+func newArchiveCipher(passphrase string, salt []byte, n, r, p int) (cipher.AEAD, error) {
+	key, err := scrypt.Key([]byte(passphrase), salt, n, r, p, common.KEY_SIZE)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// Seal encrypts an archive with a passphrase
+func Seal(archive *Archive, passphrase string) ([]byte, error) {
+	op := "Seal"
+	if len(passphrase) < minPassphrase {
+		return nil, common.NewInvalidParamsError(op, "Expected a passphrase of at least %d characters", minPassphrase)
+	}
+	var plaintext bytes.Buffer
+	zw := gzip.NewWriter(&plaintext)
+	err := json.NewEncoder(zw).Encode(archive)
+	if err != nil {
+		return nil, common.NewInternalServerErrorFromError(op, err)
+	}
+	err = zw.Close()
+	if err != nil {
+		return nil, common.NewInternalServerErrorFromError(op, err)
+	}
+
+	salt, err := common.GenRandBytes(saltSize)
+	if err != nil {
+		return nil, common.NewInternalServerErrorFromError(op, err)
+	}
+	aead, err := newArchiveCipher(passphrase, salt, scryptN, scryptR, scryptP)
+	if err != nil {
+		return nil, common.NewInternalServerErrorFromError(op, err)
+	}
+	nonce, err := common.GenRandBytes(aead.NonceSize())
+	if err != nil {
+		return nil, common.NewInternalServerErrorFromError(op, err)
+	}
+	sealed := &sealedArchive{
+		FormatVersion: ARCHIVE_FORMAT_VERSION,
+		Kdf:           KDF_SCRYPT,
+		ScryptN:       scryptN,
+		ScryptR:       scryptR,
+		ScryptP:       scryptP,
+		Salt:          hex.EncodeToString(salt),
+		Nonce:         hex.EncodeToString(nonce),
+		Ciphertext:    aead.Seal(nil, nonce, plaintext.Bytes(), nil),
+	}
+	return json.Marshal(sealed)
+}
+
+// Open decrypts an archive sealed with Seal
+func Open(data []byte, passphrase string) (*Archive, error) {
+	op := "Open"
+	var sealed sealedArchive
+	err := json.Unmarshal(data, &sealed)
+	if err != nil {
+		return nil, common.NewInvalidParamsError(op, "Not a backup archive: %v", err)
+	}
+	if sealed.FormatVersion != ARCHIVE_FORMAT_VERSION || sealed.Kdf != KDF_SCRYPT {
+		return nil, common.NewInvalidParamsError(op, "Unsupported archive format %d with kdf %s", sealed.FormatVersion, sealed.Kdf)
+	}
+	salt, err := hex.DecodeString(sealed.Salt)
+	if err != nil {
+		return nil, common.NewInvalidParamsError(op, "Invalid salt: %v", err)
+	}
+	nonce, err := hex.DecodeString(sealed.Nonce)
+	if err != nil {
+		return nil, common.NewInvalidParamsError(op, "Invalid nonce: %v", err)
+	}
+	aead, err := newArchiveCipher(passphrase, salt, sealed.ScryptN, sealed.ScryptR, sealed.ScryptP)
+	if err != nil {
+		return nil, common.NewInvalidParamsError(op, "Invalid kdf parameters: %v", err)
+	}
+	if len(nonce) != aead.NonceSize() {
+		return nil, common.NewInvalidParamsError(op, "Expected a %d byte nonce. Got %d", aead.NonceSize(), len(nonce))
+	}
+	plaintext, err := aead.Open(nil, nonce, sealed.Ciphertext, nil)
+	if err != nil {
+		return nil, common.NewInvalidParamsError(op, "Unable to decrypt archive. The passphrase is wrong or the archive is corrupt")
+	}
+
+	zr, err := gzip.NewReader(bytes.NewReader(plaintext))
+	if err != nil {
+		return nil, common.NewInvalidParamsError(op, "Invalid archive payload: %v", err)
+	}
+	payload, err := ioutil.ReadAll(zr)
+	if err != nil {
+		return nil, common.NewInvalidParamsError(op, "Invalid archive payload: %v", err)
+	}
+	var archive Archive
+	err = json.Unmarshal(payload, &archive)
+	if err != nil {
+		return nil, common.NewInvalidParamsError(op, "Invalid archive payload: %v", err)
+	}
+	return &archive, nil
+}
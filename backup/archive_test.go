@@ -0,0 +1,113 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/database"
+)
+
+const (
+	adminId   = "00000000-0000-0000-0000-000000000001"
+	devId     = "00000000-0000-0000-0000-000000000002"
+	groupId   = "00000000-0000-0000-0000-000000000003"
+	secretId  = "00000000-0000-0000-0000-000000000004"
+	versionId = "00000000-0000-0000-0000-000000000005"
+	roleId    = "00000000-0000-0000-0000-000000000006"
+	transitId = "00000000-0000-0000-0000-000000000007"
+	keyVerId  = "00000000-0000-0000-0000-000000000008"
+	nsId      = "00000000-0000-0000-0000-000000000009"
+)
+
+func makeTestArchive() *Archive {
+	rows := map[string]string{
+		"admin.users": fmt.Sprintf(`[
+			{"id": "%[1]s", "name": "admin", "created_by": "%[1]s", "updated_by": "%[2]s"},
+			{"id": "%[2]s", "name": "dev", "created_by": "%[1]s", "updated_by": "%[1]s"}
+		]`, adminId, devId),
+		"admin.namespaces":         fmt.Sprintf(`[{"id": "%s", "name": "team-a", "created_by": "%s", "updated_by": "%[2]s"}]`, nsId, adminId),
+		"admin.namespace_admins":   fmt.Sprintf(`[{"id": "n1", "namespace_id": "%s", "user_id": "%s", "created_by": "%s", "updated_by": "%[3]s"}]`, nsId, devId, adminId),
+		"admin.user_groups":        fmt.Sprintf(`[{"id": "%s", "namespace_id": "%s", "created_by": "%s", "updated_by": "%[3]s"}]`, groupId, nsId, adminId),
+		"admin.user_group_members": fmt.Sprintf(`[{"id": "m1", "user_id": "%s", "user_group_id": "%s", "created_by": "%s", "updated_by": "%[3]s"}]`, devId, groupId, adminId),
+		"admin.secrets":            fmt.Sprintf(`[{"id": "%s", "namespace_id": "%s", "current_version": 1, "created_by": "%s", "updated_by": "%[3]s"}]`, secretId, common.DEFAULT_NAMESPACE_ID, adminId),
+		"admin.secret_versions":    fmt.Sprintf(`[{"id": "%s", "secret_id": "%s", "version": 1, "value": "ciphertext", "created_by": "%s"}]`, versionId, secretId, adminId),
+		"admin.secret_permissions": fmt.Sprintf(`[
+			{"id": "p1", "user_id": "%[1]s", "secret_id": "%[2]s", "secret_name_pattern": null, "namespace_id": null, "created_by": "%[3]s", "updated_by": "%[3]s"},
+			{"id": "p2", "user_id": "%[1]s", "secret_id": null, "secret_name_pattern": "a/*", "namespace_id": "%[4]s", "created_by": "%[3]s", "updated_by": "%[3]s"}
+		]`, devId, secretId, adminId, nsId),
+		"admin.secret_group_permissions":  fmt.Sprintf(`[{"id": "g1", "user_group_id": "%s", "secret_id": "%s", "created_by": "%s", "updated_by": "%[3]s"}]`, groupId, secretId, adminId),
+		"admin.database_roles":            fmt.Sprintf(`[{"id": "%s", "name": "app", "created_by": "%s", "updated_by": "%[2]s"}]`, roleId, adminId),
+		"admin.database_role_permissions": fmt.Sprintf(`[{"id": "r1", "database_role_id": "%s", "user_id": null, "user_group_id": "%s", "created_by": "%s", "updated_by": "%[3]s"}]`, roleId, groupId, adminId),
+		"admin.leases":                    fmt.Sprintf(`[{"id": "l1", "database_role_id": "%s", "user_id": "%s", "username": "v_app_1"}]`, roleId, devId),
+		"admin.transit_keys":              fmt.Sprintf(`[{"id": "%s", "name": "pii", "type": "aes256-gcm", "latest_version": 1, "created_by": "%s", "updated_by": "%[2]s"}]`, transitId, adminId),
+		"admin.transit_key_versions":      fmt.Sprintf(`[{"id": "%s", "transit_key_id": "%s", "version": 1, "created_by": "%s"}]`, keyVerId, transitId, adminId),
+		"admin.transit_key_permissions":   fmt.Sprintf(`[{"id": "t1", "transit_key_id": "%s", "user_id": "%s", "user_group_id": null, "created_by": "%s", "updated_by": "%[3]s"}]`, transitId, devId, adminId),
+	}
+	archive := &Archive{
+		FormatVersion:    ARCHIVE_FORMAT_VERSION,
+		ServerVersion:    "v0.0.1",
+		CreatedAt:        time.Date(2022, 1, 2, 3, 4, 5, 0, time.UTC),
+		EncryptedSecrets: []*common.EncryptedSecret{{Id: versionId, Key: "key", Iv: "iv"}, {Id: roleId, Key: "key", Iv: "iv"}, {Id: keyVerId, Key: "key"}},
+	}
+	for _, table := range database.BackupTables {
+		archive.Tables = append(archive.Tables, &ArchiveTable{Name: table, Rows: json.RawMessage(rows[table])})
+	}
+	return archive
+}
+
+func TestSealOpen(t *testing.T) {
+	archive := makeTestArchive()
+	sealed, err := Seal(archive, "correct horse battery staple")
+	require.Nil(t, err, "error in Seal: %v", err)
+
+	result, err := Open(sealed, "correct horse battery staple")
+	require.Nil(t, err, "error in Open: %v", err)
+	require.Equal(t, result.ServerVersion, archive.ServerVersion)
+	require.Equal(t, result.CreatedAt, archive.CreatedAt)
+	require.Equal(t, result.EncryptedSecrets, archive.EncryptedSecrets)
+	require.Equal(t, len(result.Tables), len(archive.Tables))
+	for idx, table := range result.Tables {
+		require.Equal(t, table.Name, archive.Tables[idx].Name)
+		require.JSONEq(t, string(table.Rows), string(archive.Tables[idx].Rows))
+	}
+}
+
+func TestSealErrors(t *testing.T) {
+	result, err := Seal(makeTestArchive(), "too short")
+	require.NotNil(t, err, "no error in Seal: %v", err)
+	require.Nil(t, result, "Result was not nil: %v", result)
+}
+
+func TestOpenErrors(t *testing.T) {
+	sealed, err := Seal(makeTestArchive(), "correct horse battery staple")
+	require.Nil(t, err, "error in Seal: %v", err)
+	var envelope map[string]interface{}
+	err = json.Unmarshal(sealed, &envelope)
+	require.Nil(t, err, "error unmarshalling archive: %v", err)
+	envelope["kdf"] = "md5"
+	unknownKdf, err := json.Marshal(envelope)
+	require.Nil(t, err, "error marshalling archive: %v", err)
+
+	var tests = []struct {
+		op         string
+		sealed     []byte
+		passphrase string
+	}{
+		{op: "wrong passphrase", sealed: sealed, passphrase: "incorrect horse battery staple"},
+		{op: "not an archive", sealed: []byte("hello"), passphrase: "correct horse battery staple"},
+		{op: "unknown kdf", sealed: unknownKdf, passphrase: "correct horse battery staple"},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("Open - Errors - %v", given.op), func(t *testing.T) {
+			result, err := Open(given.sealed, given.passphrase)
+			require.NotNil(t, err, "no error in Open: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+		})
+	}
+}
@@ -0,0 +1,133 @@
+package backup
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/database"
+	"github.com/emarcey/data-vault/dependencies/secrets"
+)
+
+// RestoreSummary counts what Restore inserted
+type RestoreSummary struct {
+	Rows             map[string]int64
+	EncryptedSecrets int
+}
+
+// keyedTables are the backup tables whose rows each have a data key stored under the row's id
+var keyedTables = map[string]bool{
+	"admin.secret_versions": true,
+	"admin.database_roles":  true,
+	// transit key versions have no data of their own; the key is the transit key material
+	"admin.transit_key_versions": true,
+}
+
+// Backup snapshots every backup table in a single read-only transaction, then fetches the data key of every secret
+// version, database role and transit key version in the snapshot. Data keys are written before the versions that use them, so the snapshot never
+// references a key that doesn't exist yet.
+func Backup(ctx context.Context, db *database.DatabaseEngine, secretsManager secrets.SecretsManager, serverVersion string) (*Archive, error) {
+	tx, err := db.StartSnapshotTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	archive := &Archive{
+		FormatVersion: ARCHIVE_FORMAT_VERSION,
+		ServerVersion: serverVersion,
+		CreatedAt:     time.Now().UTC(),
+	}
+	for _, table := range database.BackupTables {
+		rows, err := database.DumpTable(ctx, tx, table)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		archive.Tables = append(archive.Tables, &ArchiveTable{Name: table, Rows: rows})
+	}
+	tx.Rollback()
+
+	for _, table := range archive.Tables {
+		if !keyedTables[table.Name] {
+			continue
+		}
+		var keyedRows []*archiveRow
+		err = json.Unmarshal(table.Rows, &keyedRows)
+		if err != nil {
+			return nil, common.NewInternalServerErrorFromError("Backup", err)
+		}
+		for _, row := range keyedRows {
+			// versions of purged secrets have no value, and their data keys are deleted
+			if table.Name == "admin.secret_versions" && row.Value == nil {
+				continue
+			}
+			encryptedSecret, err := secretsManager.GetSecret(ctx, row.Id)
+			if err != nil {
+				return nil, err
+			}
+			// the envelope manager unwraps the key, but a store without one returns it as stored
+			archive.EncryptedSecrets = append(archive.EncryptedSecrets, &common.EncryptedSecret{
+				Id:  encryptedSecret.Id,
+				Key: encryptedSecret.Key,
+				Iv:  encryptedSecret.Iv,
+			})
+		}
+	}
+
+	err = archive.Validate()
+	if err != nil {
+		return nil, err
+	}
+	return archive, nil
+}
+
+// Restore validates an archive and inserts it into an empty core database and secrets store. The core database is
+// restored in a transaction that is only committed once every data key is stored.
+func Restore(ctx context.Context, db *database.DatabaseEngine, secretsManager secrets.SecretsManager, archive *Archive) (*RestoreSummary, error) {
+	op := "Restore"
+	err := archive.Validate()
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range database.BackupTables {
+		count, err := database.CountTableRows(ctx, db, table)
+		if err != nil {
+			return nil, err
+		}
+		if count > 0 {
+			return nil, common.NewInvalidParamsError(op, "Restore requires an empty database. %s has %d rows", table, count)
+		}
+	}
+
+	tables := make(map[string]json.RawMessage)
+	for _, table := range archive.Tables {
+		tables[table.Name] = table.Rows
+	}
+	summary := &RestoreSummary{Rows: make(map[string]int64)}
+
+	tx, err := db.StartTransaction(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, table := range database.BackupTables {
+		count, err := database.RestoreTable(ctx, tx, table, tables[table])
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		summary.Rows[table] = count
+	}
+	for _, encryptedSecret := range archive.EncryptedSecrets {
+		err = secretsManager.CreateSecret(ctx, encryptedSecret)
+		if err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+		summary.EncryptedSecrets++
+	}
+	err = tx.Commit()
+	if err != nil {
+		return nil, common.NewDatabaseError(err, op, "")
+	}
+	return summary, nil
+}
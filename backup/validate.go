@@ -0,0 +1,282 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/database"
+)
+
+// maxReportedProblems caps how many problems Validate lists, so a badly broken archive gives a readable error
+const maxReportedProblems = 20
+
+// archiveRow holds the columns of a backed-up row that reference other rows. Tables without a column leave it empty.
+type archiveRow struct {
+	Id                string  `json:"id"`
+	CreatedBy         string  `json:"created_by"`
+	UpdatedBy         string  `json:"updated_by"`
+	UserId            string  `json:"user_id"`
+	UserGroupId       string  `json:"user_group_id"`
+	DatabaseRoleId    string  `json:"database_role_id"`
+	TransitKeyId      string  `json:"transit_key_id"`
+	NamespaceId       *string `json:"namespace_id"`
+	SecretId          *string `json:"secret_id"`
+	SecretNamePattern *string `json:"secret_name_pattern"`
+	Value             *string `json:"value"`
+	Version           int     `json:"version"`
+	CurrentVersion    int     `json:"current_version"`
+}
+
+type validator struct {
+	problems []string
+}
+
+func (v *validator) addProblem(message string, messageArgs ...interface{}) {
+	v.problems = append(v.problems, fmt.Sprintf(message, messageArgs...))
+}
+
+func (v *validator) checkRef(table string, row *archiveRow, column, id string, ids map[string]bool) {
+	if !ids[id] {
+		v.addProblem("%s %s references missing %s %s", table, row.Id, column, id)
+	}
+}
+
+func (v *validator) checkAuthors(table string, rows []*archiveRow, userIds map[string]bool) {
+	for _, row := range rows {
+		v.checkRef(table, row, "created_by", row.CreatedBy, userIds)
+		v.checkRef(table, row, "updated_by", row.UpdatedBy, userIds)
+	}
+}
+
+func (v *validator) idSet(table string, rows []*archiveRow) map[string]bool {
+	ids := make(map[string]bool)
+	for _, row := range rows {
+		if ids[row.Id] {
+			v.addProblem("%s has duplicate id %s", table, row.Id)
+		}
+		ids[row.Id] = true
+	}
+	return ids
+}
+
+// checkNamespace checks the namespace_id of a row in a table where every row has one
+func (v *validator) checkNamespace(table string, row *archiveRow, namespaceIds map[string]bool) {
+	if row.NamespaceId == nil {
+		v.addProblem("%s %s has no namespace_id", table, row.Id)
+		return
+	}
+	v.checkRef(table, row, "namespace_id", *row.NamespaceId, namespaceIds)
+}
+
+func (v *validator) checkPermissions(table string, rows []*archiveRow, secretIds, namespaceIds map[string]bool) {
+	for _, row := range rows {
+		if (row.SecretId == nil) == (row.SecretNamePattern == nil) {
+			v.addProblem("%s %s must have exactly one of secret_id and secret_name_pattern", table, row.Id)
+		}
+		if row.SecretId != nil {
+			v.checkRef(table, row, "secret_id", *row.SecretId, secretIds)
+		}
+		// only pattern grants are scoped to a namespace
+		if row.SecretNamePattern != nil {
+			v.checkNamespace(table, row, namespaceIds)
+		} else if row.NamespaceId != nil {
+			v.addProblem("%s %s has a namespace_id without a secret_name_pattern", table, row.Id)
+		}
+	}
+}
+
+// tableRows decodes every table in the archive, and checks that it holds exactly the tables in database.BackupTables
+func (a *Archive) tableRows(v *validator) map[string][]*archiveRow {
+	tables := make(map[string][]*archiveRow)
+	for _, table := range a.Tables {
+		if _, ok := tables[table.Name]; ok {
+			v.addProblem("Table %s is in the archive twice", table.Name)
+			continue
+		}
+		var rows []*archiveRow
+		err := json.Unmarshal(table.Rows, &rows)
+		if err != nil {
+			v.addProblem("Table %s has invalid rows: %v", table.Name, err)
+		}
+		tables[table.Name] = rows
+	}
+	for _, table := range database.BackupTables {
+		if _, ok := tables[table]; !ok {
+			v.addProblem("Table %s is missing from the archive", table)
+		}
+	}
+	if len(tables) > len(database.BackupTables) {
+		v.addProblem("Archive has unexpected tables. Expected %s", strings.Join(database.BackupTables, ", "))
+	}
+	return tables
+}
+
+// Validate checks the archive's referential integrity: every row it references, and the data key of every secret
+// version, database role and transit key version, must be in the archive
+func (a *Archive) Validate() error {
+	v := &validator{}
+	if a.FormatVersion != ARCHIVE_FORMAT_VERSION {
+		v.addProblem("Unsupported archive format %d", a.FormatVersion)
+	}
+	tables := a.tableRows(v)
+
+	users := tables["admin.users"]
+	userIds := v.idSet("users", users)
+	v.checkAuthors("users", users, userIds)
+
+	// the default namespace isn't backed up, since every database has it
+	namespaces := tables["admin.namespaces"]
+	namespaceIds := v.idSet("namespaces", namespaces)
+	v.checkAuthors("namespaces", namespaces, userIds)
+	if namespaceIds[common.DEFAULT_NAMESPACE_ID] {
+		v.addProblem("namespaces has the default namespace %s", common.DEFAULT_NAMESPACE_ID)
+	}
+	namespaceIds[common.DEFAULT_NAMESPACE_ID] = true
+
+	namespaceAdmins := tables["admin.namespace_admins"]
+	v.idSet("namespace_admins", namespaceAdmins)
+	v.checkAuthors("namespace_admins", namespaceAdmins, userIds)
+	for _, row := range namespaceAdmins {
+		v.checkNamespace("namespace_admins", row, namespaceIds)
+		v.checkRef("namespace_admins", row, "user_id", row.UserId, userIds)
+	}
+
+	userGroups := tables["admin.user_groups"]
+	userGroupIds := v.idSet("user_groups", userGroups)
+	v.checkAuthors("user_groups", userGroups, userIds)
+	for _, row := range userGroups {
+		v.checkNamespace("user_groups", row, namespaceIds)
+	}
+
+	members := tables["admin.user_group_members"]
+	v.idSet("user_group_members", members)
+	v.checkAuthors("user_group_members", members, userIds)
+	for _, row := range members {
+		v.checkRef("user_group_members", row, "user_id", row.UserId, userIds)
+		v.checkRef("user_group_members", row, "user_group_id", row.UserGroupId, userGroupIds)
+	}
+
+	secrets := tables["admin.secrets"]
+	secretIds := v.idSet("secrets", secrets)
+	v.checkAuthors("secrets", secrets, userIds)
+	for _, row := range secrets {
+		v.checkNamespace("secrets", row, namespaceIds)
+	}
+
+	keyIds := make(map[string]bool)
+	for _, key := range a.EncryptedSecrets {
+		if keyIds[key.Id] {
+			v.addProblem("encrypted_secrets has duplicate id %s", key.Id)
+		}
+		keyIds[key.Id] = true
+	}
+
+	versions := tables["admin.secret_versions"]
+	versionIds := v.idSet("secret_versions", versions)
+	secretVersions := make(map[string]bool)
+	for _, row := range versions {
+		v.checkRef("secret_versions", row, "created_by", row.CreatedBy, userIds)
+		if row.SecretId == nil {
+			v.addProblem("secret_versions %s has no secret_id", row.Id)
+			continue
+		}
+		v.checkRef("secret_versions", row, "secret_id", *row.SecretId, secretIds)
+		// versions of purged secrets have no value, and their data keys are deleted
+		if row.Value != nil {
+			v.checkRef("secret_versions", row, "encrypted secret", row.Id, keyIds)
+		}
+		secretVersions[fmt.Sprintf("%s/%d", *row.SecretId, row.Version)] = true
+	}
+	for _, row := range secrets {
+		if !secretVersions[fmt.Sprintf("%s/%d", row.Id, row.CurrentVersion)] {
+			v.addProblem("secrets %s references missing current_version %d", row.Id, row.CurrentVersion)
+		}
+	}
+
+	secretPermissions := tables["admin.secret_permissions"]
+	v.idSet("secret_permissions", secretPermissions)
+	v.checkAuthors("secret_permissions", secretPermissions, userIds)
+	v.checkPermissions("secret_permissions", secretPermissions, secretIds, namespaceIds)
+	for _, row := range secretPermissions {
+		v.checkRef("secret_permissions", row, "user_id", row.UserId, userIds)
+	}
+
+	secretGroupPermissions := tables["admin.secret_group_permissions"]
+	v.idSet("secret_group_permissions", secretGroupPermissions)
+	v.checkAuthors("secret_group_permissions", secretGroupPermissions, userIds)
+	v.checkPermissions("secret_group_permissions", secretGroupPermissions, secretIds, namespaceIds)
+	for _, row := range secretGroupPermissions {
+		v.checkRef("secret_group_permissions", row, "user_group_id", row.UserGroupId, userGroupIds)
+	}
+
+	databaseRoles := tables["admin.database_roles"]
+	databaseRoleIds := v.idSet("database_roles", databaseRoles)
+	v.checkAuthors("database_roles", databaseRoles, userIds)
+	for _, row := range databaseRoles {
+		v.checkRef("database_roles", row, "encrypted secret", row.Id, keyIds)
+	}
+
+	databaseRolePermissions := tables["admin.database_role_permissions"]
+	v.idSet("database_role_permissions", databaseRolePermissions)
+	v.checkAuthors("database_role_permissions", databaseRolePermissions, userIds)
+	for _, row := range databaseRolePermissions {
+		v.checkRef("database_role_permissions", row, "database_role_id", row.DatabaseRoleId, databaseRoleIds)
+		if (row.UserId == "") == (row.UserGroupId == "") {
+			v.addProblem("database_role_permissions %s must have exactly one of user_id and user_group_id", row.Id)
+		} else if row.UserId != "" {
+			v.checkRef("database_role_permissions", row, "user_id", row.UserId, userIds)
+		} else {
+			v.checkRef("database_role_permissions", row, "user_group_id", row.UserGroupId, userGroupIds)
+		}
+	}
+
+	leases := tables["admin.leases"]
+	v.idSet("leases", leases)
+	for _, row := range leases {
+		v.checkRef("leases", row, "database_role_id", row.DatabaseRoleId, databaseRoleIds)
+		v.checkRef("leases", row, "user_id", row.UserId, userIds)
+	}
+
+	transitKeys := tables["admin.transit_keys"]
+	transitKeyIds := v.idSet("transit_keys", transitKeys)
+	v.checkAuthors("transit_keys", transitKeys, userIds)
+
+	transitKeyVersions := tables["admin.transit_key_versions"]
+	transitKeyVersionIds := v.idSet("transit_key_versions", transitKeyVersions)
+	for _, row := range transitKeyVersions {
+		v.checkRef("transit_key_versions", row, "created_by", row.CreatedBy, userIds)
+		v.checkRef("transit_key_versions", row, "transit_key_id", row.TransitKeyId, transitKeyIds)
+		v.checkRef("transit_key_versions", row, "encrypted secret", row.Id, keyIds)
+	}
+
+	transitKeyPermissions := tables["admin.transit_key_permissions"]
+	v.idSet("transit_key_permissions", transitKeyPermissions)
+	v.checkAuthors("transit_key_permissions", transitKeyPermissions, userIds)
+	for _, row := range transitKeyPermissions {
+		v.checkRef("transit_key_permissions", row, "transit_key_id", row.TransitKeyId, transitKeyIds)
+		if (row.UserId == "") == (row.UserGroupId == "") {
+			v.addProblem("transit_key_permissions %s must have exactly one of user_id and user_group_id", row.Id)
+		} else if row.UserId != "" {
+			v.checkRef("transit_key_permissions", row, "user_id", row.UserId, userIds)
+		} else {
+			v.checkRef("transit_key_permissions", row, "user_group_id", row.UserGroupId, userGroupIds)
+		}
+	}
+
+	for _, key := range a.EncryptedSecrets {
+		if !versionIds[key.Id] && !databaseRoleIds[key.Id] && !transitKeyVersionIds[key.Id] {
+			v.addProblem("encrypted secret %s has no secret version, database role or transit key version", key.Id)
+		}
+	}
+
+	if len(v.problems) == 0 {
+		return nil
+	}
+	reported := v.problems
+	if len(reported) > maxReportedProblems {
+		reported = reported[:maxReportedProblems]
+	}
+	return common.NewInvalidParamsError("Validate", "Archive has %d referential integrity problems: %s", len(v.problems), strings.Join(reported, "; "))
+}
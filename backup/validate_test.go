@@ -0,0 +1,150 @@
+package backup
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+func setTableRows(archive *Archive, name, rows string) {
+	for _, table := range archive.Tables {
+		if table.Name == name {
+			table.Rows = json.RawMessage(rows)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	err := makeTestArchive().Validate()
+	require.Nil(t, err, "error in Validate: %v", err)
+}
+
+func TestValidatePurgedVersion(t *testing.T) {
+	archive := makeTestArchive()
+	setTableRows(archive, "admin.secret_versions", fmt.Sprintf(`[{"id": "%s", "secret_id": "%s", "version": 1, "value": null, "created_by": "%s"}]`, versionId, secretId, adminId))
+	archive.EncryptedSecrets = archive.EncryptedSecrets[:0]
+	for _, key := range makeTestArchive().EncryptedSecrets {
+		if key.Id != versionId {
+			archive.EncryptedSecrets = append(archive.EncryptedSecrets, key)
+		}
+	}
+	err := archive.Validate()
+	require.Nil(t, err, "error in Validate: %v", err)
+}
+
+func TestValidateErrors(t *testing.T) {
+	var tests = []struct {
+		op     string
+		modify func(archive *Archive)
+	}{
+		{
+			op: "missing table",
+			modify: func(archive *Archive) {
+				archive.Tables = archive.Tables[1:]
+			},
+		},
+		{
+			op: "missing author",
+			modify: func(archive *Archive) {
+				setTableRows(archive, "admin.user_groups", fmt.Sprintf(`[{"id": "%s", "namespace_id": "%s", "created_by": "nobody", "updated_by": "%s"}]`, groupId, nsId, adminId))
+			},
+		},
+		{
+			op: "missing group",
+			modify: func(archive *Archive) {
+				setTableRows(archive, "admin.user_groups", "[]")
+			},
+		},
+		{
+			op: "missing encrypted secret",
+			modify: func(archive *Archive) {
+				archive.EncryptedSecrets = nil
+			},
+		},
+		{
+			op: "orphaned encrypted secret",
+			modify: func(archive *Archive) {
+				archive.EncryptedSecrets = append(archive.EncryptedSecrets, &common.EncryptedSecret{Id: "orphan"})
+			},
+		},
+		{
+			op: "missing namespace",
+			modify: func(archive *Archive) {
+				setTableRows(archive, "admin.namespaces", "[]")
+			},
+		},
+		{
+			op: "secret without namespace",
+			modify: func(archive *Archive) {
+				setTableRows(archive, "admin.secrets", fmt.Sprintf(`[{"id": "%s", "current_version": 1, "created_by": "%s", "updated_by": "%[2]s"}]`, secretId, adminId))
+			},
+		},
+		{
+			op: "default namespace",
+			modify: func(archive *Archive) {
+				setTableRows(archive, "admin.namespaces", fmt.Sprintf(`[{"id": "%s", "name": "default", "created_by": "%s", "updated_by": "%[2]s"}]`, common.DEFAULT_NAMESPACE_ID, adminId))
+			},
+		},
+		{
+			op: "secret permission with namespace",
+			modify: func(archive *Archive) {
+				setTableRows(archive, "admin.secret_permissions", fmt.Sprintf(`[{"id": "p1", "user_id": "%s", "secret_id": "%s", "namespace_id": "%s", "created_by": "%s", "updated_by": "%[4]s"}]`, devId, secretId, nsId, adminId))
+			},
+		},
+		{
+			op: "missing current version",
+			modify: func(archive *Archive) {
+				setTableRows(archive, "admin.secrets", fmt.Sprintf(`[{"id": "%s", "namespace_id": "%s", "current_version": 2, "created_by": "%s", "updated_by": "%[3]s"}]`, secretId, nsId, adminId))
+			},
+		},
+		{
+			op: "permission with secret and pattern",
+			modify: func(archive *Archive) {
+				setTableRows(archive, "admin.secret_permissions", fmt.Sprintf(`[{"id": "p1", "user_id": "%s", "secret_id": "%s", "secret_name_pattern": "a/*", "created_by": "%s", "updated_by": "%[3]s"}]`, devId, secretId, adminId))
+			},
+		},
+		{
+			op: "lease of missing database role",
+			modify: func(archive *Archive) {
+				setTableRows(archive, "admin.leases", fmt.Sprintf(`[{"id": "l1", "database_role_id": "nothing", "user_id": "%s"}]`, devId))
+			},
+		},
+		{
+			op: "database role permission without principal",
+			modify: func(archive *Archive) {
+				setTableRows(archive, "admin.database_role_permissions", fmt.Sprintf(`[{"id": "r1", "database_role_id": "%s", "created_by": "%s", "updated_by": "%[2]s"}]`, roleId, adminId))
+			},
+		},
+		{
+			op: "transit key version of missing key",
+			modify: func(archive *Archive) {
+				setTableRows(archive, "admin.transit_key_versions", fmt.Sprintf(`[{"id": "%s", "transit_key_id": "nothing", "version": 1, "created_by": "%s"}]`, keyVerId, adminId))
+			},
+		},
+		{
+			op: "duplicate id",
+			modify: func(archive *Archive) {
+				setTableRows(archive, "admin.users", fmt.Sprintf(`[{"id": "%[1]s", "created_by": "%[1]s", "updated_by": "%[1]s"}, {"id": "%[1]s", "created_by": "%[1]s", "updated_by": "%[1]s"}]`, adminId))
+			},
+		},
+		{
+			op: "invalid rows",
+			modify: func(archive *Archive) {
+				setTableRows(archive, "admin.users", `{"id": 1}`)
+			},
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("Validate - Errors - %v", given.op), func(t *testing.T) {
+			archive := makeTestArchive()
+			given.modify(archive)
+			err := archive.Validate()
+			require.NotNil(t, err, "no error in Validate: %v", err)
+		})
+	}
+}
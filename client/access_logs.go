@@ -0,0 +1,55 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/server"
+)
+
+func accessLogsQuery(req *common.ListAccessLogsRequest) url.Values {
+	query := paginationQuery(req.PageSize, req.Offset, req.Cursor, req.IncludeTotal)
+	filters := map[string]string{
+		"userId":     req.UserId,
+		"actionType": req.ActionType,
+		"keyName":    req.KeyName,
+		"targetType": req.TargetType,
+		"targetId":   req.TargetId,
+		"requestId":  req.RequestId,
+		"outcome":    req.Outcome,
+		"sourceIp":   req.SourceIp,
+	}
+	for param, value := range filters {
+		if value != "" {
+			query.Set(param, value)
+		}
+	}
+	if !req.StartDate.IsZero() {
+		query.Set("startDate", req.StartDate.Format(common.DATE_FORMAT))
+	}
+	if !req.EndDate.IsZero() {
+		query.Set("endDate", req.EndDate.Format(common.DATE_FORMAT))
+	}
+	return query
+}
+
+// ListAccessLogs lists access logs matching every filter set on req. Empty filters and zero dates match every log.
+func (c *Client) ListAccessLogs(ctx context.Context, req *common.ListAccessLogsRequest) (*common.AccessLogPage, error) {
+	var page common.AccessLogPage
+	err := c.do(ctx, http.MethodGet, "/access-logs", accessLogsQuery(req), nil, authToken, &page)
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) VerifyAccessLogs(ctx context.Context) (*server.VerifyAccessLogsResponse, error) {
+	var resp server.VerifyAccessLogsResponse
+	err := c.do(ctx, http.MethodGet, "/access-logs/verify", nil, nil, authToken, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,321 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// defaultTokenRenewBefore renews access tokens a little before they expire, so they don't expire in flight
+const defaultTokenRenewBefore = 30 * time.Second
+
+type authType int
+
+const (
+	authClient authType = iota
+	authToken
+)
+
+type Opts struct {
+	Addr         string
+	ClientId     string
+	ClientSecret string
+	// HttpClient defaults to http.DefaultClient
+	HttpClient *http.Client
+	// TokenCache persists access tokens between clients. Tokens are only kept in memory if it's nil.
+	TokenCache TokenCache
+	// TokenRenewBefore is how long before its invalid_at an access token is renewed. Defaults to 30 seconds.
+	TokenRenewBefore time.Duration
+	// SecretCacheTTL is how long fetched secrets are reused for. Secrets aren't cached if it's 0.
+	SecretCacheTTL time.Duration
+	// Namespace holds the secrets and user groups the client works with. Defaults to the default namespace.
+	Namespace string
+}
+
+// Client calls the data-vault API. It exchanges its client id and secret for an access token, which it reuses until
+// the token expires.
+type Client struct {
+	addr         string
+	clientId     string
+	clientSecret string
+	httpClient   *http.Client
+	tokenCache   TokenCache
+	renewBefore  time.Duration
+	secretCache  *secretCache
+	namespace    string
+
+	mu    sync.Mutex
+	token *common.AccessToken
+}
+
+func NewClient(opts Opts) (*Client, error) {
+	if opts.Addr == "" {
+		return nil, common.NewInvalidParamsError("NewClient", "Expected an API address")
+	}
+	httpClient := opts.HttpClient
+	if httpClient == nil {
+		httpClient = http.DefaultClient
+	}
+	renewBefore := opts.TokenRenewBefore
+	if renewBefore == 0 {
+		renewBefore = defaultTokenRenewBefore
+	}
+	c := &Client{
+		addr:         strings.TrimRight(opts.Addr, "/"),
+		clientId:     opts.ClientId,
+		clientSecret: opts.ClientSecret,
+		httpClient:   httpClient,
+		tokenCache:   opts.TokenCache,
+		renewBefore:  renewBefore,
+		namespace:    opts.Namespace,
+	}
+	if opts.SecretCacheTTL > 0 {
+		c.secretCache = newSecretCache(opts.SecretCacheTTL)
+	}
+	return c, nil
+}
+
+func (c *Client) tokenCacheKey() string {
+	return fmt.Sprintf("%s|%s", c.addr, c.clientId)
+}
+
+func (c *Client) isTokenValid(token *common.AccessToken) bool {
+	return token != nil && token.InvalidAt.After(time.Now().Add(c.renewBefore))
+}
+
+// AccessToken returns a valid access token, from memory, from the token cache, or from the API, in that order
+func (c *Client) AccessToken(ctx context.Context) (*common.AccessToken, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.isTokenValid(c.token) {
+		return c.token, nil
+	}
+	if c.tokenCache != nil {
+		token, err := c.tokenCache.Load(c.tokenCacheKey())
+		if err != nil {
+			return nil, err
+		}
+		if c.isTokenValid(token) {
+			c.token = token
+			return token, nil
+		}
+	}
+	return c.refreshToken(ctx)
+}
+
+// GetAccessToken fetches a new access token, revoking the previous one. Use AccessToken to reuse a valid token.
+func (c *Client) GetAccessToken(ctx context.Context) (*common.AccessToken, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.refreshToken(ctx)
+}
+
+// refreshToken fetches a new access token from the API. Fetching a token invalidates the previous one.
+func (c *Client) refreshToken(ctx context.Context) (*common.AccessToken, error) {
+	var token common.AccessToken
+	err := c.do(ctx, http.MethodGet, "/access_token", nil, nil, authClient, &token)
+	if err != nil {
+		return nil, err
+	}
+	c.token = &token
+	if c.tokenCache != nil {
+		err = c.tokenCache.Save(c.tokenCacheKey(), &token)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &token, nil
+}
+
+// forgetToken drops the cached token, so the next request fetches a new one
+func (c *Client) forgetToken(stale *common.AccessToken) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if stale != nil && c.token != nil && c.token.Id != stale.Id {
+		// another request already replaced it
+		return nil
+	}
+	c.token = nil
+	if c.tokenCache != nil {
+		return c.tokenCache.Delete(c.tokenCacheKey())
+	}
+	return nil
+}
+
+func (c *Client) doAuthenticated(ctx context.Context, method, path string, query url.Values, body interface{}, out interface{}) error {
+	token, err := c.AccessToken(ctx)
+	if err != nil {
+		return err
+	}
+	err = c.doWithToken(ctx, method, path, query, body, token, out)
+	_, ok := err.(common.AuthorizationError)
+	if !ok {
+		return err
+	}
+
+	// the token can be revoked before it expires, e.g. when another client fetches a new one
+	err = c.forgetToken(token)
+	if err != nil {
+		return err
+	}
+	token, err = c.AccessToken(ctx)
+	if err != nil {
+		return err
+	}
+	return c.doWithToken(ctx, method, path, query, body, token, out)
+}
+
+func (c *Client) doWithToken(ctx context.Context, method, path string, query url.Values, body interface{}, token *common.AccessToken, out interface{}) error {
+	req, err := c.newRequest(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	req.Header.Set(common.HEADER_ACCESS_TOKEN, token.Id)
+	return c.send(req, out)
+}
+
+func (c *Client) do(ctx context.Context, method, path string, query url.Values, body interface{}, auth authType, out interface{}) error {
+	if auth == authToken {
+		return c.doAuthenticated(ctx, method, path, query, body, out)
+	}
+	req, err := c.newRequest(ctx, method, path, query, body)
+	if err != nil {
+		return err
+	}
+	if auth == authClient {
+		req.Header.Set(common.HEADER_CLIENT_ID, c.clientId)
+		req.Header.Set(common.HEADER_CLIENT_SECRET, c.clientSecret)
+	}
+	return c.send(req, out)
+}
+
+// rawBody is sent as the request body as is, instead of being encoded as JSON
+type rawBody struct {
+	contentType string
+	data        []byte
+}
+
+// rawResponse receives the response body and headers as is, instead of decoding the body as JSON
+type rawResponse struct {
+	header http.Header
+	data   []byte
+}
+
+func (c *Client) newRequest(ctx context.Context, method, path string, query url.Values, body interface{}) (*http.Request, error) {
+	reqUrl := c.addr + path
+	if len(query) > 0 {
+		reqUrl += "?" + query.Encode()
+	}
+	contentType := "application/json"
+	var bodyBytes []byte
+	if raw, ok := body.(*rawBody); ok {
+		contentType = raw.contentType
+		bodyBytes = raw.data
+	} else if body != nil {
+		var err error
+		bodyBytes, err = json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+	}
+	req, err := http.NewRequestWithContext(ctx, method, reqUrl, bytes.NewReader(bodyBytes))
+	if err != nil {
+		return nil, err
+	}
+	if body != nil && contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	return req, nil
+}
+
+func (c *Client) send(req *http.Request, out interface{}) error {
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode >= 400 {
+		var errBody struct {
+			Error string `json:"error"`
+		}
+		message := strings.TrimSpace(string(data))
+		if json.Unmarshal(data, &errBody) == nil && errBody.Error != "" {
+			message = errBody.Error
+		}
+		return decodeError(req.Method+" "+req.URL.Path, resp.StatusCode, message)
+	}
+	if raw, ok := out.(*rawResponse); ok {
+		raw.header = resp.Header
+		raw.data = data
+		return nil
+	}
+	if out == nil || len(data) == 0 {
+		return nil
+	}
+	err = json.Unmarshal(data, out)
+	if err != nil {
+		return fmt.Errorf("Could not unmarshal response from %s %s: %v", req.Method, req.URL.Path, err)
+	}
+	return nil
+}
+
+// namespacePath returns the path of an endpoint in the client's namespace. Paths in the default namespace are left
+// as they are, so clients keep working with servers that predate namespaces.
+func (c *Client) namespacePath(path string) string {
+	if c.namespace == "" || c.namespace == common.DEFAULT_NAMESPACE {
+		return path
+	}
+	return "/ns/" + url.PathEscape(c.namespace) + path
+}
+
+func paginationQuery(pageSize, offset int, cursor string, includeTotal bool) url.Values {
+	query := url.Values{}
+	if pageSize > 0 {
+		query.Set("pageSize", fmt.Sprintf("%d", pageSize))
+	}
+	if offset > 0 {
+		query.Set("offset", fmt.Sprintf("%d", offset))
+	}
+	if cursor != "" {
+		query.Set("cursor", cursor)
+	}
+	if includeTotal {
+		query.Set("total", "true")
+	}
+	return query
+}
+
+// Version returns the version of the API server
+func (c *Client) Version(ctx context.Context) (string, error) {
+	req, err := c.newRequest(ctx, http.MethodGet, "/version", nil, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	if resp.StatusCode >= 400 {
+		return "", decodeError("Version", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return string(data), nil
+}
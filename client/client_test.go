@@ -0,0 +1,360 @@
+package client
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/server"
+)
+
+// fakeApi issues a new token on every /access_token call and only accepts the latest one
+type fakeApi struct {
+	mu          sync.Mutex
+	tokenCalls  int
+	validToken  string
+	tokenExpiry time.Duration
+	secretCalls int
+	file        *common.SecretFile
+}
+
+func (f *fakeApi) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	switch r.URL.Path {
+	case "/access_token":
+		if r.Header.Get(common.HEADER_CLIENT_SECRET) != "secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error": "Invalid client"}`)
+			return
+		}
+		f.tokenCalls++
+		f.validToken = fmt.Sprintf("token%d", f.tokenCalls)
+		json.NewEncoder(w).Encode(&common.AccessToken{Id: f.validToken, InvalidAt: time.Now().Add(f.tokenExpiry)})
+	case "/users/me":
+		if r.Header.Get(common.HEADER_ACCESS_TOKEN) != f.validToken {
+			w.WriteHeader(http.StatusUnauthorized)
+			fmt.Fprint(w, `{"error": "Invalid access token"}`)
+			return
+		}
+		json.NewEncoder(w).Encode(&common.User{Id: "me"})
+	case "/users":
+		total := 3
+		page := &common.UserPage{Items: []*common.User{{Id: "user1", Name: "alice"}}}
+		if r.URL.Query().Get("cursor") == "" {
+			page.NextCursor = "next"
+		}
+		if r.URL.Query().Get("total") == "true" {
+			page.Total = &total
+		}
+		json.NewEncoder(w).Encode(page)
+	case "/secrets/secret1", "/secrets/missing":
+		f.secretCalls++
+		if r.URL.Path == "/secrets/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			json.NewEncoder(w).Encode(map[string]string{"error": common.NewResourceNotFoundError("GetSecretByName", "name", "missing").Error()})
+			return
+		}
+		json.NewEncoder(w).Encode(&common.Secret{Name: "secret1", Value: fmt.Sprintf("value%d", f.secretCalls)})
+	case "/ns/team-a/secrets/secret1":
+		json.NewEncoder(w).Encode(&common.Secret{Name: "secret1", Value: "team-a value"})
+	case "/ns/team-a/deleted/secrets/secretId/restore":
+		var req server.RestoreRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(&common.Secret{Id: "secretId", Name: req.Name})
+	case "/secrets/tls/file":
+		if r.Method == http.MethodGet {
+			w.Header().Set("Content-Type", f.file.ContentType)
+			w.Header().Set("Content-Disposition", `attachment; filename="`+f.file.Filename+`"`)
+			w.Header().Set(common.HEADER_SECRET_VERSION, fmt.Sprintf("%d", f.file.Version))
+			w.Write(f.file.Data)
+			return
+		}
+		data, _ := ioutil.ReadAll(r.Body)
+		f.file = &common.SecretFile{Name: "tls", Version: 1, ContentType: r.Header.Get("Content-Type"), Filename: r.URL.Query().Get("filename"), Data: data}
+		json.NewEncoder(w).Encode(&common.Secret{Name: "tls", ContentType: f.file.ContentType, Filename: f.file.Filename, Version: 1})
+	case "/database-roles/app/credentials":
+		json.NewEncoder(w).Encode(&common.DatabaseCredentials{LeaseId: "lease1", RoleName: "app", Username: "v_app_1", Password: "password"})
+	case "/leases/lease1/renew":
+		var req server.RenewLeaseRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		expiresAt := time.Date(2022, 1, 1, 0, 0, 0, 0, time.UTC).Add(time.Duration(req.IncrementSeconds) * time.Second)
+		json.NewEncoder(w).Encode(&common.Lease{Id: "lease1", RoleName: "app", Username: "v_app_1", ExpiresAt: expiresAt})
+	case "/transit/pii/encrypt":
+		var req server.TransitEncryptRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(&server.TransitResponse{Ciphertext: "vault:v1:" + req.Plaintext, KeyVersion: 1})
+	case "/transit/pii/decrypt":
+		var req server.TransitCiphertextRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		json.NewEncoder(w).Encode(&server.TransitResponse{Plaintext: strings.TrimPrefix(req.Ciphertext, "vault:v1:"), KeyVersion: 1})
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func newTestServer(t *testing.T, api *fakeApi) string {
+	server := httptest.NewServer(api)
+	t.Cleanup(server.Close)
+	return server.URL
+}
+
+func newTestClient(t *testing.T, addr, secret string, cache TokenCache) *Client {
+	c, err := NewClient(Opts{Addr: addr, ClientId: "id", ClientSecret: secret, TokenCache: cache})
+	require.Nil(t, err, "Unexpected err creating client: %v", err)
+	return c
+}
+
+func TestAccessTokenReusesValidToken(t *testing.T) {
+	api := &fakeApi{tokenExpiry: time.Hour}
+	c := newTestClient(t, newTestServer(t, api), "secret", nil)
+
+	for i := 0; i < 3; i++ {
+		token, err := c.AccessToken(context.Background())
+		require.Nil(t, err, "error in AccessToken: %v", err)
+		require.Equal(t, token.Id, "token1", "Expected token1, got %v", token.Id)
+	}
+	require.Equal(t, api.tokenCalls, 1, "Expected 1 token call, got %v", api.tokenCalls)
+}
+
+func TestAccessTokenRefreshesExpiredToken(t *testing.T) {
+	// tokens expiring inside the skew are treated as already expired
+	api := &fakeApi{tokenExpiry: defaultTokenRenewBefore / 2}
+	c := newTestClient(t, newTestServer(t, api), "secret", nil)
+
+	for i := 1; i <= 2; i++ {
+		token, err := c.AccessToken(context.Background())
+		require.Nil(t, err, "error in AccessToken: %v", err)
+		require.Equal(t, token.Id, fmt.Sprintf("token%d", i), "Unexpected token %v", token.Id)
+	}
+	require.Equal(t, api.tokenCalls, 2, "Expected 2 token calls, got %v", api.tokenCalls)
+}
+
+func TestAccessTokenUsesTokenCache(t *testing.T) {
+	api := &fakeApi{tokenExpiry: time.Hour}
+	cache := NewFileTokenCache(filepath.Join(t.TempDir(), "tokens.json"))
+
+	addr := newTestServer(t, api)
+
+	token, err := newTestClient(t, addr, "secret", cache).AccessToken(context.Background())
+	require.Nil(t, err, "error in AccessToken: %v", err)
+
+	// a second client for the same address and client id starts from the cached token
+	cached, err := newTestClient(t, addr, "secret", cache).AccessToken(context.Background())
+	require.Nil(t, err, "error in AccessToken: %v", err)
+	require.Equal(t, cached.Id, token.Id, "Expected cached token %v, got %v", token.Id, cached.Id)
+	require.Equal(t, api.tokenCalls, 1, "Expected 1 token call, got %v", api.tokenCalls)
+}
+
+func TestDoAuthenticatedRetriesRevokedToken(t *testing.T) {
+	api := &fakeApi{tokenExpiry: time.Hour}
+	c := newTestClient(t, newTestServer(t, api), "secret", nil)
+
+	_, err := c.AccessToken(context.Background())
+	require.Nil(t, err, "error in AccessToken: %v", err)
+	// another client fetching a token revokes ours
+	api.validToken = "revoked"
+	api.tokenCalls = 1
+
+	var user common.User
+	err = c.do(context.Background(), http.MethodGet, "/users/me", nil, nil, authToken, &user)
+	require.Nil(t, err, "error in do: %v", err)
+	require.Equal(t, user.Id, "me", "Expected user me, got %v", user.Id)
+	require.Equal(t, api.tokenCalls, 2, "Expected 2 token calls, got %v", api.tokenCalls)
+}
+
+func TestDoReturnsTypedErrors(t *testing.T) {
+	api := &fakeApi{tokenExpiry: time.Hour}
+	addr := newTestServer(t, api)
+
+	_, err := newTestClient(t, addr, "wrong", nil).AccessToken(context.Background())
+	require.Equal(t, err, common.NewAuthorizationError(), "Unexpected err %v", err)
+
+	_, err = newTestClient(t, addr, "secret", nil).GetSecret(context.Background(), &server.GetSecretRequest{Name: "missing"})
+	expected := common.NewResourceNotFoundError("GetSecretByName", "name", "missing")
+	require.Equal(t, err, expected, "Unexpected err %v", err)
+}
+
+func TestGetSecretCache(t *testing.T) {
+	api := &fakeApi{tokenExpiry: time.Hour}
+	c, err := NewClient(Opts{Addr: newTestServer(t, api), ClientId: "id", ClientSecret: "secret", SecretCacheTTL: time.Hour})
+	require.Nil(t, err, "Unexpected err creating client: %v", err)
+	req := &server.GetSecretRequest{Name: "secret1"}
+
+	for i := 0; i < 2; i++ {
+		secret, err := c.GetSecret(context.Background(), req)
+		require.Nil(t, err, "error in GetSecret: %v", err)
+		require.Equal(t, secret.Value, "value1", "Expected cached value1, got %v", secret.Value)
+		// changing a returned secret doesn't change the cache
+		secret.Value = "changed"
+	}
+
+	c.InvalidateSecret("secret1")
+	secret, err := c.GetSecret(context.Background(), req)
+	require.Nil(t, err, "error in GetSecret: %v", err)
+	require.Equal(t, secret.Value, "value2", "Expected fetched value2, got %v", secret.Value)
+	require.Equal(t, api.secretCalls, 2, "Expected 2 secret calls, got %v", api.secretCalls)
+}
+
+func TestSecretCacheExpiry(t *testing.T) {
+	cache := newSecretCache(time.Hour)
+	expired := time.Now().Add(-time.Second)
+	cache.put("expired", 0, &common.Secret{Name: "expired", ExpiresAt: &expired})
+	cache.put("fresh", 0, &common.Secret{Name: "fresh"})
+
+	_, ok := cache.get("expired", 0)
+	require.False(t, ok, "Expected secret past its expires_at to be evicted")
+	_, ok = cache.get("fresh", 1)
+	require.False(t, ok, "Expected versions to be cached separately")
+	secret, ok := cache.get("fresh", 0)
+	require.True(t, ok, "Expected fresh secret to be cached")
+	require.Equal(t, secret.Name, "fresh")
+}
+
+func TestSecretCacheCopiesFields(t *testing.T) {
+	cache := newSecretCache(time.Hour)
+	fields := map[string]string{"username": "admin"}
+	cache.put("bundle", 0, &common.Secret{Name: "bundle", Fields: fields})
+	fields["username"] = "changed"
+
+	secret, ok := cache.get("bundle", 0)
+	require.True(t, ok, "Expected bundle to be cached")
+	secret.Fields["username"] = "changed again"
+
+	secret, ok = cache.get("bundle", 0)
+	require.True(t, ok, "Expected bundle to be cached")
+	require.Equal(t, secret.Fields, map[string]string{"username": "admin"})
+}
+
+func TestSecretFileRoundTrip(t *testing.T) {
+	api := &fakeApi{tokenExpiry: time.Hour}
+	c := newTestClient(t, newTestServer(t, api), "secret", nil)
+	data := []byte{0x00, 0xff, 0x0a}
+
+	secret, err := c.CreateSecretFile(context.Background(), &server.CreateSecretFileRequest{Name: "tls", ContentType: "application/x-pem-file", Filename: "tls.key", Data: data})
+	require.Nil(t, err, "error in CreateSecretFile: %v", err)
+	require.Equal(t, secret.Filename, "tls.key")
+
+	file, err := c.GetSecretFile(context.Background(), &server.GetSecretRequest{Name: "tls"})
+	require.Nil(t, err, "error in GetSecretFile: %v", err)
+	expected := &common.SecretFile{Name: "tls", Version: 1, ContentType: "application/x-pem-file", Filename: "tls.key", Data: data}
+	require.Equal(t, file, expected, "Result %+v did not equal expected %+v", file, expected)
+}
+
+func TestListSecretsQuery(t *testing.T) {
+	createdAfter := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	query := listSecretsQuery(&common.ListSecretsRequest{
+		NamePrefix:   "app/",
+		Labels:       []string{"env=prod", "!deprecated"},
+		CreatedAfter: &createdAfter,
+		SortBy:       common.SECRET_SORT_CREATED_AT,
+		PageSize:     5,
+	})
+	require.Equal(t, query.Encode(), "createdAfter=2022-05-01&label=env%3Dprod&label=%21deprecated&namePrefix=app%2F&pageSize=5&sortBy=created_at")
+}
+
+func TestListUsersPage(t *testing.T) {
+	addr := newTestServer(t, &fakeApi{tokenExpiry: time.Hour})
+	c := newTestClient(t, addr, "secret", nil)
+
+	page, err := c.ListUsers(context.Background(), &server.PaginationRequest{PageSize: 1, IncludeTotal: true})
+	require.Nil(t, err, "error in ListUsers: %v", err)
+	total := 3
+	expected := &common.UserPage{
+		Items:    []*common.User{{Id: "user1", Name: "alice"}},
+		PageInfo: common.PageInfo{NextCursor: "next", Total: &total},
+	}
+	require.Equal(t, page, expected, "Result %+v did not equal expected %+v", page, expected)
+
+	page, err = c.ListUsers(context.Background(), &server.PaginationRequest{PageSize: 1, Cursor: page.NextCursor})
+	require.Nil(t, err, "error in ListUsers: %v", err)
+	require.Equal(t, page.PageInfo, common.PageInfo{}, "Expected last page, got %+v", page.PageInfo)
+}
+
+func TestPaginationQuery(t *testing.T) {
+	require.Equal(t, paginationQuery(5, 0, "abc", true).Encode(), "cursor=abc&pageSize=5&total=true")
+	require.Equal(t, paginationQuery(5, 10, "", false).Encode(), "offset=10&pageSize=5")
+}
+
+func TestDatabaseCredentialsLease(t *testing.T) {
+	c := newTestClient(t, newTestServer(t, &fakeApi{tokenExpiry: time.Hour}), "secret", nil)
+
+	creds, err := c.GetDatabaseCredentials(context.Background(), "app")
+	require.Nil(t, err, "error in GetDatabaseCredentials: %v", err)
+	require.Equal(t, creds.Username, "v_app_1")
+	require.Equal(t, creds.Password, "password")
+
+	lease, err := c.RenewLease(context.Background(), &server.RenewLeaseRequest{LeaseId: creds.LeaseId, IncrementSeconds: 60})
+	require.Nil(t, err, "error in RenewLease: %v", err)
+	require.Equal(t, lease.ExpiresAt, time.Date(2022, 1, 1, 0, 1, 0, 0, time.UTC))
+
+	_, err = c.GetDatabaseCredentials(context.Background(), "missing")
+	require.NotNil(t, err, "no error in GetDatabaseCredentials")
+}
+
+func TestTransitRoundTrip(t *testing.T) {
+	c := newTestClient(t, newTestServer(t, &fakeApi{tokenExpiry: time.Hour}), "secret", nil)
+
+	encrypted, err := c.TransitEncrypt(context.Background(), &server.TransitEncryptRequest{KeyName: "pii", Plaintext: "c2VjcmV0"})
+	require.Nil(t, err, "error in TransitEncrypt: %v", err)
+	require.Equal(t, encrypted, &server.TransitResponse{Ciphertext: "vault:v1:c2VjcmV0", KeyVersion: 1})
+
+	decrypted, err := c.TransitDecrypt(context.Background(), &server.TransitCiphertextRequest{KeyName: "pii", Ciphertext: encrypted.Ciphertext})
+	require.Nil(t, err, "error in TransitDecrypt: %v", err)
+	require.Equal(t, decrypted.Plaintext, "c2VjcmV0")
+
+	_, err = c.TransitRewrap(context.Background(), &server.TransitCiphertextRequest{KeyName: "pii", Ciphertext: encrypted.Ciphertext})
+	require.NotNil(t, err, "no error in TransitRewrap")
+}
+
+func TestNamespacePath(t *testing.T) {
+	var tests = []struct {
+		namespace string
+		expected  string
+	}{
+		{namespace: "", expected: "/secrets/a%2Fb"},
+		{namespace: common.DEFAULT_NAMESPACE, expected: "/secrets/a%2Fb"},
+		{namespace: "team-a", expected: "/ns/team-a/secrets/a%2Fb"},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("namespacePath - %v", given.namespace), func(t *testing.T) {
+			c, err := NewClient(Opts{Addr: "http://vault", Namespace: given.namespace})
+			require.Nil(t, err, "Unexpected err creating client: %v", err)
+			result := c.secretPath("a/b")
+			require.Equal(t, result, given.expected, "Result %v did not equal expected %v", result, given.expected)
+		})
+	}
+}
+
+func TestGetSecretInNamespace(t *testing.T) {
+	api := &fakeApi{tokenExpiry: time.Hour}
+	c, err := NewClient(Opts{Addr: newTestServer(t, api), ClientId: "id", ClientSecret: "secret", Namespace: "team-a"})
+	require.Nil(t, err, "Unexpected err creating client: %v", err)
+
+	secret, err := c.GetSecret(context.Background(), &server.GetSecretRequest{Name: "secret1"})
+	require.Nil(t, err, "error in GetSecret: %v", err)
+	require.Equal(t, secret.Value, "team-a value", "Expected the team-a secret, got %v", secret.Value)
+}
+
+func TestRestoreSecretInNamespace(t *testing.T) {
+	api := &fakeApi{tokenExpiry: time.Hour}
+	c, err := NewClient(Opts{Addr: newTestServer(t, api), ClientId: "id", ClientSecret: "secret", Namespace: "team-a"})
+	require.Nil(t, err, "Unexpected err creating client: %v", err)
+
+	secret, err := c.RestoreSecret(context.Background(), &server.RestoreRequest{Id: "secretId", Name: "renamed"})
+	require.Nil(t, err, "error in RestoreSecret: %v", err)
+	expected := &common.Secret{Id: "secretId", Name: "renamed"}
+	require.Equal(t, secret, expected, "Result %+v did not equal expected %+v", secret, expected)
+}
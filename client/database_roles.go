@@ -0,0 +1,80 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/server"
+)
+
+func databaseRolePath(name string) string {
+	return "/database-roles/" + url.PathEscape(name)
+}
+
+func leasePath(leaseId string) string {
+	return "/leases/" + url.PathEscape(leaseId)
+}
+
+func (c *Client) ListDatabaseRoles(ctx context.Context, req *server.PaginationRequest) (*common.DatabaseRolePage, error) {
+	var page common.DatabaseRolePage
+	err := c.do(ctx, http.MethodGet, "/database-roles", paginationQuery(req.PageSize, req.Offset, req.Cursor, req.IncludeTotal), nil, authToken, &page)
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) GetDatabaseRole(ctx context.Context, name string) (*common.DatabaseRole, error) {
+	var role common.DatabaseRole
+	err := c.do(ctx, http.MethodGet, databaseRolePath(name), nil, nil, authToken, &role)
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (c *Client) CreateDatabaseRole(ctx context.Context, req *server.CreateDatabaseRoleRequest) (*common.DatabaseRole, error) {
+	var role common.DatabaseRole
+	err := c.do(ctx, http.MethodPost, "/database-roles", nil, req, authToken, &role)
+	if err != nil {
+		return nil, err
+	}
+	return &role, nil
+}
+
+func (c *Client) DeleteDatabaseRole(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, databaseRolePath(name), nil, nil, authToken, nil)
+}
+
+func (c *Client) GrantDatabaseRolePermission(ctx context.Context, req *server.DatabaseRolePermissionRequest) error {
+	return c.do(ctx, http.MethodPost, databaseRolePath(req.RoleName)+"/permissions", nil, req, authToken, nil)
+}
+
+func (c *Client) RevokeDatabaseRolePermission(ctx context.Context, req *server.DatabaseRolePermissionRequest) error {
+	return c.do(ctx, http.MethodDelete, databaseRolePath(req.RoleName)+"/permissions", nil, req, authToken, nil)
+}
+
+// GetDatabaseCredentials creates new credentials for a database role. They're revoked when the lease expires.
+func (c *Client) GetDatabaseCredentials(ctx context.Context, roleName string) (*common.DatabaseCredentials, error) {
+	var creds common.DatabaseCredentials
+	err := c.do(ctx, http.MethodGet, databaseRolePath(roleName)+"/credentials", nil, nil, authToken, &creds)
+	if err != nil {
+		return nil, err
+	}
+	return &creds, nil
+}
+
+func (c *Client) RenewLease(ctx context.Context, req *server.RenewLeaseRequest) (*common.Lease, error) {
+	var lease common.Lease
+	err := c.do(ctx, http.MethodPut, leasePath(req.LeaseId)+"/renew", nil, req, authToken, &lease)
+	if err != nil {
+		return nil, err
+	}
+	return &lease, nil
+}
+
+func (c *Client) RevokeLease(ctx context.Context, leaseId string) error {
+	return c.do(ctx, http.MethodDelete, leasePath(leaseId), nil, nil, authToken, nil)
+}
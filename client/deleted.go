@@ -0,0 +1,67 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/server"
+)
+
+// ListDeletedSecrets lists the deleted secrets in the client's namespace, including purged ones
+func (c *Client) ListDeletedSecrets(ctx context.Context, req *server.PaginationRequest) (*common.DeletedRecordPage, error) {
+	var page common.DeletedRecordPage
+	err := c.do(ctx, http.MethodGet, c.namespacePath("/deleted/secrets"), paginationQuery(req.PageSize, req.Offset, req.Cursor, req.IncludeTotal), nil, authToken, &page)
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+// RestoreSecret restores a deleted secret by id, renaming it if req.Name is set
+func (c *Client) RestoreSecret(ctx context.Context, req *server.RestoreRequest) (*common.Secret, error) {
+	var secret common.Secret
+	err := c.do(ctx, http.MethodPost, c.namespacePath("/deleted/secrets/"+url.PathEscape(req.Id)+"/restore"), nil, req, authToken, &secret)
+	if err != nil {
+		return nil, err
+	}
+	c.InvalidateSecret(secret.Name)
+	return &secret, nil
+}
+
+func (c *Client) ListDeletedUserGroups(ctx context.Context, req *server.PaginationRequest) (*common.DeletedRecordPage, error) {
+	var page common.DeletedRecordPage
+	err := c.do(ctx, http.MethodGet, c.namespacePath("/deleted/user-groups"), paginationQuery(req.PageSize, req.Offset, req.Cursor, req.IncludeTotal), nil, authToken, &page)
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) RestoreUserGroup(ctx context.Context, req *server.RestoreRequest) (*common.UserGroup, error) {
+	var userGroup common.UserGroup
+	err := c.do(ctx, http.MethodPost, c.namespacePath("/deleted/user-groups/"+url.PathEscape(req.Id)+"/restore"), nil, req, authToken, &userGroup)
+	if err != nil {
+		return nil, err
+	}
+	return &userGroup, nil
+}
+
+func (c *Client) ListDeletedUsers(ctx context.Context, req *server.PaginationRequest) (*common.DeletedRecordPage, error) {
+	var page common.DeletedRecordPage
+	err := c.do(ctx, http.MethodGet, "/deleted/users", paginationQuery(req.PageSize, req.Offset, req.Cursor, req.IncludeTotal), nil, authToken, &page)
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) RestoreUser(ctx context.Context, req *server.RestoreRequest) (*server.CreateUserResponse, error) {
+	var resp server.CreateUserResponse
+	err := c.do(ctx, http.MethodPost, "/deleted/users/"+url.PathEscape(req.Id)+"/restore", nil, req, authToken, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
@@ -0,0 +1,75 @@
+package client
+
+import (
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// patterns matching the Error() strings of common errors, so they can be rebuilt with their original fields
+var (
+	notFoundPattern      = regexp.MustCompile(`^Resource not found in operation, (.*), for value, (.*), at field, (.*)$`)
+	expiredPattern       = regexp.MustCompile(`^Resource expired in operation, (.*), for value, (.*), at field, (.*)$`)
+	alreadyExistsPattern = regexp.MustCompile(`^Error for operation, (.*?): (.*)$`)
+	invalidParamsPattern = regexp.MustCompile(`^Error invalid params at (.*?): (.*)$`)
+	tooLargePattern      = regexp.MustCompile(`^Request too large in operation, (.*), limit is (\d+) bytes$`)
+)
+
+// APIError is returned when the API responds with an error status that doesn't map to a common error
+type APIError struct {
+	StatusCode int
+	Message    string
+}
+
+func (e APIError) Error() string {
+	return fmt.Sprintf("API returned %d: %s", e.StatusCode, e.Message)
+}
+
+func (e APIError) Code() int {
+	return e.StatusCode
+}
+
+// decodeError maps an error response back to the common error the server encoded, e.g. a 404 to a
+// common.ResourceNotFoundError. Operation is used when the message doesn't name one.
+func decodeError(operation string, statusCode int, message string) error {
+	switch statusCode {
+	case http.StatusBadRequest:
+		match := invalidParamsPattern.FindStringSubmatch(message)
+		if match == nil {
+			return common.NewInvalidParamsError(operation, "%s", message)
+		}
+		return common.NewInvalidParamsError(match[1], "%s", match[2])
+	case http.StatusUnauthorized:
+		return common.NewAuthorizationError()
+	case http.StatusNotFound:
+		match := notFoundPattern.FindStringSubmatch(message)
+		if match == nil {
+			return common.NewResourceNotFoundError(operation, "path", message)
+		}
+		return common.NewResourceNotFoundError(match[1], match[3], match[2])
+	case http.StatusConflict:
+		match := alreadyExistsPattern.FindStringSubmatch(message)
+		if match == nil {
+			return common.NewResourceAlreadyExistsError(operation, message)
+		}
+		return common.NewResourceAlreadyExistsError(match[1], match[2])
+	case http.StatusGone:
+		match := expiredPattern.FindStringSubmatch(message)
+		if match == nil {
+			return common.NewResourceExpiredError(operation, "path", message)
+		}
+		return common.NewResourceExpiredError(match[1], match[3], match[2])
+	case http.StatusRequestEntityTooLarge:
+		match := tooLargePattern.FindStringSubmatch(message)
+		if match == nil {
+			return APIError{StatusCode: statusCode, Message: message}
+		}
+		limit, _ := strconv.ParseInt(match[2], 10, 64)
+		return common.NewRequestTooLargeError(match[1], limit)
+	default:
+		return APIError{StatusCode: statusCode, Message: message}
+	}
+}
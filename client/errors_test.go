@@ -0,0 +1,77 @@
+package client
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+func TestDecodeError(t *testing.T) {
+	var tests = []struct {
+		statusCode int
+		message    string
+		expected   error
+	}{
+		{
+			statusCode: 400,
+			message:    common.NewInvalidParamsError("CreateSecret", "Name %s is invalid", "a: b").Error(),
+			expected:   common.NewInvalidParamsError("CreateSecret", "%s", "Name a: b is invalid"),
+		},
+		{
+			statusCode: 400,
+			message:    "bad request",
+			expected:   common.NewInvalidParamsError("GET /secrets", "%s", "bad request"),
+		},
+		{
+			statusCode: 401,
+			message:    common.NewAuthorizationError().Error(),
+			expected:   common.NewAuthorizationError(),
+		},
+		{
+			statusCode: 404,
+			message:    common.NewResourceNotFoundError("GetSecretByName", "name", "a, b").Error(),
+			expected:   common.NewResourceNotFoundError("GetSecretByName", "name", "a, b"),
+		},
+		{
+			statusCode: 404,
+			message:    "404 page not found",
+			expected:   common.NewResourceNotFoundError("GET /secrets", "path", "404 page not found"),
+		},
+		{
+			statusCode: 409,
+			message:    common.NewResourceAlreadyExistsError("CreateSecret", "Key (name)=(a) already exists.").Error(),
+			expected:   common.NewResourceAlreadyExistsError("CreateSecret", "Key (name)=(a) already exists."),
+		},
+		{
+			statusCode: 410,
+			message:    common.NewResourceExpiredError("GetSecret", "name", "a").Error(),
+			expected:   common.NewResourceExpiredError("GetSecret", "name", "a"),
+		},
+		{
+			statusCode: 413,
+			message:    common.NewRequestTooLargeError("CreateSecretFile", 1048576).Error(),
+			expected:   common.NewRequestTooLargeError("CreateSecretFile", 1048576),
+		},
+		{
+			statusCode: 413,
+			message:    "too large",
+			expected:   APIError{StatusCode: 413, Message: "too large"},
+		},
+		{
+			statusCode: 500,
+			message:    "oh no",
+			expected:   APIError{StatusCode: 500, Message: "oh no"},
+		},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("decodeError - %v", idx), func(t *testing.T) {
+			result := decodeError("GET /secrets", given.statusCode, given.message)
+			require.Equal(t, result, given.expected, "Result %v did not equal expected %v", result, given.expected)
+			require.Equal(t, result.Error(), given.expected.Error())
+		})
+	}
+}
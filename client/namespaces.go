@@ -0,0 +1,53 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/server"
+)
+
+func namespaceNamePath(name string) string {
+	return "/namespaces/" + url.PathEscape(name)
+}
+
+func (c *Client) ListNamespaces(ctx context.Context, req *server.PaginationRequest) (*common.NamespacePage, error) {
+	var page common.NamespacePage
+	err := c.do(ctx, http.MethodGet, "/namespaces", paginationQuery(req.PageSize, req.Offset, req.Cursor, req.IncludeTotal), nil, authToken, &page)
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) GetNamespace(ctx context.Context, name string) (*common.Namespace, error) {
+	var namespace common.Namespace
+	err := c.do(ctx, http.MethodGet, namespaceNamePath(name), nil, nil, authToken, &namespace)
+	if err != nil {
+		return nil, err
+	}
+	return &namespace, nil
+}
+
+func (c *Client) CreateNamespace(ctx context.Context, req *server.CreateNamespaceRequest) (*common.Namespace, error) {
+	var namespace common.Namespace
+	err := c.do(ctx, http.MethodPost, "/namespaces", nil, req, authToken, &namespace)
+	if err != nil {
+		return nil, err
+	}
+	return &namespace, nil
+}
+
+func (c *Client) DeleteNamespace(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, namespaceNamePath(name), nil, nil, authToken, nil)
+}
+
+func (c *Client) AddNamespaceAdmin(ctx context.Context, req *server.NamespaceAdminRequest) error {
+	return c.do(ctx, http.MethodPost, namespaceNamePath(req.Namespace)+"/admins", nil, req, authToken, nil)
+}
+
+func (c *Client) RemoveNamespaceAdmin(ctx context.Context, req *server.NamespaceAdminRequest) error {
+	return c.do(ctx, http.MethodDelete, namespaceNamePath(req.Namespace)+"/admins", nil, req, authToken, nil)
+}
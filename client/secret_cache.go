@@ -0,0 +1,86 @@
+package client
+
+import (
+	"sync"
+	"time"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+type secretCacheKey struct {
+	name    string
+	version int
+}
+
+type secretCacheEntry struct {
+	secret    common.Secret
+	expiresAt time.Time
+}
+
+// secretCache keeps fetched secrets in memory for a fixed TTL, or until the secret itself expires
+type secretCache struct {
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[secretCacheKey]*secretCacheEntry
+}
+
+func newSecretCache(ttl time.Duration) *secretCache {
+	return &secretCache{
+		ttl:     ttl,
+		entries: make(map[secretCacheKey]*secretCacheEntry),
+	}
+}
+
+func (c *secretCache) get(name string, version int) (*common.Secret, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := secretCacheKey{name: name, version: version}
+	entry, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+	if !time.Now().Before(entry.expiresAt) {
+		delete(c.entries, key)
+		return nil, false
+	}
+	// callers get a copy, so they can't change what other callers read
+	return copySecret(&entry.secret), true
+}
+
+func (c *secretCache) put(name string, version int, secret *common.Secret) {
+	expiresAt := time.Now().Add(c.ttl)
+	if secret.ExpiresAt != nil && secret.ExpiresAt.Before(expiresAt) {
+		expiresAt = *secret.ExpiresAt
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[secretCacheKey{name: name, version: version}] = &secretCacheEntry{secret: *copySecret(secret), expiresAt: expiresAt}
+}
+
+func copySecret(secret *common.Secret) *common.Secret {
+	secretCopy := *secret
+	if secret.Fields != nil {
+		secretCopy.Fields = make(map[string]string, len(secret.Fields))
+		for name, value := range secret.Fields {
+			secretCopy.Fields[name] = value
+		}
+	}
+	return &secretCopy
+}
+
+// invalidate drops every cached version of a secret
+func (c *secretCache) invalidate(name string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for key := range c.entries {
+		if key.name == name {
+			delete(c.entries, key)
+		}
+	}
+}
+
+func (c *secretCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[secretCacheKey]*secretCacheEntry)
+}
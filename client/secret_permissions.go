@@ -0,0 +1,24 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/emarcey/data-vault/server"
+)
+
+func (c *Client) GrantPermission(ctx context.Context, req *server.SecretPermissionRequest) error {
+	return c.do(ctx, http.MethodPost, c.secretPath(req.SecretName)+"/permissions", nil, req, authToken, nil)
+}
+
+func (c *Client) RevokePermission(ctx context.Context, req *server.SecretPermissionRequest) error {
+	return c.do(ctx, http.MethodDelete, c.secretPath(req.SecretName)+"/permissions", nil, req, authToken, nil)
+}
+
+func (c *Client) GrantPatternPermission(ctx context.Context, req *server.SecretPatternPermissionRequest) error {
+	return c.do(ctx, http.MethodPost, c.namespacePath("/secret-permissions/patterns"), nil, req, authToken, nil)
+}
+
+func (c *Client) RevokePatternPermission(ctx context.Context, req *server.SecretPatternPermissionRequest) error {
+	return c.do(ctx, http.MethodDelete, c.namespacePath("/secret-permissions/patterns"), nil, req, authToken, nil)
+}
@@ -0,0 +1,266 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"mime"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/server"
+)
+
+func (c *Client) secretPath(secretName string) string {
+	return c.namespacePath("/secrets/" + url.PathEscape(secretName))
+}
+
+func listSecretsQuery(req *common.ListSecretsRequest) url.Values {
+	query := paginationQuery(req.PageSize, req.Offset, req.Cursor, req.IncludeTotal)
+	filters := map[string]string{
+		"namePrefix":   req.NamePrefix,
+		"nameContains": req.NameContains,
+		"createdBy":    req.CreatedBy,
+		"sortBy":       req.SortBy,
+		"sortOrder":    req.SortOrder,
+	}
+	for param, value := range filters {
+		if value != "" {
+			query.Set(param, value)
+		}
+	}
+	dates := map[string]*time.Time{
+		"createdAfter":  req.CreatedAfter,
+		"createdBefore": req.CreatedBefore,
+		"updatedAfter":  req.UpdatedAfter,
+		"updatedBefore": req.UpdatedBefore,
+	}
+	for param, value := range dates {
+		if value != nil {
+			query.Set(param, value.Format(common.DATE_FORMAT))
+		}
+	}
+	for _, label := range req.Labels {
+		query.Add("label", label)
+	}
+	return query
+}
+
+// ListSecrets lists the secrets the caller can read that match every filter set on req
+func (c *Client) ListSecrets(ctx context.Context, req *common.ListSecretsRequest) (*common.SecretPage, error) {
+	var page common.SecretPage
+	err := c.do(ctx, http.MethodGet, c.namespacePath("/secrets"), listSecretsQuery(req), nil, authToken, &page)
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) CreateSecret(ctx context.Context, req *server.CreateSecretRequest) (*common.Secret, error) {
+	var secret common.Secret
+	err := c.do(ctx, http.MethodPost, c.namespacePath("/secrets"), nil, req, authToken, &secret)
+	if err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+// GetSecret fetches the secret at the given version, or at its current version if version is 0. If the client has a
+// secret cache, a cached copy is returned while it's fresh.
+func (c *Client) GetSecret(ctx context.Context, req *server.GetSecretRequest) (*common.Secret, error) {
+	if c.secretCache != nil {
+		secret, ok := c.secretCache.get(req.Name, req.Version)
+		if ok {
+			return secret, nil
+		}
+	}
+	query := url.Values{}
+	if req.Version > 0 {
+		query.Set("version", fmt.Sprintf("%d", req.Version))
+	}
+	var secret common.Secret
+	err := c.do(ctx, http.MethodGet, c.secretPath(req.Name), query, nil, authToken, &secret)
+	if err != nil {
+		return nil, err
+	}
+	if c.secretCache != nil {
+		c.secretCache.put(req.Name, req.Version, &secret)
+	}
+	return &secret, nil
+}
+
+// GetSecretField fetches a single field of a multi-field secret. Fields are not cached.
+func (c *Client) GetSecretField(ctx context.Context, req *server.GetSecretFieldRequest) (*common.SecretField, error) {
+	query := url.Values{}
+	if req.Version > 0 {
+		query.Set("version", fmt.Sprintf("%d", req.Version))
+	}
+	var field common.SecretField
+	err := c.do(ctx, http.MethodGet, c.secretPath(req.Name)+"/fields/"+url.PathEscape(req.Field), query, nil, authToken, &field)
+	if err != nil {
+		return nil, err
+	}
+	return &field, nil
+}
+
+func secretFileQuery(filename string) url.Values {
+	query := url.Values{}
+	if filename != "" {
+		query.Set("filename", filename)
+	}
+	return query
+}
+
+// CreateSecretFile uploads req.Data as a file secret. The response doesn't include the file.
+func (c *Client) CreateSecretFile(ctx context.Context, req *server.CreateSecretFileRequest) (*common.Secret, error) {
+	query := secretFileQuery(req.Filename)
+	if req.Description != "" {
+		query.Set("description", req.Description)
+	}
+	if req.ExpiresAt != nil {
+		query.Set("expires_at", req.ExpiresAt.Format(time.RFC3339))
+	}
+	var secret common.Secret
+	err := c.do(ctx, http.MethodPost, c.secretPath(req.Name)+"/file", query, &rawBody{contentType: req.ContentType, data: req.Data}, authToken, &secret)
+	if err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+// UpdateSecretFile uploads req.Data as a new version of a file secret
+func (c *Client) UpdateSecretFile(ctx context.Context, req *server.UpdateSecretFileRequest) (*common.Secret, error) {
+	c.InvalidateSecret(req.Name)
+	var secret common.Secret
+	err := c.do(ctx, http.MethodPut, c.secretPath(req.Name)+"/file", secretFileQuery(req.Filename), &rawBody{contentType: req.ContentType, data: req.Data}, authToken, &secret)
+	if err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+// GetSecretFile downloads the content of a file secret. Files are not cached.
+func (c *Client) GetSecretFile(ctx context.Context, req *server.GetSecretRequest) (*common.SecretFile, error) {
+	query := url.Values{}
+	if req.Version > 0 {
+		query.Set("version", fmt.Sprintf("%d", req.Version))
+	}
+	var resp rawResponse
+	err := c.do(ctx, http.MethodGet, c.secretPath(req.Name)+"/file", query, nil, authToken, &resp)
+	if err != nil {
+		return nil, err
+	}
+	file := &common.SecretFile{
+		Name:        req.Name,
+		ContentType: resp.header.Get("Content-Type"),
+		Data:        resp.data,
+	}
+	file.Version, _ = strconv.Atoi(resp.header.Get(common.HEADER_SECRET_VERSION))
+	_, params, err := mime.ParseMediaType(resp.header.Get("Content-Disposition"))
+	if err == nil {
+		file.Filename = params["filename"]
+	}
+	return file, nil
+}
+
+func (c *Client) UpdateSecret(ctx context.Context, req *server.UpdateSecretRequest) (*common.Secret, error) {
+	var secret common.Secret
+	c.InvalidateSecret(req.Name)
+	err := c.do(ctx, http.MethodPut, c.secretPath(req.Name), nil, req, authToken, &secret)
+	if err != nil {
+		return nil, err
+	}
+	return &secret, nil
+}
+
+func (c *Client) ListSecretVersions(ctx context.Context, secretName string) ([]*common.SecretVersion, error) {
+	var versions []*common.SecretVersion
+	err := c.do(ctx, http.MethodGet, c.secretPath(secretName)+"/versions", nil, nil, authToken, &versions)
+	if err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+func (c *Client) RollbackSecret(ctx context.Context, req *server.RollbackSecretRequest) error {
+	c.InvalidateSecret(req.Name)
+	return c.do(ctx, http.MethodPost, c.secretPath(req.Name)+"/rollback", nil, req, authToken, nil)
+}
+
+// UpdateSecretLabels replaces every label on a secret
+func (c *Client) UpdateSecretLabels(ctx context.Context, req *server.UpdateSecretLabelsRequest) error {
+	return c.do(ctx, http.MethodPut, c.secretPath(req.Name)+"/labels", nil, req, authToken, nil)
+}
+
+func (c *Client) DeleteSecret(ctx context.Context, secretName string) error {
+	c.InvalidateSecret(secretName)
+	return c.do(ctx, http.MethodDelete, c.secretPath(secretName), nil, nil, authToken, nil)
+}
+
+func (c *Client) RewrapSecrets(ctx context.Context) (*server.RewrapSecretsResponse, error) {
+	var resp server.RewrapSecretsResponse
+	err := c.do(ctx, http.MethodPost, "/keys/rewrap", nil, nil, authToken, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// RekeySecret re-encrypts every version of a secret under new data keys
+func (c *Client) RekeySecret(ctx context.Context, secretName string) (*server.RekeySecretResponse, error) {
+	c.InvalidateSecret(secretName)
+	var resp server.RekeySecretResponse
+	err := c.do(ctx, http.MethodPost, c.secretPath(secretName)+"/rekey", nil, nil, authToken, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetSecretRekeyStatus returns the progress of the server's scheduled secret rekey
+func (c *Client) GetSecretRekeyStatus(ctx context.Context) (*common.SecretRekeyStatus, error) {
+	var status common.SecretRekeyStatus
+	err := c.do(ctx, http.MethodGet, "/keys/rekey", nil, nil, authToken, &status)
+	if err != nil {
+		return nil, err
+	}
+	return &status, nil
+}
+
+// PurgeSecrets deletes the data keys and ciphertext of deleted secrets: the one with req.SecretId if it's set, and
+// otherwise every secret deleted longer ago than the server's retention period
+func (c *Client) PurgeSecrets(ctx context.Context, req *server.PurgeSecretsRequest) (*server.PurgeSecretsResponse, error) {
+	var resp server.PurgeSecretsResponse
+	err := c.do(ctx, http.MethodPost, "/keys/purge", nil, req, authToken, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListSecretPolicies lists the policies that CreateSecret and UpdateSecret can generate values from
+func (c *Client) ListSecretPolicies(ctx context.Context) ([]*common.SecretPolicy, error) {
+	var policies []*common.SecretPolicy
+	err := c.do(ctx, http.MethodGet, "/secret-policies", nil, nil, authToken, &policies)
+	if err != nil {
+		return nil, err
+	}
+	return policies, nil
+}
+
+// InvalidateSecret drops every cached version of a secret, so the next GetSecret fetches it from the API. Changes made
+// through this client invalidate the secret automatically; changes made elsewhere are only seen once the TTL passes.
+func (c *Client) InvalidateSecret(secretName string) {
+	if c.secretCache != nil {
+		c.secretCache.invalidate(secretName)
+	}
+}
+
+// ClearSecretCache drops every cached secret
+func (c *Client) ClearSecretCache() {
+	if c.secretCache != nil {
+		c.secretCache.clear()
+	}
+}
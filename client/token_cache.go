@@ -0,0 +1,105 @@
+package client
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// TokenCache stores access tokens between runs, keyed by API address and client id
+type TokenCache interface {
+	Load(key string) (*common.AccessToken, error)
+	Save(key string, token *common.AccessToken) error
+	Delete(key string) error
+}
+
+// FileTokenCache stores access tokens in a JSON file readable only by the current user
+type FileTokenCache struct {
+	path string
+	mu   sync.Mutex
+}
+
+func (c *FileTokenCache) read() (map[string]*common.AccessToken, error) {
+	tokens := make(map[string]*common.AccessToken)
+	data, err := ioutil.ReadFile(c.path)
+	if os.IsNotExist(err) {
+		return tokens, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	// a corrupt cache only costs a token refresh
+	if json.Unmarshal(data, &tokens) != nil {
+		return make(map[string]*common.AccessToken), nil
+	}
+	return tokens, nil
+}
+
+func (c *FileTokenCache) write(tokens map[string]*common.AccessToken) error {
+	data, err := json.Marshal(tokens)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(filepath.Dir(c.path), 0700)
+	if err != nil {
+		return err
+	}
+	tmpPath := c.path + ".tmp"
+	err = ioutil.WriteFile(tmpPath, data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, c.path)
+}
+
+func (c *FileTokenCache) Load(key string) (*common.AccessToken, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tokens, err := c.read()
+	if err != nil {
+		return nil, err
+	}
+	return tokens[key], nil
+}
+
+func (c *FileTokenCache) Save(key string, token *common.AccessToken) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tokens, err := c.read()
+	if err != nil {
+		return err
+	}
+	tokens[key] = token
+	return c.write(tokens)
+}
+
+func (c *FileTokenCache) Delete(key string) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	tokens, err := c.read()
+	if err != nil {
+		return err
+	}
+	if _, ok := tokens[key]; !ok {
+		return nil
+	}
+	delete(tokens, key)
+	return c.write(tokens)
+}
+
+func NewFileTokenCache(path string) *FileTokenCache {
+	return &FileTokenCache{path: path}
+}
+
+// DefaultTokenCachePath is ~/.vault/tokens.json
+func DefaultTokenCachePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(home, ".vault", "tokens.json"), nil
+}
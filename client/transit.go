@@ -0,0 +1,118 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/server"
+)
+
+func transitKeyPath(name string) string {
+	return "/transit/" + url.PathEscape(name)
+}
+
+func (c *Client) ListTransitKeys(ctx context.Context, req *server.PaginationRequest) (*common.TransitKeyPage, error) {
+	var page common.TransitKeyPage
+	err := c.do(ctx, http.MethodGet, "/transit", paginationQuery(req.PageSize, req.Offset, req.Cursor, req.IncludeTotal), nil, authToken, &page)
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) GetTransitKey(ctx context.Context, name string) (*common.TransitKey, error) {
+	var key common.TransitKey
+	err := c.do(ctx, http.MethodGet, transitKeyPath(name), nil, nil, authToken, &key)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (c *Client) CreateTransitKey(ctx context.Context, req *server.CreateTransitKeyRequest) (*common.TransitKey, error) {
+	var key common.TransitKey
+	err := c.do(ctx, http.MethodPost, "/transit", nil, req, authToken, &key)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// RotateTransitKey adds a new version to a transit key. New ciphertext uses the new version, and older versions can
+// still decrypt.
+func (c *Client) RotateTransitKey(ctx context.Context, name string) (*common.TransitKey, error) {
+	var key common.TransitKey
+	err := c.do(ctx, http.MethodPost, transitKeyPath(name)+"/rotate", nil, nil, authToken, &key)
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (c *Client) DeleteTransitKey(ctx context.Context, name string) error {
+	return c.do(ctx, http.MethodDelete, transitKeyPath(name), nil, nil, authToken, nil)
+}
+
+func (c *Client) GrantTransitKeyPermission(ctx context.Context, req *server.TransitKeyPermissionRequest) error {
+	return c.do(ctx, http.MethodPost, transitKeyPath(req.KeyName)+"/permissions", nil, req, authToken, nil)
+}
+
+func (c *Client) RevokeTransitKeyPermission(ctx context.Context, req *server.TransitKeyPermissionRequest) error {
+	return c.do(ctx, http.MethodDelete, transitKeyPath(req.KeyName)+"/permissions", nil, req, authToken, nil)
+}
+
+func (c *Client) transit(ctx context.Context, keyName string, action string, req interface{}) (*server.TransitResponse, error) {
+	var resp server.TransitResponse
+	err := c.do(ctx, http.MethodPost, transitKeyPath(keyName)+"/"+action, nil, req, authToken, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// TransitEncrypt encrypts base64 encoded plaintext with the latest version of a transit key
+func (c *Client) TransitEncrypt(ctx context.Context, req *server.TransitEncryptRequest) (*server.TransitResponse, error) {
+	return c.transit(ctx, req.KeyName, "encrypt", req)
+}
+
+// TransitDecrypt returns the base64 encoded plaintext of a transit ciphertext
+func (c *Client) TransitDecrypt(ctx context.Context, req *server.TransitCiphertextRequest) (*server.TransitResponse, error) {
+	return c.transit(ctx, req.KeyName, "decrypt", req)
+}
+
+// TransitRewrap re-encrypts a transit ciphertext with the latest version of its key, without returning the plaintext
+func (c *Client) TransitRewrap(ctx context.Context, req *server.TransitCiphertextRequest) (*server.TransitResponse, error) {
+	return c.transit(ctx, req.KeyName, "rewrap", req)
+}
+
+// TransitDataKey generates a data key for client side encryption, wrapped by a transit key
+func (c *Client) TransitDataKey(ctx context.Context, req *server.TransitDataKeyRequest) (*server.TransitResponse, error) {
+	return c.transit(ctx, req.KeyName, "datakey", req)
+}
+
+// TransitSign signs base64 encoded input with the latest version of a signing transit key
+func (c *Client) TransitSign(ctx context.Context, req *server.TransitSignRequest) (*server.TransitResponse, error) {
+	return c.transit(ctx, req.KeyName, "sign", req)
+}
+
+// TransitVerify checks a signature from TransitSign. A signature that doesn't match isn't an error.
+func (c *Client) TransitVerify(ctx context.Context, req *server.TransitSignRequest) (*server.TransitVerifyResponse, error) {
+	var resp server.TransitVerifyResponse
+	err := c.do(ctx, http.MethodPost, transitKeyPath(req.KeyName)+"/verify", nil, req, authToken, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetTransitPublicKeys returns the PEM public key of every version of an asymmetric transit key
+func (c *Client) GetTransitPublicKeys(ctx context.Context, name string) (*server.TransitPublicKeysResponse, error) {
+	var resp server.TransitPublicKeysResponse
+	err := c.do(ctx, http.MethodGet, transitKeyPath(name)+"/public-keys", nil, nil, authToken, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
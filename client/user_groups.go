@@ -0,0 +1,62 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/server"
+)
+
+func (c *Client) userGroupPath(userGroupId string) string {
+	return c.namespacePath("/user-groups/" + url.PathEscape(userGroupId))
+}
+
+func (c *Client) ListUserGroups(ctx context.Context, req *server.PaginationRequest) (*common.UserGroupPage, error) {
+	var page common.UserGroupPage
+	err := c.do(ctx, http.MethodGet, c.namespacePath("/user-groups"), paginationQuery(req.PageSize, req.Offset, req.Cursor, req.IncludeTotal), nil, authToken, &page)
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) GetUserGroup(ctx context.Context, userGroupId string) (*common.UserGroup, error) {
+	var userGroup common.UserGroup
+	err := c.do(ctx, http.MethodGet, c.userGroupPath(userGroupId), nil, nil, authToken, &userGroup)
+	if err != nil {
+		return nil, err
+	}
+	return &userGroup, nil
+}
+
+func (c *Client) ListUsersInGroup(ctx context.Context, req *server.ListUsersInGroupRequest) (*common.UserPage, error) {
+	var page common.UserPage
+	err := c.do(ctx, http.MethodGet, c.userGroupPath(req.UserGroupId)+"/users", paginationQuery(req.PageSize, req.Offset, req.Cursor, req.IncludeTotal), nil, authToken, &page)
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) CreateUserGroup(ctx context.Context, req *server.CreateUserGroupRequest) (*common.UserGroup, error) {
+	var userGroup common.UserGroup
+	err := c.do(ctx, http.MethodPost, c.namespacePath("/user-groups"), nil, req, authToken, &userGroup)
+	if err != nil {
+		return nil, err
+	}
+	return &userGroup, nil
+}
+
+func (c *Client) DeleteUserGroup(ctx context.Context, userGroupId string) error {
+	return c.do(ctx, http.MethodDelete, c.userGroupPath(userGroupId), nil, nil, authToken, nil)
+}
+
+func (c *Client) AddUserToGroup(ctx context.Context, req *server.UserGroupMemberRequest) error {
+	return c.do(ctx, http.MethodPost, c.userGroupPath(req.UserGroupId)+"/users", nil, req, authToken, nil)
+}
+
+func (c *Client) RemoveUserFromGroup(ctx context.Context, req *server.UserGroupMemberRequest) error {
+	return c.do(ctx, http.MethodDelete, c.userGroupPath(req.UserGroupId)+"/users", nil, req, authToken, nil)
+}
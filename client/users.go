@@ -0,0 +1,57 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/server"
+)
+
+func (c *Client) ListUsers(ctx context.Context, req *server.PaginationRequest) (*common.UserPage, error) {
+	var page common.UserPage
+	err := c.do(ctx, http.MethodGet, "/users", paginationQuery(req.PageSize, req.Offset, req.Cursor, req.IncludeTotal), nil, authToken, &page)
+	if err != nil {
+		return nil, err
+	}
+	return &page, nil
+}
+
+func (c *Client) GetUser(ctx context.Context, userId string) (*common.User, error) {
+	var user common.User
+	err := c.do(ctx, http.MethodGet, "/users/"+url.PathEscape(userId), nil, nil, authToken, &user)
+	if err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+func (c *Client) CreateUser(ctx context.Context, req *server.CreateUserRequest) (*server.CreateUserResponse, error) {
+	var resp server.CreateUserResponse
+	err := c.do(ctx, http.MethodPost, "/users", nil, req, authToken, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+func (c *Client) DeleteUser(ctx context.Context, userId string) error {
+	return c.do(ctx, http.MethodDelete, "/users/"+url.PathEscape(userId), nil, nil, authToken, nil)
+}
+
+// RotateUserSecret replaces the client's secret. The client switches to the new secret, and drops its access token,
+// which the rotation revokes.
+func (c *Client) RotateUserSecret(ctx context.Context) (*server.CreateUserResponse, error) {
+	var resp server.CreateUserResponse
+	err := c.do(ctx, http.MethodGet, "/rotate", nil, nil, authClient, &resp)
+	if err != nil {
+		return nil, err
+	}
+	c.clientSecret = resp.UserSecret
+	err = c.forgetToken(nil)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
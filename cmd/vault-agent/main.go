@@ -0,0 +1,63 @@
+// Command vault-agent renders config files from templates that reference vault secrets, for apps that read
+// credentials from files. It re-renders the files when the secrets change, and can signal or restart the app.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/emarcey/data-vault/agent"
+	"github.com/emarcey/data-vault/client"
+	"github.com/emarcey/data-vault/common/logger"
+)
+
+func main() {
+	configPath := flag.String("config", "./agent_conf.yml", "Agent config file")
+	flag.Parse()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	opts, err := agent.ReadOpts(*configPath)
+	if err != nil {
+		fmt.Print(err)
+		os.Exit(1)
+	}
+
+	log, err := logger.MakeLogger(opts.LoggerType, opts.Env)
+	if err != nil {
+		fmt.Print(err)
+		os.Exit(1)
+	}
+	c, err := client.NewClient(client.Opts{
+		Addr:         opts.Addr,
+		ClientId:     opts.ClientId,
+		ClientSecret: opts.ClientSecret,
+	})
+	if err != nil {
+		fmt.Print(err)
+		os.Exit(1)
+	}
+	a, err := agent.NewAgent(log, c, opts)
+	if err != nil {
+		fmt.Print(err)
+		os.Exit(1)
+	}
+
+	// Listen for application termination.
+	go func() {
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, syscall.SIGINT, syscall.SIGTERM)
+		log.Info("exit ", <-sigs)
+		cancel()
+	}()
+
+	err = a.Run(ctx)
+	if err != nil {
+		log.Error("exit ", err)
+		os.Exit(1)
+	}
+}
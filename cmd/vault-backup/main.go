@@ -0,0 +1,156 @@
+// Command vault-backup writes an encrypted backup of the core database and secrets store, and restores one into
+// empty datastores. It connects to the datastores directly, using the server's config file.
+//
+// The passphrase is read from the file given by -passphrase-file, or from the VAULT_BACKUP_PASSPHRASE environment
+// variable, so it never appears in the process list.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/emarcey/data-vault/backup"
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/dependencies"
+)
+
+const passphraseEnvVar = "VAULT_BACKUP_PASSPHRASE"
+
+func usage() {
+	fmt.Fprintf(os.Stderr, "Usage:\n")
+	fmt.Fprintf(os.Stderr, "  vault-backup [-config PATH] [-passphrase-file PATH] backup FILE\n")
+	fmt.Fprintf(os.Stderr, "  vault-backup [-config PATH] [-passphrase-file PATH] restore FILE\n")
+	fmt.Fprintf(os.Stderr, "  vault-backup [-passphrase-file PATH] verify FILE\n\nFlags:\n")
+	flag.PrintDefaults()
+}
+
+func readPassphrase(path string) (string, error) {
+	if path == "" {
+		passphrase := os.Getenv(passphraseEnvVar)
+		if passphrase == "" {
+			return "", fmt.Errorf("Expected -passphrase-file or %s", passphraseEnvVar)
+		}
+		return passphrase, nil
+	}
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(raw), "\r\n"), nil
+}
+
+func makeDependencies(ctx context.Context, configPath string) (*dependencies.Dependencies, error) {
+	opts, err := dependencies.ReadOpts(configPath)
+	if err != nil {
+		return nil, err
+	}
+	return dependencies.MakeStoreDependencies(ctx, opts)
+}
+
+func runBackup(ctx context.Context, configPath, passphrase, path string) error {
+	deps, err := makeDependencies(ctx, configPath)
+	if err != nil {
+		return err
+	}
+	defer deps.Database.Close()
+	defer deps.SecretsManager.Close(ctx)
+
+	archive, err := backup.Backup(ctx, deps.Database, deps.SecretsManager, common.Version)
+	if err != nil {
+		return err
+	}
+	sealed, err := backup.Seal(archive, passphrase)
+	if err != nil {
+		return err
+	}
+	err = ioutil.WriteFile(path, sealed, 0600)
+	if err != nil {
+		return err
+	}
+	deps.Logger.Infof("Wrote backup of %d tables and %d encrypted secrets to %s", len(archive.Tables), len(archive.EncryptedSecrets), path)
+	return nil
+}
+
+func openArchive(path, passphrase string) (*backup.Archive, error) {
+	sealed, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return backup.Open(sealed, passphrase)
+}
+
+func runRestore(ctx context.Context, configPath, passphrase, path string) error {
+	archive, err := openArchive(path, passphrase)
+	if err != nil {
+		return err
+	}
+	deps, err := makeDependencies(ctx, configPath)
+	if err != nil {
+		return err
+	}
+	defer deps.Database.Close()
+	defer deps.SecretsManager.Close(ctx)
+
+	summary, err := backup.Restore(ctx, deps.Database, deps.SecretsManager, archive)
+	if err != nil {
+		return err
+	}
+	for table, count := range summary.Rows {
+		deps.Logger.Infof("Restored %d rows to %s", count, table)
+	}
+	deps.Logger.Infof("Restored %d encrypted secrets from backup taken at %v", summary.EncryptedSecrets, archive.CreatedAt)
+	return nil
+}
+
+// runVerify decrypts and validates an archive without connecting to any datastore
+func runVerify(passphrase, path string) error {
+	archive, err := openArchive(path, passphrase)
+	if err != nil {
+		return err
+	}
+	err = archive.Validate()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Backup taken at %v by server %s is valid: %d tables, %d encrypted secrets\n", archive.CreatedAt, archive.ServerVersion, len(archive.Tables), len(archive.EncryptedSecrets))
+	return nil
+}
+
+func run(ctx context.Context) error {
+	configPath := flag.String("config", "./server_conf.yml", "Server config file, for datastore connections")
+	passphraseFile := flag.String("passphrase-file", "", "File containing the archive passphrase (env "+passphraseEnvVar+")")
+	flag.Usage = usage
+	flag.Parse()
+	if flag.NArg() != 2 {
+		usage()
+		return fmt.Errorf("Expected a command and a file")
+	}
+	passphrase, err := readPassphrase(*passphraseFile)
+	if err != nil {
+		return err
+	}
+	command, path := flag.Arg(0), flag.Arg(1)
+	switch command {
+	case "backup":
+		return runBackup(ctx, *configPath, passphrase, path)
+	case "restore":
+		return runRestore(ctx, *configPath, passphrase, path)
+	case "verify":
+		return runVerify(passphrase, path)
+	default:
+		usage()
+		return fmt.Errorf("Unknown command %s", command)
+	}
+}
+
+func main() {
+	err := run(context.Background())
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+var logsCommand = &command{
+	name: "logs",
+	subcommands: []*command{
+		{
+			name:    "ls",
+			usage:   "logs ls [-user ID] [-action TYPE] [-key NAME] [-target-type TYPE] [-target ID] [-request ID] [-outcome OUTCOME] [-source-ip IP] [-start YYYY-MM-DD] [-end YYYY-MM-DD] " + paginationUsage,
+			summary: "List access logs, newest first (admin only)",
+			run:     runLogsList,
+		},
+		{
+			name:    "verify",
+			usage:   "logs verify",
+			summary: "Verify the access log hash chain (admin only)",
+			run:     runLogsVerify,
+		},
+	},
+}
+
+func parseDateFlag(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	date, err := time.Parse(common.DATE_FORMAT, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Expected -%s as YYYY-MM-DD. Got %s", name, value)
+	}
+	return date, nil
+}
+
+func runLogsList(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("logs ls")
+	req := &common.ListAccessLogsRequest{}
+	fs.StringVar(&req.UserId, "user", "", "Only logs of actions by this user")
+	fs.StringVar(&req.ActionType, "action", "", "Only logs of this action type")
+	fs.StringVar(&req.KeyName, "key", "", "Only logs for this secret name")
+	fs.StringVar(&req.TargetType, "target-type", "", "Only logs for this target type")
+	fs.StringVar(&req.TargetId, "target", "", "Only logs for this target")
+	fs.StringVar(&req.RequestId, "request", "", "Only logs written while handling this request id")
+	fs.StringVar(&req.Outcome, "outcome", "", "Only logs with this outcome: allowed, denied, not_found or error")
+	fs.StringVar(&req.SourceIp, "source-ip", "", "Only logs of requests from this client IP")
+	paginationFlags(fs, "logs", &req.PageSize, &req.Offset, &req.Cursor, &req.IncludeTotal)
+	startDate := fs.String("start", "", "First date to return logs from, inclusive")
+	endDate := fs.String("end", "", "Last date to return logs from, inclusive")
+	_, err := parseArgs(fs, args, "logs ls [flags]", 0)
+	if err != nil {
+		return err
+	}
+	req.StartDate, err = parseDateFlag("start", *startDate)
+	if err != nil {
+		return err
+	}
+	req.EndDate, err = parseDateFlag("end", *endDate)
+	if err != nil {
+		return err
+	}
+	page, err := a.client.ListAccessLogs(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.printPage(page, page.Items, page.PageInfo)
+}
+
+func runLogsVerify(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("logs verify")
+	_, err := parseArgs(fs, args, "logs verify", 0)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.VerifyAccessLogs(ctx)
+	if err != nil {
+		return err
+	}
+	return a.print(resp)
+}
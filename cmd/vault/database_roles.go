@@ -0,0 +1,242 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/emarcey/data-vault/server"
+)
+
+var dbRoleCommand = &command{
+	name: "db-role",
+	subcommands: []*command{
+		{
+			name:    "ls",
+			usage:   "db-role ls " + paginationUsage,
+			summary: "List database roles (admin only)",
+			run:     runDbRoleList,
+		},
+		{
+			name:    "get",
+			usage:   "db-role get NAME",
+			summary: "Fetch a database role (admin only)",
+			run:     runDbRoleGet,
+		},
+		{
+			name:    "create",
+			usage:   "db-role create (-connection-url URL | -connection-url-file PATH) -creation SQL [-renew SQL] [-revocation SQL] [-ttl SECONDS] [-max-ttl SECONDS] NAME",
+			summary: "Create a database role (admin only). Repeat -creation, -renew and -revocation for several statements.",
+			run:     runDbRoleCreate,
+		},
+		{
+			name:    "delete",
+			usage:   "db-role delete NAME",
+			summary: "Delete a database role (admin only). Its leases are revoked when they expire.",
+			run:     runDbRoleDelete,
+		},
+		{
+			name:    "grant",
+			usage:   "db-role grant (-user ID | -group ID) NAME",
+			summary: "Allow a user or group to fetch credentials from a database role (admin only)",
+			run: func(ctx context.Context, a *app, args []string) error {
+				return runDbRolePermission(ctx, a, "grant", args)
+			},
+		},
+		{
+			name:    "revoke",
+			usage:   "db-role revoke (-user ID | -group ID) NAME",
+			summary: "Stop a user or group fetching credentials from a database role (admin only)",
+			run: func(ctx context.Context, a *app, args []string) error {
+				return runDbRolePermission(ctx, a, "revoke", args)
+			},
+		},
+		{
+			name:    "creds",
+			usage:   "db-role creds NAME",
+			summary: "Create database credentials from a role, under a new lease",
+			run:     runDbRoleCreds,
+		},
+	},
+}
+
+var leaseCommand = &command{
+	name: "lease",
+	subcommands: []*command{
+		{
+			name:    "renew",
+			usage:   "lease renew [-increment SECONDS] ID",
+			summary: "Extend a lease, up to its max TTL. Without -increment, it's extended by the role's default TTL.",
+			run:     runLeaseRenew,
+		},
+		{
+			name:    "revoke",
+			usage:   "lease revoke ID",
+			summary: "Drop a lease's credentials now",
+			run:     runLeaseRevoke,
+		},
+	},
+}
+
+// readConnectionUrl returns the URL from -connection-url, or the contents of -connection-url-file, which is stdin if
+// it's "-". Reading it from a file keeps the database password out of the shell history.
+func readConnectionUrl(connectionUrl, connectionUrlFile string) (string, error) {
+	if (connectionUrl == "") == (connectionUrlFile == "") {
+		return "", fmt.Errorf("Expected either -connection-url or -connection-url-file")
+	}
+	if connectionUrl != "" {
+		return connectionUrl, nil
+	}
+	var data []byte
+	var err error
+	if connectionUrlFile == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(connectionUrlFile)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(string(data)), nil
+}
+
+func runDbRoleList(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("db-role ls")
+	req := &server.PaginationRequest{}
+	paginationFlags(fs, "roles", &req.PageSize, &req.Offset, &req.Cursor, &req.IncludeTotal)
+	_, err := parseArgs(fs, args, "db-role ls "+paginationUsage, 0)
+	if err != nil {
+		return err
+	}
+	page, err := a.client.ListDatabaseRoles(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.printPage(page, page.Items, page.PageInfo)
+}
+
+func runDbRoleGet(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("db-role get")
+	args, err := parseArgs(fs, args, "db-role get NAME", 1)
+	if err != nil {
+		return err
+	}
+	role, err := a.client.GetDatabaseRole(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.print(role)
+}
+
+func runDbRoleCreate(ctx context.Context, a *app, args []string) error {
+	usage := "db-role create (-connection-url URL | -connection-url-file PATH) -creation SQL [-renew SQL] [-revocation SQL] [-ttl SECONDS] [-max-ttl SECONDS] NAME"
+	fs := a.flagSet("db-role create")
+	connectionUrl := fs.String("connection-url", "", "Postgres connection URL of a user that can create roles")
+	connectionUrlFile := fs.String("connection-url-file", "", "File to read the connection URL from, or - for stdin")
+	var creation, renew, revocation stringsFlag
+	fs.Var(&creation, "creation", "Statement run to create credentials. May be repeated.")
+	fs.Var(&renew, "renew", "Statement run to renew a lease. May be repeated. Defaults to moving the role's VALID UNTIL.")
+	fs.Var(&revocation, "revocation", "Statement run to revoke a lease. May be repeated. Defaults to dropping the role.")
+	ttl := fs.Int("ttl", 0, "Default lease TTL in seconds")
+	maxTtl := fs.Int("max-ttl", 0, "Longest a lease can be renewed for, in seconds")
+	args, err := parseArgs(fs, args, usage, 1)
+	if err != nil {
+		return err
+	}
+	url, err := readConnectionUrl(*connectionUrl, *connectionUrlFile)
+	if err != nil {
+		return err
+	}
+	role, err := a.client.CreateDatabaseRole(ctx, &server.CreateDatabaseRoleRequest{
+		Name:                 args[0],
+		ConnectionUrl:        url,
+		CreationStatements:   creation,
+		RenewStatements:      renew,
+		RevocationStatements: revocation,
+		DefaultTtlSeconds:    *ttl,
+		MaxTtlSeconds:        *maxTtl,
+	})
+	if err != nil {
+		return err
+	}
+	return a.print(role)
+}
+
+func runDbRoleDelete(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("db-role delete")
+	args, err := parseArgs(fs, args, "db-role delete NAME", 1)
+	if err != nil {
+		return err
+	}
+	err = a.client.DeleteDatabaseRole(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.done("Deleted database role %s", args[0])
+}
+
+func runDbRolePermission(ctx context.Context, a *app, action string, args []string) error {
+	usage := "db-role " + action + " (-user ID | -group ID) NAME"
+	fs := a.flagSet("db-role " + action)
+	userId := fs.String("user", "", "User id")
+	userGroupId := fs.String("group", "", "User group id")
+	args, err := parseArgs(fs, args, usage, 1)
+	if err != nil {
+		return err
+	}
+	if (*userId == "") == (*userGroupId == "") {
+		return fmt.Errorf("Expected either -user or -group. Usage: vault %s", usage)
+	}
+	req := &server.DatabaseRolePermissionRequest{RoleName: args[0], UserId: *userId, UserGroupId: *userGroupId}
+	if action == "grant" {
+		err = a.client.GrantDatabaseRolePermission(ctx, req)
+	} else {
+		err = a.client.RevokeDatabaseRolePermission(ctx, req)
+	}
+	if err != nil {
+		return err
+	}
+	return a.done("%s on database role %s: done", action, args[0])
+}
+
+func runDbRoleCreds(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("db-role creds")
+	args, err := parseArgs(fs, args, "db-role creds NAME", 1)
+	if err != nil {
+		return err
+	}
+	creds, err := a.client.GetDatabaseCredentials(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.print(creds)
+}
+
+func runLeaseRenew(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("lease renew")
+	increment := fs.Int("increment", 0, "Seconds to extend the lease by, from now")
+	args, err := parseArgs(fs, args, "lease renew [-increment SECONDS] ID", 1)
+	if err != nil {
+		return err
+	}
+	lease, err := a.client.RenewLease(ctx, &server.RenewLeaseRequest{LeaseId: args[0], IncrementSeconds: *increment})
+	if err != nil {
+		return err
+	}
+	return a.print(lease)
+}
+
+func runLeaseRevoke(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("lease revoke")
+	args, err := parseArgs(fs, args, "lease revoke ID", 1)
+	if err != nil {
+		return err
+	}
+	err = a.client.RevokeLease(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.done("Revoked lease %s", args[0])
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadConnectionUrl(t *testing.T) {
+	url, err := readConnectionUrl("postgres://vault@db/app", "")
+	require.Nil(t, err, "error in readConnectionUrl: %v", err)
+	require.Equal(t, url, "postgres://vault@db/app")
+
+	path := filepath.Join(t.TempDir(), "url")
+	err = ioutil.WriteFile(path, []byte("postgres://vault:pass@db/app\n"), 0600)
+	require.Nil(t, err, "error writing file: %v", err)
+	url, err = readConnectionUrl("", path)
+	require.Nil(t, err, "error in readConnectionUrl: %v", err)
+	require.Equal(t, url, "postgres://vault:pass@db/app")
+
+	_, err = readConnectionUrl("", "")
+	require.NotNil(t, err, "no error in readConnectionUrl without a URL")
+	_, err = readConnectionUrl("postgres://vault@db/app", path)
+	require.NotNil(t, err, "no error in readConnectionUrl with both a URL and a file")
+	_, err = readConnectionUrl("", filepath.Join(t.TempDir(), "missing"))
+	require.NotNil(t, err, "no error in readConnectionUrl with a missing file")
+}
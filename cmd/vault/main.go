@@ -0,0 +1,186 @@
+// Command vault is a command-line client for the data-vault API.
+//
+// It authenticates with a client id and secret, from flags or the VAULT_CLIENT_ID and VAULT_CLIENT_SECRET
+// environment variables, and caches the access token it exchanges them for until the token expires.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/emarcey/data-vault/client"
+)
+
+type app struct {
+	client *client.Client
+	out    io.Writer
+	format string
+}
+
+// command is either a leaf with run, or a group of subcommands
+type command struct {
+	name        string
+	usage       string
+	summary     string
+	run         func(ctx context.Context, a *app, args []string) error
+	subcommands []*command
+}
+
+var commands = []*command{
+	secretCommand,
+	grantCommand,
+	revokeCommand,
+	userCommand,
+	groupCommand,
+	namespaceCommand,
+	dbRoleCommand,
+	leaseCommand,
+	transitCommand,
+	logsCommand,
+	keyCommand,
+	tokenCommand,
+	versionCommand,
+}
+
+func envOrDefault(key, defaultValue string) string {
+	val := os.Getenv(key)
+	if val == "" {
+		return defaultValue
+	}
+	return val
+}
+
+func printUsage(w io.Writer, cmds []*command) {
+	fmt.Fprintf(w, "Usage:\n")
+	printCommands(w, cmds)
+}
+
+func printCommands(w io.Writer, cmds []*command) {
+	for _, cmd := range cmds {
+		if cmd.subcommands != nil {
+			printCommands(w, cmd.subcommands)
+			continue
+		}
+		fmt.Fprintf(w, "  vault %s\n    \t%s\n", cmd.usage, cmd.summary)
+	}
+}
+
+// flagSet returns a flag set for a command that also accepts the output format flag
+func (a *app) flagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.StringVar(&a.format, "o", a.format, "Output format: table or json")
+	return fs
+}
+
+// paginationUsage documents the flags added by paginationFlags
+const paginationUsage = "[-page-size N] [-offset N | -cursor CURSOR] [-total]"
+
+// paginationFlags adds the flags shared by every list command
+func paginationFlags(fs *flag.FlagSet, noun string, pageSize, offset *int, cursor *string, includeTotal *bool) {
+	fs.IntVar(pageSize, "page-size", 10, fmt.Sprintf("Number of %s to return", noun))
+	fs.IntVar(offset, "offset", 0, fmt.Sprintf("Number of %s to skip. Prefer -cursor.", noun))
+	fs.StringVar(cursor, "cursor", "", "Cursor of the page to fetch, printed with the previous page")
+	fs.BoolVar(includeTotal, "total", false, fmt.Sprintf("Also print the number of %s across every page", noun))
+}
+
+// parseFlags parses flags wherever they appear among the positional args, and returns the positional args
+func parseFlags(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		err := fs.Parse(args)
+		if err != nil {
+			return nil, err
+		}
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional, nil
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// parseArgs parses a command's flags and checks that it got count positional args
+func parseArgs(fs *flag.FlagSet, args []string, usage string, count int) ([]string, error) {
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return nil, err
+	}
+	if len(args) != count {
+		return nil, fmt.Errorf("Expected %d argument(s). Usage: vault %s", count, usage)
+	}
+	return args, nil
+}
+
+func dispatch(ctx context.Context, a *app, prefix string, cmds []*command, args []string) error {
+	if len(args) == 0 {
+		printUsage(os.Stderr, cmds)
+		return fmt.Errorf("Expected a command")
+	}
+	for _, cmd := range cmds {
+		if cmd.name != args[0] {
+			continue
+		}
+		if cmd.subcommands != nil {
+			return dispatch(ctx, a, prefix+" "+cmd.name, cmd.subcommands, args[1:])
+		}
+		return cmd.run(ctx, a, args[1:])
+	}
+	printUsage(os.Stderr, cmds)
+	return fmt.Errorf("Unknown command %s", strings.TrimSpace(prefix+" "+args[0]))
+}
+
+func run(ctx context.Context, args []string) error {
+	tokenCachePath, err := client.DefaultTokenCachePath()
+	if err != nil {
+		tokenCachePath = ""
+	}
+
+	fs := flag.NewFlagSet("vault", flag.ContinueOnError)
+	addr := fs.String("addr", envOrDefault("VAULT_ADDR", "http://localhost:9090"), "API address (env VAULT_ADDR)")
+	clientId := fs.String("client-id", os.Getenv("VAULT_CLIENT_ID"), "Client id (env VAULT_CLIENT_ID)")
+	clientSecret := fs.String("client-secret", os.Getenv("VAULT_CLIENT_SECRET"), "Client secret (env VAULT_CLIENT_SECRET)")
+	tokenCache := fs.String("token-cache", envOrDefault("VAULT_TOKEN_CACHE", tokenCachePath), "Access token cache file, or empty to disable (env VAULT_TOKEN_CACHE)")
+	namespace := fs.String("namespace", os.Getenv("VAULT_NAMESPACE"), "Namespace of the secrets and user groups to work with (env VAULT_NAMESPACE)")
+	format := fs.String("o", "table", "Output format: table or json")
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "vault [flags] <command>\n\nFlags:\n")
+		fs.PrintDefaults()
+		printUsage(fs.Output(), commands)
+	}
+	err = fs.Parse(args)
+	if err != nil {
+		return err
+	}
+
+	opts := client.Opts{
+		Addr:         *addr,
+		ClientId:     *clientId,
+		ClientSecret: *clientSecret,
+		Namespace:    *namespace,
+	}
+	if *tokenCache != "" {
+		opts.TokenCache = client.NewFileTokenCache(*tokenCache)
+	}
+	c, err := client.NewClient(opts)
+	if err != nil {
+		return err
+	}
+	a := &app{client: c, out: os.Stdout, format: *format}
+	return dispatch(ctx, a, "vault", commands, fs.Args())
+}
+
+func main() {
+	err := run(context.Background(), os.Args[1:])
+	if err == flag.ErrHelp {
+		os.Exit(2)
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseArgs(t *testing.T) {
+	var tests = []struct {
+		args     []string
+		expected []string
+		version  int
+	}{
+		{
+			args:     []string{"name"},
+			expected: []string{"name"},
+		},
+		{
+			args:     []string{"-version", "2", "name"},
+			expected: []string{"name"},
+			version:  2,
+		},
+		{
+			args:     []string{"name", "-version", "2"},
+			expected: []string{"name"},
+			version:  2,
+		},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("parseArgs - Successes - %v", idx), func(t *testing.T) {
+			a := &app{format: "table"}
+			fs := a.flagSet("test")
+			version := fs.Int("version", 0, "")
+			result, err := parseArgs(fs, given.args, "test", 1)
+			require.Nil(t, err, "error in parseArgs: %v", err)
+			require.Equal(t, result, given.expected, "Result %v did not equal expected %v", result, given.expected)
+			require.Equal(t, *version, given.version, "Version %v did not equal expected %v", *version, given.version)
+		})
+	}
+}
+
+func TestParseArgsErrors(t *testing.T) {
+	var tests = [][]string{
+		{},
+		{"name", "other"},
+		{"-unknown", "name"},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("parseArgs - Errors - %v", idx), func(t *testing.T) {
+			a := &app{format: "table"}
+			fs := a.flagSet("test")
+			fs.SetOutput(ioutil.Discard)
+			result, err := parseArgs(fs, given, "test", 1)
+			require.NotNil(t, err, "no error in parseArgs: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+		})
+	}
+}
+
+func TestFlagSetOutputFormat(t *testing.T) {
+	a := &app{format: "table"}
+	fs := a.flagSet("test")
+	_, err := parseArgs(fs, []string{"name", "-o", "json"}, "test", 1)
+	require.Nil(t, err, "error in parseArgs: %v", err)
+	require.Equal(t, a.format, "json", "Expected json format, got %v", a.format)
+	require.Equal(t, fs.ErrorHandling(), flag.ContinueOnError)
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+
+	"github.com/emarcey/data-vault/server"
+)
+
+var namespaceCommand = &command{
+	name: "namespace",
+	subcommands: []*command{
+		{
+			name:    "ls",
+			usage:   "namespace ls " + paginationUsage,
+			summary: "List namespaces",
+			run:     runNamespaceList,
+		},
+		{
+			name:    "get",
+			usage:   "namespace get NAME",
+			summary: "Fetch a namespace",
+			run:     runNamespaceGet,
+		},
+		{
+			name:    "create",
+			usage:   "namespace create [-description TEXT] NAME",
+			summary: "Create a namespace (admin only)",
+			run:     runNamespaceCreate,
+		},
+		{
+			name:    "delete",
+			usage:   "namespace delete NAME",
+			summary: "Delete an empty namespace (admin only)",
+			run:     runNamespaceDelete,
+		},
+		{
+			name:    "add-admin",
+			usage:   "namespace add-admin NAME USER_ID",
+			summary: "Let a user administer a namespace (admin only)",
+			run:     runNamespaceAddAdmin,
+		},
+		{
+			name:    "remove-admin",
+			usage:   "namespace remove-admin NAME USER_ID",
+			summary: "Stop a user administering a namespace (admin only)",
+			run:     runNamespaceRemoveAdmin,
+		},
+	},
+}
+
+func runNamespaceList(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("namespace ls")
+	req := &server.PaginationRequest{}
+	paginationFlags(fs, "namespaces", &req.PageSize, &req.Offset, &req.Cursor, &req.IncludeTotal)
+	_, err := parseArgs(fs, args, "namespace ls "+paginationUsage, 0)
+	if err != nil {
+		return err
+	}
+	page, err := a.client.ListNamespaces(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.printPage(page, page.Items, page.PageInfo)
+}
+
+func runNamespaceGet(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("namespace get")
+	args, err := parseArgs(fs, args, "namespace get NAME", 1)
+	if err != nil {
+		return err
+	}
+	namespace, err := a.client.GetNamespace(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.print(namespace)
+}
+
+func runNamespaceCreate(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("namespace create")
+	description := fs.String("description", "", "Description of the namespace")
+	args, err := parseArgs(fs, args, "namespace create [-description TEXT] NAME", 1)
+	if err != nil {
+		return err
+	}
+	namespace, err := a.client.CreateNamespace(ctx, &server.CreateNamespaceRequest{Name: args[0], Description: *description})
+	if err != nil {
+		return err
+	}
+	return a.print(namespace)
+}
+
+func runNamespaceDelete(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("namespace delete")
+	args, err := parseArgs(fs, args, "namespace delete NAME", 1)
+	if err != nil {
+		return err
+	}
+	err = a.client.DeleteNamespace(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.done("Deleted namespace %s", args[0])
+}
+
+func runNamespaceAddAdmin(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("namespace add-admin")
+	args, err := parseArgs(fs, args, "namespace add-admin NAME USER_ID", 2)
+	if err != nil {
+		return err
+	}
+	err = a.client.AddNamespaceAdmin(ctx, &server.NamespaceAdminRequest{Namespace: args[0], UserId: args[1]})
+	if err != nil {
+		return err
+	}
+	return a.done("Made user %s an admin of namespace %s", args[1], args[0])
+}
+
+func runNamespaceRemoveAdmin(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("namespace remove-admin")
+	args, err := parseArgs(fs, args, "namespace remove-admin NAME USER_ID", 2)
+	if err != nil {
+		return err
+	}
+	err = a.client.RemoveNamespaceAdmin(ctx, &server.NamespaceAdminRequest{Namespace: args[0], UserId: args[1]})
+	if err != nil {
+		return err
+	}
+	return a.done("Removed user %s as an admin of namespace %s", args[1], args[0])
+}
@@ -0,0 +1,157 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	bsonPrimitive "go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+var dateTimeType = reflect.TypeOf(bsonPrimitive.DateTime(0))
+
+// print writes v in the app's output format
+func (a *app) print(v interface{}) error {
+	switch a.format {
+	case "json":
+		encoder := json.NewEncoder(a.out)
+		encoder.SetIndent("", "  ")
+		return encoder.Encode(v)
+	case "table":
+		return printTable(a.out, v)
+	default:
+		return fmt.Errorf("Unknown output format %s. Expected table or json", a.format)
+	}
+}
+
+// printPage writes a page of a list. JSON output is the whole page, while tables show its items followed by the
+// total and the cursor of the next page, if there is one.
+func (a *app) printPage(page interface{}, items interface{}, info common.PageInfo) error {
+	if a.format != "table" {
+		return a.print(page)
+	}
+	err := printTable(a.out, items)
+	if err != nil {
+		return err
+	}
+	if info.Total != nil {
+		_, err = fmt.Fprintf(a.out, "\nTotal: %d\n", *info.Total)
+		if err != nil {
+			return err
+		}
+	}
+	if info.NextCursor != "" {
+		_, err = fmt.Fprintf(a.out, "\nNext page: -cursor %s\n", info.NextCursor)
+	}
+	return err
+}
+
+// done reports a command that has no response body. JSON output stays empty so it can be piped.
+func (a *app) done(message string, messageArgs ...interface{}) error {
+	if a.format == "json" {
+		return nil
+	}
+	_, err := fmt.Fprintf(a.out, message+"\n", messageArgs...)
+	return err
+}
+
+type tableColumn struct {
+	header string
+	index  int
+}
+
+// tableColumns returns a column for each exported struct field that is serialized to JSON
+func tableColumns(t reflect.Type) []tableColumn {
+	columns := make([]tableColumn, 0)
+	for idx := 0; idx < t.NumField(); idx++ {
+		field := t.Field(idx)
+		if field.PkgPath != "" {
+			continue
+		}
+		name := strings.Split(field.Tag.Get("json"), ",")[0]
+		if name == "-" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		columns = append(columns, tableColumn{header: strings.ToUpper(name), index: idx})
+	}
+	return columns
+}
+
+func formatTableValue(v reflect.Value) string {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			return ""
+		}
+		v = v.Elem()
+	}
+	switch v.Type() {
+	case timeType:
+		return v.Interface().(time.Time).Format(time.RFC3339)
+	case dateTimeType:
+		return v.Interface().(bsonPrimitive.DateTime).Time().Format(time.RFC3339)
+	}
+	if v.Kind() == reflect.Struct {
+		return fmt.Sprintf("%+v", v.Interface())
+	}
+	return fmt.Sprint(v.Interface())
+}
+
+// printTable writes a struct, or a slice of structs, as a table with a column per JSON field
+func printTable(w io.Writer, v interface{}) error {
+	rv := reflect.ValueOf(v)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return nil
+		}
+		rv = rv.Elem()
+	}
+
+	rows := []reflect.Value{rv}
+	rowType := rv.Type()
+	if rv.Kind() == reflect.Slice {
+		rows = make([]reflect.Value, 0, rv.Len())
+		for idx := 0; idx < rv.Len(); idx++ {
+			rows = append(rows, rv.Index(idx))
+		}
+		rowType = rv.Type().Elem()
+	}
+	for rowType.Kind() == reflect.Ptr {
+		rowType = rowType.Elem()
+	}
+	if rowType.Kind() != reflect.Struct {
+		_, err := fmt.Fprintln(w, v)
+		return err
+	}
+
+	columns := tableColumns(rowType)
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	headers := make([]string, 0, len(columns))
+	for _, column := range columns {
+		headers = append(headers, column.header)
+	}
+	fmt.Fprintln(tw, strings.Join(headers, "\t"))
+	for _, row := range rows {
+		for row.Kind() == reflect.Ptr && !row.IsNil() {
+			row = row.Elem()
+		}
+		if row.Kind() != reflect.Struct {
+			continue
+		}
+		values := make([]string, 0, len(columns))
+		for _, column := range columns {
+			values = append(values, formatTableValue(row.Field(column.index)))
+		}
+		fmt.Fprintln(tw, strings.Join(values, "\t"))
+	}
+	return tw.Flush()
+}
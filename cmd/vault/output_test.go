@@ -0,0 +1,34 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+func TestPrintPage(t *testing.T) {
+	total := 2
+	page := &common.UserGroupPage{
+		Items:    []*common.UserGroup{{Id: "group1", Name: "admins"}},
+		PageInfo: common.PageInfo{NextCursor: "abc", Total: &total},
+	}
+
+	var out bytes.Buffer
+	a := &app{out: &out, format: "table"}
+	err := a.printPage(page, page.Items, page.PageInfo)
+	require.Nil(t, err, "error in printPage: %v", err)
+	require.Contains(t, out.String(), "admins")
+	require.Contains(t, out.String(), "\nTotal: 2\n")
+	require.Contains(t, out.String(), "\nNext page: -cursor abc\n")
+
+	out.Reset()
+	a.format = "json"
+	err = a.printPage(page, page.Items, page.PageInfo)
+	require.Nil(t, err, "error in printPage: %v", err)
+	require.Contains(t, out.String(), `"next_cursor": "abc"`)
+	require.Contains(t, out.String(), `"total": 2`)
+	require.Contains(t, out.String(), `"items": [`)
+}
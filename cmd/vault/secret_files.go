@@ -0,0 +1,108 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"mime"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/emarcey/data-vault/server"
+)
+
+const secretUploadUsage = "secret upload [-update] [-content-type TYPE] [-filename NAME] [-description TEXT] [-expires-at RFC3339] PATH NAME"
+
+// readSecretFile reads the file to upload, which is stdin if path is "-". The filename and content type default to
+// the file's base name and the type registered for its extension.
+func readSecretFile(path, filename, contentType string) ([]byte, string, string, error) {
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(path)
+		if filename == "" {
+			filename = filepath.Base(path)
+		}
+	}
+	if err != nil {
+		return nil, "", "", err
+	}
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(filename))
+	}
+	return data, filename, contentType, nil
+}
+
+func runSecretUpload(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("secret upload")
+	update := fs.Bool("update", false, "Add a new version of an existing secret")
+	contentType := fs.String("content-type", "", "Content type. Defaults to the type for the file's extension.")
+	filename := fs.String("filename", "", "Filename returned on download. Defaults to the file's base name.")
+	description := fs.String("description", "", "Secret description. Ignored with -update.")
+	expiresAt := fs.String("expires-at", "", "Time after which the secret can no longer be read, in RFC3339. Ignored with -update.")
+	args, err := parseArgs(fs, args, secretUploadUsage, 2)
+	if err != nil {
+		return err
+	}
+	data, secretFilename, secretContentType, err := readSecretFile(args[0], *filename, *contentType)
+	if err != nil {
+		return err
+	}
+	if *update {
+		secret, err := a.client.UpdateSecretFile(ctx, &server.UpdateSecretFileRequest{
+			Name:        args[1],
+			ContentType: secretContentType,
+			Filename:    secretFilename,
+			Data:        data,
+		})
+		if err != nil {
+			return err
+		}
+		return a.print(secret)
+	}
+	req := &server.CreateSecretFileRequest{
+		Name:        args[1],
+		Description: *description,
+		ContentType: secretContentType,
+		Filename:    secretFilename,
+		Data:        data,
+	}
+	if *expiresAt != "" {
+		expiry, err := time.Parse(time.RFC3339, *expiresAt)
+		if err != nil {
+			return fmt.Errorf("Expected -expires-at in RFC3339. Got %s", *expiresAt)
+		}
+		req.ExpiresAt = &expiry
+	}
+	secret, err := a.client.CreateSecretFile(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.print(secret)
+}
+
+func runSecretDownload(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("secret download")
+	version := fs.Int("version", 0, "Version to fetch. Defaults to the current version.")
+	out := fs.String("out", "", "File to write to, created with mode 0600. Defaults to stdout.")
+	args, err := parseArgs(fs, args, "secret download [-version N] [-out PATH] NAME", 1)
+	if err != nil {
+		return err
+	}
+	file, err := a.client.GetSecretFile(ctx, &server.GetSecretRequest{Name: args[0], Version: *version})
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = a.out.Write(file.Data)
+		return err
+	}
+	err = ioutil.WriteFile(*out, file.Data, 0600)
+	if err != nil {
+		return err
+	}
+	return a.done("Wrote version %d of %s to %s", file.Version, file.Name, *out)
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadSecretFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ca.json")
+	err := ioutil.WriteFile(path, []byte("{}"), 0600)
+	require.Nil(t, err, "Unexpected error writing file: %v", err)
+
+	data, filename, contentType, err := readSecretFile(path, "", "")
+	require.Nil(t, err, "error in readSecretFile: %v", err)
+	require.Equal(t, data, []byte("{}"))
+	require.Equal(t, filename, "ca.json")
+	require.Equal(t, contentType, "application/json")
+
+	_, filename, contentType, err = readSecretFile(path, "other.pem", "application/x-pem-file")
+	require.Nil(t, err, "error in readSecretFile: %v", err)
+	require.Equal(t, filename, "other.pem")
+	require.Equal(t, contentType, "application/x-pem-file")
+
+	_, _, _, err = readSecretFile(filepath.Join(t.TempDir(), "missing"), "", "")
+	require.NotNil(t, err, "no error in readSecretFile for a missing file")
+}
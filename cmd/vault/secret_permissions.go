@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/emarcey/data-vault/server"
+)
+
+var grantCommand = &command{
+	name:    "grant",
+	usage:   "grant (-user ID | -group ID) [-level read|write|manage] (NAME | -pattern PATTERN)",
+	summary: "Grant a user or group access to a secret, or to every secret matching a pattern (patterns are admin only)",
+	run: func(ctx context.Context, a *app, args []string) error {
+		return runPermission(ctx, a, "grant", args)
+	},
+}
+
+var revokeCommand = &command{
+	name:    "revoke",
+	usage:   "revoke (-user ID | -group ID) [-level write|manage] (NAME | -pattern PATTERN)",
+	summary: "Revoke access to a secret or pattern. With -level, the grant is lowered to the level below it.",
+	run: func(ctx context.Context, a *app, args []string) error {
+		return runPermission(ctx, a, "revoke", args)
+	},
+}
+
+func runPermission(ctx context.Context, a *app, action string, args []string) error {
+	usage := action + " (-user ID | -group ID) [-level LEVEL] (NAME | -pattern PATTERN)"
+	fs := a.flagSet(action)
+	userId := fs.String("user", "", "User id")
+	userGroupId := fs.String("group", "", "User group id")
+	level := fs.String("level", "", "Permission level: read, write or manage")
+	pattern := fs.String("pattern", "", "Secret name pattern, where * matches any characters")
+	args, err := parseFlags(fs, args)
+	if err != nil {
+		return err
+	}
+	if (*userId == "") == (*userGroupId == "") {
+		return fmt.Errorf("Expected either -user or -group. Usage: vault %s", usage)
+	}
+	if (*pattern == "") == (len(args) == 0) || len(args) > 1 {
+		return fmt.Errorf("Expected either a secret name or -pattern. Usage: vault %s", usage)
+	}
+
+	if *pattern != "" {
+		req := &server.SecretPatternPermissionRequest{
+			Pattern:     *pattern,
+			UserId:      *userId,
+			UserGroupId: *userGroupId,
+			Level:       *level,
+		}
+		if action == "grant" {
+			err = a.client.GrantPatternPermission(ctx, req)
+		} else {
+			err = a.client.RevokePatternPermission(ctx, req)
+		}
+		if err != nil {
+			return err
+		}
+		return a.done("%s on %s: done", action, *pattern)
+	}
+
+	req := &server.SecretPermissionRequest{
+		SecretName:  args[0],
+		UserId:      *userId,
+		UserGroupId: *userGroupId,
+		Level:       *level,
+	}
+	if action == "grant" {
+		err = a.client.GrantPermission(ctx, req)
+	} else {
+		err = a.client.RevokePermission(ctx, req)
+	}
+	if err != nil {
+		return err
+	}
+	return a.done("%s on %s: done", action, args[0])
+}
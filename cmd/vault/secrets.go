@@ -0,0 +1,519 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/server"
+)
+
+var secretCommand = &command{
+	name: "secret",
+	subcommands: []*command{
+		{
+			name:    "ls",
+			usage:   secretListUsage,
+			summary: "List the secrets you can read. Repeat -label to require every selector: KEY=VALUE, KEY!=VALUE, KEY or !KEY.",
+			run:     runSecretList,
+		},
+		{
+			name:    "get",
+			usage:   "secret get [-version N] [-field FIELD] [-raw] NAME",
+			summary: "Fetch a secret, at its current version unless -version is set. -field fetches one field of a multi-field secret. -raw prints only the value.",
+			run:     runSecretGet,
+		},
+		{
+			name:    "create",
+			usage:   secretCreateUsage,
+			summary: "Create a secret. Use -value-file - to read the value from stdin. Repeat -field to create a multi-field secret. -generate has the server generate the value from a policy.",
+			run:     runSecretCreate,
+		},
+		{
+			name:    "update",
+			usage:   secretUpdateUsage,
+			summary: "Add a new version of a secret. The new version replaces every field.",
+			run:     runSecretUpdate,
+		},
+		{
+			name:    "policies",
+			usage:   "secret policies",
+			summary: "List the policies that -generate accepts",
+			run:     runSecretPolicies,
+		},
+		{
+			name:    "upload",
+			usage:   secretUploadUsage,
+			summary: "Upload a file as a secret, or as a new version of one with -update. Use PATH - to read from stdin.",
+			run:     runSecretUpload,
+		},
+		{
+			name:    "download",
+			usage:   "secret download [-version N] [-out PATH] NAME",
+			summary: "Download a file secret to -out, or to stdout",
+			run:     runSecretDownload,
+		},
+		{
+			name:    "labels",
+			usage:   "secret labels [-label KEY=VALUE...] NAME",
+			summary: "Replace every label on a secret. Without -label, all labels are removed.",
+			run:     runSecretLabels,
+		},
+		{
+			name:    "versions",
+			usage:   "secret versions NAME",
+			summary: "List the versions of a secret",
+			run:     runSecretVersions,
+		},
+		{
+			name:    "rollback",
+			usage:   "secret rollback -version N NAME",
+			summary: "Make an earlier version of a secret current",
+			run:     runSecretRollback,
+		},
+		{
+			name:    "rekey",
+			usage:   "secret rekey NAME",
+			summary: "Re-encrypt every version of a secret under new data keys (admin only)",
+			run:     runSecretRekey,
+		},
+		{
+			name:    "delete",
+			usage:   "secret delete NAME",
+			summary: "Delete a secret (admin only)",
+			run:     runSecretDelete,
+		},
+		{
+			name:    "deleted",
+			usage:   "secret deleted " + paginationUsage,
+			summary: "List deleted secrets (admin only)",
+			run:     runSecretDeleted,
+		},
+		{
+			name:    "restore",
+			usage:   "secret restore [-name NAME] ID",
+			summary: "Restore a deleted secret, optionally under a new name (admin only)",
+			run:     runSecretRestore,
+		},
+	},
+}
+
+const secretListUsage = "secret ls [-label SELECTOR...] [-prefix PREFIX] [-contains TEXT] [-created-by USER] [-created-after DATE] [-created-before DATE] [-updated-after DATE] [-updated-before DATE] [-sort name|created_at|updated_at] [-desc] " + paginationUsage
+
+const secretCreateUsage = "secret create (-value VALUE | -value-file PATH | -field KEY=VALUE... | -generate POLICY) [-description TEXT] [-label KEY=VALUE...] [-expires-at RFC3339] NAME"
+
+const secretUpdateUsage = "secret update (-value VALUE | -value-file PATH | -field KEY=VALUE... | -generate POLICY) NAME"
+
+var keyCommand = &command{
+	name: "key",
+	subcommands: []*command{
+		{
+			name:    "rewrap",
+			usage:   "key rewrap",
+			summary: "Re-wrap every data key under the current key encryption key (admin only)",
+			run:     runKeyRewrap,
+		},
+		{
+			name:    "rekey-status",
+			usage:   "key rekey-status",
+			summary: "Show the progress of the scheduled secret rekey (admin only)",
+			run:     runKeyRekeyStatus,
+		},
+		{
+			name:    "purge",
+			usage:   "key purge [-secret-id ID]",
+			summary: "Permanently destroy deleted secrets past the retention period, or one deleted secret by id (admin only)",
+			run:     runKeyPurge,
+		},
+	},
+}
+
+// keyValueFlag collects repeated KEY=VALUE flags, like -field and -label, into a map
+type keyValueFlag map[string]string
+
+func (f keyValueFlag) String() string {
+	pairs := make([]string, 0, len(f))
+	for name, value := range f {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+func (f keyValueFlag) Set(pair string) error {
+	parts := strings.SplitN(pair, "=", 2)
+	if len(parts) != 2 || parts[0] == "" {
+		return fmt.Errorf("Expected KEY=VALUE. Got %s", pair)
+	}
+	if _, ok := f[parts[0]]; ok {
+		return fmt.Errorf("Key %s set more than once", parts[0])
+	}
+	f[parts[0]] = parts[1]
+	return nil
+}
+
+// stringsFlag collects a flag that may be repeated
+type stringsFlag []string
+
+func (f *stringsFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *stringsFlag) Set(value string) error {
+	*f = append(*f, value)
+	return nil
+}
+
+// readSecretInput returns either the fields from -field, or the value from readSecretValue. Nothing is read if the
+// server is generating the secret from a -generate policy.
+func readSecretInput(value, valueFile string, fields keyValueFlag, generate string) (string, map[string]string, error) {
+	if generate != "" {
+		if value != "" || valueFile != "" || len(fields) > 0 {
+			return "", nil, fmt.Errorf("Expected either -generate or -value/-value-file/-field. Got both")
+		}
+		return "", nil, nil
+	}
+	if len(fields) == 0 {
+		secretValue, err := readSecretValue(value, valueFile)
+		return secretValue, nil, err
+	}
+	if value != "" || valueFile != "" {
+		return "", nil, fmt.Errorf("Expected either -field or -value/-value-file. Got both")
+	}
+	return "", fields, nil
+}
+
+// readSecretValue returns the value from -value, or the contents of -value-file, which is stdin if it's "-"
+func readSecretValue(value, valueFile string) (string, error) {
+	if value != "" && valueFile != "" {
+		return "", fmt.Errorf("Expected either -value or -value-file. Got both")
+	}
+	if valueFile == "" {
+		if value == "" {
+			return "", fmt.Errorf("Expected -value or -value-file")
+		}
+		return value, nil
+	}
+	var data []byte
+	var err error
+	if valueFile == "-" {
+		data, err = ioutil.ReadAll(os.Stdin)
+	} else {
+		data, err = ioutil.ReadFile(valueFile)
+	}
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\n"), nil
+}
+
+func runSecretList(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("secret ls")
+	var labels stringsFlag
+	fs.Var(&labels, "label", "Label selector: KEY=VALUE, KEY!=VALUE, KEY or !KEY. May be repeated.")
+	prefix := fs.String("prefix", "", "Only list secrets whose name starts with PREFIX")
+	contains := fs.String("contains", "", "Only list secrets whose name contains TEXT, ignoring case")
+	createdBy := fs.String("created-by", "", "Only list secrets created by USER")
+	createdAfter := fs.String("created-after", "", "Only list secrets created on or after DATE (YYYY-MM-DD)")
+	createdBefore := fs.String("created-before", "", "Only list secrets created before DATE (YYYY-MM-DD)")
+	updatedAfter := fs.String("updated-after", "", "Only list secrets updated on or after DATE (YYYY-MM-DD)")
+	updatedBefore := fs.String("updated-before", "", "Only list secrets updated before DATE (YYYY-MM-DD)")
+	sortBy := fs.String("sort", common.SECRET_SORT_NAME, "Sort by name, created_at or updated_at")
+	desc := fs.Bool("desc", false, "Sort in descending order")
+	req := &common.ListSecretsRequest{}
+	paginationFlags(fs, "secrets", &req.PageSize, &req.Offset, &req.Cursor, &req.IncludeTotal)
+	args, err := parseArgs(fs, args, secretListUsage, 0)
+	if err != nil {
+		return err
+	}
+	req.NamePrefix = *prefix
+	req.NameContains = *contains
+	req.CreatedBy = *createdBy
+	req.Labels = labels
+	req.SortBy = *sortBy
+	req.SortOrder = common.SORT_ORDER_ASC
+	if *desc {
+		req.SortOrder = common.SORT_ORDER_DESC
+	}
+	dates := []struct {
+		name  string
+		value string
+		dest  **time.Time
+	}{
+		{"created-after", *createdAfter, &req.CreatedAfter},
+		{"created-before", *createdBefore, &req.CreatedBefore},
+		{"updated-after", *updatedAfter, &req.UpdatedAfter},
+		{"updated-before", *updatedBefore, &req.UpdatedBefore},
+	}
+	for _, date := range dates {
+		parsed, err := parseDateFlag(date.name, date.value)
+		if err != nil {
+			return err
+		}
+		if !parsed.IsZero() {
+			*date.dest = &parsed
+		}
+	}
+	page, err := a.client.ListSecrets(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.printPage(page, page.Items, page.PageInfo)
+}
+
+func runSecretGet(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("secret get")
+	version := fs.Int("version", 0, "Version to fetch. Defaults to the current version.")
+	field := fs.String("field", "", "Field to fetch from a multi-field secret")
+	raw := fs.Bool("raw", false, "Print only the secret value")
+	args, err := parseArgs(fs, args, "secret get [-version N] [-field FIELD] [-raw] NAME", 1)
+	if err != nil {
+		return err
+	}
+	if *field != "" {
+		secretField, err := a.client.GetSecretField(ctx, &server.GetSecretFieldRequest{Name: args[0], Field: *field, Version: *version})
+		if err != nil {
+			return err
+		}
+		if *raw {
+			_, err = fmt.Fprintln(a.out, secretField.Value)
+			return err
+		}
+		return a.print(secretField)
+	}
+	secret, err := a.client.GetSecret(ctx, &server.GetSecretRequest{Name: args[0], Version: *version})
+	if err != nil {
+		return err
+	}
+	if *raw {
+		if secret.Fields != nil {
+			return fmt.Errorf("Secret %s has fields. Use -field to print one of them", secret.Name)
+		}
+		_, err = fmt.Fprintln(a.out, secret.Value)
+		return err
+	}
+	return a.print(secret)
+}
+
+func runSecretCreate(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("secret create")
+	value := fs.String("value", "", "Secret value")
+	valueFile := fs.String("value-file", "", "File to read the secret value from, or - for stdin")
+	fields := make(keyValueFlag)
+	fs.Var(fields, "field", "Field of a multi-field secret, as KEY=VALUE. May be repeated.")
+	generate := fs.String("generate", "", "Secret policy to generate the value from. See secret policies.")
+	description := fs.String("description", "", "Secret description")
+	labels := make(keyValueFlag)
+	fs.Var(labels, "label", "Label, as KEY=VALUE. May be repeated.")
+	expiresAt := fs.String("expires-at", "", "Time after which the secret can no longer be read, in RFC3339")
+	args, err := parseArgs(fs, args, secretCreateUsage, 1)
+	if err != nil {
+		return err
+	}
+	secretValue, secretFields, err := readSecretInput(*value, *valueFile, fields, *generate)
+	if err != nil {
+		return err
+	}
+	req := &server.CreateSecretRequest{
+		Name:        args[0],
+		Value:       secretValue,
+		Fields:      secretFields,
+		Generate:    *generate,
+		Description: *description,
+		Labels:      labels,
+	}
+	if *expiresAt != "" {
+		expiry, err := time.Parse(time.RFC3339, *expiresAt)
+		if err != nil {
+			return fmt.Errorf("Expected -expires-at in RFC3339. Got %s", *expiresAt)
+		}
+		req.ExpiresAt = &expiry
+	}
+	secret, err := a.client.CreateSecret(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.print(secret)
+}
+
+func runSecretUpdate(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("secret update")
+	value := fs.String("value", "", "Secret value")
+	valueFile := fs.String("value-file", "", "File to read the secret value from, or - for stdin")
+	fields := make(keyValueFlag)
+	fs.Var(fields, "field", "Field of a multi-field secret, as KEY=VALUE. May be repeated.")
+	generate := fs.String("generate", "", "Secret policy to generate the value from. See secret policies.")
+	args, err := parseArgs(fs, args, secretUpdateUsage, 1)
+	if err != nil {
+		return err
+	}
+	secretValue, secretFields, err := readSecretInput(*value, *valueFile, fields, *generate)
+	if err != nil {
+		return err
+	}
+	secret, err := a.client.UpdateSecret(ctx, &server.UpdateSecretRequest{
+		Name:     args[0],
+		Value:    secretValue,
+		Fields:   secretFields,
+		Generate: *generate,
+	})
+	if err != nil {
+		return err
+	}
+	return a.print(secret)
+}
+
+func runSecretPolicies(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("secret policies")
+	args, err := parseArgs(fs, args, "secret policies", 0)
+	if err != nil {
+		return err
+	}
+	policies, err := a.client.ListSecretPolicies(ctx)
+	if err != nil {
+		return err
+	}
+	return a.print(policies)
+}
+
+func runSecretLabels(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("secret labels")
+	labels := make(keyValueFlag)
+	fs.Var(labels, "label", "Label, as KEY=VALUE. May be repeated.")
+	args, err := parseArgs(fs, args, "secret labels [-label KEY=VALUE...] NAME", 1)
+	if err != nil {
+		return err
+	}
+	err = a.client.UpdateSecretLabels(ctx, &server.UpdateSecretLabelsRequest{Name: args[0], Labels: labels})
+	if err != nil {
+		return err
+	}
+	return a.done("Updated labels on %s", args[0])
+}
+
+func runSecretVersions(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("secret versions")
+	args, err := parseArgs(fs, args, "secret versions NAME", 1)
+	if err != nil {
+		return err
+	}
+	versions, err := a.client.ListSecretVersions(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.print(versions)
+}
+
+func runSecretRollback(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("secret rollback")
+	version := fs.Int("version", 0, "Version to make current")
+	args, err := parseArgs(fs, args, "secret rollback -version N NAME", 1)
+	if err != nil {
+		return err
+	}
+	err = a.client.RollbackSecret(ctx, &server.RollbackSecretRequest{Name: args[0], Version: *version})
+	if err != nil {
+		return err
+	}
+	return a.done("Rolled back %s to version %d", args[0], *version)
+}
+
+func runSecretRekey(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("secret rekey")
+	args, err := parseArgs(fs, args, "secret rekey NAME", 1)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.RekeySecret(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.done("Rekeyed %d versions of %s", resp.Rekeyed, resp.Name)
+}
+
+func runSecretDelete(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("secret delete")
+	args, err := parseArgs(fs, args, "secret delete NAME", 1)
+	if err != nil {
+		return err
+	}
+	err = a.client.DeleteSecret(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.done("Deleted %s", args[0])
+}
+
+func runSecretDeleted(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("secret deleted")
+	req := &server.PaginationRequest{}
+	paginationFlags(fs, "secrets", &req.PageSize, &req.Offset, &req.Cursor, &req.IncludeTotal)
+	_, err := parseArgs(fs, args, "secret deleted "+paginationUsage, 0)
+	if err != nil {
+		return err
+	}
+	page, err := a.client.ListDeletedSecrets(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.printPage(page, page.Items, page.PageInfo)
+}
+
+func runSecretRestore(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("secret restore")
+	name := fs.String("name", "", "New name, if an active secret has taken the old one")
+	args, err := parseArgs(fs, args, "secret restore [-name NAME] ID", 1)
+	if err != nil {
+		return err
+	}
+	restored, err := a.client.RestoreSecret(ctx, &server.RestoreRequest{Id: args[0], Name: *name})
+	if err != nil {
+		return err
+	}
+	return a.print(restored)
+}
+
+func runKeyRewrap(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("key rewrap")
+	args, err := parseArgs(fs, args, "key rewrap", 0)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.RewrapSecrets(ctx)
+	if err != nil {
+		return err
+	}
+	return a.print(resp)
+}
+
+func runKeyRekeyStatus(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("key rekey-status")
+	args, err := parseArgs(fs, args, "key rekey-status", 0)
+	if err != nil {
+		return err
+	}
+	status, err := a.client.GetSecretRekeyStatus(ctx)
+	if err != nil {
+		return err
+	}
+	return a.print(status)
+}
+
+func runKeyPurge(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("key purge")
+	secretId := fs.String("secret-id", "", "Id of a deleted secret to purge now, whenever it was deleted")
+	_, err := parseArgs(fs, args, "key purge [-secret-id ID]", 0)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.PurgeSecrets(ctx, &server.PurgeSecretsRequest{SecretId: *secretId})
+	if err != nil {
+		return err
+	}
+	return a.done("Purged %d secrets", resp.Purged)
+}
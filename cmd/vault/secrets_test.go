@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestKeyValueFlag(t *testing.T) {
+	a := &app{format: "table"}
+	fs := a.flagSet("test")
+	fields := make(keyValueFlag)
+	fs.Var(fields, "field", "")
+	_, err := parseArgs(fs, []string{"-field", "username=admin", "-field", "password=a=b", "name"}, "test", 1)
+	require.Nil(t, err, "error in parseArgs: %v", err)
+	require.Equal(t, fields, keyValueFlag{"username": "admin", "password": "a=b"})
+	require.Equal(t, fields.String(), "password=a=b,username=admin")
+}
+
+func TestKeyValueFlagErrors(t *testing.T) {
+	var tests = [][]string{
+		{"-field", "username", "name"},
+		{"-field", "=admin", "name"},
+		{"-field", "username=admin", "-field", "username=other", "name"},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("keyValueFlag - Errors - %v", idx), func(t *testing.T) {
+			a := &app{format: "table"}
+			fs := a.flagSet("test")
+			fs.SetOutput(ioutil.Discard)
+			fs.Var(make(keyValueFlag), "field", "")
+			_, err := parseArgs(fs, given, "test", 1)
+			require.NotNil(t, err, "no error in parseArgs: %v", err)
+		})
+	}
+}
+
+func TestStringsFlag(t *testing.T) {
+	a := &app{format: "table"}
+	fs := a.flagSet("test")
+	var labels stringsFlag
+	fs.Var(&labels, "label", "")
+	_, err := parseArgs(fs, []string{"-label", "env=prod", "-label", "!deprecated"}, "test", 0)
+	require.Nil(t, err, "error in parseArgs: %v", err)
+	require.Equal(t, labels, stringsFlag{"env=prod", "!deprecated"})
+	require.Equal(t, labels.String(), "env=prod,!deprecated")
+}
+
+func TestReadSecretInput(t *testing.T) {
+	value, fields, err := readSecretInput("", "", keyValueFlag{"username": "admin"}, "")
+	require.Nil(t, err, "error in readSecretInput: %v", err)
+	require.Equal(t, value, "")
+	require.Equal(t, fields, map[string]string{"username": "admin"})
+
+	value, fields, err = readSecretInput("value", "", keyValueFlag{}, "")
+	require.Nil(t, err, "error in readSecretInput: %v", err)
+	require.Equal(t, value, "value")
+	require.Nil(t, fields)
+
+	_, _, err = readSecretInput("value", "", keyValueFlag{"username": "admin"}, "")
+	require.NotNil(t, err, "no error in readSecretInput with both value and fields")
+
+	value, fields, err = readSecretInput("", "", keyValueFlag{}, "password")
+	require.Nil(t, err, "error in readSecretInput: %v", err)
+	require.Equal(t, value, "")
+	require.Nil(t, fields)
+
+	_, _, err = readSecretInput("value", "", keyValueFlag{}, "password")
+	require.NotNil(t, err, "no error in readSecretInput with both value and generate")
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"fmt"
+)
+
+var tokenCommand = &command{
+	name:    "token",
+	usage:   "token",
+	summary: "Print a valid access token, fetching a new one if the cached one has expired",
+	run: func(ctx context.Context, a *app, args []string) error {
+		fs := a.flagSet("token")
+		_, err := parseArgs(fs, args, "token", 0)
+		if err != nil {
+			return err
+		}
+		token, err := a.client.AccessToken(ctx)
+		if err != nil {
+			return err
+		}
+		return a.print(token)
+	},
+}
+
+var versionCommand = &command{
+	name:    "version",
+	usage:   "version",
+	summary: "Print the API server version",
+	run: func(ctx context.Context, a *app, args []string) error {
+		fs := a.flagSet("version")
+		_, err := parseArgs(fs, args, "version", 0)
+		if err != nil {
+			return err
+		}
+		version, err := a.client.Version(ctx)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(a.out, version)
+		return err
+	},
+}
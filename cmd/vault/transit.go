@@ -0,0 +1,395 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/server"
+)
+
+var transitCommand = &command{
+	name: "transit",
+	subcommands: []*command{
+		{
+			name:    "ls",
+			usage:   "transit ls " + paginationUsage,
+			summary: "List transit keys (admin only)",
+			run:     runTransitList,
+		},
+		{
+			name:    "get",
+			usage:   "transit get NAME",
+			summary: "Fetch a transit key (admin only)",
+			run:     runTransitGet,
+		},
+		{
+			name:    "create",
+			usage:   "transit create [-type TYPE] NAME",
+			summary: "Create a transit key (admin only). Its key material never leaves the server.",
+			run:     runTransitCreate,
+		},
+		{
+			name:    "rotate",
+			usage:   "transit rotate NAME",
+			summary: "Add a new version to a transit key (admin only). Older versions can still decrypt.",
+			run:     runTransitRotate,
+		},
+		{
+			name:    "delete",
+			usage:   "transit delete NAME",
+			summary: "Delete a transit key (admin only). Its ciphertext can no longer be decrypted.",
+			run:     runTransitDelete,
+		},
+		{
+			name:    "grant",
+			usage:   "transit grant (-user ID | -group ID) NAME",
+			summary: "Allow a user or group to encrypt and decrypt with a transit key (admin only)",
+			run: func(ctx context.Context, a *app, args []string) error {
+				return runTransitPermission(ctx, a, "grant", args)
+			},
+		},
+		{
+			name:    "revoke",
+			usage:   "transit revoke (-user ID | -group ID) NAME",
+			summary: "Stop a user or group using a transit key (admin only)",
+			run: func(ctx context.Context, a *app, args []string) error {
+				return runTransitPermission(ctx, a, "revoke", args)
+			},
+		},
+		{
+			name:    "encrypt",
+			usage:   "transit encrypt (-plaintext TEXT | -plaintext-file PATH) NAME",
+			summary: "Encrypt data with the latest version of a transit key",
+			run:     runTransitEncrypt,
+		},
+		{
+			name:    "decrypt",
+			usage:   "transit decrypt -ciphertext CIPHERTEXT [-out PATH] NAME",
+			summary: "Decrypt transit ciphertext, writing the plaintext to stdout or a file",
+			run:     runTransitDecrypt,
+		},
+		{
+			name:    "rewrap",
+			usage:   "transit rewrap -ciphertext CIPHERTEXT NAME",
+			summary: "Re-encrypt transit ciphertext with the latest version of its key",
+			run:     runTransitRewrap,
+		},
+		{
+			name:    "datakey",
+			usage:   "transit datakey [-bits 128|256] [-wrapped-only] NAME",
+			summary: "Generate a data key for client side encryption, wrapped by a transit key",
+			run:     runTransitDataKey,
+		},
+		{
+			name:    "sign",
+			usage:   "transit sign (-input TEXT | -input-file PATH) [-prehash] NAME",
+			summary: "Sign data with the latest version of a signing key",
+			run:     runTransitSign,
+		},
+		{
+			name:    "verify",
+			usage:   "transit verify (-input TEXT | -input-file PATH) -signature SIGNATURE [-prehash] NAME",
+			summary: "Verify a signature from transit sign. Exits with an error if it's not valid.",
+			run:     runTransitVerify,
+		},
+		{
+			name:    "public-key",
+			usage:   "transit public-key [-version N] NAME",
+			summary: "Print the PEM public key of the latest version of a signing key, or of version N",
+			run:     runTransitPublicKey,
+		},
+	},
+}
+
+// readTransitInput returns the bytes of -{flag}, or the contents of -{flag}-file, which is stdin if it's "-". File
+// contents are used as they are, so binary files round trip.
+func readTransitInput(flag, value, file string) ([]byte, error) {
+	if (value == "") == (file == "") {
+		return nil, fmt.Errorf("Expected either -%s or -%[1]s-file", flag)
+	}
+	if value != "" {
+		return []byte(value), nil
+	}
+	if file == "-" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(file)
+}
+
+// transitSignInput returns the base64 input of a sign or verify request. With prehash, the data is hashed here and
+// only its SHA-256 digest is sent, so artifacts of any size can be signed.
+func transitSignInput(data []byte, prehash bool) string {
+	if prehash {
+		digest := sha256.Sum256(data)
+		data = digest[:]
+	}
+	return base64.StdEncoding.EncodeToString(data)
+}
+
+// selectTransitPublicKey returns version of the public keys, or the latest version if it's 0
+func selectTransitPublicKey(keys *server.TransitPublicKeysResponse, version int) (*common.TransitKeyVersion, error) {
+	if len(keys.Keys) == 0 {
+		return nil, fmt.Errorf("Transit key %s has no versions", keys.Name)
+	}
+	if version == 0 {
+		return keys.Keys[len(keys.Keys)-1], nil
+	}
+	for _, key := range keys.Keys {
+		if key.Version == version {
+			return key, nil
+		}
+	}
+	return nil, fmt.Errorf("Transit key %s has no version %d", keys.Name, version)
+}
+
+func runTransitList(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("transit ls")
+	req := &server.PaginationRequest{}
+	paginationFlags(fs, "keys", &req.PageSize, &req.Offset, &req.Cursor, &req.IncludeTotal)
+	_, err := parseArgs(fs, args, "transit ls "+paginationUsage, 0)
+	if err != nil {
+		return err
+	}
+	page, err := a.client.ListTransitKeys(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.printPage(page, page.Items, page.PageInfo)
+}
+
+func runTransitGet(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("transit get")
+	args, err := parseArgs(fs, args, "transit get NAME", 1)
+	if err != nil {
+		return err
+	}
+	key, err := a.client.GetTransitKey(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.print(key)
+}
+
+func runTransitCreate(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("transit create")
+	keyType := fs.String("type", "", "Key type: "+strings.Join(common.TRANSIT_KEY_TYPES, ", ")+". Defaults to aes256-gcm.")
+	args, err := parseArgs(fs, args, "transit create [-type TYPE] NAME", 1)
+	if err != nil {
+		return err
+	}
+	key, err := a.client.CreateTransitKey(ctx, &server.CreateTransitKeyRequest{Name: args[0], Type: *keyType})
+	if err != nil {
+		return err
+	}
+	return a.print(key)
+}
+
+func runTransitRotate(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("transit rotate")
+	args, err := parseArgs(fs, args, "transit rotate NAME", 1)
+	if err != nil {
+		return err
+	}
+	key, err := a.client.RotateTransitKey(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.print(key)
+}
+
+func runTransitDelete(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("transit delete")
+	args, err := parseArgs(fs, args, "transit delete NAME", 1)
+	if err != nil {
+		return err
+	}
+	err = a.client.DeleteTransitKey(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.done("Deleted transit key %s", args[0])
+}
+
+func runTransitPermission(ctx context.Context, a *app, action string, args []string) error {
+	usage := "transit " + action + " (-user ID | -group ID) NAME"
+	fs := a.flagSet("transit " + action)
+	userId := fs.String("user", "", "User id")
+	userGroupId := fs.String("group", "", "User group id")
+	args, err := parseArgs(fs, args, usage, 1)
+	if err != nil {
+		return err
+	}
+	if (*userId == "") == (*userGroupId == "") {
+		return fmt.Errorf("Expected either -user or -group. Usage: vault %s", usage)
+	}
+	req := &server.TransitKeyPermissionRequest{KeyName: args[0], UserId: *userId, UserGroupId: *userGroupId}
+	if action == "grant" {
+		err = a.client.GrantTransitKeyPermission(ctx, req)
+	} else {
+		err = a.client.RevokeTransitKeyPermission(ctx, req)
+	}
+	if err != nil {
+		return err
+	}
+	return a.done("%s on transit key %s: done", action, args[0])
+}
+
+func runTransitEncrypt(ctx context.Context, a *app, args []string) error {
+	usage := "transit encrypt (-plaintext TEXT | -plaintext-file PATH) NAME"
+	fs := a.flagSet("transit encrypt")
+	plaintext := fs.String("plaintext", "", "Text to encrypt")
+	plaintextFile := fs.String("plaintext-file", "", "File to encrypt, or - for stdin")
+	args, err := parseArgs(fs, args, usage, 1)
+	if err != nil {
+		return err
+	}
+	data, err := readTransitInput("plaintext", *plaintext, *plaintextFile)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.TransitEncrypt(ctx, &server.TransitEncryptRequest{KeyName: args[0], Plaintext: base64.StdEncoding.EncodeToString(data)})
+	if err != nil {
+		return err
+	}
+	return a.print(resp)
+}
+
+func runTransitDecrypt(ctx context.Context, a *app, args []string) error {
+	usage := "transit decrypt -ciphertext CIPHERTEXT [-out PATH] NAME"
+	fs := a.flagSet("transit decrypt")
+	ciphertext := fs.String("ciphertext", "", "Ciphertext returned by transit encrypt")
+	out := fs.String("out", "", "File to write the plaintext to, created with mode 0600. Defaults to stdout.")
+	args, err := parseArgs(fs, args, usage, 1)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.TransitDecrypt(ctx, &server.TransitCiphertextRequest{KeyName: args[0], Ciphertext: *ciphertext})
+	if err != nil {
+		return err
+	}
+	data, err := base64.StdEncoding.DecodeString(resp.Plaintext)
+	if err != nil {
+		return err
+	}
+	if *out == "" {
+		_, err = a.out.Write(data)
+		return err
+	}
+	err = ioutil.WriteFile(*out, data, 0600)
+	if err != nil {
+		return err
+	}
+	return a.done("Wrote plaintext to %s", *out)
+}
+
+func runTransitRewrap(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("transit rewrap")
+	ciphertext := fs.String("ciphertext", "", "Ciphertext returned by transit encrypt")
+	args, err := parseArgs(fs, args, "transit rewrap -ciphertext CIPHERTEXT NAME", 1)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.TransitRewrap(ctx, &server.TransitCiphertextRequest{KeyName: args[0], Ciphertext: *ciphertext})
+	if err != nil {
+		return err
+	}
+	return a.print(resp)
+}
+
+func runTransitDataKey(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("transit datakey")
+	bits := fs.Int("bits", 0, "Size of the data key, 128 or 256. Defaults to 256.")
+	wrappedOnly := fs.Bool("wrapped-only", false, "Only return the wrapped data key")
+	args, err := parseArgs(fs, args, "transit datakey [-bits 128|256] [-wrapped-only] NAME", 1)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.TransitDataKey(ctx, &server.TransitDataKeyRequest{KeyName: args[0], Bits: *bits, WrappedOnly: *wrappedOnly})
+	if err != nil {
+		return err
+	}
+	return a.print(resp)
+}
+
+func runTransitSign(ctx context.Context, a *app, args []string) error {
+	usage := "transit sign (-input TEXT | -input-file PATH) [-prehash] NAME"
+	fs := a.flagSet("transit sign")
+	input := fs.String("input", "", "Text to sign")
+	inputFile := fs.String("input-file", "", "File to sign, or - for stdin")
+	prehash := fs.Bool("prehash", false, "Send only the SHA-256 digest of the input. Needed for inputs over 64KiB. Not supported by ed25519 or hmac keys.")
+	args, err := parseArgs(fs, args, usage, 1)
+	if err != nil {
+		return err
+	}
+	data, err := readTransitInput("input", *input, *inputFile)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.TransitSign(ctx, &server.TransitSignRequest{KeyName: args[0], Input: transitSignInput(data, *prehash), Prehashed: *prehash})
+	if err != nil {
+		return err
+	}
+	return a.print(resp)
+}
+
+func runTransitVerify(ctx context.Context, a *app, args []string) error {
+	usage := "transit verify (-input TEXT | -input-file PATH) -signature SIGNATURE [-prehash] NAME"
+	fs := a.flagSet("transit verify")
+	input := fs.String("input", "", "Text that was signed")
+	inputFile := fs.String("input-file", "", "File that was signed, or - for stdin")
+	signature := fs.String("signature", "", "Signature returned by transit sign")
+	prehash := fs.Bool("prehash", false, "Send only the SHA-256 digest of the input, as it was signed")
+	args, err := parseArgs(fs, args, usage, 1)
+	if err != nil {
+		return err
+	}
+	data, err := readTransitInput("input", *input, *inputFile)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.TransitVerify(ctx, &server.TransitSignRequest{
+		KeyName:   args[0],
+		Input:     transitSignInput(data, *prehash),
+		Prehashed: *prehash,
+		Signature: *signature,
+	})
+	if err != nil {
+		return err
+	}
+	err = a.print(resp)
+	if err != nil {
+		return err
+	}
+	if !resp.Valid {
+		return fmt.Errorf("Signature is not valid")
+	}
+	return nil
+}
+
+func runTransitPublicKey(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("transit public-key")
+	version := fs.Int("version", 0, "Version to print. Defaults to the latest version.")
+	args, err := parseArgs(fs, args, "transit public-key [-version N] NAME", 1)
+	if err != nil {
+		return err
+	}
+	keys, err := a.client.GetTransitPublicKeys(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	if a.format == "json" {
+		return a.print(keys)
+	}
+	key, err := selectTransitPublicKey(keys, *version)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprint(a.out, key.PublicKey)
+	return err
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/server"
+)
+
+func TestReadTransitInput(t *testing.T) {
+	data, err := readTransitInput("plaintext", "secret", "")
+	require.Nil(t, err, "error in readTransitInput: %v", err)
+	require.Equal(t, data, []byte("secret"))
+
+	path := filepath.Join(t.TempDir(), "plaintext")
+	err = ioutil.WriteFile(path, []byte{0x00, 0xff, 0x0a}, 0600)
+	require.Nil(t, err, "error writing file: %v", err)
+	data, err = readTransitInput("plaintext", "", path)
+	require.Nil(t, err, "error in readTransitInput: %v", err)
+	require.Equal(t, data, []byte{0x00, 0xff, 0x0a})
+
+	_, err = readTransitInput("input", "", "")
+	require.EqualError(t, err, "Expected either -input or -input-file")
+	_, err = readTransitInput("plaintext", "secret", path)
+	require.NotNil(t, err, "no error in readTransitInput with both plaintext and a file")
+}
+
+func TestTransitSignInput(t *testing.T) {
+	require.Equal(t, transitSignInput([]byte("artifact"), false), base64.StdEncoding.EncodeToString([]byte("artifact")))
+	digest := sha256.Sum256([]byte("artifact"))
+	require.Equal(t, transitSignInput([]byte("artifact"), true), base64.StdEncoding.EncodeToString(digest[:]))
+}
+
+func TestSelectTransitPublicKey(t *testing.T) {
+	keys := &server.TransitPublicKeysResponse{
+		Name: "releases",
+		Keys: []*common.TransitKeyVersion{
+			{Version: 1, PublicKey: "pem1"},
+			{Version: 2, PublicKey: "pem2"},
+		},
+	}
+	key, err := selectTransitPublicKey(keys, 0)
+	require.Nil(t, err, "error in selectTransitPublicKey: %v", err)
+	require.Equal(t, key.PublicKey, "pem2")
+
+	key, err = selectTransitPublicKey(keys, 1)
+	require.Nil(t, err, "error in selectTransitPublicKey: %v", err)
+	require.Equal(t, key.PublicKey, "pem1")
+
+	_, err = selectTransitPublicKey(keys, 3)
+	require.NotNil(t, err, "no error in selectTransitPublicKey for a missing version")
+}
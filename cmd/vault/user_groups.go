@@ -0,0 +1,192 @@
+package main
+
+import (
+	"context"
+
+	"github.com/emarcey/data-vault/server"
+)
+
+var groupCommand = &command{
+	name: "group",
+	subcommands: []*command{
+		{
+			name:    "ls",
+			usage:   "group ls " + paginationUsage,
+			summary: "List user groups",
+			run:     runGroupList,
+		},
+		{
+			name:    "get",
+			usage:   "group get ID",
+			summary: "Fetch a user group (admin only)",
+			run:     runGroupGet,
+		},
+		{
+			name:    "members",
+			usage:   "group members " + paginationUsage + " ID",
+			summary: "List the users in a group",
+			run:     runGroupMembers,
+		},
+		{
+			name:    "create",
+			usage:   "group create NAME",
+			summary: "Create a user group (admin only)",
+			run:     runGroupCreate,
+		},
+		{
+			name:    "delete",
+			usage:   "group delete ID",
+			summary: "Delete a user group (admin only)",
+			run:     runGroupDelete,
+		},
+		{
+			name:    "deleted",
+			usage:   "group deleted " + paginationUsage,
+			summary: "List deleted groups (admin only)",
+			run:     runGroupDeleted,
+		},
+		{
+			name:    "restore",
+			usage:   "group restore [-name NAME] ID",
+			summary: "Restore a deleted group, optionally under a new name (admin only)",
+			run:     runGroupRestore,
+		},
+		{
+			name:    "add",
+			usage:   "group add ID USER_ID",
+			summary: "Add a user to a group (admin only)",
+			run:     runGroupAdd,
+		},
+		{
+			name:    "remove",
+			usage:   "group remove ID USER_ID",
+			summary: "Remove a user from a group (admin only)",
+			run:     runGroupRemove,
+		},
+	},
+}
+
+func runGroupList(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("group ls")
+	req := &server.PaginationRequest{}
+	paginationFlags(fs, "groups", &req.PageSize, &req.Offset, &req.Cursor, &req.IncludeTotal)
+	_, err := parseArgs(fs, args, "group ls "+paginationUsage, 0)
+	if err != nil {
+		return err
+	}
+	page, err := a.client.ListUserGroups(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.printPage(page, page.Items, page.PageInfo)
+}
+
+func runGroupGet(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("group get")
+	args, err := parseArgs(fs, args, "group get ID", 1)
+	if err != nil {
+		return err
+	}
+	userGroup, err := a.client.GetUserGroup(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.print(userGroup)
+}
+
+func runGroupMembers(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("group members")
+	req := &server.ListUsersInGroupRequest{}
+	paginationFlags(fs, "users", &req.PageSize, &req.Offset, &req.Cursor, &req.IncludeTotal)
+	args, err := parseArgs(fs, args, "group members "+paginationUsage+" ID", 1)
+	if err != nil {
+		return err
+	}
+	req.UserGroupId = args[0]
+	page, err := a.client.ListUsersInGroup(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.printPage(page, page.Items, page.PageInfo)
+}
+
+func runGroupCreate(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("group create")
+	args, err := parseArgs(fs, args, "group create NAME", 1)
+	if err != nil {
+		return err
+	}
+	userGroup, err := a.client.CreateUserGroup(ctx, &server.CreateUserGroupRequest{Name: args[0]})
+	if err != nil {
+		return err
+	}
+	return a.print(userGroup)
+}
+
+func runGroupDelete(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("group delete")
+	args, err := parseArgs(fs, args, "group delete ID", 1)
+	if err != nil {
+		return err
+	}
+	err = a.client.DeleteUserGroup(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.done("Deleted group %s", args[0])
+}
+
+func runGroupDeleted(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("group deleted")
+	req := &server.PaginationRequest{}
+	paginationFlags(fs, "groups", &req.PageSize, &req.Offset, &req.Cursor, &req.IncludeTotal)
+	_, err := parseArgs(fs, args, "group deleted "+paginationUsage, 0)
+	if err != nil {
+		return err
+	}
+	page, err := a.client.ListDeletedUserGroups(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.printPage(page, page.Items, page.PageInfo)
+}
+
+func runGroupRestore(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("group restore")
+	name := fs.String("name", "", "New name, if an active group has taken the old one")
+	args, err := parseArgs(fs, args, "group restore [-name NAME] ID", 1)
+	if err != nil {
+		return err
+	}
+	restored, err := a.client.RestoreUserGroup(ctx, &server.RestoreRequest{Id: args[0], Name: *name})
+	if err != nil {
+		return err
+	}
+	return a.print(restored)
+}
+
+func runGroupAdd(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("group add")
+	args, err := parseArgs(fs, args, "group add ID USER_ID", 2)
+	if err != nil {
+		return err
+	}
+	err = a.client.AddUserToGroup(ctx, &server.UserGroupMemberRequest{UserGroupId: args[0], UserId: args[1]})
+	if err != nil {
+		return err
+	}
+	return a.done("Added user %s to group %s", args[1], args[0])
+}
+
+func runGroupRemove(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("group remove")
+	args, err := parseArgs(fs, args, "group remove ID USER_ID", 2)
+	if err != nil {
+		return err
+	}
+	err = a.client.RemoveUserFromGroup(ctx, &server.UserGroupMemberRequest{UserGroupId: args[0], UserId: args[1]})
+	if err != nil {
+		return err
+	}
+	return a.done("Removed user %s from group %s", args[1], args[0])
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+
+	"github.com/emarcey/data-vault/server"
+)
+
+var userCommand = &command{
+	name: "user",
+	subcommands: []*command{
+		{
+			name:    "ls",
+			usage:   "user ls " + paginationUsage,
+			summary: "List users",
+			run:     runUserList,
+		},
+		{
+			name:    "get",
+			usage:   "user get ID",
+			summary: "Fetch a user (admin only)",
+			run:     runUserGet,
+		},
+		{
+			name:    "create",
+			usage:   "user create [-type developer|admin] NAME",
+			summary: "Create a user and print its client secret (admin only)",
+			run:     runUserCreate,
+		},
+		{
+			name:    "delete",
+			usage:   "user delete ID",
+			summary: "Delete a user (admin only)",
+			run:     runUserDelete,
+		},
+		{
+			name:    "deleted",
+			usage:   "user deleted " + paginationUsage,
+			summary: "List deleted users (admin only)",
+			run:     runUserDeleted,
+		},
+		{
+			name:    "restore",
+			usage:   "user restore [-name NAME] ID",
+			summary: "Restore a deleted user, optionally under a new name, and print its new secret (admin only)",
+			run:     runUserRestore,
+		},
+		{
+			name:    "rotate",
+			usage:   "user rotate",
+			summary: "Rotate your client secret and print the new one",
+			run:     runUserRotate,
+		},
+	},
+}
+
+func runUserList(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("user ls")
+	req := &server.PaginationRequest{}
+	paginationFlags(fs, "users", &req.PageSize, &req.Offset, &req.Cursor, &req.IncludeTotal)
+	_, err := parseArgs(fs, args, "user ls "+paginationUsage, 0)
+	if err != nil {
+		return err
+	}
+	page, err := a.client.ListUsers(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.printPage(page, page.Items, page.PageInfo)
+}
+
+func runUserGet(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("user get")
+	args, err := parseArgs(fs, args, "user get ID", 1)
+	if err != nil {
+		return err
+	}
+	user, err := a.client.GetUser(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.print(user)
+}
+
+func runUserCreate(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("user create")
+	userType := fs.String("type", "developer", "User type: developer or admin")
+	args, err := parseArgs(fs, args, "user create [-type developer|admin] NAME", 1)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.CreateUser(ctx, &server.CreateUserRequest{Name: args[0], Type: *userType})
+	if err != nil {
+		return err
+	}
+	return a.print(resp)
+}
+
+func runUserDelete(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("user delete")
+	args, err := parseArgs(fs, args, "user delete ID", 1)
+	if err != nil {
+		return err
+	}
+	err = a.client.DeleteUser(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	return a.done("Deleted user %s", args[0])
+}
+
+func runUserDeleted(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("user deleted")
+	req := &server.PaginationRequest{}
+	paginationFlags(fs, "users", &req.PageSize, &req.Offset, &req.Cursor, &req.IncludeTotal)
+	_, err := parseArgs(fs, args, "user deleted "+paginationUsage, 0)
+	if err != nil {
+		return err
+	}
+	page, err := a.client.ListDeletedUsers(ctx, req)
+	if err != nil {
+		return err
+	}
+	return a.printPage(page, page.Items, page.PageInfo)
+}
+
+func runUserRestore(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("user restore")
+	name := fs.String("name", "", "New name, if an active user has taken the old one")
+	args, err := parseArgs(fs, args, "user restore [-name NAME] ID", 1)
+	if err != nil {
+		return err
+	}
+	restored, err := a.client.RestoreUser(ctx, &server.RestoreRequest{Id: args[0], Name: *name})
+	if err != nil {
+		return err
+	}
+	return a.print(restored)
+}
+
+func runUserRotate(ctx context.Context, a *app, args []string) error {
+	fs := a.flagSet("user rotate")
+	_, err := parseArgs(fs, args, "user rotate", 0)
+	if err != nil {
+		return err
+	}
+	resp, err := a.client.RotateUserSecret(ctx)
+	if err != nil {
+		return err
+	}
+	return a.print(resp)
+}
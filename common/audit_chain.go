@@ -0,0 +1,80 @@
+package common
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// AccessLogChainBreak describes the first access log that does not follow from the one before it
+type AccessLogChainBreak struct {
+	Sequence int64  `json:"sequence"`
+	Reason   string `json:"reason"`
+}
+
+// ChainHash hashes the content of a log together with its sequence and the hash of the log before it. The hash is an
+// HMAC under key, which is kept out of the secrets datastore, so a log can't be rewritten with a matching hash by
+// someone who can only write to the datastore.
+func (l *AccessLog) ChainHash(key []byte) string {
+	// a JSON array keeps field boundaries unambiguous; marshalling strings and ints can't fail
+	content, _ := json.Marshal([]interface{}{
+		l.Sequence,
+		l.PrevHash,
+		l.UserId,
+		l.ActionType,
+		l.KeyName,
+		l.TargetType,
+		l.TargetId,
+		l.Outcome,
+		l.RequestId,
+		l.SourceIp,
+		l.UserAgent,
+		int64(l.AccessAt),
+	})
+	return HmacSha256(key, string(content))
+}
+
+// ChainAccessLog links log to prev, which is nil for the first log in the chain, hashing it under key
+func ChainAccessLog(key []byte, prev *AccessLog, log *AccessLog) {
+	log.Sequence = 1
+	log.PrevHash = ""
+	if prev != nil {
+		log.Sequence = prev.Sequence + 1
+		log.PrevHash = prev.Hash
+	}
+	log.Hash = log.ChainHash(key)
+}
+
+// VerifyAccessLogChain checks that logs, ordered by sequence, continue the chain from prev, which is nil at the start of
+// the chain, and were hashed under key. Returns the number of logs verified before the first break, and the break, if
+// any.
+func VerifyAccessLogChain(key []byte, prev *AccessLog, logs []*AccessLog) (int, *AccessLogChainBreak) {
+	expectedSequence := int64(1)
+	expectedPrevHash := ""
+	if prev != nil {
+		expectedSequence = prev.Sequence + 1
+		expectedPrevHash = prev.Hash
+	}
+	for idx, log := range logs {
+		if log.Sequence != expectedSequence {
+			return idx, &AccessLogChainBreak{
+				Sequence: expectedSequence,
+				Reason:   fmt.Sprintf("Expected sequence %d. Got %d", expectedSequence, log.Sequence),
+			}
+		}
+		if log.PrevHash != expectedPrevHash {
+			return idx, &AccessLogChainBreak{
+				Sequence: log.Sequence,
+				Reason:   "Previous hash does not match the hash of the log before it",
+			}
+		}
+		if log.Hash != log.ChainHash(key) {
+			return idx, &AccessLogChainBreak{
+				Sequence: log.Sequence,
+				Reason:   "Hash does not match the content of the log",
+			}
+		}
+		expectedSequence = log.Sequence + 1
+		expectedPrevHash = log.Hash
+	}
+	return len(logs), nil
+}
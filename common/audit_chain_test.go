@@ -0,0 +1,111 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+var testChainKey = []byte("chain key")
+
+func newTestAccessLogChain(length int) []*AccessLog {
+	logs := make([]*AccessLog, 0, length)
+	var prev *AccessLog
+	for idx := 0; idx < length; idx++ {
+		log := NewAccessLog("userId", "GetSecret", fmt.Sprintf("key%d", idx))
+		ChainAccessLog(testChainKey, prev, log)
+		logs = append(logs, log)
+		prev = log
+	}
+	return logs
+}
+
+func TestChainAccessLog(t *testing.T) {
+	logs := newTestAccessLogChain(2)
+
+	require.Equal(t, logs[0].Sequence, int64(1), "Expected first sequence 1, got: %v", logs[0].Sequence)
+	require.Equal(t, logs[0].PrevHash, "", "Expected empty first prev hash, got: %v", logs[0].PrevHash)
+	require.Equal(t, logs[1].Sequence, int64(2), "Expected second sequence 2, got: %v", logs[1].Sequence)
+	require.Equal(t, logs[1].PrevHash, logs[0].Hash, "Expected prev hash %v, got: %v", logs[0].Hash, logs[1].PrevHash)
+	require.Equal(t, logs[1].Hash, logs[1].ChainHash(testChainKey), "Expected hash %v, got: %v", logs[1].ChainHash(testChainKey), logs[1].Hash)
+}
+
+func TestVerifyAccessLogChainBreaks(t *testing.T) {
+	var tests = []struct {
+		testName         string
+		tamper           func(logs []*AccessLog) []*AccessLog
+		expectedVerified int
+		expectedSequence int64
+	}{
+		{
+			testName: "edited",
+			tamper: func(logs []*AccessLog) []*AccessLog {
+				logs[1].KeyName = "edited"
+				return logs
+			},
+			expectedVerified: 1,
+			expectedSequence: 2,
+		},
+		{
+			testName: "deleted",
+			tamper: func(logs []*AccessLog) []*AccessLog {
+				return append(logs[:1], logs[2:]...)
+			},
+			expectedVerified: 1,
+			expectedSequence: 2,
+		},
+		{
+			testName: "rehashed",
+			tamper: func(logs []*AccessLog) []*AccessLog {
+				logs[1].KeyName = "edited"
+				logs[1].Hash = logs[1].ChainHash(testChainKey)
+				return logs
+			},
+			expectedVerified: 2,
+			expectedSequence: 3,
+		},
+		{
+			testName: "rechained without the key",
+			tamper: func(logs []*AccessLog) []*AccessLog {
+				logs[1].KeyName = "edited"
+				ChainAccessLog([]byte("guessed key"), logs[0], logs[1])
+				ChainAccessLog([]byte("guessed key"), logs[1], logs[2])
+				return logs
+			},
+			expectedVerified: 1,
+			expectedSequence: 2,
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("VerifyAccessLogChain - Breaks - %v", given.testName), func(t *testing.T) {
+			logs := given.tamper(newTestAccessLogChain(3))
+			verified, chainBreak := VerifyAccessLogChain(testChainKey, nil, logs)
+			require.NotNil(t, chainBreak, "Expected a chain break")
+			require.Equal(t, verified, given.expectedVerified, "Verified %v did not equal expected %v", verified, given.expectedVerified)
+			require.Equal(t, chainBreak.Sequence, given.expectedSequence, "Break sequence %v did not equal expected %v", chainBreak.Sequence, given.expectedSequence)
+		})
+	}
+}
+
+func TestVerifyAccessLogChainSuccesses(t *testing.T) {
+	logs := newTestAccessLogChain(3)
+	var tests = []struct {
+		testName string
+		prev     *AccessLog
+		logs     []*AccessLog
+	}{
+		{testName: "empty", prev: nil, logs: []*AccessLog{}},
+		{testName: "full chain", prev: nil, logs: logs},
+		{testName: "continued chain", prev: logs[0], logs: logs[1:]},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("VerifyAccessLogChain - Successes - %v", given.testName), func(t *testing.T) {
+			verified, chainBreak := VerifyAccessLogChain(testChainKey, given.prev, given.logs)
+			require.Nil(t, chainBreak, "Unexpected chain break: %+v", chainBreak)
+			require.Equal(t, verified, len(given.logs), "Verified %v did not equal expected %v", verified, len(given.logs))
+		})
+	}
+}
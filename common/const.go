@@ -15,6 +15,11 @@ const (
 const HEADER_ACCESS_TOKEN = "Access-Token"
 const HEADER_CLIENT_ID = "Client-Id"
 const HEADER_CLIENT_SECRET = "Client-Secret"
+const HEADER_REQUEST_ID = "X-Request-Id"
+const HEADER_FORWARDED_FOR = "X-Forwarded-For"
+const HEADER_REAL_IP = "X-Real-Ip"
+const HEADER_USER_AGENT = "User-Agent"
+const HEADER_SECRET_VERSION = "X-Secret-Version"
 
 var HEADER_AUTH_REGEX = regexp.MustCompile(`^Bearer (.*)$`)
 
@@ -34,3 +39,176 @@ const KEY_SIZE = 32
 const DATE_FORMAT = "2006-01-02"
 
 var DEFAULT_START_TIME = time.Unix(0, 0)
+
+const (
+	PERMISSION_LEVEL_READ   = "read"
+	PERMISSION_LEVEL_WRITE  = "write"
+	PERMISSION_LEVEL_MANAGE = "manage"
+)
+
+// PERMISSION_LEVELS is ordered from least to most privileged. Each level includes the ones before it.
+var PERMISSION_LEVELS = []string{PERMISSION_LEVEL_READ, PERMISSION_LEVEL_WRITE, PERMISSION_LEVEL_MANAGE}
+
+const (
+	OUTCOME_ALLOWED   = "allowed"
+	OUTCOME_DENIED    = "denied"
+	OUTCOME_NOT_FOUND = "not_found"
+	OUTCOME_ERROR     = "error"
+)
+
+// SYSTEM_USER_ID is the user id of access logs written by background jobs, which act without a calling user
+const SYSTEM_USER_ID = "system"
+
+const (
+	TARGET_TYPE_SECRET         = "secret"
+	TARGET_TYPE_SECRET_PATTERN = "secret_pattern"
+	TARGET_TYPE_USER           = "user"
+	TARGET_TYPE_USER_GROUP     = "user_group"
+	TARGET_TYPE_KEY            = "key"
+	TARGET_TYPE_ENDPOINT       = "endpoint"
+	TARGET_TYPE_DATABASE_ROLE  = "database_role"
+	TARGET_TYPE_LEASE          = "lease"
+	TARGET_TYPE_TRANSIT_KEY    = "transit_key"
+	TARGET_TYPE_NAMESPACE      = "namespace"
+)
+
+const (
+	SECRET_VALUE_TYPE_STRING = "string"
+	SECRET_VALUE_TYPE_FIELDS = "fields"
+	SECRET_VALUE_TYPE_BINARY = "binary"
+)
+
+// MAX_SECRET_FIELDS caps the number of fields in a multi-field secret
+const MAX_SECRET_FIELDS = 100
+
+// MAX_SECRET_LABELS caps the number of labels on a secret
+const MAX_SECRET_LABELS = 50
+
+// MAX_LABEL_VALUE_LENGTH caps the length of a label value
+const MAX_LABEL_VALUE_LENGTH = 255
+
+const (
+	SECRET_SORT_NAME       = "name"
+	SECRET_SORT_CREATED_AT = "created_at"
+	SECRET_SORT_UPDATED_AT = "updated_at"
+)
+
+const (
+	SORT_ORDER_ASC  = "asc"
+	SORT_ORDER_DESC = "desc"
+)
+
+// DEFAULT_MAX_SECRET_FILE_BYTES is the size limit for file secrets when serverConfigs.maxSecretFileBytes isn't set
+const DEFAULT_MAX_SECRET_FILE_BYTES = 1 << 20
+
+// DEFAULT_FAILED_AUTH_LOGS_PER_MINUTE is how many failed authentications from each client IP are written to the access
+// log per minute when serverConfigs.failedAuthLogsPerMinute isn't set
+const DEFAULT_FAILED_AUTH_LOGS_PER_MINUTE = 10
+
+// DEFAULT_SECRET_CONTENT_TYPE is stored for file secrets uploaded without a content type
+const DEFAULT_SECRET_CONTENT_TYPE = "application/octet-stream"
+
+// DEFAULT_LEASE_TTL_SECONDS is the lease TTL of database roles created without a default_ttl_seconds
+const DEFAULT_LEASE_TTL_SECONDS = 60 * 60
+
+// DEFAULT_MAX_LEASE_TTL_SECONDS is the longest a lease can be renewed to for database roles created without a
+// max_ttl_seconds
+const DEFAULT_MAX_LEASE_TTL_SECONDS = 24 * 60 * 60
+
+// DATABASE_ROLE_NAME_REGEX matches database role names. Names are used in URLs and in generated usernames.
+var DATABASE_ROLE_NAME_REGEX = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,31}$`)
+
+// DEFAULT_RENEW_STATEMENTS extend the expiry of a Postgres role created with VALID UNTIL '{{expiration}}'
+var DEFAULT_RENEW_STATEMENTS = []string{`ALTER ROLE "{{name}}" VALID UNTIL '{{expiration}}';`}
+
+// DEFAULT_REVOCATION_STATEMENTS drop a Postgres role, along with its grants in the connection's database
+var DEFAULT_REVOCATION_STATEMENTS = []string{`DROP OWNED BY "{{name}}";`, `DROP ROLE IF EXISTS "{{name}}";`}
+
+const (
+	SECRET_POLICY_TYPE_PASSWORD     = "password"
+	SECRET_POLICY_TYPE_PASSPHRASE   = "passphrase"
+	SECRET_POLICY_TYPE_RANDOM_BYTES = "random_bytes"
+	SECRET_POLICY_TYPE_KEY_PAIR     = "key_pair"
+	SECRET_POLICY_TYPE_SSH_KEY      = "ssh_key"
+)
+
+const (
+	CHARACTER_CLASS_LOWERCASE = "lowercase"
+	CHARACTER_CLASS_UPPERCASE = "uppercase"
+	CHARACTER_CLASS_DIGITS    = "digits"
+	CHARACTER_CLASS_SYMBOLS   = "symbols"
+)
+
+// CHARACTER_CLASSES are the characters of each password character class. Symbols leave out quotes, backslashes and
+// spaces, so generated passwords can be pasted into shells and config files.
+var CHARACTER_CLASSES = map[string]string{
+	CHARACTER_CLASS_LOWERCASE: "abcdefghijklmnopqrstuvwxyz",
+	CHARACTER_CLASS_UPPERCASE: "ABCDEFGHIJKLMNOPQRSTUVWXYZ",
+	CHARACTER_CLASS_DIGITS:    "0123456789",
+	CHARACTER_CLASS_SYMBOLS:   "!#$%&*+-.:=?@^_~",
+}
+
+const (
+	KEY_ALGORITHM_RSA     = "rsa"
+	KEY_ALGORITHM_ECDSA   = "ecdsa"
+	KEY_ALGORITHM_ED25519 = "ed25519"
+)
+
+const (
+	ENCODING_HEX    = "hex"
+	ENCODING_BASE64 = "base64"
+)
+
+// MAX_GENERATED_SECRET_LENGTH caps the length of generated passwords and the number of generated random bytes
+const MAX_GENERATED_SECRET_LENGTH = 1024
+
+// MAX_PASSPHRASE_WORDS caps the number of words in a generated passphrase
+const MAX_PASSPHRASE_WORDS = 64
+
+// DEFAULT_NAMESPACE is the namespace of requests that don't name one. It holds every secret and user group created
+// before namespaces were added, and can't be deleted.
+const (
+	DEFAULT_NAMESPACE    = "default"
+	DEFAULT_NAMESPACE_ID = "00000000-0000-0000-0000-000000000000"
+)
+
+// NAMESPACE_NAME_REGEX matches namespace names, which are used in URLs and in the target ids of access logs
+var NAMESPACE_NAME_REGEX = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+// TRANSIT_KEY_NAME_REGEX matches transit key names, which are used in URLs
+var TRANSIT_KEY_NAME_REGEX = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,63}$`)
+
+const (
+	TRANSIT_KEY_TYPE_AES256_GCM   = "aes256-gcm"
+	TRANSIT_KEY_TYPE_ED25519      = "ed25519"
+	TRANSIT_KEY_TYPE_ECDSA_P256   = "ecdsa-p256"
+	TRANSIT_KEY_TYPE_RSA_PSS_2048 = "rsa-pss-2048"
+	TRANSIT_KEY_TYPE_RSA_PSS_4096 = "rsa-pss-4096"
+	TRANSIT_KEY_TYPE_HMAC_SHA256  = "hmac-sha256"
+)
+
+// TRANSIT_KEY_TYPES are the algorithms a transit key can use. aes256-gcm keys encrypt, and the rest sign.
+var TRANSIT_KEY_TYPES = []string{
+	TRANSIT_KEY_TYPE_AES256_GCM,
+	TRANSIT_KEY_TYPE_ED25519,
+	TRANSIT_KEY_TYPE_ECDSA_P256,
+	TRANSIT_KEY_TYPE_RSA_PSS_2048,
+	TRANSIT_KEY_TYPE_RSA_PSS_4096,
+	TRANSIT_KEY_TYPE_HMAC_SHA256,
+}
+
+const (
+	TRANSIT_KEY_USE_ENCRYPT = "encrypt"
+	TRANSIT_KEY_USE_SIGN    = "sign"
+	TRANSIT_KEY_USE_EXPORT  = "export a public key"
+)
+
+// TRANSIT_CIPHERTEXT_PREFIX starts every transit ciphertext and signature, and is followed by the key version and a
+// colon
+const TRANSIT_CIPHERTEXT_PREFIX = "vault:v"
+
+// MAX_TRANSIT_PLAINTEXT_BYTES caps the size of the data a transit key encrypts or signs in one request
+const MAX_TRANSIT_PLAINTEXT_BYTES = 64 * 1024
+
+// DEFAULT_TRANSIT_DATA_KEY_BITS is the size of the data keys generated by transit keys when no size is given
+const DEFAULT_TRANSIT_DATA_KEY_BITS = 256
@@ -5,8 +5,21 @@ import (
 	"net/http"
 )
 
-var HeadersContextKey struct{}
-var UserContextKey struct{}
+// contextKey gives each context value its own key. Untyped struct{} keys would all compare equal.
+type contextKey string
+
+var HeadersContextKey = contextKey("headers")
+var UserContextKey = contextKey("user")
+var RequestIdContextKey = contextKey("requestId")
+var RequestSourceContextKey = contextKey("requestSource")
+var NamespaceNameContextKey = contextKey("namespaceName")
+var NamespaceContextKey = contextKey("namespace")
+
+// RequestSource identifies where a request came from
+type RequestSource struct {
+	Ip        string
+	UserAgent string
+}
 
 func InjectHeaderIntoContext(ctx context.Context, r *http.Request) context.Context {
 	return context.WithValue(ctx, HeadersContextKey, r.Header)
@@ -54,3 +67,57 @@ func FetchUserFromContext(ctx context.Context) (*User, error) {
 
 	return user, nil
 }
+
+func InjectRequestIdIntoContext(ctx context.Context, requestId string) context.Context {
+	return context.WithValue(ctx, RequestIdContextKey, requestId)
+}
+
+// FetchRequestIdFromContext returns the id of the current request, or an empty string outside of a request
+func FetchRequestIdFromContext(ctx context.Context) string {
+	requestId, ok := ctx.Value(RequestIdContextKey).(string)
+	if !ok {
+		return ""
+	}
+	return requestId
+}
+
+func InjectRequestSourceIntoContext(ctx context.Context, source *RequestSource) context.Context {
+	return context.WithValue(ctx, RequestSourceContextKey, source)
+}
+
+// FetchRequestSourceFromContext returns the source of the current request, or an empty source outside of a request
+func FetchRequestSourceFromContext(ctx context.Context) *RequestSource {
+	source, ok := ctx.Value(RequestSourceContextKey).(*RequestSource)
+	if !ok || source == nil {
+		return &RequestSource{}
+	}
+	return source
+}
+
+// InjectNamespaceNameIntoContext records the namespace a request was made in, before it's been looked up
+func InjectNamespaceNameIntoContext(ctx context.Context, name string) context.Context {
+	return context.WithValue(ctx, NamespaceNameContextKey, name)
+}
+
+// FetchNamespaceNameFromContext returns the namespace a request was made in, or the default namespace if it didn't
+// name one
+func FetchNamespaceNameFromContext(ctx context.Context) string {
+	name, ok := ctx.Value(NamespaceNameContextKey).(string)
+	if !ok || name == "" {
+		return DEFAULT_NAMESPACE
+	}
+	return name
+}
+
+func InjectNamespaceIntoContext(ctx context.Context, namespace *Namespace) context.Context {
+	return context.WithValue(ctx, NamespaceContextKey, namespace)
+}
+
+func FetchNamespaceFromContext(ctx context.Context) (*Namespace, error) {
+	op := "FetchNamespaceFromContext"
+	namespace, ok := ctx.Value(NamespaceContextKey).(*Namespace)
+	if !ok || namespace == nil {
+		return nil, NewInvalidParamsError(op, "No namespace in context.")
+	}
+	return namespace, nil
+}
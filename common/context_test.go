@@ -128,3 +128,115 @@ func TestFetchUserFromContextSuccesses(t *testing.T) {
 		})
 	}
 }
+
+func TestFetchRequestIdFromContext(t *testing.T) {
+	var tests = []struct {
+		testName string
+		ctx      context.Context
+		expected string
+	}{
+		{
+			testName: "background context",
+			ctx:      context.Background(),
+			expected: "",
+		},
+		{
+			testName: "not string",
+			ctx:      context.WithValue(context.Background(), RequestIdContextKey, 123),
+			expected: "",
+		},
+		{
+			testName: "request id",
+			ctx:      InjectRequestIdIntoContext(context.Background(), "requestId"),
+			expected: "requestId",
+		},
+		{
+			testName: "request id alongside user",
+			ctx:      InjectUserIntoContext(InjectRequestIdIntoContext(context.Background(), "requestId"), &User{}),
+			expected: "requestId",
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("FetchRequestIdFromContext - %v", given.testName), func(t *testing.T) {
+			result := FetchRequestIdFromContext(given.ctx)
+			require.Equal(t, result, given.expected, "Result, %v, does not equal expected, %v", result, given.expected)
+		})
+	}
+}
+
+func TestFetchRequestSourceFromContext(t *testing.T) {
+	var tests = []struct {
+		testName string
+		ctx      context.Context
+		expected *RequestSource
+	}{
+		{
+			testName: "background context",
+			ctx:      context.Background(),
+			expected: &RequestSource{},
+		},
+		{
+			testName: "not request source",
+			ctx:      context.WithValue(context.Background(), RequestSourceContextKey, "zoop"),
+			expected: &RequestSource{},
+		},
+		{
+			testName: "request source",
+			ctx:      InjectRequestSourceIntoContext(context.Background(), &RequestSource{Ip: "10.0.0.1", UserAgent: "curl/7.79.1"}),
+			expected: &RequestSource{Ip: "10.0.0.1", UserAgent: "curl/7.79.1"},
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("FetchRequestSourceFromContext - %v", given.testName), func(t *testing.T) {
+			result := FetchRequestSourceFromContext(given.ctx)
+			require.Equal(t, result, given.expected, "Result %v did not equal expected %v", result, given.expected)
+		})
+	}
+}
+
+func TestFetchNamespaceNameFromContext(t *testing.T) {
+	var tests = []struct {
+		testName string
+		ctx      context.Context
+		expected string
+	}{
+		{
+			testName: "background context",
+			ctx:      context.Background(),
+			expected: DEFAULT_NAMESPACE,
+		},
+		{
+			testName: "empty name",
+			ctx:      InjectNamespaceNameIntoContext(context.Background(), ""),
+			expected: DEFAULT_NAMESPACE,
+		},
+		{
+			testName: "namespace name",
+			ctx:      InjectNamespaceNameIntoContext(context.Background(), "payments"),
+			expected: "payments",
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("FetchNamespaceNameFromContext - %v", given.testName), func(t *testing.T) {
+			result := FetchNamespaceNameFromContext(given.ctx)
+			require.Equal(t, result, given.expected, "Result %v did not equal expected %v", result, given.expected)
+		})
+	}
+}
+
+func TestFetchNamespaceFromContext(t *testing.T) {
+	var nilNamespace *Namespace
+	namespace := &Namespace{Id: "id", Name: "payments"}
+
+	_, err := FetchNamespaceFromContext(context.Background())
+	require.NotNil(t, err, "no error in FetchNamespaceFromContext with no namespace")
+	_, err = FetchNamespaceFromContext(InjectNamespaceIntoContext(context.Background(), nilNamespace))
+	require.NotNil(t, err, "no error in FetchNamespaceFromContext with nil namespace")
+
+	result, err := FetchNamespaceFromContext(InjectNamespaceIntoContext(context.Background(), namespace))
+	require.Nil(t, err, "error in FetchNamespaceFromContext: %v", err)
+	require.Equal(t, result, namespace, "Result %v did not equal expected %v", result, namespace)
+}
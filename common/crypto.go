@@ -3,6 +3,7 @@ package common
 import (
 	"crypto/aes"
 	"crypto/cipher"
+	"crypto/hmac"
 	"crypto/rand"
 	"crypto/sha256"
 	"encoding/hex"
@@ -16,6 +17,13 @@ func HashSha256(s string) string {
 	return fmt.Sprintf("sha256:%s", hex.EncodeToString(hash[:]))
 }
 
+// HmacSha256 returns the hex-encoded HMAC-SHA256 of s under key
+func HmacSha256(key []byte, s string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(s))
+	return fmt.Sprintf("hmac-sha256:%s", hex.EncodeToString(mac.Sum(nil)))
+}
+
 func GenUuid() string {
 	return uuid.New().String()
 }
@@ -34,6 +42,11 @@ func GenRandBytes(numBytes int) ([]byte, error) {
 }
 
 func EncryptSecret(id, value string, keySize int) (string, *EncryptedSecret, error) {
+	return EncryptSecretBytes(id, []byte(value), keySize)
+}
+
+// EncryptSecretBytes encrypts raw bytes, e.g. the contents of a file secret, under a new data key
+func EncryptSecretBytes(id string, value []byte, keySize int) (string, *EncryptedSecret, error) {
 	key, err := GenRandBytes(keySize)
 	if err != nil {
 		return "", nil, NewInternalServerErrorFromError("EncryptSecret", err)
@@ -54,7 +67,7 @@ func EncryptSecret(id, value string, keySize int) (string, *EncryptedSecret, err
 		return "", nil, NewInternalServerErrorFromError("EncryptSecret", err)
 	}
 
-	ciphertext := aesGCM.Seal(nil, iv, []byte(value), nil)
+	ciphertext := aesGCM.Seal(nil, iv, value, nil)
 	return hex.EncodeToString(ciphertext), &EncryptedSecret{
 		Id:  id,
 		Key: hex.EncodeToString(key),
@@ -63,38 +76,120 @@ func EncryptSecret(id, value string, keySize int) (string, *EncryptedSecret, err
 }
 
 func DecryptSecret(ciphertext string, secret *EncryptedSecret) (string, error) {
+	plaintext, err := DecryptSecretBytes(ciphertext, secret)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// DecryptSecretBytes reverses EncryptSecretBytes
+func DecryptSecretBytes(ciphertext string, secret *EncryptedSecret) ([]byte, error) {
 	if secret == nil {
-		return "", NewInternalServerError("EncryptSecret", "Received nil *EncryptedSecret")
+		return nil, NewInternalServerError("DecryptSecret", "Received nil *EncryptedSecret")
 	}
 
 	iv, err := hex.DecodeString(secret.Iv)
 	if err != nil {
-		return "", NewInternalServerErrorFromError("DecryptSecret", err)
+		return nil, NewInternalServerErrorFromError("DecryptSecret", err)
 	}
 
 	key, err := hex.DecodeString(secret.Key)
 	if err != nil {
-		return "", NewInternalServerErrorFromError("DecryptSecret", err)
+		return nil, NewInternalServerErrorFromError("DecryptSecret", err)
 	}
 
 	value, err := hex.DecodeString(ciphertext)
 	if err != nil {
-		return "", NewInternalServerErrorFromError("DecryptSecret", err)
+		return nil, NewInternalServerErrorFromError("DecryptSecret", err)
 	}
 
 	block, err := aes.NewCipher(key)
 	if err != nil {
-		return "", NewInternalServerErrorFromError("DecryptSecret", err)
+		return nil, NewInternalServerErrorFromError("DecryptSecret", err)
 	}
 
 	aesGCM, err := cipher.NewGCMWithNonceSize(block, len(iv))
 	if err != nil {
-		return "", NewInternalServerErrorFromError("DecryptSecret", err)
+		return nil, NewInternalServerErrorFromError("DecryptSecret", err)
 	}
 
 	plaintext, err := aesGCM.Open(nil, iv, value, nil)
 	if err != nil {
-		return "", NewInternalServerErrorFromError("DecryptSecret", err)
+		return nil, NewInternalServerErrorFromError("DecryptSecret", err)
 	}
-	return string(plaintext), nil
+	return plaintext, nil
+}
+
+// SealBytes encrypts plaintext with AES-GCM under key, returning the random nonce followed by the ciphertext.
+// additionalData is authenticated but not encrypted, and must be given again to open the ciphertext.
+func SealBytes(key, plaintext, additionalData []byte) ([]byte, error) {
+	op := "SealBytes"
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, NewInternalServerErrorFromError(op, err)
+	}
+
+	aesGCM, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, NewInternalServerErrorFromError(op, err)
+	}
+
+	nonce, err := GenRandBytes(aesGCM.NonceSize())
+	if err != nil {
+		return nil, NewInternalServerErrorFromError(op, err)
+	}
+	return aesGCM.Seal(nonce, nonce, plaintext, additionalData), nil
+}
+
+// OpenBytes reverses SealBytes. It fails unless additionalData is what the plaintext was sealed with.
+func OpenBytes(key, sealed, additionalData []byte) ([]byte, error) {
+	op := "OpenBytes"
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, NewInternalServerErrorFromError(op, err)
+	}
+
+	aesGCM, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, NewInternalServerErrorFromError(op, err)
+	}
+
+	if len(sealed) < aesGCM.NonceSize() {
+		return nil, NewInternalServerError(op, "Ciphertext is too short")
+	}
+	nonce, ciphertext := sealed[:aesGCM.NonceSize()], sealed[aesGCM.NonceSize():]
+	plaintext, err := aesGCM.Open(nil, nonce, ciphertext, additionalData)
+	if err != nil {
+		return nil, NewInternalServerErrorFromError(op, err)
+	}
+	return plaintext, nil
+}
+
+// WrapKey encrypts a hex-encoded data key with a key encryption key, returning the hex-encoded nonce and ciphertext.
+// The wrapped key is bound to the id of the record it is stored under, so it can't be moved to another record.
+func WrapKey(kek []byte, recordId, dataKey string) (string, error) {
+	key, err := hex.DecodeString(dataKey)
+	if err != nil {
+		return "", NewInternalServerErrorFromError("WrapKey", err)
+	}
+	wrapped, err := SealBytes(kek, key, []byte(recordId))
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(wrapped), nil
+}
+
+// UnwrapKey reverses WrapKey, returning the hex-encoded data key. It fails unless recordId is the id the key was
+// wrapped for.
+func UnwrapKey(kek []byte, recordId, wrappedKey string) (string, error) {
+	wrapped, err := hex.DecodeString(wrappedKey)
+	if err != nil {
+		return "", NewInternalServerErrorFromError("UnwrapKey", err)
+	}
+	key, err := OpenBytes(kek, wrapped, []byte(recordId))
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(key), nil
 }
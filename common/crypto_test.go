@@ -15,6 +15,14 @@ func TestHashSha256(t *testing.T) {
 	}
 }
 
+func TestHmacSha256(t *testing.T) {
+	given := "my hmac test"
+	expected := "hmac-sha256:313e496ec1c9e5a28f7dda849c1a19cb328cf7eae4e6093b2003adeba1ac2bd6"
+	if HmacSha256([]byte("my key"), given) != expected {
+		t.Errorf("Given, %v, != expected, %v", given, expected)
+	}
+}
+
 func TestGenRandBytesErrorCases(t *testing.T) {
 	tests := []int{0, -1, -12345}
 
@@ -78,3 +86,111 @@ func TestEncryptDecrypt(t *testing.T) {
 
 	require.Equal(t, plaintext, givenValue, "Plaintext, %v, does not equal given, %v", plaintext, givenValue)
 }
+
+func TestEncryptDecryptBytes(t *testing.T) {
+	givenValue := []byte{0x00, 0xff, 0xfe, 0x0a, 0x80}
+
+	ciphertext, secret, err := EncryptSecretBytes("id1", givenValue, KEY_SIZE)
+	require.Nil(t, err, "Expected nil error at EncryptSecretBytes. Got: %v", err)
+
+	plaintext, err := DecryptSecretBytes(ciphertext, secret)
+	require.Nil(t, err, "Expected nil error at DecryptSecretBytes. Got: %v", err)
+	require.Equal(t, plaintext, givenValue, "Plaintext, %v, does not equal given, %v", plaintext, givenValue)
+}
+
+func TestWrapKeyErrorCases(t *testing.T) {
+	kek, err := GenRandBytes(KEY_SIZE)
+	require.Nil(t, err, "Unexpected error generating kek: %v", err)
+
+	var tests = []struct {
+		testName string
+		kek      []byte
+		dataKey  string
+	}{
+		{
+			testName: "invalid kek size",
+			kek:      []byte("short"),
+			dataKey:  "abcd",
+		},
+		{
+			testName: "data key not hex",
+			kek:      kek,
+			dataKey:  "zoop",
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("WrapKey - Error - %v", given.testName), func(t *testing.T) {
+			wrapped, err := WrapKey(given.kek, "id1", given.dataKey)
+			require.NotNil(t, err, "Expected non-nil error")
+			require.Empty(t, wrapped, "Expected wrapped to be empty. Got: %v", wrapped)
+		})
+	}
+}
+
+func TestUnwrapKeyErrorCases(t *testing.T) {
+	kek, err := GenRandBytes(KEY_SIZE)
+	require.Nil(t, err, "Unexpected error generating kek: %v", err)
+	otherKek, err := GenRandBytes(KEY_SIZE)
+	require.Nil(t, err, "Unexpected error generating kek: %v", err)
+	wrapped, err := WrapKey(otherKek, "id1", "abcd")
+	require.Nil(t, err, "Unexpected error wrapping key: %v", err)
+	wrappedForOtherRecord, err := WrapKey(kek, "id2", "abcd")
+	require.Nil(t, err, "Unexpected error wrapping key: %v", err)
+
+	var tests = []struct {
+		testName   string
+		kek        []byte
+		wrappedKey string
+	}{
+		{
+			testName:   "invalid kek size",
+			kek:        []byte("short"),
+			wrappedKey: wrapped,
+		},
+		{
+			testName:   "wrapped key not hex",
+			kek:        kek,
+			wrappedKey: "zoop",
+		},
+		{
+			testName:   "wrapped key too short",
+			kek:        kek,
+			wrappedKey: "abcd",
+		},
+		{
+			testName:   "wrong kek",
+			kek:        kek,
+			wrappedKey: wrapped,
+		},
+		{
+			testName:   "wrapped for another record",
+			kek:        kek,
+			wrappedKey: wrappedForOtherRecord,
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("UnwrapKey - Error - %v", given.testName), func(t *testing.T) {
+			dataKey, err := UnwrapKey(given.kek, "id1", given.wrappedKey)
+			require.NotNil(t, err, "Expected non-nil error")
+			require.Empty(t, dataKey, "Expected data key to be empty. Got: %v", dataKey)
+		})
+	}
+}
+
+func TestWrapUnwrapKey(t *testing.T) {
+	kek, err := GenRandBytes(KEY_SIZE)
+	require.Nil(t, err, "Unexpected error generating kek: %v", err)
+
+	_, secret, err := EncryptSecret("id1", "hello there", KEY_SIZE)
+	require.Nil(t, err, "Expected nil error at EncryptSecret. Got: %v", err)
+
+	wrapped, err := WrapKey(kek, secret.Id, secret.Key)
+	require.Nil(t, err, "Expected nil error at WrapKey. Got: %v", err)
+	require.NotEqual(t, wrapped, secret.Key, "Key was not transformed")
+
+	dataKey, err := UnwrapKey(kek, secret.Id, wrapped)
+	require.Nil(t, err, "Expected nil error at UnwrapKey. Got: %v", err)
+	require.Equal(t, dataKey, secret.Key, "Data key, %v, does not equal original, %v", dataKey, secret.Key)
+}
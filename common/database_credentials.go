@@ -0,0 +1,104 @@
+package common
+
+import (
+	"encoding/hex"
+	"strings"
+	"time"
+)
+
+// maxDatabaseUsernameLength is the longest identifier Postgres keeps without truncating it
+const maxDatabaseUsernameLength = 63
+
+// ValidateDatabaseRole checks a new database role, and fills in the default TTLs and statements
+func ValidateDatabaseRole(operation string, role *DatabaseRole) error {
+	if !DATABASE_ROLE_NAME_REGEX.MatchString(role.Name) {
+		return NewInvalidParamsError(operation, "Expected name of up to 32 lowercase letters, digits, _ or -. Got %s", role.Name)
+	}
+	if role.ConnectionUrl == "" {
+		return NewInvalidParamsError(operation, "Expected a connection_url")
+	}
+	if len(role.CreationStatements) == 0 {
+		return NewInvalidParamsError(operation, "Expected at least one creation statement")
+	}
+	hasName := false
+	for _, statement := range role.CreationStatements {
+		if strings.Contains(statement, "{{name}}") {
+			hasName = true
+		}
+	}
+	if !hasName {
+		return NewInvalidParamsError(operation, "Expected creation statements to use {{name}}")
+	}
+	if role.DefaultTtlSeconds < 0 || role.MaxTtlSeconds < 0 {
+		return NewInvalidParamsError(operation, "Expected TTLs to be positive")
+	}
+
+	if role.DefaultTtlSeconds == 0 {
+		role.DefaultTtlSeconds = DEFAULT_LEASE_TTL_SECONDS
+	}
+	if role.MaxTtlSeconds == 0 {
+		role.MaxTtlSeconds = DEFAULT_MAX_LEASE_TTL_SECONDS
+	}
+	if role.DefaultTtlSeconds > role.MaxTtlSeconds {
+		return NewInvalidParamsError(operation, "Expected default_ttl_seconds %d to be at most max_ttl_seconds %d", role.DefaultTtlSeconds, role.MaxTtlSeconds)
+	}
+	if len(role.RenewStatements) == 0 {
+		role.RenewStatements = DEFAULT_RENEW_STATEMENTS
+	}
+	if len(role.RevocationStatements) == 0 {
+		role.RevocationStatements = DEFAULT_REVOCATION_STATEMENTS
+	}
+	return nil
+}
+
+// RenderStatements fills in the {{name}}, {{password}} and {{expiration}} placeholders of a role's statements.
+// Generated usernames and passwords are only letters, digits and _, so they're safe to quote in SQL.
+func RenderStatements(statements []string, username, password string, expiration time.Time) []string {
+	replacer := strings.NewReplacer(
+		"{{name}}", username,
+		"{{password}}", password,
+		"{{expiration}}", expiration.UTC().Format("2006-01-02 15:04:05Z07:00"),
+	)
+	rendered := make([]string, len(statements))
+	for idx, statement := range statements {
+		rendered[idx] = replacer.Replace(statement)
+	}
+	return rendered
+}
+
+func genHex(numBytes int) (string, error) {
+	bytes, err := GenRandBytes(numBytes)
+	if err != nil {
+		return "", NewInternalServerErrorFromError("GenDatabaseCredentials", err)
+	}
+	return hex.EncodeToString(bytes), nil
+}
+
+// GenDatabaseUsername returns a new, unique username for credentials created by a role, e.g. v_payments_ro_1a2b3c4d5e6f.
+// Role names too long to fit are truncated, keeping the prefix and the random suffix.
+func GenDatabaseUsername(roleName string) (string, error) {
+	suffix, err := genHex(6)
+	if err != nil {
+		return "", err
+	}
+	name := strings.ReplaceAll(roleName, "-", "_")
+	maxNameLength := maxDatabaseUsernameLength - len("v_") - len("_") - len(suffix)
+	if len(name) > maxNameLength {
+		name = name[:maxNameLength]
+	}
+	return "v_" + name + "_" + suffix, nil
+}
+
+// GenDatabasePassword returns a new random password for dynamic credentials
+func GenDatabasePassword() (string, error) {
+	return genHex(20)
+}
+
+// LeaseExpiry returns the expiry of a lease issued or renewed at now for ttlSeconds, capped at maxExpiresAt
+func LeaseExpiry(now time.Time, ttlSeconds int, maxExpiresAt time.Time) time.Time {
+	expiresAt := now.Add(time.Duration(ttlSeconds) * time.Second)
+	if expiresAt.After(maxExpiresAt) {
+		return maxExpiresAt
+	}
+	return expiresAt
+}
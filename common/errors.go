@@ -50,6 +50,10 @@ func NewMongoGetSecretError(message string, messageArgs ...interface{}) SecretsE
 	return SecretsError{secretsManagerType: "mongodb", method: "GetSecret", message: message, messageArgs: messageArgs}
 }
 
+func NewPostgresSecretsError(method, message string, messageArgs ...interface{}) SecretsError {
+	return SecretsError{secretsManagerType: "postgres", method: method, message: message, messageArgs: messageArgs}
+}
+
 type DatabaseError struct {
 	operation   string
 	message     string
@@ -157,6 +161,51 @@ func NewResourceNotFoundError(operation, field, value string) ResourceNotFoundEr
 	return ResourceNotFoundError{operation: operation, field: field, value: value}
 }
 
+// ResourceAccessDeniedError is returned when a resource exists but the user has no access to it. It reports the same
+// code and message as ResourceNotFoundError, so that users can't probe for resources they can't see.
+type ResourceAccessDeniedError struct {
+	ResourceNotFoundError
+}
+
+func NewResourceAccessDeniedError(operation, field, value string) ResourceAccessDeniedError {
+	return ResourceAccessDeniedError{NewResourceNotFoundError(operation, field, value)}
+}
+
+type ResourceExpiredError struct {
+	operation string
+	field     string
+	value     string
+}
+
+func (e ResourceExpiredError) Error() string {
+	return fmt.Sprintf("Resource expired in operation, %s, for value, %s, at field, %s", e.operation, e.value, e.field)
+}
+
+func (e ResourceExpiredError) Code() int {
+	return 410
+}
+
+func NewResourceExpiredError(operation, field, value string) ResourceExpiredError {
+	return ResourceExpiredError{operation: operation, field: field, value: value}
+}
+
+type RequestTooLargeError struct {
+	operation string
+	limit     int64
+}
+
+func (e RequestTooLargeError) Error() string {
+	return fmt.Sprintf("Request too large in operation, %s, limit is %d bytes", e.operation, e.limit)
+}
+
+func (e RequestTooLargeError) Code() int {
+	return 413
+}
+
+func NewRequestTooLargeError(operation string, limit int64) RequestTooLargeError {
+	return RequestTooLargeError{operation: operation, limit: limit}
+}
+
 type InternalServerError struct {
 	functionName string
 	message      string
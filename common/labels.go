@@ -0,0 +1,74 @@
+package common
+
+import (
+	"regexp"
+	"strings"
+)
+
+// LABEL_KEY_REGEX matches label keys: up to 63 letters, digits, ".", "_", "-" or "/", starting and ending with a
+// letter or digit
+var LABEL_KEY_REGEX = regexp.MustCompile(`^[A-Za-z0-9]([A-Za-z0-9._/-]{0,61}[A-Za-z0-9])?$`)
+
+// LabelSelector is a parsed set of label selectors. A secret matches if every part matches.
+type LabelSelector struct {
+	Equals    map[string]string
+	NotEquals map[string]string
+	Exists    []string
+	NotExists []string
+}
+
+// ValidateLabels checks the keys and values of a secret's labels
+func ValidateLabels(operation string, labels map[string]string) error {
+	if len(labels) > MAX_SECRET_LABELS {
+		return NewInvalidParamsError(operation, "Expected at most %d labels. Got %d", MAX_SECRET_LABELS, len(labels))
+	}
+	for key, value := range labels {
+		if !LABEL_KEY_REGEX.MatchString(key) {
+			return NewInvalidParamsError(operation, "Invalid label key %s", key)
+		}
+		if len(value) > MAX_LABEL_VALUE_LENGTH {
+			return NewInvalidParamsError(operation, "Expected label %s to be at most %d characters", key, MAX_LABEL_VALUE_LENGTH)
+		}
+	}
+	return nil
+}
+
+// ParseLabelSelectors parses selectors of the form "key=value", "key!=value", "key" and "!key". Each selector can
+// also be a comma separated list of them.
+func ParseLabelSelectors(operation string, selectors []string) (*LabelSelector, error) {
+	selector := &LabelSelector{
+		Equals:    make(map[string]string),
+		NotEquals: make(map[string]string),
+		Exists:    make([]string, 0),
+		NotExists: make([]string, 0),
+	}
+	for _, terms := range selectors {
+		for _, term := range strings.Split(terms, ",") {
+			term = strings.TrimSpace(term)
+			var key string
+			switch {
+			case strings.Contains(term, "!="):
+				parts := strings.SplitN(term, "!=", 2)
+				key = parts[0]
+				selector.NotEquals[key] = parts[1]
+			case strings.Contains(term, "="):
+				parts := strings.SplitN(term, "=", 2)
+				key = parts[0]
+				if value, ok := selector.Equals[key]; ok && value != parts[1] {
+					return nil, NewInvalidParamsError(operation, "Label %s selected with more than one value", key)
+				}
+				selector.Equals[key] = parts[1]
+			case strings.HasPrefix(term, "!"):
+				key = strings.TrimPrefix(term, "!")
+				selector.NotExists = append(selector.NotExists, key)
+			default:
+				key = term
+				selector.Exists = append(selector.Exists, key)
+			}
+			if !LABEL_KEY_REGEX.MatchString(key) {
+				return nil, NewInvalidParamsError(operation, "Invalid label selector %s", term)
+			}
+		}
+	}
+	return selector, nil
+}
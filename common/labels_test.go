@@ -0,0 +1,70 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateLabels(t *testing.T) {
+	tooManyLabels := make(map[string]string)
+	for idx := 0; idx <= MAX_SECRET_LABELS; idx++ {
+		tooManyLabels[fmt.Sprintf("label%d", idx)] = "value"
+	}
+	var tests = []struct {
+		labels   map[string]string
+		expected bool
+	}{
+		{labels: nil, expected: true},
+		{labels: map[string]string{"env": "prod", "team/owner": "payments", "tier": ""}, expected: true},
+		{labels: map[string]string{"": "prod"}, expected: false},
+		{labels: map[string]string{"-env": "prod"}, expected: false},
+		{labels: map[string]string{"env=": "prod"}, expected: false},
+		{labels: map[string]string{"env": strings.Repeat("a", MAX_LABEL_VALUE_LENGTH+1)}, expected: false},
+		{labels: tooManyLabels, expected: false},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("ValidateLabels - %v", idx), func(t *testing.T) {
+			err := ValidateLabels("test", given.labels)
+			require.Equal(t, err == nil, given.expected, "Unexpected result for %v: %v", given.labels, err)
+		})
+	}
+}
+
+func TestParseLabelSelectors(t *testing.T) {
+	selector, err := ParseLabelSelectors("test", []string{"env=prod,tier!=dev", "team", "!deprecated", "version=a=b"})
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+	expected := &LabelSelector{
+		Equals:    map[string]string{"env": "prod", "version": "a=b"},
+		NotEquals: map[string]string{"tier": "dev"},
+		Exists:    []string{"team"},
+		NotExists: []string{"deprecated"},
+	}
+	require.Equal(t, selector, expected, "Result %+v did not equal expected %+v", selector, expected)
+
+	selector, err = ParseLabelSelectors("test", nil)
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+	require.Equal(t, selector, &LabelSelector{Equals: map[string]string{}, NotEquals: map[string]string{}, Exists: []string{}, NotExists: []string{}})
+}
+
+func TestParseLabelSelectorsErrors(t *testing.T) {
+	var tests = [][]string{
+		{""},
+		{"env=prod,"},
+		{"=prod"},
+		{"!"},
+		{"env=prod", "env=dev"},
+		{"bad key"},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("ParseLabelSelectors - Errors - %v", idx), func(t *testing.T) {
+			selector, err := ParseLabelSelectors("test", given)
+			require.NotNil(t, err, "Expected non-nil error")
+			require.Nil(t, selector, "Result was not nil: %v", selector)
+		})
+	}
+}
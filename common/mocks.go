@@ -27,3 +27,10 @@ func NewDummyUserGroup(t *testing.T) *UserGroup {
 	require.Nil(t, err, "Unexpected error generating dummy user group: %v", err)
 	return &tmp
 }
+
+func NewDummyNamespace(t *testing.T) *Namespace {
+	tmp := Namespace{}
+	err := faker.FakeData(&tmp)
+	require.Nil(t, err, "Unexpected error generating dummy namespace: %v", err)
+	return &tmp
+}
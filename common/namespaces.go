@@ -0,0 +1,18 @@
+package common
+
+// ValidateNamespaceName checks that a namespace name can be used in URLs and qualified names
+func ValidateNamespaceName(operation, name string) error {
+	if !NAMESPACE_NAME_REGEX.MatchString(name) {
+		return NewInvalidParamsError(operation, "Expected namespace name to be 1-64 lowercase letters, digits, _ or -, starting with a letter or digit. Got %s", name)
+	}
+	return nil
+}
+
+// QualifiedName prefixes a name with its namespace, as "{namespace}:{name}", so access logs tell apart secrets with
+// the same name. Names in the default namespace are left as they are, so their logs are unchanged.
+func QualifiedName(namespace, name string) string {
+	if namespace == "" || namespace == DEFAULT_NAMESPACE {
+		return name
+	}
+	return namespace + ":" + name
+}
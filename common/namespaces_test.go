@@ -0,0 +1,53 @@
+package common
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateNamespaceName(t *testing.T) {
+	var tests = []struct {
+		name     string
+		expected bool
+	}{
+		{name: "default", expected: true},
+		{name: "payments", expected: true},
+		{name: "team_a-1", expected: true},
+		{name: strings.Repeat("a", 64), expected: true},
+		{name: "", expected: false},
+		{name: "-payments", expected: false},
+		{name: "Payments", expected: false},
+		{name: "payments:prod", expected: false},
+		{name: "payments/prod", expected: false},
+		{name: strings.Repeat("a", 65), expected: false},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("ValidateNamespaceName - %v", idx), func(t *testing.T) {
+			err := ValidateNamespaceName("test", given.name)
+			require.Equal(t, err == nil, given.expected, "Unexpected result for %s: %v", given.name, err)
+		})
+	}
+}
+
+func TestQualifiedName(t *testing.T) {
+	var tests = []struct {
+		namespace string
+		name      string
+		expected  string
+	}{
+		{namespace: "", name: "payments/db", expected: "payments/db"},
+		{namespace: DEFAULT_NAMESPACE, name: "payments/db", expected: "payments/db"},
+		{namespace: "payments", name: "db", expected: "payments:db"},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("QualifiedName - %v", idx), func(t *testing.T) {
+			result := QualifiedName(given.namespace, given.name)
+			require.Equal(t, result, given.expected, "Result %v did not equal expected %v", result, given.expected)
+		})
+	}
+}
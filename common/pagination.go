@@ -0,0 +1,142 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strconv"
+	"time"
+
+	bsonPrimitive "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+// PageCursor marks the last item of a page. Lists continue from the item after it, in the same order.
+type PageCursor struct {
+	// Sort is the order the cursor was issued for, for lists that can be sorted more than one way
+	Sort string `json:"s,omitempty"`
+	// Value is the sort value of the last item
+	Value string `json:"v"`
+	// Id is the id of the last item, which breaks ties between items with the same Value
+	Id string `json:"id"`
+}
+
+// PageInfo is returned with every page of a list
+type PageInfo struct {
+	// NextCursor fetches the next page. Empty on the last page.
+	NextCursor string `json:"next_cursor,omitempty"`
+	// Total is the number of items across every page, if it was requested
+	Total *int `json:"total,omitempty"`
+}
+
+type UserPage struct {
+	Items []*User `json:"items"`
+	PageInfo
+}
+
+type UserGroupPage struct {
+	Items []*UserGroup `json:"items"`
+	PageInfo
+}
+
+type SecretPage struct {
+	Items []*Secret `json:"items"`
+	PageInfo
+}
+
+type DatabaseRolePage struct {
+	Items []*DatabaseRole `json:"items"`
+	PageInfo
+}
+
+type TransitKeyPage struct {
+	Items []*TransitKey `json:"items"`
+	PageInfo
+}
+
+type NamespacePage struct {
+	Items []*Namespace `json:"items"`
+	PageInfo
+}
+
+type DeletedRecordPage struct {
+	Items []*DeletedRecord `json:"items"`
+	PageInfo
+}
+
+type AccessLogPage struct {
+	Items []*AccessLog `json:"items"`
+	PageInfo
+}
+
+// EncodeCursor returns the opaque form of cursor sent to clients
+func EncodeCursor(cursor *PageCursor) string {
+	// marshalling a struct of strings can't fail
+	raw, _ := json.Marshal(cursor)
+	return base64.RawURLEncoding.EncodeToString(raw)
+}
+
+// DecodeCursor reverses EncodeCursor. An empty cursor decodes to nil.
+func DecodeCursor(operation, cursor string) (*PageCursor, error) {
+	if cursor == "" {
+		return nil, nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return nil, NewInvalidParamsError(operation, "Invalid cursor %s", cursor)
+	}
+	var decoded PageCursor
+	err = json.Unmarshal(raw, &decoded)
+	if err != nil || decoded.Id == "" {
+		return nil, NewInvalidParamsError(operation, "Invalid cursor %s", cursor)
+	}
+	return &decoded, nil
+}
+
+// DecodeSortedCursor is DecodeCursor for lists that can be sorted more than one way. It rejects cursors issued for
+// a different sort.
+func DecodeSortedCursor(operation, cursor, sort string) (*PageCursor, error) {
+	decoded, err := DecodeCursor(operation, cursor)
+	if err != nil || decoded == nil {
+		return decoded, err
+	}
+	if decoded.Sort != sort {
+		return nil, NewInvalidParamsError(operation, "Cursor was issued for sort %s, not %s", decoded.Sort, sort)
+	}
+	return decoded, nil
+}
+
+// Sort identifies the order of the secrets listed by r, which cursors must be used with
+func (r *ListSecretsRequest) Sort() string {
+	return r.SortBy + ":" + r.SortOrder
+}
+
+// SecretCursor returns the cursor after secret in the list requested by req
+func SecretCursor(req *ListSecretsRequest, secret *Secret) *PageCursor {
+	var sortTime *time.Time
+	switch req.SortBy {
+	case SECRET_SORT_CREATED_AT:
+		sortTime = secret.CreatedAt
+	case SECRET_SORT_UPDATED_AT:
+		sortTime = secret.UpdatedAt
+	default:
+		return &PageCursor{Sort: req.Sort(), Value: secret.Name, Id: secret.Id}
+	}
+	value := ""
+	if sortTime != nil {
+		value = sortTime.Format(time.RFC3339Nano)
+	}
+	return &PageCursor{Sort: req.Sort(), Value: value, Id: secret.Id}
+}
+
+// AccessLogCursor returns the cursor after log in a list of access logs
+func AccessLogCursor(log *AccessLog) *PageCursor {
+	return &PageCursor{Value: strconv.FormatInt(int64(log.AccessAt), 10), Id: log.Id}
+}
+
+// AccessLogCursorTime returns the access time of the log an access log cursor was issued after
+func AccessLogCursorTime(operation string, cursor *PageCursor) (bsonPrimitive.DateTime, error) {
+	accessAt, err := strconv.ParseInt(cursor.Value, 10, 64)
+	if err != nil {
+		return 0, NewInvalidParamsError(operation, "Invalid cursor for access logs")
+	}
+	return bsonPrimitive.DateTime(accessAt), nil
+}
@@ -0,0 +1,94 @@
+package common
+
+import (
+	"encoding/base64"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	bsonPrimitive "go.mongodb.org/mongo-driver/bson/primitive"
+)
+
+func TestEncodeDecodeCursor(t *testing.T) {
+	cursor := &PageCursor{Sort: "name:asc", Value: "app/db", Id: "id1"}
+	decoded, err := DecodeCursor("test", EncodeCursor(cursor))
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+	require.Equal(t, cursor, decoded)
+
+	decoded, err = DecodeCursor("test", "")
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+	require.Nil(t, decoded, "Expected empty cursor to decode to nil. Got: %v", decoded)
+}
+
+func TestDecodeCursorErrors(t *testing.T) {
+	var tests = []string{
+		"not base64!",
+		base64.RawURLEncoding.EncodeToString([]byte("not json")),
+		base64.RawURLEncoding.EncodeToString([]byte(`{"v":"app/db"}`)),
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("DecodeCursor - Errors - %v", idx), func(t *testing.T) {
+			result, err := DecodeCursor("test", given)
+			require.NotNil(t, err, "Expected error decoding %s", given)
+			require.Nil(t, result, "Result was not nil: %v", result)
+		})
+	}
+}
+
+func TestDecodeSortedCursor(t *testing.T) {
+	cursor := EncodeCursor(&PageCursor{Sort: "name:asc", Value: "app/db", Id: "id1"})
+
+	decoded, err := DecodeSortedCursor("test", cursor, "name:asc")
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+	require.Equal(t, "id1", decoded.Id)
+
+	decoded, err = DecodeSortedCursor("test", cursor, "name:desc")
+	require.NotNil(t, err, "Expected error for a different sort")
+	require.Nil(t, decoded, "Result was not nil: %v", decoded)
+
+	decoded, err = DecodeSortedCursor("test", "", "name:desc")
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+	require.Nil(t, decoded, "Result was not nil: %v", decoded)
+}
+
+func TestSecretCursor(t *testing.T) {
+	createdAt := time.Date(2021, 1, 2, 3, 4, 5, 6, time.UTC)
+	secret := &Secret{Id: "id1", Name: "app/db", CreatedAt: &createdAt}
+	var tests = []struct {
+		req      *ListSecretsRequest
+		expected *PageCursor
+	}{
+		{
+			req:      &ListSecretsRequest{SortBy: SECRET_SORT_NAME, SortOrder: SORT_ORDER_ASC},
+			expected: &PageCursor{Sort: "name:asc", Value: "app/db", Id: "id1"},
+		},
+		{
+			req:      &ListSecretsRequest{SortBy: SECRET_SORT_CREATED_AT, SortOrder: SORT_ORDER_DESC},
+			expected: &PageCursor{Sort: "created_at:desc", Value: "2021-01-02T03:04:05.000000006Z", Id: "id1"},
+		},
+		{
+			req:      &ListSecretsRequest{SortBy: SECRET_SORT_UPDATED_AT, SortOrder: SORT_ORDER_ASC},
+			expected: &PageCursor{Sort: "updated_at:asc", Value: "", Id: "id1"},
+		},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("SecretCursor - %v", idx), func(t *testing.T) {
+			require.Equal(t, given.expected, SecretCursor(given.req, secret))
+		})
+	}
+}
+
+func TestAccessLogCursor(t *testing.T) {
+	cursor := AccessLogCursor(&AccessLog{Id: "log1", AccessAt: bsonPrimitive.DateTime(1609459200000)})
+	require.Equal(t, &PageCursor{Value: "1609459200000", Id: "log1"}, cursor)
+
+	accessAt, err := AccessLogCursorTime("test", cursor)
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+	require.Equal(t, bsonPrimitive.DateTime(1609459200000), accessAt)
+
+	_, err = AccessLogCursorTime("test", &PageCursor{Value: "yesterday", Id: "log1"})
+	require.NotNil(t, err, "Expected error for a non-numeric access time")
+}
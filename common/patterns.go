@@ -0,0 +1,18 @@
+package common
+
+import (
+	"strings"
+)
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`, `*`, `%`)
+
+// SecretPatternToLike converts a secret name glob, where * matches any run of characters, to a Postgres LIKE pattern
+func SecretPatternToLike(pattern string) (string, error) {
+	if strings.TrimSpace(pattern) == "" {
+		return "", NewInvalidParamsError("SecretPatternToLike", "Pattern must not be empty")
+	}
+	if strings.Trim(pattern, "*") == "" {
+		return "", NewInvalidParamsError("SecretPatternToLike", "Pattern must contain at least one non-wildcard character. Got %s", pattern)
+	}
+	return likeEscaper.Replace(pattern), nil
+}
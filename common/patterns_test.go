@@ -0,0 +1,41 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretPatternToLikeErrors(t *testing.T) {
+	var inits = []string{"", "   ", "*", "**"}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("SecretPatternToLike - Errors - %v", idx), func(t *testing.T) {
+			result, err := SecretPatternToLike(given)
+			require.NotNil(t, err, "no error in SecretPatternToLike: %v", err)
+			require.Empty(t, result, "Expected empty result, got: %v", result)
+		})
+	}
+}
+
+func TestSecretPatternToLikeSuccesses(t *testing.T) {
+	var inits = []struct {
+		pattern  string
+		expected string
+	}{
+		{pattern: "payments/*", expected: "payments/%"},
+		{pattern: "*-staging", expected: "%-staging"},
+		{pattern: "db_*_100%", expected: `db\_%\_100\%`},
+		{pattern: `a\b*`, expected: `a\\b%`},
+		{pattern: "exact-name", expected: "exact-name"},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("SecretPatternToLike - Successes - %v", idx), func(t *testing.T) {
+			result, err := SecretPatternToLike(given.pattern)
+			require.Nil(t, err, "error in SecretPatternToLike: %v", err)
+			require.Equal(t, result, given.expected, "Result %v did not equal expected %v", result, given.expected)
+		})
+	}
+}
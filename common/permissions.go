@@ -0,0 +1,24 @@
+package common
+
+// ParsePermissionLevel validates a requested permission level. An empty level defaults to read.
+func ParsePermissionLevel(op, level string) (string, error) {
+	if level == "" {
+		return PERMISSION_LEVEL_READ, nil
+	}
+	for _, validLevel := range PERMISSION_LEVELS {
+		if level == validLevel {
+			return level, nil
+		}
+	}
+	return "", NewInvalidParamsError(op, "Expected permission level to be one of %v. Got %s", PERMISSION_LEVELS, level)
+}
+
+// PermissionLevelBelow returns the next less privileged level, or an empty string for read
+func PermissionLevelBelow(level string) string {
+	for idx, validLevel := range PERMISSION_LEVELS {
+		if level == validLevel && idx > 0 {
+			return PERMISSION_LEVELS[idx-1]
+		}
+	}
+	return ""
+}
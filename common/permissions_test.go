@@ -0,0 +1,59 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParsePermissionLevelErrors(t *testing.T) {
+	var inits = []string{"admin", "READ", " read"}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ParsePermissionLevel - Errors - %v", idx), func(t *testing.T) {
+			result, err := ParsePermissionLevel("op", given)
+			require.NotNil(t, err, "no error in ParsePermissionLevel: %v", err)
+			require.Empty(t, result, "Expected empty result, got: %v", result)
+		})
+	}
+}
+
+func TestParsePermissionLevelSuccesses(t *testing.T) {
+	var inits = []struct {
+		level    string
+		expected string
+	}{
+		{level: "", expected: PERMISSION_LEVEL_READ},
+		{level: "read", expected: PERMISSION_LEVEL_READ},
+		{level: "write", expected: PERMISSION_LEVEL_WRITE},
+		{level: "manage", expected: PERMISSION_LEVEL_MANAGE},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ParsePermissionLevel - Successes - %v", idx), func(t *testing.T) {
+			result, err := ParsePermissionLevel("op", given.level)
+			require.Nil(t, err, "error in ParsePermissionLevel: %v", err)
+			require.Equal(t, result, given.expected, "Result %v did not equal expected %v", result, given.expected)
+		})
+	}
+}
+
+func TestPermissionLevelBelow(t *testing.T) {
+	var inits = []struct {
+		level    string
+		expected string
+	}{
+		{level: "read", expected: ""},
+		{level: "write", expected: PERMISSION_LEVEL_READ},
+		{level: "manage", expected: PERMISSION_LEVEL_WRITE},
+		{level: "unknown", expected: ""},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("PermissionLevelBelow - %v", idx), func(t *testing.T) {
+			result := PermissionLevelBelow(given.level)
+			require.Equal(t, result, given.expected, "Result %v did not equal expected %v", result, given.expected)
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"strings"
+)
+
+// ValidateSecretFields checks the field names of a multi-field secret. Names are used in URLs, so can't contain "/".
+func ValidateSecretFields(operation string, fields map[string]string) error {
+	if len(fields) == 0 {
+		return NewInvalidParamsError(operation, "Expected at least one field")
+	}
+	if len(fields) > MAX_SECRET_FIELDS {
+		return NewInvalidParamsError(operation, "Expected at most %d fields. Got %d", MAX_SECRET_FIELDS, len(fields))
+	}
+	for name := range fields {
+		if strings.TrimSpace(name) == "" {
+			return NewInvalidParamsError(operation, "Field names must not be empty")
+		}
+		if strings.Contains(name, "/") {
+			return NewInvalidParamsError(operation, "Field names must not contain /. Got %s", name)
+		}
+	}
+	return nil
+}
+
+// SecretPlaintext returns the plaintext to encrypt for a secret with either a value or fields, and its value type.
+// Fields are encrypted together, as a single JSON payload.
+func SecretPlaintext(operation, value string, fields map[string]string) (string, string, error) {
+	if fields == nil {
+		return value, SECRET_VALUE_TYPE_STRING, nil
+	}
+	if value != "" {
+		return "", "", NewInvalidParamsError(operation, "Expected either value or fields, not both")
+	}
+	err := ValidateSecretFields(operation, fields)
+	if err != nil {
+		return "", "", err
+	}
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return "", "", NewInternalServerErrorFromError(operation, err)
+	}
+	return string(payload), SECRET_VALUE_TYPE_FIELDS, nil
+}
+
+// SetSecretPlaintext sets a decrypted plaintext on a secret, as its value or fields depending on its value type.
+// File secrets can hold any bytes, so their value is base64 encoded to fit in JSON.
+func SetSecretPlaintext(secret *Secret, plaintext string) error {
+	if secret.ValueType == SECRET_VALUE_TYPE_BINARY {
+		secret.Value = base64.StdEncoding.EncodeToString([]byte(plaintext))
+		return nil
+	}
+	if secret.ValueType != SECRET_VALUE_TYPE_FIELDS {
+		secret.Value = plaintext
+		return nil
+	}
+	var fields map[string]string
+	err := json.Unmarshal([]byte(plaintext), &fields)
+	if err != nil {
+		return NewInternalServerError("SetSecretPlaintext", "Secret %s has invalid fields: %v", secret.Name, err)
+	}
+	secret.Value = ""
+	secret.Fields = fields
+	return nil
+}
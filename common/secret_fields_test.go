@@ -0,0 +1,74 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecretPlaintextErrors(t *testing.T) {
+	tooManyFields := make(map[string]string)
+	for idx := 0; idx <= MAX_SECRET_FIELDS; idx++ {
+		tooManyFields[fmt.Sprintf("field%d", idx)] = "value"
+	}
+	var tests = []struct {
+		value  string
+		fields map[string]string
+	}{
+		{value: "value", fields: map[string]string{"username": "admin"}},
+		{fields: map[string]string{}},
+		{fields: map[string]string{" ": "admin"}},
+		{fields: map[string]string{"user/name": "admin"}},
+		{fields: tooManyFields},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("SecretPlaintext - Errors - %v", idx), func(t *testing.T) {
+			plaintext, valueType, err := SecretPlaintext("test", given.value, given.fields)
+			require.NotNil(t, err, "Expected non-nil error")
+			require.Equal(t, plaintext, "")
+			require.Equal(t, valueType, "")
+		})
+	}
+}
+
+func TestSecretPlaintextRoundTrip(t *testing.T) {
+	var tests = []struct {
+		value     string
+		fields    map[string]string
+		valueType string
+	}{
+		{value: "value", valueType: SECRET_VALUE_TYPE_STRING},
+		{value: "", valueType: SECRET_VALUE_TYPE_STRING},
+		{fields: map[string]string{"username": "admin", "password": "hunter2"}, valueType: SECRET_VALUE_TYPE_FIELDS},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("SecretPlaintext - Successes - %v", idx), func(t *testing.T) {
+			plaintext, valueType, err := SecretPlaintext("test", given.value, given.fields)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			require.Equal(t, valueType, given.valueType)
+
+			secret := &Secret{ValueType: valueType}
+			err = SetSecretPlaintext(secret, plaintext)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			require.Equal(t, secret.Value, given.value)
+			require.Equal(t, secret.Fields, given.fields)
+		})
+	}
+}
+
+func TestSetSecretPlaintextErrors(t *testing.T) {
+	secret := &Secret{Name: "secret", ValueType: SECRET_VALUE_TYPE_FIELDS}
+	err := SetSecretPlaintext(secret, "not json")
+	require.NotNil(t, err, "Expected non-nil error")
+	require.Nil(t, secret.Fields)
+}
+
+func TestSetSecretPlaintextBinary(t *testing.T) {
+	secret := &Secret{Name: "secret", ValueType: SECRET_VALUE_TYPE_BINARY}
+	err := SetSecretPlaintext(secret, string([]byte{0x00, 0xff}))
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+	require.Equal(t, secret.Value, "AP8=")
+}
@@ -0,0 +1,28 @@
+package common
+
+import (
+	"mime"
+	"strings"
+)
+
+// ValidateSecretFile checks an uploaded file secret against the size limit, and returns its content type, which
+// defaults to application/octet-stream
+func ValidateSecretFile(operation, contentType, filename string, size int, limit int64) (string, error) {
+	if size == 0 {
+		return "", NewInvalidParamsError(operation, "Expected a non-empty file")
+	}
+	if int64(size) > limit {
+		return "", NewRequestTooLargeError(operation, limit)
+	}
+	if strings.ContainsAny(filename, "/\\\"") || filename == "." || filename == ".." {
+		return "", NewInvalidParamsError(operation, "Expected a filename without a path. Got %s", filename)
+	}
+	if contentType == "" {
+		return DEFAULT_SECRET_CONTENT_TYPE, nil
+	}
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return "", NewInvalidParamsError(operation, "Expected a valid content type. Got %s", contentType)
+	}
+	return mime.FormatMediaType(mediaType, params), nil
+}
@@ -0,0 +1,53 @@
+package common
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestValidateSecretFileErrors(t *testing.T) {
+	var tests = []struct {
+		testName    string
+		contentType string
+		filename    string
+		size        int
+	}{
+		{testName: "empty", size: 0},
+		{testName: "too large", size: 11},
+		{testName: "path in filename", filename: "../tls.key", size: 1},
+		{testName: "parent filename", filename: "..", size: 1},
+		{testName: "invalid content type", contentType: "text/", size: 1},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("ValidateSecretFile - Errors - %v", given.testName), func(t *testing.T) {
+			contentType, err := ValidateSecretFile("test", given.contentType, given.filename, given.size, 10)
+			require.NotNil(t, err, "Expected non-nil error")
+			require.Equal(t, contentType, "")
+		})
+	}
+}
+
+func TestValidateSecretFileSuccess(t *testing.T) {
+	var tests = []struct {
+		contentType string
+		expected    string
+	}{
+		{contentType: "", expected: DEFAULT_SECRET_CONTENT_TYPE},
+		{contentType: "application/x-pem-file", expected: "application/x-pem-file"},
+		{contentType: "Text/Plain; Charset=utf-8", expected: "text/plain; charset=utf-8"},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("ValidateSecretFile - Success - %v", idx), func(t *testing.T) {
+			contentType, err := ValidateSecretFile("test", given.contentType, "tls.key", 10, 10)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			require.Equal(t, contentType, given.expected)
+		})
+	}
+
+	_, err := ValidateSecretFile("test", "", "", 11, 10)
+	require.Equal(t, err, NewRequestTooLargeError("test", 10))
+}
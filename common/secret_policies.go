@@ -0,0 +1,358 @@
+package common
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"encoding/pem"
+	"sort"
+	"strings"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// SecretPolicy describes how to generate a secret value. Which fields apply depends on Type.
+type SecretPolicy struct {
+	Name string `json:"name" yaml:"-"`
+	Type string `json:"type" yaml:"type"`
+	// Length is the number of characters in a password
+	Length int `json:"length,omitempty" yaml:"length"`
+	// CharacterClasses are the classes a password draws from. Every class appears at least once.
+	CharacterClasses []string `json:"character_classes,omitempty" yaml:"characterClasses"`
+	// Words is the number of words in a passphrase
+	Words     int    `json:"words,omitempty" yaml:"words"`
+	Separator string `json:"separator,omitempty" yaml:"separator"`
+	// WordListFile is a file of passphrase words, one per line. Passphrases use a built-in list if it's empty.
+	WordListFile string `json:"-" yaml:"wordListFile"`
+	// Bytes is the number of random bytes, which are encoded with Encoding
+	Bytes    int    `json:"bytes,omitempty" yaml:"bytes"`
+	Encoding string `json:"encoding,omitempty" yaml:"encoding"`
+	// Algorithm and Bits describe a key pair. Bits is the RSA modulus or the ECDSA curve size.
+	Algorithm string `json:"algorithm,omitempty" yaml:"algorithm"`
+	Bits      int    `json:"bits,omitempty" yaml:"bits"`
+
+	wordList []string
+}
+
+// DefaultSecretPolicies returns the built-in policies, which serverConfigs.secretPolicies can add to or override
+func DefaultSecretPolicies() map[string]*SecretPolicy {
+	allClasses := []string{CHARACTER_CLASS_LOWERCASE, CHARACTER_CLASS_UPPERCASE, CHARACTER_CLASS_DIGITS, CHARACTER_CLASS_SYMBOLS}
+	return map[string]*SecretPolicy{
+		"password":     {Type: SECRET_POLICY_TYPE_PASSWORD, Length: 32, CharacterClasses: allClasses},
+		"alphanumeric": {Type: SECRET_POLICY_TYPE_PASSWORD, Length: 32, CharacterClasses: allClasses[:3]},
+		"passphrase":   {Type: SECRET_POLICY_TYPE_PASSPHRASE, Words: 10},
+		"hex-32":       {Type: SECRET_POLICY_TYPE_RANDOM_BYTES, Bytes: 32, Encoding: ENCODING_HEX},
+		"base64-32":    {Type: SECRET_POLICY_TYPE_RANDOM_BYTES, Bytes: 32, Encoding: ENCODING_BASE64},
+		"rsa-3072":     {Type: SECRET_POLICY_TYPE_KEY_PAIR, Algorithm: KEY_ALGORITHM_RSA, Bits: 3072},
+		"ecdsa-p256":   {Type: SECRET_POLICY_TYPE_KEY_PAIR, Algorithm: KEY_ALGORITHM_ECDSA, Bits: 256},
+		"ed25519":      {Type: SECRET_POLICY_TYPE_KEY_PAIR, Algorithm: KEY_ALGORITHM_ED25519},
+		"ssh-rsa":      {Type: SECRET_POLICY_TYPE_SSH_KEY, Algorithm: KEY_ALGORITHM_RSA, Bits: 3072},
+		"ssh-ecdsa":    {Type: SECRET_POLICY_TYPE_SSH_KEY, Algorithm: KEY_ALGORITHM_ECDSA, Bits: 256},
+		"ssh-ed25519":  {Type: SECRET_POLICY_TYPE_SSH_KEY, Algorithm: KEY_ALGORITHM_ED25519},
+	}
+}
+
+// ValidateSecretPolicy checks a policy, and fills in its defaults
+func ValidateSecretPolicy(operation string, policy *SecretPolicy) error {
+	switch policy.Type {
+	case SECRET_POLICY_TYPE_PASSWORD:
+		if len(policy.CharacterClasses) == 0 {
+			return NewInvalidParamsError(operation, "Policy %s: expected at least one character class", policy.Name)
+		}
+		seen := make(map[string]bool)
+		for _, class := range policy.CharacterClasses {
+			if _, ok := CHARACTER_CLASSES[class]; !ok {
+				return NewInvalidParamsError(operation, "Policy %s: unknown character class %s", policy.Name, class)
+			}
+			if seen[class] {
+				return NewInvalidParamsError(operation, "Policy %s: duplicate character class %s", policy.Name, class)
+			}
+			seen[class] = true
+		}
+		if policy.Length < len(policy.CharacterClasses) || policy.Length > MAX_GENERATED_SECRET_LENGTH {
+			return NewInvalidParamsError(operation, "Policy %s: expected length between %d and %d. Got %d", policy.Name, len(policy.CharacterClasses), MAX_GENERATED_SECRET_LENGTH, policy.Length)
+		}
+	case SECRET_POLICY_TYPE_PASSPHRASE:
+		if policy.Words < 1 || policy.Words > MAX_PASSPHRASE_WORDS {
+			return NewInvalidParamsError(operation, "Policy %s: expected between 1 and %d words. Got %d", policy.Name, MAX_PASSPHRASE_WORDS, policy.Words)
+		}
+		if policy.Separator == "" {
+			policy.Separator = "-"
+		}
+		if policy.wordList == nil {
+			policy.wordList = defaultWordList
+		}
+	case SECRET_POLICY_TYPE_RANDOM_BYTES:
+		if policy.Bytes < 1 || policy.Bytes > MAX_GENERATED_SECRET_LENGTH {
+			return NewInvalidParamsError(operation, "Policy %s: expected between 1 and %d bytes. Got %d", policy.Name, MAX_GENERATED_SECRET_LENGTH, policy.Bytes)
+		}
+		if policy.Encoding == "" {
+			policy.Encoding = ENCODING_HEX
+		}
+		if policy.Encoding != ENCODING_HEX && policy.Encoding != ENCODING_BASE64 {
+			return NewInvalidParamsError(operation, "Policy %s: expected encoding hex or base64. Got %s", policy.Name, policy.Encoding)
+		}
+	case SECRET_POLICY_TYPE_KEY_PAIR, SECRET_POLICY_TYPE_SSH_KEY:
+		switch policy.Algorithm {
+		case KEY_ALGORITHM_RSA:
+			if policy.Bits == 0 {
+				policy.Bits = 3072
+			}
+			if policy.Bits != 2048 && policy.Bits != 3072 && policy.Bits != 4096 {
+				return NewInvalidParamsError(operation, "Policy %s: expected RSA bits of 2048, 3072 or 4096. Got %d", policy.Name, policy.Bits)
+			}
+		case KEY_ALGORITHM_ECDSA:
+			if policy.Bits == 0 {
+				policy.Bits = 256
+			}
+			if _, err := ecdsaCurve(operation, policy.Bits); err != nil {
+				return err
+			}
+		case KEY_ALGORITHM_ED25519:
+			policy.Bits = 0
+		default:
+			return NewInvalidParamsError(operation, "Policy %s: expected algorithm rsa, ecdsa or ed25519. Got %s", policy.Name, policy.Algorithm)
+		}
+	default:
+		return NewInvalidParamsError(operation, "Policy %s: unknown type %s", policy.Name, policy.Type)
+	}
+	return nil
+}
+
+// SetWordList sets the words a passphrase policy draws from, from the contents of its WordListFile. Blank lines and
+// repeated words are skipped, since repeats would skew the choice of words.
+func (p *SecretPolicy) SetWordList(operation, data string) error {
+	seen := make(map[string]bool)
+	words := make([]string, 0)
+	for _, line := range strings.Split(data, "\n") {
+		word := strings.TrimSpace(line)
+		if word == "" || seen[word] {
+			continue
+		}
+		seen[word] = true
+		words = append(words, word)
+	}
+	if len(words) < 2 {
+		return NewInvalidParamsError(operation, "Policy %s: expected at least 2 words in %s. Got %d", p.Name, p.WordListFile, len(words))
+	}
+	p.wordList = words
+	return nil
+}
+
+// randIndex returns a uniformly random index below n. Values from the top of the range that would bias the result
+// towards low indexes are rejected.
+func randIndex(n int) (int, error) {
+	limit := uint32(1<<32 - (1<<32)%uint64(n))
+	for {
+		bytes, err := GenRandBytes(4)
+		if err != nil {
+			return 0, err
+		}
+		val := binary.BigEndian.Uint32(bytes)
+		if limit == 0 || val < limit {
+			return int(val % uint32(n)), nil
+		}
+	}
+}
+
+func generatePassword(policy *SecretPolicy) (string, error) {
+	alphabet := ""
+	for _, class := range policy.CharacterClasses {
+		alphabet += CHARACTER_CLASSES[class]
+	}
+	// passwords missing a class are thrown away, so every password that has them all is equally likely
+	for {
+		password := make([]byte, policy.Length)
+		for idx := range password {
+			charIdx, err := randIndex(len(alphabet))
+			if err != nil {
+				return "", err
+			}
+			password[idx] = alphabet[charIdx]
+		}
+		hasAll := true
+		for _, class := range policy.CharacterClasses {
+			if !strings.ContainsAny(string(password), CHARACTER_CLASSES[class]) {
+				hasAll = false
+			}
+		}
+		if hasAll {
+			return string(password), nil
+		}
+	}
+}
+
+func generatePassphrase(policy *SecretPolicy) (string, error) {
+	words := make([]string, policy.Words)
+	for idx := range words {
+		wordIdx, err := randIndex(len(policy.wordList))
+		if err != nil {
+			return "", err
+		}
+		words[idx] = policy.wordList[wordIdx]
+	}
+	return strings.Join(words, policy.Separator), nil
+}
+
+func ecdsaCurve(operation string, bits int) (elliptic.Curve, error) {
+	switch bits {
+	case 256:
+		return elliptic.P256(), nil
+	case 384:
+		return elliptic.P384(), nil
+	case 521:
+		return elliptic.P521(), nil
+	}
+	return nil, NewInvalidParamsError(operation, "Expected ECDSA bits of 256, 384 or 521. Got %d", bits)
+}
+
+func generateKey(operation string, policy *SecretPolicy) (crypto.Signer, error) {
+	switch policy.Algorithm {
+	case KEY_ALGORITHM_RSA:
+		return rsa.GenerateKey(rand.Reader, policy.Bits)
+	case KEY_ALGORITHM_ECDSA:
+		curve, err := ecdsaCurve(operation, policy.Bits)
+		if err != nil {
+			return nil, err
+		}
+		return ecdsa.GenerateKey(curve, rand.Reader)
+	}
+	seed, err := GenRandBytes(ed25519.SeedSize)
+	if err != nil {
+		return nil, err
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// generateKeyPair returns a PKCS #8 private key and a PKIX public key, both PEM encoded
+func generateKeyPair(operation string, policy *SecretPolicy) (map[string]string, error) {
+	key, err := generateKey(operation, policy)
+	if err != nil {
+		return nil, err
+	}
+	privateDer, err := x509.MarshalPKCS8PrivateKey(key)
+	if err != nil {
+		return nil, err
+	}
+	publicDer, err := x509.MarshalPKIXPublicKey(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	return map[string]string{
+		"private_key": string(pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: privateDer})),
+		"public_key":  string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicDer})),
+	}, nil
+}
+
+// generateSshKey returns a private key that ssh and ssh-keygen accept, and the public key in authorized_keys format
+func generateSshKey(operation string, policy *SecretPolicy) (map[string]string, error) {
+	key, err := generateKey(operation, policy)
+	if err != nil {
+		return nil, err
+	}
+	publicKey, err := ssh.NewPublicKey(key.Public())
+	if err != nil {
+		return nil, err
+	}
+	var block *pem.Block
+	switch typedKey := key.(type) {
+	case *rsa.PrivateKey:
+		block = &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(typedKey)}
+	case *ecdsa.PrivateKey:
+		der, err := x509.MarshalECPrivateKey(typedKey)
+		if err != nil {
+			return nil, err
+		}
+		block = &pem.Block{Type: "EC PRIVATE KEY", Bytes: der}
+	case ed25519.PrivateKey:
+		block, err = marshalOpenSshEd25519(typedKey, publicKey)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return map[string]string{
+		"private_key": string(pem.EncodeToMemory(block)),
+		"public_key":  strings.TrimSpace(string(ssh.MarshalAuthorizedKey(publicKey))),
+	}, nil
+}
+
+// marshalOpenSshEd25519 encodes an unencrypted key in the openssh-key-v1 format, the only format OpenSSH reads
+// Ed25519 private keys from
+func marshalOpenSshEd25519(key ed25519.PrivateKey, publicKey ssh.PublicKey) (*pem.Block, error) {
+	checkBytes, err := GenRandBytes(4)
+	if err != nil {
+		return nil, err
+	}
+	check := binary.BigEndian.Uint32(checkBytes)
+	public := []byte(key.Public().(ed25519.PublicKey))
+	private := ssh.Marshal(struct {
+		Check1  uint32
+		Check2  uint32
+		KeyType string
+		Public  []byte
+		Private []byte
+		Comment string
+	}{check, check, ssh.KeyAlgoED25519, public, []byte(key), ""})
+	// the private section is padded to the cipher block size, which is 8 without a cipher
+	for idx := 1; len(private)%8 != 0; idx++ {
+		private = append(private, byte(idx))
+	}
+	body := ssh.Marshal(struct {
+		CipherName   string
+		KdfName      string
+		KdfOpts      string
+		NumKeys      uint32
+		PublicKey    []byte
+		PrivateBlock []byte
+	}{"none", "none", "", 1, publicKey.Marshal(), private})
+	return &pem.Block{Type: "OPENSSH PRIVATE KEY", Bytes: append([]byte("openssh-key-v1\x00"), body...)}, nil
+}
+
+// GenerateSecret generates a value from a validated policy. Key pairs are returned as private_key and public_key
+// fields, and everything else as a value.
+func GenerateSecret(operation string, policy *SecretPolicy) (string, map[string]string, error) {
+	var value string
+	var fields map[string]string
+	var err error
+	switch policy.Type {
+	case SECRET_POLICY_TYPE_PASSWORD:
+		value, err = generatePassword(policy)
+	case SECRET_POLICY_TYPE_PASSPHRASE:
+		value, err = generatePassphrase(policy)
+	case SECRET_POLICY_TYPE_RANDOM_BYTES:
+		var bytes []byte
+		bytes, err = GenRandBytes(policy.Bytes)
+		if policy.Encoding == ENCODING_BASE64 {
+			value = base64.StdEncoding.EncodeToString(bytes)
+		} else {
+			value = hex.EncodeToString(bytes)
+		}
+	case SECRET_POLICY_TYPE_KEY_PAIR:
+		fields, err = generateKeyPair(operation, policy)
+	case SECRET_POLICY_TYPE_SSH_KEY:
+		fields, err = generateSshKey(operation, policy)
+	default:
+		return "", nil, NewInvalidParamsError(operation, "Policy %s: unknown type %s", policy.Name, policy.Type)
+	}
+	if err != nil {
+		return "", nil, NewInternalServerErrorFromError(operation, err)
+	}
+	return value, fields, nil
+}
+
+// SortedSecretPolicies returns policies sorted by name
+func SortedSecretPolicies(policies map[string]*SecretPolicy) []*SecretPolicy {
+	sorted := make([]*SecretPolicy, 0, len(policies))
+	for _, policy := range policies {
+		sorted = append(sorted, policy)
+	}
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i].Name < sorted[j].Name })
+	return sorted
+}
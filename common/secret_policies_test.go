@@ -0,0 +1,184 @@
+package common
+
+import (
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestDefaultSecretPoliciesAreValid(t *testing.T) {
+	for name, policy := range DefaultSecretPolicies() {
+		policy.Name = name
+		err := ValidateSecretPolicy("test", policy)
+		require.Nil(t, err, "Expected default policy %s to be valid. Got: %v", name, err)
+	}
+}
+
+func TestDefaultWordList(t *testing.T) {
+	require.Equal(t, len(defaultWordList), 256)
+	seen := make(map[string]bool)
+	for _, word := range defaultWordList {
+		require.False(t, seen[word], "Duplicate word %s", word)
+		seen[word] = true
+	}
+}
+
+func TestValidateSecretPolicyErrors(t *testing.T) {
+	var tests = []struct {
+		op     string
+		policy *SecretPolicy
+	}{
+		{op: "unknown type", policy: &SecretPolicy{Type: "pin"}},
+		{op: "no classes", policy: &SecretPolicy{Type: SECRET_POLICY_TYPE_PASSWORD, Length: 10}},
+		{op: "unknown class", policy: &SecretPolicy{Type: SECRET_POLICY_TYPE_PASSWORD, Length: 10, CharacterClasses: []string{"emoji"}}},
+		{op: "duplicate class", policy: &SecretPolicy{Type: SECRET_POLICY_TYPE_PASSWORD, Length: 10, CharacterClasses: []string{"digits", "digits"}}},
+		{op: "short password", policy: &SecretPolicy{Type: SECRET_POLICY_TYPE_PASSWORD, Length: 1, CharacterClasses: []string{"digits", "symbols"}}},
+		{op: "long password", policy: &SecretPolicy{Type: SECRET_POLICY_TYPE_PASSWORD, Length: MAX_GENERATED_SECRET_LENGTH + 1, CharacterClasses: []string{"digits"}}},
+		{op: "no words", policy: &SecretPolicy{Type: SECRET_POLICY_TYPE_PASSPHRASE}},
+		{op: "no bytes", policy: &SecretPolicy{Type: SECRET_POLICY_TYPE_RANDOM_BYTES}},
+		{op: "unknown encoding", policy: &SecretPolicy{Type: SECRET_POLICY_TYPE_RANDOM_BYTES, Bytes: 16, Encoding: "base32"}},
+		{op: "unknown algorithm", policy: &SecretPolicy{Type: SECRET_POLICY_TYPE_KEY_PAIR, Algorithm: "dsa"}},
+		{op: "small rsa", policy: &SecretPolicy{Type: SECRET_POLICY_TYPE_KEY_PAIR, Algorithm: KEY_ALGORITHM_RSA, Bits: 1024}},
+		{op: "unknown curve", policy: &SecretPolicy{Type: SECRET_POLICY_TYPE_SSH_KEY, Algorithm: KEY_ALGORITHM_ECDSA, Bits: 224}},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("ValidateSecretPolicy - Errors - %v", given.op), func(t *testing.T) {
+			err := ValidateSecretPolicy("test", given.policy)
+			require.NotNil(t, err, "Expected an error")
+		})
+	}
+}
+
+func TestSetWordList(t *testing.T) {
+	policy := &SecretPolicy{Name: "words", Type: SECRET_POLICY_TYPE_PASSPHRASE, Words: 4, Separator: " "}
+	err := policy.SetWordList("test", "alpha\n\nbeta\nalpha\n  gamma  \n")
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+	require.Equal(t, policy.wordList, []string{"alpha", "beta", "gamma"})
+
+	err = ValidateSecretPolicy("test", policy)
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+	value, _, err := GenerateSecret("test", policy)
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+	words := strings.Split(value, " ")
+	require.Equal(t, len(words), 4)
+	for _, word := range words {
+		require.Contains(t, policy.wordList, word)
+	}
+
+	err = policy.SetWordList("test", "alpha\nalpha\n")
+	require.NotNil(t, err, "Expected an error for a single word list")
+}
+
+func TestRandIndex(t *testing.T) {
+	counts := make([]int, 3)
+	for idx := 0; idx < 300; idx++ {
+		val, err := randIndex(3)
+		require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+		counts[val]++
+	}
+	for val, count := range counts {
+		require.True(t, count > 0, "Expected index %d to be returned", val)
+	}
+	val, err := randIndex(1)
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+	require.Equal(t, val, 0)
+}
+
+func TestGenerateSecretValues(t *testing.T) {
+	var tests = []struct {
+		policy *SecretPolicy
+		check  func(t *testing.T, value string)
+	}{
+		{
+			policy: &SecretPolicy{Type: SECRET_POLICY_TYPE_PASSWORD, Length: 4, CharacterClasses: []string{CHARACTER_CLASS_LOWERCASE, CHARACTER_CLASS_UPPERCASE, CHARACTER_CLASS_DIGITS, CHARACTER_CLASS_SYMBOLS}},
+			check: func(t *testing.T, value string) {
+				require.Equal(t, len(value), 4)
+				for _, chars := range CHARACTER_CLASSES {
+					require.True(t, strings.ContainsAny(value, chars), "Password %s is missing one of %s", value, chars)
+				}
+			},
+		},
+		{
+			policy: &SecretPolicy{Type: SECRET_POLICY_TYPE_PASSPHRASE, Words: 5},
+			check: func(t *testing.T, value string) {
+				require.Equal(t, len(strings.Split(value, "-")), 5)
+			},
+		},
+		{
+			policy: &SecretPolicy{Type: SECRET_POLICY_TYPE_RANDOM_BYTES, Bytes: 16},
+			check: func(t *testing.T, value string) {
+				bytes, err := hex.DecodeString(value)
+				require.Nil(t, err, "Expected hex. Got: %s", value)
+				require.Equal(t, len(bytes), 16)
+			},
+		},
+		{
+			policy: &SecretPolicy{Type: SECRET_POLICY_TYPE_RANDOM_BYTES, Bytes: 16, Encoding: ENCODING_BASE64},
+			check: func(t *testing.T, value string) {
+				bytes, err := base64.StdEncoding.DecodeString(value)
+				require.Nil(t, err, "Expected base64. Got: %s", value)
+				require.Equal(t, len(bytes), 16)
+			},
+		},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("GenerateSecret - Values - %v", idx), func(t *testing.T) {
+			err := ValidateSecretPolicy("test", given.policy)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			value, fields, err := GenerateSecret("test", given.policy)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			require.Nil(t, fields, "Expected no fields. Got: %v", fields)
+			given.check(t, value)
+		})
+	}
+}
+
+func TestGenerateSecretKeyPairs(t *testing.T) {
+	for _, algorithm := range []string{KEY_ALGORITHM_RSA, KEY_ALGORITHM_ECDSA, KEY_ALGORITHM_ED25519} {
+		t.Run(fmt.Sprintf("GenerateSecret - KeyPair - %v", algorithm), func(t *testing.T) {
+			policy := &SecretPolicy{Type: SECRET_POLICY_TYPE_KEY_PAIR, Algorithm: algorithm, Bits: 0}
+			if algorithm == KEY_ALGORITHM_RSA {
+				policy.Bits = 2048
+			}
+			err := ValidateSecretPolicy("test", policy)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			value, fields, err := GenerateSecret("test", policy)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			require.Equal(t, value, "")
+
+			block, _ := pem.Decode([]byte(fields["private_key"]))
+			require.NotNil(t, block, "Expected a PEM private key")
+			_, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+			require.Nil(t, err, "Expected a PKCS #8 private key. Got: %v", err)
+			block, _ = pem.Decode([]byte(fields["public_key"]))
+			require.NotNil(t, block, "Expected a PEM public key")
+			_, err = x509.ParsePKIXPublicKey(block.Bytes)
+			require.Nil(t, err, "Expected a PKIX public key. Got: %v", err)
+		})
+		t.Run(fmt.Sprintf("GenerateSecret - SshKey - %v", algorithm), func(t *testing.T) {
+			policy := &SecretPolicy{Type: SECRET_POLICY_TYPE_SSH_KEY, Algorithm: algorithm}
+			if algorithm == KEY_ALGORITHM_RSA {
+				policy.Bits = 2048
+			}
+			err := ValidateSecretPolicy("test", policy)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			_, fields, err := GenerateSecret("test", policy)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+
+			signer, err := ssh.ParsePrivateKey([]byte(fields["private_key"]))
+			require.Nil(t, err, "Expected an SSH private key. Got: %v", err)
+			publicKey, _, _, _, err := ssh.ParseAuthorizedKey([]byte(fields["public_key"]))
+			require.Nil(t, err, "Expected an authorized_keys public key. Got: %v", err)
+			require.Equal(t, publicKey.Marshal(), signer.PublicKey().Marshal())
+		})
+	}
+}
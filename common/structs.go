@@ -1,6 +1,7 @@
 package common
 
 import (
+	"context"
 	"time"
 
 	bsonPrimitive "go.mongodb.org/mongo-driver/bson/primitive"
@@ -30,6 +31,24 @@ func (u *User) GetStatusCode() int {
 	return u.StatusCode
 }
 
+// Namespace holds secrets, user groups and grants, so teams can reuse names. IsAdmin is whether the calling user can
+// administer it, as a global admin or a namespace admin.
+type Namespace struct {
+	Id          string     `json:"id"`
+	Name        string     `json:"name"`
+	Description string     `json:"description"`
+	IsAdmin     bool       `json:"is_admin"`
+	CreatedAt   *time.Time `json:"created_at,omitempty" faker:"-"`
+	StatusCode  int        `json:"-" faker:"-"`
+}
+
+func (n *Namespace) GetStatusCode() int {
+	if n.StatusCode == 0 {
+		return 200
+	}
+	return n.StatusCode
+}
+
 type UserGroup struct {
 	Id         string `json:"id"`
 	Name       string `json:"name"`
@@ -59,13 +78,24 @@ func (a *AccessToken) GetStatusCode() int {
 }
 
 type Secret struct {
-	Id          string `json:"id"`
-	Name        string `json:"name"`
-	Value       string `json:"value,omitempty"`
-	Description string `json:"description"`
-	CreatedBy   string `json:"created_by"`
-	UpdatedBy   string `json:"updated_by"`
-	StatusCode  int    `json:"-" faker:"-"`
+	Id          string            `json:"id"`
+	Namespace   string            `json:"-"`
+	Name        string            `json:"name"`
+	Value       string            `json:"value,omitempty"`
+	Fields      map[string]string `json:"fields,omitempty" faker:"-"`
+	ValueType   string            `json:"value_type,omitempty"`
+	ContentType string            `json:"content_type,omitempty"`
+	Filename    string            `json:"filename,omitempty"`
+	Description string            `json:"description"`
+	Labels      map[string]string `json:"labels,omitempty" faker:"-"`
+	CreatedBy   string            `json:"created_by"`
+	UpdatedBy   string            `json:"updated_by"`
+	Version     int               `json:"version"`
+	VersionId   string            `json:"-"`
+	ExpiresAt   *time.Time        `json:"expires_at,omitempty" faker:"-"`
+	CreatedAt   *time.Time        `json:"created_at,omitempty" faker:"-"`
+	UpdatedAt   *time.Time        `json:"updated_at,omitempty" faker:"-"`
+	StatusCode  int               `json:"-" faker:"-"`
 }
 
 func (s *Secret) GetStatusCode() int {
@@ -75,17 +105,190 @@ func (s *Secret) GetStatusCode() int {
 	return s.StatusCode
 }
 
+// SecretField is a single field of a multi-field secret
+type SecretField struct {
+	SecretName string `json:"secret_name"`
+	Field      string `json:"field"`
+	Value      string `json:"value"`
+	Version    int    `json:"version"`
+	StatusCode int    `json:"-" faker:"-"`
+}
+
+func (f *SecretField) GetStatusCode() int {
+	if f.StatusCode == 0 {
+		return 200
+	}
+	return f.StatusCode
+}
+
+// SecretFile is the decrypted content of a file secret, which is written to the response as is rather than as JSON
+type SecretFile struct {
+	Name        string
+	Version     int
+	ContentType string
+	Filename    string
+	Data        []byte
+}
+
+type SecretVersion struct {
+	Version   int       `json:"version"`
+	IsCurrent bool      `json:"is_current"`
+	UpdatedBy string    `json:"updated_by"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// RekeyableSecretVersion is a secret version, with its encrypted value, to be re-encrypted under a new data key. Id is
+// the id of the version and of its current data key. CreatedBy is the id of the secret's creator.
+type RekeyableSecretVersion struct {
+	Id         string
+	SecretId   string
+	SecretName string
+	Namespace  string
+	Version    int
+	Value      string
+}
+
+// DeletedRecord is a soft-deleted secret, user or user group. DeletedBy is the name of the user who last updated it,
+// which is whoever deleted it unless it expired. PurgedAt is only set for purged secrets, which can't be restored.
+type DeletedRecord struct {
+	Id        string     `json:"id"`
+	Name      string     `json:"name"`
+	DeletedBy string     `json:"deleted_by"`
+	DeletedAt time.Time  `json:"deleted_at"`
+	PurgedAt  *time.Time `json:"purged_at,omitempty"`
+}
+
+// PurgeableSecret is a deleted secret to be purged. CreatedBy is the id of the secret's creator.
+type PurgeableSecret struct {
+	Id        string
+	Name      string
+	Namespace string
+}
+
+// SecretRekeyStatus reports the progress of the scheduled secret rekey. The Last fields describe the most recent
+// run, and Pending is the number of versions whose data key is older than MaxKeyAgeDays.
+type SecretRekeyStatus struct {
+	Enabled       bool       `json:"enabled"`
+	MaxKeyAgeDays int        `json:"max_key_age_days,omitempty"`
+	Pending       int        `json:"pending"`
+	LastRunAt     *time.Time `json:"last_run_at,omitempty"`
+	LastRekeyed   int        `json:"last_rekeyed"`
+	LastFailed    int        `json:"last_failed"`
+	TotalRekeyed  int        `json:"total_rekeyed"`
+	TotalFailed   int        `json:"total_failed"`
+}
+
+// DatabaseRole creates dynamic credentials on a database. ConnectionUrl is encrypted at rest and never returned.
+type DatabaseRole struct {
+	Id                   string     `json:"id"`
+	Name                 string     `json:"name"`
+	ConnectionUrl        string     `json:"-"`
+	CreationStatements   []string   `json:"creation_statements" faker:"-"`
+	RenewStatements      []string   `json:"renew_statements" faker:"-"`
+	RevocationStatements []string   `json:"revocation_statements" faker:"-"`
+	DefaultTtlSeconds    int        `json:"default_ttl_seconds"`
+	MaxTtlSeconds        int        `json:"max_ttl_seconds"`
+	CreatedBy            string     `json:"created_by"`
+	CreatedAt            *time.Time `json:"created_at,omitempty" faker:"-"`
+	StatusCode           int        `json:"-" faker:"-"`
+}
+
+func (r *DatabaseRole) GetStatusCode() int {
+	if r.StatusCode == 0 {
+		return 200
+	}
+	return r.StatusCode
+}
+
+// Lease tracks the dynamic credentials created for a user until they're revoked. A lease can be renewed up to its
+// MaxExpiresAt.
+type Lease struct {
+	Id           string     `json:"id"`
+	RoleId       string     `json:"-"`
+	RoleName     string     `json:"role_name"`
+	UserId       string     `json:"user_id"`
+	Username     string     `json:"username"`
+	ExpiresAt    time.Time  `json:"expires_at"`
+	MaxExpiresAt time.Time  `json:"max_expires_at"`
+	RevokedAt    *time.Time `json:"revoked_at,omitempty" faker:"-"`
+	StatusCode   int        `json:"-" faker:"-"`
+}
+
+func (l *Lease) GetStatusCode() int {
+	if l.StatusCode == 0 {
+		return 200
+	}
+	return l.StatusCode
+}
+
+// DatabaseCredentials are the dynamic credentials created for a lease. The password can't be fetched again.
+type DatabaseCredentials struct {
+	LeaseId      string    `json:"lease_id"`
+	RoleName     string    `json:"role_name"`
+	Username     string    `json:"username"`
+	Password     string    `json:"password"`
+	ExpiresAt    time.Time `json:"expires_at"`
+	MaxExpiresAt time.Time `json:"max_expires_at"`
+	StatusCode   int       `json:"-" faker:"-"`
+}
+
+func (c *DatabaseCredentials) GetStatusCode() int {
+	if c.StatusCode == 0 {
+		return 200
+	}
+	return c.StatusCode
+}
+
+// TransitKey encrypts or signs data for users without the data being stored. Rotating the key adds a version; new
+// ciphertexts and signatures use LatestVersion, and every earlier version can still decrypt and verify.
+type TransitKey struct {
+	Id            string     `json:"id"`
+	Name          string     `json:"name"`
+	Type          string     `json:"type"`
+	LatestVersion int        `json:"latest_version"`
+	CreatedBy     string     `json:"created_by"`
+	CreatedAt     *time.Time `json:"created_at,omitempty" faker:"-"`
+	UpdatedAt     *time.Time `json:"updated_at,omitempty" faker:"-"`
+	StatusCode    int        `json:"-" faker:"-"`
+}
+
+func (k *TransitKey) GetStatusCode() int {
+	if k.StatusCode == 0 {
+		return 200
+	}
+	return k.StatusCode
+}
+
+// TransitKeyVersion is a version of a transit key. PublicKey is only set for asymmetric signing keys.
+type TransitKeyVersion struct {
+	Id        string     `json:"-"`
+	Version   int        `json:"version"`
+	PublicKey string     `json:"public_key,omitempty"`
+	CreatedAt *time.Time `json:"created_at,omitempty" faker:"-"`
+}
+
 type EncryptedSecret struct {
-	Id  string `json:"_id" bson:"_id"`
-	Key string `json:"key" bson:"key"`
-	Iv  string `json:"iv" bson:"iv"`
+	Id    string `json:"_id" bson:"_id"`
+	Key   string `json:"key" bson:"key"`
+	Iv    string `json:"iv" bson:"iv"`
+	KekId string `json:"kek_id,omitempty" bson:"kek_id,omitempty"`
 }
 
 type AccessLog struct {
+	Id         string                 `json:"id,omitempty" bson:"_id,omitempty"`
 	UserId     string                 `json:"user_id" bson:"user_id"`
 	ActionType string                 `json:"action_type" bson:"action_type"`
 	KeyName    string                 `json:"key_name" bson:"key_name"`
+	TargetType string                 `json:"target_type,omitempty" bson:"target_type,omitempty"`
+	TargetId   string                 `json:"target_id,omitempty" bson:"target_id,omitempty"`
+	Outcome    string                 `json:"outcome,omitempty" bson:"outcome,omitempty"`
+	RequestId  string                 `json:"request_id,omitempty" bson:"request_id,omitempty"`
+	SourceIp   string                 `json:"source_ip,omitempty" bson:"source_ip,omitempty"`
+	UserAgent  string                 `json:"user_agent,omitempty" bson:"user_agent,omitempty"`
 	AccessAt   bsonPrimitive.DateTime `json:"access_at" bson:"access_at"`
+	Sequence   int64                  `json:"sequence,omitempty" bson:"sequence,omitempty"`
+	PrevHash   string                 `json:"prev_hash,omitempty" bson:"prev_hash,omitempty"`
+	Hash       string                 `json:"hash,omitempty" bson:"hash,omitempty"`
 }
 
 func NewAccessLog(userId, actionType, keyName string) *AccessLog {
@@ -97,10 +300,72 @@ func NewAccessLog(userId, actionType, keyName string) *AccessLog {
 	}
 }
 
+// NewAuditLog builds an access log for the outcome of an action on a target. Secret targets also set KeyName.
+func NewAuditLog(ctx context.Context, userId, actionType, targetType, targetId string, actionErr error) *AccessLog {
+	keyName := ""
+	if targetType == TARGET_TYPE_SECRET {
+		keyName = targetId
+	}
+	source := FetchRequestSourceFromContext(ctx)
+	log := NewAccessLog(userId, actionType, keyName)
+	log.TargetType = targetType
+	log.TargetId = targetId
+	log.Outcome = OutcomeForError(actionErr)
+	log.RequestId = FetchRequestIdFromContext(ctx)
+	log.SourceIp = source.Ip
+	log.UserAgent = source.UserAgent
+	return log
+}
+
+// OutcomeForError classifies the error an action returned for the access log
+func OutcomeForError(err error) string {
+	switch err.(type) {
+	case nil:
+		return OUTCOME_ALLOWED
+	case AuthorizationError, ResourceAccessDeniedError:
+		return OUTCOME_DENIED
+	case ResourceNotFoundError, ResourceExpiredError:
+		return OUTCOME_NOT_FOUND
+	default:
+		return OUTCOME_ERROR
+	}
+}
+
+// ListAccessLogsRequest filters access logs. Empty string filters match every log.
 type ListAccessLogsRequest struct {
-	UserId    string
-	PageSize  int
-	Offset    int
-	StartDate time.Time
-	EndDate   time.Time
+	UserId     string
+	ActionType string
+	KeyName    string
+	TargetType string
+	TargetId   string
+	RequestId  string
+	Outcome    string
+	SourceIp   string
+	PageSize   int
+	Offset     int
+	// Cursor continues from the NextCursor of an earlier page. It can't be combined with Offset.
+	Cursor       string
+	IncludeTotal bool
+	StartDate    time.Time
+	EndDate      time.Time
+}
+
+// ListSecretsRequest filters and sorts secrets. Empty filters match every secret.
+type ListSecretsRequest struct {
+	NamePrefix   string
+	NameContains string
+	CreatedBy    string
+	// Labels are label selectors, e.g. "env=prod", "env!=dev", "team" or "!deprecated", which must all match
+	Labels        []string
+	CreatedAfter  *time.Time
+	CreatedBefore *time.Time
+	UpdatedAfter  *time.Time
+	UpdatedBefore *time.Time
+	SortBy        string
+	SortOrder     string
+	PageSize      int
+	Offset        int
+	// Cursor continues from the NextCursor of an earlier page. It can't be combined with Offset.
+	Cursor       string
+	IncludeTotal bool
 }
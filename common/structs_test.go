@@ -0,0 +1,63 @@
+package common
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewAuditLog(t *testing.T) {
+	ctx := InjectRequestIdIntoContext(context.Background(), "requestId")
+	ctx = InjectRequestSourceIntoContext(ctx, &RequestSource{Ip: "10.0.0.1", UserAgent: "curl/7.79.1"})
+	var tests = []struct {
+		testName   string
+		targetType string
+		actionErr  error
+		expected   *AccessLog
+	}{
+		{
+			testName:   "secret allowed",
+			targetType: TARGET_TYPE_SECRET,
+			expected:   &AccessLog{UserId: "userId", ActionType: "action", KeyName: "target", TargetType: TARGET_TYPE_SECRET, TargetId: "target", Outcome: OUTCOME_ALLOWED, RequestId: "requestId", SourceIp: "10.0.0.1", UserAgent: "curl/7.79.1"},
+		},
+		{
+			testName:   "user error",
+			targetType: TARGET_TYPE_USER,
+			actionErr:  fmt.Errorf("oh no"),
+			expected:   &AccessLog{UserId: "userId", ActionType: "action", TargetType: TARGET_TYPE_USER, TargetId: "target", Outcome: OUTCOME_ERROR, RequestId: "requestId", SourceIp: "10.0.0.1", UserAgent: "curl/7.79.1"},
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("NewAuditLog - %v", given.testName), func(t *testing.T) {
+			result := NewAuditLog(ctx, "userId", "action", given.targetType, "target", given.actionErr)
+			given.expected.AccessAt = result.AccessAt
+			require.Equal(t, result, given.expected, "Result, %+v, does not equal expected, %+v", result, given.expected)
+		})
+	}
+}
+
+func TestOutcomeForError(t *testing.T) {
+	var tests = []struct {
+		testName string
+		err      error
+		expected string
+	}{
+		{testName: "nil", err: nil, expected: OUTCOME_ALLOWED},
+		{testName: "authorization", err: NewAuthorizationError(), expected: OUTCOME_DENIED},
+		{testName: "access denied", err: NewResourceAccessDeniedError("op", "name", "value"), expected: OUTCOME_DENIED},
+		{testName: "not found", err: NewResourceNotFoundError("op", "name", "value"), expected: OUTCOME_NOT_FOUND},
+		{testName: "expired", err: NewResourceExpiredError("op", "name", "value"), expected: OUTCOME_NOT_FOUND},
+		{testName: "invalid params", err: NewInvalidParamsError("op", "oh no"), expected: OUTCOME_ERROR},
+		{testName: "other", err: fmt.Errorf("oh no"), expected: OUTCOME_ERROR},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("OutcomeForError - %v", given.testName), func(t *testing.T) {
+			result := OutcomeForError(given.err)
+			require.Equal(t, result, given.expected, "Result, %v, does not equal expected, %v", result, given.expected)
+		})
+	}
+}
@@ -2,7 +2,6 @@ package tracer
 
 import (
 	"context"
-	"fmt"
 
 	sentry "github.com/getsentry/sentry-go"
 	"github.com/sirupsen/logrus"
@@ -0,0 +1,141 @@
+package common
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// NewTransitKeyMaterial generates the key of a new transit key version, stored in the secrets manager under id.
+// Encryption and HMAC keys are random bytes, and asymmetric keys are PKCS #8, both hex encoded like a secret's key.
+func NewTransitKeyMaterial(id, keyType string) (*EncryptedSecret, error) {
+	op := "NewTransitKeyMaterial"
+	var key []byte
+	var err error
+	policy, ok := transitSigningPolicies[keyType]
+	if ok {
+		key, err = newTransitSigningKey(op, policy)
+	} else {
+		key, err = GenRandBytes(KEY_SIZE)
+	}
+	if err != nil {
+		return nil, NewInternalServerErrorFromError(op, err)
+	}
+	return &EncryptedSecret{Id: id, Key: hex.EncodeToString(key)}, nil
+}
+
+// ValidateTransitKeyType checks that keyType is one of TRANSIT_KEY_TYPES
+func ValidateTransitKeyType(operation, keyType string) error {
+	for _, t := range TRANSIT_KEY_TYPES {
+		if keyType == t {
+			return nil
+		}
+	}
+	return NewInvalidParamsError(operation, "Expected key type of %s. Got %s", strings.Join(TRANSIT_KEY_TYPES, ", "), keyType)
+}
+
+// CheckTransitKeyUse returns an invalid params error if key's type can't be used for use, one of the
+// TRANSIT_KEY_USE_ constants
+func CheckTransitKeyUse(operation string, key *TransitKey, use string) error {
+	var ok bool
+	switch use {
+	case TRANSIT_KEY_USE_ENCRYPT:
+		ok = key.Type == TRANSIT_KEY_TYPE_AES256_GCM
+	case TRANSIT_KEY_USE_SIGN:
+		ok = key.Type != TRANSIT_KEY_TYPE_AES256_GCM
+	case TRANSIT_KEY_USE_EXPORT:
+		_, ok = transitSigningPolicies[key.Type]
+	}
+	if !ok {
+		return NewInvalidParamsError(operation, "Transit key %s of type %s can't %s", key.Name, key.Type, use)
+	}
+	return nil
+}
+
+// FormatTransitCiphertext prefixes sealed data with the key version it was sealed with, e.g. vault:v2:{base64}
+func FormatTransitCiphertext(version int, sealed []byte) string {
+	return fmt.Sprintf("%s%d:%s", TRANSIT_CIPHERTEXT_PREFIX, version, base64.StdEncoding.EncodeToString(sealed))
+}
+
+// ParseTransitCiphertext reverses FormatTransitCiphertext, returning the key version and the sealed data
+func ParseTransitCiphertext(operation, ciphertext string) (int, []byte, error) {
+	return parseTransitValue(operation, "ciphertext", ciphertext)
+}
+
+// ParseTransitSignature returns the key version and the raw signature of a signature from TransitSign
+func ParseTransitSignature(operation, signature string) (int, []byte, error) {
+	return parseTransitValue(operation, "signature", signature)
+}
+
+func parseTransitValue(operation, name, value string) (int, []byte, error) {
+	parts := strings.SplitN(strings.TrimPrefix(value, TRANSIT_CIPHERTEXT_PREFIX), ":", 2)
+	if !strings.HasPrefix(value, TRANSIT_CIPHERTEXT_PREFIX) || len(parts) != 2 {
+		return 0, nil, NewInvalidParamsError(operation, "Expected %s of the form %sN:DATA", name, TRANSIT_CIPHERTEXT_PREFIX)
+	}
+	version, err := strconv.Atoi(parts[0])
+	if err != nil || version < 1 {
+		return 0, nil, NewInvalidParamsError(operation, "Expected a positive %s key version. Got %s", name, parts[0])
+	}
+	data, err := base64.StdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return 0, nil, NewInvalidParamsError(operation, "Expected base64 %s data", name)
+	}
+	return version, data, nil
+}
+
+// TransitEncrypt seals plaintext with a version of a transit key, whose hex-encoded key is in keyMaterial
+func TransitEncrypt(operation string, version int, keyMaterial *EncryptedSecret, plaintext []byte) (string, error) {
+	key, err := hex.DecodeString(keyMaterial.Key)
+	if err != nil {
+		return "", NewInternalServerErrorFromError(operation, err)
+	}
+	sealed, err := SealBytes(key, plaintext, nil)
+	if err != nil {
+		return "", err
+	}
+	return FormatTransitCiphertext(version, sealed), nil
+}
+
+// TransitDecrypt reverses TransitEncrypt, given the sealed data from ParseTransitCiphertext. Ciphertexts that don't
+// open are the caller's mistake, so the error is an invalid params error.
+func TransitDecrypt(operation string, keyMaterial *EncryptedSecret, sealed []byte) ([]byte, error) {
+	key, err := hex.DecodeString(keyMaterial.Key)
+	if err != nil {
+		return nil, NewInternalServerErrorFromError(operation, err)
+	}
+	plaintext, err := OpenBytes(key, sealed, nil)
+	if err != nil {
+		return nil, NewInvalidParamsError(operation, "Could not decrypt ciphertext")
+	}
+	return plaintext, nil
+}
+
+// DecodeTransitPlaintext decodes the base64 plaintext of a transit request, and checks its size
+func DecodeTransitPlaintext(operation, plaintext string) ([]byte, error) {
+	return DecodeTransitInput(operation, "plaintext", plaintext)
+}
+
+// DecodeTransitInput decodes a base64 field of a transit request, named name in errors, and checks its size
+func DecodeTransitInput(operation, name, value string) ([]byte, error) {
+	data, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		return nil, NewInvalidParamsError(operation, "Expected base64 %s", name)
+	}
+	if len(data) > MAX_TRANSIT_PLAINTEXT_BYTES {
+		return nil, NewInvalidParamsError(operation, "Expected %s of at most %d bytes. Got %d", name, MAX_TRANSIT_PLAINTEXT_BYTES, len(data))
+	}
+	return data, nil
+}
+
+// TransitDataKeySize returns the size in bytes of a data key of bits, which is 128 or 256, or the default if it's 0
+func TransitDataKeySize(operation string, bits int) (int, error) {
+	if bits == 0 {
+		bits = DEFAULT_TRANSIT_DATA_KEY_BITS
+	}
+	if bits != 128 && bits != 256 {
+		return 0, NewInvalidParamsError(operation, "Expected bits of 128 or 256. Got %d", bits)
+	}
+	return bits / 8, nil
+}
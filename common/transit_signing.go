@@ -0,0 +1,158 @@
+package common
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+)
+
+// transitSigningPolicies are the asymmetric transit key types, as the policies their keys are generated from
+var transitSigningPolicies = map[string]*SecretPolicy{
+	TRANSIT_KEY_TYPE_ED25519:      {Algorithm: KEY_ALGORITHM_ED25519},
+	TRANSIT_KEY_TYPE_ECDSA_P256:   {Algorithm: KEY_ALGORITHM_ECDSA, Bits: 256},
+	TRANSIT_KEY_TYPE_RSA_PSS_2048: {Algorithm: KEY_ALGORITHM_RSA, Bits: 2048},
+	TRANSIT_KEY_TYPE_RSA_PSS_4096: {Algorithm: KEY_ALGORITHM_RSA, Bits: 4096},
+}
+
+// transitPssOptions salts RSA-PSS signatures with as many bytes as the hash, which is what openssl and most
+// libraries verify by default
+var transitPssOptions = &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash, Hash: crypto.SHA256}
+
+// newTransitSigningKey generates an asymmetric key, returned as PKCS #8 DER
+func newTransitSigningKey(operation string, policy *SecretPolicy) ([]byte, error) {
+	key, err := generateKey(operation, policy)
+	if err != nil {
+		return nil, err
+	}
+	return x509.MarshalPKCS8PrivateKey(key)
+}
+
+func parseTransitSigningKey(operation string, keyMaterial *EncryptedSecret) (crypto.Signer, error) {
+	der, err := hex.DecodeString(keyMaterial.Key)
+	if err != nil {
+		return nil, NewInternalServerErrorFromError(operation, err)
+	}
+	key, err := x509.ParsePKCS8PrivateKey(der)
+	if err != nil {
+		return nil, NewInternalServerErrorFromError(operation, err)
+	}
+	signer, ok := key.(crypto.Signer)
+	if !ok {
+		return nil, NewInternalServerError(operation, "Expected a signing key. Got %T", key)
+	}
+	return signer, nil
+}
+
+// transitDigest returns what a key of keyType signs for input. ECDSA and RSA sign its SHA-256 digest, which callers
+// can compute themselves with prehashed, e.g. for artifacts over MAX_TRANSIT_PLAINTEXT_BYTES. Ed25519 and HMAC keys
+// sign input as it is.
+func transitDigest(operation, keyType string, input []byte, prehashed bool) ([]byte, error) {
+	switch keyType {
+	case TRANSIT_KEY_TYPE_ED25519, TRANSIT_KEY_TYPE_HMAC_SHA256:
+		if prehashed {
+			return nil, NewInvalidParamsError(operation, "Transit keys of type %s can't sign prehashed input", keyType)
+		}
+		return input, nil
+	}
+	if !prehashed {
+		digest := sha256.Sum256(input)
+		return digest[:], nil
+	}
+	if len(input) != sha256.Size {
+		return nil, NewInvalidParamsError(operation, "Expected a prehashed input of %d bytes. Got %d", sha256.Size, len(input))
+	}
+	return input, nil
+}
+
+func transitHmac(operation string, keyMaterial *EncryptedSecret, input []byte) ([]byte, error) {
+	key, err := hex.DecodeString(keyMaterial.Key)
+	if err != nil {
+		return nil, NewInternalServerErrorFromError(operation, err)
+	}
+	mac := hmac.New(sha256.New, key)
+	mac.Write(input)
+	return mac.Sum(nil), nil
+}
+
+// TransitSign signs input with a version of a transit key of keyType, returning the signature prefixed with the
+// version, like a transit ciphertext
+func TransitSign(operation, keyType string, version int, keyMaterial *EncryptedSecret, input []byte, prehashed bool) (string, error) {
+	digest, err := transitDigest(operation, keyType, input, prehashed)
+	if err != nil {
+		return "", err
+	}
+	if keyType == TRANSIT_KEY_TYPE_HMAC_SHA256 {
+		mac, err := transitHmac(operation, keyMaterial, digest)
+		if err != nil {
+			return "", err
+		}
+		return FormatTransitCiphertext(version, mac), nil
+	}
+	signer, err := parseTransitSigningKey(operation, keyMaterial)
+	if err != nil {
+		return "", err
+	}
+	var signature []byte
+	switch key := signer.(type) {
+	case ed25519.PrivateKey:
+		signature = ed25519.Sign(key, digest)
+	case *ecdsa.PrivateKey:
+		signature, err = ecdsa.SignASN1(rand.Reader, key, digest)
+	case *rsa.PrivateKey:
+		signature, err = rsa.SignPSS(rand.Reader, key, crypto.SHA256, digest, transitPssOptions)
+	default:
+		return "", NewInternalServerError(operation, "Unexpected signing key of type %T", signer)
+	}
+	if err != nil {
+		return "", NewInternalServerErrorFromError(operation, err)
+	}
+	return FormatTransitCiphertext(version, signature), nil
+}
+
+// TransitVerify checks a raw signature from ParseTransitSignature against input
+func TransitVerify(operation, keyType string, keyMaterial *EncryptedSecret, input, signature []byte, prehashed bool) (bool, error) {
+	digest, err := transitDigest(operation, keyType, input, prehashed)
+	if err != nil {
+		return false, err
+	}
+	if keyType == TRANSIT_KEY_TYPE_HMAC_SHA256 {
+		mac, err := transitHmac(operation, keyMaterial, digest)
+		if err != nil {
+			return false, err
+		}
+		return hmac.Equal(mac, signature), nil
+	}
+	signer, err := parseTransitSigningKey(operation, keyMaterial)
+	if err != nil {
+		return false, err
+	}
+	switch key := signer.Public().(type) {
+	case ed25519.PublicKey:
+		return ed25519.Verify(key, digest, signature), nil
+	case *ecdsa.PublicKey:
+		return ecdsa.VerifyASN1(key, digest, signature), nil
+	case *rsa.PublicKey:
+		return rsa.VerifyPSS(key, crypto.SHA256, digest, signature, transitPssOptions) == nil, nil
+	}
+	return false, NewInternalServerError(operation, "Unexpected signing key of type %T", signer)
+}
+
+// TransitPublicKey returns the PEM encoded PKIX public key of an asymmetric transit key version
+func TransitPublicKey(operation string, keyMaterial *EncryptedSecret) (string, error) {
+	signer, err := parseTransitSigningKey(operation, keyMaterial)
+	if err != nil {
+		return "", err
+	}
+	der, err := x509.MarshalPKIXPublicKey(signer.Public())
+	if err != nil {
+		return "", NewInternalServerErrorFromError(operation, err)
+	}
+	return string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})), nil
+}
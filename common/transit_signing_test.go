@@ -0,0 +1,112 @@
+package common
+
+import (
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransitSignVerify(t *testing.T) {
+	var tests = []string{
+		TRANSIT_KEY_TYPE_ED25519,
+		TRANSIT_KEY_TYPE_ECDSA_P256,
+		TRANSIT_KEY_TYPE_RSA_PSS_2048,
+		TRANSIT_KEY_TYPE_HMAC_SHA256,
+	}
+
+	for idx, keyType := range tests {
+		t.Run(fmt.Sprintf("TransitSignVerify - Successes - %v", idx), func(t *testing.T) {
+			keyMaterial, err := NewTransitKeyMaterial("versionId", keyType)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			input := []byte("release-1.2.3.tar.gz")
+
+			signature, err := TransitSign("test", keyType, 2, keyMaterial, input, false)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			require.True(t, strings.HasPrefix(signature, "vault:v2:"), "Unexpected signature %s", signature)
+
+			version, raw, err := ParseTransitSignature("test", signature)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			require.Equal(t, version, 2)
+			valid, err := TransitVerify("test", keyType, keyMaterial, input, raw, false)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			require.True(t, valid, "Expected signature to be valid")
+
+			valid, err = TransitVerify("test", keyType, keyMaterial, []byte("release-1.2.4.tar.gz"), raw, false)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			require.False(t, valid, "Expected signature of other input to be invalid")
+
+			otherKey, err := NewTransitKeyMaterial("otherId", keyType)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			valid, err = TransitVerify("test", keyType, otherKey, input, raw, false)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			require.False(t, valid, "Expected signature to be invalid under another key")
+		})
+	}
+}
+
+func TestTransitSignPrehashed(t *testing.T) {
+	input := []byte("release-1.2.3.tar.gz")
+	digest := sha256.Sum256(input)
+
+	for idx, keyType := range []string{TRANSIT_KEY_TYPE_ECDSA_P256, TRANSIT_KEY_TYPE_RSA_PSS_2048} {
+		t.Run(fmt.Sprintf("TransitSignPrehashed - Successes - %v", idx), func(t *testing.T) {
+			keyMaterial, err := NewTransitKeyMaterial("versionId", keyType)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			signature, err := TransitSign("test", keyType, 1, keyMaterial, digest[:], true)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			_, raw, err := ParseTransitSignature("test", signature)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+
+			valid, err := TransitVerify("test", keyType, keyMaterial, input, raw, false)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			require.True(t, valid, "Expected a prehashed signature to verify against the input")
+
+			_, err = TransitSign("test", keyType, 1, keyMaterial, input, true)
+			require.NotNil(t, err, "Expected an error for a prehashed input that isn't a digest")
+		})
+	}
+
+	for idx, keyType := range []string{TRANSIT_KEY_TYPE_ED25519, TRANSIT_KEY_TYPE_HMAC_SHA256} {
+		t.Run(fmt.Sprintf("TransitSignPrehashed - Errors - %v", idx), func(t *testing.T) {
+			keyMaterial, err := NewTransitKeyMaterial("versionId", keyType)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			_, err = TransitSign("test", keyType, 1, keyMaterial, digest[:], true)
+			require.NotNil(t, err, "Expected an error signing prehashed input")
+			require.IsType(t, err, InvalidParamsError{})
+		})
+	}
+}
+
+func TestTransitPublicKey(t *testing.T) {
+	keyMaterial, err := NewTransitKeyMaterial("versionId", TRANSIT_KEY_TYPE_ECDSA_P256)
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+	publicKey, err := TransitPublicKey("test", keyMaterial)
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+
+	block, _ := pem.Decode([]byte(publicKey))
+	require.NotNil(t, block, "Expected a PEM public key. Got %s", publicKey)
+	require.Equal(t, block.Type, "PUBLIC KEY")
+	_, err = x509.ParsePKIXPublicKey(block.Bytes)
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+}
+
+func TestCheckTransitKeyUse(t *testing.T) {
+	aesKey := &TransitKey{Name: "pii", Type: TRANSIT_KEY_TYPE_AES256_GCM}
+	hmacKey := &TransitKey{Name: "webhooks", Type: TRANSIT_KEY_TYPE_HMAC_SHA256}
+	ed25519Key := &TransitKey{Name: "releases", Type: TRANSIT_KEY_TYPE_ED25519}
+
+	require.Nil(t, CheckTransitKeyUse("test", aesKey, TRANSIT_KEY_USE_ENCRYPT))
+	require.NotNil(t, CheckTransitKeyUse("test", aesKey, TRANSIT_KEY_USE_SIGN))
+	require.NotNil(t, CheckTransitKeyUse("test", hmacKey, TRANSIT_KEY_USE_ENCRYPT))
+	require.Nil(t, CheckTransitKeyUse("test", hmacKey, TRANSIT_KEY_USE_SIGN))
+	require.NotNil(t, CheckTransitKeyUse("test", hmacKey, TRANSIT_KEY_USE_EXPORT))
+	require.Nil(t, CheckTransitKeyUse("test", ed25519Key, TRANSIT_KEY_USE_EXPORT))
+
+	require.Nil(t, ValidateTransitKeyType("test", TRANSIT_KEY_TYPE_RSA_PSS_4096))
+	require.NotNil(t, ValidateTransitKeyType("test", "rsa-pkcs1-2048"))
+}
@@ -0,0 +1,83 @@
+package common
+
+import (
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransitEncryptDecrypt(t *testing.T) {
+	keyMaterial, err := NewTransitKeyMaterial("versionId", TRANSIT_KEY_TYPE_AES256_GCM)
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+	require.Equal(t, keyMaterial.Id, "versionId")
+
+	ciphertext, err := TransitEncrypt("test", 3, keyMaterial, []byte("123-45-6789"))
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+	require.True(t, strings.HasPrefix(ciphertext, "vault:v3:"), "Unexpected ciphertext %s", ciphertext)
+
+	version, sealed, err := ParseTransitCiphertext("test", ciphertext)
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+	require.Equal(t, version, 3)
+	plaintext, err := TransitDecrypt("test", keyMaterial, sealed)
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+	require.Equal(t, string(plaintext), "123-45-6789")
+
+	otherKey, err := NewTransitKeyMaterial("otherId", TRANSIT_KEY_TYPE_AES256_GCM)
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+	_, err = TransitDecrypt("test", otherKey, sealed)
+	require.NotNil(t, err, "Expected an error decrypting with the wrong key")
+	require.IsType(t, err, InvalidParamsError{})
+}
+
+func TestParseTransitCiphertextErrors(t *testing.T) {
+	var tests = []string{
+		"",
+		"vault:v1",
+		"v1:AAAA",
+		"vault:v0:AAAA",
+		"vault:vx:AAAA",
+		"vault:v1:not base64!",
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("ParseTransitCiphertext - Errors - %v", idx), func(t *testing.T) {
+			_, _, err := ParseTransitCiphertext("test", given)
+			require.NotNil(t, err, "Expected an error for %s", given)
+		})
+	}
+}
+
+func TestDecodeTransitPlaintext(t *testing.T) {
+	data, err := DecodeTransitPlaintext("test", base64.StdEncoding.EncodeToString([]byte("data")))
+	require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+	require.Equal(t, string(data), "data")
+
+	_, err = DecodeTransitPlaintext("test", "not base64!")
+	require.NotNil(t, err, "Expected an error for invalid base64")
+	_, err = DecodeTransitPlaintext("test", base64.StdEncoding.EncodeToString(make([]byte, MAX_TRANSIT_PLAINTEXT_BYTES+1)))
+	require.NotNil(t, err, "Expected an error for oversized plaintext")
+}
+
+func TestTransitDataKeySize(t *testing.T) {
+	var tests = []struct {
+		bits     int
+		expected int
+	}{
+		{bits: 0, expected: 32},
+		{bits: 128, expected: 16},
+		{bits: 256, expected: 32},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("TransitDataKeySize - Successes - %v", given.bits), func(t *testing.T) {
+			size, err := TransitDataKeySize("test", given.bits)
+			require.Nil(t, err, "Expected err to be nil. Got: %v", err)
+			require.Equal(t, size, given.expected)
+		})
+	}
+	_, err := TransitDataKeySize("test", 192)
+	require.NotNil(t, err, "Expected an error for 192 bits")
+}
@@ -0,0 +1,29 @@
+package common
+
+// defaultWordList is the word list of passphrase policies without a wordListFile. Each word adds 8 bits of entropy.
+var defaultWordList = []string{
+	"able", "acid", "acorn", "actor", "adult", "agent", "alarm", "album", "alert", "alley", "amber", "angle",
+	"ankle", "apple", "apron", "arena", "armor", "arrow", "atlas", "attic", "audio", "aunt", "autumn", "award",
+	"bacon", "badge", "bagel", "baker", "bamboo", "banjo", "barn", "basil", "basket", "beach", "beard",
+	"beaver", "bench", "berry", "bicycle", "bison", "blanket", "blossom", "board", "bonus", "border", "bottle",
+	"bounce", "bracket", "bread", "breeze", "brick", "bridge", "bronze", "brook", "broom", "bubble", "bucket",
+	"buffalo", "bundle", "butter", "button", "cabin", "cactus", "camel", "camera", "candle", "canoe", "canyon",
+	"carbon", "carpet", "carrot", "castle", "cattle", "cedar", "cello", "chalk", "chapel", "cheese", "cherry",
+	"chess", "chimney", "cider", "cinema", "circus", "citrus", "clay", "cliff", "clock", "cloud", "clover",
+	"coach", "cobalt", "cocoa", "coffee", "comet", "copper", "coral", "cotton", "cougar", "crane", "crayon",
+	"cricket", "crystal", "cube", "cupboard", "curtain", "cushion", "daisy", "dance", "delta", "denim",
+	"desert", "diamond", "dinner", "dolphin", "donkey", "dragon", "drawer", "dream", "drum", "eagle", "earth",
+	"easel", "echo", "elbow", "elder", "ember", "emerald", "engine", "falcon", "feather", "fence", "fern",
+	"ferry", "fiddle", "field", "finch", "flag", "flame", "flute", "forest", "fossil", "fox", "frost", "galaxy",
+	"garden", "garlic", "gecko", "ginger", "giraffe", "glacier", "glove", "goat", "granite", "grape", "gravel",
+	"guitar", "hammer", "harbor", "harvest", "hazel", "helmet", "heron", "hickory", "honey", "horizon",
+	"hornet", "iceberg", "igloo", "island", "ivory", "jacket", "jaguar", "jasmine", "jelly", "jigsaw", "jungle",
+	"kettle", "kiwi", "koala", "ladder", "lagoon", "lantern", "laser", "lemon", "lettuce", "lilac", "lily",
+	"linen", "lizard", "lobster", "locket", "lotus", "magnet", "mango", "maple", "marble", "meadow", "melon",
+	"mermaid", "meteor", "mitten", "monkey", "mosaic", "moss", "mountain", "muffin", "mustard", "napkin",
+	"nectar", "needle", "nest", "noodle", "oasis", "ocean", "olive", "onion", "orange", "orbit", "orchid",
+	"otter", "owl", "oyster", "paddle", "palace", "panda", "panther", "paper", "parrot", "peach", "peanut",
+	"pebble", "pelican", "pepper", "piano", "pickle", "pillow", "pilot", "pine", "planet", "plum", "pocket",
+	"pony", "poppy", "potato", "prism", "pumpkin", "puzzle", "quartz", "quilt", "rabbit", "radar", "radish",
+	"raven", "reef", "ribbon",
+}
@@ -0,0 +1,159 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// BackupTables are the tables included in a backup, in an order in which they can be restored without breaking
+// foreign keys. Access tokens are left out, since restored users can fetch new ones.
+var BackupTables = []string{
+	"admin.users",
+	"admin.namespaces",
+	"admin.namespace_admins",
+	"admin.user_groups",
+	"admin.user_group_members",
+	"admin.secrets",
+	"admin.secret_versions",
+	"admin.secret_permissions",
+	"admin.secret_group_permissions",
+	"admin.database_roles",
+	"admin.database_role_permissions",
+	"admin.leases",
+	"admin.transit_keys",
+	"admin.transit_key_versions",
+	"admin.transit_key_permissions",
+}
+
+// backupTableFilters leave out rows the migrations create, which every database being restored to already has
+var backupTableFilters = map[string]string{
+	"admin.namespaces": fmt.Sprintf("WHERE id <> '%s'", common.DEFAULT_NAMESPACE_ID),
+}
+
+func isBackupTable(table string) bool {
+	for _, backupTable := range BackupTables {
+		if table == backupTable {
+			return true
+		}
+	}
+	return false
+}
+
+// DumpTable returns every row of a backup table, including inactive rows but not rows created by the migrations, as a
+// JSON array of objects keyed by column
+func DumpTable(ctx context.Context, db Database, table string) (json.RawMessage, error) {
+	operation := "DumpTable"
+	if !isBackupTable(table) {
+		return nil, common.NewInvalidParamsError(operation, "Unknown backup table %s", table)
+	}
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := fmt.Sprintf(`
+	SELECT	COALESCE(json_agg(t ORDER BY t.created_at, t.id), '[]')
+	FROM	%s t
+	%s
+	`, table, backupTableFilters[table])
+	rows, err := db.QueryContext(tracer.Context(), query)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	var dump []byte
+	for rows.Next() {
+		err = rows.Scan(&dump)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	if dump == nil {
+		return nil, common.NewDatabaseError(fmt.Errorf("no rows"), operation, "No result dumping %s", table)
+	}
+	return json.RawMessage(dump), nil
+}
+
+// CountTableRows counts every row of a backup table, including inactive rows but not rows created by the migrations
+func CountTableRows(ctx context.Context, db Database, table string) (int, error) {
+	operation := "CountTableRows"
+	if !isBackupTable(table) {
+		return 0, common.NewInvalidParamsError(operation, "Unknown backup table %s", table)
+	}
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := fmt.Sprintf(`
+	SELECT	COUNT(*)
+	FROM	%s
+	%s
+	`, table, backupTableFilters[table])
+	rows, err := db.QueryContext(tracer.Context(), query)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return 0, dbErr
+	}
+	defer rows.Close()
+
+	var count int
+	for rows.Next() {
+		err = rows.Scan(&count)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return 0, dbErr
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return 0, dbErr
+	}
+	return count, nil
+}
+
+// RestoreTable inserts rows dumped by DumpTable. The rows are inserted in a single statement, so rows can reference
+// each other, e.g. a user created by another user in the same dump.
+func RestoreTable(ctx context.Context, db Database, table string, dump json.RawMessage) (int64, error) {
+	operation := "RestoreTable"
+	if !isBackupTable(table) {
+		return 0, common.NewInvalidParamsError(operation, "Unknown backup table %s", table)
+	}
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := fmt.Sprintf(`
+	INSERT INTO %s
+	SELECT	*
+	FROM	json_populate_recordset(NULL::%s, $1)
+	`, table, table)
+	result, err := db.ExecContext(tracer.Context(), query, string(dump))
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return 0, dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return 0, dbErr
+	}
+	db.GetLogger().Debugf("%s restored %d rows to %s", operation, rowsAffected, table)
+	return rowsAffected, nil
+}
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+func TestDumpTableErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").
+				WillReturnRows(sqlmock.NewRows([]string{"json_agg"}).
+					AddRow([]byte("[]")).
+					RowError(0, fmt.Errorf("oh no not the row"))).
+				RowsWillBeClosed()
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").
+				WillReturnRows(sqlmock.NewRows([]string{"json_agg"})).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("DumpTable - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := DumpTable(context.Background(), dbMock, "admin.users")
+			require.NotNil(t, err, "no error in DumpTable: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+
+	t.Run("DumpTable - Errors - unknown table", func(t *testing.T) {
+		dbMock, err := NewMockDatabase()
+		require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+		result, err := DumpTable(context.Background(), dbMock, "admin.access_tokens")
+		require.NotNil(t, err, "no error in DumpTable: %v", err)
+		require.Nil(t, result, "Result was not nil: %v", result)
+	})
+}
+
+func TestDumpTableSuccesses(t *testing.T) {
+	dump := `[{"id": "id1", "name": "user1"}]`
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectQuery("SELECT(.*)FROM	admin.users t").
+		WillReturnRows(sqlmock.NewRows([]string{"json_agg"}).AddRow([]byte(dump))).
+		RowsWillBeClosed()
+
+	result, err := DumpTable(context.Background(), dbMock, "admin.users")
+	require.Nil(t, err, "error in DumpTable: %v", err)
+	require.Equal(t, result, json.RawMessage(dump), "Result %s did not equal expected %s", result, dump)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestDumpTableSkipsDefaultNamespace(t *testing.T) {
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectQuery("FROM	admin.namespaces t\\s+WHERE id <> '" + common.DEFAULT_NAMESPACE_ID + "'").
+		WillReturnRows(sqlmock.NewRows([]string{"json_agg"}).AddRow([]byte("[]"))).
+		RowsWillBeClosed()
+	dbMock.mock.ExpectQuery("FROM	admin.namespaces\\s+WHERE id <> '" + common.DEFAULT_NAMESPACE_ID + "'").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0)).
+		RowsWillBeClosed()
+
+	result, err := DumpTable(context.Background(), dbMock, "admin.namespaces")
+	require.Nil(t, err, "error in DumpTable: %v", err)
+	require.Equal(t, result, json.RawMessage("[]"), "Result %s did not equal expected []", result)
+	count, err := CountTableRows(context.Background(), dbMock, "admin.namespaces")
+	require.Nil(t, err, "error in CountTableRows: %v", err)
+	require.Equal(t, count, 0, "Result %v did not equal expected 0", count)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestCountTableRowsErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).
+					AddRow(1).
+					RowError(0, fmt.Errorf("oh no not the row"))).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("CountTableRows - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := CountTableRows(context.Background(), dbMock, "admin.secrets")
+			require.NotNil(t, err, "no error in CountTableRows: %v", err)
+			require.Equal(t, result, 0, "Expected 0 result, got: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestCountTableRowsSuccesses(t *testing.T) {
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectQuery("SELECT").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3)).
+		RowsWillBeClosed()
+
+	result, err := CountTableRows(context.Background(), dbMock, "admin.secrets")
+	require.Nil(t, err, "error in CountTableRows: %v", err)
+	require.Equal(t, result, 3, "Result %v did not equal expected 3", result)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestRestoreTableErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("INSERT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("RestoreTable - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := RestoreTable(context.Background(), dbMock, "admin.users", json.RawMessage("[]"))
+			require.NotNil(t, err, "no error in RestoreTable: %v", err)
+			require.Equal(t, result, int64(0), "Expected 0 result, got: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestRestoreTableSuccesses(t *testing.T) {
+	dump := `[{"id": "id1"}, {"id": "id2"}]`
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectExec("INSERT INTO admin.secrets").WithArgs(dump).WillReturnResult(sqlmock.NewResult(0, 2))
+
+	result, err := RestoreTable(context.Background(), dbMock, "admin.secrets", json.RawMessage(dump))
+	require.Nil(t, err, "error in RestoreTable: %v", err)
+	require.Equal(t, result, int64(2), "Result %v did not equal expected 2", result)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
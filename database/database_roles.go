@@ -0,0 +1,435 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/lib/pq"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// databaseRoleColumns are selected by every query that returns a database role, in the order scanDatabaseRole expects
+const databaseRoleColumns = `
+			r.id,
+			r.name,
+			r.connection_url,
+			r.creation_statements,
+			r.renew_statements,
+			r.revocation_statements,
+			r.default_ttl_seconds,
+			r.max_ttl_seconds,
+			created_by_user.name,
+			r.created_at`
+
+func scanDatabaseRole(rows *sql.Rows, extra ...interface{}) (*common.DatabaseRole, error) {
+	var row common.DatabaseRole
+	dest := []interface{}{
+		&row.Id,
+		&row.Name,
+		&row.ConnectionUrl,
+		pq.Array(&row.CreationStatements),
+		pq.Array(&row.RenewStatements),
+		pq.Array(&row.RevocationStatements),
+		&row.DefaultTtlSeconds,
+		&row.MaxTtlSeconds,
+		&row.CreatedBy,
+		&row.CreatedAt,
+	}
+	err := rows.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// CreateDatabaseRole stores a new role. Its ConnectionUrl must already be encrypted.
+func CreateDatabaseRole(ctx context.Context, db Database, callingUserId string, role *common.DatabaseRole) error {
+	operation := "CreateDatabaseRole"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	INSERT INTO  admin.database_roles (id, name, connection_url, creation_statements, renew_statements, revocation_statements, default_ttl_seconds, max_ttl_seconds, created_by, updated_by)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+	`
+	result, err := db.ExecContext(
+		tracer.Context(),
+		query,
+		role.Id,
+		role.Name,
+		role.ConnectionUrl,
+		pq.Array(role.CreationStatements),
+		pq.Array(role.RenewStatements),
+		pq.Array(role.RevocationStatements),
+		role.DefaultTtlSeconds,
+		role.MaxTtlSeconds,
+		callingUserId,
+		callingUserId,
+	)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	db.GetLogger().Debugf("%s created %d rows", operation, rowsAffected)
+	return nil
+}
+
+// ListDatabaseRoles returns active roles ordered by name, starting after the cursor if one is given. Connection URLs
+// aren't selected.
+func ListDatabaseRoles(ctx context.Context, db Database, limit, offset int, after *common.PageCursor) ([]*common.DatabaseRole, error) {
+	operation := "ListDatabaseRoles"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	afterId, afterName := cursorArgs(after)
+	query := `
+	SELECT	` + databaseRoleColumns + `
+	FROM	admin.database_roles r
+	JOIN	admin.users created_by_user
+		ON 	r.created_by = created_by_user.id
+	WHERE	r.is_active
+		AND ($1 = '' OR (r.name, r.id) > ($2, NULLIF($1, '')::uuid))
+	ORDER BY r.name, r.id
+	LIMIT	$3
+	OFFSET 	$4
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, afterId, afterName, limit, offset)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	roles := make([]*common.DatabaseRole, 0)
+
+	for rows.Next() {
+		role, err := scanDatabaseRole(rows)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+		role.ConnectionUrl = ""
+		roles = append(roles, role)
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	return roles, nil
+}
+
+// CountDatabaseRoles returns the number of active roles
+func CountDatabaseRoles(ctx context.Context, db Database) (int, error) {
+	query := `
+	SELECT	COUNT(*)
+	FROM	admin.database_roles r
+	WHERE	r.is_active
+	`
+	return count(ctx, db, "CountDatabaseRoles", query)
+}
+
+// GetDatabaseRole returns an active role by name
+func GetDatabaseRole(ctx context.Context, db Database, name string) (*common.DatabaseRole, error) {
+	operation := "GetDatabaseRole"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	SELECT	` + databaseRoleColumns + `
+	FROM	admin.database_roles r
+	JOIN	admin.users created_by_user
+		ON 	r.created_by = created_by_user.id
+	WHERE	r.name = $1
+		AND r.is_active
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, name)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	var role *common.DatabaseRole
+
+	for rows.Next() {
+		role, err = scanDatabaseRole(rows)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	if role == nil {
+		return nil, common.NewResourceNotFoundError(operation, "name", name)
+	}
+	return role, nil
+}
+
+// GetDatabaseRoleById returns a role by id, even if it's been deleted, so leases created by it can still be revoked
+func GetDatabaseRoleById(ctx context.Context, db Database, roleId string) (*common.DatabaseRole, error) {
+	operation := "GetDatabaseRoleById"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	SELECT	` + databaseRoleColumns + `
+	FROM	admin.database_roles r
+	JOIN	admin.users created_by_user
+		ON 	r.created_by = created_by_user.id
+	WHERE	r.id = $1
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, roleId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	var role *common.DatabaseRole
+
+	for rows.Next() {
+		role, err = scanDatabaseRole(rows)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	if role == nil {
+		return nil, common.NewResourceNotFoundError(operation, "id", roleId)
+	}
+	return role, nil
+}
+
+// GetDatabaseRoleWithAccess returns an active role if the user is an admin, or is granted the role directly or
+// through a group
+func GetDatabaseRoleWithAccess(ctx context.Context, db Database, user *common.User, name string) (*common.DatabaseRole, error) {
+	operation := "GetDatabaseRoleWithAccess"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	SELECT	` + databaseRoleColumns + `,
+			($1 OR EXISTS (
+				SELECT	1
+				FROM	admin.database_role_permissions p
+				LEFT JOIN admin.user_group_members ugm
+					ON 	ugm.user_group_id = p.user_group_id
+					AND ugm.user_id = $2
+					AND ugm.is_active
+				WHERE	p.database_role_id = r.id
+					AND p.is_active
+					AND (p.user_id = $2 OR ugm.id IS NOT NULL)
+			)) AS has_access
+	FROM	admin.database_roles r
+	JOIN	admin.users created_by_user
+		ON 	r.created_by = created_by_user.id
+	WHERE	r.name = $3
+		AND r.is_active
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, user.IsAdmin(), user.Id, name)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	var role *common.DatabaseRole
+	var hasAccess bool
+
+	for rows.Next() {
+		role, err = scanDatabaseRole(rows, &hasAccess)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	if role == nil {
+		return nil, common.NewResourceNotFoundError(operation, "name", name)
+	}
+	if !hasAccess {
+		return nil, common.NewResourceAccessDeniedError(operation, "name", name)
+	}
+	return role, nil
+}
+
+// DeleteDatabaseRole soft deletes an active role. Its leases stay active until they expire or are revoked.
+func DeleteDatabaseRole(ctx context.Context, db Database, callingUserId, name string) error {
+	operation := "DeleteDatabaseRole"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	UPDATE  admin.database_roles
+	SET is_active = false,
+		updated_by = $1
+	WHERE	name = $2
+		AND is_active
+	`
+	result, err := db.ExecContext(tracer.Context(), query, callingUserId, name)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	if rowsAffected == 0 {
+		return common.NewResourceNotFoundError(operation, "name", name)
+	}
+	db.GetLogger().Debugf("%s soft deleted %d rows", operation, rowsAffected)
+	return nil
+}
+
+// permissionPrincipal returns the column and id of the user or user group a database role or transit key permission
+// is for
+func permissionPrincipal(userId, userGroupId string) (string, string) {
+	if userId != "" {
+		return "user_id", userId
+	}
+	return "user_group_id", userGroupId
+}
+
+// CreateDatabaseRolePermission allows a user, or the members of a user group, to fetch credentials from a role.
+// Existing grants are left as they are.
+func CreateDatabaseRolePermission(ctx context.Context, db Database, callingUserId, roleId, userId, userGroupId string) error {
+	operation := "CreateDatabaseRolePermission"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	column, principalId := permissionPrincipal(userId, userGroupId)
+	query := `
+	INSERT INTO  admin.database_role_permissions (database_role_id, ` + column + `, created_by, updated_by)
+	VALUES($1, $2, $3, $4)
+	ON CONFLICT (database_role_id, ` + column + `) WHERE is_active
+	DO NOTHING
+	`
+	result, err := db.ExecContext(tracer.Context(), query, roleId, principalId, callingUserId, callingUserId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	db.GetLogger().Debugf("%s created %d rows", operation, rowsAffected)
+	return nil
+}
+
+// DeleteDatabaseRolePermission revokes a user's or user group's grant on a role
+func DeleteDatabaseRolePermission(ctx context.Context, db Database, callingUserId, roleId, userId, userGroupId string) error {
+	operation := "DeleteDatabaseRolePermission"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	column, principalId := permissionPrincipal(userId, userGroupId)
+	query := `
+	UPDATE  admin.database_role_permissions
+	SET is_active = false,
+		updated_by = $1
+	WHERE	database_role_id = $2
+		AND ` + column + ` = $3
+		AND is_active
+	`
+	result, err := db.ExecContext(tracer.Context(), query, callingUserId, roleId, principalId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	db.GetLogger().Debugf("%s soft deleted %d rows", operation, rowsAffected)
+	return nil
+}
+
+// ListDatabaseRoleIds returns the id of every role, including deleted ones, which is also the id of the key its
+// connection URL is encrypted with
+func ListDatabaseRoleIds(ctx context.Context, db Database) ([]string, error) {
+	operation := "ListDatabaseRoleIds"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	SELECT	r.id
+	FROM	admin.database_roles r
+	ORDER BY r.created_at
+	`
+	rows, err := db.QueryContext(tracer.Context(), query)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+
+	for rows.Next() {
+		var id string
+		err = rows.Scan(&id)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+		ids = append(ids, id)
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	return ids, nil
+}
@@ -0,0 +1,277 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+var databaseRoleRowColumns = []string{"id", "name", "connection_url", "creation_statements", "renew_statements", "revocation_statements", "default_ttl_seconds", "max_ttl_seconds", "created_by", "created_at"}
+
+func TestCreateDatabaseRoleErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("INSERT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("CreateDatabaseRole - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = CreateDatabaseRole(context.Background(), dbMock, "callingUserId", &common.DatabaseRole{Id: "roleId", Name: "app"})
+			require.NotNil(t, err, "no error in CreateDatabaseRole: %v", err)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestCreateDatabaseRoleSuccesses(t *testing.T) {
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectExec("INSERT").
+		WithArgs("roleId", "app", "ciphertext", `{"CREATE ROLE \"{{name}}\";"}`, `{"ALTER ROLE"}`, `{"DROP ROLE"}`, 60, 120, "callingUserId", "callingUserId").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = CreateDatabaseRole(context.Background(), dbMock, "callingUserId", &common.DatabaseRole{
+		Id:                   "roleId",
+		Name:                 "app",
+		ConnectionUrl:        "ciphertext",
+		CreationStatements:   []string{`CREATE ROLE "{{name}}";`},
+		RenewStatements:      []string{"ALTER ROLE"},
+		RevocationStatements: []string{"DROP ROLE"},
+		DefaultTtlSeconds:    60,
+		MaxTtlSeconds:        120,
+	})
+	require.Nil(t, err, "error in CreateDatabaseRole: %v", err)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestListDatabaseRolesSuccesses(t *testing.T) {
+	now := time.Now()
+	var inits = []struct {
+		initFunc initFunc
+		after    *common.PageCursor
+		expected []*common.DatabaseRole
+	}{
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WithArgs("", "", 11, 0).
+					WillReturnRows(sqlmock.NewRows(databaseRoleRowColumns)).
+					RowsWillBeClosed()
+			},
+			expected: []*common.DatabaseRole{},
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WithArgs("roleId0", "aaa", 11, 0).
+					WillReturnRows(sqlmock.NewRows(databaseRoleRowColumns).
+						AddRow("roleId1", "app", "ciphertext", "{create}", "{renew}", "{revoke}", 60, 120, "admin", now)).
+					RowsWillBeClosed()
+			},
+			after: &common.PageCursor{Value: "aaa", Id: "roleId0"},
+			expected: []*common.DatabaseRole{{
+				Id:                   "roleId1",
+				Name:                 "app",
+				CreationStatements:   []string{"create"},
+				RenewStatements:      []string{"renew"},
+				RevocationStatements: []string{"revoke"},
+				DefaultTtlSeconds:    60,
+				MaxTtlSeconds:        120,
+				CreatedBy:            "admin",
+				CreatedAt:            &now,
+			}},
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ListDatabaseRoles - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given.initFunc(dbMock)
+
+			result, err := ListDatabaseRoles(context.Background(), dbMock, 11, 0, given.after)
+			require.Nil(t, err, "error in ListDatabaseRoles: %v", err)
+			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestGetDatabaseRoleWithAccessErrors(t *testing.T) {
+	user1 := common.NewDummyUser(t)
+	user1.Type = "developer"
+	columns := append(databaseRoleRowColumns, "has_access")
+	var inits = []struct {
+		initFunc initFunc
+		expected error
+	}{
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
+			},
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WillReturnRows(sqlmock.NewRows(columns)).
+					RowsWillBeClosed()
+			},
+			expected: common.NewResourceNotFoundError("GetDatabaseRoleWithAccess", "name", "app"),
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WithArgs(false, user1.Id, "app").
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow("roleId1", "app", "ciphertext", "{create}", "{renew}", "{revoke}", 60, 120, "admin", time.Now(), false)).
+					RowsWillBeClosed()
+			},
+			expected: common.NewResourceAccessDeniedError("GetDatabaseRoleWithAccess", "name", "app"),
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("GetDatabaseRoleWithAccess - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given.initFunc(dbMock)
+
+			result, err := GetDatabaseRoleWithAccess(context.Background(), dbMock, user1, "app")
+			require.NotNil(t, err, "no error in GetDatabaseRoleWithAccess: %v", err)
+			if given.expected != nil {
+				require.Equal(t, given.expected, err)
+			}
+			require.Nil(t, result, "Result was not nil: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestGetDatabaseRoleWithAccessSuccesses(t *testing.T) {
+	user1 := common.NewDummyUser(t)
+	user1.Type = "developer"
+	now := time.Now()
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectQuery("admin.database_role_permissions").
+		WithArgs(false, user1.Id, "app").
+		WillReturnRows(sqlmock.NewRows(append(databaseRoleRowColumns, "has_access")).
+			AddRow("roleId1", "app", "ciphertext", "{create}", "{renew}", "{revoke}", 60, 120, "admin", now, true)).
+		RowsWillBeClosed()
+
+	result, err := GetDatabaseRoleWithAccess(context.Background(), dbMock, user1, "app")
+	require.Nil(t, err, "error in GetDatabaseRoleWithAccess: %v", err)
+	expected := &common.DatabaseRole{
+		Id:                   "roleId1",
+		Name:                 "app",
+		ConnectionUrl:        "ciphertext",
+		CreationStatements:   []string{"create"},
+		RenewStatements:      []string{"renew"},
+		RevocationStatements: []string{"revoke"},
+		DefaultTtlSeconds:    60,
+		MaxTtlSeconds:        120,
+		CreatedBy:            "admin",
+		CreatedAt:            &now,
+	}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestDeleteDatabaseRoleErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("DeleteDatabaseRole - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = DeleteDatabaseRole(context.Background(), dbMock, "callingUserId", "app")
+			require.NotNil(t, err, "no error in DeleteDatabaseRole: %v", err)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestDatabaseRolePermissionSuccesses(t *testing.T) {
+	var inits = []struct {
+		initFunc    initFunc
+		userId      string
+		userGroupId string
+		grant       bool
+	}{
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectExec("INSERT INTO  admin.database_role_permissions \\(database_role_id, user_id,").
+					WithArgs("roleId", "userId", "callingUserId", "callingUserId").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			userId: "userId",
+			grant:  true,
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectExec("INSERT INTO  admin.database_role_permissions \\(database_role_id, user_group_id,").
+					WithArgs("roleId", "userGroupId", "callingUserId", "callingUserId").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			userGroupId: "userGroupId",
+			grant:       true,
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectExec("AND user_group_id = \\$3").
+					WithArgs("callingUserId", "roleId", "userGroupId").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			userGroupId: "userGroupId",
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("DatabaseRolePermission - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given.initFunc(dbMock)
+
+			if given.grant {
+				err = CreateDatabaseRolePermission(context.Background(), dbMock, "callingUserId", "roleId", given.userId, given.userGroupId)
+			} else {
+				err = DeleteDatabaseRolePermission(context.Background(), dbMock, "callingUserId", "roleId", given.userId, given.userGroupId)
+			}
+			require.Nil(t, err, "error in DatabaseRolePermission: %v", err)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
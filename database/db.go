@@ -97,6 +97,22 @@ func (db *DatabaseEngine) StartTransaction(ctx context.Context) (*DatabaseTransa
 	}, nil
 }
 
+// StartSnapshotTransaction starts a read-only transaction in which every query sees the same snapshot of the database
+func (db *DatabaseEngine) StartSnapshotTransaction(ctx context.Context) (*DatabaseTransaction, error) {
+	tx, err := db.db.BeginTx(ctx, &sql.TxOptions{
+		Isolation: sql.LevelRepeatableRead,
+		ReadOnly:  true,
+	})
+	if err != nil {
+		return nil, common.NewDatabaseError(err, "StartSnapshotTransaction", "")
+	}
+	return &DatabaseTransaction{
+		tx:            tx,
+		logger:        db.logger,
+		tracerCreator: db.tracerCreator,
+	}, nil
+}
+
 func (db *DatabaseTransaction) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
 	childTracer := db.tracerCreator(ctx, "execContext")
 	defer childTracer.Close()
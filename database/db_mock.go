@@ -37,6 +37,11 @@ func (m *MockDatabase) GetLogger() *logrus.Logger {
 	return m.logger
 }
 
+// Mock returns the sqlmock expectations of the database, for tests outside this package
+func (m *MockDatabase) Mock() sqlmock.Sqlmock {
+	return m.mock
+}
+
 func NewMockDatabase() (*MockDatabase, error) {
 	db, mock, err := sqlmock.New()
 	if err != nil {
@@ -0,0 +1,42 @@
+package database
+
+import (
+	"context"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// listDeleted runs a query that selects the id, name, deleted by user name, deletion time and purge time of deleted
+// records
+func listDeleted(ctx context.Context, db Database, operation, query string, args ...interface{}) ([]*common.DeletedRecord, error) {
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	rows, err := db.QueryContext(tracer.Context(), query, args...)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	records := make([]*common.DeletedRecord, 0)
+
+	for rows.Next() {
+		var row common.DeletedRecord
+		err = rows.Scan(&row.Id, &row.Name, &row.DeletedBy, &row.DeletedAt, &row.PurgedAt)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+		records = append(records, &row)
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	return records, nil
+}
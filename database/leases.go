@@ -0,0 +1,207 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// leaseColumns are selected by every query that returns a lease, in the order scanLease expects
+const leaseColumns = `
+			l.id,
+			l.database_role_id,
+			r.name,
+			l.user_id,
+			l.username,
+			l.expires_at,
+			l.max_expires_at,
+			l.revoked_at`
+
+func scanLease(rows *sql.Rows) (*common.Lease, error) {
+	var row common.Lease
+	err := rows.Scan(&row.Id, &row.RoleId, &row.RoleName, &row.UserId, &row.Username, &row.ExpiresAt, &row.MaxExpiresAt, &row.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+func CreateLease(ctx context.Context, db Database, lease *common.Lease) error {
+	operation := "CreateLease"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	INSERT INTO  admin.leases (id, database_role_id, user_id, username, expires_at, max_expires_at)
+	VALUES($1, $2, $3, $4, $5, $6)
+	`
+	result, err := db.ExecContext(tracer.Context(), query, lease.Id, lease.RoleId, lease.UserId, lease.Username, lease.ExpiresAt, lease.MaxExpiresAt)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	db.GetLogger().Debugf("%s created %d rows", operation, rowsAffected)
+	return nil
+}
+
+// GetLease returns a lease that hasn't been revoked. Expired leases are returned until the lease revoker revokes them.
+func GetLease(ctx context.Context, db Database, leaseId string) (*common.Lease, error) {
+	operation := "GetLease"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	SELECT	` + leaseColumns + `
+	FROM	admin.leases l
+	JOIN	admin.database_roles r
+		ON 	l.database_role_id = r.id
+	WHERE	l.id = $1
+		AND l.revoked_at IS NULL
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, leaseId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	var lease *common.Lease
+
+	for rows.Next() {
+		lease, err = scanLease(rows)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	if lease == nil {
+		return nil, common.NewResourceNotFoundError(operation, "id", leaseId)
+	}
+	return lease, nil
+}
+
+// RenewLease moves the expiry of a lease that hasn't been revoked
+func RenewLease(ctx context.Context, db Database, leaseId string, expiresAt time.Time) error {
+	operation := "RenewLease"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	UPDATE  admin.leases
+	SET expires_at = $1
+	WHERE	id = $2
+		AND revoked_at IS NULL
+	`
+	result, err := db.ExecContext(tracer.Context(), query, expiresAt, leaseId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	if rowsAffected == 0 {
+		return common.NewResourceNotFoundError(operation, "id", leaseId)
+	}
+	db.GetLogger().Debugf("%s updated %d rows", operation, rowsAffected)
+	return nil
+}
+
+// RevokeLease marks a lease as revoked, once its credentials have been dropped
+func RevokeLease(ctx context.Context, db Database, leaseId string) error {
+	operation := "RevokeLease"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	UPDATE  admin.leases
+	SET revoked_at = NOW()
+	WHERE	id = $1
+		AND revoked_at IS NULL
+	`
+	result, err := db.ExecContext(tracer.Context(), query, leaseId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	if rowsAffected == 0 {
+		return common.NewResourceNotFoundError(operation, "id", leaseId)
+	}
+	db.GetLogger().Debugf("%s updated %d rows", operation, rowsAffected)
+	return nil
+}
+
+// ListExpiredLeases returns up to limit leases that have expired but haven't been revoked, oldest first
+func ListExpiredLeases(ctx context.Context, db Database, limit int) ([]*common.Lease, error) {
+	operation := "ListExpiredLeases"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	SELECT	` + leaseColumns + `
+	FROM	admin.leases l
+	JOIN	admin.database_roles r
+		ON 	l.database_role_id = r.id
+	WHERE	l.revoked_at IS NULL
+		AND l.expires_at <= NOW()
+	ORDER BY l.expires_at
+	LIMIT	$1
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, limit)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	leases := make([]*common.Lease, 0)
+
+	for rows.Next() {
+		lease, err := scanLease(rows)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+		leases = append(leases, lease)
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	return leases, nil
+}
@@ -0,0 +1,218 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+var leaseRowColumns = []string{"id", "database_role_id", "name", "user_id", "username", "expires_at", "max_expires_at", "revoked_at"}
+
+func TestCreateLeaseErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("INSERT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("CreateLease - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = CreateLease(context.Background(), dbMock, &common.Lease{Id: "leaseId"})
+			require.NotNil(t, err, "no error in CreateLease: %v", err)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestCreateLeaseSuccesses(t *testing.T) {
+	now := time.Now()
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectExec("INSERT").
+		WithArgs("leaseId", "roleId", "userId", "v_app_1", now, now.Add(time.Hour)).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = CreateLease(context.Background(), dbMock, &common.Lease{
+		Id:           "leaseId",
+		RoleId:       "roleId",
+		UserId:       "userId",
+		Username:     "v_app_1",
+		ExpiresAt:    now,
+		MaxExpiresAt: now.Add(time.Hour),
+	})
+	require.Nil(t, err, "error in CreateLease: %v", err)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestGetLeaseErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").
+				WillReturnRows(sqlmock.NewRows(leaseRowColumns)).
+				RowsWillBeClosed()
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").
+				WillReturnRows(sqlmock.NewRows(leaseRowColumns).
+					AddRow("leaseId", "roleId", "app", "userId", "v_app_1", time.Now(), time.Now(), nil).
+					RowError(0, fmt.Errorf("oh no not the row"))).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("GetLease - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := GetLease(context.Background(), dbMock, "leaseId")
+			require.NotNil(t, err, "no error in GetLease: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestGetLeaseSuccesses(t *testing.T) {
+	now := time.Now()
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectQuery("SELECT").
+		WithArgs("leaseId").
+		WillReturnRows(sqlmock.NewRows(leaseRowColumns).
+			AddRow("leaseId", "roleId", "app", "userId", "v_app_1", now, now.Add(time.Hour), nil)).
+		RowsWillBeClosed()
+
+	result, err := GetLease(context.Background(), dbMock, "leaseId")
+	require.Nil(t, err, "error in GetLease: %v", err)
+	expected := &common.Lease{
+		Id:           "leaseId",
+		RoleId:       "roleId",
+		RoleName:     "app",
+		UserId:       "userId",
+		Username:     "v_app_1",
+		ExpiresAt:    now,
+		MaxExpiresAt: now.Add(time.Hour),
+	}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestUpdateLeaseErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("RenewLease - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = RenewLease(context.Background(), dbMock, "leaseId", time.Now())
+			require.NotNil(t, err, "no error in RenewLease: %v", err)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+		t.Run(fmt.Sprintf("RevokeLease - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = RevokeLease(context.Background(), dbMock, "leaseId")
+			require.NotNil(t, err, "no error in RevokeLease: %v", err)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestUpdateLeaseSuccesses(t *testing.T) {
+	now := time.Now()
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectExec("SET expires_at").WithArgs(now, "leaseId").WillReturnResult(sqlmock.NewResult(1, 1))
+	dbMock.mock.ExpectExec("SET revoked_at").WithArgs("leaseId").WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = RenewLease(context.Background(), dbMock, "leaseId", now)
+	require.Nil(t, err, "error in RenewLease: %v", err)
+	err = RevokeLease(context.Background(), dbMock, "leaseId")
+	require.Nil(t, err, "error in RevokeLease: %v", err)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestListExpiredLeasesSuccesses(t *testing.T) {
+	now := time.Now()
+	var inits = []struct {
+		initFunc initFunc
+		expected []*common.Lease
+	}{
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WithArgs(100).
+					WillReturnRows(sqlmock.NewRows(leaseRowColumns)).
+					RowsWillBeClosed()
+			},
+			expected: []*common.Lease{},
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("l.expires_at <= NOW\\(\\)").
+					WithArgs(100).
+					WillReturnRows(sqlmock.NewRows(leaseRowColumns).
+						AddRow("leaseId1", "roleId", "app", "userId", "v_app_1", now, now, nil).
+						AddRow("leaseId2", "roleId", "app", "userId", "v_app_2", now, now, nil)).
+					RowsWillBeClosed()
+			},
+			expected: []*common.Lease{
+				{Id: "leaseId1", RoleId: "roleId", RoleName: "app", UserId: "userId", Username: "v_app_1", ExpiresAt: now, MaxExpiresAt: now},
+				{Id: "leaseId2", RoleId: "roleId", RoleName: "app", UserId: "userId", Username: "v_app_2", ExpiresAt: now, MaxExpiresAt: now},
+			},
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ListExpiredLeases - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given.initFunc(dbMock)
+
+			result, err := ListExpiredLeases(context.Background(), dbMock, 100)
+			require.Nil(t, err, "error in ListExpiredLeases: %v", err)
+			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
@@ -0,0 +1,263 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// scanNamespace reads a row of id, name, description, is_admin and created_at
+func scanNamespace(rows *sql.Rows) (*common.Namespace, error) {
+	var row common.Namespace
+	err := rows.Scan(&row.Id, &row.Name, &row.Description, &row.IsAdmin, &row.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// CreateNamespace creates a namespace, returned as administrable by its creator
+func CreateNamespace(ctx context.Context, db Database, callingUserId, namespaceId, name, description string) (*common.Namespace, error) {
+	operation := "CreateNamespace"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	INSERT INTO  admin.namespaces (id, name, description, created_by, updated_by)
+	VALUES($1, $2, $3, $4, $5)
+	RETURNING id, name, description, true, created_at
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, namespaceId, name, description, callingUserId, callingUserId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	var namespace *common.Namespace
+	for rows.Next() {
+		namespace, err = scanNamespace(rows)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	if namespace == nil {
+		return nil, common.NewResourceNotFoundError(operation, "id", namespaceId)
+	}
+
+	db.GetLogger().Debugf("%s created 1 row", operation)
+	return namespace, nil
+}
+
+// GetNamespace fetches an active namespace by name, with whether the user can administer it: global admins can
+// administer every namespace, and namespace admins only their own
+func GetNamespace(ctx context.Context, db Database, user *common.User, name string) (*common.Namespace, error) {
+	operation := "GetNamespace"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	SELECT	n.id,
+			n.name,
+			n.description,
+			($1 OR na.id IS NOT NULL) AS is_admin,
+			n.created_at
+	FROM	admin.namespaces n
+	LEFT JOIN admin.namespace_admins na
+		ON	na.namespace_id = n.id AND na.user_id = $2 AND na.is_active
+	WHERE	n.name = $3
+		AND n.is_active
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, user.IsAdmin(), user.Id, name)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	var namespace *common.Namespace
+	for rows.Next() {
+		namespace, err = scanNamespace(rows)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	if namespace == nil {
+		return nil, common.NewResourceNotFoundError(operation, "name", name)
+	}
+	return namespace, nil
+}
+
+// ListNamespaces returns active namespaces ordered by name, starting after the cursor if one is given, with whether
+// the user can administer each
+func ListNamespaces(ctx context.Context, db Database, user *common.User, limit, offset int, after *common.PageCursor) ([]*common.Namespace, error) {
+	operation := "ListNamespaces"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	afterId, afterName := cursorArgs(after)
+	query := `
+	SELECT	n.id,
+			n.name,
+			n.description,
+			($1 OR na.id IS NOT NULL) AS is_admin,
+			n.created_at
+	FROM	admin.namespaces n
+	LEFT JOIN admin.namespace_admins na
+		ON	na.namespace_id = n.id AND na.user_id = $2 AND na.is_active
+	WHERE	n.is_active
+		AND ($3 = '' OR (n.name, n.id) > ($4, NULLIF($3, '')::uuid))
+	ORDER BY n.name, n.id
+	LIMIT	$5
+	OFFSET 	$6
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, user.IsAdmin(), user.Id, afterId, afterName, limit, offset)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	namespaces := make([]*common.Namespace, 0)
+
+	for rows.Next() {
+		namespace, err := scanNamespace(rows)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+		namespaces = append(namespaces, namespace)
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	return namespaces, nil
+}
+
+// CountNamespaces returns the number of active namespaces
+func CountNamespaces(ctx context.Context, db Database) (int, error) {
+	query := `
+	SELECT	COUNT(*)
+	FROM	admin.namespaces n
+	WHERE	n.is_active
+	`
+	return count(ctx, db, "CountNamespaces", query)
+}
+
+// DeleteNamespace soft deletes a namespace. Namespaces that still hold active secrets or user groups, and the default
+// namespace, aren't deleted, and return an invalid params error.
+func DeleteNamespace(ctx context.Context, db Database, callingUserId, namespaceId string) error {
+	operation := "DeleteNamespace"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	UPDATE	admin.namespaces n
+	SET		is_active = false,
+			updated_by = $1
+	WHERE	n.id = $2
+		AND n.id <> $3
+		AND n.is_active
+		AND NOT EXISTS (SELECT 1 FROM admin.secrets s WHERE s.namespace_id = n.id AND s.is_active)
+		AND NOT EXISTS (SELECT 1 FROM admin.user_groups ug WHERE ug.namespace_id = n.id AND ug.is_active)
+	`
+	result, err := db.ExecContext(tracer.Context(), query, callingUserId, namespaceId, common.DEFAULT_NAMESPACE_ID)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	if rowsAffected == 0 {
+		return common.NewInvalidParamsError(operation, "Namespace %s is the default namespace or still has active secrets or user groups", namespaceId)
+	}
+	db.GetLogger().Debugf("%s soft deleted %d rows", operation, rowsAffected)
+	return nil
+}
+
+// CreateNamespaceAdmin lets a user administer a namespace. Existing namespace admins are left as they are.
+func CreateNamespaceAdmin(ctx context.Context, db Database, callingUserId, namespaceId, userId string) error {
+	operation := "CreateNamespaceAdmin"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	INSERT INTO  admin.namespace_admins (namespace_id, user_id, created_by, updated_by)
+	VALUES($1, $2, $3, $4)
+	ON CONFLICT (namespace_id, user_id) WHERE is_active
+	DO NOTHING
+	`
+	result, err := db.ExecContext(tracer.Context(), query, namespaceId, userId, callingUserId, callingUserId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	db.GetLogger().Debugf("%s created %d rows", operation, rowsAffected)
+	return nil
+}
+
+func DeleteNamespaceAdmin(ctx context.Context, db Database, callingUserId, namespaceId, userId string) error {
+	operation := "DeleteNamespaceAdmin"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	UPDATE  admin.namespace_admins
+	SET is_active = false,
+		updated_by = $1
+	WHERE	namespace_id = $2 AND user_id = $3 AND is_active
+	`
+	result, err := db.ExecContext(tracer.Context(), query, callingUserId, namespaceId, userId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	db.GetLogger().Debugf("%s soft deleted %d rows", operation, rowsAffected)
+	return nil
+}
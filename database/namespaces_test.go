@@ -0,0 +1,294 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+var namespaceRowColumns = []string{"id", "name", "description", "is_admin", "created_at"}
+
+func TestCreateNamespaceErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("INSERT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("INSERT").
+				WillReturnRows(sqlmock.NewRows(namespaceRowColumns).
+					AddRow("namespaceId", "team-a", "", true, time.Now()).
+					RowError(0, fmt.Errorf("oh no not the row"))).
+				RowsWillBeClosed()
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("INSERT").WillReturnRows(sqlmock.NewRows(namespaceRowColumns)).RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("CreateNamespace - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := CreateNamespace(context.Background(), dbMock, "callingUserId", "namespaceId", "team-a", "")
+			require.NotNil(t, err, "no error in CreateNamespace: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestCreateNamespaceSuccesses(t *testing.T) {
+	now := time.Now()
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectQuery("INSERT INTO  admin.namespaces").
+		WithArgs("namespaceId", "team-a", "Team A", "callingUserId", "callingUserId").
+		WillReturnRows(sqlmock.NewRows(namespaceRowColumns).AddRow("namespaceId", "team-a", "Team A", true, now)).
+		RowsWillBeClosed()
+
+	result, err := CreateNamespace(context.Background(), dbMock, "callingUserId", "namespaceId", "team-a", "Team A")
+	require.Nil(t, err, "error in CreateNamespace: %v", err)
+	expected := &common.Namespace{Id: "namespaceId", Name: "team-a", Description: "Team A", IsAdmin: true, CreatedAt: &now}
+	require.Equal(t, expected, result, "Result %+v did not equal expected %+v", result, expected)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestGetNamespaceErrors(t *testing.T) {
+	user1 := common.NewDummyUser(t)
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").
+				WillReturnRows(sqlmock.NewRows(namespaceRowColumns).
+					AddRow("namespaceId", "team-a", "", false, time.Now()).
+					RowError(0, fmt.Errorf("oh no not the row"))).
+				RowsWillBeClosed()
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows(namespaceRowColumns)).RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("GetNamespace - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := GetNamespace(context.Background(), dbMock, user1, "team-a")
+			require.NotNil(t, err, "no error in GetNamespace: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestGetNamespaceSuccesses(t *testing.T) {
+	now := time.Now()
+	user1 := common.NewDummyUser(t)
+	var inits = []struct {
+		initFunc initFunc
+		expected *common.Namespace
+	}{
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WithArgs(user1.IsAdmin(), user1.Id, "team-a").
+					WillReturnRows(sqlmock.NewRows(namespaceRowColumns).AddRow("namespaceId", "team-a", "", false, now)).
+					RowsWillBeClosed()
+			},
+			expected: &common.Namespace{Id: "namespaceId", Name: "team-a", Description: "", IsAdmin: false, CreatedAt: &now},
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WithArgs(user1.IsAdmin(), user1.Id, "team-a").
+					WillReturnRows(sqlmock.NewRows(namespaceRowColumns).AddRow("namespaceId", "team-a", "", true, now)).
+					RowsWillBeClosed()
+			},
+			expected: &common.Namespace{Id: "namespaceId", Name: "team-a", Description: "", IsAdmin: true, CreatedAt: &now},
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("GetNamespace - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given.initFunc(dbMock)
+
+			result, err := GetNamespace(context.Background(), dbMock, user1, "team-a")
+			require.Nil(t, err, "error in GetNamespace: %v", err)
+			require.Equal(t, given.expected, result, "Result %+v did not equal expected %+v", result, given.expected)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestListNamespacesSuccesses(t *testing.T) {
+	now := time.Now()
+	user1 := common.NewDummyUser(t)
+	var inits = []struct {
+		initFunc initFunc
+		after    *common.PageCursor
+		expected []*common.Namespace
+	}{
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WithArgs(user1.IsAdmin(), user1.Id, "", "", 11, 0).
+					WillReturnRows(sqlmock.NewRows(namespaceRowColumns)).
+					RowsWillBeClosed()
+			},
+			expected: []*common.Namespace{},
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WithArgs(user1.IsAdmin(), user1.Id, "namespaceId0", "default", 11, 0).
+					WillReturnRows(sqlmock.NewRows(namespaceRowColumns).
+						AddRow("namespaceId1", "team-a", "Team A", true, now)).
+					RowsWillBeClosed()
+			},
+			after:    &common.PageCursor{Value: "default", Id: "namespaceId0"},
+			expected: []*common.Namespace{{Id: "namespaceId1", Name: "team-a", Description: "Team A", IsAdmin: true, CreatedAt: &now}},
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ListNamespaces - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given.initFunc(dbMock)
+
+			result, err := ListNamespaces(context.Background(), dbMock, user1, 11, 0, given.after)
+			require.Nil(t, err, "error in ListNamespaces: %v", err)
+			require.Equal(t, given.expected, result, "Result %+v did not equal expected %+v", result, given.expected)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestDeleteNamespaceErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("DeleteNamespace - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = DeleteNamespace(context.Background(), dbMock, "callingUserId", "namespaceId")
+			require.NotNil(t, err, "no error in DeleteNamespace: %v", err)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestDeleteNamespaceSuccesses(t *testing.T) {
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectExec("UPDATE").
+		WithArgs("callingUserId", "namespaceId", common.DEFAULT_NAMESPACE_ID).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = DeleteNamespace(context.Background(), dbMock, "callingUserId", "namespaceId")
+	require.Nil(t, err, "error in DeleteNamespace: %v", err)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestCreateNamespaceAdminErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("INSERT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("CreateNamespaceAdmin - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = CreateNamespaceAdmin(context.Background(), dbMock, "callingUserId", "namespaceId", "userId")
+			require.NotNil(t, err, "no error in CreateNamespaceAdmin: %v", err)
+		})
+	}
+}
+
+func TestCreateNamespaceAdminSuccesses(t *testing.T) {
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectExec("INSERT").
+		WithArgs("namespaceId", "userId", "callingUserId", "callingUserId").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = CreateNamespaceAdmin(context.Background(), dbMock, "callingUserId", "namespaceId", "userId")
+	require.Nil(t, err, "error in CreateNamespaceAdmin: %v", err)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestDeleteNamespaceAdminErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("DeleteNamespaceAdmin - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = DeleteNamespaceAdmin(context.Background(), dbMock, "callingUserId", "namespaceId", "userId")
+			require.NotNil(t, err, "no error in DeleteNamespaceAdmin: %v", err)
+		})
+	}
+}
+
+func TestDeleteNamespaceAdminSuccesses(t *testing.T) {
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectExec("UPDATE").
+		WithArgs("callingUserId", "namespaceId", "userId").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = DeleteNamespaceAdmin(context.Background(), dbMock, "callingUserId", "namespaceId", "userId")
+	require.Nil(t, err, "error in DeleteNamespaceAdmin: %v", err)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
@@ -0,0 +1,46 @@
+package database
+
+import (
+	"context"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// cursorArgs returns the id and sort value of after, or empty strings to start from the first item
+func cursorArgs(after *common.PageCursor) (string, string) {
+	if after == nil {
+		return "", ""
+	}
+	return after.Id, after.Value
+}
+
+// count runs a query that selects a single count
+func count(ctx context.Context, db Database, operation, query string, args ...interface{}) (int, error) {
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	rows, err := db.QueryContext(tracer.Context(), query, args...)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return 0, dbErr
+	}
+	defer rows.Close()
+
+	var total int
+	for rows.Next() {
+		err = rows.Scan(&total)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return 0, dbErr
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return 0, dbErr
+	}
+	return total, nil
+}
@@ -0,0 +1,130 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+func TestCursorArgs(t *testing.T) {
+	id, value := cursorArgs(nil)
+	require.Equal(t, "", id)
+	require.Equal(t, "", value)
+
+	id, value = cursorArgs(&common.PageCursor{Value: "name1", Id: "id1"})
+	require.Equal(t, "id1", id)
+	require.Equal(t, "name1", value)
+}
+
+func TestCountErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow("not a number")).
+				RowsWillBeClosed()
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).
+					AddRow(3).
+					RowError(0, fmt.Errorf("oh no not the row"))).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("Count - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := CountUsers(context.Background(), dbMock)
+			require.NotNil(t, err, "no error in CountUsers: %v", err)
+			require.Equal(t, 0, result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestCountSuccesses(t *testing.T) {
+	user1 := common.NewDummyUser(t)
+	namespace1 := common.NewDummyNamespace(t)
+	var inits = []struct {
+		initFunc initFunc
+		count    func(dbMock *MockDatabase) (int, error)
+		expected int
+	}{
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("FROM	admin.users u").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3)).
+					RowsWillBeClosed()
+			},
+			count: func(dbMock *MockDatabase) (int, error) {
+				return CountUsers(context.Background(), dbMock)
+			},
+			expected: 3,
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("FROM	admin.user_groups u").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0)).
+					RowsWillBeClosed()
+			},
+			count: func(dbMock *MockDatabase) (int, error) {
+				return CountUserGroups(context.Background(), dbMock, "namespaceId")
+			},
+			expected: 0,
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WithArgs("userGroupId1").
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(7)).
+					RowsWillBeClosed()
+			},
+			count: func(dbMock *MockDatabase) (int, error) {
+				return CountUsersInGroup(context.Background(), dbMock, "userGroupId1")
+			},
+			expected: 7,
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("COUNT\\(DISTINCT s.id\\)").
+					WithArgs(user1.Id, user1.Id, namespace1.IsAdmin, user1.Id, "app/", "", "",
+						"{}", pq.Array([]string(nil)), pq.Array([]string(nil)), "{}",
+						nil, nil, nil, nil, namespace1.Id).
+					WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(12)).
+					RowsWillBeClosed()
+			},
+			count: func(dbMock *MockDatabase) (int, error) {
+				return CountSecrets(context.Background(), dbMock, user1, namespace1, &common.ListSecretsRequest{NamePrefix: "app/"}, nil)
+			},
+			expected: 12,
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("Count - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given.initFunc(dbMock)
+
+			result, err := given.count(dbMock)
+			require.Nil(t, err, "no error in count: %v", err)
+			require.Equal(t, given.expected, result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
@@ -36,16 +36,116 @@ func DeleteSecretGroupPermission(ctx context.Context, db Database, callingUserId
 	return nil
 }
 
-func CreateSecretGroupPermission(ctx context.Context, db Database, callingUserId, userGroupId, secretId string) error {
+// CreateSecretGroupPermission grants a level on a secret. If an active grant already exists, its level is replaced.
+func CreateSecretGroupPermission(ctx context.Context, db Database, callingUserId, userGroupId, secretId, level string) error {
 	operation := "CreateSecretGroupPermission"
 	tracer := db.CreateTrace(ctx, operation)
 	defer tracer.Close()
 
 	query := `
-	INSERT INTO  admin.secret_group_permissions (user_group_id, secret_id, created_by, updated_by)
-	VALUES($1, $2, $3, $4)
+	INSERT INTO  admin.secret_group_permissions (user_group_id, secret_id, level, created_by, updated_by)
+	VALUES($1, $2, $3, $4, $5)
+	ON CONFLICT (user_group_id, secret_id) WHERE is_active
+	DO UPDATE SET level = EXCLUDED.level, updated_by = EXCLUDED.updated_by
 	`
-	result, err := db.ExecContext(tracer.Context(), query, userGroupId, secretId, callingUserId, callingUserId)
+	result, err := db.ExecContext(tracer.Context(), query, userGroupId, secretId, level, callingUserId, callingUserId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	db.GetLogger().Debugf("%s created %d rows", operation, rowsAffected)
+
+	return nil
+}
+
+// DowngradeSecretGroupPermission lowers an active grant on a secret to level. Grants already at or below level are unchanged.
+func DowngradeSecretGroupPermission(ctx context.Context, db Database, callingUserId, userGroupId, secretId, level string) error {
+	operation := "DowngradeSecretGroupPermission"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	UPDATE  admin.secret_group_permissions p
+	SET level = $1,
+		updated_by = $2
+	FROM	admin.permission_level current_level,
+			admin.permission_level new_level
+	WHERE	p.user_group_id = $3
+		AND p.secret_id = $4
+		AND p.is_active
+		AND current_level.id = p.level
+		AND new_level.id = $5
+		AND current_level.rank > new_level.rank
+	`
+	result, err := db.ExecContext(tracer.Context(), query, level, callingUserId, userGroupId, secretId, level)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	db.GetLogger().Debugf("%s updated %d rows", operation, rowsAffected)
+
+	return nil
+}
+
+// DeleteSecretGroupPatternPermission revokes a user group grant on a secret name pattern in a namespace
+func DeleteSecretGroupPatternPermission(ctx context.Context, db Database, callingUserId, userGroupId, namespaceId, pattern string) error {
+	operation := "DeleteSecretGroupPatternPermission"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	UPDATE  admin.secret_group_permissions
+	SET is_active = false,
+		updated_by = $1
+	WHERE	user_group_id = $2 and namespace_id = $3 and secret_name_pattern = $4
+	`
+	result, err := db.ExecContext(tracer.Context(), query, callingUserId, userGroupId, namespaceId, pattern)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	db.GetLogger().Debugf("%s soft deleted %d rows", operation, rowsAffected)
+
+	return nil
+}
+
+// CreateSecretGroupPatternPermission grants a user group access to every secret in a namespace whose name matches pattern, including secrets created later
+func CreateSecretGroupPatternPermission(ctx context.Context, db Database, callingUserId, userGroupId, namespaceId, pattern, likePattern, level string) error {
+	operation := "CreateSecretGroupPatternPermission"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	INSERT INTO  admin.secret_group_permissions (user_group_id, namespace_id, secret_name_pattern, secret_name_like, level, created_by, updated_by)
+	VALUES($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (user_group_id, namespace_id, secret_name_pattern) WHERE is_active
+	DO UPDATE SET level = EXCLUDED.level, updated_by = EXCLUDED.updated_by
+	`
+	result, err := db.ExecContext(tracer.Context(), query, userGroupId, namespaceId, pattern, likePattern, level, callingUserId, callingUserId)
 	if err != nil {
 		dbErr := common.NewDatabaseError(err, operation, "")
 		tracer.CaptureException(dbErr)
@@ -70,7 +70,7 @@ func TestCreateSecretGroupPermissionErrors(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given(dbMock)
 
-			err = CreateSecretGroupPermission(context.Background(), dbMock, "callingUserId", "userGroupId", "secretId")
+			err = CreateSecretGroupPermission(context.Background(), dbMock, "callingUserId", "userGroupId", "secretId", "write")
 			require.NotNil(t, err, "no error in CreateSecretGroupPermission: %v", err)
 			err = dbMock.mock.ExpectationsWereMet()
 			require.Nil(t, err, "expectations not met: %v", err)
@@ -81,7 +81,7 @@ func TestCreateSecretGroupPermissionErrors(t *testing.T) {
 func TestCreateSecretGroupPermissionSuccesses(t *testing.T) {
 	var inits = []initFunc{
 		func(dbMock *MockDatabase) {
-			dbMock.mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewResult(1, 1)).WithArgs("userGroupId", "secretId", "callingUserId", "callingUserId")
+			dbMock.mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewResult(1, 1)).WithArgs("userGroupId", "secretId", "write", "callingUserId", "callingUserId")
 		},
 	}
 
@@ -91,10 +91,136 @@ func TestCreateSecretGroupPermissionSuccesses(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given(dbMock)
 
-			err = CreateSecretGroupPermission(context.Background(), dbMock, "callingUserId", "userGroupId", "secretId")
+			err = CreateSecretGroupPermission(context.Background(), dbMock, "callingUserId", "userGroupId", "secretId", "write")
 			require.Nil(t, err, "error in CreateSecretGroupPermission: %v", err)
 			err = dbMock.mock.ExpectationsWereMet()
 			require.Nil(t, err, "expectations not met: %v", err)
 		})
 	}
 }
+
+func TestDeleteSecretGroupPatternPermissionErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("DeleteSecretGroupPatternPermission - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = DeleteSecretGroupPatternPermission(context.Background(), dbMock, "callingUserId", "userGroupId", "namespaceId", "payments/*")
+			require.NotNil(t, err, "no error in DeleteSecretGroupPatternPermission: %v", err)
+		})
+	}
+}
+
+func TestDeleteSecretGroupPatternPermissionSuccesses(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(1, 1)).WithArgs("callingUserId", "userGroupId", "namespaceId", "payments/*")
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("DeleteSecretGroupPatternPermission - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = DeleteSecretGroupPatternPermission(context.Background(), dbMock, "callingUserId", "userGroupId", "namespaceId", "payments/*")
+			require.Nil(t, err, "error in DeleteSecretGroupPatternPermission: %v", err)
+		})
+	}
+}
+
+func TestCreateSecretGroupPatternPermissionErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("INSERT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("CreateSecretGroupPatternPermission - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = CreateSecretGroupPatternPermission(context.Background(), dbMock, "callingUserId", "userGroupId", "namespaceId", "payments/*", "payments/%", "manage")
+			require.NotNil(t, err, "no error in CreateSecretGroupPatternPermission: %v", err)
+		})
+	}
+}
+
+func TestCreateSecretGroupPatternPermissionSuccesses(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewResult(1, 1)).WithArgs("userGroupId", "namespaceId", "payments/*", "payments/%", "manage", "callingUserId", "callingUserId")
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("CreateSecretGroupPatternPermission - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = CreateSecretGroupPatternPermission(context.Background(), dbMock, "callingUserId", "userGroupId", "namespaceId", "payments/*", "payments/%", "manage")
+			require.Nil(t, err, "error in CreateSecretGroupPatternPermission: %v", err)
+		})
+	}
+}
+
+func TestDowngradeSecretGroupPermissionErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("DowngradeSecretGroupPermission - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = DowngradeSecretGroupPermission(context.Background(), dbMock, "callingUserId", "userGroupId", "secretId", "read")
+			require.NotNil(t, err, "no error in DowngradeSecretGroupPermission: %v", err)
+		})
+	}
+}
+
+func TestDowngradeSecretGroupPermissionSuccesses(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(1, 1)).WithArgs("read", "callingUserId", "userGroupId", "secretId", "read")
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("DowngradeSecretGroupPermission - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = DowngradeSecretGroupPermission(context.Background(), dbMock, "callingUserId", "userGroupId", "secretId", "read")
+			require.Nil(t, err, "error in DowngradeSecretGroupPermission: %v", err)
+		})
+	}
+}
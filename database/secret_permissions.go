@@ -35,16 +35,116 @@ func DeleteSecretPermission(ctx context.Context, db Database, callingUserId, use
 	return nil
 }
 
-func CreateSecretPermission(ctx context.Context, db Database, callingUserId, userId, secretId string) error {
+// CreateSecretPermission grants a level on a secret. If an active grant already exists, its level is replaced.
+func CreateSecretPermission(ctx context.Context, db Database, callingUserId, userId, secretId, level string) error {
 	operation := "CreateSecretPermission"
 	tracer := db.CreateTrace(ctx, operation)
 	defer tracer.Close()
 
 	query := `
-	INSERT INTO  admin.secret_permissions (user_id, secret_id, created_by, updated_by)
-	VALUES($1, $2, $3, $4)
+	INSERT INTO  admin.secret_permissions (user_id, secret_id, level, created_by, updated_by)
+	VALUES($1, $2, $3, $4, $5)
+	ON CONFLICT (user_id, secret_id) WHERE is_active
+	DO UPDATE SET level = EXCLUDED.level, updated_by = EXCLUDED.updated_by
 	`
-	result, err := db.ExecContext(tracer.Context(), query, userId, secretId, callingUserId, callingUserId)
+	result, err := db.ExecContext(tracer.Context(), query, userId, secretId, level, callingUserId, callingUserId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	db.GetLogger().Debugf("%s created %d rows", operation, rowsAffected)
+
+	return nil
+}
+
+// DowngradeSecretPermission lowers an active grant on a secret to level. Grants already at or below level are unchanged.
+func DowngradeSecretPermission(ctx context.Context, db Database, callingUserId, userId, secretId, level string) error {
+	operation := "DowngradeSecretPermission"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	UPDATE  admin.secret_permissions p
+	SET level = $1,
+		updated_by = $2
+	FROM	admin.permission_level current_level,
+			admin.permission_level new_level
+	WHERE	p.user_id = $3
+		AND p.secret_id = $4
+		AND p.is_active
+		AND current_level.id = p.level
+		AND new_level.id = $5
+		AND current_level.rank > new_level.rank
+	`
+	result, err := db.ExecContext(tracer.Context(), query, level, callingUserId, userId, secretId, level)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	db.GetLogger().Debugf("%s updated %d rows", operation, rowsAffected)
+
+	return nil
+}
+
+// DeleteSecretPatternPermission revokes a user grant on a secret name pattern in a namespace
+func DeleteSecretPatternPermission(ctx context.Context, db Database, callingUserId, userId, namespaceId, pattern string) error {
+	operation := "DeleteSecretPatternPermission"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	UPDATE  admin.secret_permissions
+	SET is_active = false,
+		updated_by = $1
+	WHERE	user_id = $2 and namespace_id = $3 and secret_name_pattern = $4
+	`
+	result, err := db.ExecContext(tracer.Context(), query, callingUserId, userId, namespaceId, pattern)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	db.GetLogger().Debugf("%s soft deleted %d rows", operation, rowsAffected)
+
+	return nil
+}
+
+// CreateSecretPatternPermission grants a user access to every secret in a namespace whose name matches pattern, including secrets created later
+func CreateSecretPatternPermission(ctx context.Context, db Database, callingUserId, userId, namespaceId, pattern, likePattern, level string) error {
+	operation := "CreateSecretPatternPermission"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	INSERT INTO  admin.secret_permissions (user_id, namespace_id, secret_name_pattern, secret_name_like, level, created_by, updated_by)
+	VALUES($1, $2, $3, $4, $5, $6, $7)
+	ON CONFLICT (user_id, namespace_id, secret_name_pattern) WHERE is_active
+	DO UPDATE SET level = EXCLUDED.level, updated_by = EXCLUDED.updated_by
+	`
+	result, err := db.ExecContext(tracer.Context(), query, userId, namespaceId, pattern, likePattern, level, callingUserId, callingUserId)
 	if err != nil {
 		dbErr := common.NewDatabaseError(err, operation, "")
 		tracer.CaptureException(dbErr)
@@ -66,7 +66,7 @@ func TestCreateSecretPermissionErrors(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given(dbMock)
 
-			err = CreateSecretPermission(context.Background(), dbMock, "callingUserId", "userId", "secretId")
+			err = CreateSecretPermission(context.Background(), dbMock, "callingUserId", "userId", "secretId", "write")
 			require.NotNil(t, err, "no error in CreateSecretPermission: %v", err)
 		})
 	}
@@ -75,7 +75,7 @@ func TestCreateSecretPermissionErrors(t *testing.T) {
 func TestCreateSecretPermissionSuccesses(t *testing.T) {
 	var inits = []initFunc{
 		func(dbMock *MockDatabase) {
-			dbMock.mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewResult(1, 1)).WithArgs("userId", "secretId", "callingUserId", "callingUserId")
+			dbMock.mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewResult(1, 1)).WithArgs("userId", "secretId", "write", "callingUserId", "callingUserId")
 		},
 	}
 
@@ -85,8 +85,134 @@ func TestCreateSecretPermissionSuccesses(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given(dbMock)
 
-			err = CreateSecretPermission(context.Background(), dbMock, "callingUserId", "userId", "secretId")
+			err = CreateSecretPermission(context.Background(), dbMock, "callingUserId", "userId", "secretId", "write")
 			require.Nil(t, err, "error in CreateSecretPermission: %v", err)
 		})
 	}
 }
+
+func TestDeleteSecretPatternPermissionErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("DeleteSecretPatternPermission - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = DeleteSecretPatternPermission(context.Background(), dbMock, "callingUserId", "userId", "namespaceId", "payments/*")
+			require.NotNil(t, err, "no error in DeleteSecretPatternPermission: %v", err)
+		})
+	}
+}
+
+func TestDeleteSecretPatternPermissionSuccesses(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(1, 1)).WithArgs("callingUserId", "userId", "namespaceId", "payments/*")
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("DeleteSecretPatternPermission - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = DeleteSecretPatternPermission(context.Background(), dbMock, "callingUserId", "userId", "namespaceId", "payments/*")
+			require.Nil(t, err, "error in DeleteSecretPatternPermission: %v", err)
+		})
+	}
+}
+
+func TestCreateSecretPatternPermissionErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("INSERT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("CreateSecretPatternPermission - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = CreateSecretPatternPermission(context.Background(), dbMock, "callingUserId", "userId", "namespaceId", "payments/*", "payments/%", "manage")
+			require.NotNil(t, err, "no error in CreateSecretPatternPermission: %v", err)
+		})
+	}
+}
+
+func TestCreateSecretPatternPermissionSuccesses(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewResult(1, 1)).WithArgs("userId", "namespaceId", "payments/*", "payments/%", "manage", "callingUserId", "callingUserId")
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("CreateSecretPatternPermission - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = CreateSecretPatternPermission(context.Background(), dbMock, "callingUserId", "userId", "namespaceId", "payments/*", "payments/%", "manage")
+			require.Nil(t, err, "error in CreateSecretPatternPermission: %v", err)
+		})
+	}
+}
+
+func TestDowngradeSecretPermissionErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("DowngradeSecretPermission - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = DowngradeSecretPermission(context.Background(), dbMock, "callingUserId", "userId", "secretId", "read")
+			require.NotNil(t, err, "no error in DowngradeSecretPermission: %v", err)
+		})
+	}
+}
+
+func TestDowngradeSecretPermissionSuccesses(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(1, 1)).WithArgs("read", "callingUserId", "userId", "secretId", "read")
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("DowngradeSecretPermission - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = DowngradeSecretPermission(context.Background(), dbMock, "callingUserId", "userId", "secretId", "read")
+			require.Nil(t, err, "error in DowngradeSecretPermission: %v", err)
+		})
+	}
+}
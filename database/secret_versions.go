@@ -0,0 +1,344 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// CreateSecretVersion adds a new version to an existing secret and makes it the current version. The version is
+// read from the VersionId, Value, ValueType, ContentType and Filename of secret.
+func CreateSecretVersion(ctx context.Context, db Database, callingUserId, secretId string, secret *common.Secret) (int, error) {
+	operation := "CreateSecretVersion"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	WITH new_version AS (
+		INSERT INTO  admin.secret_versions (id, secret_id, version, value, value_type, content_type, filename, created_by)
+		SELECT	$1, $2, COALESCE(MAX(sv.version), 0) + 1, $3, $4, $5, $6, $7
+		FROM	admin.secret_versions sv
+		WHERE	sv.secret_id = $8
+		RETURNING secret_id, version
+	)
+	UPDATE	admin.secrets s
+	SET		current_version = nv.version,
+			updated_by = $9
+	FROM	new_version nv
+	WHERE	s.id = nv.secret_id
+		AND s.is_active
+	RETURNING s.current_version
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, secret.VersionId, secretId, secret.Value, secret.ValueType, secret.ContentType, secret.Filename, callingUserId, secretId, callingUserId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return 0, dbErr
+	}
+	defer rows.Close()
+
+	var version int
+	for rows.Next() {
+		err = rows.Scan(&version)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return 0, dbErr
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return 0, dbErr
+	}
+	if version == 0 {
+		return 0, common.NewResourceNotFoundError(operation, "id", secretId)
+	}
+
+	db.GetLogger().Debugf("%s created version %d", operation, version)
+	return version, nil
+}
+
+func ListSecretVersions(ctx context.Context, db Database, secretId string) ([]*common.SecretVersion, error) {
+	operation := "ListSecretVersions"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	SELECT	sv.version,
+			sv.version = s.current_version AS is_current,
+			created_by_user.name AS updated_by,
+			sv.created_at AS updated_at
+	FROM	admin.secret_versions sv
+	JOIN	admin.secrets s
+		ON	sv.secret_id = s.id
+	JOIN	admin.users created_by_user
+		ON 	sv.created_by = created_by_user.id
+	WHERE	sv.secret_id = $1
+	ORDER BY sv.version DESC
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, secretId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	versions := make([]*common.SecretVersion, 0)
+
+	for rows.Next() {
+		var row common.SecretVersion
+		err = rows.Scan(&row.Version, &row.IsCurrent, &row.UpdatedBy, &row.UpdatedAt)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+		versions = append(versions, &row)
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	return versions, nil
+}
+
+// SetSecretCurrentVersion points a secret at one of its existing versions
+func SetSecretCurrentVersion(ctx context.Context, db Database, callingUserId, secretId string, version int) error {
+	operation := "SetSecretCurrentVersion"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	UPDATE	admin.secrets s
+	SET		current_version = sv.version,
+			updated_by = $1
+	FROM	admin.secret_versions sv
+	WHERE	s.id = $2
+		AND s.is_active
+		AND sv.secret_id = s.id
+		AND sv.version = $3
+	`
+	result, err := db.ExecContext(tracer.Context(), query, callingUserId, secretId, version)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	if rowsAffected == 0 {
+		return common.NewResourceNotFoundError(operation, "version", fmt.Sprintf("%d", version))
+	}
+	db.GetLogger().Debugf("%s updated %d rows", operation, rowsAffected)
+
+	return nil
+}
+
+// ListSecretVersionIds returns the id of every secret version, including versions of deleted secrets. Versions of
+// purged secrets have no data key, and aren't included.
+func ListSecretVersionIds(ctx context.Context, db Database) ([]string, error) {
+	operation := "ListSecretVersionIds"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	SELECT	sv.id
+	FROM	admin.secret_versions sv
+	WHERE	sv.value IS NOT NULL
+	ORDER BY sv.created_at
+	`
+	rows, err := db.QueryContext(tracer.Context(), query)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+
+	for rows.Next() {
+		var id string
+		err = rows.Scan(&id)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+		ids = append(ids, id)
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	return ids, nil
+}
+
+// ListPurgedSecretVersionIds returns the ids of a purged secret's versions whose data keys are still stored. It locks
+// the versions, so it waits for a rekey already in progress to commit and returns the version's new id; the secret is
+// marked purged first, so no later rekey can move a version to a new key.
+func ListPurgedSecretVersionIds(ctx context.Context, db Database, secretId string) ([]string, error) {
+	operation := "ListPurgedSecretVersionIds"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	SELECT	sv.id
+	FROM	admin.secret_versions sv
+	WHERE	sv.secret_id = $1
+		AND sv.value IS NOT NULL
+	ORDER BY sv.version
+	FOR UPDATE
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, secretId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+
+	for rows.Next() {
+		var id string
+		err = rows.Scan(&id)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+		ids = append(ids, id)
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	return ids, nil
+}
+
+// ListSecretVersionsToRekey returns up to limit secret versions whose data key was created before keyedBefore,
+// oldest key first, including versions of deleted secrets that haven't been purged. If secretId is set, only its versions are
+// returned. A limit of 0 returns every version.
+func ListSecretVersionsToRekey(ctx context.Context, db Database, secretId string, keyedBefore time.Time, limit int) ([]*common.RekeyableSecretVersion, error) {
+	operation := "ListSecretVersionsToRekey"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	SELECT	sv.id,
+			sv.secret_id,
+			s.name,
+			n.name,
+			sv.version,
+			sv.value
+	FROM	admin.secret_versions sv
+	JOIN	admin.secrets s
+		ON	sv.secret_id = s.id
+	JOIN	admin.namespaces n
+		ON	s.namespace_id = n.id
+	WHERE	($1 = '' OR sv.secret_id = NULLIF($1, '')::uuid)
+		AND sv.value IS NOT NULL
+		AND s.purged_at IS NULL
+		AND COALESCE(sv.rekeyed_at, sv.created_at) < $2
+	ORDER BY COALESCE(sv.rekeyed_at, sv.created_at), sv.id
+	LIMIT	NULLIF($3, 0)
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, secretId, keyedBefore, limit)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	versions := make([]*common.RekeyableSecretVersion, 0)
+
+	for rows.Next() {
+		var row common.RekeyableSecretVersion
+		err = rows.Scan(&row.Id, &row.SecretId, &row.SecretName, &row.Namespace, &row.Version, &row.Value)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+		versions = append(versions, &row)
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	return versions, nil
+}
+
+// CountSecretVersionsToRekey returns the number of secret versions whose data key was created before keyedBefore,
+// excluding versions of purged secrets
+func CountSecretVersionsToRekey(ctx context.Context, db Database, keyedBefore time.Time) (int, error) {
+	query := `
+	SELECT	COUNT(*)
+	FROM	admin.secret_versions sv
+	JOIN	admin.secrets s
+		ON	sv.secret_id = s.id
+	WHERE	sv.value IS NOT NULL
+		AND s.purged_at IS NULL
+		AND COALESCE(sv.rekeyed_at, sv.created_at) < $1
+	`
+	return count(ctx, db, "CountSecretVersionsToRekey", query, keyedBefore)
+}
+
+// RekeySecretVersion moves a secret version to the data key stored under newId, with value encrypted under it. The
+// id and value change in one statement, so the version is never paired with the wrong key. Returns a not found
+// error if the version was already rekeyed, or its secret has been marked purged, in which case the new key must be
+// deleted by the caller.
+func RekeySecretVersion(ctx context.Context, db Database, oldId, newId, value string) error {
+	operation := "RekeySecretVersion"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	UPDATE	admin.secret_versions sv
+	SET		id = $1,
+			value = $2,
+			rekeyed_at = now()
+	FROM	admin.secrets s
+	WHERE	sv.id = $3
+		AND sv.secret_id = s.id
+		AND s.purged_at IS NULL
+	`
+	result, err := db.ExecContext(tracer.Context(), query, newId, value, oldId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	if rowsAffected == 0 {
+		return common.NewResourceNotFoundError(operation, "id", oldId)
+	}
+	db.GetLogger().Debugf("%s updated %d rows", operation, rowsAffected)
+	return nil
+}
@@ -0,0 +1,501 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+func TestCreateSecretVersionErrors(t *testing.T) {
+	secret1 := &common.Secret{VersionId: "versionId", Value: "value", ValueType: common.SECRET_VALUE_TYPE_STRING}
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("INSERT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("INSERT").
+				WillReturnRows(sqlmock.NewRows([]string{"current_version"}).
+					AddRow(2).
+					RowError(0, fmt.Errorf("oh no not the row"))).
+				RowsWillBeClosed()
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("INSERT").
+				WillReturnRows(sqlmock.NewRows([]string{"current_version"})).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("CreateSecretVersion - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := CreateSecretVersion(context.Background(), dbMock, "callingUserId", "secretId", secret1)
+			require.NotNil(t, err, "no error in CreateSecretVersion: %v", err)
+			require.Equal(t, result, 0, "Expected 0 result, got: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestCreateSecretVersionSuccesses(t *testing.T) {
+	secret1 := &common.Secret{VersionId: "versionId", Value: "value", ValueType: common.SECRET_VALUE_TYPE_BINARY, ContentType: "application/x-pem-file", Filename: "tls.key"}
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("INSERT").
+				WithArgs("versionId", "secretId", "value", common.SECRET_VALUE_TYPE_BINARY, "application/x-pem-file", "tls.key", "callingUserId", "secretId", "callingUserId").
+				WillReturnRows(sqlmock.NewRows([]string{"current_version"}).AddRow(2)).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("CreateSecretVersion - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := CreateSecretVersion(context.Background(), dbMock, "callingUserId", "secretId", secret1)
+			require.Nil(t, err, "error in CreateSecretVersion: %v", err)
+			require.Equal(t, result, 2, "Result %v did not equal expected 2", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestListSecretVersionsErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").
+				WillReturnRows(sqlmock.NewRows([]string{"version", "is_current", "updated_by", "updated_at"}).
+					AddRow(1, true, "user", time.Now()).
+					RowError(0, fmt.Errorf("oh no not the row"))).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ListSecretVersions - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := ListSecretVersions(context.Background(), dbMock, "secretId")
+			require.NotNil(t, err, "no error in ListSecretVersions: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestListSecretVersionsSuccesses(t *testing.T) {
+	now := time.Now()
+	version1 := &common.SecretVersion{Version: 1, IsCurrent: false, UpdatedBy: "user1", UpdatedAt: now}
+	version2 := &common.SecretVersion{Version: 2, IsCurrent: true, UpdatedBy: "user2", UpdatedAt: now}
+	var inits = []struct {
+		initFunc initFunc
+		expected []*common.SecretVersion
+	}{
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WillReturnRows(sqlmock.NewRows([]string{"version", "is_current", "updated_by", "updated_at"})).
+					RowsWillBeClosed()
+			},
+			expected: []*common.SecretVersion{},
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WithArgs("secretId").
+					WillReturnRows(sqlmock.NewRows([]string{"version", "is_current", "updated_by", "updated_at"}).
+						AddRow(version2.Version, version2.IsCurrent, version2.UpdatedBy, version2.UpdatedAt).
+						AddRow(version1.Version, version1.IsCurrent, version1.UpdatedBy, version1.UpdatedAt)).
+					RowsWillBeClosed()
+			},
+			expected: []*common.SecretVersion{version2, version1},
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ListSecretVersions - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given.initFunc(dbMock)
+
+			result, err := ListSecretVersions(context.Background(), dbMock, "secretId")
+			require.Nil(t, err, "error in ListSecretVersions: %v", err)
+			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestSetSecretCurrentVersionErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("SetSecretCurrentVersion - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = SetSecretCurrentVersion(context.Background(), dbMock, "callingUserId", "secretId", 1)
+			require.NotNil(t, err, "no error in SetSecretCurrentVersion: %v", err)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestSetSecretCurrentVersionSuccesses(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WithArgs("callingUserId", "secretId", 1).WillReturnResult(sqlmock.NewResult(1, 1))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("SetSecretCurrentVersion - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = SetSecretCurrentVersion(context.Background(), dbMock, "callingUserId", "secretId", 1)
+			require.Nil(t, err, "error in SetSecretCurrentVersion: %v", err)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestListSecretVersionIdsErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).
+					AddRow("id1").
+					RowError(0, fmt.Errorf("oh no not the row"))).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ListSecretVersionIds - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := ListSecretVersionIds(context.Background(), dbMock)
+			require.NotNil(t, err, "no error in ListSecretVersionIds: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestListSecretVersionIdsSuccesses(t *testing.T) {
+	var inits = []struct {
+		initFunc initFunc
+		expected []string
+	}{
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WillReturnRows(sqlmock.NewRows([]string{"id"})).
+					RowsWillBeClosed()
+			},
+			expected: []string{},
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("id1").AddRow("id2")).
+					RowsWillBeClosed()
+			},
+			expected: []string{"id1", "id2"},
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ListSecretVersionIds - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given.initFunc(dbMock)
+
+			result, err := ListSecretVersionIds(context.Background(), dbMock)
+			require.Nil(t, err, "error in ListSecretVersionIds: %v", err)
+			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestListPurgedSecretVersionIdsErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).
+					AddRow("id1").
+					RowError(0, fmt.Errorf("oh no not the row"))).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ListPurgedSecretVersionIds - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := ListPurgedSecretVersionIds(context.Background(), dbMock, "secretId")
+			require.NotNil(t, err, "no error in ListPurgedSecretVersionIds: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestListPurgedSecretVersionIdsSuccesses(t *testing.T) {
+	var inits = []struct {
+		initFunc initFunc
+		expected []string
+	}{
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT (.+) FOR UPDATE").
+					WithArgs("secretId").
+					WillReturnRows(sqlmock.NewRows([]string{"id"})).
+					RowsWillBeClosed()
+			},
+			expected: []string{},
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT (.+) FOR UPDATE").
+					WithArgs("secretId").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("id1").AddRow("id2")).
+					RowsWillBeClosed()
+			},
+			expected: []string{"id1", "id2"},
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ListPurgedSecretVersionIds - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given.initFunc(dbMock)
+
+			result, err := ListPurgedSecretVersionIds(context.Background(), dbMock, "secretId")
+			require.Nil(t, err, "error in ListPurgedSecretVersionIds: %v", err)
+			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestListSecretVersionsToRekeyErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "secret_id", "name", "namespace", "version", "value"}).
+					AddRow("id1", "secretId", "name", "default", 1, "value").
+					RowError(0, fmt.Errorf("oh no not the row"))).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ListSecretVersionsToRekey - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := ListSecretVersionsToRekey(context.Background(), dbMock, "", time.Now(), 100)
+			require.NotNil(t, err, "no error in ListSecretVersionsToRekey: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestListSecretVersionsToRekeySuccesses(t *testing.T) {
+	keyedBefore := time.Now()
+	version1 := &common.RekeyableSecretVersion{Id: "id1", SecretId: "secretId", SecretName: "name", Namespace: "default", Version: 1, Value: "value1"}
+	version2 := &common.RekeyableSecretVersion{Id: "id2", SecretId: "secretId", SecretName: "name", Namespace: "default", Version: 2, Value: "value2"}
+	var inits = []struct {
+		initFunc initFunc
+		expected []*common.RekeyableSecretVersion
+	}{
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WithArgs("", keyedBefore, 100).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "secret_id", "name", "namespace", "version", "value"})).
+					RowsWillBeClosed()
+			},
+			expected: []*common.RekeyableSecretVersion{},
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WithArgs("", keyedBefore, 100).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "secret_id", "name", "namespace", "version", "value"}).
+						AddRow(version1.Id, version1.SecretId, version1.SecretName, version1.Namespace, version1.Version, version1.Value).
+						AddRow(version2.Id, version2.SecretId, version2.SecretName, version2.Namespace, version2.Version, version2.Value)).
+					RowsWillBeClosed()
+			},
+			expected: []*common.RekeyableSecretVersion{version1, version2},
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ListSecretVersionsToRekey - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given.initFunc(dbMock)
+
+			result, err := ListSecretVersionsToRekey(context.Background(), dbMock, "", keyedBefore, 100)
+			require.Nil(t, err, "error in ListSecretVersionsToRekey: %v", err)
+			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestCountSecretVersionsToRekeyErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("CountSecretVersionsToRekey - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := CountSecretVersionsToRekey(context.Background(), dbMock, time.Now())
+			require.NotNil(t, err, "no error in CountSecretVersionsToRekey: %v", err)
+			require.Equal(t, result, 0, "Expected 0 result, got: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestCountSecretVersionsToRekeySuccesses(t *testing.T) {
+	keyedBefore := time.Now()
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").
+				WithArgs(keyedBefore).
+				WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3)).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("CountSecretVersionsToRekey - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := CountSecretVersionsToRekey(context.Background(), dbMock, keyedBefore)
+			require.Nil(t, err, "error in CountSecretVersionsToRekey: %v", err)
+			require.Equal(t, result, 3, "Result %v did not equal expected 3", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestRekeySecretVersionErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("RekeySecretVersion - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = RekeySecretVersion(context.Background(), dbMock, "oldId", "newId", "value")
+			require.NotNil(t, err, "no error in RekeySecretVersion: %v", err)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestRekeySecretVersionSuccesses(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE (.+) s.purged_at IS NULL").WithArgs("newId", "value", "oldId").WillReturnResult(sqlmock.NewResult(1, 1))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("RekeySecretVersion - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = RekeySecretVersion(context.Background(), dbMock, "oldId", "newId", "value")
+			require.Nil(t, err, "error in RekeySecretVersion: %v", err)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
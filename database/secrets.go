@@ -2,20 +2,69 @@ package database
 
 import (
 	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
 
 	"github.com/emarcey/data-vault/common"
 )
 
-func CreateSecret(ctx context.Context, db Database, secret *common.Secret) error {
+// secretSortColumns maps the sort keys accepted by ListSecrets to the columns they order by
+var secretSortColumns = map[string]string{
+	common.SECRET_SORT_NAME:       "s.name",
+	common.SECRET_SORT_CREATED_AT: "s.created_at",
+	common.SECRET_SORT_UPDATED_AT: "s.updated_at",
+}
+
+// marshalLabels encodes labels as a JSON object, never as null
+func marshalLabels(labels map[string]string) (string, error) {
+	if len(labels) == 0 {
+		return "{}", nil
+	}
+	raw, err := json.Marshal(labels)
+	if err != nil {
+		return "", err
+	}
+	return string(raw), nil
+}
+
+// unmarshalLabels decodes a JSON object of labels. Secrets without labels get a nil map.
+func unmarshalLabels(raw []byte) (map[string]string, error) {
+	var labels map[string]string
+	err := json.Unmarshal(raw, &labels)
+	if err != nil {
+		return nil, err
+	}
+	if len(labels) == 0 {
+		return nil, nil
+	}
+	return labels, nil
+}
+
+// CreateSecret creates a secret in a namespace, with its first version
+func CreateSecret(ctx context.Context, db Database, namespaceId string, secret *common.Secret) error {
 	operation := "CreateSecret"
 	tracer := db.CreateTrace(ctx, operation)
 	defer tracer.Close()
 
+	labels, err := marshalLabels(secret.Labels)
+	if err != nil {
+		return common.NewDatabaseError(err, operation, "Error marshalling labels: %v", err)
+	}
+
 	query := `
-	INSERT INTO  admin.secrets (id, name, value, description, created_by, updated_by)
-	VALUES($1, $2, $3, $4, $5, $6)
+	WITH new_secret AS (
+		INSERT INTO  admin.secrets (id, name, description, labels, expires_at, created_by, updated_by, namespace_id)
+		VALUES($1, $2, $3, $4, $5, $6, $7, $14)
+		RETURNING id
+	)
+	INSERT INTO  admin.secret_versions (id, secret_id, version, value, value_type, content_type, filename, created_by)
+	SELECT	$8, ns.id, 1, $9, $10, $11, $12, $13
+	FROM	new_secret ns
 	`
-	result, err := db.ExecContext(tracer.Context(), query, secret.Id, secret.Name, secret.Value, secret.Description, secret.CreatedBy, secret.UpdatedBy)
+	result, err := db.ExecContext(tracer.Context(), query, secret.Id, secret.Name, secret.Description, labels, secret.ExpiresAt, secret.CreatedBy, secret.UpdatedBy, secret.VersionId, secret.Value, secret.ValueType, secret.ContentType, secret.Filename, secret.CreatedBy, namespaceId)
 	if err != nil {
 		dbErr := common.NewDatabaseError(err, operation, "")
 		tracer.CaptureException(dbErr)
@@ -32,7 +81,11 @@ func CreateSecret(ctx context.Context, db Database, secret *common.Secret) error
 	return nil
 }
 
-func GetSecretByName(ctx context.Context, db Database, user *common.User, secretName string) (*common.Secret, error) {
+// GetSecretByName fetches the secret in a namespace at the given version, or at its current version if version is 0.
+// Its updated_by and updated_at are the author and creation time of that version. Admins of the namespace can read
+// every secret in it. Returns a ResourceExpiredError if the secret has expired, whether or not the reaper has
+// deactivated or purged it yet. Purged secrets have no value.
+func GetSecretByName(ctx context.Context, db Database, user *common.User, namespace *common.Namespace, secretName string, version int) (*common.Secret, error) {
 	operation := "GetSecretByName"
 	tracer := db.CreateTrace(ctx, operation)
 	defer tracer.Close()
@@ -40,26 +93,39 @@ func GetSecretByName(ctx context.Context, db Database, user *common.User, secret
 	query := `
 	SELECT	DISTINCT s.id,
 			s.name,
-			s.value,
+			COALESCE(sv.value, '') AS value,
+			sv.value_type,
+			sv.content_type,
+			sv.filename,
 			s.description,
 			created_by_user.name AS created_by,
-			updated_by_user.name AS updated_by
+			updated_by_user.name AS updated_by,
+			sv.created_at AS updated_at,
+			sv.version,
+			sv.id AS version_id,
+			s.expires_at,
+			COALESCE(s.expires_at <= NOW(), false) AS is_expired,
+			(sp.id IS NOT NULL OR $4 OR s.created_by = $5 OR sgp.id IS NOT NULL) AS has_access
 	FROM	admin.secrets s
+	JOIN	admin.secret_versions sv
+		ON	sv.secret_id = s.id
+		AND sv.version = COALESCE(NULLIF($6, 0), s.current_version)
 	JOIN	admin.users created_by_user
 		ON 	s.created_by = created_by_user.id
 		JOIN	admin.users updated_by_user
-		ON 	s.updated_by = updated_by_user.id
+		ON 	sv.created_by = updated_by_user.id
 	LEFT JOIN admin.secret_permissions sp
-		ON sp.secret_id = s.id AND sp.user_id = $1 AND sp.is_active
+		ON (sp.secret_id = s.id OR (sp.namespace_id = s.namespace_id AND s.name LIKE sp.secret_name_like)) AND sp.user_id = $1 AND sp.is_active
 	LEFT JOIN admin.user_group_members ugm
 		ON 	ugm.user_id = $2 AND ugm.is_active
 	LEFT JOIN admin.secret_group_permissions sgp
-		ON sgp.secret_id = s.id AND sgp.user_group_id = ugm.user_group_id AND sgp.is_active
+		ON (sgp.secret_id = s.id OR (sgp.namespace_id = s.namespace_id AND s.name LIKE sgp.secret_name_like)) AND sgp.user_group_id = ugm.user_group_id AND sgp.is_active
 	WHERE	s.name = $3
-		AND s.is_active
-		AND (sp.id IS NOT NULL OR $4 OR s.created_by = $5 OR sgp.id IS NOT NULL)
+		AND s.namespace_id = $7
+		AND (s.is_active OR s.expired_at IS NOT NULL)
+	ORDER BY is_expired DESC
 	`
-	rows, err := db.QueryContext(tracer.Context(), query, user.Id, user.Id, secretName, user.IsAdmin(), user.Id)
+	rows, err := db.QueryContext(tracer.Context(), query, user.Id, user.Id, secretName, namespace.IsAdmin, user.Id, version, namespace.Id)
 	if err != nil {
 		dbErr := common.NewDatabaseError(err, operation, "")
 		tracer.CaptureException(dbErr)
@@ -68,16 +134,24 @@ func GetSecretByName(ctx context.Context, db Database, user *common.User, secret
 	defer rows.Close()
 
 	var secret *common.Secret
+	var isExpired, isDenied bool
 
+	// rows are ordered so an unexpired secret, if any, is scanned last
 	for rows.Next() {
 		var row common.Secret
-		err = rows.Scan(&row.Id, &row.Name, &row.Value, &row.Description, &row.CreatedBy, &row.UpdatedBy)
+		var rowIsExpired, hasAccess bool
+		err = rows.Scan(&row.Id, &row.Name, &row.Value, &row.ValueType, &row.ContentType, &row.Filename, &row.Description, &row.CreatedBy, &row.UpdatedBy, &row.UpdatedAt, &row.Version, &row.VersionId, &row.ExpiresAt, &rowIsExpired, &hasAccess)
 		if err != nil {
 			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
 			tracer.CaptureException(dbErr)
 			return nil, dbErr
 		}
+		if !hasAccess {
+			isDenied = true
+			continue
+		}
 		secret = &row
+		isExpired = rowIsExpired
 	}
 	err = rows.Err()
 	if err != nil {
@@ -85,40 +159,114 @@ func GetSecretByName(ctx context.Context, db Database, user *common.User, secret
 		tracer.CaptureException(dbErr)
 		return nil, dbErr
 	}
+	if secret == nil && isDenied {
+		return nil, common.NewResourceAccessDeniedError(operation, "name", secretName)
+	}
 	if secret == nil {
 		return nil, common.NewResourceNotFoundError(operation, "name", secretName)
 	}
+	if isExpired {
+		return nil, common.NewResourceExpiredError(operation, "name", secretName)
+	}
 	return secret, nil
 }
 
-func ListSecrets(ctx context.Context, db Database, user *common.User, pageSize, offset int) ([]*common.Secret, error) {
-	operation := "ListSecrets"
-	tracer := db.CreateTrace(ctx, operation)
-	defer tracer.Close()
-
-	query := `
-	SELECT	DISTINCT s.id,
-			s.name,
-			s.description,
-			created_by_user.name AS created_by,
-			updated_by_user.name AS updated_by
+// secretListFilters selects the secrets in a namespace that a user can read and that match a ListSecretsRequest. It
+// takes the arguments returned by secretListArgs.
+const secretListFilters = `
 	FROM	admin.secrets s
 	JOIN	admin.users created_by_user
 		ON 	s.created_by = created_by_user.id
 		JOIN	admin.users updated_by_user
 		ON 	s.updated_by = updated_by_user.id
 	LEFT JOIN admin.secret_permissions sp
-		ON sp.secret_id = s.id AND sp.user_id = $1 AND sp.is_active
+		ON (sp.secret_id = s.id OR (sp.namespace_id = s.namespace_id AND s.name LIKE sp.secret_name_like)) AND sp.user_id = $1 AND sp.is_active
 	LEFT JOIN admin.user_group_members ugm
 		ON 	ugm.user_id = $2 AND ugm.is_active
 	LEFT JOIN admin.secret_group_permissions sgp
-		ON sgp.secret_id = s.id AND sgp.user_group_id = ugm.user_group_id AND sgp.is_active
+		ON (sgp.secret_id = s.id OR (sgp.namespace_id = s.namespace_id AND s.name LIKE sgp.secret_name_like)) AND sgp.user_group_id = ugm.user_group_id AND sgp.is_active
 	WHERE	s.is_active
+		AND s.namespace_id = $16
+		AND (s.expires_at IS NULL OR s.expires_at > NOW())
 		AND (sp.id IS NOT NULL OR $3 OR s.created_by = $4 OR sgp.id IS NOT NULL)
-	LIMIT 	$5
-	OFFSET 	$6
-	`
-	rows, err := db.QueryContext(tracer.Context(), query, user.Id, user.Id, user.IsAdmin(), user.Id, pageSize, offset)
+		AND left(s.name, length($5::text)) = $5::text
+		AND strpos(lower(s.name), lower($6::text)) > 0
+		AND ($7::text = '' OR created_by_user.name = $7::text)
+		AND s.labels @> $8::jsonb
+		AND s.labels ?& $9::text[]
+		AND NOT s.labels ?| $10::text[]
+		AND NOT EXISTS (SELECT 1 FROM jsonb_each_text($11::jsonb) ne WHERE s.labels ->> ne.key = ne.value)
+		AND ($12::timestamptz IS NULL OR s.created_at >= $12)
+		AND ($13::timestamptz IS NULL OR s.created_at < $13)
+		AND ($14::timestamptz IS NULL OR s.updated_at >= $14)
+		AND ($15::timestamptz IS NULL OR s.updated_at < $15)
+`
+
+func secretListArgs(user *common.User, namespace *common.Namespace, req *common.ListSecretsRequest, selector *common.LabelSelector) ([]interface{}, error) {
+	if selector == nil {
+		selector = &common.LabelSelector{}
+	}
+	equals, err := marshalLabels(selector.Equals)
+	if err != nil {
+		return nil, err
+	}
+	notEquals, err := marshalLabels(selector.NotEquals)
+	if err != nil {
+		return nil, err
+	}
+	return []interface{}{
+		user.Id, user.Id, namespace.IsAdmin, user.Id,
+		req.NamePrefix, req.NameContains, req.CreatedBy,
+		equals, pq.Array(selector.Exists), pq.Array(selector.NotExists), notEquals,
+		req.CreatedAfter, req.CreatedBefore, req.UpdatedAfter, req.UpdatedBefore,
+		namespace.Id,
+	}, nil
+}
+
+// ListSecrets returns the active secrets in a namespace that the user can read and that match every filter in req,
+// starting after the cursor if one is given. selector holds the parsed req.Labels and may be nil.
+func ListSecrets(ctx context.Context, db Database, user *common.User, namespace *common.Namespace, req *common.ListSecretsRequest, selector *common.LabelSelector, limit int, after *common.PageCursor) ([]*common.Secret, error) {
+	operation := "ListSecrets"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	sortColumn, ok := secretSortColumns[req.SortBy]
+	if !ok {
+		sortColumn = secretSortColumns[common.SECRET_SORT_NAME]
+	}
+	// the cursor holds a name or an RFC 3339 time, depending on the sort
+	afterValue := "$18"
+	if sortColumn != secretSortColumns[common.SECRET_SORT_NAME] {
+		afterValue = "NULLIF($18, '')::timestamptz"
+	}
+	sortOrder, afterOperator := "ASC", ">"
+	if req.SortOrder == common.SORT_ORDER_DESC {
+		sortOrder, afterOperator = "DESC", "<"
+	}
+	args, err := secretListArgs(user, namespace, req, selector)
+	if err != nil {
+		return nil, common.NewDatabaseError(err, operation, "Error marshalling labels: %v", err)
+	}
+	afterId, afterSortValue := cursorArgs(after)
+	args = append(args, afterId, afterSortValue, limit, req.Offset)
+
+	query := `
+	SELECT	DISTINCT s.id,
+			s.name,
+			s.description,
+			s.labels,
+			created_by_user.name AS created_by,
+			updated_by_user.name AS updated_by,
+			s.current_version,
+			s.expires_at,
+			s.created_at,
+			s.updated_at` + secretListFilters + fmt.Sprintf(`
+		AND ($17 = '' OR (%s, s.id) %s (%s, NULLIF($17, '')::uuid))
+	ORDER BY %s %s, s.id %s
+	LIMIT 	$19
+	OFFSET 	$20
+	`, sortColumn, afterOperator, afterValue, sortColumn, sortOrder, sortOrder)
+	rows, err := db.QueryContext(tracer.Context(), query, args...)
 	if err != nil {
 		dbErr := common.NewDatabaseError(err, operation, "")
 		tracer.CaptureException(dbErr)
@@ -130,12 +278,19 @@ func ListSecrets(ctx context.Context, db Database, user *common.User, pageSize,
 
 	for rows.Next() {
 		var row common.Secret
-		err = rows.Scan(&row.Id, &row.Name, &row.Description, &row.CreatedBy, &row.UpdatedBy)
+		var labels []byte
+		err = rows.Scan(&row.Id, &row.Name, &row.Description, &labels, &row.CreatedBy, &row.UpdatedBy, &row.Version, &row.ExpiresAt, &row.CreatedAt, &row.UpdatedAt)
 		if err != nil {
 			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
 			tracer.CaptureException(dbErr)
 			return nil, dbErr
 		}
+		row.Labels, err = unmarshalLabels(labels)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error unmarshalling labels: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
 		secrets = append(secrets, &row)
 	}
 	err = rows.Err()
@@ -147,23 +302,92 @@ func ListSecrets(ctx context.Context, db Database, user *common.User, pageSize,
 	return secrets, nil
 }
 
-func GetSecretIdWithWriteAccess(ctx context.Context, db Database, user *common.User, secretName string) (string, error) {
-	operation := "GetSecretIdWithWriteAccess"
+// CountSecrets returns the number of secrets ListSecrets would return across every page
+func CountSecrets(ctx context.Context, db Database, user *common.User, namespace *common.Namespace, req *common.ListSecretsRequest, selector *common.LabelSelector) (int, error) {
+	operation := "CountSecrets"
+	args, err := secretListArgs(user, namespace, req, selector)
+	if err != nil {
+		return 0, common.NewDatabaseError(err, operation, "Error marshalling labels: %v", err)
+	}
+	query := `
+	SELECT	COUNT(DISTINCT s.id)` + secretListFilters
+	return count(ctx, db, operation, query, args...)
+}
+
+// SetSecretLabels replaces all labels on an active secret
+func SetSecretLabels(ctx context.Context, db Database, callingUserId, secretId string, labels map[string]string) error {
+	operation := "SetSecretLabels"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	rawLabels, err := marshalLabels(labels)
+	if err != nil {
+		return common.NewDatabaseError(err, operation, "Error marshalling labels: %v", err)
+	}
+
+	query := `
+	UPDATE	admin.secrets
+	SET		labels = $1,
+			updated_by = $2
+	WHERE	id = $3
+		AND is_active
+	`
+	result, err := db.ExecContext(tracer.Context(), query, rawLabels, callingUserId, secretId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	if rowsAffected == 0 {
+		return common.NewResourceNotFoundError(operation, "id", secretId)
+	}
+	db.GetLogger().Debugf("%s updated %d rows", operation, rowsAffected)
+	return nil
+}
+
+// GetSecretIdWithAccess returns the id of the secret in a namespace if the user is an admin of the namespace, its
+// creator, or holds a grant on it (directly, through a group or through a name pattern) at or above level
+func GetSecretIdWithAccess(ctx context.Context, db Database, user *common.User, namespace *common.Namespace, secretName, level string) (string, error) {
+	operation := "GetSecretIdWithAccess"
 	tracer := db.CreateTrace(ctx, operation)
 	defer tracer.Close()
 
 	query := `
-	SELECT	s.id
+	WITH allowed_levels AS (
+		SELECT	pl.id
+		FROM	admin.permission_level pl
+		JOIN	admin.permission_level required_level
+			ON	pl.rank >= required_level.rank
+		WHERE	required_level.id = $1
+	)
+	SELECT	DISTINCT s.id,
+			($5 OR s.created_by = $6 OR sp.id IS NOT NULL OR sgp.id IS NOT NULL) AS has_access
 	FROM	admin.secrets s
 	JOIN	admin.users created_by_user
 		ON 	s.created_by = created_by_user.id
 		JOIN	admin.users updated_by_user
 		ON 	s.updated_by = updated_by_user.id
-	WHERE	s.name = $1
+	LEFT JOIN admin.secret_permissions sp
+		ON (sp.secret_id = s.id OR (sp.namespace_id = s.namespace_id AND s.name LIKE sp.secret_name_like)) AND sp.user_id = $2 AND sp.is_active
+		AND sp.level IN (SELECT id FROM allowed_levels)
+	LEFT JOIN admin.user_group_members ugm
+		ON 	ugm.user_id = $3 AND ugm.is_active
+	LEFT JOIN admin.secret_group_permissions sgp
+		ON (sgp.secret_id = s.id OR (sgp.namespace_id = s.namespace_id AND s.name LIKE sgp.secret_name_like)) AND sgp.user_group_id = ugm.user_group_id AND sgp.is_active
+		AND sgp.level IN (SELECT id FROM allowed_levels)
+	WHERE	s.name = $4
+		AND s.namespace_id = $7
 		AND s.is_active
-		AND ($2 OR s.created_by = $3)
+		AND (s.expires_at IS NULL OR s.expires_at > NOW())
 	`
-	rows, err := db.QueryContext(tracer.Context(), query, secretName, user.IsAdmin(), user.Id)
+	rows, err := db.QueryContext(tracer.Context(), query, level, user.Id, user.Id, secretName, namespace.IsAdmin, user.Id, namespace.Id)
 	if err != nil {
 		dbErr := common.NewDatabaseError(err, operation, "")
 		tracer.CaptureException(dbErr)
@@ -172,20 +396,34 @@ func GetSecretIdWithWriteAccess(ctx context.Context, db Database, user *common.U
 	defer rows.Close()
 
 	var id string
+	var isDenied bool
 
 	for rows.Next() {
-		err = rows.Scan(&id)
+		var hasAccess bool
+		err = rows.Scan(&id, &hasAccess)
 		if err != nil {
 			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
 			tracer.CaptureException(dbErr)
 			return "", dbErr
 		}
-		return id, nil
+		if hasAccess {
+			return id, nil
+		}
+		isDenied = true
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return "", dbErr
+	}
+	if isDenied {
+		return "", common.NewResourceAccessDeniedError(operation, "name", secretName)
 	}
 	return "", common.NewResourceNotFoundError(operation, "name", secretName)
 }
 
-func DeleteSecret(ctx context.Context, db Database, userId, secretName string) error {
+func DeleteSecret(ctx context.Context, db Database, userId, namespaceId, secretName string) error {
 	operation := "DeleteSecret"
 	tracer := db.CreateTrace(ctx, operation)
 	defer tracer.Close()
@@ -193,10 +431,11 @@ func DeleteSecret(ctx context.Context, db Database, userId, secretName string) e
 	query := `
 	UPDATE  admin.secrets
 	SET is_active = false,
+		deleted_at = NOW(),
 		updated_by = $1
-	WHERE	name = $2 AND is_active = true
+	WHERE	name = $2 AND namespace_id = $3 AND is_active = true
 	`
-	result, err := db.ExecContext(tracer.Context(), query, userId, secretName)
+	result, err := db.ExecContext(tracer.Context(), query, userId, secretName, namespaceId)
 	if err != nil {
 		dbErr := common.NewDatabaseError(err, operation, "")
 		tracer.CaptureException(dbErr)
@@ -213,3 +452,261 @@ func DeleteSecret(ctx context.Context, db Database, userId, secretName string) e
 
 	return nil
 }
+
+// ExpireSecrets deactivates every active secret past its expiry time, recording when in expired_at so it reads as
+// expired rather than deleted, and in deleted_at so it is purged like a deleted secret, and returns them, with their namespace names
+func ExpireSecrets(ctx context.Context, db Database) ([]*common.Secret, error) {
+	operation := "ExpireSecrets"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	UPDATE	admin.secrets s
+	SET		is_active = false,
+			expired_at = NOW(),
+			deleted_at = NOW()
+	FROM	admin.namespaces n
+	WHERE	s.is_active
+		AND s.expires_at <= NOW()
+		AND n.id = s.namespace_id
+	RETURNING s.id, s.name, n.name, s.expires_at
+	`
+	rows, err := db.QueryContext(tracer.Context(), query)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	secrets := make([]*common.Secret, 0)
+
+	for rows.Next() {
+		var row common.Secret
+		err = rows.Scan(&row.Id, &row.Name, &row.Namespace, &row.ExpiresAt)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+		secrets = append(secrets, &row)
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	db.GetLogger().Debugf("%s deactivated %d rows", operation, len(secrets))
+	return secrets, nil
+}
+
+// ListSecretsToPurge returns up to limit deleted secrets that were deleted, or expired, before deletedBefore and haven't
+// been purged, oldest deletion first, followed by secrets whose purge was interrupted. If secretId is set, only that
+// secret is returned. A limit of 0 returns every secret.
+func ListSecretsToPurge(ctx context.Context, db Database, secretId string, deletedBefore time.Time, limit int) ([]*common.PurgeableSecret, error) {
+	operation := "ListSecretsToPurge"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	SELECT	s.id,
+			s.name,
+			n.name
+	FROM	admin.secrets s
+	JOIN	admin.namespaces n
+		ON	s.namespace_id = n.id
+	WHERE	($1 = '' OR s.id = NULLIF($1, '')::uuid)
+		AND NOT s.is_active
+		AND (
+			(s.purged_at IS NULL AND s.deleted_at < $2)
+			OR (s.purged_at IS NOT NULL AND EXISTS (SELECT 1 FROM admin.secret_versions sv WHERE sv.secret_id = s.id AND sv.value IS NOT NULL))
+		)
+	ORDER BY s.purged_at NULLS FIRST, s.deleted_at, s.id
+	LIMIT	NULLIF($3, 0)
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, secretId, deletedBefore, limit)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	secrets := make([]*common.PurgeableSecret, 0)
+
+	for rows.Next() {
+		var row common.PurgeableSecret
+		err = rows.Scan(&row.Id, &row.Name, &row.Namespace)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+		secrets = append(secrets, &row)
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	return secrets, nil
+}
+
+// MarkSecretPurged marks a deleted secret purged before its data keys are deleted, so it can't be restored or rekeyed
+// part way through the purge. Secrets already marked by an interrupted purge are left as they are. The secret keeps the
+// user who deleted it, since the purge is recorded in the access log. Returns a not found error if the secret is active.
+func MarkSecretPurged(ctx context.Context, db Database, secretId string) error {
+	operation := "MarkSecretPurged"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	UPDATE	admin.secrets
+	SET		purged_at = COALESCE(purged_at, NOW())
+	WHERE	id = $1
+		AND NOT is_active
+	`
+	result, err := db.ExecContext(tracer.Context(), query, secretId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	if rowsAffected == 0 {
+		return common.NewResourceNotFoundError(operation, "id", secretId)
+	}
+	db.GetLogger().Debugf("%s marked %d rows", operation, rowsAffected)
+	return nil
+}
+
+// ClearPurgedSecretVersions clears the encrypted value of every version of a purged secret, leaving the rows as a
+// tombstone. Its data keys must already be deleted.
+func ClearPurgedSecretVersions(ctx context.Context, db Database, secretId string) error {
+	operation := "ClearPurgedSecretVersions"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	UPDATE	admin.secret_versions sv
+	SET		value = NULL
+	FROM	admin.secrets s
+	WHERE	sv.secret_id = s.id
+		AND s.id = $1
+		AND s.purged_at IS NOT NULL
+		AND sv.value IS NOT NULL
+	`
+	result, err := db.ExecContext(tracer.Context(), query, secretId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	db.GetLogger().Debugf("%s cleared %d versions", operation, rowsAffected)
+	return nil
+}
+
+// ListDeletedSecrets returns the deleted secrets in a namespace ordered by name, starting after the cursor if one is
+// given. Purged secrets are included, with the time they were purged.
+func ListDeletedSecrets(ctx context.Context, db Database, namespaceId string, limit, offset int, after *common.PageCursor) ([]*common.DeletedRecord, error) {
+	afterId, afterName := cursorArgs(after)
+	query := `
+	SELECT	s.id,
+			s.name,
+			updated_by_user.name AS deleted_by,
+			s.deleted_at,
+			s.purged_at
+	FROM	admin.secrets s
+	JOIN	admin.users updated_by_user
+		ON 	s.updated_by = updated_by_user.id
+	WHERE	NOT s.is_active
+		AND s.namespace_id = $5
+		AND ($1 = '' OR (s.name, s.id) > ($2, NULLIF($1, '')::uuid))
+	ORDER BY s.name, s.id
+	LIMIT	$3
+	OFFSET 	$4
+	`
+	return listDeleted(ctx, db, "ListDeletedSecrets", query, afterId, afterName, limit, offset, namespaceId)
+}
+
+// CountDeletedSecrets returns the number of deleted secrets in a namespace, including purged secrets
+func CountDeletedSecrets(ctx context.Context, db Database, namespaceId string) (int, error) {
+	query := `
+	SELECT	COUNT(*)
+	FROM	admin.secrets s
+	WHERE	NOT s.is_active
+		AND s.namespace_id = $1
+	`
+	return count(ctx, db, "CountDeletedSecrets", query, namespaceId)
+}
+
+// RestoreSecret reactivates a deleted secret, renaming it if name is set. Its versions and permissions were left in
+// place when it was deleted, so they apply again. A secret that expired is restored without an expiry, so the reaper
+// doesn't deactivate it again. Returns a not found error if the secret was purged, and an already exists error if an
+// active secret in the namespace has its name.
+func RestoreSecret(ctx context.Context, db Database, callingUserId, namespaceId, secretId, name string) (*common.Secret, error) {
+	operation := "RestoreSecret"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	UPDATE	admin.secrets
+	SET		is_active = true,
+			name = COALESCE(NULLIF($4, ''), name),
+			expires_at = CASE WHEN expires_at <= NOW() THEN NULL ELSE expires_at END,
+			expired_at = NULL,
+			deleted_at = NULL,
+			updated_by = $1
+	WHERE	id = $2
+		AND namespace_id = $3
+		AND NOT is_active
+		AND purged_at IS NULL
+	RETURNING id, name, description, current_version, expires_at
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, callingUserId, secretId, namespaceId, name)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	var secret *common.Secret
+	for rows.Next() {
+		var row common.Secret
+		err = rows.Scan(&row.Id, &row.Name, &row.Description, &row.Version, &row.ExpiresAt)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+		secret = &row
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	if secret == nil {
+		return nil, common.NewResourceNotFoundError(operation, "id", secretId)
+	}
+
+	db.GetLogger().Debugf("%s restored 1 row", operation)
+	return secret, nil
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
 	"github.com/stretchr/testify/require"
 
 	"github.com/emarcey/data-vault/common"
@@ -28,7 +30,7 @@ func TestCreateSecretErrors(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given(dbMock)
 
-			err = CreateSecret(context.Background(), dbMock, secret1)
+			err = CreateSecret(context.Background(), dbMock, "namespaceId", secret1)
 			require.NotNil(t, err, "no error in CreateSecret: %v", err)
 			err = dbMock.mock.ExpectationsWereMet()
 			require.Nil(t, err, "expectations not met: %v", err)
@@ -50,7 +52,7 @@ func TestCreateSecretSuccesses(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given(dbMock)
 
-			err = CreateSecret(context.Background(), dbMock, secret1)
+			err = CreateSecret(context.Background(), dbMock, "namespaceId", secret1)
 			require.Nil(t, err, "error in CreateSecret: %v", err)
 			err = dbMock.mock.ExpectationsWereMet()
 			require.Nil(t, err, "expectations not met: %v", err)
@@ -60,16 +62,28 @@ func TestCreateSecretSuccesses(t *testing.T) {
 
 func TestGetSecretByNameErrors(t *testing.T) {
 	user1 := common.NewDummyUser(t)
+	namespace1 := common.NewDummyNamespace(t)
 	secret1 := common.NewDummySecret(t)
 	var inits = []initFunc{
 		func(dbMock *MockDatabase) {
 			dbMock.mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
 		},
 		func(dbMock *MockDatabase) {
-			dbMock.mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id", "name", "value", "description", "created_by", "updated_by"}).
-				AddRow(secret1.Id, secret1.Name, secret1.Value, secret1.Description, secret1.CreatedBy, secret1.UpdatedBy).
+			dbMock.mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id", "name", "value", "value_type", "content_type", "filename", "description", "created_by", "updated_by", "updated_at", "version", "version_id", "expires_at", "is_expired", "has_access"}).
+				AddRow(secret1.Id, secret1.Name, secret1.Value, secret1.ValueType, secret1.ContentType, secret1.Filename, secret1.Description, secret1.CreatedBy, secret1.UpdatedBy, secret1.UpdatedAt, secret1.Version, secret1.VersionId, nil, false, true).
 				RowError(0, fmt.Errorf("oh no not the row"))).RowsWillBeClosed()
 		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id", "name", "value", "value_type", "content_type", "filename", "description", "created_by", "updated_by", "updated_at", "version", "version_id", "expires_at", "is_expired", "has_access"})).RowsWillBeClosed()
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id", "name", "value", "value_type", "content_type", "filename", "description", "created_by", "updated_by", "updated_at", "version", "version_id", "expires_at", "is_expired", "has_access"}).
+				AddRow(secret1.Id, secret1.Name, secret1.Value, secret1.ValueType, secret1.ContentType, secret1.Filename, secret1.Description, secret1.CreatedBy, secret1.UpdatedBy, secret1.UpdatedAt, secret1.Version, secret1.VersionId, time.Now(), true, true)).RowsWillBeClosed()
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id", "name", "value", "value_type", "content_type", "filename", "description", "created_by", "updated_by", "updated_at", "version", "version_id", "expires_at", "is_expired", "has_access"}).
+				AddRow(secret1.Id, secret1.Name, secret1.Value, secret1.ValueType, secret1.ContentType, secret1.Filename, secret1.Description, secret1.CreatedBy, secret1.UpdatedBy, secret1.UpdatedAt, secret1.Version, secret1.VersionId, nil, false, false)).RowsWillBeClosed()
+		},
 	}
 
 	for idx, given := range inits {
@@ -78,7 +92,7 @@ func TestGetSecretByNameErrors(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given(dbMock)
 
-			result, err := GetSecretByName(context.Background(), dbMock, user1, "secretName")
+			result, err := GetSecretByName(context.Background(), dbMock, user1, namespace1, "secretName", 0)
 			require.NotNil(t, err, "no error in GetSecretByName: %v", err)
 			require.Nil(t, result, "Expected nil result, got: %v", result)
 			err = dbMock.mock.ExpectationsWereMet()
@@ -89,7 +103,11 @@ func TestGetSecretByNameErrors(t *testing.T) {
 
 func TestGetSecretByNameSuccesses(t *testing.T) {
 	user1 := common.NewDummyUser(t)
+	namespace1 := common.NewDummyNamespace(t)
 	secret1 := common.NewDummySecret(t)
+	secret1.Namespace = ""
+	now := time.Now()
+	secret1.UpdatedAt = &now
 
 	var inits = []struct {
 		initFunc initFunc
@@ -97,8 +115,8 @@ func TestGetSecretByNameSuccesses(t *testing.T) {
 	}{
 		{
 			initFunc: func(dbMock *MockDatabase) {
-				dbMock.mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id", "name", "value", "description", "created_by", "updated_by"}).
-					AddRow(secret1.Id, secret1.Name, secret1.Value, secret1.Description, secret1.CreatedBy, secret1.UpdatedBy)).RowsWillBeClosed()
+				dbMock.mock.ExpectQuery("SELECT (.+) s.expired_at IS NOT NULL").WillReturnRows(sqlmock.NewRows([]string{"id", "name", "value", "value_type", "content_type", "filename", "description", "created_by", "updated_by", "updated_at", "version", "version_id", "expires_at", "is_expired", "has_access"}).
+					AddRow(secret1.Id, secret1.Name, secret1.Value, secret1.ValueType, secret1.ContentType, secret1.Filename, secret1.Description, secret1.CreatedBy, secret1.UpdatedBy, secret1.UpdatedAt, secret1.Version, secret1.VersionId, nil, false, true)).RowsWillBeClosed()
 			},
 			expected: secret1,
 		},
@@ -110,7 +128,7 @@ func TestGetSecretByNameSuccesses(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given.initFunc(dbMock)
 
-			result, err := GetSecretByName(context.Background(), dbMock, user1, "secretName")
+			result, err := GetSecretByName(context.Background(), dbMock, user1, namespace1, "secretName", 0)
 			require.Nil(t, err, "Unexpected error in GetSecretByName: %v", err)
 			require.Equal(t, result, given.expected, "Result %+v does not equal expected %+v", result, given.expected)
 			err = dbMock.mock.ExpectationsWereMet()
@@ -119,8 +137,9 @@ func TestGetSecretByNameSuccesses(t *testing.T) {
 	}
 }
 
-func TestGetSecretIdWithWriteAccessErrors(t *testing.T) {
+func TestGetSecretIdWithAccessErrors(t *testing.T) {
 	user1 := common.NewDummyUser(t)
+	namespace1 := common.NewDummyNamespace(t)
 	secret1 := common.NewDummySecret(t)
 	var inits = []initFunc{
 		func(dbMock *MockDatabase) {
@@ -131,16 +150,22 @@ func TestGetSecretIdWithWriteAccessErrors(t *testing.T) {
 				AddRow(secret1.Id, secret1.Name, secret1.Value, secret1.Description, secret1.CreatedBy, secret1.UpdatedBy).
 				RowError(0, fmt.Errorf("oh no not the row"))).RowsWillBeClosed()
 		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id", "has_access"})).RowsWillBeClosed()
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id", "has_access"}).AddRow(secret1.Id, false)).RowsWillBeClosed()
+		},
 	}
 
 	for idx, given := range inits {
-		t.Run(fmt.Sprintf("GetSecretIdWithWriteAccess - Errors - %v", idx), func(t *testing.T) {
+		t.Run(fmt.Sprintf("GetSecretIdWithAccess - Errors - %v", idx), func(t *testing.T) {
 			dbMock, err := NewMockDatabase()
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given(dbMock)
 
-			result, err := GetSecretIdWithWriteAccess(context.Background(), dbMock, user1, "secretName")
-			require.NotNil(t, err, "no error in GetSecretIdWithWriteAccess: %v", err)
+			result, err := GetSecretIdWithAccess(context.Background(), dbMock, user1, namespace1, "secretName", "write")
+			require.NotNil(t, err, "no error in GetSecretIdWithAccess: %v", err)
 			require.Empty(t, result, "Expected nil result, got: %v", result)
 			err = dbMock.mock.ExpectationsWereMet()
 			require.Nil(t, err, "expectations not met: %v", err)
@@ -148,8 +173,9 @@ func TestGetSecretIdWithWriteAccessErrors(t *testing.T) {
 	}
 }
 
-func TestGetSecretIdWithWriteAccessSuccesses(t *testing.T) {
+func TestGetSecretIdWithAccessSuccesses(t *testing.T) {
 	user1 := common.NewDummyUser(t)
+	namespace1 := common.NewDummyNamespace(t)
 	secret1 := common.NewDummySecret(t)
 
 	var inits = []struct {
@@ -158,21 +184,21 @@ func TestGetSecretIdWithWriteAccessSuccesses(t *testing.T) {
 	}{
 		{
 			initFunc: func(dbMock *MockDatabase) {
-				dbMock.mock.ExpectQuery("SELECT").WillReturnRows(sqlmock.NewRows([]string{"id"}).
-					AddRow(secret1.Id)).RowsWillBeClosed()
+				dbMock.mock.ExpectQuery("SELECT").WithArgs("write", user1.Id, user1.Id, "secretName", namespace1.IsAdmin, user1.Id, namespace1.Id).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "has_access"}).AddRow(secret1.Id, true)).RowsWillBeClosed()
 			},
 			expected: secret1,
 		},
 	}
 
 	for idx, given := range inits {
-		t.Run(fmt.Sprintf("GetSecretIdWithWriteAccess - Errors - %v", idx), func(t *testing.T) {
+		t.Run(fmt.Sprintf("GetSecretIdWithAccess - Errors - %v", idx), func(t *testing.T) {
 			dbMock, err := NewMockDatabase()
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given.initFunc(dbMock)
 
-			result, err := GetSecretIdWithWriteAccess(context.Background(), dbMock, user1, "secretName")
-			require.Nil(t, err, "Unexpected error in GetSecretIdWithWriteAccess: %v", err)
+			result, err := GetSecretIdWithAccess(context.Background(), dbMock, user1, namespace1, "secretName", "write")
+			require.Nil(t, err, "Unexpected error in GetSecretIdWithAccess: %v", err)
 			require.Equal(t, result, given.expected.Id, "Result %+v does not equal expected %+v", result, given.expected)
 			err = dbMock.mock.ExpectationsWereMet()
 			require.Nil(t, err, "expectations not met: %v", err)
@@ -196,7 +222,7 @@ func TestDeleteSecretErrors(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given(dbMock)
 
-			err = DeleteSecret(context.Background(), dbMock, "userId", "secretName")
+			err = DeleteSecret(context.Background(), dbMock, "userId", "namespaceId", "secretName")
 			require.NotNil(t, err, "no error in DeleteSecret: %v", err)
 			err = dbMock.mock.ExpectationsWereMet()
 			require.Nil(t, err, "expectations not met: %v", err)
@@ -207,7 +233,7 @@ func TestDeleteSecretErrors(t *testing.T) {
 func TestDeleteSecretSuccesses(t *testing.T) {
 	var inits = []initFunc{
 		func(dbMock *MockDatabase) {
-			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(1, 1))
+			dbMock.mock.ExpectExec(`UPDATE (.+) deleted_at = NOW\(\)`).WillReturnResult(sqlmock.NewResult(1, 1))
 		},
 	}
 
@@ -217,7 +243,7 @@ func TestDeleteSecretSuccesses(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given(dbMock)
 
-			err = DeleteSecret(context.Background(), dbMock, "userId", "secretName")
+			err = DeleteSecret(context.Background(), dbMock, "userId", "namespaceId", "secretName")
 			require.Nil(t, err, "error in DeleteSecret: %v", err)
 			err = dbMock.mock.ExpectationsWereMet()
 			require.Nil(t, err, "expectations not met: %v", err)
@@ -228,17 +254,26 @@ func TestDeleteSecretSuccesses(t *testing.T) {
 func TestListSecretsErrors(t *testing.T) {
 	secret1 := common.NewDummySecret(t)
 	user1 := common.NewDummyUser(t)
+	namespace1 := common.NewDummyNamespace(t)
+	columns := []string{"id", "name", "description", "labels", "created_by", "updated_by", "current_version", "expires_at", "created_at", "updated_at"}
+	now := time.Now()
 	var inits = []initFunc{
 		func(dbMock *MockDatabase) {
 			dbMock.mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
 		},
 		func(dbMock *MockDatabase) {
 			dbMock.mock.ExpectQuery("SELECT").
-				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "created_by", "updated_by"}).
-					AddRow(secret1.Id, secret1.Name, secret1.Description, secret1.CreatedBy, secret1.UpdatedBy).
+				WillReturnRows(sqlmock.NewRows(columns).
+					AddRow(secret1.Id, secret1.Name, secret1.Description, []byte("{}"), secret1.CreatedBy, secret1.UpdatedBy, secret1.Version, nil, now, now).
 					RowError(0, fmt.Errorf("oh no not the row"))).
 				RowsWillBeClosed()
 		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").
+				WillReturnRows(sqlmock.NewRows(columns).
+					AddRow(secret1.Id, secret1.Name, secret1.Description, []byte("not json"), secret1.CreatedBy, secret1.UpdatedBy, secret1.Version, nil, now, now)).
+				RowsWillBeClosed()
+		},
 	}
 
 	for idx, given := range inits {
@@ -247,7 +282,7 @@ func TestListSecretsErrors(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given(dbMock)
 
-			result, err := ListSecrets(context.Background(), dbMock, user1, 0, 10)
+			result, err := ListSecrets(context.Background(), dbMock, user1, namespace1, &common.ListSecretsRequest{PageSize: 10}, nil, 11, nil)
 			require.NotNil(t, err, "no error in ListSecrets: %v", err)
 			require.Nil(t, result, "Result was not nil: %v", result)
 			err = dbMock.mock.ExpectationsWereMet()
@@ -257,42 +292,102 @@ func TestListSecretsErrors(t *testing.T) {
 }
 
 func TestListSecretsSuccesses(t *testing.T) {
+	now := time.Now()
 	secret1 := common.NewDummySecret(t)
 	secret1.Value = ""
+	secret1.ValueType = ""
+	secret1.ContentType = ""
+	secret1.Filename = ""
+	secret1.VersionId = ""
+	secret1.Namespace = ""
+	secret1.Labels = map[string]string{"env": "prod"}
+	secret1.CreatedAt = &now
+	secret1.UpdatedAt = &now
 	secret2 := common.NewDummySecret(t)
 	secret2.Value = ""
+	secret2.ValueType = ""
+	secret2.ContentType = ""
+	secret2.Filename = ""
+	secret2.VersionId = ""
+	secret2.Namespace = ""
+	secret2.CreatedAt = &now
+	secret2.UpdatedAt = &now
 	user1 := common.NewDummyUser(t)
+	namespace1 := common.NewDummyNamespace(t)
+	columns := []string{"id", "name", "description", "labels", "created_by", "updated_by", "current_version", "expires_at", "created_at", "updated_at"}
 	var inits = []struct {
 		initFunc initFunc
+		req      *common.ListSecretsRequest
+		selector *common.LabelSelector
+		after    *common.PageCursor
 		expected []*common.Secret
 	}{
 		{
 			initFunc: func(dbMock *MockDatabase) {
-				dbMock.mock.ExpectQuery("SELECT").
-					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "created_by", "updated_by"})).
+				dbMock.mock.ExpectQuery("ORDER BY s.name ASC, s.id").
+					WillReturnRows(sqlmock.NewRows(columns)).
 					RowsWillBeClosed()
 			},
+			req:      &common.ListSecretsRequest{PageSize: 10},
 			expected: []*common.Secret{},
 		},
 		{
 			initFunc: func(dbMock *MockDatabase) {
 				dbMock.mock.ExpectQuery("SELECT").
-					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "created_by", "updated_by"}).
-						AddRow(secret1.Id, secret1.Name, secret1.Description, secret1.CreatedBy, secret1.UpdatedBy)).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(secret1.Id, secret1.Name, secret1.Description, []byte(`{"env":"prod"}`), secret1.CreatedBy, secret1.UpdatedBy, secret1.Version, nil, now, now)).
 					RowsWillBeClosed()
 			},
+			req:      &common.ListSecretsRequest{PageSize: 10},
 			expected: []*common.Secret{secret1},
 		},
 		{
 			initFunc: func(dbMock *MockDatabase) {
-				dbMock.mock.ExpectQuery("SELECT").
-					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "created_by", "updated_by"}).
-						AddRow(secret1.Id, secret1.Name, secret1.Description, secret1.CreatedBy, secret1.UpdatedBy).
-						AddRow(secret2.Id, secret2.Name, secret2.Description, secret2.CreatedBy, secret2.UpdatedBy)).
+				dbMock.mock.ExpectQuery("ORDER BY s.updated_at DESC, s.id").
+					WithArgs(user1.Id, user1.Id, namespace1.IsAdmin, user1.Id, "app/", "db", "creator",
+						`{"env":"prod"}`, pq.Array([]string{"team"}), pq.Array([]string{"deprecated"}), `{"tier":"dev"}`,
+						&now, nil, nil, nil, namespace1.Id, "", "", 11, 20).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(secret1.Id, secret1.Name, secret1.Description, []byte(`{"env":"prod"}`), secret1.CreatedBy, secret1.UpdatedBy, secret1.Version, nil, now, now).
+						AddRow(secret2.Id, secret2.Name, secret2.Description, []byte("{}"), secret2.CreatedBy, secret2.UpdatedBy, secret2.Version, nil, now, now)).
 					RowsWillBeClosed()
 			},
+			req: &common.ListSecretsRequest{
+				NamePrefix:   "app/",
+				NameContains: "db",
+				CreatedBy:    "creator",
+				CreatedAfter: &now,
+				SortBy:       common.SECRET_SORT_UPDATED_AT,
+				SortOrder:    common.SORT_ORDER_DESC,
+				PageSize:     10,
+				Offset:       20,
+			},
+			selector: &common.LabelSelector{
+				Equals:    map[string]string{"env": "prod"},
+				NotEquals: map[string]string{"tier": "dev"},
+				Exists:    []string{"team"},
+				NotExists: []string{"deprecated"},
+			},
 			expected: []*common.Secret{secret1, secret2},
 		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("ORDER BY s.created_at ASC, s.id").
+					WithArgs(user1.Id, user1.Id, namespace1.IsAdmin, user1.Id, "", "", "",
+						"{}", pq.Array([]string(nil)), pq.Array([]string(nil)), "{}",
+						nil, nil, nil, nil, namespace1.Id, secret1.Id, "2021-01-01T00:00:00Z", 11, 0).
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow(secret2.Id, secret2.Name, secret2.Description, []byte("{}"), secret2.CreatedBy, secret2.UpdatedBy, secret2.Version, nil, now, now)).
+					RowsWillBeClosed()
+			},
+			req: &common.ListSecretsRequest{
+				SortBy:    common.SECRET_SORT_CREATED_AT,
+				SortOrder: common.SORT_ORDER_ASC,
+				PageSize:  10,
+			},
+			after:    &common.PageCursor{Sort: "created_at:asc", Value: "2021-01-01T00:00:00Z", Id: secret1.Id},
+			expected: []*common.Secret{secret2},
+		},
 	}
 
 	for idx, given := range inits {
@@ -301,7 +396,7 @@ func TestListSecretsSuccesses(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given.initFunc(dbMock)
 
-			result, err := ListSecrets(context.Background(), dbMock, user1, 0, 10)
+			result, err := ListSecrets(context.Background(), dbMock, user1, namespace1, given.req, given.selector, 11, given.after)
 			require.Nil(t, err, "no error in ListSecrets: %v", err)
 			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
 			err = dbMock.mock.ExpectationsWereMet()
@@ -309,3 +404,365 @@ func TestListSecretsSuccesses(t *testing.T) {
 		})
 	}
 }
+
+func TestSetSecretLabelsErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("SetSecretLabels - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = SetSecretLabels(context.Background(), dbMock, "callingUserId", "secretId", map[string]string{"env": "prod"})
+			require.NotNil(t, err, "no error in SetSecretLabels: %v", err)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestSetSecretLabelsSuccesses(t *testing.T) {
+	var inits = []struct {
+		initFunc initFunc
+		labels   map[string]string
+	}{
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectExec("UPDATE").WithArgs(`{"env":"prod"}`, "callingUserId", "secretId").WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			labels: map[string]string{"env": "prod"},
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectExec("UPDATE").WithArgs("{}", "callingUserId", "secretId").WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			labels: nil,
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("SetSecretLabels - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given.initFunc(dbMock)
+
+			err = SetSecretLabels(context.Background(), dbMock, "callingUserId", "secretId", given.labels)
+			require.Nil(t, err, "error in SetSecretLabels: %v", err)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestExpireSecretsErrors(t *testing.T) {
+	secret1 := common.NewDummySecret(t)
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("UPDATE").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "namespace", "expires_at"}).
+					AddRow(secret1.Id, secret1.Name, secret1.Namespace, time.Now()).
+					RowError(0, fmt.Errorf("oh no not the row"))).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ExpireSecrets - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := ExpireSecrets(context.Background(), dbMock)
+			require.NotNil(t, err, "no error in ExpireSecrets: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestExpireSecretsSuccesses(t *testing.T) {
+	expiresAt := time.Now()
+	secret1 := &common.Secret{Id: "id1", Name: "name1", Namespace: "team-a", ExpiresAt: &expiresAt}
+	var inits = []struct {
+		initFunc initFunc
+		expected []*common.Secret
+	}{
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery(`UPDATE (.+) expired_at = NOW\(\),\s+deleted_at = NOW\(\)`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "namespace", "expires_at"})).
+					RowsWillBeClosed()
+			},
+			expected: []*common.Secret{},
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery(`UPDATE (.+) expired_at = NOW\(\),\s+deleted_at = NOW\(\)`).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "namespace", "expires_at"}).
+						AddRow(secret1.Id, secret1.Name, secret1.Namespace, expiresAt)).
+					RowsWillBeClosed()
+			},
+			expected: []*common.Secret{secret1},
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ExpireSecrets - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given.initFunc(dbMock)
+
+			result, err := ExpireSecrets(context.Background(), dbMock)
+			require.Nil(t, err, "error in ExpireSecrets: %v", err)
+			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestListSecretsToPurgeErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+					AddRow("id1", "name1")).
+				RowsWillBeClosed()
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "namespace"}).
+					AddRow("id1", "name1", "default").
+					RowError(0, fmt.Errorf("oh no not the row"))).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ListSecretsToPurge - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := ListSecretsToPurge(context.Background(), dbMock, "", time.Now(), 10)
+			require.NotNil(t, err, "no error in ListSecretsToPurge: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestListSecretsToPurgeSuccesses(t *testing.T) {
+	deletedBefore := time.Now()
+	secret1 := &common.PurgeableSecret{Id: "id1", Name: "name1", Namespace: "team-a"}
+	var inits = []struct {
+		secretId string
+		initFunc initFunc
+		expected []*common.PurgeableSecret
+	}{
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WithArgs("", deletedBefore, 10).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "namespace"})).
+					RowsWillBeClosed()
+			},
+			expected: []*common.PurgeableSecret{},
+		},
+		{
+			secretId: "id1",
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WithArgs("id1", deletedBefore, 10).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "namespace"}).
+						AddRow(secret1.Id, secret1.Name, secret1.Namespace)).
+					RowsWillBeClosed()
+			},
+			expected: []*common.PurgeableSecret{secret1},
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ListSecretsToPurge - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given.initFunc(dbMock)
+
+			result, err := ListSecretsToPurge(context.Background(), dbMock, given.secretId, deletedBefore, 10)
+			require.Nil(t, err, "error in ListSecretsToPurge: %v", err)
+			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestMarkSecretPurgedErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("MarkSecretPurged - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = MarkSecretPurged(context.Background(), dbMock, "secretId")
+			require.NotNil(t, err, "no error in MarkSecretPurged: %v", err)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestMarkSecretPurgedSuccesses(t *testing.T) {
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectExec("UPDATE").
+		WithArgs("secretId").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err = MarkSecretPurged(context.Background(), dbMock, "secretId")
+	require.Nil(t, err, "error in MarkSecretPurged: %v", err)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestClearPurgedSecretVersionsErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ClearPurgedSecretVersions - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = ClearPurgedSecretVersions(context.Background(), dbMock, "secretId")
+			require.NotNil(t, err, "no error in ClearPurgedSecretVersions: %v", err)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestClearPurgedSecretVersionsSuccesses(t *testing.T) {
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	// an interrupted purge may have nothing left to clear
+	dbMock.mock.ExpectExec("UPDATE").
+		WithArgs("secretId").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+
+	err = ClearPurgedSecretVersions(context.Background(), dbMock, "secretId")
+	require.Nil(t, err, "error in ClearPurgedSecretVersions: %v", err)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestListDeletedSecretsSuccesses(t *testing.T) {
+	deletedAt := time.Now()
+	purgedAt := deletedAt.Add(time.Hour)
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectQuery("SELECT").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "deleted_by", "deleted_at", "purged_at"}).
+			AddRow("secretId1", "name1", "deletedBy", deletedAt, nil).
+			AddRow("secretId2", "name2", "deletedBy", deletedAt, purgedAt)).
+		RowsWillBeClosed()
+
+	result, err := ListDeletedSecrets(context.Background(), dbMock, "namespaceId", 11, 0, nil)
+	require.Nil(t, err, "error in ListDeletedSecrets: %v", err)
+	expected := []*common.DeletedRecord{
+		{Id: "secretId1", Name: "name1", DeletedBy: "deletedBy", DeletedAt: deletedAt},
+		{Id: "secretId2", Name: "name2", DeletedBy: "deletedBy", DeletedAt: deletedAt, PurgedAt: &purgedAt},
+	}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestRestoreSecretErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("UPDATE").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "current_version", "expires_at"}).
+					AddRow("secretId", "name", "description", 1, nil).
+					RowError(0, fmt.Errorf("oh no not the row"))).
+				RowsWillBeClosed()
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("UPDATE").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "current_version", "expires_at"})).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("RestoreSecret - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := RestoreSecret(context.Background(), dbMock, "callingUserId", "namespaceId", "secretId", "")
+			require.NotNil(t, err, "no error in RestoreSecret: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestRestoreSecretSuccesses(t *testing.T) {
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectQuery("UPDATE (.+) expired_at = NULL").
+		WithArgs("callingUserId", "secretId", "namespaceId", "newName").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "description", "current_version", "expires_at"}).
+			AddRow("secretId", "newName", "description", 2, nil)).
+		RowsWillBeClosed()
+
+	result, err := RestoreSecret(context.Background(), dbMock, "callingUserId", "namespaceId", "secretId", "newName")
+	require.Nil(t, err, "error in RestoreSecret: %v", err)
+	expected := &common.Secret{Id: "secretId", Name: "newName", Description: "description", Version: 2}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
@@ -0,0 +1,505 @@
+package database
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// transitKeyColumns are selected by every query that returns a transit key, in the order scanTransitKey expects
+const transitKeyColumns = `
+			k.id,
+			k.name,
+			k.type,
+			k.latest_version,
+			created_by_user.name,
+			k.created_at,
+			k.updated_at`
+
+func scanTransitKey(rows *sql.Rows, extra ...interface{}) (*common.TransitKey, error) {
+	var row common.TransitKey
+	dest := []interface{}{
+		&row.Id,
+		&row.Name,
+		&row.Type,
+		&row.LatestVersion,
+		&row.CreatedBy,
+		&row.CreatedAt,
+		&row.UpdatedAt,
+	}
+	err := rows.Scan(append(dest, extra...)...)
+	if err != nil {
+		return nil, err
+	}
+	return &row, nil
+}
+
+// CreateTransitKey stores a new transit key with its first version, whose key material is stored under versionId
+func CreateTransitKey(ctx context.Context, db Database, callingUserId string, key *common.TransitKey, versionId string) error {
+	operation := "CreateTransitKey"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	WITH new_key AS (
+		INSERT INTO  admin.transit_keys (id, name, type, latest_version, created_by, updated_by)
+		VALUES($1, $2, $3, 1, $4, $5)
+		RETURNING id
+	)
+	INSERT INTO  admin.transit_key_versions (id, transit_key_id, version, created_by)
+	SELECT	$6, nk.id, 1, $7
+	FROM	new_key nk
+	`
+	result, err := db.ExecContext(tracer.Context(), query, key.Id, key.Name, key.Type, callingUserId, callingUserId, versionId, callingUserId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	db.GetLogger().Debugf("%s created %d rows", operation, rowsAffected)
+	return nil
+}
+
+// ListTransitKeys returns active transit keys ordered by name, starting after the cursor if one is given
+func ListTransitKeys(ctx context.Context, db Database, limit, offset int, after *common.PageCursor) ([]*common.TransitKey, error) {
+	operation := "ListTransitKeys"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	afterId, afterName := cursorArgs(after)
+	query := `
+	SELECT	` + transitKeyColumns + `
+	FROM	admin.transit_keys k
+	JOIN	admin.users created_by_user
+		ON 	k.created_by = created_by_user.id
+	WHERE	k.is_active
+		AND ($1 = '' OR (k.name, k.id) > ($2, NULLIF($1, '')::uuid))
+	ORDER BY k.name, k.id
+	LIMIT	$3
+	OFFSET 	$4
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, afterId, afterName, limit, offset)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	keys := make([]*common.TransitKey, 0)
+
+	for rows.Next() {
+		key, err := scanTransitKey(rows)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+		keys = append(keys, key)
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	return keys, nil
+}
+
+// CountTransitKeys returns the number of active transit keys
+func CountTransitKeys(ctx context.Context, db Database) (int, error) {
+	query := `
+	SELECT	COUNT(*)
+	FROM	admin.transit_keys k
+	WHERE	k.is_active
+	`
+	return count(ctx, db, "CountTransitKeys", query)
+}
+
+// GetTransitKey returns an active transit key by name
+func GetTransitKey(ctx context.Context, db Database, name string) (*common.TransitKey, error) {
+	operation := "GetTransitKey"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	SELECT	` + transitKeyColumns + `
+	FROM	admin.transit_keys k
+	JOIN	admin.users created_by_user
+		ON 	k.created_by = created_by_user.id
+	WHERE	k.name = $1
+		AND k.is_active
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, name)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	var key *common.TransitKey
+
+	for rows.Next() {
+		key, err = scanTransitKey(rows)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	if key == nil {
+		return nil, common.NewResourceNotFoundError(operation, "name", name)
+	}
+	return key, nil
+}
+
+// GetTransitKeyWithAccess returns an active transit key if the user is an admin, or is granted the key directly or
+// through a group
+func GetTransitKeyWithAccess(ctx context.Context, db Database, user *common.User, name string) (*common.TransitKey, error) {
+	operation := "GetTransitKeyWithAccess"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	SELECT	` + transitKeyColumns + `,
+			($1 OR EXISTS (
+				SELECT	1
+				FROM	admin.transit_key_permissions p
+				LEFT JOIN admin.user_group_members ugm
+					ON 	ugm.user_group_id = p.user_group_id
+					AND ugm.user_id = $2
+					AND ugm.is_active
+				WHERE	p.transit_key_id = k.id
+					AND p.is_active
+					AND (p.user_id = $2 OR ugm.id IS NOT NULL)
+			)) AS has_access
+	FROM	admin.transit_keys k
+	JOIN	admin.users created_by_user
+		ON 	k.created_by = created_by_user.id
+	WHERE	k.name = $3
+		AND k.is_active
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, user.IsAdmin(), user.Id, name)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	var key *common.TransitKey
+	var hasAccess bool
+
+	for rows.Next() {
+		key, err = scanTransitKey(rows, &hasAccess)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	if key == nil {
+		return nil, common.NewResourceNotFoundError(operation, "name", name)
+	}
+	if !hasAccess {
+		return nil, common.NewResourceAccessDeniedError(operation, "name", name)
+	}
+	return key, nil
+}
+
+// DeleteTransitKey soft deletes an active transit key. Its versions are kept, but nothing can be decrypted with them.
+func DeleteTransitKey(ctx context.Context, db Database, callingUserId, name string) error {
+	operation := "DeleteTransitKey"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	UPDATE  admin.transit_keys
+	SET is_active = false,
+		updated_by = $1
+	WHERE	name = $2
+		AND is_active
+	`
+	result, err := db.ExecContext(tracer.Context(), query, callingUserId, name)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	if rowsAffected == 0 {
+		return common.NewResourceNotFoundError(operation, "name", name)
+	}
+	db.GetLogger().Debugf("%s soft deleted %d rows", operation, rowsAffected)
+	return nil
+}
+
+// RotateTransitKey adds a new version to an active transit key, whose key material is stored under versionId, and
+// makes it the latest version
+func RotateTransitKey(ctx context.Context, db Database, callingUserId, keyId, versionId string) (int, error) {
+	operation := "RotateTransitKey"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	WITH new_version AS (
+		INSERT INTO  admin.transit_key_versions (id, transit_key_id, version, created_by)
+		SELECT	$1, $2, MAX(kv.version) + 1, $3
+		FROM	admin.transit_key_versions kv
+		WHERE	kv.transit_key_id = $4
+		RETURNING transit_key_id, version
+	)
+	UPDATE	admin.transit_keys k
+	SET		latest_version = nv.version,
+			updated_by = $5
+	FROM	new_version nv
+	WHERE	k.id = nv.transit_key_id
+		AND k.is_active
+	RETURNING k.latest_version
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, versionId, keyId, callingUserId, keyId, callingUserId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return 0, dbErr
+	}
+	defer rows.Close()
+
+	var version int
+	for rows.Next() {
+		err = rows.Scan(&version)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return 0, dbErr
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return 0, dbErr
+	}
+	if version == 0 {
+		return 0, common.NewResourceNotFoundError(operation, "id", keyId)
+	}
+
+	db.GetLogger().Debugf("%s created version %d", operation, version)
+	return version, nil
+}
+
+// GetTransitKeyVersionId returns the id of a version of a transit key, which is also the id of its key material
+func GetTransitKeyVersionId(ctx context.Context, db Database, keyId string, version int) (string, error) {
+	operation := "GetTransitKeyVersionId"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	SELECT	kv.id
+	FROM	admin.transit_key_versions kv
+	WHERE	kv.transit_key_id = $1
+		AND kv.version = $2
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, keyId, version)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return "", dbErr
+	}
+	defer rows.Close()
+
+	var versionId string
+	for rows.Next() {
+		err = rows.Scan(&versionId)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return "", dbErr
+		}
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return "", dbErr
+	}
+	if versionId == "" {
+		return "", common.NewResourceNotFoundError(operation, "version", fmt.Sprintf("%d", version))
+	}
+	return versionId, nil
+}
+
+// ListTransitKeyVersions returns every version of a transit key, oldest first
+func ListTransitKeyVersions(ctx context.Context, db Database, keyId string) ([]*common.TransitKeyVersion, error) {
+	operation := "ListTransitKeyVersions"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	SELECT	kv.id,
+			kv.version,
+			kv.created_at
+	FROM	admin.transit_key_versions kv
+	WHERE	kv.transit_key_id = $1
+	ORDER BY kv.version
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, keyId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	versions := make([]*common.TransitKeyVersion, 0)
+
+	for rows.Next() {
+		var row common.TransitKeyVersion
+		err = rows.Scan(&row.Id, &row.Version, &row.CreatedAt)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+		versions = append(versions, &row)
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	return versions, nil
+}
+
+// CreateTransitKeyPermission allows a user, or the members of a user group, to use a transit key. Existing grants
+// are left as they are.
+func CreateTransitKeyPermission(ctx context.Context, db Database, callingUserId, keyId, userId, userGroupId string) error {
+	operation := "CreateTransitKeyPermission"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	column, principalId := permissionPrincipal(userId, userGroupId)
+	query := `
+	INSERT INTO  admin.transit_key_permissions (transit_key_id, ` + column + `, created_by, updated_by)
+	VALUES($1, $2, $3, $4)
+	ON CONFLICT (transit_key_id, ` + column + `) WHERE is_active
+	DO NOTHING
+	`
+	result, err := db.ExecContext(tracer.Context(), query, keyId, principalId, callingUserId, callingUserId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	db.GetLogger().Debugf("%s created %d rows", operation, rowsAffected)
+	return nil
+}
+
+// DeleteTransitKeyPermission revokes a user's or user group's grant on a transit key
+func DeleteTransitKeyPermission(ctx context.Context, db Database, callingUserId, keyId, userId, userGroupId string) error {
+	operation := "DeleteTransitKeyPermission"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	column, principalId := permissionPrincipal(userId, userGroupId)
+	query := `
+	UPDATE  admin.transit_key_permissions
+	SET is_active = false,
+		updated_by = $1
+	WHERE	transit_key_id = $2
+		AND ` + column + ` = $3
+		AND is_active
+	`
+	result, err := db.ExecContext(tracer.Context(), query, callingUserId, keyId, principalId)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return dbErr
+	}
+	db.GetLogger().Debugf("%s soft deleted %d rows", operation, rowsAffected)
+	return nil
+}
+
+// ListTransitKeyVersionIds returns the id of every transit key version, including those of deleted keys, which is
+// also the id of its key material
+func ListTransitKeyVersionIds(ctx context.Context, db Database) ([]string, error) {
+	operation := "ListTransitKeyVersionIds"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	SELECT	kv.id
+	FROM	admin.transit_key_versions kv
+	ORDER BY kv.created_at
+	`
+	rows, err := db.QueryContext(tracer.Context(), query)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	ids := make([]string, 0)
+
+	for rows.Next() {
+		var id string
+		err = rows.Scan(&id)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+		ids = append(ids, id)
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	return ids, nil
+}
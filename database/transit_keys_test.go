@@ -0,0 +1,349 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	sqlmock "github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+var transitKeyRowColumns = []string{"id", "name", "type", "latest_version", "created_by", "created_at", "updated_at"}
+
+func TestCreateTransitKeyErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("INSERT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("INSERT").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("CreateTransitKey - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = CreateTransitKey(context.Background(), dbMock, "callingUserId", &common.TransitKey{Id: "keyId", Name: "pii", Type: common.TRANSIT_KEY_TYPE_AES256_GCM}, "versionId")
+			require.NotNil(t, err, "no error in CreateTransitKey: %v", err)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestCreateTransitKeySuccesses(t *testing.T) {
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectExec("INSERT INTO  admin.transit_key_versions").
+		WithArgs("keyId", "pii", common.TRANSIT_KEY_TYPE_AES256_GCM, "callingUserId", "callingUserId", "versionId", "callingUserId").
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	err = CreateTransitKey(context.Background(), dbMock, "callingUserId", &common.TransitKey{Id: "keyId", Name: "pii", Type: common.TRANSIT_KEY_TYPE_AES256_GCM}, "versionId")
+	require.Nil(t, err, "error in CreateTransitKey: %v", err)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestListTransitKeysSuccesses(t *testing.T) {
+	now := time.Now()
+	var inits = []struct {
+		initFunc initFunc
+		after    *common.PageCursor
+		expected []*common.TransitKey
+	}{
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WithArgs("", "", 11, 0).
+					WillReturnRows(sqlmock.NewRows(transitKeyRowColumns)).
+					RowsWillBeClosed()
+			},
+			expected: []*common.TransitKey{},
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WithArgs("keyId0", "aaa", 11, 0).
+					WillReturnRows(sqlmock.NewRows(transitKeyRowColumns).
+						AddRow("keyId1", "pii", common.TRANSIT_KEY_TYPE_AES256_GCM, 2, "admin", now, now)).
+					RowsWillBeClosed()
+			},
+			after: &common.PageCursor{Value: "aaa", Id: "keyId0"},
+			expected: []*common.TransitKey{{
+				Id:            "keyId1",
+				Name:          "pii",
+				Type:          common.TRANSIT_KEY_TYPE_AES256_GCM,
+				LatestVersion: 2,
+				CreatedBy:     "admin",
+				CreatedAt:     &now,
+				UpdatedAt:     &now,
+			}},
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ListTransitKeys - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given.initFunc(dbMock)
+
+			result, err := ListTransitKeys(context.Background(), dbMock, 11, 0, given.after)
+			require.Nil(t, err, "error in ListTransitKeys: %v", err)
+			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestGetTransitKeyWithAccessErrors(t *testing.T) {
+	user1 := common.NewDummyUser(t)
+	user1.Type = "developer"
+	columns := append(transitKeyRowColumns, "has_access")
+	var inits = []struct {
+		initFunc initFunc
+		expected error
+	}{
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
+			},
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WillReturnRows(sqlmock.NewRows(columns)).
+					RowsWillBeClosed()
+			},
+			expected: common.NewResourceNotFoundError("GetTransitKeyWithAccess", "name", "pii"),
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WithArgs(false, user1.Id, "pii").
+					WillReturnRows(sqlmock.NewRows(columns).
+						AddRow("keyId1", "pii", common.TRANSIT_KEY_TYPE_AES256_GCM, 1, "admin", time.Now(), time.Now(), false)).
+					RowsWillBeClosed()
+			},
+			expected: common.NewResourceAccessDeniedError("GetTransitKeyWithAccess", "name", "pii"),
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("GetTransitKeyWithAccess - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given.initFunc(dbMock)
+
+			result, err := GetTransitKeyWithAccess(context.Background(), dbMock, user1, "pii")
+			require.NotNil(t, err, "no error in GetTransitKeyWithAccess: %v", err)
+			if given.expected != nil {
+				require.Equal(t, given.expected, err)
+			}
+			require.Nil(t, result, "Result was not nil: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestGetTransitKeyWithAccessSuccesses(t *testing.T) {
+	user1 := common.NewDummyUser(t)
+	user1.Type = "developer"
+	now := time.Now()
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectQuery("admin.transit_key_permissions").
+		WithArgs(false, user1.Id, "pii").
+		WillReturnRows(sqlmock.NewRows(append(transitKeyRowColumns, "has_access")).
+			AddRow("keyId1", "pii", common.TRANSIT_KEY_TYPE_ED25519, 3, "admin", now, now, true)).
+		RowsWillBeClosed()
+
+	result, err := GetTransitKeyWithAccess(context.Background(), dbMock, user1, "pii")
+	require.Nil(t, err, "error in GetTransitKeyWithAccess: %v", err)
+	expected := &common.TransitKey{
+		Id:            "keyId1",
+		Name:          "pii",
+		Type:          common.TRANSIT_KEY_TYPE_ED25519,
+		LatestVersion: 3,
+		CreatedBy:     "admin",
+		CreatedAt:     &now,
+		UpdatedAt:     &now,
+	}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestDeleteTransitKeyErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("DeleteTransitKey - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			err = DeleteTransitKey(context.Background(), dbMock, "callingUserId", "pii")
+			require.NotNil(t, err, "no error in DeleteTransitKey: %v", err)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestRotateTransitKeyErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("WITH new_version").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("WITH new_version").
+				WillReturnRows(sqlmock.NewRows([]string{"latest_version"})).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("RotateTransitKey - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			version, err := RotateTransitKey(context.Background(), dbMock, "callingUserId", "keyId", "versionId")
+			require.NotNil(t, err, "no error in RotateTransitKey: %v", err)
+			require.Equal(t, version, 0)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestRotateTransitKeySuccesses(t *testing.T) {
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectQuery("WITH new_version").
+		WithArgs("versionId", "keyId", "callingUserId", "keyId", "callingUserId").
+		WillReturnRows(sqlmock.NewRows([]string{"latest_version"}).AddRow(2)).
+		RowsWillBeClosed()
+
+	version, err := RotateTransitKey(context.Background(), dbMock, "callingUserId", "keyId", "versionId")
+	require.Nil(t, err, "error in RotateTransitKey: %v", err)
+	require.Equal(t, version, 2)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestGetTransitKeyVersionId(t *testing.T) {
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectQuery("SELECT").
+		WithArgs("keyId", 2).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("versionId")).
+		RowsWillBeClosed()
+	dbMock.mock.ExpectQuery("SELECT").
+		WithArgs("keyId", 5).
+		WillReturnRows(sqlmock.NewRows([]string{"id"})).
+		RowsWillBeClosed()
+
+	versionId, err := GetTransitKeyVersionId(context.Background(), dbMock, "keyId", 2)
+	require.Nil(t, err, "error in GetTransitKeyVersionId: %v", err)
+	require.Equal(t, versionId, "versionId")
+
+	_, err = GetTransitKeyVersionId(context.Background(), dbMock, "keyId", 5)
+	require.Equal(t, err, common.NewResourceNotFoundError("GetTransitKeyVersionId", "version", "5"))
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestListTransitKeyVersions(t *testing.T) {
+	now := time.Now()
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectQuery("SELECT").
+		WithArgs("keyId").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "version", "created_at"}).
+			AddRow("versionId1", 1, now).
+			AddRow("versionId2", 2, now)).
+		RowsWillBeClosed()
+
+	result, err := ListTransitKeyVersions(context.Background(), dbMock, "keyId")
+	require.Nil(t, err, "error in ListTransitKeyVersions: %v", err)
+	expected := []*common.TransitKeyVersion{
+		{Id: "versionId1", Version: 1, CreatedAt: &now},
+		{Id: "versionId2", Version: 2, CreatedAt: &now},
+	}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestTransitKeyPermissionSuccesses(t *testing.T) {
+	var inits = []struct {
+		initFunc    initFunc
+		userId      string
+		userGroupId string
+		grant       bool
+	}{
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectExec("INSERT INTO  admin.transit_key_permissions \\(transit_key_id, user_id,").
+					WithArgs("keyId", "userId", "callingUserId", "callingUserId").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			userId: "userId",
+			grant:  true,
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectExec("AND user_id = \\$3").
+					WithArgs("callingUserId", "keyId", "userId").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			userId: "userId",
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectExec("INSERT INTO  admin.transit_key_permissions \\(transit_key_id, user_group_id,").
+					WithArgs("keyId", "userGroupId", "callingUserId", "callingUserId").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+			userGroupId: "userGroupId",
+			grant:       true,
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("TransitKeyPermission - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given.initFunc(dbMock)
+
+			if given.grant {
+				err = CreateTransitKeyPermission(context.Background(), dbMock, "callingUserId", "keyId", given.userId, given.userGroupId)
+			} else {
+				err = DeleteTransitKeyPermission(context.Background(), dbMock, "callingUserId", "keyId", given.userId, given.userGroupId)
+			}
+			require.Nil(t, err, "error in TransitKeyPermission: %v", err)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
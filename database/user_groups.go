@@ -6,18 +6,19 @@ import (
 	"github.com/emarcey/data-vault/common"
 )
 
-func CreateUserGroup(ctx context.Context, db Database, callingUserId, userGroupId, userGroupName string) (*common.UserGroup, error) {
+// CreateUserGroup creates a user group in a namespace
+func CreateUserGroup(ctx context.Context, db Database, callingUserId, namespaceId, userGroupId, userGroupName string) (*common.UserGroup, error) {
 	operation := "CreateUserGroup"
 	tracer := db.CreateTrace(ctx, operation)
 	defer tracer.Close()
 
 	query := `
-	INSERT INTO  admin.user_groups (id, name, is_active, created_by, updated_by)
-	VALUES($1, $2, $3, $4, $5)
+	INSERT INTO  admin.user_groups (id, name, is_active, created_by, updated_by, namespace_id)
+	VALUES($1, $2, $3, $4, $5, $6)
 	RETURNING id, name
 	`
 	var userGroup *common.UserGroup
-	rows, err := db.QueryContext(tracer.Context(), query, userGroupId, userGroupName, true, callingUserId, callingUserId)
+	rows, err := db.QueryContext(tracer.Context(), query, userGroupId, userGroupName, true, callingUserId, callingUserId, namespaceId)
 	if err != nil {
 		dbErr := common.NewDatabaseError(err, operation, "")
 		tracer.CaptureException(dbErr)
@@ -48,20 +49,25 @@ func CreateUserGroup(ctx context.Context, db Database, callingUserId, userGroupI
 	return userGroup, nil
 }
 
-func ListUserGroups(ctx context.Context, db Database, pageSize, offset int) ([]*common.UserGroup, error) {
+// ListUserGroups returns the active groups in a namespace ordered by name, starting after the cursor if one is given
+func ListUserGroups(ctx context.Context, db Database, namespaceId string, limit, offset int, after *common.PageCursor) ([]*common.UserGroup, error) {
 	operation := "ListUserGroups"
 	tracer := db.CreateTrace(ctx, operation)
 	defer tracer.Close()
 
+	afterId, afterName := cursorArgs(after)
 	query := `
 	SELECT	u.id,
 			u.name
 	FROM	admin.user_groups u
 	WHERE	u.is_active
-	LIMIT	$1
-	OFFSET 	$2
+		AND u.namespace_id = $5
+		AND ($1 = '' OR (u.name, u.id) > ($2, NULLIF($1, '')::uuid))
+	ORDER BY u.name, u.id
+	LIMIT	$3
+	OFFSET 	$4
 	`
-	rows, err := db.QueryContext(tracer.Context(), query, pageSize, offset)
+	rows, err := db.QueryContext(tracer.Context(), query, afterId, afterName, limit, offset, namespaceId)
 	if err != nil {
 		dbErr := common.NewDatabaseError(err, operation, "")
 		tracer.CaptureException(dbErr)
@@ -90,7 +96,19 @@ func ListUserGroups(ctx context.Context, db Database, pageSize, offset int) ([]*
 	return userGroups, nil
 }
 
-func GetUserGroup(ctx context.Context, db Database, userGroupId string) (*common.UserGroup, error) {
+// CountUserGroups returns the number of active groups in a namespace
+func CountUserGroups(ctx context.Context, db Database, namespaceId string) (int, error) {
+	query := `
+	SELECT	COUNT(*)
+	FROM	admin.user_groups u
+	WHERE	u.is_active
+		AND u.namespace_id = $1
+	`
+	return count(ctx, db, "CountUserGroups", query, namespaceId)
+}
+
+// GetUserGroup fetches an active group, if it's in the namespace
+func GetUserGroup(ctx context.Context, db Database, namespaceId, userGroupId string) (*common.UserGroup, error) {
 	operation := "GetUserGroup"
 	tracer := db.CreateTrace(ctx, operation)
 	defer tracer.Close()
@@ -100,9 +118,10 @@ func GetUserGroup(ctx context.Context, db Database, userGroupId string) (*common
 			u.name
 	FROM	admin.user_groups u
 	WHERE	id = $1
+		AND u.namespace_id = $2
 		AND u.is_active
 	`
-	rows, err := db.QueryContext(tracer.Context(), query, userGroupId)
+	rows, err := db.QueryContext(tracer.Context(), query, userGroupId, namespaceId)
 	if err != nil {
 		dbErr := common.NewDatabaseError(err, operation, "")
 		tracer.CaptureException(dbErr)
@@ -134,18 +153,18 @@ func GetUserGroup(ctx context.Context, db Database, userGroupId string) (*common
 	return user, nil
 }
 
-func DeleteUserGroup(ctx context.Context, db Database, callingUserId, userGroupId string) error {
+func DeleteUserGroup(ctx context.Context, db Database, callingUserId, namespaceId, userGroupId string) error {
 	operation := "DeleteUserGroup"
 	tracer := db.CreateTrace(ctx, operation)
 	defer tracer.Close()
 
 	query := `
 	UPDATE  admin.user_groups
-	SET is_active = false
+	SET is_active = false,
 		updated_by = $1
-	WHERE	id = $2
+	WHERE	id = $2 AND namespace_id = $3
 	`
-	result, err := db.ExecContext(tracer.Context(), query, callingUserId, userGroupId)
+	result, err := db.ExecContext(tracer.Context(), query, callingUserId, userGroupId, namespaceId)
 	if err != nil {
 		dbErr := common.NewDatabaseError(err, operation, "")
 		tracer.CaptureException(dbErr)
@@ -162,3 +181,88 @@ func DeleteUserGroup(ctx context.Context, db Database, callingUserId, userGroupI
 
 	return nil
 }
+
+// ListDeletedUserGroups returns the deleted groups in a namespace ordered by name, starting after the cursor if one is
+// given
+func ListDeletedUserGroups(ctx context.Context, db Database, namespaceId string, limit, offset int, after *common.PageCursor) ([]*common.DeletedRecord, error) {
+	afterId, afterName := cursorArgs(after)
+	query := `
+	SELECT	u.id,
+			u.name,
+			updated_by_user.name AS deleted_by,
+			u.updated_at AS deleted_at,
+			NULL::timestamptz AS purged_at
+	FROM	admin.user_groups u
+	JOIN	admin.users updated_by_user
+		ON 	u.updated_by = updated_by_user.id
+	WHERE	NOT u.is_active
+		AND u.namespace_id = $5
+		AND ($1 = '' OR (u.name, u.id) > ($2, NULLIF($1, '')::uuid))
+	ORDER BY u.name, u.id
+	LIMIT	$3
+	OFFSET 	$4
+	`
+	return listDeleted(ctx, db, "ListDeletedUserGroups", query, afterId, afterName, limit, offset, namespaceId)
+}
+
+// CountDeletedUserGroups returns the number of deleted groups in a namespace
+func CountDeletedUserGroups(ctx context.Context, db Database, namespaceId string) (int, error) {
+	query := `
+	SELECT	COUNT(*)
+	FROM	admin.user_groups u
+	WHERE	NOT u.is_active
+		AND u.namespace_id = $1
+	`
+	return count(ctx, db, "CountDeletedUserGroups", query, namespaceId)
+}
+
+// RestoreUserGroup reactivates a deleted group, renaming it if name is set. Its members and permissions were left in
+// place when it was deleted, so they apply again. Returns an already exists error if an active group in the namespace
+// has its name.
+func RestoreUserGroup(ctx context.Context, db Database, callingUserId, namespaceId, userGroupId, name string) (*common.UserGroup, error) {
+	operation := "RestoreUserGroup"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	UPDATE	admin.user_groups
+	SET		is_active = true,
+			name = COALESCE(NULLIF($4, ''), name),
+			updated_by = $1
+	WHERE	id = $2
+		AND namespace_id = $3
+		AND NOT is_active
+	RETURNING id, name
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, callingUserId, userGroupId, namespaceId, name)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	var userGroup *common.UserGroup
+	for rows.Next() {
+		var row common.UserGroup
+		err = rows.Scan(&row.Id, &row.Name)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+		userGroup = &row
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	if userGroup == nil {
+		return nil, common.NewResourceNotFoundError(operation, "id", userGroupId)
+	}
+
+	db.GetLogger().Debugf("%s restored 1 row", operation)
+	return userGroup, nil
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/require"
@@ -27,7 +28,7 @@ func TestDeleteUserGroupErrors(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given(dbMock)
 
-			err = DeleteUserGroup(context.Background(), dbMock, "callingUserId", "userGroupId")
+			err = DeleteUserGroup(context.Background(), dbMock, "callingUserId", "namespaceId", "userGroupId")
 			require.NotNil(t, err, "no error in DeleteUserGroup: %v", err)
 			err = dbMock.mock.ExpectationsWereMet()
 			require.Nil(t, err, "expectations not met: %v", err)
@@ -38,7 +39,7 @@ func TestDeleteUserGroupErrors(t *testing.T) {
 func TestDeleteUserGroupSuccesses(t *testing.T) {
 	var inits = []initFunc{
 		func(dbMock *MockDatabase) {
-			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(1, 1)).WithArgs("callingUserId", "userGroupId")
+			dbMock.mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(1, 1)).WithArgs("callingUserId", "userGroupId", "namespaceId")
 		},
 	}
 
@@ -48,7 +49,7 @@ func TestDeleteUserGroupSuccesses(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given(dbMock)
 
-			err = DeleteUserGroup(context.Background(), dbMock, "callingUserId", "userGroupId")
+			err = DeleteUserGroup(context.Background(), dbMock, "callingUserId", "namespaceId", "userGroupId")
 			require.Nil(t, err, "error in DeleteUserGroup: %v", err)
 			err = dbMock.mock.ExpectationsWereMet()
 			require.Nil(t, err, "expectations not met: %v", err)
@@ -77,7 +78,7 @@ func TestCreateUserGroupErrors(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given(dbMock)
 
-			result, err := CreateUserGroup(context.Background(), dbMock, "callingUserId", userGroup1.Id, userGroup1.Name)
+			result, err := CreateUserGroup(context.Background(), dbMock, "callingUserId", "namespaceId", userGroup1.Id, userGroup1.Name)
 			require.NotNil(t, err, "no error in CreateUserGroup: %v", err)
 			require.Nil(t, result, "Result was not nil: %v", result)
 			err = dbMock.mock.ExpectationsWereMet()
@@ -109,7 +110,7 @@ func TestCreateUserGroupSuccesses(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given.initFunc(dbMock)
 
-			result, err := CreateUserGroup(context.Background(), dbMock, "callingUserId", userGroup1.Id, userGroup1.Name)
+			result, err := CreateUserGroup(context.Background(), dbMock, "callingUserId", "namespaceId", userGroup1.Id, userGroup1.Name)
 			require.Nil(t, err, "no error in CreateUserGroup: %v", err)
 			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
 			err = dbMock.mock.ExpectationsWereMet()
@@ -139,7 +140,7 @@ func TestGetUserGroupErrors(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given(dbMock)
 
-			result, err := GetUserGroup(context.Background(), dbMock, userGroup1.Id)
+			result, err := GetUserGroup(context.Background(), dbMock, "namespaceId", userGroup1.Id)
 			require.NotNil(t, err, "no error in GetUserGroup: %v", err)
 			require.Nil(t, result, "Result was not nil: %v", result)
 			err = dbMock.mock.ExpectationsWereMet()
@@ -171,7 +172,7 @@ func TestGetUserGroupSuccesses(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given.initFunc(dbMock)
 
-			result, err := GetUserGroup(context.Background(), dbMock, userGroup1.Id)
+			result, err := GetUserGroup(context.Background(), dbMock, "namespaceId", userGroup1.Id)
 			require.Nil(t, err, "no error in GetUserGroup: %v", err)
 			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
 			err = dbMock.mock.ExpectationsWereMet()
@@ -201,7 +202,7 @@ func TestListUserGroupsErrors(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given(dbMock)
 
-			result, err := ListUserGroups(context.Background(), dbMock, 0, 10)
+			result, err := ListUserGroups(context.Background(), dbMock, "namespaceId", 11, 0, nil)
 			require.NotNil(t, err, "no error in ListUserGroups: %v", err)
 			require.Nil(t, result, "Result was not nil: %v", result)
 			err = dbMock.mock.ExpectationsWereMet()
@@ -252,7 +253,7 @@ func TestListUserGroupsSuccesses(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given.initFunc(dbMock)
 
-			result, err := ListUserGroups(context.Background(), dbMock, 0, 10)
+			result, err := ListUserGroups(context.Background(), dbMock, "namespaceId", 11, 0, nil)
 			require.Nil(t, err, "no error in ListUserGroups: %v", err)
 			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
 			err = dbMock.mock.ExpectationsWereMet()
@@ -260,3 +261,73 @@ func TestListUserGroupsSuccesses(t *testing.T) {
 		})
 	}
 }
+
+func TestListDeletedUserGroupsSuccesses(t *testing.T) {
+	deletedAt := time.Now()
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectQuery("SELECT").
+		WithArgs("", "", 11, 0, "namespaceId").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "deleted_by", "deleted_at", "purged_at"}).
+			AddRow("userGroupId", "name", "deletedBy", deletedAt, nil)).
+		RowsWillBeClosed()
+
+	result, err := ListDeletedUserGroups(context.Background(), dbMock, "namespaceId", 11, 0, nil)
+	require.Nil(t, err, "error in ListDeletedUserGroups: %v", err)
+	expected := []*common.DeletedRecord{{Id: "userGroupId", Name: "name", DeletedBy: "deletedBy", DeletedAt: deletedAt}}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestRestoreUserGroupErrors(t *testing.T) {
+	userGroup1 := common.NewDummyUserGroup(t)
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("UPDATE").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+					AddRow(userGroup1.Id, userGroup1.Name).
+					RowError(0, fmt.Errorf("oh no not the row"))).
+				RowsWillBeClosed()
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("UPDATE").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name"})).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("RestoreUserGroup - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := RestoreUserGroup(context.Background(), dbMock, "callingUserId", "namespaceId", "userGroupId", "")
+			require.NotNil(t, err, "no error in RestoreUserGroup: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestRestoreUserGroupSuccesses(t *testing.T) {
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectQuery("UPDATE").
+		WithArgs("callingUserId", "userGroupId", "namespaceId", "").
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+			AddRow("userGroupId", "name")).
+		RowsWillBeClosed()
+
+	result, err := RestoreUserGroup(context.Background(), dbMock, "callingUserId", "namespaceId", "userGroupId", "")
+	require.Nil(t, err, "error in RestoreUserGroup: %v", err)
+	expected := &common.UserGroup{Id: "userGroupId", Name: "name"}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
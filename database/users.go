@@ -49,11 +49,13 @@ func SelectUsersForAuth(ctx context.Context, db Database) (map[string]*common.Us
 	return userMap, nil
 }
 
-func ListUsers(ctx context.Context, db Database, pageSize, offset int) ([]*common.User, error) {
+// ListUsers returns active users ordered by name, starting after the cursor if one is given
+func ListUsers(ctx context.Context, db Database, limit, offset int, after *common.PageCursor) ([]*common.User, error) {
 	operation := "ListUsers"
 	tracer := db.CreateTrace(ctx, operation)
 	defer tracer.Close()
 
+	afterId, afterName := cursorArgs(after)
 	query := `
 	SELECT	u.id,
 			u.name,
@@ -61,10 +63,12 @@ func ListUsers(ctx context.Context, db Database, pageSize, offset int) ([]*commo
 			u.type
 	FROM	admin.users u
 	WHERE	u.is_active
-	LIMIT	$1
-	OFFSET 	$2
+		AND ($1 = '' OR (u.name, u.id) > ($2, NULLIF($1, '')::uuid))
+	ORDER BY u.name, u.id
+	LIMIT	$3
+	OFFSET 	$4
 	`
-	rows, err := db.QueryContext(tracer.Context(), query, pageSize, offset)
+	rows, err := db.QueryContext(tracer.Context(), query, afterId, afterName, limit, offset)
 	if err != nil {
 		dbErr := common.NewDatabaseError(err, operation, "")
 		tracer.CaptureException(dbErr)
@@ -93,11 +97,23 @@ func ListUsers(ctx context.Context, db Database, pageSize, offset int) ([]*commo
 	return users, nil
 }
 
-func ListUsersInGroup(ctx context.Context, db Database, userGroupId string, pageSize, offset int) ([]*common.User, error) {
+// CountUsers returns the number of active users
+func CountUsers(ctx context.Context, db Database) (int, error) {
+	query := `
+	SELECT	COUNT(*)
+	FROM	admin.users u
+	WHERE	u.is_active
+	`
+	return count(ctx, db, "CountUsers", query)
+}
+
+// ListUsersInGroup returns the active members of a group ordered by name, starting after the cursor if one is given
+func ListUsersInGroup(ctx context.Context, db Database, userGroupId string, limit, offset int, after *common.PageCursor) ([]*common.User, error) {
 	operation := "ListUsersInGroup"
 	tracer := db.CreateTrace(ctx, operation)
 	defer tracer.Close()
 
+	afterId, afterName := cursorArgs(after)
 	query := `
 	SELECT	u.id,
 			u.name,
@@ -112,10 +128,12 @@ func ListUsersInGroup(ctx context.Context, db Database, userGroupId string, page
 		AND ug.is_active
 		AND ug.id = $1
 	WHERE	u.is_active
-	LIMIT 	$2
-	OFFSET 	$3
+		AND ($2 = '' OR (u.name, u.id) > ($3, NULLIF($2, '')::uuid))
+	ORDER BY u.name, u.id
+	LIMIT 	$4
+	OFFSET 	$5
 	`
-	rows, err := db.QueryContext(tracer.Context(), query, userGroupId, pageSize, offset)
+	rows, err := db.QueryContext(tracer.Context(), query, userGroupId, afterId, afterName, limit, offset)
 	if err != nil {
 		dbErr := common.NewDatabaseError(err, operation, "")
 		tracer.CaptureException(dbErr)
@@ -144,6 +162,23 @@ func ListUsersInGroup(ctx context.Context, db Database, userGroupId string, page
 	return users, nil
 }
 
+// CountUsersInGroup returns the number of active members of a group
+func CountUsersInGroup(ctx context.Context, db Database, userGroupId string) (int, error) {
+	query := `
+	SELECT	COUNT(*)
+	FROM	admin.users u
+	JOIN	admin.user_group_members ugm
+		ON 	u.id = ugm.user_id
+		AND ugm.is_active
+	JOIN	admin.user_groups ug
+		ON 	ugm.user_group_id = ug.id
+		AND ug.is_active
+		AND ug.id = $1
+	WHERE	u.is_active
+	`
+	return count(ctx, db, "CountUsersInGroup", query, userGroupId)
+}
+
 func GetUserById(ctx context.Context, db Database, userId string) (*common.User, error) {
 	operation := "GetUserById"
 	tracer := db.CreateTrace(ctx, operation)
@@ -289,3 +324,86 @@ func RotateUserSecret(ctx context.Context, db Database, userId, userSecretHash s
 
 	return nil
 }
+
+// ListDeletedUsers returns deleted users ordered by name, starting after the cursor if one is given
+func ListDeletedUsers(ctx context.Context, db Database, limit, offset int, after *common.PageCursor) ([]*common.DeletedRecord, error) {
+	afterId, afterName := cursorArgs(after)
+	query := `
+	SELECT	u.id,
+			u.name,
+			updated_by_user.name AS deleted_by,
+			u.updated_at AS deleted_at,
+			NULL::timestamptz AS purged_at
+	FROM	admin.users u
+	JOIN	admin.users updated_by_user
+		ON 	u.updated_by = updated_by_user.id
+	WHERE	NOT u.is_active
+		AND ($1 = '' OR (u.name, u.id) > ($2, NULLIF($1, '')::uuid))
+	ORDER BY u.name, u.id
+	LIMIT	$3
+	OFFSET 	$4
+	`
+	return listDeleted(ctx, db, "ListDeletedUsers", query, afterId, afterName, limit, offset)
+}
+
+// CountDeletedUsers returns the number of deleted users
+func CountDeletedUsers(ctx context.Context, db Database) (int, error) {
+	query := `
+	SELECT	COUNT(*)
+	FROM	admin.users u
+	WHERE	NOT u.is_active
+	`
+	return count(ctx, db, "CountDeletedUsers", query)
+}
+
+// RestoreUser reactivates a deleted user, renaming it if name is set, and replaces its client secret hash, so the secret
+// it had when it was deleted doesn't work again. Its group memberships and permissions were left in place when it was
+// deleted, so they apply again. The user is returned with its new secret hash, for the auth cache. Returns an already
+// exists error if an active user has its name.
+func RestoreUser(ctx context.Context, db Database, callingUserId, userId, name, userSecretHash string) (*common.User, error) {
+	operation := "RestoreUser"
+	tracer := db.CreateTrace(ctx, operation)
+	defer tracer.Close()
+
+	query := `
+	UPDATE	admin.users
+	SET		is_active = true,
+			name = COALESCE(NULLIF($3, ''), name),
+			client_secret_hash = $4,
+			updated_by = $1
+	WHERE	id = $2
+		AND NOT is_active
+	RETURNING id, name, is_active, type, client_secret_hash
+	`
+	rows, err := db.QueryContext(tracer.Context(), query, callingUserId, userId, name, userSecretHash)
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "")
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	defer rows.Close()
+
+	var user *common.User
+	for rows.Next() {
+		var row common.User
+		err = rows.Scan(&row.Id, &row.Name, &row.IsActive, &row.Type, &row.SecretHash)
+		if err != nil {
+			dbErr := common.NewDatabaseError(err, operation, "Error in scan operation: %v", err)
+			tracer.CaptureException(dbErr)
+			return nil, dbErr
+		}
+		user = &row
+	}
+	err = rows.Err()
+	if err != nil {
+		dbErr := common.NewDatabaseError(err, operation, "Error in rows.Err() operation: %v", err)
+		tracer.CaptureException(dbErr)
+		return nil, dbErr
+	}
+	if user == nil {
+		return nil, common.NewResourceNotFoundError(operation, "id", userId)
+	}
+
+	db.GetLogger().Debugf("%s restored 1 row", operation)
+	return user, nil
+}
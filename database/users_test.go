@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"testing"
+	"time"
 
 	sqlmock "github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/require"
@@ -339,7 +340,7 @@ func TestListUsersErrors(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given(dbMock)
 
-			result, err := ListUsers(context.Background(), dbMock, 0, 10)
+			result, err := ListUsers(context.Background(), dbMock, 11, 0, nil)
 			require.NotNil(t, err, "no error in ListUsers: %v", err)
 			require.Nil(t, result, "Result was not nil: %v", result)
 			err = dbMock.mock.ExpectationsWereMet()
@@ -392,7 +393,7 @@ func TestListUsersSuccesses(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given.initFunc(dbMock)
 
-			result, err := ListUsers(context.Background(), dbMock, 0, 10)
+			result, err := ListUsers(context.Background(), dbMock, 11, 0, nil)
 			require.Nil(t, err, "no error in ListUsers: %v", err)
 			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
 			err = dbMock.mock.ExpectationsWereMet()
@@ -422,7 +423,7 @@ func TestListUsersInGroupErrors(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given(dbMock)
 
-			result, err := ListUsersInGroup(context.Background(), dbMock, "userGroupId1", 0, 10)
+			result, err := ListUsersInGroup(context.Background(), dbMock, "userGroupId1", 11, 0, nil)
 			require.NotNil(t, err, "no error in ListUsersInGroup: %v", err)
 			require.Nil(t, result, "Result was not nil: %v", result)
 			err = dbMock.mock.ExpectationsWereMet()
@@ -475,7 +476,7 @@ func TestListUsersInGroupSuccesses(t *testing.T) {
 			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
 			given.initFunc(dbMock)
 
-			result, err := ListUsersInGroup(context.Background(), dbMock, "userGroupId1", 0, 10)
+			result, err := ListUsersInGroup(context.Background(), dbMock, "userGroupId1", 11, 0, nil)
 			require.Nil(t, err, "no error in ListUsersInGroup: %v", err)
 			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
 			err = dbMock.mock.ExpectationsWereMet()
@@ -483,3 +484,132 @@ func TestListUsersInGroupSuccesses(t *testing.T) {
 		})
 	}
 }
+
+func TestListDeletedUsersErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("SELECT").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "deleted_by", "deleted_at", "purged_at"}).
+					AddRow("userId", "name", "deletedBy", time.Now(), nil).
+					RowError(0, fmt.Errorf("oh no not the row"))).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ListDeletedUsers - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := ListDeletedUsers(context.Background(), dbMock, 11, 0, nil)
+			require.NotNil(t, err, "no error in ListDeletedUsers: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestListDeletedUsersSuccesses(t *testing.T) {
+	deletedAt := time.Now()
+	var inits = []struct {
+		initFunc initFunc
+		expected []*common.DeletedRecord
+	}{
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "deleted_by", "deleted_at", "purged_at"})).
+					RowsWillBeClosed()
+			},
+			expected: []*common.DeletedRecord{},
+		},
+		{
+			initFunc: func(dbMock *MockDatabase) {
+				dbMock.mock.ExpectQuery("SELECT").
+					WithArgs("afterId", "afterName", 11, 0).
+					WillReturnRows(sqlmock.NewRows([]string{"id", "name", "deleted_by", "deleted_at", "purged_at"}).
+						AddRow("userId", "name", "deletedBy", deletedAt, nil)).
+					RowsWillBeClosed()
+			},
+			expected: []*common.DeletedRecord{{Id: "userId", Name: "name", DeletedBy: "deletedBy", DeletedAt: deletedAt}},
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("ListDeletedUsers - Successes - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given.initFunc(dbMock)
+
+			result, err := ListDeletedUsers(context.Background(), dbMock, 11, 0, &common.PageCursor{Id: "afterId", Value: "afterName"})
+			require.Nil(t, err, "error in ListDeletedUsers: %v", err)
+			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestRestoreUserErrors(t *testing.T) {
+	user1 := common.NewDummyUser(t)
+	var inits = []initFunc{
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("UPDATE").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name"}).
+					AddRow(user1.Id, user1.Name)).
+				RowsWillBeClosed()
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("UPDATE").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "is_active", "type", "client_secret_hash"}).
+					AddRow(user1.Id, user1.Name, true, user1.Type, user1.SecretHash).
+					RowError(0, fmt.Errorf("oh no not the row"))).
+				RowsWillBeClosed()
+		},
+		func(dbMock *MockDatabase) {
+			dbMock.mock.ExpectQuery("UPDATE").
+				WillReturnRows(sqlmock.NewRows([]string{"id", "name", "is_active", "type", "client_secret_hash"})).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("RestoreUser - Errors - %v", idx), func(t *testing.T) {
+			dbMock, err := NewMockDatabase()
+			require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+			given(dbMock)
+
+			result, err := RestoreUser(context.Background(), dbMock, "callingUserId", "userId", "", "userSecretHash")
+			require.NotNil(t, err, "no error in RestoreUser: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+			err = dbMock.mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestRestoreUserSuccesses(t *testing.T) {
+	user1 := common.NewDummyUser(t)
+	user1.IsActive = true
+	dbMock, err := NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	dbMock.mock.ExpectQuery("UPDATE").
+		WithArgs("callingUserId", user1.Id, user1.Name, user1.SecretHash).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "name", "is_active", "type", "client_secret_hash"}).
+			AddRow(user1.Id, user1.Name, user1.IsActive, user1.Type, user1.SecretHash)).
+		RowsWillBeClosed()
+
+	result, err := RestoreUser(context.Background(), dbMock, "callingUserId", user1.Id, user1.Name, user1.SecretHash)
+	require.Nil(t, err, "error in RestoreUser: %v", err)
+	require.Equal(t, result, user1, "Result %+v did not equal expected %+v", result, user1)
+	err = dbMock.mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
@@ -104,9 +104,7 @@ func (u *AccessTokenCache) Refresh(ctx context.Context, db *database.DatabaseEng
 }
 
 func NewAccessTokenCache(ctx context.Context, logger *logrus.Logger, db *database.DatabaseEngine, dataRefreshSeconds int) (*AccessTokenCache, error) {
-	var m sync.Mutex
 	accessTokenCache := &AccessTokenCache{
-		m:            m,
 		logger:       logger,
 		accessTokens: make(map[string]*common.AccessToken),
 		updates:      make(chan AccessTokenCacheUpdate, 10),
@@ -124,9 +122,7 @@ func NewAccessTokenCache(ctx context.Context, logger *logrus.Logger, db *databas
 }
 
 func NewMockAccessTokenCache(logger *logrus.Logger, accessTokens map[string]*common.AccessToken) *AccessTokenCache {
-	var m sync.Mutex
 	return &AccessTokenCache{
-		m:            m,
 		logger:       logger,
 		accessTokens: accessTokens,
 		updates:      make(chan AccessTokenCacheUpdate, 10),
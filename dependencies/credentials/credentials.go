@@ -0,0 +1,37 @@
+package credentials
+
+import (
+	"context"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/dependencies/secrets"
+)
+
+// Engine runs the statements that create, renew and revoke dynamic credentials on a database
+type Engine interface {
+	// Execute runs statements against the database at connectionUrl, in a single transaction
+	Execute(ctx context.Context, connectionUrl string, statements []string) error
+}
+
+// EncryptConnectionUrl encrypts a new role's connection URL under a new data key, stored under the role's id
+func EncryptConnectionUrl(ctx context.Context, secretsManager secrets.SecretsManager, role *common.DatabaseRole) error {
+	ciphertext, encryptedSecret, err := common.EncryptSecret(role.Id, role.ConnectionUrl, common.KEY_SIZE)
+	if err != nil {
+		return err
+	}
+	err = secretsManager.CreateSecret(ctx, encryptedSecret)
+	if err != nil {
+		return err
+	}
+	role.ConnectionUrl = ciphertext
+	return nil
+}
+
+// DecryptConnectionUrl returns the plaintext connection URL of a role fetched from the database
+func DecryptConnectionUrl(ctx context.Context, secretsManager secrets.SecretsManager, role *common.DatabaseRole) (string, error) {
+	encryptedSecret, err := secretsManager.GetSecret(ctx, role.Id)
+	if err != nil {
+		return "", err
+	}
+	return common.DecryptSecret(role.ConnectionUrl, encryptedSecret)
+}
@@ -0,0 +1,45 @@
+package credentials
+
+import (
+	"context"
+	"database/sql"
+
+	_ "github.com/lib/pq"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// PostgresEngine creates credentials on Postgres. It opens a new connection for every call, since roles can point at
+// any number of databases and are rarely used more than a few times a minute.
+type PostgresEngine struct{}
+
+func NewPostgresEngine() *PostgresEngine {
+	return &PostgresEngine{}
+}
+
+func (e *PostgresEngine) Execute(ctx context.Context, connectionUrl string, statements []string) error {
+	operation := "ExecuteStatements"
+	db, err := sql.Open("postgres", connectionUrl)
+	if err != nil {
+		// the error can include the connection URL, so it isn't returned
+		return common.NewInvalidParamsError(operation, "Invalid connection_url")
+	}
+	defer db.Close()
+
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return common.NewDatabaseError(err, operation, "Error connecting to database: %v", err)
+	}
+	for idx, statement := range statements {
+		_, err = tx.ExecContext(ctx, statement)
+		if err != nil {
+			tx.Rollback()
+			return common.NewDatabaseError(err, operation, "Error in statement %d: %v", idx+1, err)
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return common.NewDatabaseError(err, operation, "Error committing statements: %v", err)
+	}
+	return nil
+}
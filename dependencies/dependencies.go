@@ -3,6 +3,7 @@ package dependencies
 import (
 	"context"
 	"io/ioutil"
+	"net"
 
 	"github.com/sirupsen/logrus"
 	"gopkg.in/yaml.v2"
@@ -11,12 +12,40 @@ import (
 	"github.com/emarcey/data-vault/common/logger"
 	"github.com/emarcey/data-vault/common/tracer"
 	"github.com/emarcey/data-vault/database"
+	"github.com/emarcey/data-vault/dependencies/credentials"
 	"github.com/emarcey/data-vault/dependencies/secrets"
 )
 
 type ServerConfigs struct {
-	AccessTokenHours   int `yaml:"accessTokenHours"`
-	DataRefreshSeconds int `yaml:"dataRefreshSeconds"`
+	AccessTokenHours    int   `yaml:"accessTokenHours"`
+	DataRefreshSeconds  int   `yaml:"dataRefreshSeconds"`
+	SecretReaperSeconds int   `yaml:"secretReaperSeconds"`
+	LeaseRevokerSeconds int   `yaml:"leaseRevokerSeconds"`
+	MaxSecretFileBytes  int64 `yaml:"maxSecretFileBytes"`
+	// SecretRekeyDays is the age, in days, after which a secret version's data key is replaced. 0 disables the
+	// scheduled rekey.
+	SecretRekeyDays    int `yaml:"secretRekeyDays"`
+	SecretRekeySeconds int `yaml:"secretRekeySeconds"`
+	// SecretPurgeDays is how long, in days, deleted secrets are kept before they're purged. 0 disables the scheduled
+	// purge.
+	SecretPurgeDays    int `yaml:"secretPurgeDays"`
+	SecretPurgeSeconds int `yaml:"secretPurgeSeconds"`
+	// SecretPolicies are secret generation policies, added to the built-in ones
+	SecretPolicies map[string]*common.SecretPolicy `yaml:"secretPolicies"`
+	// TrustedProxies are the IPs or CIDR ranges of proxies whose X-Forwarded-For and X-Real-Ip headers are believed.
+	// Requests from any other peer are recorded with the connection's IP.
+	TrustedProxies []string `yaml:"trustedProxies"`
+	// FailedAuthLogsPerMinute caps how many failed authentications from each client IP are written to the access log
+	// per minute
+	FailedAuthLogsPerMinute int `yaml:"failedAuthLogsPerMinute"`
+}
+
+// SecretFileLimit returns the maximum size of a file secret, in bytes
+func (c *ServerConfigs) SecretFileLimit() int64 {
+	if c == nil || c.MaxSecretFileBytes <= 0 {
+		return common.DEFAULT_MAX_SECRET_FILE_BYTES
+	}
+	return c.MaxSecretFileBytes
 }
 
 type DependenciesInitOpts struct {
@@ -31,14 +60,23 @@ type DependenciesInitOpts struct {
 }
 
 type Dependencies struct {
-	Env            string
-	Logger         *logrus.Logger
-	Tracer         tracer.TracerCreator
-	SecretsManager secrets.SecretsManager
-	Database       *database.DatabaseEngine
-	AuthUsers      *UserCache
-	AccessTokens   *AccessTokenCache
-	ServerConfigs  *ServerConfigs
+	Env               string
+	Logger            *logrus.Logger
+	Tracer            tracer.TracerCreator
+	SecretsManager    secrets.SecretsManager
+	AccessLogChainKey []byte
+	Database          *database.DatabaseEngine
+	AuthUsers         *UserCache
+	AccessTokens      *AccessTokenCache
+	SecretReaper      *SecretReaper
+	Credentials       credentials.Engine
+	LeaseRevoker      *LeaseRevoker
+	SecretRekeyer     *SecretRekeyer
+	SecretPurger      *SecretPurger
+	SecretPolicies    map[string]*common.SecretPolicy
+	TrustedProxies    []*net.IPNet
+	FailedAuthLogs    *FailedAuthLimiter
+	ServerConfigs     *ServerConfigs
 }
 
 func ReadOpts(filename string) (DependenciesInitOpts, error) {
@@ -54,7 +92,9 @@ func ReadOpts(filename string) (DependenciesInitOpts, error) {
 	return opts, nil
 }
 
-func MakeDependencies(ctx context.Context, opts DependenciesInitOpts) (*Dependencies, error) {
+// MakeStoreDependencies connects to the datastores without starting the caches and background jobs the server needs.
+// It's used by offline tools such as backup and restore.
+func MakeStoreDependencies(ctx context.Context, opts DependenciesInitOpts) (*Dependencies, error) {
 	logger, err := logger.MakeLogger(opts.LoggerType, opts.Env)
 	if err != nil {
 		return nil, err
@@ -64,7 +104,11 @@ func MakeDependencies(ctx context.Context, opts DependenciesInitOpts) (*Dependen
 		return nil, err
 	}
 
-	secretsManager, err := secrets.NewSecretsManager(ctx, opts.SecretsManagerOpts)
+	chainKey, err := secrets.NewChainKey(opts.SecretsManagerOpts.ChainKeyOpts)
+	if err != nil {
+		return nil, err
+	}
+	secretsManager, err := secrets.NewSecretsManager(ctx, opts.SecretsManagerOpts, chainKey)
 	if err != nil {
 		return nil, err
 	}
@@ -72,25 +116,70 @@ func MakeDependencies(ctx context.Context, opts DependenciesInitOpts) (*Dependen
 	if err != nil {
 		return nil, err
 	}
+	return &Dependencies{
+		Env:               opts.Env,
+		Logger:            logger,
+		Tracer:            tracer,
+		SecretsManager:    secretsManager,
+		AccessLogChainKey: chainKey,
+		Database:          db,
+		ServerConfigs:     opts.ServerConfigs,
+	}, nil
+}
 
-	authUsers, err := NewUserCache(ctx, logger, db, opts.ServerConfigs.DataRefreshSeconds)
+func MakeDependencies(ctx context.Context, opts DependenciesInitOpts) (*Dependencies, error) {
+	deps, err := MakeStoreDependencies(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	secretPolicies, err := LoadSecretPolicies(opts.ServerConfigs.SecretPolicies)
+	if err != nil {
+		return nil, err
+	}
+	trustedProxies, err := LoadTrustedProxies(opts.ServerConfigs.TrustedProxies)
+	if err != nil {
+		return nil, err
+	}
+	authUsers, err := NewUserCache(ctx, deps.Logger, deps.Database, opts.ServerConfigs.DataRefreshSeconds)
 	if err != nil {
 		return nil, err
 	}
 
-	accessTokens, err := NewAccessTokenCache(ctx, logger, db, opts.ServerConfigs.DataRefreshSeconds)
+	accessTokens, err := NewAccessTokenCache(ctx, deps.Logger, deps.Database, opts.ServerConfigs.DataRefreshSeconds)
 	if err != nil {
 		return nil, err
 	}
-	deps := &Dependencies{
-		Env:            opts.Env,
-		Logger:         logger,
-		Tracer:         tracer,
-		SecretsManager: secretsManager,
-		Database:       db,
-		AuthUsers:      authUsers,
-		AccessTokens:   accessTokens,
-		ServerConfigs:  opts.ServerConfigs,
+	secretReaperSeconds := opts.ServerConfigs.SecretReaperSeconds
+	if secretReaperSeconds <= 0 {
+		secretReaperSeconds = opts.ServerConfigs.DataRefreshSeconds
+	}
+	secretReaper := NewSecretReaper(ctx, deps.Logger, deps.Database, deps.SecretsManager, secretReaperSeconds)
+	leaseRevokerSeconds := opts.ServerConfigs.LeaseRevokerSeconds
+	if leaseRevokerSeconds <= 0 {
+		leaseRevokerSeconds = opts.ServerConfigs.DataRefreshSeconds
+	}
+	credentialsEngine := credentials.NewPostgresEngine()
+	leaseRevoker := NewLeaseRevoker(ctx, deps.Logger, deps.Database, deps.SecretsManager, credentialsEngine, leaseRevokerSeconds)
+	secretRekeySeconds := opts.ServerConfigs.SecretRekeySeconds
+	if secretRekeySeconds <= 0 {
+		secretRekeySeconds = opts.ServerConfigs.DataRefreshSeconds
 	}
+	secretRekeyer := NewSecretRekeyer(ctx, deps.Logger, deps.Database, deps.SecretsManager, opts.ServerConfigs.SecretRekeyDays, secretRekeySeconds)
+	secretPurgeSeconds := opts.ServerConfigs.SecretPurgeSeconds
+	if secretPurgeSeconds <= 0 {
+		secretPurgeSeconds = opts.ServerConfigs.DataRefreshSeconds
+	}
+	secretPurger := NewSecretPurger(ctx, deps.Logger, deps.Database, deps.SecretsManager, opts.ServerConfigs.SecretPurgeDays, secretPurgeSeconds)
+
+	deps.AuthUsers = authUsers
+	deps.AccessTokens = accessTokens
+	deps.SecretReaper = secretReaper
+	deps.Credentials = credentialsEngine
+	deps.LeaseRevoker = leaseRevoker
+	deps.SecretRekeyer = secretRekeyer
+	deps.SecretPurger = secretPurger
+	deps.SecretPolicies = secretPolicies
+	deps.TrustedProxies = trustedProxies
+	deps.FailedAuthLogs = NewFailedAuthLimiter(deps.Logger, opts.ServerConfigs.FailedAuthLogsPerMinute)
 	return deps, nil
 }
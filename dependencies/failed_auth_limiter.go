@@ -0,0 +1,66 @@
+package dependencies
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// FailedAuthLimiter caps how many failed authentications from each client IP are written to the access log per minute.
+// Every access log takes the hash chain's lock, so without a cap a flood of bad credentials would hold up the logs of
+// authenticated requests. Failures over the cap are counted, and reported in the server log with the first failure after the minute ends.
+type FailedAuthLimiter struct {
+	logger *logrus.Logger
+	limit  int
+	now    func() time.Time
+
+	mu          sync.Mutex
+	windowStart time.Time
+	counts      map[string]int
+}
+
+// Allow reports whether a failed authentication from sourceIp should be written to the access log. A nil limiter
+// allows every log.
+func (l *FailedAuthLimiter) Allow(sourceIp string) bool {
+	if l == nil {
+		return true
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := l.now()
+	if now.Sub(l.windowStart) >= time.Minute {
+		l.reportSuppressed()
+		l.windowStart = now
+		l.counts = make(map[string]int)
+	}
+	l.counts[sourceIp]++
+	return l.counts[sourceIp] <= l.limit
+}
+
+// reportSuppressed logs the number of failed authentications from each client IP that weren't written to the access
+// log in the current minute
+func (l *FailedAuthLimiter) reportSuppressed() {
+	for sourceIp, count := range l.counts {
+		if count > l.limit {
+			l.logger.Warnf("%d failed authentications from %s were not written to the access log", count-l.limit, sourceIp)
+		}
+	}
+}
+
+// NewFailedAuthLimiter creates a FailedAuthLimiter that allows perMinute logs from each client IP, or
+// DEFAULT_FAILED_AUTH_LOGS_PER_MINUTE if perMinute isn't positive
+func NewFailedAuthLimiter(logger *logrus.Logger, perMinute int) *FailedAuthLimiter {
+	if perMinute <= 0 {
+		perMinute = common.DEFAULT_FAILED_AUTH_LOGS_PER_MINUTE
+	}
+	return &FailedAuthLimiter{
+		logger: logger,
+		limit:  perMinute,
+		now:    time.Now,
+		counts: make(map[string]int),
+	}
+}
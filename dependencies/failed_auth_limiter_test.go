@@ -0,0 +1,54 @@
+package dependencies
+
+import (
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+func TestFailedAuthLimiterAllow(t *testing.T) {
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	now := time.Now()
+	limiter := NewFailedAuthLimiter(logger, 2)
+	limiter.now = func() time.Time { return now }
+
+	var tests = []struct {
+		testName string
+		sourceIp string
+		advance  time.Duration
+		expected bool
+	}{
+		{testName: "first", sourceIp: "10.0.0.1", expected: true},
+		{testName: "at the cap", sourceIp: "10.0.0.1", expected: true},
+		{testName: "over the cap", sourceIp: "10.0.0.1", expected: false},
+		{testName: "other source", sourceIp: "10.0.0.2", expected: true},
+		{testName: "same minute", sourceIp: "10.0.0.1", advance: 59 * time.Second, expected: false},
+		{testName: "next minute", sourceIp: "10.0.0.1", advance: time.Second, expected: true},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("FailedAuthLimiter.Allow - %v", given.testName), func(t *testing.T) {
+			now = now.Add(given.advance)
+			result := limiter.Allow(given.sourceIp)
+			require.Equal(t, result, given.expected, "Result %v did not equal expected %v", result, given.expected)
+		})
+	}
+}
+
+func TestFailedAuthLimiterDefaults(t *testing.T) {
+	var nilLimiter *FailedAuthLimiter
+	require.True(t, nilLimiter.Allow("10.0.0.1"), "Expected a nil limiter to allow every log")
+
+	limiter := NewFailedAuthLimiter(logrus.New(), 0)
+	for idx := 0; idx < common.DEFAULT_FAILED_AUTH_LOGS_PER_MINUTE; idx++ {
+		require.True(t, limiter.Allow("10.0.0.1"), "Expected log %v to be allowed", idx)
+	}
+	require.False(t, limiter.Allow("10.0.0.1"), "Expected logs over the default cap to be skipped")
+}
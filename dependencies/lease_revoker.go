@@ -0,0 +1,93 @@
+package dependencies
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/database"
+	"github.com/emarcey/data-vault/dependencies/credentials"
+	"github.com/emarcey/data-vault/dependencies/secrets"
+)
+
+// revokeBatchSize caps the number of expired leases revoked in one tick
+const revokeBatchSize = 100
+
+// LeaseRevoker periodically drops the credentials of expired leases
+type LeaseRevoker struct {
+	logger         *logrus.Logger
+	db             database.Database
+	secretsManager secrets.SecretsManager
+	engine         credentials.Engine
+}
+
+// Revoke runs a lease's revocation statements and marks it revoked. If the statements fail, the lease stays active
+// so the revoker retries it.
+func (r *LeaseRevoker) Revoke(ctx context.Context, lease *common.Lease) error {
+	role, err := database.GetDatabaseRoleById(ctx, r.db, lease.RoleId)
+	if err != nil {
+		return err
+	}
+	connectionUrl, err := credentials.DecryptConnectionUrl(ctx, r.secretsManager, role)
+	if err != nil {
+		return err
+	}
+	err = r.engine.Execute(ctx, connectionUrl, common.RenderStatements(role.RevocationStatements, lease.Username, "", lease.ExpiresAt))
+	if err != nil {
+		return err
+	}
+	return database.RevokeLease(ctx, r.db, lease.Id)
+}
+
+func (r *LeaseRevoker) handleRevoke(ctx context.Context) error {
+	expiredLeases, err := database.ListExpiredLeases(ctx, r.db, revokeBatchSize)
+	if err != nil {
+		return err
+	}
+	for _, lease := range expiredLeases {
+		err = r.Revoke(ctx, lease)
+		if err != nil {
+			// failures are retried every tick, so only successful revocations are audited
+			r.logger.Errorf("Error revoking lease %s: %v", lease.Id, err)
+			continue
+		}
+		// there is no calling user, so expiry is logged against the lease's owner
+		err = r.secretsManager.LogAccess(ctx, common.NewAuditLog(ctx, lease.UserId, "LeaseExpired", common.TARGET_TYPE_LEASE, lease.Id, nil))
+		if err != nil {
+			r.logger.Errorf("Error logging expiry of lease %s: %v", lease.Id, err)
+		}
+	}
+	return nil
+}
+
+func (r *LeaseRevoker) RevokeExpired(ctx context.Context, revokeSeconds int) {
+	timer := time.NewTicker(time.Duration(revokeSeconds) * time.Second)
+	for true {
+		select {
+		case <-ctx.Done():
+			r.logger.Debug("Context canceled. Closing LeaseRevoker")
+			timer.Stop()
+			return
+		case <-timer.C:
+			err := r.handleRevoke(ctx)
+			if err != nil {
+				r.logger.Errorf("Error in ListExpiredLeases revoke: %v", err)
+			}
+		}
+	}
+}
+
+func NewLeaseRevoker(ctx context.Context, logger *logrus.Logger, db database.Database, secretsManager secrets.SecretsManager, engine credentials.Engine, revokeSeconds int) *LeaseRevoker {
+	leaseRevoker := &LeaseRevoker{
+		logger:         logger,
+		db:             db,
+		secretsManager: secretsManager,
+		engine:         engine,
+	}
+
+	go leaseRevoker.RevokeExpired(ctx, revokeSeconds)
+
+	return leaseRevoker
+}
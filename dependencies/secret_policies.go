@@ -0,0 +1,38 @@
+package dependencies
+
+import (
+	"io/ioutil"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// LoadSecretPolicies returns the built-in secret generation policies, overridden by and merged with the configured
+// ones. Every policy is validated, and word list files are read, so a bad policy stops the server from starting.
+func LoadSecretPolicies(configured map[string]*common.SecretPolicy) (map[string]*common.SecretPolicy, error) {
+	op := "secret-policies"
+	policies := common.DefaultSecretPolicies()
+	for name, policy := range configured {
+		if policy == nil {
+			return nil, common.NewInitializationError(op, "Policy %s is empty", name)
+		}
+		policies[name] = policy
+	}
+	for name, policy := range policies {
+		policy.Name = name
+		if policy.WordListFile != "" {
+			data, err := ioutil.ReadFile(policy.WordListFile)
+			if err != nil {
+				return nil, common.NewInitializationError(op, "Unable to read word list for policy %s: %v", name, err)
+			}
+			err = policy.SetWordList(op, string(data))
+			if err != nil {
+				return nil, common.NewInitializationError(op, "%v", err)
+			}
+		}
+		err := common.ValidateSecretPolicy(op, policy)
+		if err != nil {
+			return nil, common.NewInitializationError(op, "%v", err)
+		}
+	}
+	return policies, nil
+}
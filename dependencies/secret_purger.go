@@ -0,0 +1,142 @@
+package dependencies
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/database"
+	"github.com/emarcey/data-vault/dependencies/secrets"
+)
+
+// purgeBatchSize caps the number of secrets loaded at once by a purge
+const purgeBatchSize = 100
+
+// SecretPurger crypto-shreds deleted secrets: it deletes the data key of every version, so the values can never be
+// decrypted, then clears the ciphertext and leaves the secret as a tombstone. It runs on demand, and periodically for
+// every secret deleted more than retentionDays ago.
+type SecretPurger struct {
+	logger         *logrus.Logger
+	db             *database.DatabaseEngine
+	secretsManager secrets.SecretsManager
+	retentionDays  int
+
+	// mu serializes purges, so the scheduled run and an on-demand purge don't race on the same secret
+	mu sync.Mutex
+}
+
+// purgeSecret marks a deleted secret purged, so it can't be restored or rekeyed, deletes its data keys, then clears its
+// versions. The version ids are read after the secret is marked, so a version rekeyed in the meantime has its new key
+// deleted. purgedBy is recorded on the SecretPurged access log.
+func (p *SecretPurger) purgeSecret(ctx context.Context, purgedBy string, secret *common.PurgeableSecret) error {
+	err := database.MarkSecretPurged(ctx, p.db, secret.Id)
+	if err != nil {
+		return err
+	}
+	versionIds, err := database.ListPurgedSecretVersionIds(ctx, p.db, secret.Id)
+	if err != nil {
+		return err
+	}
+	for _, versionId := range versionIds {
+		err = p.secretsManager.DeleteSecret(ctx, versionId)
+		// a key that is already gone was deleted by an interrupted purge
+		if _, ok := err.(common.ResourceNotFoundError); err != nil && !ok {
+			return err
+		}
+	}
+	err = database.ClearPurgedSecretVersions(ctx, p.db, secret.Id)
+	if err != nil {
+		return err
+	}
+	err = p.secretsManager.LogAccess(ctx, common.NewAuditLog(ctx, purgedBy, "SecretPurged", common.TARGET_TYPE_SECRET, common.QualifiedName(secret.Namespace, secret.Name), nil))
+	if err != nil {
+		p.logger.Errorf("Error logging purge of secret %s: %v", secret.Name, err)
+	}
+	return nil
+}
+
+// PurgeSecret purges a single deleted secret now, however recently it was deleted. Returns a not found error if the
+// secret is active or was already purged.
+func (p *SecretPurger) PurgeSecret(ctx context.Context, userId, secretId string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	deletedSecrets, err := database.ListSecretsToPurge(ctx, p.db, secretId, time.Now(), 0)
+	if err != nil {
+		return err
+	}
+	if len(deletedSecrets) == 0 {
+		return common.NewResourceNotFoundError("PurgeSecret", "id", secretId)
+	}
+	return p.purgeSecret(ctx, userId, deletedSecrets[0])
+}
+
+// PurgeDeleted purges every secret deleted more than retentionDays ago, returning the number purged. Secrets that fail
+// are logged and skipped.
+func (p *SecretPurger) PurgeDeleted(ctx context.Context, userId string) (int, error) {
+	if p.retentionDays <= 0 {
+		return 0, common.NewInvalidParamsError("PurgeDeleted", "Scheduled purging is disabled. Set serverConfigs.secretPurgeDays, or purge a single secret by id")
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	deletedBefore := time.Now().AddDate(0, 0, -p.retentionDays)
+	purged, failed := 0, 0
+	for {
+		deletedSecrets, err := database.ListSecretsToPurge(ctx, p.db, "", deletedBefore, purgeBatchSize+failed)
+		if err != nil {
+			return purged, err
+		}
+		// failed secrets are still listed, ahead of the ones not yet tried
+		if len(deletedSecrets) <= failed {
+			break
+		}
+		for _, secret := range deletedSecrets[failed:] {
+			err = p.purgeSecret(ctx, userId, secret)
+			if err != nil {
+				p.logger.Errorf("Error purging secret %s: %v", secret.Name, err)
+				failed++
+				continue
+			}
+			purged++
+		}
+		p.logger.Infof("SecretPurger progress: %d secrets purged, %d failed", purged, failed)
+	}
+	return purged, nil
+}
+
+func (p *SecretPurger) Purge(ctx context.Context, purgeSeconds int) {
+	timer := time.NewTicker(time.Duration(purgeSeconds) * time.Second)
+	for true {
+		select {
+		case <-ctx.Done():
+			p.logger.Debug("Context canceled. Closing SecretPurger")
+			timer.Stop()
+			return
+		case <-timer.C:
+			_, err := p.PurgeDeleted(ctx, common.SYSTEM_USER_ID)
+			if err != nil {
+				p.logger.Errorf("Error in ListSecretsToPurge purge: %v", err)
+			}
+		}
+	}
+}
+
+// NewSecretPurger creates a SecretPurger. The scheduled purge only runs if retentionDays is positive.
+func NewSecretPurger(ctx context.Context, logger *logrus.Logger, db *database.DatabaseEngine, secretsManager secrets.SecretsManager, retentionDays, purgeSeconds int) *SecretPurger {
+	secretPurger := &SecretPurger{
+		logger:         logger,
+		db:             db,
+		secretsManager: secretsManager,
+		retentionDays:  retentionDays,
+	}
+
+	if retentionDays > 0 {
+		go secretPurger.Purge(ctx, purgeSeconds)
+	}
+
+	return secretPurger
+}
@@ -0,0 +1,60 @@
+package dependencies
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/database"
+	"github.com/emarcey/data-vault/dependencies/secrets"
+)
+
+// SecretReaper periodically deactivates expired secrets
+type SecretReaper struct {
+	logger         *logrus.Logger
+	secretsManager secrets.SecretsManager
+}
+
+func (r *SecretReaper) handleReap(ctx context.Context, db database.Database) error {
+	expiredSecrets, err := database.ExpireSecrets(ctx, db)
+	if err != nil {
+		return err
+	}
+	for _, secret := range expiredSecrets {
+		err = r.secretsManager.LogAccess(ctx, common.NewAuditLog(ctx, common.SYSTEM_USER_ID, "SecretExpired", common.TARGET_TYPE_SECRET, common.QualifiedName(secret.Namespace, secret.Name), nil))
+		if err != nil {
+			r.logger.Errorf("Error logging expiry of secret %s: %v", secret.Name, err)
+		}
+	}
+	return nil
+}
+
+func (r *SecretReaper) Reap(ctx context.Context, db database.Database, reapSeconds int) {
+	timer := time.NewTicker(time.Duration(reapSeconds) * time.Second)
+	for true {
+		select {
+		case <-ctx.Done():
+			r.logger.Debug("Context canceled. Closing SecretReaper")
+			timer.Stop()
+			return
+		case <-timer.C:
+			err := r.handleReap(ctx, db)
+			if err != nil {
+				r.logger.Errorf("Error in ExpireSecrets reap: %v", err)
+			}
+		}
+	}
+}
+
+func NewSecretReaper(ctx context.Context, logger *logrus.Logger, db database.Database, secretsManager secrets.SecretsManager, reapSeconds int) *SecretReaper {
+	secretReaper := &SecretReaper{
+		logger:         logger,
+		secretsManager: secretsManager,
+	}
+
+	go secretReaper.Reap(ctx, db, reapSeconds)
+
+	return secretReaper
+}
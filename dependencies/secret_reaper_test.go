@@ -0,0 +1,86 @@
+package dependencies
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/database"
+)
+
+var expiredSecretColumns = []string{"id", "name", "namespace", "expires_at"}
+
+func newTestSecretReaper(t *testing.T) (*SecretReaper, *memorySecretsManager, *database.MockDatabase) {
+	dbMock, err := database.NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	secretsManager := newMemorySecretsManager()
+	return &SecretReaper{
+		logger:         logger,
+		secretsManager: secretsManager,
+	}, secretsManager, dbMock
+}
+
+func TestSecretReaperHandleReapErrors(t *testing.T) {
+	reaper, secretsManager, dbMock := newTestSecretReaper(t)
+	dbMock.Mock().ExpectQuery("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+
+	err := reaper.handleReap(context.Background(), dbMock)
+	require.NotNil(t, err, "no error in handleReap: %v", err)
+	require.Equal(t, len(secretsManager.logs), 0, "Logs %v did not equal expected 0", len(secretsManager.logs))
+	err = dbMock.Mock().ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+func TestSecretReaperHandleReapSuccesses(t *testing.T) {
+	expiresAt := time.Now()
+	var tests = []struct {
+		testName     string
+		rows         *sqlmock.Rows
+		expectedLogs []*common.AccessLog
+	}{
+		{
+			testName:     "nothing expired",
+			rows:         sqlmock.NewRows(expiredSecretColumns),
+			expectedLogs: []*common.AccessLog{},
+		},
+		{
+			testName: "expired secrets",
+			rows: sqlmock.NewRows(expiredSecretColumns).
+				AddRow("id1", "name1", common.DEFAULT_NAMESPACE, expiresAt).
+				AddRow("id2", "name2", "team-a", expiresAt),
+			expectedLogs: []*common.AccessLog{
+				{UserId: common.SYSTEM_USER_ID, ActionType: "SecretExpired", TargetType: common.TARGET_TYPE_SECRET, TargetId: common.QualifiedName(common.DEFAULT_NAMESPACE, "name1")},
+				{UserId: common.SYSTEM_USER_ID, ActionType: "SecretExpired", TargetType: common.TARGET_TYPE_SECRET, TargetId: common.QualifiedName("team-a", "name2")},
+			},
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("SecretReaper.handleReap - Successes - %v", given.testName), func(t *testing.T) {
+			reaper, secretsManager, dbMock := newTestSecretReaper(t)
+			dbMock.Mock().ExpectQuery(`UPDATE (.+) expired_at = NOW\(\)`).WillReturnRows(given.rows).RowsWillBeClosed()
+
+			err := reaper.handleReap(context.Background(), dbMock)
+			require.Nil(t, err, "error in handleReap: %v", err)
+			err = dbMock.Mock().ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+			require.Equal(t, len(secretsManager.logs), len(given.expectedLogs), "Logs %v did not equal expected %v", len(secretsManager.logs), len(given.expectedLogs))
+			for idx, expected := range given.expectedLogs {
+				log := secretsManager.logs[idx]
+				require.Equal(t, log.UserId, expected.UserId, "UserId %v did not equal expected %v", log.UserId, expected.UserId)
+				require.Equal(t, log.ActionType, expected.ActionType, "ActionType %v did not equal expected %v", log.ActionType, expected.ActionType)
+				require.Equal(t, log.TargetType, expected.TargetType, "TargetType %v did not equal expected %v", log.TargetType, expected.TargetType)
+				require.Equal(t, log.TargetId, expected.TargetId, "TargetId %v did not equal expected %v", log.TargetId, expected.TargetId)
+			}
+		})
+	}
+}
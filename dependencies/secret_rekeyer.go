@@ -0,0 +1,185 @@
+package dependencies
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/database"
+	"github.com/emarcey/data-vault/dependencies/secrets"
+)
+
+// rekeyBatchSize caps the number of secret versions loaded at once by a rekey
+const rekeyBatchSize = 100
+
+// SecretRekeyer re-encrypts secret versions under fresh data keys. It runs on demand for a single secret, and
+// periodically for every version whose data key is older than maxKeyAgeDays.
+type SecretRekeyer struct {
+	logger         *logrus.Logger
+	db             database.Database
+	secretsManager secrets.SecretsManager
+	maxKeyAgeDays  int
+
+	// mu serializes rekeys, so the scheduled run and an on-demand rekey don't race on the same version
+	mu     sync.Mutex
+	status common.SecretRekeyStatus
+}
+
+// RekeyVersion decrypts a secret version with its current data key, encrypts it under a new one and points the
+// version at the new key. The old key is deleted only once the version no longer uses it.
+func (r *SecretRekeyer) RekeyVersion(ctx context.Context, version *common.RekeyableSecretVersion) error {
+	oldKey, err := r.secretsManager.GetSecret(ctx, version.Id)
+	if err != nil {
+		return err
+	}
+	plaintext, err := common.DecryptSecretBytes(version.Value, oldKey)
+	if err != nil {
+		return err
+	}
+	value, newKey, err := common.EncryptSecretBytes(common.GenUuid(), plaintext, common.KEY_SIZE)
+	if err != nil {
+		return err
+	}
+	err = r.secretsManager.CreateSecret(ctx, newKey)
+	if err != nil {
+		return err
+	}
+	err = database.RekeySecretVersion(ctx, r.db, version.Id, newKey.Id, value)
+	if err != nil {
+		// the version still uses its old key, so the new one is unused
+		deleteErr := r.secretsManager.DeleteSecret(ctx, newKey.Id)
+		if deleteErr != nil {
+			r.logger.Errorf("Error deleting unused data key %s: %v", newKey.Id, deleteErr)
+		}
+		return err
+	}
+	// the version is already rekeyed, so a leftover old key is only logged
+	err = r.secretsManager.DeleteSecret(ctx, version.Id)
+	if err != nil {
+		r.logger.Errorf("Error deleting old data key %s of secret %s: %v", version.Id, version.SecretName, err)
+	}
+	return nil
+}
+
+// RekeySecret rekeys every version of a secret, returning the number of versions rekeyed. It stops at the first
+// failure; versions rekeyed before it keep their new keys.
+func (r *SecretRekeyer) RekeySecret(ctx context.Context, secretId string) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	versions, err := database.ListSecretVersionsToRekey(ctx, r.db, secretId, time.Now(), 0)
+	if err != nil {
+		return 0, err
+	}
+	rekeyed := 0
+	for _, version := range versions {
+		err = r.RekeyVersion(ctx, version)
+		if err != nil {
+			return rekeyed, err
+		}
+		rekeyed++
+	}
+	return rekeyed, nil
+}
+
+func (r *SecretRekeyer) keyedBefore() time.Time {
+	return time.Now().AddDate(0, 0, -r.maxKeyAgeDays)
+}
+
+func (r *SecretRekeyer) handleRekey(ctx context.Context) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	// versions keyed after the run starts are never picked up, so the run ends even if every version fails
+	keyedBefore := r.keyedBefore()
+	startedAt := time.Now()
+	rekeyed, failed := 0, 0
+	defer func() {
+		r.status.LastRunAt = &startedAt
+		r.status.LastRekeyed = rekeyed
+		r.status.LastFailed = failed
+		r.status.TotalRekeyed += rekeyed
+		r.status.TotalFailed += failed
+	}()
+
+	for {
+		versions, err := database.ListSecretVersionsToRekey(ctx, r.db, "", keyedBefore, rekeyBatchSize+failed)
+		if err != nil {
+			return err
+		}
+		// failed versions are still listed, ahead of the ones not yet tried
+		if len(versions) <= failed {
+			break
+		}
+		for _, version := range versions[failed:] {
+			err = r.RekeyVersion(ctx, version)
+			if err != nil {
+				r.logger.Errorf("Error rekeying version %d of secret %s: %v", version.Version, version.SecretName, err)
+				failed++
+				continue
+			}
+			rekeyed++
+			err = r.secretsManager.LogAccess(ctx, common.NewAuditLog(ctx, common.SYSTEM_USER_ID, "SecretRekeyed", common.TARGET_TYPE_SECRET, common.QualifiedName(version.Namespace, version.SecretName), nil))
+			if err != nil {
+				r.logger.Errorf("Error logging rekey of secret %s: %v", version.SecretName, err)
+			}
+		}
+		r.logger.Infof("SecretRekeyer progress: %d versions rekeyed, %d failed", rekeyed, failed)
+	}
+	return nil
+}
+
+// Status returns the progress of the scheduled rekey, with the number of versions still due
+func (r *SecretRekeyer) Status(ctx context.Context) (*common.SecretRekeyStatus, error) {
+	r.mu.Lock()
+	status := r.status
+	r.mu.Unlock()
+
+	status.Enabled = r.maxKeyAgeDays > 0
+	if !status.Enabled {
+		return &status, nil
+	}
+	status.MaxKeyAgeDays = r.maxKeyAgeDays
+	pending, err := database.CountSecretVersionsToRekey(ctx, r.db, r.keyedBefore())
+	if err != nil {
+		return nil, err
+	}
+	status.Pending = pending
+	return &status, nil
+}
+
+func (r *SecretRekeyer) Rekey(ctx context.Context, rekeySeconds int) {
+	timer := time.NewTicker(time.Duration(rekeySeconds) * time.Second)
+	for true {
+		select {
+		case <-ctx.Done():
+			r.logger.Debug("Context canceled. Closing SecretRekeyer")
+			timer.Stop()
+			return
+		case <-timer.C:
+			err := r.handleRekey(ctx)
+			if err != nil {
+				r.logger.Errorf("Error in ListSecretVersionsToRekey rekey: %v", err)
+			}
+		}
+	}
+}
+
+// NewSecretRekeyer creates a SecretRekeyer. The scheduled rekey only runs if maxKeyAgeDays is positive.
+func NewSecretRekeyer(ctx context.Context, logger *logrus.Logger, db database.Database, secretsManager secrets.SecretsManager, maxKeyAgeDays, rekeySeconds int) *SecretRekeyer {
+	secretRekeyer := &SecretRekeyer{
+		logger:         logger,
+		db:             db,
+		secretsManager: secretsManager,
+		maxKeyAgeDays:  maxKeyAgeDays,
+	}
+
+	if maxKeyAgeDays > 0 {
+		go secretRekeyer.Rekey(ctx, rekeySeconds)
+	}
+
+	return secretRekeyer
+}
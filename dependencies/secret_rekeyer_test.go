@@ -0,0 +1,172 @@
+package dependencies
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/database"
+)
+
+var rekeyColumns = []string{"id", "secret_id", "name", "namespace", "version", "value"}
+
+func newTestRekeyer(t *testing.T) (*SecretRekeyer, *memorySecretsManager, sqlmock.Sqlmock) {
+	dbMock, err := database.NewMockDatabase()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	secretsManager := newMemorySecretsManager()
+	return &SecretRekeyer{
+		logger:         logger,
+		db:             dbMock,
+		secretsManager: secretsManager,
+		maxKeyAgeDays:  90,
+	}, secretsManager, dbMock.Mock()
+}
+
+// newRekeyableVersion encrypts plaintext under a new data key stored in secretsManager
+func newRekeyableVersion(t *testing.T, secretsManager *memorySecretsManager, version int, plaintext string) *common.RekeyableSecretVersion {
+	value, key, err := common.EncryptSecretBytes(common.GenUuid(), []byte(plaintext), common.KEY_SIZE)
+	require.Nil(t, err, "Unexpected err encrypting secret: %v", err)
+	secretsManager.keys[key.Id] = key
+	return &common.RekeyableSecretVersion{
+		Id:         key.Id,
+		SecretId:   "secretId",
+		SecretName: "secret",
+		Namespace:  common.DEFAULT_NAMESPACE,
+		Version:    version,
+		Value:      value,
+	}
+}
+
+func addRekeyRow(rows *sqlmock.Rows, version *common.RekeyableSecretVersion) *sqlmock.Rows {
+	return rows.AddRow(version.Id, version.SecretId, version.SecretName, version.Namespace, version.Version, version.Value)
+}
+
+func TestRekeyVersionDeletesNewKeyOnFailure(t *testing.T) {
+	var tests = []struct {
+		testName string
+		initFunc func(mock sqlmock.Sqlmock)
+	}{
+		{
+			testName: "update error",
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+			},
+		},
+		{
+			testName: "already rekeyed or purged",
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("RekeyVersion - Errors - %v", given.testName), func(t *testing.T) {
+			rekeyer, secretsManager, mock := newTestRekeyer(t)
+			version := newRekeyableVersion(t, secretsManager, 1, "value")
+			given.initFunc(mock)
+
+			err := rekeyer.RekeyVersion(context.Background(), version)
+			require.NotNil(t, err, "no error in RekeyVersion: %v", err)
+			require.Equal(t, len(secretsManager.keys), 1, "Expected only the old key to be left, got %v keys", len(secretsManager.keys))
+			_, ok := secretsManager.keys[version.Id]
+			require.True(t, ok, "Old key %v was deleted", version.Id)
+			err = mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestRekeyVersionKeepsOldKeyOnDeleteFailure(t *testing.T) {
+	rekeyer, secretsManager, mock := newTestRekeyer(t)
+	version := newRekeyableVersion(t, secretsManager, 1, "value")
+	secretsManager.deleteErrs[version.Id] = fmt.Errorf("Oh no!")
+	mock.ExpectExec("UPDATE").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), version.Id).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := rekeyer.RekeyVersion(context.Background(), version)
+	require.Nil(t, err, "error in RekeyVersion: %v", err)
+	err = mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+	require.Equal(t, len(secretsManager.keys), 2, "Expected the old and new keys, got %v keys", len(secretsManager.keys))
+}
+
+func TestRekeyVersionSuccesses(t *testing.T) {
+	rekeyer, secretsManager, mock := newTestRekeyer(t)
+	version := newRekeyableVersion(t, secretsManager, 1, "value")
+	var value string
+	mock.ExpectExec("UPDATE").
+		WithArgs(sqlmock.AnyArg(), capturedArg{&value}, version.Id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	err := rekeyer.RekeyVersion(context.Background(), version)
+	require.Nil(t, err, "error in RekeyVersion: %v", err)
+	err = mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+	require.Equal(t, len(secretsManager.keys), 1, "Expected only the new key to be left, got %v keys", len(secretsManager.keys))
+	for _, newKey := range secretsManager.keys {
+		plaintext, err := common.DecryptSecretBytes(value, newKey)
+		require.Nil(t, err, "error decrypting rekeyed value: %v", err)
+		require.Equal(t, string(plaintext), "value", "Plaintext %v did not equal expected value", string(plaintext))
+	}
+}
+
+func TestHandleRekeyPagesPastFailures(t *testing.T) {
+	rekeyer, secretsManager, mock := newTestRekeyer(t)
+	// the first version's key is missing, so it fails on every run and stays at the head of the list
+	failing := newRekeyableVersion(t, secretsManager, 1, "value1")
+	delete(secretsManager.keys, failing.Id)
+	succeeding := newRekeyableVersion(t, secretsManager, 2, "value2")
+
+	mock.ExpectQuery("SELECT").
+		WithArgs("", sqlmock.AnyArg(), rekeyBatchSize).
+		WillReturnRows(addRekeyRow(addRekeyRow(sqlmock.NewRows(rekeyColumns), failing), succeeding)).
+		RowsWillBeClosed()
+	mock.ExpectExec("UPDATE").WithArgs(sqlmock.AnyArg(), sqlmock.AnyArg(), succeeding.Id).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery("SELECT").
+		WithArgs("", sqlmock.AnyArg(), rekeyBatchSize+1).
+		WillReturnRows(addRekeyRow(sqlmock.NewRows(rekeyColumns), failing)).
+		RowsWillBeClosed()
+
+	err := rekeyer.handleRekey(context.Background())
+	require.Nil(t, err, "error in handleRekey: %v", err)
+	err = mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+	require.Equal(t, rekeyer.status.LastRekeyed, 1, "LastRekeyed %v did not equal expected 1", rekeyer.status.LastRekeyed)
+	require.Equal(t, rekeyer.status.LastFailed, 1, "LastFailed %v did not equal expected 1", rekeyer.status.LastFailed)
+	require.Equal(t, len(secretsManager.logs), 1, "Logs %v did not equal expected 1", len(secretsManager.logs))
+	require.Equal(t, secretsManager.logs[0].ActionType, "SecretRekeyed", "ActionType %v did not equal expected SecretRekeyed", secretsManager.logs[0].ActionType)
+	require.Equal(t, secretsManager.logs[0].UserId, common.SYSTEM_USER_ID, "UserId %v did not equal expected %v", secretsManager.logs[0].UserId, common.SYSTEM_USER_ID)
+}
+
+func TestHandleRekeyErrors(t *testing.T) {
+	rekeyer, _, mock := newTestRekeyer(t)
+	mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
+
+	err := rekeyer.handleRekey(context.Background())
+	require.NotNil(t, err, "no error in handleRekey: %v", err)
+	require.NotNil(t, rekeyer.status.LastRunAt, "LastRunAt was not set")
+	err = mock.ExpectationsWereMet()
+	require.Nil(t, err, "expectations not met: %v", err)
+}
+
+// capturedArg matches any string argument, storing it in value
+type capturedArg struct {
+	value *string
+}
+
+func (a capturedArg) Match(v driver.Value) bool {
+	s, ok := v.(string)
+	if ok {
+		*a.value = s
+	}
+	return ok
+}
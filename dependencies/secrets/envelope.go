@@ -0,0 +1,112 @@
+package secrets
+
+import (
+	"context"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// KeyRewrapper is implemented by secrets managers that can re-wrap stored data keys under the current master key
+type KeyRewrapper interface {
+	CurrentKeyId() string
+	RewrapSecret(ctx context.Context, secretId string) (bool, error)
+}
+
+// EnvelopeSecretsManager wraps data keys with a key encryption key before they reach the underlying secrets manager
+type EnvelopeSecretsManager struct {
+	SecretsManager
+	kek KeyEncryptionKeyProvider
+}
+
+func (s *EnvelopeSecretsManager) wrap(secret *common.EncryptedSecret) (*common.EncryptedSecret, error) {
+	keyId := s.kek.CurrentKeyId()
+	kek, err := s.kek.GetKey(keyId)
+	if err != nil {
+		return nil, err
+	}
+	wrappedKey, err := common.WrapKey(kek, secret.Id, secret.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &common.EncryptedSecret{
+		Id:    secret.Id,
+		Key:   wrappedKey,
+		Iv:    secret.Iv,
+		KekId: keyId,
+	}, nil
+}
+
+func (s *EnvelopeSecretsManager) unwrap(secret *common.EncryptedSecret) (*common.EncryptedSecret, error) {
+	// keys stored before a KEK was configured are kept in plaintext until rewrapped
+	if secret.KekId == "" {
+		return secret, nil
+	}
+	kek, err := s.kek.GetKey(secret.KekId)
+	if err != nil {
+		return nil, err
+	}
+	key, err := common.UnwrapKey(kek, secret.Id, secret.Key)
+	if err != nil {
+		return nil, err
+	}
+	return &common.EncryptedSecret{
+		Id:  secret.Id,
+		Key: key,
+		Iv:  secret.Iv,
+	}, nil
+}
+
+func (s *EnvelopeSecretsManager) CreateSecret(ctx context.Context, secret *common.EncryptedSecret) error {
+	wrapped, err := s.wrap(secret)
+	if err != nil {
+		return err
+	}
+	return s.SecretsManager.CreateSecret(ctx, wrapped)
+}
+
+func (s *EnvelopeSecretsManager) UpdateSecret(ctx context.Context, secret *common.EncryptedSecret) error {
+	wrapped, err := s.wrap(secret)
+	if err != nil {
+		return err
+	}
+	return s.SecretsManager.UpdateSecret(ctx, wrapped)
+}
+
+func (s *EnvelopeSecretsManager) GetSecret(ctx context.Context, secretId string) (*common.EncryptedSecret, error) {
+	secret, err := s.SecretsManager.GetSecret(ctx, secretId)
+	if err != nil {
+		return nil, err
+	}
+	return s.unwrap(secret)
+}
+
+func (s *EnvelopeSecretsManager) CurrentKeyId() string {
+	return s.kek.CurrentKeyId()
+}
+
+// RewrapSecret re-wraps a single data key under the current KEK. Returns false if it was already current.
+func (s *EnvelopeSecretsManager) RewrapSecret(ctx context.Context, secretId string) (bool, error) {
+	stored, err := s.SecretsManager.GetSecret(ctx, secretId)
+	if err != nil {
+		return false, err
+	}
+	if stored.KekId == s.kek.CurrentKeyId() {
+		return false, nil
+	}
+	secret, err := s.unwrap(stored)
+	if err != nil {
+		return false, err
+	}
+	err = s.UpdateSecret(ctx, secret)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func NewEnvelopeSecretsManager(secretsManager SecretsManager, kek KeyEncryptionKeyProvider) SecretsManager {
+	return &EnvelopeSecretsManager{
+		SecretsManager: secretsManager,
+		kek:            kek,
+	}
+}
@@ -0,0 +1,192 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// memorySecretsManager stores secrets as they're given, so tests can see what the envelope passes down
+type memorySecretsManager struct {
+	SecretsManager
+	secrets map[string]*common.EncryptedSecret
+	updates int
+}
+
+func newMemorySecretsManager() *memorySecretsManager {
+	return &memorySecretsManager{secrets: make(map[string]*common.EncryptedSecret)}
+}
+
+func (m *memorySecretsManager) CreateSecret(_ context.Context, secret *common.EncryptedSecret) error {
+	stored := *secret
+	m.secrets[secret.Id] = &stored
+	return nil
+}
+
+func (m *memorySecretsManager) UpdateSecret(_ context.Context, secret *common.EncryptedSecret) error {
+	m.updates++
+	stored := *secret
+	m.secrets[secret.Id] = &stored
+	return nil
+}
+
+func (m *memorySecretsManager) GetSecret(_ context.Context, secretId string) (*common.EncryptedSecret, error) {
+	secret, ok := m.secrets[secretId]
+	if !ok {
+		return nil, common.NewResourceNotFoundError("GetSecret", "id", secretId)
+	}
+	stored := *secret
+	return &stored, nil
+}
+
+func testKek(b byte) []byte {
+	return []byte(strings.Repeat(string([]byte{b}), common.KEY_SIZE))
+}
+
+func newTestEnvelope(currentKeyId string) (*EnvelopeSecretsManager, *memorySecretsManager) {
+	store := newMemorySecretsManager()
+	kek := &StaticKekProvider{
+		currentKeyId: currentKeyId,
+		keys:         map[string][]byte{"kek1": testKek(1), "kek2": testKek(2)},
+	}
+	return NewEnvelopeSecretsManager(store, kek).(*EnvelopeSecretsManager), store
+}
+
+var dataKey = strings.Repeat("ab", common.KEY_SIZE)
+
+func TestEnvelopeRoundTrip(t *testing.T) {
+	var tests = []struct {
+		testName string
+		create   func(s *EnvelopeSecretsManager, secret *common.EncryptedSecret) error
+	}{
+		{
+			testName: "create",
+			create: func(s *EnvelopeSecretsManager, secret *common.EncryptedSecret) error {
+				return s.CreateSecret(context.Background(), secret)
+			},
+		},
+		{
+			testName: "update",
+			create: func(s *EnvelopeSecretsManager, secret *common.EncryptedSecret) error {
+				return s.UpdateSecret(context.Background(), secret)
+			},
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("EnvelopeSecretsManager round trip - %v", given.testName), func(t *testing.T) {
+			envelope, store := newTestEnvelope("kek1")
+			secret := &common.EncryptedSecret{Id: "secretId", Key: dataKey, Iv: "iv"}
+
+			err := given.create(envelope, secret)
+			require.Nil(t, err, "error wrapping secret: %v", err)
+			stored := store.secrets["secretId"]
+			require.Equal(t, stored.KekId, "kek1", "KekId %v did not equal expected kek1", stored.KekId)
+			require.NotEqual(t, stored.Key, dataKey, "Data key was stored unwrapped")
+			require.Equal(t, stored.Iv, "iv", "Iv %v did not equal expected iv", stored.Iv)
+
+			result, err := envelope.GetSecret(context.Background(), "secretId")
+			require.Nil(t, err, "error in GetSecret: %v", err)
+			require.Equal(t, result, secret, "Result %+v did not equal expected %+v", result, secret)
+		})
+	}
+}
+
+func TestEnvelopeGetSecretWithoutKekId(t *testing.T) {
+	envelope, store := newTestEnvelope("kek1")
+	plaintext := &common.EncryptedSecret{Id: "secretId", Key: dataKey, Iv: "iv"}
+	store.secrets["secretId"] = plaintext
+
+	result, err := envelope.GetSecret(context.Background(), "secretId")
+	require.Nil(t, err, "error in GetSecret: %v", err)
+	require.Equal(t, result, plaintext, "Result %+v did not equal expected %+v", result, plaintext)
+}
+
+func TestEnvelopeGetSecretErrors(t *testing.T) {
+	var tests = []struct {
+		testName string
+		stored   *common.EncryptedSecret
+	}{
+		{
+			testName: "not found",
+		},
+		{
+			testName: "unknown kek",
+			stored:   &common.EncryptedSecret{Id: "secretId", Key: dataKey, Iv: "iv", KekId: "kek3"},
+		},
+		{
+			testName: "not wrapped",
+			stored:   &common.EncryptedSecret{Id: "secretId", Key: dataKey, Iv: "iv", KekId: "kek1"},
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("EnvelopeSecretsManager.GetSecret - Errors - %v", given.testName), func(t *testing.T) {
+			envelope, store := newTestEnvelope("kek1")
+			if given.stored != nil {
+				store.secrets[given.stored.Id] = given.stored
+			}
+			result, err := envelope.GetSecret(context.Background(), "secretId")
+			require.NotNil(t, err, "no error in GetSecret: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+		})
+	}
+}
+
+func TestEnvelopeRewrapSecret(t *testing.T) {
+	var tests = []struct {
+		testName        string
+		storedKekId     string
+		expected        bool
+		expectedUpdates int
+	}{
+		{
+			testName:        "already current",
+			storedKekId:     "kek2",
+			expected:        false,
+			expectedUpdates: 0,
+		},
+		{
+			testName:        "previous kek",
+			storedKekId:     "kek1",
+			expected:        true,
+			expectedUpdates: 1,
+		},
+		{
+			testName:        "no kek",
+			storedKekId:     "",
+			expected:        true,
+			expectedUpdates: 1,
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("EnvelopeSecretsManager.RewrapSecret - %v", given.testName), func(t *testing.T) {
+			envelope, store := newTestEnvelope(given.storedKekId)
+			secret := &common.EncryptedSecret{Id: "secretId", Key: dataKey, Iv: "iv"}
+			if given.storedKekId == "" {
+				err := store.CreateSecret(context.Background(), secret)
+				require.Nil(t, err, "error in CreateSecret: %v", err)
+			} else {
+				err := envelope.CreateSecret(context.Background(), secret)
+				require.Nil(t, err, "error in CreateSecret: %v", err)
+			}
+			envelope.kek.(*StaticKekProvider).currentKeyId = "kek2"
+
+			result, err := envelope.RewrapSecret(context.Background(), "secretId")
+			require.Nil(t, err, "error in RewrapSecret: %v", err)
+			require.Equal(t, result, given.expected, "Result %v did not equal expected %v", result, given.expected)
+			require.Equal(t, store.updates, given.expectedUpdates, "Updates %v did not equal expected %v", store.updates, given.expectedUpdates)
+			require.Equal(t, store.secrets["secretId"].KekId, "kek2", "KekId %v did not equal expected kek2", store.secrets["secretId"].KekId)
+
+			unwrapped, err := envelope.GetSecret(context.Background(), "secretId")
+			require.Nil(t, err, "error in GetSecret: %v", err)
+			require.Equal(t, unwrapped, secret, "Result %+v did not equal expected %+v", unwrapped, secret)
+		})
+	}
+}
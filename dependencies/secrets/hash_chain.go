@@ -0,0 +1,67 @@
+package secrets
+
+import (
+	"context"
+	"sync"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// hashChainAttempts bounds how often LogAccess re-reads the head of the chain after another writer appended to it
+const hashChainAttempts = 3
+
+// HashChainSecretsManager links every access log to the one before it, so that edited or deleted logs can be detected
+type HashChainSecretsManager struct {
+	SecretsManager
+	key        []byte
+	mu         sync.Mutex
+	head       *common.AccessLog
+	headLoaded bool
+}
+
+func (s *HashChainSecretsManager) loadHead(ctx context.Context) error {
+	head, err := s.SecretsManager.GetLatestAccessLog(ctx)
+	if err != nil {
+		return err
+	}
+	s.head = head
+	s.headLoaded = true
+	return nil
+}
+
+func (s *HashChainSecretsManager) LogAccess(ctx context.Context, log *common.AccessLog) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var err error
+	for attempt := 0; attempt < hashChainAttempts; attempt++ {
+		// the cached head is stale if another server appended to the chain, which fails the insert on the unique sequence
+		if !s.headLoaded || attempt > 0 {
+			err = s.loadHead(ctx)
+			if err != nil {
+				return err
+			}
+		}
+		common.ChainAccessLog(s.key, s.head, log)
+		err = s.SecretsManager.LogAccess(ctx, log)
+		if err == nil {
+			head := *log
+			s.head = &head
+			return nil
+		}
+		// only a sequence conflict means the head is stale; any other error would fail again
+		if _, ok := err.(common.ResourceAlreadyExistsError); !ok {
+			return err
+		}
+	}
+	return err
+}
+
+// NewHashChainSecretsManager creates a HashChainSecretsManager that hashes logs under key. key must be kept out of the
+// underlying secrets manager's datastore.
+func NewHashChainSecretsManager(secretsManager SecretsManager, key []byte) SecretsManager {
+	return &HashChainSecretsManager{
+		SecretsManager: secretsManager,
+		key:            key,
+	}
+}
@@ -0,0 +1,118 @@
+package secrets
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+var testChainKey = []byte("chain key")
+
+// fakeLogStore keeps access logs in memory and, like the unique sequence index, rejects a log that doesn't extend the
+// end of the chain
+type fakeLogStore struct {
+	SecretsManager
+	logs       []*common.AccessLog
+	headCalls  int
+	inserts    int
+	alwaysFail error
+}
+
+func (f *fakeLogStore) GetLatestAccessLog(_ context.Context) (*common.AccessLog, error) {
+	f.headCalls++
+	if len(f.logs) == 0 {
+		return nil, nil
+	}
+	head := *f.logs[len(f.logs)-1]
+	return &head, nil
+}
+
+func (f *fakeLogStore) LogAccess(_ context.Context, log *common.AccessLog) error {
+	f.inserts++
+	if f.alwaysFail != nil {
+		return f.alwaysFail
+	}
+	if len(f.logs) > 0 && log.Sequence <= f.logs[len(f.logs)-1].Sequence {
+		return common.NewResourceAlreadyExistsError("LogAccess", fmt.Sprintf("Sequence %d already exists", log.Sequence))
+	}
+	stored := *log
+	f.logs = append(f.logs, &stored)
+	return nil
+}
+
+// appendFromOtherServer appends a log the way a second server sharing the store would
+func (f *fakeLogStore) appendFromOtherServer() {
+	var prev *common.AccessLog
+	if len(f.logs) > 0 {
+		prev = f.logs[len(f.logs)-1]
+	}
+	log := common.NewAccessLog("otherUserId", "GetSecret", "other")
+	common.ChainAccessLog(testChainKey, prev, log)
+	f.logs = append(f.logs, log)
+}
+
+func TestHashChainLogAccessCachesHead(t *testing.T) {
+	store := &fakeLogStore{}
+	secretsManager := NewHashChainSecretsManager(store, testChainKey)
+
+	for idx := 0; idx < 3; idx++ {
+		err := secretsManager.LogAccess(context.Background(), common.NewAccessLog("userId", "GetSecret", fmt.Sprintf("key%d", idx)))
+		require.Nil(t, err, "error in LogAccess: %v", err)
+	}
+	require.Equal(t, store.headCalls, 1, "Expected the head to be loaded once, got %v loads", store.headCalls)
+	verified, chainBreak := common.VerifyAccessLogChain(testChainKey, nil, store.logs)
+	require.Nil(t, chainBreak, "Unexpected chain break: %+v", chainBreak)
+	require.Equal(t, verified, 3, "Verified %v did not equal expected 3", verified)
+}
+
+func TestHashChainLogAccessReloadsHeadAfterConflict(t *testing.T) {
+	store := &fakeLogStore{}
+	secretsManager := NewHashChainSecretsManager(store, testChainKey)
+
+	err := secretsManager.LogAccess(context.Background(), common.NewAccessLog("userId", "GetSecret", "key0"))
+	require.Nil(t, err, "error in LogAccess: %v", err)
+	store.appendFromOtherServer()
+
+	log := common.NewAccessLog("userId", "GetSecret", "key1")
+	err = secretsManager.LogAccess(context.Background(), log)
+	require.Nil(t, err, "error in LogAccess: %v", err)
+	require.Equal(t, log.Sequence, int64(3), "Expected sequence 3 after the conflict, got %v", log.Sequence)
+	require.Equal(t, store.headCalls, 2, "Expected the head to be reloaded once, got %v loads", store.headCalls)
+	verified, chainBreak := common.VerifyAccessLogChain(testChainKey, nil, store.logs)
+	require.Nil(t, chainBreak, "Unexpected chain break: %+v", chainBreak)
+	require.Equal(t, verified, 3, "Verified %v did not equal expected 3", verified)
+}
+
+func TestHashChainLogAccessErrors(t *testing.T) {
+	var tests = []struct {
+		testName        string
+		insertErr       error
+		expectedInserts int
+	}{
+		{
+			testName:        "conflict every attempt",
+			insertErr:       common.NewResourceAlreadyExistsError("LogAccess", "Sequence 1 already exists"),
+			expectedInserts: hashChainAttempts,
+		},
+		{
+			testName:        "not a conflict",
+			insertErr:       fmt.Errorf("Oh no!"),
+			expectedInserts: 1,
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("HashChainSecretsManager.LogAccess - Errors - %v", given.testName), func(t *testing.T) {
+			store := &fakeLogStore{alwaysFail: given.insertErr}
+			secretsManager := NewHashChainSecretsManager(store, testChainKey)
+
+			err := secretsManager.LogAccess(context.Background(), common.NewAccessLog("userId", "GetSecret", "key0"))
+			require.Equal(t, err, given.insertErr, "Error %v did not equal expected %v", err, given.insertErr)
+			require.Equal(t, store.inserts, given.expectedInserts, "Inserts %v did not equal expected %v", store.inserts, given.expectedInserts)
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package secrets
+
+import (
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// KeyEncryptionKeyProvider supplies the master keys used to wrap per-secret data keys
+type KeyEncryptionKeyProvider interface {
+	CurrentKeyId() string
+	GetKey(keyId string) ([]byte, error)
+}
+
+type KekOpts struct {
+	ProviderType string `yaml:"providerType"`
+	CurrentKeyId string `yaml:"currentKeyId"`
+	FilePath     string `yaml:"filePath"`
+	EnvVar       string `yaml:"envVar"`
+}
+
+type StaticKekProvider struct {
+	currentKeyId string
+	keys         map[string][]byte
+}
+
+func (p *StaticKekProvider) CurrentKeyId() string {
+	return p.currentKeyId
+}
+
+func (p *StaticKekProvider) GetKey(keyId string) ([]byte, error) {
+	key, ok := p.keys[keyId]
+	if !ok {
+		return nil, common.NewInternalServerError("GetKey", "Unknown key encryption key %s", keyId)
+	}
+	return key, nil
+}
+
+func newStaticKekProvider(currentKeyId string, hexKeys map[string]string) (KeyEncryptionKeyProvider, error) {
+	keys := make(map[string][]byte)
+	for keyId, hexKey := range hexKeys {
+		key, err := hex.DecodeString(strings.TrimSpace(hexKey))
+		if err != nil {
+			return nil, common.NewInitializationError("kek provider", "Key %s is not valid hex: %v", keyId, err)
+		}
+		if len(key) != common.KEY_SIZE {
+			return nil, common.NewInitializationError("kek provider", "Key %s must be %d bytes. Got %d", keyId, common.KEY_SIZE, len(key))
+		}
+		keys[keyId] = key
+	}
+	if _, ok := keys[currentKeyId]; !ok {
+		return nil, common.NewInitializationError("kek provider", "Current key %s not found", currentKeyId)
+	}
+	return &StaticKekProvider{currentKeyId: currentKeyId, keys: keys}, nil
+}
+
+// NewFileKekProvider reads a YAML file mapping key ids to hex-encoded keys
+func NewFileKekProvider(currentKeyId, filePath string) (KeyEncryptionKeyProvider, error) {
+	raw, err := ioutil.ReadFile(filePath)
+	if err != nil {
+		return nil, common.NewInitializationError("kek provider", "Unable to read key file, %s, with error: %v", filePath, err)
+	}
+	var hexKeys map[string]string
+	err = yaml.Unmarshal(raw, &hexKeys)
+	if err != nil {
+		return nil, common.NewInitializationError("kek provider", "Unable to unmarshal key file, %s, with error: %v", filePath, err)
+	}
+	return newStaticKekProvider(currentKeyId, hexKeys)
+}
+
+// NewEnvKekProvider reads keys from an environment variable of the form "keyId1:hexKey1,keyId2:hexKey2"
+func NewEnvKekProvider(currentKeyId, envVar string) (KeyEncryptionKeyProvider, error) {
+	raw := os.Getenv(envVar)
+	if raw == "" {
+		return nil, common.NewInitializationError("kek provider", "Environment variable %s is not set", envVar)
+	}
+	hexKeys := make(map[string]string)
+	for _, pair := range strings.Split(raw, ",") {
+		parts := strings.SplitN(pair, ":", 2)
+		if len(parts) != 2 {
+			return nil, common.NewInitializationError("kek provider", "Expected keyId:hexKey pairs in %s", envVar)
+		}
+		hexKeys[strings.TrimSpace(parts[0])] = parts[1]
+	}
+	return newStaticKekProvider(currentKeyId, hexKeys)
+}
+
+func NewKekProvider(opts KekOpts) (KeyEncryptionKeyProvider, error) {
+	switch opts.ProviderType {
+	case "file":
+		return NewFileKekProvider(opts.CurrentKeyId, opts.FilePath)
+	case "env":
+		return NewEnvKekProvider(opts.CurrentKeyId, opts.EnvVar)
+	case "none", "":
+		return nil, nil
+	default:
+		return nil, common.NewInitializationError("kek provider", "Unknown kek provider type %s", opts.ProviderType)
+	}
+}
@@ -0,0 +1,174 @@
+package secrets
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+var hexKek1 = strings.Repeat("01", common.KEY_SIZE)
+var hexKek2 = strings.Repeat("02", common.KEY_SIZE)
+
+func writeKeyFile(t *testing.T, contents string) string {
+	file, err := ioutil.TempFile("", "kek-*.yml")
+	require.Nil(t, err, "Unexpected err creating key file: %v", err)
+	defer file.Close()
+	_, err = file.WriteString(contents)
+	require.Nil(t, err, "Unexpected err writing key file: %v", err)
+	return file.Name()
+}
+
+func TestNewKekProviderErrors(t *testing.T) {
+	var tests = []struct {
+		testName string
+		opts     KekOpts
+		keyFile  string
+		envValue string
+	}{
+		{
+			testName: "unknown provider",
+			opts:     KekOpts{ProviderType: "vault", CurrentKeyId: "kek1"},
+		},
+		{
+			testName: "missing file",
+			opts:     KekOpts{ProviderType: "file", CurrentKeyId: "kek1", FilePath: "/does/not/exist.yml"},
+		},
+		{
+			testName: "file not yaml",
+			opts:     KekOpts{ProviderType: "file", CurrentKeyId: "kek1"},
+			keyFile:  "- kek1\n- kek2",
+		},
+		{
+			testName: "file key not hex",
+			opts:     KekOpts{ProviderType: "file", CurrentKeyId: "kek1"},
+			keyFile:  "kek1: not hex\n",
+		},
+		{
+			testName: "file key too short",
+			opts:     KekOpts{ProviderType: "file", CurrentKeyId: "kek1"},
+			keyFile:  fmt.Sprintf("kek1: %s\n", hexKek1[2:]),
+		},
+		{
+			testName: "file key too long",
+			opts:     KekOpts{ProviderType: "file", CurrentKeyId: "kek1"},
+			keyFile:  fmt.Sprintf("kek1: %s00\n", hexKek1),
+		},
+		{
+			testName: "file missing current key",
+			opts:     KekOpts{ProviderType: "file", CurrentKeyId: "kek3"},
+			keyFile:  fmt.Sprintf("kek1: %s\n", hexKek1),
+		},
+		{
+			testName: "env not set",
+			opts:     KekOpts{ProviderType: "env", CurrentKeyId: "kek1"},
+		},
+		{
+			testName: "env not pairs",
+			opts:     KekOpts{ProviderType: "env", CurrentKeyId: "kek1"},
+			envValue: hexKek1,
+		},
+		{
+			testName: "env key too short",
+			opts:     KekOpts{ProviderType: "env", CurrentKeyId: "kek1"},
+			envValue: "kek1:" + hexKek1[2:],
+		},
+		{
+			testName: "env missing current key",
+			opts:     KekOpts{ProviderType: "env", CurrentKeyId: "kek3"},
+			envValue: "kek1:" + hexKek1,
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("NewKekProvider - Errors - %v", given.testName), func(t *testing.T) {
+			if given.keyFile != "" {
+				given.opts.FilePath = writeKeyFile(t, given.keyFile)
+				defer os.Remove(given.opts.FilePath)
+			}
+			given.opts.EnvVar = "DATA_VAULT_TEST_KEK"
+			os.Setenv(given.opts.EnvVar, given.envValue)
+			defer os.Unsetenv(given.opts.EnvVar)
+
+			result, err := NewKekProvider(given.opts)
+			require.NotNil(t, err, "no error in NewKekProvider: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+		})
+	}
+}
+
+func TestNewKekProviderSuccesses(t *testing.T) {
+	var tests = []struct {
+		testName string
+		opts     KekOpts
+		keyFile  string
+		envValue string
+		expected *StaticKekProvider
+	}{
+		{
+			testName: "file",
+			opts:     KekOpts{ProviderType: "file", CurrentKeyId: "kek2"},
+			keyFile:  fmt.Sprintf("kek1: %s\nkek2: \"%s\"\n", hexKek1, hexKek2),
+			expected: &StaticKekProvider{currentKeyId: "kek2", keys: map[string][]byte{"kek1": testKek(1), "kek2": testKek(2)}},
+		},
+		{
+			testName: "env",
+			opts:     KekOpts{ProviderType: "env", CurrentKeyId: "kek1"},
+			envValue: fmt.Sprintf("kek1:%s, kek2: %s ", hexKek1, hexKek2),
+			expected: &StaticKekProvider{currentKeyId: "kek1", keys: map[string][]byte{"kek1": testKek(1), "kek2": testKek(2)}},
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("NewKekProvider - Successes - %v", given.testName), func(t *testing.T) {
+			if given.keyFile != "" {
+				given.opts.FilePath = writeKeyFile(t, given.keyFile)
+				defer os.Remove(given.opts.FilePath)
+			}
+			given.opts.EnvVar = "DATA_VAULT_TEST_KEK"
+			os.Setenv(given.opts.EnvVar, given.envValue)
+			defer os.Unsetenv(given.opts.EnvVar)
+
+			result, err := NewKekProvider(given.opts)
+			require.Nil(t, err, "error in NewKekProvider: %v", err)
+			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
+		})
+	}
+}
+
+func TestNewKekProviderNone(t *testing.T) {
+	for _, providerType := range []string{"", "none"} {
+		result, err := NewKekProvider(KekOpts{ProviderType: providerType})
+		require.Nil(t, err, "error in NewKekProvider: %v", err)
+		require.Nil(t, result, "Result was not nil: %v", result)
+	}
+}
+
+func TestStaticKekProviderGetKey(t *testing.T) {
+	provider := &StaticKekProvider{currentKeyId: "kek1", keys: map[string][]byte{"kek1": testKek(1)}}
+	key, err := provider.GetKey("kek1")
+	require.Nil(t, err, "error in GetKey: %v", err)
+	require.Equal(t, key, testKek(1), "Key %v did not equal expected %v", key, testKek(1))
+
+	key, err = provider.GetKey("kek2")
+	require.NotNil(t, err, "no error in GetKey: %v", err)
+	require.Nil(t, key, "Key was not nil: %v", key)
+}
+
+func TestNewChainKey(t *testing.T) {
+	os.Setenv("DATA_VAULT_TEST_CHAIN_KEY", "chain1:"+hexKek1)
+	defer os.Unsetenv("DATA_VAULT_TEST_CHAIN_KEY")
+
+	key, err := NewChainKey(KekOpts{ProviderType: "env", CurrentKeyId: "chain1", EnvVar: "DATA_VAULT_TEST_CHAIN_KEY"})
+	require.Nil(t, err, "error in NewChainKey: %v", err)
+	require.Equal(t, key, testKek(1), "Key %v did not equal expected %v", key, testKek(1))
+
+	key, err = NewChainKey(KekOpts{ProviderType: "none"})
+	require.NotNil(t, err, "no error in NewChainKey: %v", err)
+	require.Nil(t, key, "Key was not nil: %v", key)
+}
@@ -42,12 +42,32 @@ func (s *MongoSecretsManager) reconnect(ctx context.Context) error {
 	return nil
 }
 
+// createSequenceIndex makes sequence unique across chained logs, so a writer appending with a stale head of the hash
+// chain fails instead of forking it. Logs written before the chain have no sequence and aren't indexed.
+func (s *MongoSecretsManager) createSequenceIndex(ctx context.Context) error {
+	index := mongo.IndexModel{
+		Keys: bson.D{{Key: "sequence", Value: 1}},
+		Options: mongoOptions.Index().
+			SetName("uq__access_logs__sequence").
+			SetUnique(true).
+			SetPartialFilterExpression(bson.M{"sequence": bson.M{"$gt": 0}}),
+	}
+	_, err := s.logCollection.Indexes().CreateOne(ctx, index)
+	if err != nil {
+		return common.NewMongoError("createSequenceIndex", "Error creating unique sequence index on %s, received error, %v", s.logCollectionName, err)
+	}
+	return nil
+}
+
 func (s *MongoSecretsManager) GetSecret(ctx context.Context, secretId string) (*common.EncryptedSecret, error) {
 	result := s.secretsCollection.FindOne(ctx, bson.M{"_id": secretId})
 	if result == nil {
 		return nil, common.NewMongoGetSecretError("FindOne for secret %s returned nil.", secretId)
 	}
 	err := result.Err()
+	if err == mongo.ErrNoDocuments {
+		return nil, common.NewResourceNotFoundError("GetSecret", "id", secretId)
+	}
 	if err != nil {
 		return nil, err
 	}
@@ -68,34 +88,93 @@ func (s *MongoSecretsManager) CreateSecret(ctx context.Context, secret *common.E
 	return nil
 }
 
+func (s *MongoSecretsManager) UpdateSecret(ctx context.Context, secret *common.EncryptedSecret) error {
+	result, err := s.secretsCollection.ReplaceOne(ctx, bson.M{"_id": secret.Id}, secret)
+	if err != nil {
+		return common.NewMongoError("UpdateSecret", "Error replacing secret, %s, received error, %v", secret.Id, err)
+	}
+	if result.MatchedCount == 0 {
+		return common.NewMongoError("UpdateSecret", "Secret %s not found", secret.Id)
+	}
+	return nil
+}
+
+func (s *MongoSecretsManager) DeleteSecret(ctx context.Context, secretId string) error {
+	result, err := s.secretsCollection.DeleteOne(ctx, bson.M{"_id": secretId})
+	if err != nil {
+		return common.NewMongoError("DeleteSecret", "Error deleting secret, %s, received error, %v", secretId, err)
+	}
+	if result.DeletedCount == 0 {
+		return common.NewResourceNotFoundError("DeleteSecret", "id", secretId)
+	}
+	return nil
+}
+
 func (s *MongoSecretsManager) Close(ctx context.Context) {
 	s.client.Disconnect(ctx)
 }
 
 func (s *MongoSecretsManager) LogAccess(ctx context.Context, log *common.AccessLog) error {
 	_, err := s.logCollection.InsertOne(ctx, log)
+	if mongo.IsDuplicateKeyError(err) {
+		// another writer took the sequence first
+		return common.NewResourceAlreadyExistsError("LogAccess", fmt.Sprintf("Sequence %d already exists", log.Sequence))
+	}
 	if err != nil {
 		return common.NewMongoError("LogAccess", "Error inserting log, %+v, received error, %v", log, err)
 	}
 	return nil
 }
 
-func (s *MongoSecretsManager) ListAccessLogs(ctx context.Context, req *common.ListAccessLogsRequest) ([]*common.AccessLog, error) {
-	op := "ListAccessLogs"
-	if req == nil {
-		return nil, common.NewMongoError(op, "Request is nil")
-	}
+func accessLogFilterQueries(req *common.ListAccessLogsRequest) []bson.M {
 	filterQueries := []bson.M{
-		bson.M{"user_id": req.UserId},
 		bson.M{"access_at": bson.M{
 			"$gte": bsonPrimitive.NewDateTimeFromTime(req.StartDate),
 			"$lte": bsonPrimitive.NewDateTimeFromTime(req.EndDate),
 		}},
 	}
+	optionalFilters := map[string]string{
+		"user_id":     req.UserId,
+		"action_type": req.ActionType,
+		"key_name":    req.KeyName,
+		"target_type": req.TargetType,
+		"target_id":   req.TargetId,
+		"request_id":  req.RequestId,
+		"outcome":     req.Outcome,
+		"source_ip":   req.SourceIp,
+	}
+	for field, value := range optionalFilters {
+		if value != "" {
+			filterQueries = append(filterQueries, bson.M{field: value})
+		}
+	}
+	return filterQueries
+}
 
-	sort := map[string]interface{}{"access_at": -1}
+func (s *MongoSecretsManager) ListAccessLogs(ctx context.Context, req *common.ListAccessLogsRequest, limit int, after *common.PageCursor) ([]*common.AccessLog, error) {
+	op := "ListAccessLogs"
+	if req == nil {
+		return nil, common.NewMongoError(op, "Request is nil")
+	}
+	filterQueries := accessLogFilterQueries(req)
+	if after != nil {
+		accessAt, err := common.AccessLogCursorTime(op, after)
+		if err != nil {
+			return nil, err
+		}
+		afterId, err := bsonPrimitive.ObjectIDFromHex(after.Id)
+		if err != nil {
+			return nil, common.NewInvalidParamsError(op, "Invalid cursor for access logs")
+		}
+		filterQueries = append(filterQueries, bson.M{"$or": []bson.M{
+			bson.M{"access_at": bson.M{"$lt": accessAt}},
+			bson.M{"access_at": accessAt, "_id": bson.M{"$lt": afterId}},
+		}})
+	}
 
-	opts := mongoOptions.Find().SetLimit(int64(req.PageSize)).SetSkip(int64(req.Offset)).SetSort(sort)
+	sort := bson.D{{Key: "access_at", Value: -1}, {Key: "_id", Value: -1}}
+
+	opts := mongoOptions.Find().SetLimit(int64(limit)).SetSkip(int64(req.Offset)).SetSort(sort)
 
 	rows, err := s.logCollection.Find(ctx, bson.M{"$and": filterQueries}, opts)
 	if err != nil {
@@ -112,6 +191,55 @@ func (s *MongoSecretsManager) ListAccessLogs(ctx context.Context, req *common.Li
 
 }
 
+func (s *MongoSecretsManager) CountAccessLogs(ctx context.Context, req *common.ListAccessLogsRequest) (int, error) {
+	op := "CountAccessLogs"
+	if req == nil {
+		return 0, common.NewMongoError(op, "Request is nil")
+	}
+	total, err := s.logCollection.CountDocuments(ctx, bson.M{"$and": accessLogFilterQueries(req)})
+	if err != nil {
+		return 0, common.NewMongoError(op, "Error counting documents: %s", err)
+	}
+	return int(total), nil
+}
+
+func (s *MongoSecretsManager) GetLatestAccessLog(ctx context.Context) (*common.AccessLog, error) {
+	op := "GetLatestAccessLog"
+	opts := mongoOptions.FindOne().SetSort(bson.M{"sequence": -1})
+	result := s.logCollection.FindOne(ctx, bson.M{"sequence": bson.M{"$gt": 0}}, opts)
+	err := result.Err()
+	if err == mongo.ErrNoDocuments {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, common.NewMongoError(op, "Error finding latest log: %s", err)
+	}
+
+	var log common.AccessLog
+	err = result.Decode(&log)
+	if err != nil {
+		return nil, common.NewMongoError(op, "Error decoding latest log: %s", err)
+	}
+	return &log, nil
+}
+
+func (s *MongoSecretsManager) ListAccessLogChain(ctx context.Context, afterSequence int64, limit int) ([]*common.AccessLog, error) {
+	op := "ListAccessLogChain"
+	opts := mongoOptions.Find().SetLimit(int64(limit)).SetSort(bson.M{"sequence": 1})
+	rows, err := s.logCollection.Find(ctx, bson.M{"sequence": bson.M{"$gt": afterSequence}}, opts)
+	if err != nil {
+		return nil, common.NewMongoError(op, "Error finding documents: %s", err)
+	}
+	defer rows.Close(ctx)
+
+	logs := make([]*common.AccessLog, 0)
+	err = rows.All(ctx, &logs)
+	if err != nil {
+		return nil, common.NewMongoError(op, "Error decoding documents: %s", err)
+	}
+	return logs, nil
+}
+
 func NewMongoSecretsManager(ctx context.Context, opts MongoSecretsOpts) (SecretsManager, error) {
 	var t time.Duration
 	retryWrites := true
@@ -138,5 +266,9 @@ func NewMongoSecretsManager(ctx context.Context, opts MongoSecretsOpts) (Secrets
 	if err != nil {
 		return nil, err
 	}
+	err = secretsManager.createSequenceIndex(ctx)
+	if err != nil {
+		return nil, err
+	}
 	return secretsManager, nil
 }
@@ -0,0 +1,321 @@
+package secrets
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/lib/pq"
+	bsonPrimitive "go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+type PostgresSecretsOpts struct {
+	Username     string `yaml:"username"`
+	Password     string `yaml:"password"`
+	Host         string `yaml:"host"`
+	DatabaseName string `yaml:"databaseName"`
+	SchemaName   string `yaml:"schemaName"`
+	SslMode      string `yaml:"sslMode"`
+}
+
+type PostgresSecretsManager struct {
+	db                 *sql.DB
+	secretsTableName   string
+	accessLogTableName string
+}
+
+func (s *PostgresSecretsManager) GetSecret(ctx context.Context, secretId string) (*common.EncryptedSecret, error) {
+	query := fmt.Sprintf(`
+	SELECT	id,
+			key,
+			iv,
+			kek_id
+	FROM	%s
+	WHERE	id = $1
+	`, s.secretsTableName)
+	rows, err := s.db.QueryContext(ctx, query, secretId)
+	if err != nil {
+		return nil, common.NewPostgresSecretsError("GetSecret", "Error fetching secret %s: %v", secretId, err)
+	}
+	defer rows.Close()
+
+	var secret *common.EncryptedSecret
+	for rows.Next() {
+		var row common.EncryptedSecret
+		err = rows.Scan(&row.Id, &row.Key, &row.Iv, &row.KekId)
+		if err != nil {
+			return nil, common.NewPostgresSecretsError("GetSecret", "Error scanning secret %s: %v", secretId, err)
+		}
+		secret = &row
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, common.NewPostgresSecretsError("GetSecret", "Error reading secret %s: %v", secretId, err)
+	}
+	if secret == nil {
+		return nil, common.NewResourceNotFoundError("GetSecret", "id", secretId)
+	}
+	return secret, nil
+}
+
+func (s *PostgresSecretsManager) CreateSecret(ctx context.Context, secret *common.EncryptedSecret) error {
+	query := fmt.Sprintf(`
+	INSERT INTO %s (id, key, iv, kek_id)
+	VALUES($1, $2, $3, $4)
+	`, s.secretsTableName)
+	_, err := s.db.ExecContext(ctx, query, secret.Id, secret.Key, secret.Iv, secret.KekId)
+	if err != nil {
+		return common.NewPostgresSecretsError("CreateSecret", "Error inserting secret, %s, received error, %v", secret.Id, err)
+	}
+	return nil
+}
+
+func (s *PostgresSecretsManager) UpdateSecret(ctx context.Context, secret *common.EncryptedSecret) error {
+	query := fmt.Sprintf(`
+	UPDATE	%s
+	SET		key = $1,
+			iv = $2,
+			kek_id = $3
+	WHERE	id = $4
+	`, s.secretsTableName)
+	result, err := s.db.ExecContext(ctx, query, secret.Key, secret.Iv, secret.KekId, secret.Id)
+	if err != nil {
+		return common.NewPostgresSecretsError("UpdateSecret", "Error updating secret, %s, received error, %v", secret.Id, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return common.NewPostgresSecretsError("UpdateSecret", "Error updating secret, %s, received error, %v", secret.Id, err)
+	}
+	if rowsAffected == 0 {
+		return common.NewPostgresSecretsError("UpdateSecret", "Secret %s not found", secret.Id)
+	}
+	return nil
+}
+
+func (s *PostgresSecretsManager) DeleteSecret(ctx context.Context, secretId string) error {
+	query := fmt.Sprintf(`
+	DELETE FROM %s
+	WHERE	id = $1
+	`, s.secretsTableName)
+	result, err := s.db.ExecContext(ctx, query, secretId)
+	if err != nil {
+		return common.NewPostgresSecretsError("DeleteSecret", "Error deleting secret, %s, received error, %v", secretId, err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return common.NewPostgresSecretsError("DeleteSecret", "Error deleting secret, %s, received error, %v", secretId, err)
+	}
+	if rowsAffected == 0 {
+		return common.NewResourceNotFoundError("DeleteSecret", "id", secretId)
+	}
+	return nil
+}
+
+func (s *PostgresSecretsManager) Close(_ context.Context) {
+	s.db.Close()
+}
+
+func (s *PostgresSecretsManager) LogAccess(ctx context.Context, log *common.AccessLog) error {
+	query := fmt.Sprintf(`
+	INSERT INTO %s (user_id, action_type, key_name, target_type, target_id, outcome, request_id, source_ip, user_agent, access_at, sequence, prev_hash, hash)
+	VALUES($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, NULLIF($11, 0), $12, $13)
+	`, s.accessLogTableName)
+	_, err := s.db.ExecContext(ctx, query, log.UserId, log.ActionType, log.KeyName, log.TargetType, log.TargetId, log.Outcome, log.RequestId, log.SourceIp, log.UserAgent, log.AccessAt.Time(), log.Sequence, log.PrevHash, log.Hash)
+	if pqErr, ok := err.(*pq.Error); ok && pqErr.Code == "23505" {
+		// another writer took the sequence first
+		return common.NewResourceAlreadyExistsError("LogAccess", pqErr.Detail)
+	}
+	if err != nil {
+		return common.NewPostgresSecretsError("LogAccess", "Error inserting log, %+v, received error, %v", log, err)
+	}
+	return nil
+}
+
+// accessLogFilters selects the logs matching a ListAccessLogsRequest, taking its filters as $1 to $10
+const accessLogFilters = `
+	WHERE	($1 = '' OR user_id = $1)
+		AND ($2 = '' OR action_type = $2)
+		AND ($3 = '' OR key_name = $3)
+		AND ($4 = '' OR target_type = $4)
+		AND ($5 = '' OR target_id = $5)
+		AND ($6 = '' OR request_id = $6)
+		AND ($7 = '' OR outcome = $7)
+		AND ($8 = '' OR source_ip = $8)
+		AND access_at >= $9
+		AND access_at <= $10
+`
+
+func accessLogFilterArgs(req *common.ListAccessLogsRequest) []interface{} {
+	return []interface{}{req.UserId, req.ActionType, req.KeyName, req.TargetType, req.TargetId, req.RequestId, req.Outcome, req.SourceIp, req.StartDate, req.EndDate}
+}
+
+func (s *PostgresSecretsManager) ListAccessLogs(ctx context.Context, req *common.ListAccessLogsRequest, limit int, after *common.PageCursor) ([]*common.AccessLog, error) {
+	op := "ListAccessLogs"
+	if req == nil {
+		return nil, common.NewPostgresSecretsError(op, "Request is nil")
+	}
+	args := accessLogFilterArgs(req)
+	afterId := ""
+	afterAccessAt := time.Time{}
+	if after != nil {
+		accessAt, err := common.AccessLogCursorTime(op, after)
+		if err != nil {
+			return nil, err
+		}
+		afterId = after.Id
+		afterAccessAt = accessAt.Time()
+	}
+	args = append(args, afterId, afterAccessAt, limit, req.Offset)
+
+	query := fmt.Sprintf(`
+	SELECT	id,
+			user_id,
+			action_type,
+			key_name,
+			target_type,
+			target_id,
+			outcome,
+			request_id,
+			source_ip,
+			user_agent,
+			access_at,
+			COALESCE(sequence, 0),
+			prev_hash,
+			hash
+	FROM	%s`+accessLogFilters+`
+		AND ($11 = '' OR (access_at, id) < ($12, NULLIF($11, '')::bigint))
+	ORDER BY access_at DESC, id DESC
+	LIMIT	$13
+	OFFSET	$14
+	`, s.accessLogTableName)
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, common.NewPostgresSecretsError(op, "Error finding logs: %s", err)
+	}
+	defer rows.Close()
+
+	logs := make([]*common.AccessLog, 0)
+	for rows.Next() {
+		row, err := scanAccessLog(rows)
+		if err != nil {
+			return nil, common.NewPostgresSecretsError(op, "Error decoding logs: %s", err)
+		}
+		logs = append(logs, row)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, common.NewPostgresSecretsError(op, "Error decoding logs: %s", err)
+	}
+	return logs, nil
+}
+
+func (s *PostgresSecretsManager) CountAccessLogs(ctx context.Context, req *common.ListAccessLogsRequest) (int, error) {
+	op := "CountAccessLogs"
+	if req == nil {
+		return 0, common.NewPostgresSecretsError(op, "Request is nil")
+	}
+	query := fmt.Sprintf(`
+	SELECT	COUNT(*)
+	FROM	%s`+accessLogFilters, s.accessLogTableName)
+	var total int
+	err := s.db.QueryRowContext(ctx, query, accessLogFilterArgs(req)...).Scan(&total)
+	if err != nil {
+		return 0, common.NewPostgresSecretsError(op, "Error counting logs: %s", err)
+	}
+	return total, nil
+}
+
+func (s *PostgresSecretsManager) GetLatestAccessLog(ctx context.Context) (*common.AccessLog, error) {
+	logs, err := s.listAccessLogChain(ctx, "GetLatestAccessLog", "sequence DESC", 0, 1)
+	if err != nil || len(logs) == 0 {
+		return nil, err
+	}
+	return logs[0], nil
+}
+
+func (s *PostgresSecretsManager) ListAccessLogChain(ctx context.Context, afterSequence int64, limit int) ([]*common.AccessLog, error) {
+	return s.listAccessLogChain(ctx, "ListAccessLogChain", "sequence", afterSequence, limit)
+}
+
+func (s *PostgresSecretsManager) listAccessLogChain(ctx context.Context, op string, orderBy string, afterSequence int64, limit int) ([]*common.AccessLog, error) {
+	query := fmt.Sprintf(`
+	SELECT	id,
+			user_id,
+			action_type,
+			key_name,
+			target_type,
+			target_id,
+			outcome,
+			request_id,
+			source_ip,
+			user_agent,
+			access_at,
+			sequence,
+			prev_hash,
+			hash
+	FROM	%s
+	WHERE	sequence > $1
+	ORDER BY %s
+	LIMIT	$2
+	`, s.accessLogTableName, orderBy)
+	rows, err := s.db.QueryContext(ctx, query, afterSequence, limit)
+	if err != nil {
+		return nil, common.NewPostgresSecretsError(op, "Error finding logs: %s", err)
+	}
+	defer rows.Close()
+
+	logs := make([]*common.AccessLog, 0)
+	for rows.Next() {
+		row, err := scanAccessLog(rows)
+		if err != nil {
+			return nil, common.NewPostgresSecretsError(op, "Error decoding logs: %s", err)
+		}
+		logs = append(logs, row)
+	}
+	err = rows.Err()
+	if err != nil {
+		return nil, common.NewPostgresSecretsError(op, "Error decoding logs: %s", err)
+	}
+	return logs, nil
+}
+
+func scanAccessLog(rows *sql.Rows) (*common.AccessLog, error) {
+	var row common.AccessLog
+	var accessAt time.Time
+	err := rows.Scan(&row.Id, &row.UserId, &row.ActionType, &row.KeyName, &row.TargetType, &row.TargetId, &row.Outcome, &row.RequestId, &row.SourceIp, &row.UserAgent, &accessAt, &row.Sequence, &row.PrevHash, &row.Hash)
+	if err != nil {
+		return nil, err
+	}
+	row.AccessAt = bsonPrimitive.NewDateTimeFromTime(accessAt)
+	return &row, nil
+}
+
+func NewPostgresSecretsManager(_ context.Context, opts PostgresSecretsOpts) (SecretsManager, error) {
+	sslMode := opts.SslMode
+	if sslMode == "" {
+		sslMode = "require"
+	}
+	connStr := fmt.Sprintf("postgres://%s:%s@%s/%s?sslmode=%s",
+		opts.Username,
+		opts.Password,
+		opts.Host,
+		opts.DatabaseName,
+		sslMode,
+	)
+	db, err := sql.Open("postgres", connStr)
+	if err != nil {
+		return nil, common.NewInitializationError("secrets manager", "Error during sql.Open: %v", err)
+	}
+	schemaName := opts.SchemaName
+	if schemaName == "" {
+		schemaName = "secrets"
+	}
+	return &PostgresSecretsManager{
+		db:                 db,
+		secretsTableName:   fmt.Sprintf("%s.encrypted_secrets", pq.QuoteIdentifier(schemaName)),
+		accessLogTableName: fmt.Sprintf("%s.access_logs", pq.QuoteIdentifier(schemaName)),
+	}, nil
+}
@@ -0,0 +1,627 @@
+package secrets
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/lib/pq"
+	"github.com/stretchr/testify/require"
+	bsonPrimitive "go.mongodb.org/mongo-driver/bson/primitive"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+type initFunc func(mock sqlmock.Sqlmock)
+
+var accessLogColumns = []string{"id", "user_id", "action_type", "key_name", "target_type", "target_id", "outcome", "request_id", "source_ip", "user_agent", "access_at", "sequence", "prev_hash", "hash"}
+
+var testSecret = &common.EncryptedSecret{Id: "secretId", Key: "key", Iv: "iv", KekId: "kekId"}
+
+var testAccessAt = time.Date(2021, 10, 1, 12, 0, 0, 0, time.UTC)
+
+var testAccessLog = &common.AccessLog{
+	Id:         "1",
+	UserId:     "userId",
+	ActionType: "GetSecret",
+	KeyName:    "default/secret",
+	TargetType: common.TARGET_TYPE_SECRET,
+	TargetId:   "default/secret",
+	Outcome:    "allowed",
+	RequestId:  "requestId",
+	SourceIp:   "10.0.0.1",
+	UserAgent:  "agent",
+	AccessAt:   bsonPrimitive.NewDateTimeFromTime(testAccessAt),
+	Sequence:   1,
+	PrevHash:   "",
+	Hash:       "hash",
+}
+
+// timeArg matches a time argument at the same instant, in any location
+type timeArg time.Time
+
+func (a timeArg) Match(v driver.Value) bool {
+	t, ok := v.(time.Time)
+	return ok && t.Equal(time.Time(a))
+}
+
+func addAccessLogRow(rows *sqlmock.Rows, log *common.AccessLog) *sqlmock.Rows {
+	return rows.AddRow(log.Id, log.UserId, log.ActionType, log.KeyName, log.TargetType, log.TargetId, log.Outcome, log.RequestId, log.SourceIp, log.UserAgent, log.AccessAt.Time(), log.Sequence, log.PrevHash, log.Hash)
+}
+
+func newMockPostgresSecretsManager(t *testing.T) (*PostgresSecretsManager, sqlmock.Sqlmock) {
+	db, mock, err := sqlmock.New()
+	require.Nil(t, err, "Unexpected err creating mock db: %v", err)
+	return &PostgresSecretsManager{
+		db:                 db,
+		secretsTableName:   "secrets.encrypted_secrets",
+		accessLogTableName: "secrets.access_logs",
+	}, mock
+}
+
+func TestPostgresGetSecretErrors(t *testing.T) {
+	var inits = []struct {
+		initFunc   initFunc
+		isNotFound bool
+	}{
+		{
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
+			},
+		},
+		{
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "key", "iv"}).AddRow("secretId", "key", "iv")).
+					RowsWillBeClosed()
+			},
+		},
+		{
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "key", "iv", "kek_id"}).
+						AddRow("secretId", "key", "iv", "kekId").
+						RowError(0, fmt.Errorf("oh no not the row"))).
+					RowsWillBeClosed()
+			},
+		},
+		{
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "key", "iv", "kek_id"})).
+					RowsWillBeClosed()
+			},
+			isNotFound: true,
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("PostgresSecretsManager.GetSecret - Errors - %v", idx), func(t *testing.T) {
+			secretsManager, mock := newMockPostgresSecretsManager(t)
+			given.initFunc(mock)
+
+			result, err := secretsManager.GetSecret(context.Background(), "secretId")
+			require.NotNil(t, err, "no error in GetSecret: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+			_, isNotFound := err.(common.ResourceNotFoundError)
+			require.Equal(t, isNotFound, given.isNotFound, "Error %v was not the expected type", err)
+			err = mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestPostgresGetSecretSuccesses(t *testing.T) {
+	var inits = []struct {
+		initFunc initFunc
+		expected *common.EncryptedSecret
+	}{
+		{
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM secrets.encrypted_secrets").
+					WithArgs("secretId").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "key", "iv", "kek_id"}).AddRow("secretId", "key", "iv", "kekId")).
+					RowsWillBeClosed()
+			},
+			expected: testSecret,
+		},
+		{
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM secrets.encrypted_secrets").
+					WithArgs("secretId").
+					WillReturnRows(sqlmock.NewRows([]string{"id", "key", "iv", "kek_id"}).AddRow("secretId", "key", "iv", "")).
+					RowsWillBeClosed()
+			},
+			expected: &common.EncryptedSecret{Id: "secretId", Key: "key", Iv: "iv"},
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("PostgresSecretsManager.GetSecret - Successes - %v", idx), func(t *testing.T) {
+			secretsManager, mock := newMockPostgresSecretsManager(t)
+			given.initFunc(mock)
+
+			result, err := secretsManager.GetSecret(context.Background(), "secretId")
+			require.Nil(t, err, "error in GetSecret: %v", err)
+			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
+			err = mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestPostgresCreateSecretErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(mock sqlmock.Sqlmock) {
+			mock.ExpectExec("INSERT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("PostgresSecretsManager.CreateSecret - Errors - %v", idx), func(t *testing.T) {
+			secretsManager, mock := newMockPostgresSecretsManager(t)
+			given(mock)
+
+			err := secretsManager.CreateSecret(context.Background(), testSecret)
+			require.NotNil(t, err, "no error in CreateSecret: %v", err)
+			err = mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestPostgresCreateSecretSuccesses(t *testing.T) {
+	var inits = []initFunc{
+		func(mock sqlmock.Sqlmock) {
+			mock.ExpectExec("INSERT INTO secrets.encrypted_secrets").
+				WithArgs("secretId", "key", "iv", "kekId").
+				WillReturnResult(sqlmock.NewResult(1, 1))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("PostgresSecretsManager.CreateSecret - Successes - %v", idx), func(t *testing.T) {
+			secretsManager, mock := newMockPostgresSecretsManager(t)
+			given(mock)
+
+			err := secretsManager.CreateSecret(context.Background(), testSecret)
+			require.Nil(t, err, "error in CreateSecret: %v", err)
+			err = mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestPostgresUpdateSecretErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(mock sqlmock.Sqlmock) {
+			mock.ExpectExec("UPDATE").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(mock sqlmock.Sqlmock) {
+			mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+		},
+		func(mock sqlmock.Sqlmock) {
+			mock.ExpectExec("UPDATE").WillReturnResult(sqlmock.NewResult(0, 0))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("PostgresSecretsManager.UpdateSecret - Errors - %v", idx), func(t *testing.T) {
+			secretsManager, mock := newMockPostgresSecretsManager(t)
+			given(mock)
+
+			err := secretsManager.UpdateSecret(context.Background(), testSecret)
+			require.NotNil(t, err, "no error in UpdateSecret: %v", err)
+			err = mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestPostgresUpdateSecretSuccesses(t *testing.T) {
+	var inits = []initFunc{
+		func(mock sqlmock.Sqlmock) {
+			mock.ExpectExec("UPDATE secrets.encrypted_secrets").
+				WithArgs("key", "iv", "kekId", "secretId").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("PostgresSecretsManager.UpdateSecret - Successes - %v", idx), func(t *testing.T) {
+			secretsManager, mock := newMockPostgresSecretsManager(t)
+			given(mock)
+
+			err := secretsManager.UpdateSecret(context.Background(), testSecret)
+			require.Nil(t, err, "error in UpdateSecret: %v", err)
+			err = mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestPostgresDeleteSecretErrors(t *testing.T) {
+	var inits = []struct {
+		initFunc   initFunc
+		isNotFound bool
+	}{
+		{
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE").WillReturnError(fmt.Errorf("Oh no!"))
+			},
+		},
+		{
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewErrorResult(fmt.Errorf("zoop")))
+			},
+		},
+		{
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("DELETE").WillReturnResult(sqlmock.NewResult(0, 0))
+			},
+			isNotFound: true,
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("PostgresSecretsManager.DeleteSecret - Errors - %v", idx), func(t *testing.T) {
+			secretsManager, mock := newMockPostgresSecretsManager(t)
+			given.initFunc(mock)
+
+			err := secretsManager.DeleteSecret(context.Background(), "secretId")
+			require.NotNil(t, err, "no error in DeleteSecret: %v", err)
+			_, isNotFound := err.(common.ResourceNotFoundError)
+			require.Equal(t, isNotFound, given.isNotFound, "Error %v was not the expected type", err)
+			err = mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestPostgresDeleteSecretSuccesses(t *testing.T) {
+	var inits = []initFunc{
+		func(mock sqlmock.Sqlmock) {
+			mock.ExpectExec("DELETE FROM secrets.encrypted_secrets").
+				WithArgs("secretId").
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("PostgresSecretsManager.DeleteSecret - Successes - %v", idx), func(t *testing.T) {
+			secretsManager, mock := newMockPostgresSecretsManager(t)
+			given(mock)
+
+			err := secretsManager.DeleteSecret(context.Background(), "secretId")
+			require.Nil(t, err, "error in DeleteSecret: %v", err)
+			err = mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestPostgresLogAccessErrors(t *testing.T) {
+	var inits = []struct {
+		initFunc   initFunc
+		isConflict bool
+	}{
+		{
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT").WillReturnError(fmt.Errorf("Oh no!"))
+			},
+		},
+		{
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT").WillReturnError(&pq.Error{Code: "23505", Detail: "Key (sequence)=(1) already exists."})
+			},
+			isConflict: true,
+		},
+		{
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT").WillReturnError(&pq.Error{Code: "23502"})
+			},
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("PostgresSecretsManager.LogAccess - Errors - %v", idx), func(t *testing.T) {
+			secretsManager, mock := newMockPostgresSecretsManager(t)
+			given.initFunc(mock)
+
+			err := secretsManager.LogAccess(context.Background(), testAccessLog)
+			require.NotNil(t, err, "no error in LogAccess: %v", err)
+			_, isConflict := err.(common.ResourceAlreadyExistsError)
+			require.Equal(t, isConflict, given.isConflict, "Error %v was not the expected type", err)
+			err = mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestPostgresLogAccessSuccesses(t *testing.T) {
+	unchained := &common.AccessLog{UserId: "userId", ActionType: "GetSecret", KeyName: "secret", AccessAt: testAccessLog.AccessAt}
+	var inits = []struct {
+		log      *common.AccessLog
+		initFunc initFunc
+	}{
+		{
+			log: testAccessLog,
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO secrets.access_logs").
+					WithArgs("userId", "GetSecret", "default/secret", common.TARGET_TYPE_SECRET, "default/secret", "allowed", "requestId", "10.0.0.1", "agent", timeArg(testAccessAt), int64(1), "", "hash").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+		{
+			log: unchained,
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectExec("INSERT INTO secrets.access_logs").
+					WithArgs("userId", "GetSecret", "secret", "", "", "", "", "", "", timeArg(testAccessAt), int64(0), "", "").
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			},
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("PostgresSecretsManager.LogAccess - Successes - %v", idx), func(t *testing.T) {
+			secretsManager, mock := newMockPostgresSecretsManager(t)
+			given.initFunc(mock)
+
+			err := secretsManager.LogAccess(context.Background(), given.log)
+			require.Nil(t, err, "error in LogAccess: %v", err)
+			err = mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestPostgresListAccessLogsErrors(t *testing.T) {
+	var tests = []struct {
+		req      *common.ListAccessLogsRequest
+		after    *common.PageCursor
+		initFunc initFunc
+	}{
+		{
+			req:      nil,
+			initFunc: func(mock sqlmock.Sqlmock) {},
+		},
+		{
+			req:      &common.ListAccessLogsRequest{},
+			after:    &common.PageCursor{Value: "not a time", Id: "1"},
+			initFunc: func(mock sqlmock.Sqlmock) {},
+		},
+		{
+			req: &common.ListAccessLogsRequest{},
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
+			},
+		},
+		{
+			req: &common.ListAccessLogsRequest{},
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT").
+					WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1")).
+					RowsWillBeClosed()
+			},
+		},
+		{
+			req: &common.ListAccessLogsRequest{},
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT").
+					WillReturnRows(addAccessLogRow(sqlmock.NewRows(accessLogColumns), testAccessLog).
+						RowError(0, fmt.Errorf("oh no not the row"))).
+					RowsWillBeClosed()
+			},
+		},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("PostgresSecretsManager.ListAccessLogs - Errors - %v", idx), func(t *testing.T) {
+			secretsManager, mock := newMockPostgresSecretsManager(t)
+			given.initFunc(mock)
+
+			result, err := secretsManager.ListAccessLogs(context.Background(), given.req, 10, given.after)
+			require.NotNil(t, err, "no error in ListAccessLogs: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+			err = mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestPostgresListAccessLogsSuccesses(t *testing.T) {
+	startDate := testAccessAt.Add(-time.Hour)
+	endDate := testAccessAt.Add(time.Hour)
+	req := &common.ListAccessLogsRequest{UserId: "userId", Outcome: "allowed", Offset: 5, StartDate: startDate, EndDate: endDate}
+	var tests = []struct {
+		after    *common.PageCursor
+		initFunc initFunc
+		expected []*common.AccessLog
+	}{
+		{
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM secrets.access_logs").
+					WithArgs("userId", "", "", "", "", "", "allowed", "", startDate, endDate, "", time.Time{}, 10, 5).
+					WillReturnRows(sqlmock.NewRows(accessLogColumns)).
+					RowsWillBeClosed()
+			},
+			expected: []*common.AccessLog{},
+		},
+		{
+			after: &common.PageCursor{Value: fmt.Sprintf("%d", int64(testAccessLog.AccessAt)), Id: "2"},
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) FROM secrets.access_logs").
+					WithArgs("userId", "", "", "", "", "", "allowed", "", startDate, endDate, "2", timeArg(testAccessAt), 10, 5).
+					WillReturnRows(addAccessLogRow(sqlmock.NewRows(accessLogColumns), testAccessLog)).
+					RowsWillBeClosed()
+			},
+			expected: []*common.AccessLog{testAccessLog},
+		},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("PostgresSecretsManager.ListAccessLogs - Successes - %v", idx), func(t *testing.T) {
+			secretsManager, mock := newMockPostgresSecretsManager(t)
+			given.initFunc(mock)
+
+			result, err := secretsManager.ListAccessLogs(context.Background(), req, 10, given.after)
+			require.Nil(t, err, "error in ListAccessLogs: %v", err)
+			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
+			err = mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestPostgresGetLatestAccessLogErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(mock sqlmock.Sqlmock) {
+			mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(mock sqlmock.Sqlmock) {
+			mock.ExpectQuery("SELECT").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1")).
+				RowsWillBeClosed()
+		},
+		func(mock sqlmock.Sqlmock) {
+			mock.ExpectQuery("SELECT").
+				WillReturnRows(addAccessLogRow(sqlmock.NewRows(accessLogColumns), testAccessLog).
+					RowError(0, fmt.Errorf("oh no not the row"))).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("PostgresSecretsManager.GetLatestAccessLog - Errors - %v", idx), func(t *testing.T) {
+			secretsManager, mock := newMockPostgresSecretsManager(t)
+			given(mock)
+
+			result, err := secretsManager.GetLatestAccessLog(context.Background())
+			require.NotNil(t, err, "no error in GetLatestAccessLog: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+			err = mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestPostgresGetLatestAccessLogSuccesses(t *testing.T) {
+	var inits = []struct {
+		initFunc initFunc
+		expected *common.AccessLog
+	}{
+		{
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) ORDER BY sequence DESC").
+					WithArgs(int64(0), 1).
+					WillReturnRows(sqlmock.NewRows(accessLogColumns)).
+					RowsWillBeClosed()
+			},
+			expected: nil,
+		},
+		{
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) ORDER BY sequence DESC").
+					WithArgs(int64(0), 1).
+					WillReturnRows(addAccessLogRow(sqlmock.NewRows(accessLogColumns), testAccessLog)).
+					RowsWillBeClosed()
+			},
+			expected: testAccessLog,
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("PostgresSecretsManager.GetLatestAccessLog - Successes - %v", idx), func(t *testing.T) {
+			secretsManager, mock := newMockPostgresSecretsManager(t)
+			given.initFunc(mock)
+
+			result, err := secretsManager.GetLatestAccessLog(context.Background())
+			require.Nil(t, err, "error in GetLatestAccessLog: %v", err)
+			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
+			err = mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestPostgresListAccessLogChainErrors(t *testing.T) {
+	var inits = []initFunc{
+		func(mock sqlmock.Sqlmock) {
+			mock.ExpectQuery("SELECT").WillReturnError(fmt.Errorf("Oh no!"))
+		},
+		func(mock sqlmock.Sqlmock) {
+			mock.ExpectQuery("SELECT").
+				WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow("1")).
+				RowsWillBeClosed()
+		},
+		func(mock sqlmock.Sqlmock) {
+			mock.ExpectQuery("SELECT").
+				WillReturnRows(addAccessLogRow(sqlmock.NewRows(accessLogColumns), testAccessLog).
+					RowError(0, fmt.Errorf("oh no not the row"))).
+				RowsWillBeClosed()
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("PostgresSecretsManager.ListAccessLogChain - Errors - %v", idx), func(t *testing.T) {
+			secretsManager, mock := newMockPostgresSecretsManager(t)
+			given(mock)
+
+			result, err := secretsManager.ListAccessLogChain(context.Background(), 0, 100)
+			require.NotNil(t, err, "no error in ListAccessLogChain: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+			err = mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
+
+func TestPostgresListAccessLogChainSuccesses(t *testing.T) {
+	second := *testAccessLog
+	second.Id = "2"
+	second.Sequence = 2
+	second.PrevHash = testAccessLog.Hash
+	second.Hash = "hash2"
+	var inits = []struct {
+		afterSequence int64
+		initFunc      initFunc
+		expected      []*common.AccessLog
+	}{
+		{
+			afterSequence: 0,
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) WHERE	sequence > (.+) ORDER BY sequence").
+					WithArgs(int64(0), 100).
+					WillReturnRows(addAccessLogRow(addAccessLogRow(sqlmock.NewRows(accessLogColumns), testAccessLog), &second)).
+					RowsWillBeClosed()
+			},
+			expected: []*common.AccessLog{testAccessLog, &second},
+		},
+		{
+			afterSequence: 2,
+			initFunc: func(mock sqlmock.Sqlmock) {
+				mock.ExpectQuery("SELECT (.+) WHERE	sequence > (.+) ORDER BY sequence").
+					WithArgs(int64(2), 100).
+					WillReturnRows(sqlmock.NewRows(accessLogColumns)).
+					RowsWillBeClosed()
+			},
+			expected: []*common.AccessLog{},
+		},
+	}
+
+	for idx, given := range inits {
+		t.Run(fmt.Sprintf("PostgresSecretsManager.ListAccessLogChain - Successes - %v", idx), func(t *testing.T) {
+			secretsManager, mock := newMockPostgresSecretsManager(t)
+			given.initFunc(mock)
+
+			result, err := secretsManager.ListAccessLogChain(context.Background(), given.afterSequence, 100)
+			require.Nil(t, err, "error in ListAccessLogChain: %v", err)
+			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
+			err = mock.ExpectationsWereMet()
+			require.Nil(t, err, "expectations not met: %v", err)
+		})
+	}
+}
@@ -8,22 +8,68 @@ import (
 
 type SecretsManager interface {
 	CreateSecret(ctx context.Context, secret *common.EncryptedSecret) error
+	UpdateSecret(ctx context.Context, secret *common.EncryptedSecret) error
+	// GetSecret fetches a data key. Returns a common.ResourceNotFoundError if there is no key with the id.
 	GetSecret(ctx context.Context, secretId string) (*common.EncryptedSecret, error)
+	// DeleteSecret removes a data key. Anything encrypted under it can't be decrypted again. Returns a
+	// common.ResourceNotFoundError if there is no key with the id.
+	DeleteSecret(ctx context.Context, secretId string) error
 	LogAccess(ctx context.Context, log *common.AccessLog) error
-	ListAccessLogs(ctx context.Context, req *common.ListAccessLogsRequest) ([]*common.AccessLog, error)
+	// ListAccessLogs returns up to limit logs matching req, newest first, starting after the cursor if one is given
+	ListAccessLogs(ctx context.Context, req *common.ListAccessLogsRequest, limit int, after *common.PageCursor) ([]*common.AccessLog, error)
+	// CountAccessLogs returns the number of logs matching req
+	CountAccessLogs(ctx context.Context, req *common.ListAccessLogsRequest) (int, error)
+	// GetLatestAccessLog returns the chained log with the highest sequence, or nil if there are none
+	GetLatestAccessLog(ctx context.Context) (*common.AccessLog, error)
+	// ListAccessLogChain returns up to limit chained logs with a sequence above afterSequence, in sequence order
+	ListAccessLogChain(ctx context.Context, afterSequence int64, limit int) ([]*common.AccessLog, error)
 	Close(ctx context.Context)
 }
 
 type SecretsManagerOpts struct {
-	ManagerType string           `yaml:"managerType"`
-	MongoOpts   MongoSecretsOpts `yaml:"mongoOpts"`
+	ManagerType  string              `yaml:"managerType"`
+	MongoOpts    MongoSecretsOpts    `yaml:"mongoOpts"`
+	PostgresOpts PostgresSecretsOpts `yaml:"postgresOpts"`
+	KekOpts      KekOpts             `yaml:"kekOpts"`
+	ChainKeyOpts KekOpts             `yaml:"chainKeyOpts"`
 }
 
-func NewSecretsManager(ctx context.Context, opts SecretsManagerOpts) (SecretsManager, error) {
+func newBaseSecretsManager(ctx context.Context, opts SecretsManagerOpts) (SecretsManager, error) {
 	switch opts.ManagerType {
 	case "mongodb":
 		return NewMongoSecretsManager(ctx, opts.MongoOpts)
+	case "postgres":
+		return NewPostgresSecretsManager(ctx, opts.PostgresOpts)
 	default:
 		return nil, common.NewInitializationError("secrets manager", "Unknown secrets manager type %s", opts.ManagerType)
 	}
 }
+
+// NewChainKey loads the key access logs are hashed under. It's configured like a KEK, and the current key is used.
+func NewChainKey(opts KekOpts) ([]byte, error) {
+	provider, err := NewKekProvider(opts)
+	if err != nil {
+		return nil, err
+	}
+	if provider == nil {
+		return nil, common.NewInitializationError("chain key", "secretsManagerOpts.chainKeyOpts must be set")
+	}
+	return provider.GetKey(provider.CurrentKeyId())
+}
+
+// NewSecretsManager creates the configured secrets manager, hashing its access logs under chainKey
+func NewSecretsManager(ctx context.Context, opts SecretsManagerOpts, chainKey []byte) (SecretsManager, error) {
+	baseSecretsManager, err := newBaseSecretsManager(ctx, opts)
+	if err != nil {
+		return nil, err
+	}
+	secretsManager := NewHashChainSecretsManager(baseSecretsManager, chainKey)
+	kek, err := NewKekProvider(opts.KekOpts)
+	if err != nil {
+		return nil, err
+	}
+	if kek == nil {
+		return secretsManager, nil
+	}
+	return NewEnvelopeSecretsManager(secretsManager, kek), nil
+}
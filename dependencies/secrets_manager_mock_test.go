@@ -0,0 +1,52 @@
+package dependencies
+
+import (
+	"context"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/dependencies/secrets"
+)
+
+// memorySecretsManager keeps data keys and access logs in memory. deleteErrs fails the deletion of the given keys.
+type memorySecretsManager struct {
+	secrets.SecretsManager
+	keys       map[string]*common.EncryptedSecret
+	deleteErrs map[string]error
+	logs       []*common.AccessLog
+}
+
+func newMemorySecretsManager() *memorySecretsManager {
+	return &memorySecretsManager{
+		keys:       make(map[string]*common.EncryptedSecret),
+		deleteErrs: make(map[string]error),
+	}
+}
+
+func (m *memorySecretsManager) GetSecret(_ context.Context, secretId string) (*common.EncryptedSecret, error) {
+	key, ok := m.keys[secretId]
+	if !ok {
+		return nil, common.NewResourceNotFoundError("GetSecret", "id", secretId)
+	}
+	return key, nil
+}
+
+func (m *memorySecretsManager) CreateSecret(_ context.Context, secret *common.EncryptedSecret) error {
+	m.keys[secret.Id] = secret
+	return nil
+}
+
+func (m *memorySecretsManager) DeleteSecret(_ context.Context, secretId string) error {
+	if err, ok := m.deleteErrs[secretId]; ok {
+		return err
+	}
+	if _, ok := m.keys[secretId]; !ok {
+		return common.NewResourceNotFoundError("DeleteSecret", "id", secretId)
+	}
+	delete(m.keys, secretId)
+	return nil
+}
+
+func (m *memorySecretsManager) LogAccess(_ context.Context, log *common.AccessLog) error {
+	m.logs = append(m.logs, log)
+	return nil
+}
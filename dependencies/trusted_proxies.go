@@ -0,0 +1,37 @@
+package dependencies
+
+import (
+	"net"
+	"strings"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// LoadTrustedProxies parses the configured trusted proxies, each an IP or a CIDR range. A bad entry stops the server
+// from starting.
+func LoadTrustedProxies(configured []string) ([]*net.IPNet, error) {
+	op := "trusted-proxies"
+	proxies := make([]*net.IPNet, 0, len(configured))
+	for _, entry := range configured {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, "/") {
+			ip := net.ParseIP(entry)
+			if ip == nil {
+				return nil, common.NewInitializationError(op, "Invalid trusted proxy %s", entry)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip = ip.To4()
+				bits = 8 * net.IPv4len
+			}
+			proxies = append(proxies, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, ipNet, err := net.ParseCIDR(entry)
+		if err != nil {
+			return nil, common.NewInitializationError(op, "Invalid trusted proxy %s: %v", entry, err)
+		}
+		proxies = append(proxies, ipNet)
+	}
+	return proxies, nil
+}
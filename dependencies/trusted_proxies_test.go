@@ -0,0 +1,70 @@
+package dependencies
+
+import (
+	"fmt"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLoadTrustedProxiesErrors(t *testing.T) {
+	var tests = [][]string{
+		{"not an ip"},
+		{"10.0.0.1/33"},
+		{"10.0.0.0/24", "10.0.0"},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("LoadTrustedProxies - Errors - %v", idx), func(t *testing.T) {
+			result, err := LoadTrustedProxies(given)
+			require.NotNil(t, err, "no error in LoadTrustedProxies: %v", err)
+			require.Nil(t, result, "Result was not nil: %v", result)
+		})
+	}
+}
+
+func TestLoadTrustedProxiesSuccesses(t *testing.T) {
+	var tests = []struct {
+		configured []string
+		trusted    []string
+		untrusted  []string
+	}{
+		{
+			configured: nil,
+			untrusted:  []string{"10.0.0.1"},
+		},
+		{
+			configured: []string{"10.0.0.1", " ::1 "},
+			trusted:    []string{"10.0.0.1", "::1"},
+			untrusted:  []string{"10.0.0.2", "::2"},
+		},
+		{
+			configured: []string{"10.0.0.0/24", "fd00::/8"},
+			trusted:    []string{"10.0.0.1", "10.0.0.255", "fd00::1"},
+			untrusted:  []string{"10.0.1.1", "fe80::1"},
+		},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("LoadTrustedProxies - Successes - %v", idx), func(t *testing.T) {
+			result, err := LoadTrustedProxies(given.configured)
+			require.Nil(t, err, "error in LoadTrustedProxies: %v", err)
+			for _, ip := range given.trusted {
+				require.True(t, containsIp(result, ip), "Expected %v to be trusted", ip)
+			}
+			for _, ip := range given.untrusted {
+				require.False(t, containsIp(result, ip), "Expected %v not to be trusted", ip)
+			}
+		})
+	}
+}
+
+func containsIp(proxies []*net.IPNet, ip string) bool {
+	for _, proxy := range proxies {
+		if proxy.Contains(net.ParseIP(ip)) {
+			return true
+		}
+	}
+	return false
+}
@@ -104,9 +104,7 @@ func (u *UserCache) Refresh(ctx context.Context, db *database.DatabaseEngine, da
 }
 
 func NewUserCache(ctx context.Context, logger *logrus.Logger, db *database.DatabaseEngine, dataRefreshSeconds int) (*UserCache, error) {
-	var m sync.Mutex
 	userCache := &UserCache{
-		m:       m,
 		logger:  logger,
 		users:   make(map[string]*common.User),
 		updates: make(chan UserCacheUpdate, 10),
@@ -124,9 +122,7 @@ func NewUserCache(ctx context.Context, logger *logrus.Logger, db *database.Datab
 }
 
 func NewMockUserCache(logger *logrus.Logger, users map[string]*common.User) *UserCache {
-	var m sync.Mutex
 	return &UserCache{
-		m:       m,
 		logger:  logger,
 		users:   users,
 		updates: make(chan UserCacheUpdate, 10),
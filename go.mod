@@ -13,6 +13,7 @@ require (
 	github.com/sirupsen/logrus v1.8.1
 	github.com/stretchr/testify v1.7.1
 	go.mongodb.org/mongo-driver v1.7.3
+	golang.org/x/crypto v0.0.0-20210921155107-089bfa567519
 	golang.org/x/tools v0.1.10 // indirect
 	gopkg.in/DataDog/dd-trace-go.v1 v1.37.1
 	gopkg.in/yaml.v2 v2.4.0
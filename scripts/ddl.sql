@@ -24,6 +24,18 @@ CREATE TABLE admin.user_type (
 INSERT INTO admin.user_type VALUES ('admin');
 INSERT INTO admin.user_type VALUES ('developer');
 
+CREATE TABLE admin.permission_level (
+    id TEXT PRIMARY KEY NOT NULL,
+    rank INTEGER NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL
+);
+
+COMMENT ON TABLE admin.permission_level IS 'Levels of access a secret permission can grant. A level includes every level with a lower rank.';
+
+INSERT INTO admin.permission_level VALUES ('read', 1);
+INSERT INTO admin.permission_level VALUES ('write', 2);
+INSERT INTO admin.permission_level VALUES ('manage', 3);
+
 CREATE TABLE admin.users (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
@@ -69,11 +81,59 @@ CREATE TRIGGER set_admin__access_tokens_timestamp
 EXECUTE PROCEDURE trigger_set_timestamp();
 
 
+CREATE TABLE admin.namespaces (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    created_by UUID REFERENCES admin.users(id),
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_by UUID REFERENCES admin.users(id),
+    is_active BOOLEAN NOT NULL DEFAULT true
+);
+
+CREATE TRIGGER set_admin__namespaces_timestamp
+    BEFORE UPDATE ON admin.namespaces
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+COMMENT ON TABLE admin.namespaces IS 'namespaces stores the namespaces secrets and user groups live in. Names only need to be unique within a namespace.';
+COMMENT ON COLUMN admin.namespaces.created_by IS 'Null for the built-in default namespace.';
+CREATE UNIQUE INDEX uq__admin__namespaces__name ON admin.namespaces(name) WHERE is_active;
+
+INSERT INTO admin.namespaces (id, name, description) VALUES ('00000000-0000-0000-0000-000000000000', 'default', 'Secrets and user groups created without a namespace');
+
+CREATE TABLE admin.namespace_admins (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    namespace_id UUID REFERENCES admin.namespaces(id) NOT NULL,
+    user_id UUID REFERENCES admin.users(id) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    created_by UUID REFERENCES admin.users(id) NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_by UUID REFERENCES admin.users(id) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true
+);
+
+CREATE TRIGGER set_admin__namespace_admins_timestamp
+    BEFORE UPDATE ON admin.namespace_admins
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+COMMENT ON TABLE admin.namespace_admins IS 'namespace_admins stores the users with admin access to the secrets, groups and grants of a namespace';
+CREATE UNIQUE INDEX uq__admin__namespace_admins__namespace_user ON admin.namespace_admins(namespace_id, user_id) WHERE is_active;
+
+
 CREATE TABLE admin.secrets (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    namespace_id UUID REFERENCES admin.namespaces(id) NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000',
     name TEXT NOT NULL,
-    value TEXT NOT NULL,
     description TEXT NOT NULL,
+    labels JSONB NOT NULL DEFAULT '{}',
+    current_version INTEGER NOT NULL DEFAULT 1,
+    expires_at TIMESTAMPTZ,
+    expired_at TIMESTAMPTZ,
+    deleted_at TIMESTAMPTZ,
+    purged_at TIMESTAMPTZ,
     created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
     created_by UUID REFERENCES admin.users(id) NOT NULL,
     updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
@@ -87,17 +147,54 @@ CREATE TRIGGER set_admin__secrets_timestamp
 EXECUTE PROCEDURE trigger_set_timestamp();
 
 COMMENT ON TABLE admin.secrets IS 'secrets stores all user created secrets for data being stored. Kept separate from information schema so we can log who did what.';
-CREATE UNIQUE INDEX uq__admin__secrets__name ON admin.secrets(name) WHERE is_active;
+CREATE UNIQUE INDEX uq__admin__secrets__namespace_name ON admin.secrets(namespace_id, name) WHERE is_active;
+COMMENT ON COLUMN admin.secrets.current_version IS 'The version in admin.secret_versions returned when a secret is fetched without an explicit version.';
+COMMENT ON COLUMN admin.secrets.expires_at IS 'Optional time after which the secret is hidden and then deactivated by the secret reaper. Null if the secret does not expire.';
+CREATE INDEX idx__admin__secrets__expires_at ON admin.secrets(expires_at) WHERE is_active;
+COMMENT ON COLUMN admin.secrets.expired_at IS 'When the secret reaper deactivated the secret because it expired. Null if the secret is active, or was deleted rather than expired.';
+COMMENT ON COLUMN admin.secrets.labels IS 'Free-form key=value labels, stored as a JSON object of strings. Used to filter secret listings.';
+CREATE INDEX idx__admin__secrets__labels ON admin.secrets USING GIN (labels);
+COMMENT ON COLUMN admin.secrets.purged_at IS 'When the deleted secret was purged. Its versions have no value and their data keys are deleted, so it can never be decrypted or restored. Null if it has not been purged.';
+COMMENT ON COLUMN admin.secrets.deleted_at IS 'When the secret was deleted, or deactivated by the secret reaper because it expired. Null if the secret is active. Purge retention is measured from it.';
+CREATE INDEX idx__admin__secrets__deleted_at ON admin.secrets(deleted_at) WHERE NOT is_active AND purged_at IS NULL;
+
+CREATE TABLE admin.secret_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    secret_id UUID REFERENCES admin.secrets(id) NOT NULL,
+    version INTEGER NOT NULL,
+    value TEXT,
+    value_type TEXT NOT NULL DEFAULT 'string',
+    content_type TEXT NOT NULL DEFAULT '',
+    filename TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    created_by UUID REFERENCES admin.users(id) NOT NULL,
+    rekeyed_at TIMESTAMPTZ
+);
+
+COMMENT ON TABLE admin.secret_versions IS 'secret_versions stores every encrypted value written to a secret. The id of each version is the id of its encryption key in the secrets manager.';
+COMMENT ON COLUMN admin.secret_versions.value IS 'The encrypted value. Null once the secret is purged.';
+COMMENT ON COLUMN admin.secret_versions.value_type IS 'string if value decrypts to a single string, fields if it decrypts to a JSON object of named fields, binary if it decrypts to the raw bytes of a file';
+COMMENT ON COLUMN admin.secret_versions.content_type IS 'content type of a binary secret, returned when it is downloaded. Empty for other secrets.';
+COMMENT ON COLUMN admin.secret_versions.filename IS 'filename of a binary secret, returned when it is downloaded. Empty for other secrets.';
+COMMENT ON COLUMN admin.secret_versions.rekeyed_at IS 'When the value was last re-encrypted under a new data key. Null if it still uses the key it was created with.';
+CREATE UNIQUE INDEX uq__admin__secret_versions__secret_version ON admin.secret_versions(secret_id, version);
+CREATE INDEX idx__admin__secret_versions__keyed_at ON admin.secret_versions(COALESCE(rekeyed_at, created_at));
 
 CREATE TABLE admin.secret_permissions (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     user_id UUID REFERENCES admin.users(id) NOT NULL,
-    secret_id UUID REFERENCES admin.secrets(id) NOT NULL,
+    secret_id UUID REFERENCES admin.secrets(id),
+    secret_name_pattern TEXT,
+    secret_name_like TEXT,
+    namespace_id UUID REFERENCES admin.namespaces(id),
+    level TEXT REFERENCES admin.permission_level(id) NOT NULL DEFAULT 'read',
     created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
     created_by UUID REFERENCES admin.users(id) NOT NULL,
     updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
     updated_by UUID REFERENCES admin.users(id) NOT NULL,
-    is_active BOOLEAN NOT NULL DEFAULT true
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    CHECK ((secret_id IS NULL) <> (secret_name_pattern IS NULL)),
+    CHECK ((namespace_id IS NULL) = (secret_name_pattern IS NULL))
 );
 
 CREATE TRIGGER set_admin__secret_permissions_timestamp
@@ -107,9 +204,15 @@ EXECUTE PROCEDURE trigger_set_timestamp();
 
 COMMENT ON TABLE admin.secret_permissions IS 'secret permissions stores all secret access permissions';
 CREATE UNIQUE INDEX uq__admin__secret_permissions__user_secret ON admin.secret_permissions(user_id, secret_id) WHERE is_active;
+COMMENT ON COLUMN admin.secret_permissions.secret_name_pattern IS 'A secret name glob (e.g. "payments/*"). Set instead of secret_id to grant access to every matching secret, including ones created later.';
+COMMENT ON COLUMN admin.secret_permissions.secret_name_like IS 'secret_name_pattern translated to a LIKE pattern.';
+COMMENT ON COLUMN admin.secret_permissions.level IS 'read allows fetching the secret, write allows updating it and manage allows granting permissions on it.';
+COMMENT ON COLUMN admin.secret_permissions.namespace_id IS 'The namespace a secret_name_pattern grant matches secrets in. Null for grants on a secret_id.';
+CREATE UNIQUE INDEX uq__admin__secret_permissions__user_namespace_pattern ON admin.secret_permissions(user_id, namespace_id, secret_name_pattern) WHERE is_active;
 
 CREATE TABLE admin.user_groups (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    namespace_id UUID REFERENCES admin.namespaces(id) NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000',
     name TEXT NOT NULL,
     created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
     created_by UUID REFERENCES admin.users(id) NOT NULL,
@@ -124,7 +227,7 @@ CREATE TRIGGER set_admin__user_groups_timestamp
 EXECUTE PROCEDURE trigger_set_timestamp();
 
 COMMENT ON TABLE admin.user_groups IS 'user_groups stores all distinct user permission groups';
-CREATE UNIQUE INDEX uq__admin__user_groups__name ON admin.user_groups(name) WHERE is_active;
+CREATE UNIQUE INDEX uq__admin__user_groups__namespace_name ON admin.user_groups(namespace_id, name) WHERE is_active;
 
 
 CREATE TABLE admin.user_group_members (
@@ -149,12 +252,18 @@ CREATE UNIQUE INDEX uq__admin__user_group_members__user_secret ON admin.user_gro
 CREATE TABLE admin.secret_group_permissions (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     user_group_id UUID REFERENCES admin.user_groups(id) NOT NULL,
-    secret_id UUID REFERENCES admin.secrets(id) NOT NULL,
+    secret_id UUID REFERENCES admin.secrets(id),
+    secret_name_pattern TEXT,
+    secret_name_like TEXT,
+    namespace_id UUID REFERENCES admin.namespaces(id),
+    level TEXT REFERENCES admin.permission_level(id) NOT NULL DEFAULT 'read',
     created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
     created_by UUID REFERENCES admin.users(id) NOT NULL,
     updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
     updated_by UUID REFERENCES admin.users(id) NOT NULL,
-    is_active BOOLEAN NOT NULL DEFAULT true
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    CHECK ((secret_id IS NULL) <> (secret_name_pattern IS NULL)),
+    CHECK ((namespace_id IS NULL) = (secret_name_pattern IS NULL))
 );
 
 CREATE TRIGGER set_admin__secret_group_permissions_timestamp
@@ -164,5 +273,150 @@ EXECUTE PROCEDURE trigger_set_timestamp();
 
 COMMENT ON TABLE admin.secret_group_permissions IS 'secret group permissions stores all secret access permissions for user groups';
 CREATE UNIQUE INDEX uq__admin__secret_group_permissions__user_group_secret ON admin.secret_group_permissions(user_group_id, secret_id) WHERE is_active;
+COMMENT ON COLUMN admin.secret_group_permissions.secret_name_pattern IS 'A secret name glob (e.g. "payments/*"). Set instead of secret_id to grant access to every matching secret, including ones created later.';
+COMMENT ON COLUMN admin.secret_group_permissions.secret_name_like IS 'secret_name_pattern translated to a LIKE pattern.';
+COMMENT ON COLUMN admin.secret_group_permissions.level IS 'read allows fetching the secret, write allows updating it and manage allows granting permissions on it.';
+COMMENT ON COLUMN admin.secret_group_permissions.namespace_id IS 'The namespace a secret_name_pattern grant matches secrets in. Null for grants on a secret_id.';
+CREATE UNIQUE INDEX uq__admin__secret_group_permissions__user_group_namespace_pattern ON admin.secret_group_permissions(user_group_id, namespace_id, secret_name_pattern) WHERE is_active;
+
+CREATE TABLE admin.database_roles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    connection_url TEXT NOT NULL,
+    creation_statements TEXT[] NOT NULL,
+    renew_statements TEXT[] NOT NULL,
+    revocation_statements TEXT[] NOT NULL,
+    default_ttl_seconds INTEGER NOT NULL,
+    max_ttl_seconds INTEGER NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    created_by UUID REFERENCES admin.users(id) NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_by UUID REFERENCES admin.users(id) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true
+);
+
+CREATE TRIGGER set_admin__database_roles_timestamp
+    BEFORE UPDATE ON admin.database_roles
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+COMMENT ON TABLE admin.database_roles IS 'database_roles stores the connections and SQL templates used to create dynamic database credentials';
+COMMENT ON COLUMN admin.database_roles.connection_url IS 'The encrypted connection URL of the database credentials are created on. The id of the role is the id of its encryption key in the secrets manager.';
+COMMENT ON COLUMN admin.database_roles.creation_statements IS 'SQL run to create credentials, with {{name}}, {{password}} and {{expiration}} placeholders';
+CREATE UNIQUE INDEX uq__admin__database_roles__name ON admin.database_roles(name) WHERE is_active;
+
+CREATE TABLE admin.database_role_permissions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    database_role_id UUID REFERENCES admin.database_roles(id) NOT NULL,
+    user_id UUID REFERENCES admin.users(id),
+    user_group_id UUID REFERENCES admin.user_groups(id),
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    created_by UUID REFERENCES admin.users(id) NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_by UUID REFERENCES admin.users(id) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    CHECK ((user_id IS NULL) <> (user_group_id IS NULL))
+);
+
+CREATE TRIGGER set_admin__database_role_permissions_timestamp
+    BEFORE UPDATE ON admin.database_role_permissions
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+COMMENT ON TABLE admin.database_role_permissions IS 'database_role_permissions stores the users and user groups allowed to fetch credentials from a database role';
+CREATE UNIQUE INDEX uq__admin__database_role_permissions__role_user ON admin.database_role_permissions(database_role_id, user_id) WHERE is_active;
+CREATE UNIQUE INDEX uq__admin__database_role_permissions__role_user_group ON admin.database_role_permissions(database_role_id, user_group_id) WHERE is_active;
+
+CREATE TABLE admin.leases (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    database_role_id UUID REFERENCES admin.database_roles(id) NOT NULL,
+    user_id UUID REFERENCES admin.users(id) NOT NULL,
+    username TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    max_expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL
+);
+
+CREATE TRIGGER set_admin__leases_timestamp
+    BEFORE UPDATE ON admin.leases
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+COMMENT ON TABLE admin.leases IS 'leases stores the dynamic database credentials created for users. The lease revoker drops the credentials once expires_at passes.';
+COMMENT ON COLUMN admin.leases.username IS 'The database username of the credentials. Passwords are never stored.';
+COMMENT ON COLUMN admin.leases.max_expires_at IS 'The latest expires_at a renewal can set, from the max_ttl_seconds of the role when the lease was created.';
+COMMENT ON COLUMN admin.leases.revoked_at IS 'When the credentials were dropped. Null while they are active.';
+CREATE INDEX idx__admin__leases__expires_at ON admin.leases(expires_at) WHERE revoked_at IS NULL;
+
+CREATE TABLE admin.transit_key_type (
+    id TEXT PRIMARY KEY NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL
+);
+
+COMMENT ON TABLE admin.transit_key_type IS 'Algorithms a transit key can use. aes256-gcm keys encrypt, and the rest sign.';
+
+INSERT INTO admin.transit_key_type VALUES ('aes256-gcm');
+INSERT INTO admin.transit_key_type VALUES ('ed25519');
+INSERT INTO admin.transit_key_type VALUES ('ecdsa-p256');
+INSERT INTO admin.transit_key_type VALUES ('rsa-pss-2048');
+INSERT INTO admin.transit_key_type VALUES ('rsa-pss-4096');
+INSERT INTO admin.transit_key_type VALUES ('hmac-sha256');
+
+CREATE TABLE admin.transit_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    type TEXT REFERENCES admin.transit_key_type(id) NOT NULL DEFAULT 'aes256-gcm',
+    latest_version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    created_by UUID REFERENCES admin.users(id) NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_by UUID REFERENCES admin.users(id) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true
+);
+
+CREATE TRIGGER set_admin__transit_keys_timestamp
+    BEFORE UPDATE ON admin.transit_keys
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+COMMENT ON TABLE admin.transit_keys IS 'transit_keys stores named keys that encrypt and decrypt data for users without the data being stored';
+COMMENT ON COLUMN admin.transit_keys.type IS 'The algorithm of every version of the key. It can''t be changed by rotation.';
+COMMENT ON COLUMN admin.transit_keys.latest_version IS 'The version new ciphertexts are encrypted with. Every earlier version can still decrypt.';
+CREATE UNIQUE INDEX uq__admin__transit_keys__name ON admin.transit_keys(name) WHERE is_active;
+
+CREATE TABLE admin.transit_key_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transit_key_id UUID REFERENCES admin.transit_keys(id) NOT NULL,
+    version INTEGER NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    created_by UUID REFERENCES admin.users(id) NOT NULL
+);
+
+COMMENT ON TABLE admin.transit_key_versions IS 'transit_key_versions stores each version of a transit key. The id of a version is the id of its key material in the secrets manager.';
+CREATE UNIQUE INDEX uq__admin__transit_key_versions__key_version ON admin.transit_key_versions(transit_key_id, version);
+
+CREATE TABLE admin.transit_key_permissions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transit_key_id UUID REFERENCES admin.transit_keys(id) NOT NULL,
+    user_id UUID REFERENCES admin.users(id),
+    user_group_id UUID REFERENCES admin.user_groups(id),
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    created_by UUID REFERENCES admin.users(id) NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_by UUID REFERENCES admin.users(id) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    CHECK ((user_id IS NULL) <> (user_group_id IS NULL))
+);
+
+CREATE TRIGGER set_admin__transit_key_permissions_timestamp
+    BEFORE UPDATE ON admin.transit_key_permissions
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+COMMENT ON TABLE admin.transit_key_permissions IS 'transit_key_permissions stores the users and user groups allowed to encrypt and decrypt with a transit key';
+CREATE UNIQUE INDEX uq__admin__transit_key_permissions__key_user ON admin.transit_key_permissions(transit_key_id, user_id) WHERE is_active;
+CREATE UNIQUE INDEX uq__admin__transit_key_permissions__key_user_group ON admin.transit_key_permissions(transit_key_id, user_group_id) WHERE is_active;
 
 COMMIT;
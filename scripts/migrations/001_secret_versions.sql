@@ -0,0 +1,26 @@
+-- Moves secret values into admin.secret_versions. Existing values become version 1,
+-- keyed by the secret id so they keep using the encryption key already in the secrets manager.
+BEGIN;
+
+ALTER TABLE admin.secrets ADD COLUMN current_version INTEGER NOT NULL DEFAULT 1;
+COMMENT ON COLUMN admin.secrets.current_version IS 'The version in admin.secret_versions returned when a secret is fetched without an explicit version.';
+
+CREATE TABLE admin.secret_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    secret_id UUID REFERENCES admin.secrets(id) NOT NULL,
+    version INTEGER NOT NULL,
+    value TEXT NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    created_by UUID REFERENCES admin.users(id) NOT NULL
+);
+
+COMMENT ON TABLE admin.secret_versions IS 'secret_versions stores every encrypted value written to a secret. The id of each version is the id of its encryption key in the secrets manager.';
+CREATE UNIQUE INDEX uq__admin__secret_versions__secret_version ON admin.secret_versions(secret_id, version);
+
+INSERT INTO admin.secret_versions (id, secret_id, version, value, created_at, created_by)
+SELECT  id, id, 1, value, updated_at, updated_by
+FROM    admin.secrets;
+
+ALTER TABLE admin.secrets DROP COLUMN value;
+
+COMMIT;
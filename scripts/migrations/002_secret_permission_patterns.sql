@@ -0,0 +1,20 @@
+-- Allows user and group permissions to be granted on a secret name pattern instead of a single secret.
+BEGIN;
+
+ALTER TABLE admin.secret_permissions ALTER COLUMN secret_id DROP NOT NULL;
+ALTER TABLE admin.secret_permissions ADD COLUMN secret_name_pattern TEXT;
+ALTER TABLE admin.secret_permissions ADD COLUMN secret_name_like TEXT;
+ALTER TABLE admin.secret_permissions ADD CHECK ((secret_id IS NULL) <> (secret_name_pattern IS NULL));
+COMMENT ON COLUMN admin.secret_permissions.secret_name_pattern IS 'A secret name glob (e.g. "payments/*"). Set instead of secret_id to grant access to every matching secret, including ones created later.';
+COMMENT ON COLUMN admin.secret_permissions.secret_name_like IS 'secret_name_pattern translated to a LIKE pattern.';
+CREATE UNIQUE INDEX uq__admin__secret_permissions__user_pattern ON admin.secret_permissions(user_id, secret_name_pattern) WHERE is_active;
+
+ALTER TABLE admin.secret_group_permissions ALTER COLUMN secret_id DROP NOT NULL;
+ALTER TABLE admin.secret_group_permissions ADD COLUMN secret_name_pattern TEXT;
+ALTER TABLE admin.secret_group_permissions ADD COLUMN secret_name_like TEXT;
+ALTER TABLE admin.secret_group_permissions ADD CHECK ((secret_id IS NULL) <> (secret_name_pattern IS NULL));
+COMMENT ON COLUMN admin.secret_group_permissions.secret_name_pattern IS 'A secret name glob (e.g. "payments/*"). Set instead of secret_id to grant access to every matching secret, including ones created later.';
+COMMENT ON COLUMN admin.secret_group_permissions.secret_name_like IS 'secret_name_pattern translated to a LIKE pattern.';
+CREATE UNIQUE INDEX uq__admin__secret_group_permissions__user_group_pattern ON admin.secret_group_permissions(user_group_id, secret_name_pattern) WHERE is_active;
+
+COMMIT;
@@ -0,0 +1,22 @@
+-- Adds read/write/manage levels to secret permissions. Existing grants, on a single secret or a pattern, stay read only.
+BEGIN;
+
+CREATE TABLE admin.permission_level (
+    id TEXT PRIMARY KEY NOT NULL,
+    rank INTEGER NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL
+);
+
+COMMENT ON TABLE admin.permission_level IS 'Levels of access a secret permission can grant. A level includes every level with a lower rank.';
+
+INSERT INTO admin.permission_level VALUES ('read', 1);
+INSERT INTO admin.permission_level VALUES ('write', 2);
+INSERT INTO admin.permission_level VALUES ('manage', 3);
+
+ALTER TABLE admin.secret_permissions ADD COLUMN level TEXT REFERENCES admin.permission_level(id) NOT NULL DEFAULT 'read';
+COMMENT ON COLUMN admin.secret_permissions.level IS 'read allows fetching the secret, write allows updating it and manage allows granting permissions on it.';
+
+ALTER TABLE admin.secret_group_permissions ADD COLUMN level TEXT REFERENCES admin.permission_level(id) NOT NULL DEFAULT 'read';
+COMMENT ON COLUMN admin.secret_group_permissions.level IS 'read allows fetching the secret, write allows updating it and manage allows granting permissions on it.';
+
+COMMIT;
@@ -0,0 +1,11 @@
+-- Adds an optional expiry time to secrets, and records when the secret reaper deactivated an expired secret, so expired
+-- secrets can be told apart from deleted ones.
+BEGIN;
+
+ALTER TABLE admin.secrets ADD COLUMN expires_at TIMESTAMPTZ;
+COMMENT ON COLUMN admin.secrets.expires_at IS 'Optional time after which the secret is hidden and then deactivated by the secret reaper. Null if the secret does not expire.';
+CREATE INDEX idx__admin__secrets__expires_at ON admin.secrets(expires_at) WHERE is_active;
+ALTER TABLE admin.secrets ADD COLUMN expired_at TIMESTAMPTZ;
+COMMENT ON COLUMN admin.secrets.expired_at IS 'When the secret reaper deactivated the secret because it expired. Null if the secret is active, or was deleted rather than expired.';
+
+COMMIT;
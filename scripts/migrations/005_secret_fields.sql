@@ -0,0 +1,7 @@
+-- Adds multi-field secrets. Every existing version holds a single string value.
+BEGIN;
+
+ALTER TABLE admin.secret_versions ADD COLUMN value_type TEXT NOT NULL DEFAULT 'string';
+COMMENT ON COLUMN admin.secret_versions.value_type IS 'string if value decrypts to a single string, fields if it decrypts to a JSON object of named fields';
+
+COMMIT;
@@ -0,0 +1,10 @@
+-- Adds file secrets, which hold raw bytes with the content type and filename they were uploaded with
+BEGIN;
+
+ALTER TABLE admin.secret_versions ADD COLUMN content_type TEXT NOT NULL DEFAULT '';
+ALTER TABLE admin.secret_versions ADD COLUMN filename TEXT NOT NULL DEFAULT '';
+COMMENT ON COLUMN admin.secret_versions.content_type IS 'content type of a binary secret, returned when it is downloaded. Empty for other secrets.';
+COMMENT ON COLUMN admin.secret_versions.filename IS 'filename of a binary secret, returned when it is downloaded. Empty for other secrets.';
+COMMENT ON COLUMN admin.secret_versions.value_type IS 'string if value decrypts to a single string, fields if it decrypts to a JSON object of named fields, binary if it decrypts to the raw bytes of a file';
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Adds free-form key=value labels to secrets, used to filter secret listings
+BEGIN;
+
+ALTER TABLE admin.secrets ADD COLUMN labels JSONB NOT NULL DEFAULT '{}';
+COMMENT ON COLUMN admin.secrets.labels IS 'Free-form key=value labels, stored as a JSON object of strings. Used to filter secret listings.';
+CREATE INDEX idx__admin__secrets__labels ON admin.secrets USING GIN (labels);
+
+COMMIT;
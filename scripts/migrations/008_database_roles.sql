@@ -0,0 +1,75 @@
+-- Adds database roles, which create dynamic database credentials, and the leases that track them
+BEGIN;
+
+CREATE TABLE admin.database_roles (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    connection_url TEXT NOT NULL,
+    creation_statements TEXT[] NOT NULL,
+    renew_statements TEXT[] NOT NULL,
+    revocation_statements TEXT[] NOT NULL,
+    default_ttl_seconds INTEGER NOT NULL,
+    max_ttl_seconds INTEGER NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    created_by UUID REFERENCES admin.users(id) NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_by UUID REFERENCES admin.users(id) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true
+);
+
+CREATE TRIGGER set_admin__database_roles_timestamp
+    BEFORE UPDATE ON admin.database_roles
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+COMMENT ON TABLE admin.database_roles IS 'database_roles stores the connections and SQL templates used to create dynamic database credentials';
+COMMENT ON COLUMN admin.database_roles.connection_url IS 'The encrypted connection URL of the database credentials are created on. The id of the role is the id of its encryption key in the secrets manager.';
+COMMENT ON COLUMN admin.database_roles.creation_statements IS 'SQL run to create credentials, with {{name}}, {{password}} and {{expiration}} placeholders';
+CREATE UNIQUE INDEX uq__admin__database_roles__name ON admin.database_roles(name) WHERE is_active;
+
+CREATE TABLE admin.database_role_permissions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    database_role_id UUID REFERENCES admin.database_roles(id) NOT NULL,
+    user_id UUID REFERENCES admin.users(id),
+    user_group_id UUID REFERENCES admin.user_groups(id),
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    created_by UUID REFERENCES admin.users(id) NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_by UUID REFERENCES admin.users(id) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    CHECK ((user_id IS NULL) <> (user_group_id IS NULL))
+);
+
+CREATE TRIGGER set_admin__database_role_permissions_timestamp
+    BEFORE UPDATE ON admin.database_role_permissions
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+COMMENT ON TABLE admin.database_role_permissions IS 'database_role_permissions stores the users and user groups allowed to fetch credentials from a database role';
+CREATE UNIQUE INDEX uq__admin__database_role_permissions__role_user ON admin.database_role_permissions(database_role_id, user_id) WHERE is_active;
+CREATE UNIQUE INDEX uq__admin__database_role_permissions__role_user_group ON admin.database_role_permissions(database_role_id, user_group_id) WHERE is_active;
+
+CREATE TABLE admin.leases (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    database_role_id UUID REFERENCES admin.database_roles(id) NOT NULL,
+    user_id UUID REFERENCES admin.users(id) NOT NULL,
+    username TEXT NOT NULL,
+    expires_at TIMESTAMPTZ NOT NULL,
+    max_expires_at TIMESTAMPTZ NOT NULL,
+    revoked_at TIMESTAMPTZ,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL
+);
+
+CREATE TRIGGER set_admin__leases_timestamp
+    BEFORE UPDATE ON admin.leases
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+COMMENT ON TABLE admin.leases IS 'leases stores the dynamic database credentials created for users. The lease revoker drops the credentials once expires_at passes.';
+COMMENT ON COLUMN admin.leases.username IS 'The database username of the credentials. Passwords are never stored.';
+COMMENT ON COLUMN admin.leases.max_expires_at IS 'The latest expires_at a renewal can set, from the max_ttl_seconds of the role when the lease was created.';
+COMMENT ON COLUMN admin.leases.revoked_at IS 'When the credentials were dropped. Null while they are active.';
+CREATE INDEX idx__admin__leases__expires_at ON admin.leases(expires_at) WHERE revoked_at IS NULL;
+
+COMMIT;
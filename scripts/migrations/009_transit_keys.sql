@@ -0,0 +1,57 @@
+-- Adds transit keys, which encrypt and decrypt data for users without storing it
+BEGIN;
+
+CREATE TABLE admin.transit_keys (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    latest_version INTEGER NOT NULL DEFAULT 1,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    created_by UUID REFERENCES admin.users(id) NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_by UUID REFERENCES admin.users(id) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true
+);
+
+CREATE TRIGGER set_admin__transit_keys_timestamp
+    BEFORE UPDATE ON admin.transit_keys
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+COMMENT ON TABLE admin.transit_keys IS 'transit_keys stores named keys that encrypt and decrypt data for users without the data being stored';
+COMMENT ON COLUMN admin.transit_keys.latest_version IS 'The version new ciphertexts are encrypted with. Every earlier version can still decrypt.';
+CREATE UNIQUE INDEX uq__admin__transit_keys__name ON admin.transit_keys(name) WHERE is_active;
+
+CREATE TABLE admin.transit_key_versions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transit_key_id UUID REFERENCES admin.transit_keys(id) NOT NULL,
+    version INTEGER NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    created_by UUID REFERENCES admin.users(id) NOT NULL
+);
+
+COMMENT ON TABLE admin.transit_key_versions IS 'transit_key_versions stores each version of a transit key. The id of a version is the id of its key material in the secrets manager.';
+CREATE UNIQUE INDEX uq__admin__transit_key_versions__key_version ON admin.transit_key_versions(transit_key_id, version);
+
+CREATE TABLE admin.transit_key_permissions (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    transit_key_id UUID REFERENCES admin.transit_keys(id) NOT NULL,
+    user_id UUID REFERENCES admin.users(id),
+    user_group_id UUID REFERENCES admin.user_groups(id),
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    created_by UUID REFERENCES admin.users(id) NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_by UUID REFERENCES admin.users(id) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true,
+    CHECK ((user_id IS NULL) <> (user_group_id IS NULL))
+);
+
+CREATE TRIGGER set_admin__transit_key_permissions_timestamp
+    BEFORE UPDATE ON admin.transit_key_permissions
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+COMMENT ON TABLE admin.transit_key_permissions IS 'transit_key_permissions stores the users and user groups allowed to encrypt and decrypt with a transit key';
+CREATE UNIQUE INDEX uq__admin__transit_key_permissions__key_user ON admin.transit_key_permissions(transit_key_id, user_id) WHERE is_active;
+CREATE UNIQUE INDEX uq__admin__transit_key_permissions__key_user_group ON admin.transit_key_permissions(transit_key_id, user_group_id) WHERE is_active;
+
+COMMIT;
@@ -0,0 +1,21 @@
+-- Adds key types to transit keys, so they can sign and verify as well as encrypt
+BEGIN;
+
+CREATE TABLE admin.transit_key_type (
+    id TEXT PRIMARY KEY NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL
+);
+
+COMMENT ON TABLE admin.transit_key_type IS 'Algorithms a transit key can use. aes256-gcm keys encrypt, and the rest sign.';
+
+INSERT INTO admin.transit_key_type VALUES ('aes256-gcm');
+INSERT INTO admin.transit_key_type VALUES ('ed25519');
+INSERT INTO admin.transit_key_type VALUES ('ecdsa-p256');
+INSERT INTO admin.transit_key_type VALUES ('rsa-pss-2048');
+INSERT INTO admin.transit_key_type VALUES ('rsa-pss-4096');
+INSERT INTO admin.transit_key_type VALUES ('hmac-sha256');
+
+ALTER TABLE admin.transit_keys ADD COLUMN type TEXT REFERENCES admin.transit_key_type(id) NOT NULL DEFAULT 'aes256-gcm';
+COMMENT ON COLUMN admin.transit_keys.type IS 'The algorithm of every version of the key. It can''t be changed by rotation.';
+
+COMMIT;
@@ -0,0 +1,8 @@
+-- Tracks when each secret version was last re-encrypted under a new data key, so old keys can be rotated
+BEGIN;
+
+ALTER TABLE admin.secret_versions ADD COLUMN rekeyed_at TIMESTAMPTZ;
+COMMENT ON COLUMN admin.secret_versions.rekeyed_at IS 'When the value was last re-encrypted under a new data key. Null if it still uses the key it was created with.';
+CREATE INDEX idx__admin__secret_versions__keyed_at ON admin.secret_versions(COALESCE(rekeyed_at, created_at));
+
+COMMIT;
@@ -0,0 +1,68 @@
+-- Adds namespaces, so teams can reuse secret and group names. Existing secrets, groups and pattern grants move to the
+-- default namespace.
+BEGIN;
+
+CREATE TABLE admin.namespaces (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    name TEXT NOT NULL,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    created_by UUID REFERENCES admin.users(id),
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_by UUID REFERENCES admin.users(id),
+    is_active BOOLEAN NOT NULL DEFAULT true
+);
+
+CREATE TRIGGER set_admin__namespaces_timestamp
+    BEFORE UPDATE ON admin.namespaces
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+COMMENT ON TABLE admin.namespaces IS 'namespaces stores the namespaces secrets and user groups live in. Names only need to be unique within a namespace.';
+COMMENT ON COLUMN admin.namespaces.created_by IS 'Null for the built-in default namespace.';
+CREATE UNIQUE INDEX uq__admin__namespaces__name ON admin.namespaces(name) WHERE is_active;
+
+INSERT INTO admin.namespaces (id, name, description) VALUES ('00000000-0000-0000-0000-000000000000', 'default', 'Secrets and user groups created without a namespace');
+
+CREATE TABLE admin.namespace_admins (
+    id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+    namespace_id UUID REFERENCES admin.namespaces(id) NOT NULL,
+    user_id UUID REFERENCES admin.users(id) NOT NULL,
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    created_by UUID REFERENCES admin.users(id) NOT NULL,
+    updated_at TIMESTAMPTZ DEFAULT now() NOT NULL,
+    updated_by UUID REFERENCES admin.users(id) NOT NULL,
+    is_active BOOLEAN NOT NULL DEFAULT true
+);
+
+CREATE TRIGGER set_admin__namespace_admins_timestamp
+    BEFORE UPDATE ON admin.namespace_admins
+    FOR EACH ROW
+EXECUTE PROCEDURE trigger_set_timestamp();
+
+COMMENT ON TABLE admin.namespace_admins IS 'namespace_admins stores the users with admin access to the secrets, groups and grants of a namespace';
+CREATE UNIQUE INDEX uq__admin__namespace_admins__namespace_user ON admin.namespace_admins(namespace_id, user_id) WHERE is_active;
+
+ALTER TABLE admin.secrets ADD COLUMN namespace_id UUID REFERENCES admin.namespaces(id) NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000';
+DROP INDEX admin.uq__admin__secrets__name;
+CREATE UNIQUE INDEX uq__admin__secrets__namespace_name ON admin.secrets(namespace_id, name) WHERE is_active;
+
+ALTER TABLE admin.user_groups ADD COLUMN namespace_id UUID REFERENCES admin.namespaces(id) NOT NULL DEFAULT '00000000-0000-0000-0000-000000000000';
+DROP INDEX admin.uq__admin__user_groups__name;
+CREATE UNIQUE INDEX uq__admin__user_groups__namespace_name ON admin.user_groups(namespace_id, name) WHERE is_active;
+
+ALTER TABLE admin.secret_permissions ADD COLUMN namespace_id UUID REFERENCES admin.namespaces(id);
+UPDATE admin.secret_permissions SET namespace_id = '00000000-0000-0000-0000-000000000000' WHERE secret_name_pattern IS NOT NULL;
+ALTER TABLE admin.secret_permissions ADD CHECK ((namespace_id IS NULL) = (secret_name_pattern IS NULL));
+COMMENT ON COLUMN admin.secret_permissions.namespace_id IS 'The namespace a secret_name_pattern grant matches secrets in. Null for grants on a secret_id.';
+DROP INDEX admin.uq__admin__secret_permissions__user_pattern;
+CREATE UNIQUE INDEX uq__admin__secret_permissions__user_namespace_pattern ON admin.secret_permissions(user_id, namespace_id, secret_name_pattern) WHERE is_active;
+
+ALTER TABLE admin.secret_group_permissions ADD COLUMN namespace_id UUID REFERENCES admin.namespaces(id);
+UPDATE admin.secret_group_permissions SET namespace_id = '00000000-0000-0000-0000-000000000000' WHERE secret_name_pattern IS NOT NULL;
+ALTER TABLE admin.secret_group_permissions ADD CHECK ((namespace_id IS NULL) = (secret_name_pattern IS NULL));
+COMMENT ON COLUMN admin.secret_group_permissions.namespace_id IS 'The namespace a secret_name_pattern grant matches secrets in. Null for grants on a secret_id.';
+DROP INDEX admin.uq__admin__secret_group_permissions__user_group_pattern;
+CREATE UNIQUE INDEX uq__admin__secret_group_permissions__user_group_namespace_pattern ON admin.secret_group_permissions(user_group_id, namespace_id, secret_name_pattern) WHERE is_active;
+
+COMMIT;
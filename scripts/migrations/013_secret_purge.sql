@@ -0,0 +1,16 @@
+-- Lets deleted secrets be purged: their data keys are deleted and their ciphertext cleared, leaving a tombstone
+BEGIN;
+
+ALTER TABLE admin.secrets ADD COLUMN purged_at TIMESTAMPTZ;
+COMMENT ON COLUMN admin.secrets.purged_at IS 'When the deleted secret was purged. Its versions have no value and their data keys are deleted, so it can never be decrypted or restored. Null if it has not been purged.';
+
+ALTER TABLE admin.secrets ADD COLUMN deleted_at TIMESTAMPTZ;
+COMMENT ON COLUMN admin.secrets.deleted_at IS 'When the secret was deleted, or deactivated by the secret reaper because it expired. Null if the secret is active. Purge retention is measured from it.';
+-- Nothing updated a deleted secret before purging existed, so its updated_at is when it was deleted
+UPDATE admin.secrets SET deleted_at = COALESCE(expired_at, updated_at) WHERE NOT is_active;
+CREATE INDEX idx__admin__secrets__deleted_at ON admin.secrets(deleted_at) WHERE NOT is_active AND purged_at IS NULL;
+
+ALTER TABLE admin.secret_versions ALTER COLUMN value DROP NOT NULL;
+COMMENT ON COLUMN admin.secret_versions.value IS 'The encrypted value. Null once the secret is purged.';
+
+COMMIT;
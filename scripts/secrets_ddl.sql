@@ -0,0 +1,49 @@
+-- This DDL is for the Postgres secrets manager (secretsManagerOpts.managerType: postgres)
+-- Run it against a database or schema kept separate from the core database, so that
+-- encrypted values and their keys never live in the same place.
+BEGIN;
+
+CREATE SCHEMA IF NOT EXISTS secrets;
+COMMENT ON SCHEMA secrets IS 'Secrets schema contains encryption keys for secrets and the access log';
+
+CREATE TABLE secrets.encrypted_secrets (
+    id TEXT PRIMARY KEY,
+    key TEXT NOT NULL,
+    iv TEXT NOT NULL,
+    kek_id TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMPTZ DEFAULT now() NOT NULL
+);
+
+COMMENT ON TABLE secrets.encrypted_secrets IS 'encrypted_secrets stores the encryption key and IV for each secret version, keyed by the version id';
+COMMENT ON COLUMN secrets.encrypted_secrets.kek_id IS 'Id of the key encryption key that wrapped key. Empty if key is stored unwrapped.';
+
+CREATE TABLE secrets.access_logs (
+    id BIGSERIAL PRIMARY KEY,
+    user_id TEXT NOT NULL,
+    action_type TEXT NOT NULL,
+    key_name TEXT NOT NULL,
+    target_type TEXT NOT NULL DEFAULT '',
+    target_id TEXT NOT NULL DEFAULT '',
+    outcome TEXT NOT NULL DEFAULT '',
+    request_id TEXT NOT NULL DEFAULT '',
+    source_ip TEXT NOT NULL DEFAULT '',
+    user_agent TEXT NOT NULL DEFAULT '',
+    access_at TIMESTAMPTZ NOT NULL,
+    sequence BIGINT UNIQUE,
+    prev_hash TEXT NOT NULL DEFAULT '',
+    hash TEXT NOT NULL DEFAULT ''
+);
+
+COMMENT ON TABLE secrets.access_logs IS 'access_logs stores a record of every mutating operation, and every secret read, performed against the API';
+COMMENT ON COLUMN secrets.access_logs.outcome IS 'allowed, denied, not_found or error';
+COMMENT ON COLUMN secrets.access_logs.request_id IS 'X-Request-Id header of the request, or a generated id if it was not set';
+COMMENT ON COLUMN secrets.access_logs.source_ip IS 'Client IP. The connection IP, unless the connection is from a trusted proxy, then the last untrusted X-Forwarded-For hop or X-Real-Ip';
+COMMENT ON COLUMN secrets.access_logs.sequence IS 'Position of the log in the hash chain. Null if the log was written without chaining.';
+COMMENT ON COLUMN secrets.access_logs.prev_hash IS 'hash of the log at the previous sequence. Empty for the first log in the chain.';
+COMMENT ON COLUMN secrets.access_logs.hash IS 'HMAC-SHA256 of the log content, sequence and prev_hash, under the chain key, which is kept out of this database';
+
+CREATE INDEX idx__secrets__access_logs__user_access_at ON secrets.access_logs(user_id, access_at DESC);
+CREATE INDEX idx__secrets__access_logs__target_access_at ON secrets.access_logs(target_type, target_id, access_at DESC);
+CREATE INDEX idx__secrets__access_logs__outcome_access_at ON secrets.access_logs(outcome, access_at DESC);
+
+COMMIT;
@@ -10,9 +10,65 @@ import (
 	"github.com/emarcey/data-vault/common"
 )
 
+func parseAccessLogFilters(ctx context.Context, op string, r *http.Request) (*common.ListAccessLogsRequest, error) {
+	paginationInterface, err := decodePaginationRequest(op)(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	pagination, ok := paginationInterface.(*PaginationRequest)
+	if !ok {
+		return nil, common.NewInvalidParamsError(op, "Expected pagination of type *PaginationRequest Got %T", paginationInterface)
+	}
+
+	urlParams := r.URL.Query()
+	startDate, err := parseDateUrlParam(op, urlParams, "startDate", common.DEFAULT_START_TIME)
+	if err != nil {
+		return nil, err
+	}
+
+	endDate, err := parseDateUrlParam(op, urlParams, "endDate", time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	req := &common.ListAccessLogsRequest{
+		PageSize:     pagination.PageSize,
+		Offset:       pagination.Offset,
+		Cursor:       pagination.Cursor,
+		IncludeTotal: pagination.IncludeTotal,
+		StartDate:    startDate,
+		EndDate:      endDate,
+	}
+	filters := []struct {
+		paramName string
+		value     *string
+	}{
+		{"userId", &req.UserId},
+		{"actionType", &req.ActionType},
+		{"keyName", &req.KeyName},
+		{"targetType", &req.TargetType},
+		{"targetId", &req.TargetId},
+		{"requestId", &req.RequestId},
+		{"outcome", &req.Outcome},
+		{"sourceIp", &req.SourceIp},
+	}
+	for _, filter := range filters {
+		*filter.value, err = parseStringUrlParam(op, urlParams, filter.paramName, "")
+		if err != nil {
+			return nil, err
+		}
+	}
+	return req, nil
+}
+
 func decodeAccessLogsRequest(op string) httptransport.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		return parseAccessLogFilters(ctx, op, r)
+	}
+}
+
+func decodeUserAccessLogsRequest(op string) httptransport.DecodeRequestFunc {
 	userIdDecoder := decodeRequestUrlId(op)
-	paginationDecoder := decodePaginationRequest(op)
 	return func(ctx context.Context, r *http.Request) (interface{}, error) {
 		userIdInterface, err := userIdDecoder(ctx, r)
 		if err != nil {
@@ -23,49 +79,53 @@ func decodeAccessLogsRequest(op string) httptransport.DecodeRequestFunc {
 			return nil, common.NewInvalidParamsError(op, "Expected id of type string Got %T", userIdInterface)
 		}
 
-		paginationInterface, err := paginationDecoder(ctx, r)
-		if err != nil {
-			return nil, err
-		}
-		pagination := paginationInterface.(*PaginationRequest)
-		if !ok {
-			return nil, common.NewInvalidParamsError(op, "Expected pagination of type string Got %T", paginationInterface)
-		}
-
-		urlParams := r.URL.Query()
-		startDate, err := parseDateUrlParam(op, urlParams, "startDate", common.DEFAULT_START_TIME)
+		req, err := parseAccessLogFilters(ctx, op, r)
 		if err != nil {
 			return nil, err
 		}
-
-		endDate, err := parseDateUrlParam(op, urlParams, "endDate", time.Now())
-		if err != nil {
-			return nil, err
-		}
-
-		return &common.ListAccessLogsRequest{
-			UserId:    userId,
-			PageSize:  pagination.PageSize,
-			Offset:    pagination.Offset,
-			StartDate: startDate,
-			EndDate:   endDate,
-		}, nil
+		req.UserId = userId
+		return req, nil
 	}
 }
 
-func listAccessLogsEndpoint(s Service) endpointBuilder {
-	op := "ListAccessLogs"
-	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+func makeListAccessLogsEndpoint(s Service, op string) func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+	return func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
 		req, ok := reqInterface.(*common.ListAccessLogsRequest)
 		if !ok {
 			return nil, common.NewInvalidParamsError(op, "Expected request of type *common.ListAccessLogsRequest Got %T", reqInterface)
 		}
 		return s.ListAccessLogs(ctx, req)
 	}
+}
+
+func listAccessLogsEndpoint(s Service) endpointBuilder {
+	op := "ListAccessLogs"
 	return endpointBuilder{
-		endpoint: e,
+		endpoint: makeListAccessLogsEndpoint(s, op),
 		decoder:  decodeAccessLogsRequest(op),
 		method:   HTTP_GET,
+		path:     "/access-logs",
+	}
+}
+
+func listUserAccessLogsEndpoint(s Service) endpointBuilder {
+	op := "ListUserAccessLogs"
+	return endpointBuilder{
+		endpoint: makeListAccessLogsEndpoint(s, op),
+		decoder:  decodeUserAccessLogsRequest(op),
+		method:   HTTP_GET,
 		path:     "/users/{id}/access-logs",
 	}
 }
+
+func verifyAccessLogsEndpoint(s Service) endpointBuilder {
+	e := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return s.VerifyAccessLogs(ctx)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  noOpDecodeRequest,
+		method:   HTTP_GET,
+		path:     "/access-logs/verify",
+	}
+}
@@ -0,0 +1,226 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+func listDatabaseRolesEndpoint(s Service) endpointBuilder {
+	op := "ListDatabaseRoles"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*PaginationRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *PaginationRequest. Got %T", reqInterface)
+		}
+		return s.ListDatabaseRoles(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodePaginationRequest(op),
+		method:   HTTP_GET,
+		path:     "/database-roles",
+	}
+}
+
+func getDatabaseRoleEndpoint(s Service) endpointBuilder {
+	op := "GetDatabaseRole"
+	e := func(ctx context.Context, nameInterface interface{}) (interface{}, error) {
+		name, ok := nameInterface.(string)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected database role name of type string. Got %T", nameInterface)
+		}
+		return s.GetDatabaseRole(ctx, name)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeRequestUrlName(op),
+		method:   HTTP_GET,
+		path:     "/database-roles/{name}",
+	}
+}
+
+func decodeCreateDatabaseRoleRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	var req CreateDatabaseRoleRequest
+	err = json.Unmarshal(data, &req)
+	if err != nil {
+		// the body has the connection URL in it, so it isn't echoed back
+		return nil, common.NewInvalidParamsError("CreateDatabaseRole", "Could not unmarshal request")
+	}
+	return &req, nil
+}
+
+func createDatabaseRoleEndpoint(s Service) endpointBuilder {
+	op := "CreateDatabaseRole"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*CreateDatabaseRoleRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *CreateDatabaseRoleRequest. Got %T", reqInterface)
+		}
+		return s.CreateDatabaseRole(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeCreateDatabaseRoleRequest,
+		method:   HTTP_POST,
+		path:     "/database-roles",
+	}
+}
+
+func deleteDatabaseRoleEndpoint(s Service) endpointBuilder {
+	op := "DeleteDatabaseRole"
+	e := func(ctx context.Context, nameInterface interface{}) (interface{}, error) {
+		name, ok := nameInterface.(string)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected database role name of type string. Got %T", nameInterface)
+		}
+		return nil, s.DeleteDatabaseRole(ctx, name)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeRequestUrlName(op),
+		method:   HTTP_DELETE,
+		path:     "/database-roles/{name}",
+	}
+}
+
+var decodeDatabaseRolePermissionUrl = decodeRequestUrlName("DatabaseRolePermission")
+
+func decodeDatabaseRolePermissionRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	op := "DatabaseRolePermission"
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	var req DatabaseRolePermissionRequest
+	err = json.Unmarshal(data, &req)
+	if err != nil {
+		return nil, common.NewInvalidParamsError(op, "Could not unmarshal request: %v", string(data))
+	}
+	roleName, err := decodeDatabaseRolePermissionUrl(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	req.RoleName = roleName.(string)
+	return &req, nil
+}
+
+func createDatabaseRolePermissionEndpoint(s Service) endpointBuilder {
+	op := "GrantDatabaseRolePermission"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*DatabaseRolePermissionRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *DatabaseRolePermissionRequest. Got %T", reqInterface)
+		}
+		err := s.GrantDatabaseRolePermission(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return NewStatusResponse(), nil
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeDatabaseRolePermissionRequest,
+		method:   HTTP_POST,
+		path:     "/database-roles/{name}/permissions",
+	}
+}
+
+func deleteDatabaseRolePermissionEndpoint(s Service) endpointBuilder {
+	op := "RevokeDatabaseRolePermission"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*DatabaseRolePermissionRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *DatabaseRolePermissionRequest. Got %T", reqInterface)
+		}
+		return nil, s.RevokeDatabaseRolePermission(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeDatabaseRolePermissionRequest,
+		method:   HTTP_DELETE,
+		path:     "/database-roles/{name}/permissions",
+	}
+}
+
+func getDatabaseCredentialsEndpoint(s Service) endpointBuilder {
+	op := "GetDatabaseCredentials"
+	e := func(ctx context.Context, nameInterface interface{}) (interface{}, error) {
+		name, ok := nameInterface.(string)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected database role name of type string. Got %T", nameInterface)
+		}
+		return s.GetDatabaseCredentials(ctx, name)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeRequestUrlName(op),
+		method:   HTTP_GET,
+		path:     "/database-roles/{name}/credentials",
+	}
+}
+
+var decodeRenewLeaseUrl = decodeRequestUrlId("RenewLease")
+
+// decodeRenewLeaseRequest accepts an empty body, which renews by the role's default TTL
+func decodeRenewLeaseRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	op := "RenewLease"
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	var req RenewLeaseRequest
+	if len(data) > 0 {
+		err = json.Unmarshal(data, &req)
+		if err != nil {
+			return nil, common.NewInvalidParamsError(op, "Could not unmarshal request: %v", string(data))
+		}
+	}
+	leaseId, err := decodeRenewLeaseUrl(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	req.LeaseId = leaseId.(string)
+	return &req, nil
+}
+
+func renewLeaseEndpoint(s Service) endpointBuilder {
+	op := "RenewLease"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*RenewLeaseRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *RenewLeaseRequest. Got %T", reqInterface)
+		}
+		return s.RenewLease(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeRenewLeaseRequest,
+		method:   HTTP_PUT,
+		path:     "/leases/{id}/renew",
+	}
+}
+
+func revokeLeaseEndpoint(s Service) endpointBuilder {
+	op := "RevokeLease"
+	e := func(ctx context.Context, leaseIdInterface interface{}) (interface{}, error) {
+		leaseId, ok := leaseIdInterface.(string)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected lease ID of type string. Got %T", leaseIdInterface)
+		}
+		return nil, s.RevokeLease(ctx, leaseId)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeRequestUrlId(op),
+		method:   HTTP_DELETE,
+		path:     "/leases/{id}",
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCreateDatabaseRoleRequest(t *testing.T) {
+	body := `{"name": "app", "connection_url": "postgres://vault@db/app", "creation_statements": ["CREATE ROLE \"{{name}}\";"], "default_ttl_seconds": 60}`
+	r := httptest.NewRequest(HTTP_POST, "/database-roles", strings.NewReader(body))
+	result, err := decodeCreateDatabaseRoleRequest(context.Background(), r)
+	require.Nil(t, err, "Unexpected error in decodeCreateDatabaseRoleRequest: %v", err)
+	expected := &CreateDatabaseRoleRequest{
+		Name:               "app",
+		ConnectionUrl:      "postgres://vault@db/app",
+		CreationStatements: []string{`CREATE ROLE "{{name}}";`},
+		DefaultTtlSeconds:  60,
+	}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+
+	r = httptest.NewRequest(HTTP_POST, "/database-roles", strings.NewReader(`{"connection_url": "postgres://vault:hunter2@db/app"`))
+	result, err = decodeCreateDatabaseRoleRequest(context.Background(), r)
+	require.NotNil(t, err, "no error in decodeCreateDatabaseRoleRequest")
+	require.Nil(t, result, "Result was not nil: %v", result)
+	require.NotContains(t, err.Error(), "hunter2", "Error echoed the connection URL: %v", err)
+}
+
+func TestDecodeDatabaseRolePermissionRequest(t *testing.T) {
+	r := httptest.NewRequest(HTTP_POST, "/database-roles/app/permissions", strings.NewReader(`{"user_group_id": "groupId"}`))
+	r = mux.SetURLVars(r, map[string]string{"name": "app"})
+	result, err := decodeDatabaseRolePermissionRequest(context.Background(), r)
+	require.Nil(t, err, "Unexpected error in decodeDatabaseRolePermissionRequest: %v", err)
+	expected := &DatabaseRolePermissionRequest{RoleName: "app", UserGroupId: "groupId"}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+}
+
+func TestDecodeRenewLeaseRequest(t *testing.T) {
+	var tests = []struct {
+		body     string
+		expected *RenewLeaseRequest
+	}{
+		{
+			body:     "",
+			expected: &RenewLeaseRequest{LeaseId: "leaseId"},
+		},
+		{
+			body:     `{"increment_seconds": 600}`,
+			expected: &RenewLeaseRequest{LeaseId: "leaseId", IncrementSeconds: 600},
+		},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("decodeRenewLeaseRequest - Successes - %v", idx), func(t *testing.T) {
+			r := httptest.NewRequest(HTTP_PUT, "/leases/leaseId/renew", strings.NewReader(given.body))
+			r = mux.SetURLVars(r, map[string]string{"id": "leaseId"})
+			result, err := decodeRenewLeaseRequest(context.Background(), r)
+			require.Nil(t, err, "Unexpected error in decodeRenewLeaseRequest: %v", err)
+			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
+		})
+	}
+
+	r := httptest.NewRequest(HTTP_PUT, "/leases/leaseId/renew", strings.NewReader(`{"increment_seconds": "soon"}`))
+	r = mux.SetURLVars(r, map[string]string{"id": "leaseId"})
+	result, err := decodeRenewLeaseRequest(context.Background(), r)
+	require.NotNil(t, err, "no error in decodeRenewLeaseRequest")
+	require.Nil(t, result, "Result was not nil: %v", result)
+}
@@ -0,0 +1,138 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// decodeRestoreRequest reads the id of the record to restore from the url, and an optional new name from the body
+func decodeRestoreRequest(op string) httptransport.DecodeRequestFunc {
+	decodeUrlId := decodeRequestUrlId(op)
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		data, err := ioutil.ReadAll(r.Body)
+		if err != nil {
+			return nil, err
+		}
+		var req RestoreRequest
+		if len(data) > 0 {
+			err = json.Unmarshal(data, &req)
+			if err != nil {
+				return nil, common.NewInvalidParamsError(op, "Could not unmarshal request: %v", string(data))
+			}
+		}
+		id, err := decodeUrlId(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		req.Id = id.(string)
+		return &req, nil
+	}
+}
+
+func listDeletedSecretsEndpoint(s Service) endpointBuilder {
+	op := "ListDeletedSecrets"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*PaginationRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *PaginationRequest. Got %T", reqInterface)
+		}
+		return s.ListDeletedSecrets(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodePaginationRequest(op),
+		method:   HTTP_GET,
+		path:     "/deleted/secrets",
+	}
+}
+
+func restoreSecretEndpoint(s Service) endpointBuilder {
+	op := "RestoreSecret"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*RestoreRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *RestoreRequest. Got %T", reqInterface)
+		}
+		return s.RestoreSecret(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeRestoreRequest(op),
+		method:   HTTP_POST,
+		path:     "/deleted/secrets/{id}/restore",
+	}
+}
+
+func listDeletedUserGroupsEndpoint(s Service) endpointBuilder {
+	op := "ListDeletedUserGroups"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*PaginationRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *PaginationRequest. Got %T", reqInterface)
+		}
+		return s.ListDeletedUserGroups(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodePaginationRequest(op),
+		method:   HTTP_GET,
+		path:     "/deleted/user-groups",
+	}
+}
+
+func restoreUserGroupEndpoint(s Service) endpointBuilder {
+	op := "RestoreUserGroup"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*RestoreRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *RestoreRequest. Got %T", reqInterface)
+		}
+		return s.RestoreUserGroup(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeRestoreRequest(op),
+		method:   HTTP_POST,
+		path:     "/deleted/user-groups/{id}/restore",
+	}
+}
+
+func listDeletedUsersEndpoint(s Service) endpointBuilder {
+	op := "ListDeletedUsers"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*PaginationRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *PaginationRequest. Got %T", reqInterface)
+		}
+		return s.ListDeletedUsers(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodePaginationRequest(op),
+		method:   HTTP_GET,
+		path:     "/deleted/users",
+	}
+}
+
+func restoreUserEndpoint(s Service) endpointBuilder {
+	op := "RestoreUser"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*RestoreRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *RestoreRequest. Got %T", reqInterface)
+		}
+		return s.RestoreUser(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeRestoreRequest(op),
+		method:   HTTP_POST,
+		path:     "/deleted/users/{id}/restore",
+	}
+}
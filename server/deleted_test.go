@@ -0,0 +1,50 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeRestoreRequest(t *testing.T) {
+	var tests = []struct {
+		body     string
+		expected *RestoreRequest
+	}{
+		{
+			body:     "",
+			expected: &RestoreRequest{Id: "secretId"},
+		},
+		{
+			body:     `{"name": "newName"}`,
+			expected: &RestoreRequest{Id: "secretId", Name: "newName"},
+		},
+	}
+
+	decoder := decodeRestoreRequest("RestoreSecret")
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("decodeRestoreRequest - Successes - %v", idx), func(t *testing.T) {
+			r := httptest.NewRequest(HTTP_POST, "/deleted/secrets/secretId/restore", strings.NewReader(given.body))
+			r = mux.SetURLVars(r, map[string]string{"id": "secretId"})
+			result, err := decoder(context.Background(), r)
+			require.Nil(t, err, "Unexpected error in decodeRestoreRequest: %v", err)
+			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
+		})
+	}
+
+	r := httptest.NewRequest(HTTP_POST, "/deleted/secrets/secretId/restore", strings.NewReader(`{"name": 1}`))
+	r = mux.SetURLVars(r, map[string]string{"id": "secretId"})
+	result, err := decoder(context.Background(), r)
+	require.NotNil(t, err, "no error in decodeRestoreRequest")
+	require.Nil(t, result, "Result was not nil: %v", result)
+
+	r = httptest.NewRequest(HTTP_POST, "/deleted/secrets//restore", strings.NewReader(""))
+	result, err = decoder(context.Background(), r)
+	require.NotNil(t, err, "no error in decodeRestoreRequest")
+	require.Nil(t, result, "Result was not nil: %v", result)
+}
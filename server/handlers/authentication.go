@@ -40,6 +40,19 @@ func authenticateClient(ctx context.Context, op string, tracer tracer.Tracer, au
 	return user, nil
 }
 
+// logFailedAuthentication records a denied access log against the user a request tried to authenticate as, if known.
+// Failures from a client IP over the limiter's cap are only counted.
+func logFailedAuthentication(ctx context.Context, op string, deps *dependencies.Dependencies, userId string) {
+	if !deps.FailedAuthLogs.Allow(common.FetchRequestSourceFromContext(ctx).Ip) {
+		return
+	}
+	log := common.NewAuditLog(ctx, userId, "Authenticate", common.TARGET_TYPE_ENDPOINT, op, common.NewAuthorizationError())
+	err := deps.SecretsManager.LogAccess(ctx, log)
+	if err != nil {
+		deps.Logger.Errorf("Error logging failed authentication %s: %v", op, err)
+	}
+}
+
 // EndpointClientAuthenticationWrapper validates request authentication by client id/secret, with admin check optional
 func EndpointClientAuthenticationWrapper(e endpoint.Endpoint, op string, deps *dependencies.Dependencies, checkAdmin bool) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
@@ -50,6 +63,8 @@ func EndpointClientAuthenticationWrapper(e endpoint.Endpoint, op string, deps *d
 		if err != nil {
 			tracer.CaptureException(err)
 			deps.Logger.Errorf("Error authenticating %s: %v", op, err)
+			clientId, _ := common.FetchStringFromContextHeaders(ctx, common.HEADER_CLIENT_ID)
+			logFailedAuthentication(tracer.Context(), op, deps, clientId)
 			return nil, common.NewAuthorizationError()
 		}
 		newCtx := common.InjectUserIntoContext(tracer.Context(), user)
@@ -90,6 +105,19 @@ func authenticateAccessToken(
 	return user, nil
 }
 
+// accessTokenUserId returns the user the request's access token was issued to, or an empty string if it's unknown
+func accessTokenUserId(ctx context.Context, accessTokens *dependencies.AccessTokenCache) string {
+	authTokenRaw, err := common.FetchStringFromContextHeaders(ctx, common.HEADER_ACCESS_TOKEN)
+	if err != nil {
+		return ""
+	}
+	accessToken := accessTokens.Get(common.HashSha256(authTokenRaw))
+	if accessToken == nil {
+		return ""
+	}
+	return accessToken.UserId
+}
+
 // EndpointAccessTokenAuthenticationWrapper validates request authentication by access token
 func EndpointAccessTokenAuthenticationWrapper(e endpoint.Endpoint, op string, deps *dependencies.Dependencies, checkAdmin bool) endpoint.Endpoint {
 	return func(ctx context.Context, request interface{}) (interface{}, error) {
@@ -100,6 +128,7 @@ func EndpointAccessTokenAuthenticationWrapper(e endpoint.Endpoint, op string, de
 		if err != nil {
 			tracer.CaptureException(err)
 			deps.Logger.Errorf("Error authenticating %s: %v", op, err)
+			logFailedAuthentication(tracer.Context(), op, deps, accessTokenUserId(ctx, deps.AccessTokens))
 			return nil, common.NewAuthorizationError()
 		}
 		newCtx := common.InjectUserIntoContext(tracer.Context(), user)
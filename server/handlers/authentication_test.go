@@ -13,6 +13,7 @@ import (
 	"github.com/emarcey/data-vault/common"
 	"github.com/emarcey/data-vault/common/tracer"
 	"github.com/emarcey/data-vault/dependencies"
+	"github.com/emarcey/data-vault/dependencies/secrets"
 )
 
 var devUser = &common.User{
@@ -280,3 +281,81 @@ func TestAuthenticateAccessTokenSuccesses(t *testing.T) {
 		})
 	}
 }
+
+func TestAccessTokenUserId(t *testing.T) {
+	var tests = []struct {
+		testName string
+		ctx      context.Context
+		expected string
+	}{
+		{
+			testName: "no header",
+			ctx:      context.Background(),
+			expected: "",
+		},
+		{
+			testName: "unknown token",
+			ctx: common.InjectHeaderIntoContext(context.Background(), &http.Request{
+				Header: map[string][]string{
+					"Access-Token": []string{"zoop"},
+				},
+			}),
+			expected: "",
+		},
+		{
+			testName: "token without user",
+			ctx: common.InjectHeaderIntoContext(context.Background(), &http.Request{
+				Header: map[string][]string{
+					"Access-Token": []string{"hangingAccessToken"},
+				},
+			}),
+			expected: "noUser",
+		},
+		{
+			testName: "dev user",
+			ctx: common.InjectHeaderIntoContext(context.Background(), &http.Request{
+				Header: map[string][]string{
+					"Access-Token": []string{"devAccessToken"},
+				},
+			}),
+			expected: devUser.Id,
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("accessTokenUserId - %v", given.testName), func(t *testing.T) {
+			result := accessTokenUserId(given.ctx, accessTokenCache)
+			require.Equal(t, result, given.expected, "Result %v did not equal expected %v", result, given.expected)
+		})
+	}
+}
+
+// countingSecretsManager counts the access logs written to it
+type countingSecretsManager struct {
+	secrets.SecretsManager
+	logs []*common.AccessLog
+}
+
+func (m *countingSecretsManager) LogAccess(_ context.Context, log *common.AccessLog) error {
+	m.logs = append(m.logs, log)
+	return nil
+}
+
+func TestLogFailedAuthenticationLimitsEachSource(t *testing.T) {
+	secretsManager := &countingSecretsManager{}
+	deps := &dependencies.Dependencies{
+		Logger:         testLogger,
+		SecretsManager: secretsManager,
+		FailedAuthLogs: dependencies.NewFailedAuthLimiter(testLogger, 2),
+	}
+	flooding := common.InjectRequestSourceIntoContext(context.Background(), &common.RequestSource{Ip: "10.0.0.1"})
+	other := common.InjectRequestSourceIntoContext(context.Background(), &common.RequestSource{Ip: "10.0.0.2"})
+
+	for idx := 0; idx < 5; idx++ {
+		logFailedAuthentication(flooding, "op", deps, devUser.Id)
+	}
+	logFailedAuthentication(other, "op", deps, devUser.Id)
+
+	require.Equal(t, len(secretsManager.logs), 3, "Logs %v did not equal expected 3", len(secretsManager.logs))
+	require.Equal(t, secretsManager.logs[2].SourceIp, "10.0.0.2", "Source %v did not equal expected 10.0.0.2", secretsManager.logs[2].SourceIp)
+}
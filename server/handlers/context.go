@@ -2,10 +2,13 @@ package handlers
 
 import (
 	"context"
+	"net"
 	"net/http"
+	"strings"
 
 	"github.com/emarcey/data-vault/common"
 	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
 )
 
 // WriteHeadersToContext populates the context with values from the request header
@@ -14,3 +17,77 @@ func WriteHeadersToContext() httptransport.RequestFunc {
 		return common.InjectHeaderIntoContext(ctx, r)
 	}
 }
+
+// WriteRequestIdToContext populates the context with the request id from the X-Request-Id header, generating one if it's not set
+func WriteRequestIdToContext() httptransport.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		requestId := r.Header.Get(common.HEADER_REQUEST_ID)
+		if requestId == "" {
+			requestId = common.GenUuid()
+		}
+		return common.InjectRequestIdIntoContext(ctx, requestId)
+	}
+}
+
+// isTrustedProxy reports whether ip is one of the trusted proxies
+func isTrustedProxy(ip net.IP, trustedProxies []*net.IPNet) bool {
+	if ip == nil {
+		return false
+	}
+	for _, proxy := range trustedProxies {
+		if proxy.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// requestSourceIp returns the client IP. The X-Forwarded-For and X-Real-Ip headers are only believed if the connection
+// is from a trusted proxy, since any other client can set them. X-Forwarded-For is read from the last hop back,
+// skipping trusted proxies, so a client can't spoof its IP by sending its own header through the proxy.
+func requestSourceIp(r *http.Request, trustedProxies []*net.IPNet) string {
+	remoteIp, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		remoteIp = r.RemoteAddr
+	}
+	if !isTrustedProxy(net.ParseIP(remoteIp), trustedProxies) {
+		return remoteIp
+	}
+
+	forwardedFor := r.Header.Get(common.HEADER_FORWARDED_FOR)
+	if forwardedFor != "" {
+		hops := strings.Split(forwardedFor, ",")
+		for idx := len(hops) - 1; idx >= 0; idx-- {
+			hop := strings.TrimSpace(hops[idx])
+			if idx == 0 || !isTrustedProxy(net.ParseIP(hop), trustedProxies) {
+				return hop
+			}
+		}
+	}
+	realIp := r.Header.Get(common.HEADER_REAL_IP)
+	if realIp != "" {
+		return strings.TrimSpace(realIp)
+	}
+	return remoteIp
+}
+
+// WriteRequestSourceToContext populates the context with the client IP and user agent of the request
+func WriteRequestSourceToContext(trustedProxies []*net.IPNet) httptransport.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		return common.InjectRequestSourceIntoContext(ctx, &common.RequestSource{
+			Ip:        requestSourceIp(r, trustedProxies),
+			UserAgent: r.Header.Get(common.HEADER_USER_AGENT),
+		})
+	}
+}
+
+// WriteNamespaceToContext populates the context with the namespace from the request path, if it has one
+func WriteNamespaceToContext() httptransport.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		namespace, ok := mux.Vars(r)["namespace"]
+		if !ok {
+			return ctx
+		}
+		return common.InjectNamespaceNameIntoContext(ctx, namespace)
+	}
+}
@@ -0,0 +1,157 @@
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+func TestRequestSourceIp(t *testing.T) {
+	_, proxy, _ := net.ParseCIDR("10.0.0.4/32")
+	_, proxyRange, _ := net.ParseCIDR("10.0.0.0/24")
+	var tests = []struct {
+		testName       string
+		request        *http.Request
+		trustedProxies []*net.IPNet
+		expected       string
+	}{
+		{
+			testName: "forwarded for from trusted proxy",
+			request: &http.Request{
+				Header: map[string][]string{
+					"X-Forwarded-For": []string{"10.0.0.1, 10.0.0.2"},
+					"X-Real-Ip":       []string{"10.0.0.3"},
+				},
+				RemoteAddr: "10.0.0.4:5000",
+			},
+			trustedProxies: []*net.IPNet{proxy},
+			expected:       "10.0.0.2",
+		},
+		{
+			testName: "forwarded for through trusted proxies",
+			request: &http.Request{
+				Header: map[string][]string{
+					"X-Forwarded-For": []string{"192.168.0.1, 10.0.0.1, 10.0.0.2"},
+				},
+				RemoteAddr: "10.0.0.4:5000",
+			},
+			trustedProxies: []*net.IPNet{proxyRange},
+			expected:       "192.168.0.1",
+		},
+		{
+			testName: "forwarded for only through trusted proxies",
+			request: &http.Request{
+				Header: map[string][]string{
+					"X-Forwarded-For": []string{"10.0.0.1, 10.0.0.2"},
+				},
+				RemoteAddr: "10.0.0.4:5000",
+			},
+			trustedProxies: []*net.IPNet{proxyRange},
+			expected:       "10.0.0.1",
+		},
+		{
+			testName: "forwarded for from untrusted peer",
+			request: &http.Request{
+				Header: map[string][]string{
+					"X-Forwarded-For": []string{"10.0.0.1, 10.0.0.2"},
+					"X-Real-Ip":       []string{"10.0.0.3"},
+				},
+				RemoteAddr: "192.168.0.1:5000",
+			},
+			trustedProxies: []*net.IPNet{proxy},
+			expected:       "192.168.0.1",
+		},
+		{
+			testName: "forwarded for without trusted proxies",
+			request: &http.Request{
+				Header: map[string][]string{
+					"X-Forwarded-For": []string{"10.0.0.1"},
+				},
+				RemoteAddr: "10.0.0.4:5000",
+			},
+			expected: "10.0.0.4",
+		},
+		{
+			testName: "real ip from trusted proxy",
+			request: &http.Request{
+				Header: map[string][]string{
+					"X-Real-Ip": []string{"10.0.0.3"},
+				},
+				RemoteAddr: "10.0.0.4:5000",
+			},
+			trustedProxies: []*net.IPNet{proxy},
+			expected:       "10.0.0.3",
+		},
+		{
+			testName: "real ip from untrusted peer",
+			request: &http.Request{
+				Header: map[string][]string{
+					"X-Real-Ip": []string{"10.0.0.3"},
+				},
+				RemoteAddr: "192.168.0.1:5000",
+			},
+			trustedProxies: []*net.IPNet{proxy},
+			expected:       "192.168.0.1",
+		},
+		{
+			testName: "remote addr",
+			request: &http.Request{
+				Header:     map[string][]string{},
+				RemoteAddr: "10.0.0.4:5000",
+			},
+			trustedProxies: []*net.IPNet{proxy},
+			expected:       "10.0.0.4",
+		},
+		{
+			testName: "remote addr without port",
+			request: &http.Request{
+				Header:     map[string][]string{},
+				RemoteAddr: "10.0.0.4",
+			},
+			expected: "10.0.0.4",
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("requestSourceIp - %v", given.testName), func(t *testing.T) {
+			result := requestSourceIp(given.request, given.trustedProxies)
+			require.Equal(t, result, given.expected, "Result %v did not equal expected %v", result, given.expected)
+		})
+	}
+}
+
+func TestWriteNamespaceToContext(t *testing.T) {
+	var tests = []struct {
+		testName string
+		vars     map[string]string
+		expected string
+	}{
+		{
+			testName: "no namespace",
+			vars:     map[string]string{},
+			expected: common.DEFAULT_NAMESPACE,
+		},
+		{
+			testName: "namespace",
+			vars:     map[string]string{"namespace": "team-a"},
+			expected: "team-a",
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("WriteNamespaceToContext - %v", given.testName), func(t *testing.T) {
+			r := mux.SetURLVars(httptest.NewRequest(http.MethodGet, "/secrets", nil), given.vars)
+			ctx := WriteNamespaceToContext()(context.Background(), r)
+			result := common.FetchNamespaceNameFromContext(ctx)
+			require.Equal(t, result, given.expected, "Result %v did not equal expected %v", result, given.expected)
+		})
+	}
+}
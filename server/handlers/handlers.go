@@ -22,3 +22,15 @@ func HandleClientEndpoints(e endpoint.Endpoint, op string, deps *dependencies.De
 func HandleTokenEndpoints(e endpoint.Endpoint, op string, deps *dependencies.Dependencies) endpoint.Endpoint {
 	return EndpointLoggingWrapper(EndpointTracingWrapper(EndpointAccessTokenAuthenticationWrapper(e, op, deps, false), op, deps), op, deps)
 }
+
+// HandleNamespaceAdminEndpoints -- wrapper to add logging/tracing/auth for access_token endpoints that need an admin
+// of the request's namespace
+func HandleNamespaceAdminEndpoints(e endpoint.Endpoint, op string, deps *dependencies.Dependencies) endpoint.Endpoint {
+	return EndpointLoggingWrapper(EndpointTracingWrapper(EndpointAccessTokenAuthenticationWrapper(EndpointNamespaceWrapper(e, op, deps, true), op, deps, false), op, deps), op, deps)
+}
+
+// HandleNamespaceTokenEndpoints -- wrapper to add logging/tracing/auth for access_token endpoints in the request's
+// namespace
+func HandleNamespaceTokenEndpoints(e endpoint.Endpoint, op string, deps *dependencies.Dependencies) endpoint.Endpoint {
+	return EndpointLoggingWrapper(EndpointTracingWrapper(EndpointAccessTokenAuthenticationWrapper(EndpointNamespaceWrapper(e, op, deps, false), op, deps, false), op, deps), op, deps)
+}
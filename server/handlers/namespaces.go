@@ -0,0 +1,32 @@
+package handlers
+
+import (
+	"context"
+
+	"github.com/go-kit/kit/endpoint"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/database"
+	"github.com/emarcey/data-vault/dependencies"
+)
+
+// EndpointNamespaceWrapper looks up the namespace a request was made in and adds it to the context, with admin check
+// optional. It must run after authentication.
+func EndpointNamespaceWrapper(e endpoint.Endpoint, op string, deps *dependencies.Dependencies, checkAdmin bool) endpoint.Endpoint {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		user, err := common.FetchUserFromContext(ctx)
+		if err != nil {
+			return nil, err
+		}
+		namespace, err := database.GetNamespace(ctx, deps.Database, user, common.FetchNamespaceNameFromContext(ctx))
+		if err != nil {
+			return nil, err
+		}
+		if checkAdmin && !namespace.IsAdmin {
+			deps.Logger.Errorf("Error authenticating %s: User %s is not an admin of namespace %s.", op, user.Id, namespace.Name)
+			logFailedAuthentication(ctx, op, deps, user.Id)
+			return nil, common.NewAuthorizationError()
+		}
+		return e(common.InjectNamespaceIntoContext(ctx, namespace), request)
+	}
+}
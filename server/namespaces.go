@@ -0,0 +1,150 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+func listNamespacesEndpoint(s Service) endpointBuilder {
+	op := "ListNamespaces"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*PaginationRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *PaginationRequest. Got %T", reqInterface)
+		}
+		return s.ListNamespaces(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodePaginationRequest(op),
+		method:   HTTP_GET,
+		path:     "/namespaces",
+	}
+}
+
+func getNamespaceEndpoint(s Service) endpointBuilder {
+	op := "GetNamespace"
+	e := func(ctx context.Context, nameInterface interface{}) (interface{}, error) {
+		name, ok := nameInterface.(string)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected namespace name of type string. Got %T", nameInterface)
+		}
+		return s.GetNamespace(ctx, name)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeRequestUrlName(op),
+		method:   HTTP_GET,
+		path:     "/namespaces/{name}",
+	}
+}
+
+func decodeCreateNamespaceRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	var req CreateNamespaceRequest
+	err = json.Unmarshal(data, &req)
+	if err != nil {
+		return nil, common.NewInvalidParamsError("CreateNamespace", "Could not unmarshal request: %v", string(data))
+	}
+	return &req, nil
+}
+
+func createNamespaceEndpoint(s Service) endpointBuilder {
+	op := "CreateNamespace"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*CreateNamespaceRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *CreateNamespaceRequest. Got %T", reqInterface)
+		}
+		return s.CreateNamespace(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeCreateNamespaceRequest,
+		method:   HTTP_POST,
+		path:     "/namespaces",
+	}
+}
+
+func deleteNamespaceEndpoint(s Service) endpointBuilder {
+	op := "DeleteNamespace"
+	e := func(ctx context.Context, nameInterface interface{}) (interface{}, error) {
+		name, ok := nameInterface.(string)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected namespace name of type string. Got %T", nameInterface)
+		}
+		return nil, s.DeleteNamespace(ctx, name)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeRequestUrlName(op),
+		method:   HTTP_DELETE,
+		path:     "/namespaces/{name}",
+	}
+}
+
+var decodeNamespaceAdminUrl = decodeRequestUrlName("NamespaceAdmin")
+
+func decodeNamespaceAdminRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	op := "NamespaceAdmin"
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	var req NamespaceAdminRequest
+	err = json.Unmarshal(data, &req)
+	if err != nil {
+		return nil, common.NewInvalidParamsError(op, "Could not unmarshal request: %v", string(data))
+	}
+	namespace, err := decodeNamespaceAdminUrl(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Namespace = namespace.(string)
+	return &req, nil
+}
+
+func addNamespaceAdminEndpoint(s Service) endpointBuilder {
+	op := "AddNamespaceAdmin"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*NamespaceAdminRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *NamespaceAdminRequest. Got %T", reqInterface)
+		}
+		err := s.AddNamespaceAdmin(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return NewStatusResponse(), nil
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeNamespaceAdminRequest,
+		method:   HTTP_POST,
+		path:     "/namespaces/{name}/admins",
+	}
+}
+
+func removeNamespaceAdminEndpoint(s Service) endpointBuilder {
+	op := "RemoveNamespaceAdmin"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*NamespaceAdminRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *NamespaceAdminRequest. Got %T", reqInterface)
+		}
+		return nil, s.RemoveNamespaceAdmin(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeNamespaceAdminRequest,
+		method:   HTTP_DELETE,
+		path:     "/namespaces/{name}/admins",
+	}
+}
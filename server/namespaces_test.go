@@ -0,0 +1,33 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeCreateNamespaceRequest(t *testing.T) {
+	r := httptest.NewRequest(HTTP_POST, "/namespaces", strings.NewReader(`{"name": "team-a", "description": "Team A"}`))
+	result, err := decodeCreateNamespaceRequest(context.Background(), r)
+	require.Nil(t, err, "Unexpected error in decodeCreateNamespaceRequest: %v", err)
+	expected := &CreateNamespaceRequest{Name: "team-a", Description: "Team A"}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+
+	r = httptest.NewRequest(HTTP_POST, "/namespaces", strings.NewReader(`{"name": "team-a"`))
+	result, err = decodeCreateNamespaceRequest(context.Background(), r)
+	require.NotNil(t, err, "no error in decodeCreateNamespaceRequest")
+	require.Nil(t, result, "Result was not nil: %v", result)
+}
+
+func TestDecodeNamespaceAdminRequest(t *testing.T) {
+	r := httptest.NewRequest(HTTP_POST, "/namespaces/team-a/admins", strings.NewReader(`{"user_id": "userId"}`))
+	r = mux.SetURLVars(r, map[string]string{"name": "team-a"})
+	result, err := decodeNamespaceAdminRequest(context.Background(), r)
+	require.Nil(t, err, "Unexpected error in decodeNamespaceAdminRequest: %v", err)
+	expected := &NamespaceAdminRequest{Namespace: "team-a", UserId: "userId"}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+}
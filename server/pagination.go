@@ -0,0 +1,35 @@
+package server
+
+import (
+	"github.com/emarcey/data-vault/common"
+)
+
+// pageLimit is the number of items to fetch for a page. The extra item shows whether there's a next page.
+func pageLimit(pageSize int) int {
+	return pageSize + 1
+}
+
+// newPageInfo returns the PageInfo for a page of up to pageSize items, out of the fetched items fetched with
+// pageLimit. cursorAt returns the cursor after the item at an index. count is only called if includeTotal is set.
+func newPageInfo(fetched, pageSize int, cursorAt func(idx int) *common.PageCursor, includeTotal bool, count func() (int, error)) (common.PageInfo, error) {
+	var info common.PageInfo
+	if fetched > pageSize {
+		info.NextCursor = common.EncodeCursor(cursorAt(pageSize - 1))
+	}
+	if includeTotal {
+		total, err := count()
+		if err != nil {
+			return info, err
+		}
+		info.Total = &total
+	}
+	return info, nil
+}
+
+// pageSlice returns the number of fetched items that belong on a page of pageSize
+func pageSlice(fetched, pageSize int) int {
+	if fetched > pageSize {
+		return pageSize
+	}
+	return fetched
+}
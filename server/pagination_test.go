@@ -0,0 +1,92 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+func TestNewPageInfo(t *testing.T) {
+	cursorAt := func(idx int) *common.PageCursor {
+		return &common.PageCursor{Value: fmt.Sprintf("name%d", idx), Id: fmt.Sprintf("id%d", idx)}
+	}
+	counted := false
+	count := func() (int, error) {
+		counted = true
+		return 42, nil
+	}
+
+	info, err := newPageInfo(3, 3, cursorAt, false, count)
+	require.Nil(t, err, "Unexpected error in newPageInfo: %v", err)
+	require.Equal(t, common.PageInfo{}, info)
+	require.False(t, counted, "count was called without includeTotal")
+
+	info, err = newPageInfo(4, 3, cursorAt, true, count)
+	require.Nil(t, err, "Unexpected error in newPageInfo: %v", err)
+	total := 42
+	require.Equal(t, common.PageInfo{NextCursor: common.EncodeCursor(cursorAt(2)), Total: &total}, info)
+
+	_, err = newPageInfo(1, 3, cursorAt, true, func() (int, error) {
+		return 0, fmt.Errorf("Oh no!")
+	})
+	require.NotNil(t, err, "no error in newPageInfo")
+}
+
+func TestPageSlice(t *testing.T) {
+	require.Equal(t, 11, pageLimit(10))
+	require.Equal(t, 10, pageSlice(11, 10))
+	require.Equal(t, 4, pageSlice(4, 10))
+	require.Equal(t, 0, pageSlice(0, 10))
+}
+
+func TestDecodePaginationRequest(t *testing.T) {
+	var tests = []struct {
+		url      string
+		expected *PaginationRequest
+	}{
+		{
+			url:      "/users",
+			expected: &PaginationRequest{PageSize: 10},
+		},
+		{
+			url:      "/users?pageSize=5&offset=10",
+			expected: &PaginationRequest{PageSize: 5, Offset: 10},
+		},
+		{
+			url:      "/users?cursor=abc&total=true",
+			expected: &PaginationRequest{PageSize: 10, Cursor: "abc", IncludeTotal: true},
+		},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("decodePaginationRequest - Successes - %v", idx), func(t *testing.T) {
+			r := httptest.NewRequest(HTTP_GET, given.url, nil)
+			result, err := decodePaginationRequest("test")(context.Background(), r)
+			require.Nil(t, err, "Unexpected error in decodePaginationRequest: %v", err)
+			require.Equal(t, given.expected, result)
+		})
+	}
+}
+
+func TestDecodePaginationRequestErrors(t *testing.T) {
+	var tests = []string{
+		"/users?pageSize=0",
+		"/users?offset=a",
+		"/users?cursor=abc&offset=10",
+		"/users?total=maybe",
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("decodePaginationRequest - Errors - %v", idx), func(t *testing.T) {
+			r := httptest.NewRequest(HTTP_GET, given, nil)
+			result, err := decodePaginationRequest("test")(context.Background(), r)
+			require.NotNil(t, err, "no error in decodePaginationRequest")
+			require.Nil(t, result, "Result was not nil: %v", result)
+		})
+	}
+}
@@ -27,26 +27,43 @@ var (
 type endpointBuilder struct {
 	endpoint endpoint.Endpoint
 	decoder  httptransport.DecodeRequestFunc
-	method   string
-	path     string
+	// encoder overrides the encoder passed to makeMethods, for endpoints that don't respond with JSON
+	encoder httptransport.EncodeResponseFunc
+	method  string
+	path    string
 }
 
 func makeMethods(r *mux.Router, deps *dependencies.Dependencies, handler handlers.EndpointHandler, endpoints []endpointBuilder, encoder httptransport.EncodeResponseFunc, options ...httptransport.ServerOption) {
 	for _, endpoint := range endpoints {
+		endpointEncoder := encoder
+		if endpoint.encoder != nil {
+			endpointEncoder = endpoint.encoder
+		}
 		r.Methods(endpoint.method).Path(endpoint.path).Handler(httptransport.NewServer(
 			handler(endpoint.endpoint, fmt.Sprintf("%s %s", endpoint.method, endpoint.path), deps),
 			endpoint.decoder,
-			encoder,
+			endpointEncoder,
 			options...,
 		))
 	}
 }
 
+// namespaced registers each endpoint at its path, in the default namespace, and under /ns/{namespace}
+func namespaced(endpoints []endpointBuilder) []endpointBuilder {
+	result := make([]endpointBuilder, 0, 2*len(endpoints))
+	for _, endpoint := range endpoints {
+		result = append(result, endpoint)
+		endpoint.path = "/ns/{namespace}" + endpoint.path
+		result = append(result, endpoint)
+	}
+	return result
+}
+
 func MakeHttpHandler(s Service, deps *dependencies.Dependencies) http.Handler {
 	r := mux.NewRouter()
 	options := []httptransport.ServerOption{
 		httptransport.ServerErrorEncoder(handlers.EncodeError),
-		httptransport.ServerBefore(handlers.WriteHeadersToContext()),
+		httptransport.ServerBefore(handlers.WriteHeadersToContext(), handlers.WriteRequestIdToContext(), handlers.WriteRequestSourceToContext(deps.TrustedProxies), handlers.WriteNamespaceToContext()),
 	}
 
 	r.Methods(HTTP_GET).Path("/version").HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -57,15 +74,50 @@ func MakeHttpHandler(s Service, deps *dependencies.Dependencies) http.Handler {
 		getUserEndpoint(s),
 		deleteUserEndpoint(s),
 		createUserEndpoint(s),
+		createNamespaceEndpoint(s),
+		deleteNamespaceEndpoint(s),
+		addNamespaceAdminEndpoint(s),
+		removeNamespaceAdminEndpoint(s),
+		rewrapSecretsEndpoint(s),
+		getSecretRekeyStatusEndpoint(s),
+		purgeSecretsEndpoint(s),
+		listDeletedUsersEndpoint(s),
+		restoreUserEndpoint(s),
+		listAccessLogsEndpoint(s),
+		listUserAccessLogsEndpoint(s),
+		verifyAccessLogsEndpoint(s),
+		listDatabaseRolesEndpoint(s),
+		getDatabaseRoleEndpoint(s),
+		createDatabaseRoleEndpoint(s),
+		deleteDatabaseRoleEndpoint(s),
+		createDatabaseRolePermissionEndpoint(s),
+		deleteDatabaseRolePermissionEndpoint(s),
+		listTransitKeysEndpoint(s),
+		getTransitKeyEndpoint(s),
+		createTransitKeyEndpoint(s),
+		rotateTransitKeyEndpoint(s),
+		deleteTransitKeyEndpoint(s),
+		createTransitKeyPermissionEndpoint(s),
+		deleteTransitKeyPermissionEndpoint(s),
+	}
+	makeMethods(r, deps, handlers.HandleAdminEndpoints, adminEndpoints, encodeResponse, options...)
+
+	namespaceAdminEndpoints := []endpointBuilder{
 		deleteSecretEndpoint(s),
+		rekeySecretEndpoint(s),
+		createSecretPatternPermissionEndpoint(s),
+		deleteSecretPatternPermissionEndpoint(s),
 		getUserGroupEndpoint(s),
 		deleteUserGroupEndpoint(s),
 		createUserGroupEndpoint(s),
 		addUserToGroupEndpoint(s),
 		removeUserFromGroupEndpoint(s),
-		listAccessLogsEndpoint(s),
+		listDeletedSecretsEndpoint(s),
+		restoreSecretEndpoint(s),
+		listDeletedUserGroupsEndpoint(s),
+		restoreUserGroupEndpoint(s),
 	}
-	makeMethods(r, deps, handlers.HandleAdminEndpoints, adminEndpoints, encodeResponse, options...)
+	makeMethods(r, deps, handlers.HandleNamespaceAdminEndpoints, namespaced(namespaceAdminEndpoints), encodeResponse, options...)
 
 	clientEndpoints := []endpointBuilder{
 		getAccessTokenEndpoint(s),
@@ -73,16 +125,42 @@ func MakeHttpHandler(s Service, deps *dependencies.Dependencies) http.Handler {
 	}
 	makeMethods(r, deps, handlers.HandleClientEndpoints, clientEndpoints, encodeResponse, options...)
 
-	accessTokenEndpoints := []endpointBuilder{
-		listUsersEndpoint(s),
+	secretFileLimit := deps.ServerConfigs.SecretFileLimit()
+	namespaceTokenEndpoints := []endpointBuilder{
 		listSecretsEndpoint(s),
 		createSecretEndpoint(s),
 		getSecretEndpoint(s),
+		getSecretFieldEndpoint(s),
+		createSecretFileEndpoint(s, secretFileLimit),
+		getSecretFileEndpoint(s),
+		updateSecretFileEndpoint(s, secretFileLimit),
+		updateSecretEndpoint(s),
+		listSecretVersionsEndpoint(s),
+		rollbackSecretEndpoint(s),
+		updateSecretLabelsEndpoint(s),
 		createSecretPermissionEndpoint(s),
 		deleteSecretPermissionEndpoint(s),
 		listUserGroupsEndpoint(s),
 		listUsersInGroupEndpoint(s),
 	}
+	makeMethods(r, deps, handlers.HandleNamespaceTokenEndpoints, namespaced(namespaceTokenEndpoints), encodeResponse, options...)
+
+	accessTokenEndpoints := []endpointBuilder{
+		listUsersEndpoint(s),
+		listNamespacesEndpoint(s),
+		getNamespaceEndpoint(s),
+		listSecretPoliciesEndpoint(s),
+		getDatabaseCredentialsEndpoint(s),
+		renewLeaseEndpoint(s),
+		revokeLeaseEndpoint(s),
+		transitEncryptEndpoint(s),
+		transitDecryptEndpoint(s),
+		transitRewrapEndpoint(s),
+		transitDataKeyEndpoint(s),
+		transitSignEndpoint(s),
+		transitVerifyEndpoint(s),
+		getTransitPublicKeysEndpoint(s),
+	}
 	makeMethods(r, deps, handlers.HandleTokenEndpoints, accessTokenEndpoints, encodeResponse, options...)
 	return r
 }
@@ -126,6 +204,17 @@ func parseIntegerUrlParam(op string, urlParams map[string][]string, paramName st
 	return paramInt, nil
 }
 
+func parseStringUrlParam(op string, urlParams map[string][]string, paramName string, defaultValue string) (string, error) {
+	param, ok := urlParams[paramName]
+	if !ok {
+		return defaultValue, nil
+	}
+	if len(param) != 1 {
+		return "", common.NewInvalidParamsError(op, "Expected single string value for %v, got %v", paramName, param)
+	}
+	return param[0], nil
+}
+
 func parseDateUrlParam(op string, urlParams map[string][]string, paramName string, defaultValue time.Time) (time.Time, error) {
 	param, ok := urlParams[paramName]
 	if !ok {
@@ -141,6 +230,33 @@ func parseDateUrlParam(op string, urlParams map[string][]string, paramName strin
 	return paramDate, nil
 }
 
+// parseOptionalDateUrlParam is parseDateUrlParam for filters that are off unless given
+func parseOptionalDateUrlParam(op string, urlParams map[string][]string, paramName string) (*time.Time, error) {
+	if _, ok := urlParams[paramName]; !ok {
+		return nil, nil
+	}
+	paramDate, err := parseDateUrlParam(op, urlParams, paramName, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	return &paramDate, nil
+}
+
+func parseBoolUrlParam(op string, urlParams map[string][]string, paramName string, defaultValue bool) (bool, error) {
+	param, ok := urlParams[paramName]
+	if !ok {
+		return defaultValue, nil
+	}
+	if len(param) != 1 {
+		return false, common.NewInvalidParamsError(op, "Expected single boolean value for %v, got %v", paramName, param)
+	}
+	paramBool, err := strconv.ParseBool(param[0])
+	if err != nil {
+		return false, common.NewInvalidParamsError(op, "Expected single boolean value for %v, got %v", paramName, param)
+	}
+	return paramBool, nil
+}
+
 func decodePaginationRequest(op string) httptransport.DecodeRequestFunc {
 	return func(_ context.Context, r *http.Request) (interface{}, error) {
 		urlParams := r.URL.Query()
@@ -148,15 +264,33 @@ func decodePaginationRequest(op string) httptransport.DecodeRequestFunc {
 		if err != nil {
 			return nil, err
 		}
+		if pageSize < 1 {
+			return nil, common.NewInvalidParamsError(op, "Expected positive pageSize. Got %d", pageSize)
+		}
 
 		offset, err := parseIntegerUrlParam(op, urlParams, "offset", 0)
 		if err != nil {
 			return nil, err
 		}
 
+		cursor, err := parseStringUrlParam(op, urlParams, "cursor", "")
+		if err != nil {
+			return nil, err
+		}
+		if cursor != "" && offset > 0 {
+			return nil, common.NewInvalidParamsError(op, "Expected either cursor or offset. Got both")
+		}
+
+		includeTotal, err := parseBoolUrlParam(op, urlParams, "total", false)
+		if err != nil {
+			return nil, err
+		}
+
 		return &PaginationRequest{
-			PageSize: pageSize,
-			Offset:   offset,
+			PageSize:     pageSize,
+			Offset:       offset,
+			Cursor:       cursor,
+			IncludeTotal: includeTotal,
 		}, nil
 	}
 }
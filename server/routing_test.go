@@ -166,6 +166,81 @@ func TestParseIntegerUrlParamSuccess(t *testing.T) {
 	}
 }
 
+func TestParseStringUrlParamErrors(t *testing.T) {
+	var tests = []struct {
+		op        string
+		urlParams map[string][]string
+		paramName string
+	}{
+		{
+			op: "too few vals",
+			urlParams: map[string][]string{
+				"anything": []string{},
+			},
+		},
+		{
+			op: "too many vals",
+			urlParams: map[string][]string{
+				"anything": []string{"abc", "def"},
+			},
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("parseStringUrlParam - Errors - %v", given.op), func(t *testing.T) {
+			result, err := parseStringUrlParam(given.op, given.urlParams, "anything", "default")
+
+			require.NotNil(t, err, "no error in parseStringUrlParam: %v", err)
+			require.Equal(t, result, "", "Result, %v, does not equal expected, ''", result)
+		})
+	}
+}
+
+func TestParseStringUrlParamSuccess(t *testing.T) {
+	var tests = []struct {
+		op           string
+		urlParams    map[string][]string
+		paramName    string
+		defaultValue string
+		expected     string
+	}{
+		{
+			op:           "empty map",
+			urlParams:    map[string][]string{},
+			paramName:    "anything",
+			defaultValue: "default",
+			expected:     "default",
+		},
+		{
+			op: "missing val",
+			urlParams: map[string][]string{
+				"anything2": []string{"abc", "def"},
+			},
+			paramName:    "anything",
+			defaultValue: "default",
+			expected:     "default",
+		},
+		{
+			op: "found",
+			urlParams: map[string][]string{
+				"anything": []string{"abc"},
+			},
+			paramName:    "anything",
+			defaultValue: "default",
+			expected:     "abc",
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("parseStringUrlParam - Success - %v", given.op), func(t *testing.T) {
+			result, err := parseStringUrlParam(given.op, given.urlParams, given.paramName, given.defaultValue)
+
+			require.Nil(t, err, "error in parseStringUrlParam: %v", err)
+			require.Equal(t, result, given.expected, "Result, %v, does not equal expected, %v", result, given.expected)
+		})
+	}
+}
+
 func TestParseDateUrlParamErrors(t *testing.T) {
 	defaultVal := time.Now()
 	var tests = []struct {
@@ -258,3 +333,19 @@ func TestParseDateUrlParamSuccess(t *testing.T) {
 		})
 	}
 }
+
+func TestNamespaced(t *testing.T) {
+	endpoints := []endpointBuilder{
+		{method: HTTP_GET, path: "/secrets"},
+		{method: HTTP_DELETE, path: "/secrets/{name}"},
+	}
+	result := namespaced(endpoints)
+	expected := []endpointBuilder{
+		{method: HTTP_GET, path: "/secrets"},
+		{method: HTTP_GET, path: "/ns/{namespace}/secrets"},
+		{method: HTTP_DELETE, path: "/secrets/{name}"},
+		{method: HTTP_DELETE, path: "/ns/{namespace}/secrets/{name}"},
+	}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+	require.Equal(t, endpoints[0].path, "/secrets", "namespaced modified its input: %+v", endpoints)
+}
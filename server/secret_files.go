@@ -0,0 +1,214 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"time"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+// secretFileUpload is a file read from a multipart form's "file" part, or from a raw request body
+type secretFileUpload struct {
+	contentType string
+	filename    string
+	data        []byte
+}
+
+// readLimited reads at most limit bytes, so oversized uploads are rejected without being read into memory
+func readLimited(op string, reader io.Reader, limit int64) ([]byte, error) {
+	data, err := ioutil.ReadAll(io.LimitReader(reader, limit+1))
+	if err != nil {
+		return nil, common.NewInvalidParamsError(op, "Unable to read file: %v", err)
+	}
+	if int64(len(data)) > limit {
+		return nil, common.NewRequestTooLargeError(op, limit)
+	}
+	return data, nil
+}
+
+// readSecretFileUpload reads the file from a multipart/form-data request, taking its content type and filename from
+// the "file" part. Any other request body is read as the file, with its Content-Type and the filename URL param.
+func readSecretFileUpload(op string, r *http.Request, limit int64) (*secretFileUpload, error) {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || mediaType != "multipart/form-data" {
+		filename, err := parseStringUrlParam(op, r.URL.Query(), "filename", "")
+		if err != nil {
+			return nil, err
+		}
+		data, err := readLimited(op, r.Body, limit)
+		if err != nil {
+			return nil, err
+		}
+		return &secretFileUpload{contentType: r.Header.Get("Content-Type"), filename: filename, data: data}, nil
+	}
+
+	reader, err := r.MultipartReader()
+	if err != nil {
+		return nil, common.NewInvalidParamsError(op, "Unable to read multipart form: %v", err)
+	}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, common.NewInvalidParamsError(op, "Unable to read multipart form: %v", err)
+		}
+		if part.FormName() != "file" {
+			part.Close()
+			continue
+		}
+		data, err := readLimited(op, part, limit)
+		if err != nil {
+			return nil, err
+		}
+		return &secretFileUpload{contentType: part.Header.Get("Content-Type"), filename: part.FileName(), data: data}, nil
+	}
+	return nil, common.NewInvalidParamsError(op, "Expected a multipart form with a file part")
+}
+
+func parseExpiresAtUrlParam(op string, urlParams map[string][]string) (*time.Time, error) {
+	param, err := parseStringUrlParam(op, urlParams, "expires_at", "")
+	if err != nil || param == "" {
+		return nil, err
+	}
+	expiresAt, err := time.Parse(time.RFC3339, param)
+	if err != nil {
+		return nil, common.NewInvalidParamsError(op, "Expected expires_at in RFC3339. Got %s", param)
+	}
+	return &expiresAt, nil
+}
+
+func decodeCreateSecretFileRequest(limit int64) httptransport.DecodeRequestFunc {
+	op := "CreateSecretFile"
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		secretName, err := parseStringValue(op, mux.Vars(r), "name")
+		if err != nil {
+			return nil, err
+		}
+		urlParams := r.URL.Query()
+		description, err := parseStringUrlParam(op, urlParams, "description", "")
+		if err != nil {
+			return nil, err
+		}
+		expiresAt, err := parseExpiresAtUrlParam(op, urlParams)
+		if err != nil {
+			return nil, err
+		}
+		upload, err := readSecretFileUpload(op, r, limit)
+		if err != nil {
+			return nil, err
+		}
+		return &CreateSecretFileRequest{
+			Name:        secretName,
+			Description: description,
+			ExpiresAt:   expiresAt,
+			ContentType: upload.contentType,
+			Filename:    upload.filename,
+			Data:        upload.data,
+		}, nil
+	}
+}
+
+func createSecretFileEndpoint(s Service, limit int64) endpointBuilder {
+	op := "CreateSecretFile"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*CreateSecretFileRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *CreateSecretFileRequest. Got %T", reqInterface)
+		}
+		return s.CreateSecretFile(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeCreateSecretFileRequest(limit),
+		method:   HTTP_POST,
+		path:     "/secrets/{name}/file",
+	}
+}
+
+func decodeUpdateSecretFileRequest(limit int64) httptransport.DecodeRequestFunc {
+	op := "UpdateSecretFile"
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		secretName, err := parseStringValue(op, mux.Vars(r), "name")
+		if err != nil {
+			return nil, err
+		}
+		upload, err := readSecretFileUpload(op, r, limit)
+		if err != nil {
+			return nil, err
+		}
+		return &UpdateSecretFileRequest{
+			Name:        secretName,
+			ContentType: upload.contentType,
+			Filename:    upload.filename,
+			Data:        upload.data,
+		}, nil
+	}
+}
+
+func updateSecretFileEndpoint(s Service, limit int64) endpointBuilder {
+	op := "UpdateSecretFile"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*UpdateSecretFileRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *UpdateSecretFileRequest. Got %T", reqInterface)
+		}
+		return s.UpdateSecretFile(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeUpdateSecretFileRequest(limit),
+		method:   HTTP_PUT,
+		path:     "/secrets/{name}/file",
+	}
+}
+
+func getSecretFileEndpoint(s Service) endpointBuilder {
+	op := "GetSecretFile"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*GetSecretRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *GetSecretRequest. Got %T", reqInterface)
+		}
+		return s.GetSecretFile(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeGetSecretRequest(op),
+		encoder:  encodeFileResponse,
+		method:   HTTP_GET,
+		path:     "/secrets/{name}/file",
+	}
+}
+
+// encodeFileResponse streams a *common.SecretFile as the response body, with its own content type. Anything else is
+// encoded as JSON.
+func encodeFileResponse(ctx context.Context, w http.ResponseWriter, response interface{}) error {
+	file, ok := response.(*common.SecretFile)
+	if !ok {
+		return encodeResponse(ctx, w, response)
+	}
+	contentType := file.ContentType
+	if contentType == "" {
+		contentType = common.DEFAULT_SECRET_CONTENT_TYPE
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Content-Length", fmt.Sprintf("%d", len(file.Data)))
+	w.Header().Set(common.HEADER_SECRET_VERSION, fmt.Sprintf("%d", file.Version))
+	if file.Filename != "" {
+		w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": file.Filename}))
+	}
+	w.WriteHeader(http.StatusOK)
+	_, err := io.Copy(w, bytes.NewReader(file.Data))
+	return err
+}
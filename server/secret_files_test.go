@@ -0,0 +1,137 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"net/textproto"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+func newMultipartRequest(t *testing.T, formName, filename, contentType string, data []byte) *http.Request {
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	require.Nil(t, writer.WriteField("description", "ignored"))
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Disposition", fmt.Sprintf(`form-data; name="%s"; filename="%s"`, formName, filename))
+	header.Set("Content-Type", contentType)
+	part, err := writer.CreatePart(header)
+	require.Nil(t, err, "Unexpected error creating part: %v", err)
+	_, err = part.Write(data)
+	require.Nil(t, err, "Unexpected error writing part: %v", err)
+	require.Nil(t, writer.Close())
+
+	r := httptest.NewRequest(HTTP_POST, "/secrets/tls/file", &body)
+	r.Header.Set("Content-Type", writer.FormDataContentType())
+	return r
+}
+
+func TestReadSecretFileUploadSuccess(t *testing.T) {
+	data := []byte{0x00, 0xff, 0x0a}
+	raw := httptest.NewRequest(HTTP_POST, "/secrets/tls/file?filename=tls.key", bytes.NewReader(data))
+	raw.Header.Set("Content-Type", "application/x-pem-file")
+	noContentType := httptest.NewRequest(HTTP_POST, "/secrets/tls/file", bytes.NewReader(data))
+
+	var tests = []struct {
+		testName string
+		request  *http.Request
+		expected *secretFileUpload
+	}{
+		{
+			testName: "raw",
+			request:  raw,
+			expected: &secretFileUpload{contentType: "application/x-pem-file", filename: "tls.key", data: data},
+		},
+		{
+			testName: "raw without content type",
+			request:  noContentType,
+			expected: &secretFileUpload{data: data},
+		},
+		{
+			testName: "multipart",
+			request:  newMultipartRequest(t, "file", "tls.key", "application/x-pem-file", data),
+			expected: &secretFileUpload{contentType: "application/x-pem-file", filename: "tls.key", data: data},
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("readSecretFileUpload - Success - %v", given.testName), func(t *testing.T) {
+			result, err := readSecretFileUpload("test", given.request, 3)
+			require.Nil(t, err, "Unexpected error in readSecretFileUpload: %v", err)
+			require.Equal(t, result, given.expected, "Result, %+v, does not equal expected, %+v", result, given.expected)
+		})
+	}
+}
+
+func TestReadSecretFileUploadErrors(t *testing.T) {
+	data := []byte("too large")
+	var tests = []struct {
+		testName string
+		request  *http.Request
+		expected error
+	}{
+		{
+			testName: "raw too large",
+			request:  httptest.NewRequest(HTTP_POST, "/secrets/tls/file", bytes.NewReader(data)),
+			expected: common.NewRequestTooLargeError("test", 3),
+		},
+		{
+			testName: "multipart too large",
+			request:  newMultipartRequest(t, "file", "tls.key", "application/x-pem-file", data),
+			expected: common.NewRequestTooLargeError("test", 3),
+		},
+		{
+			testName: "multipart without file part",
+			request:  newMultipartRequest(t, "other", "tls.key", "application/x-pem-file", data),
+			expected: common.NewInvalidParamsError("test", "Expected a multipart form with a file part"),
+		},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("readSecretFileUpload - Errors - %v", given.testName), func(t *testing.T) {
+			result, err := readSecretFileUpload("test", given.request, 3)
+			require.Equal(t, err, given.expected, "Error, %v, does not equal expected, %v", err, given.expected)
+			require.Nil(t, result, "Result was not nil: %v", result)
+		})
+	}
+}
+
+func TestParseExpiresAtUrlParam(t *testing.T) {
+	result, err := parseExpiresAtUrlParam("test", map[string][]string{})
+	require.Nil(t, err, "Unexpected error in parseExpiresAtUrlParam: %v", err)
+	require.Nil(t, result, "Result was not nil: %v", result)
+
+	result, err = parseExpiresAtUrlParam("test", map[string][]string{"expires_at": {"2022-05-01T00:00:00Z"}})
+	require.Nil(t, err, "Unexpected error in parseExpiresAtUrlParam: %v", err)
+	require.Equal(t, result.Unix(), int64(1651363200))
+
+	result, err = parseExpiresAtUrlParam("test", map[string][]string{"expires_at": {"2022-05-01"}})
+	require.NotNil(t, err, "no error in parseExpiresAtUrlParam")
+	require.Nil(t, result, "Result was not nil: %v", result)
+}
+
+func TestEncodeFileResponse(t *testing.T) {
+	w := httptest.NewRecorder()
+	file := &common.SecretFile{Name: "tls", Version: 2, ContentType: "application/x-pem-file", Filename: "tls.key", Data: []byte{0x00, 0xff}}
+	err := encodeFileResponse(context.Background(), w, file)
+	require.Nil(t, err, "Unexpected error in encodeFileResponse: %v", err)
+	require.Equal(t, w.Code, http.StatusOK)
+	require.Equal(t, w.Header().Get("Content-Type"), "application/x-pem-file")
+	require.Equal(t, w.Header().Get("Content-Length"), "2")
+	require.Equal(t, w.Header().Get("Content-Disposition"), "attachment; filename=tls.key")
+	require.Equal(t, w.Header().Get(common.HEADER_SECRET_VERSION), "2")
+	require.Equal(t, w.Body.Bytes(), file.Data)
+
+	// anything else falls back to JSON
+	w = httptest.NewRecorder()
+	err = encodeFileResponse(context.Background(), w, nil)
+	require.Nil(t, err, "Unexpected error in encodeFileResponse: %v", err)
+	require.Equal(t, w.Code, http.StatusNoContent)
+}
@@ -67,3 +67,55 @@ func deleteSecretPermissionEndpoint(s Service) endpointBuilder {
 		path:     "/secrets/{name}/permissions",
 	}
 }
+
+func decodeSecretPatternPermissionRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	op := "SecretPatternPermission"
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	var req SecretPatternPermissionRequest
+	err = json.Unmarshal(data, &req)
+	if err != nil {
+		return nil, common.NewInvalidParamsError(op, "Could not unmarshal request: %v", string(data))
+	}
+	return &req, nil
+}
+
+func createSecretPatternPermissionEndpoint(s Service) endpointBuilder {
+	op := "CreateSecretPatternPermission"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*SecretPatternPermissionRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *SecretPatternPermissionRequest. Got %T", reqInterface)
+		}
+		err := s.GrantPatternPermission(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return NewStatusResponse(), nil
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeSecretPatternPermissionRequest,
+		method:   HTTP_POST,
+		path:     "/secret-permissions/patterns",
+	}
+}
+
+func deleteSecretPatternPermissionEndpoint(s Service) endpointBuilder {
+	op := "RevokeSecretPatternPermission"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*SecretPatternPermissionRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *SecretPatternPermissionRequest. Got %T", reqInterface)
+		}
+		return nil, s.RevokePatternPermission(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeSecretPatternPermissionRequest,
+		method:   HTTP_DELETE,
+		path:     "/secret-permissions/patterns",
+	}
+}
@@ -5,22 +5,90 @@ import (
 	"encoding/json"
 	"io/ioutil"
 	"net/http"
+	"time"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+	"github.com/gorilla/mux"
 
 	"github.com/emarcey/data-vault/common"
 )
 
+func decodeListSecretsRequest(op string) httptransport.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		paginationInterface, err := decodePaginationRequest(op)(ctx, r)
+		if err != nil {
+			return nil, err
+		}
+		pagination, ok := paginationInterface.(*PaginationRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected pagination of type *PaginationRequest Got %T", paginationInterface)
+		}
+
+		urlParams := r.URL.Query()
+		req := &common.ListSecretsRequest{
+			Labels:       urlParams["label"],
+			PageSize:     pagination.PageSize,
+			Offset:       pagination.Offset,
+			Cursor:       pagination.Cursor,
+			IncludeTotal: pagination.IncludeTotal,
+		}
+		stringFilters := []struct {
+			paramName    string
+			defaultValue string
+			value        *string
+		}{
+			{"namePrefix", "", &req.NamePrefix},
+			{"nameContains", "", &req.NameContains},
+			{"createdBy", "", &req.CreatedBy},
+			{"sortBy", common.SECRET_SORT_NAME, &req.SortBy},
+			{"sortOrder", common.SORT_ORDER_ASC, &req.SortOrder},
+		}
+		for _, filter := range stringFilters {
+			*filter.value, err = parseStringUrlParam(op, urlParams, filter.paramName, filter.defaultValue)
+			if err != nil {
+				return nil, err
+			}
+		}
+		dateFilters := []struct {
+			paramName string
+			value     **time.Time
+		}{
+			{"createdAfter", &req.CreatedAfter},
+			{"createdBefore", &req.CreatedBefore},
+			{"updatedAfter", &req.UpdatedAfter},
+			{"updatedBefore", &req.UpdatedBefore},
+		}
+		for _, filter := range dateFilters {
+			*filter.value, err = parseOptionalDateUrlParam(op, urlParams, filter.paramName)
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		switch req.SortBy {
+		case common.SECRET_SORT_NAME, common.SECRET_SORT_CREATED_AT, common.SECRET_SORT_UPDATED_AT:
+		default:
+			return nil, common.NewInvalidParamsError(op, "Expected sortBy to be one of %s, %s or %s. Got %s", common.SECRET_SORT_NAME, common.SECRET_SORT_CREATED_AT, common.SECRET_SORT_UPDATED_AT, req.SortBy)
+		}
+		if req.SortOrder != common.SORT_ORDER_ASC && req.SortOrder != common.SORT_ORDER_DESC {
+			return nil, common.NewInvalidParamsError(op, "Expected sortOrder to be %s or %s. Got %s", common.SORT_ORDER_ASC, common.SORT_ORDER_DESC, req.SortOrder)
+		}
+		return req, nil
+	}
+}
+
 func listSecretsEndpoint(s Service) endpointBuilder {
 	op := "ListSecrets"
 	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
-		req, ok := reqInterface.(*PaginationRequest)
+		req, ok := reqInterface.(*common.ListSecretsRequest)
 		if !ok {
-			return nil, common.NewInvalidParamsError(op, "Expected request of type *PaginationRequest. Got %T", reqInterface)
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *common.ListSecretsRequest. Got %T", reqInterface)
 		}
 		return s.ListSecrets(ctx, req)
 	}
 	return endpointBuilder{
 		endpoint: e,
-		decoder:  decodePaginationRequest(op),
+		decoder:  decodeListSecretsRequest(op),
 		method:   HTTP_GET,
 		path:     "/secrets",
 	}
@@ -56,20 +124,299 @@ func createSecretEndpoint(s Service) endpointBuilder {
 	}
 }
 
+func decodeGetSecretRequest(op string) httptransport.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		secretName, err := parseStringValue(op, mux.Vars(r), "name")
+		if err != nil {
+			return nil, err
+		}
+		version, err := parseIntegerUrlParam(op, r.URL.Query(), "version", 0)
+		if err != nil {
+			return nil, err
+		}
+		return &GetSecretRequest{
+			Name:    secretName,
+			Version: version,
+		}, nil
+	}
+}
+
 func getSecretEndpoint(s Service) endpointBuilder {
 	op := "GetSecret"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*GetSecretRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *GetSecretRequest. Got %T", reqInterface)
+		}
+		return s.GetSecret(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeGetSecretRequest(op),
+		method:   HTTP_GET,
+		path:     "/secrets/{name}",
+	}
+}
+
+func decodeGetSecretFieldRequest(op string) httptransport.DecodeRequestFunc {
+	return func(_ context.Context, r *http.Request) (interface{}, error) {
+		vars := mux.Vars(r)
+		secretName, err := parseStringValue(op, vars, "name")
+		if err != nil {
+			return nil, err
+		}
+		field, err := parseStringValue(op, vars, "field")
+		if err != nil {
+			return nil, err
+		}
+		version, err := parseIntegerUrlParam(op, r.URL.Query(), "version", 0)
+		if err != nil {
+			return nil, err
+		}
+		return &GetSecretFieldRequest{
+			Name:    secretName,
+			Field:   field,
+			Version: version,
+		}, nil
+	}
+}
+
+func getSecretFieldEndpoint(s Service) endpointBuilder {
+	op := "GetSecretField"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*GetSecretFieldRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *GetSecretFieldRequest. Got %T", reqInterface)
+		}
+		return s.GetSecretField(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeGetSecretFieldRequest(op),
+		method:   HTTP_GET,
+		path:     "/secrets/{name}/fields/{field}",
+	}
+}
+
+var decodeUpdateSecretUrl = decodeRequestUrlName("UpdateSecret")
+
+func decodeUpdateSecretRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	var req UpdateSecretRequest
+	err = json.Unmarshal(data, &req)
+	if err != nil {
+		return nil, common.NewInvalidParamsError("UpdateSecret", "Could not unmarshal request: %v", string(data))
+	}
+	secretName, err := decodeUpdateSecretUrl(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Name = secretName.(string)
+	return &req, nil
+}
+
+func updateSecretEndpoint(s Service) endpointBuilder {
+	op := "UpdateSecret"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*UpdateSecretRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *UpdateSecretRequest. Got %T", reqInterface)
+		}
+		return s.UpdateSecret(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeUpdateSecretRequest,
+		method:   HTTP_PUT,
+		path:     "/secrets/{name}",
+	}
+}
+
+func listSecretVersionsEndpoint(s Service) endpointBuilder {
+	op := "ListSecretVersions"
 	e := func(ctx context.Context, secretNameInterface interface{}) (interface{}, error) {
 		secretName, ok := secretNameInterface.(string)
 		if !ok {
-			return nil, common.NewInvalidParamsError(op, "Expected user ID of type string. Got %T", secretNameInterface)
+			return nil, common.NewInvalidParamsError(op, "Expected secret name of type string. Got %T", secretNameInterface)
 		}
-		return s.GetSecret(ctx, secretName)
+		return s.ListSecretVersions(ctx, secretName)
 	}
 	return endpointBuilder{
 		endpoint: e,
 		decoder:  decodeRequestUrlName(op),
 		method:   HTTP_GET,
-		path:     "/secrets/{name}",
+		path:     "/secrets/{name}/versions",
+	}
+}
+
+var decodeRollbackSecretUrl = decodeRequestUrlName("RollbackSecret")
+
+func decodeRollbackSecretRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	var req RollbackSecretRequest
+	err = json.Unmarshal(data, &req)
+	if err != nil {
+		return nil, common.NewInvalidParamsError("RollbackSecret", "Could not unmarshal request: %v", string(data))
+	}
+	secretName, err := decodeRollbackSecretUrl(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Name = secretName.(string)
+	return &req, nil
+}
+
+func rollbackSecretEndpoint(s Service) endpointBuilder {
+	op := "RollbackSecret"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*RollbackSecretRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *RollbackSecretRequest. Got %T", reqInterface)
+		}
+		err := s.RollbackSecret(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return NewStatusResponse(), nil
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeRollbackSecretRequest,
+		method:   HTTP_POST,
+		path:     "/secrets/{name}/rollback",
+	}
+}
+
+var decodeUpdateSecretLabelsUrl = decodeRequestUrlName("UpdateSecretLabels")
+
+func decodeUpdateSecretLabelsRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	var req UpdateSecretLabelsRequest
+	err = json.Unmarshal(data, &req)
+	if err != nil {
+		return nil, common.NewInvalidParamsError("UpdateSecretLabels", "Could not unmarshal request: %v", string(data))
+	}
+	secretName, err := decodeUpdateSecretLabelsUrl(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	req.Name = secretName.(string)
+	return &req, nil
+}
+
+func updateSecretLabelsEndpoint(s Service) endpointBuilder {
+	op := "UpdateSecretLabels"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*UpdateSecretLabelsRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *UpdateSecretLabelsRequest. Got %T", reqInterface)
+		}
+		err := s.UpdateSecretLabels(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return NewStatusResponse(), nil
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeUpdateSecretLabelsRequest,
+		method:   HTTP_PUT,
+		path:     "/secrets/{name}/labels",
+	}
+}
+
+func rewrapSecretsEndpoint(s Service) endpointBuilder {
+	e := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return s.RewrapSecrets(ctx)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  noOpDecodeRequest,
+		method:   HTTP_POST,
+		path:     "/keys/rewrap",
+	}
+}
+
+func rekeySecretEndpoint(s Service) endpointBuilder {
+	op := "RekeySecret"
+	e := func(ctx context.Context, secretNameInterface interface{}) (interface{}, error) {
+		secretName, ok := secretNameInterface.(string)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected secret name of type string. Got %T", secretNameInterface)
+		}
+		return s.RekeySecret(ctx, secretName)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeRequestUrlName(op),
+		method:   HTTP_POST,
+		path:     "/secrets/{name}/rekey",
+	}
+}
+
+func getSecretRekeyStatusEndpoint(s Service) endpointBuilder {
+	e := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return s.GetSecretRekeyStatus(ctx)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  noOpDecodeRequest,
+		method:   HTTP_GET,
+		path:     "/keys/rekey",
+	}
+}
+
+// decodePurgeSecretsRequest accepts an empty body, which purges every secret past the retention period
+func decodePurgeSecretsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	var req PurgeSecretsRequest
+	if len(data) > 0 {
+		err = json.Unmarshal(data, &req)
+		if err != nil {
+			return nil, common.NewInvalidParamsError("PurgeSecrets", "Could not unmarshal request: %v", string(data))
+		}
+	}
+	return &req, nil
+}
+
+func purgeSecretsEndpoint(s Service) endpointBuilder {
+	op := "PurgeSecrets"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*PurgeSecretsRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *PurgeSecretsRequest. Got %T", reqInterface)
+		}
+		return s.PurgeSecrets(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodePurgeSecretsRequest,
+		method:   HTTP_POST,
+		path:     "/keys/purge",
+	}
+}
+
+func listSecretPoliciesEndpoint(s Service) endpointBuilder {
+	e := func(ctx context.Context, _ interface{}) (interface{}, error) {
+		return s.ListSecretPolicies(ctx)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  noOpDecodeRequest,
+		method:   HTTP_GET,
+		path:     "/secret-policies",
 	}
 }
 
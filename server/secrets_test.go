@@ -0,0 +1,104 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+func TestDecodeListSecretsRequest(t *testing.T) {
+	r := httptest.NewRequest(HTTP_GET, "/secrets", nil)
+	result, err := decodeListSecretsRequest("test")(context.Background(), r)
+	require.Nil(t, err, "Unexpected error in decodeListSecretsRequest: %v", err)
+	expected := &common.ListSecretsRequest{SortBy: common.SECRET_SORT_NAME, SortOrder: common.SORT_ORDER_ASC, PageSize: 10}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+
+	r = httptest.NewRequest(HTTP_GET, "/secrets?label=env%3Dprod&label=!deprecated&namePrefix=app%2F&nameContains=db&createdBy=admin&createdAfter=2022-05-01&updatedBefore=2022-06-01&sortBy=updated_at&sortOrder=desc&pageSize=5&offset=10", nil)
+	result, err = decodeListSecretsRequest("test")(context.Background(), r)
+	require.Nil(t, err, "Unexpected error in decodeListSecretsRequest: %v", err)
+	createdAfter := time.Date(2022, 5, 1, 0, 0, 0, 0, time.UTC)
+	updatedBefore := time.Date(2022, 6, 1, 0, 0, 0, 0, time.UTC)
+	expected = &common.ListSecretsRequest{
+		NamePrefix:    "app/",
+		NameContains:  "db",
+		CreatedBy:     "admin",
+		Labels:        []string{"env=prod", "!deprecated"},
+		CreatedAfter:  &createdAfter,
+		UpdatedBefore: &updatedBefore,
+		SortBy:        common.SECRET_SORT_UPDATED_AT,
+		SortOrder:     common.SORT_ORDER_DESC,
+		PageSize:      5,
+		Offset:        10,
+	}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+
+	r = httptest.NewRequest(HTTP_GET, "/secrets?cursor=abc&total=true", nil)
+	result, err = decodeListSecretsRequest("test")(context.Background(), r)
+	require.Nil(t, err, "Unexpected error in decodeListSecretsRequest: %v", err)
+	expected = &common.ListSecretsRequest{
+		SortBy:       common.SECRET_SORT_NAME,
+		SortOrder:    common.SORT_ORDER_ASC,
+		PageSize:     10,
+		Cursor:       "abc",
+		IncludeTotal: true,
+	}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+}
+
+func TestDecodeListSecretsRequestErrors(t *testing.T) {
+	var tests = []string{
+		"/secrets?pageSize=a",
+		"/secrets?namePrefix=a&namePrefix=b",
+		"/secrets?createdAfter=yesterday",
+		"/secrets?sortBy=value",
+		"/secrets?sortOrder=up",
+		"/secrets?cursor=abc&offset=10",
+		"/secrets?total=maybe",
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("decodeListSecretsRequest - Errors - %v", idx), func(t *testing.T) {
+			r := httptest.NewRequest(HTTP_GET, given, nil)
+			result, err := decodeListSecretsRequest("test")(context.Background(), r)
+			require.NotNil(t, err, "no error in decodeListSecretsRequest")
+			require.Nil(t, result, "Result was not nil: %v", result)
+		})
+	}
+}
+
+func TestDecodePurgeSecretsRequest(t *testing.T) {
+	var tests = []struct {
+		body     string
+		expected *PurgeSecretsRequest
+	}{
+		{
+			body:     "",
+			expected: &PurgeSecretsRequest{},
+		},
+		{
+			body:     `{"secret_id": "c13dc88b-9563-43d8-bb70-81cb7f5af675"}`,
+			expected: &PurgeSecretsRequest{SecretId: "c13dc88b-9563-43d8-bb70-81cb7f5af675"},
+		},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("decodePurgeSecretsRequest - Successes - %v", idx), func(t *testing.T) {
+			r := httptest.NewRequest(HTTP_POST, "/keys/purge", strings.NewReader(given.body))
+			result, err := decodePurgeSecretsRequest(context.Background(), r)
+			require.Nil(t, err, "Unexpected error in decodePurgeSecretsRequest: %v", err)
+			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
+		})
+	}
+
+	r := httptest.NewRequest(HTTP_POST, "/keys/purge", strings.NewReader(`{"secret_id": 1}`))
+	result, err := decodePurgeSecretsRequest(context.Background(), r)
+	require.NotNil(t, err, "no error in decodePurgeSecretsRequest")
+	require.Nil(t, result, "Result was not nil: %v", result)
+}
@@ -2,43 +2,105 @@ package server
 
 import (
 	"context"
+	"encoding/base64"
 	"time"
 
 	"github.com/emarcey/data-vault/common"
 	"github.com/emarcey/data-vault/database"
 	"github.com/emarcey/data-vault/dependencies"
+	"github.com/emarcey/data-vault/dependencies/credentials"
+	"github.com/emarcey/data-vault/dependencies/secrets"
 )
 
 type Service interface {
 	Version() string
 
 	// users
-	ListUsers(ctx context.Context, req *PaginationRequest) ([]*common.User, error)
+	ListUsers(ctx context.Context, req *PaginationRequest) (*common.UserPage, error)
 	GetUser(ctx context.Context, userId string) (*common.User, error)
 	CreateUser(ctx context.Context, req *CreateUserRequest) (*CreateUserResponse, error)
 	RotateUserSecret(ctx context.Context) (*CreateUserResponse, error)
 	DeleteUser(ctx context.Context, userId string) error
 	GetAccessToken(ctx context.Context) (*common.AccessToken, error)
 
+	// namespaces
+	ListNamespaces(ctx context.Context, req *PaginationRequest) (*common.NamespacePage, error)
+	GetNamespace(ctx context.Context, name string) (*common.Namespace, error)
+	CreateNamespace(ctx context.Context, req *CreateNamespaceRequest) (*common.Namespace, error)
+	DeleteNamespace(ctx context.Context, name string) error
+	AddNamespaceAdmin(ctx context.Context, req *NamespaceAdminRequest) error
+	RemoveNamespaceAdmin(ctx context.Context, req *NamespaceAdminRequest) error
+
 	// user groups
-	ListUserGroups(ctx context.Context, req *PaginationRequest) ([]*common.UserGroup, error)
+	ListUserGroups(ctx context.Context, req *PaginationRequest) (*common.UserGroupPage, error)
 	GetUserGroup(ctx context.Context, userGroupId string) (*common.UserGroup, error)
-	ListUsersInGroup(ctx context.Context, req *ListUsersInGroupRequest) ([]*common.User, error)
+	ListUsersInGroup(ctx context.Context, req *ListUsersInGroupRequest) (*common.UserPage, error)
 	CreateUserGroup(ctx context.Context, req *CreateUserGroupRequest) (*common.UserGroup, error)
 	DeleteUserGroup(ctx context.Context, userGroupId string) error
 	AddUserToGroup(ctx context.Context, req *UserGroupMemberRequest) error
 	RemoveUserFromGroup(ctx context.Context, req *UserGroupMemberRequest) error
 
 	// secrets
-	ListSecrets(ctx context.Context, req *PaginationRequest) ([]*common.Secret, error)
+	ListSecrets(ctx context.Context, req *common.ListSecretsRequest) (*common.SecretPage, error)
 	CreateSecret(ctx context.Context, key *CreateSecretRequest) (*common.Secret, error)
-	GetSecret(ctx context.Context, secretName string) (*common.Secret, error)
+	GetSecret(ctx context.Context, req *GetSecretRequest) (*common.Secret, error)
+	GetSecretField(ctx context.Context, req *GetSecretFieldRequest) (*common.SecretField, error)
+	CreateSecretFile(ctx context.Context, req *CreateSecretFileRequest) (*common.Secret, error)
+	GetSecretFile(ctx context.Context, req *GetSecretRequest) (*common.SecretFile, error)
+	UpdateSecretFile(ctx context.Context, req *UpdateSecretFileRequest) (*common.Secret, error)
+	UpdateSecret(ctx context.Context, req *UpdateSecretRequest) (*common.Secret, error)
+	ListSecretVersions(ctx context.Context, secretName string) ([]*common.SecretVersion, error)
+	RollbackSecret(ctx context.Context, req *RollbackSecretRequest) error
+	UpdateSecretLabels(ctx context.Context, req *UpdateSecretLabelsRequest) error
 	DeleteSecret(ctx context.Context, secretName string) error
+	RewrapSecrets(ctx context.Context) (*RewrapSecretsResponse, error)
+	RekeySecret(ctx context.Context, secretName string) (*RekeySecretResponse, error)
+	GetSecretRekeyStatus(ctx context.Context) (*common.SecretRekeyStatus, error)
+	PurgeSecrets(ctx context.Context, req *PurgeSecretsRequest) (*PurgeSecretsResponse, error)
+	ListSecretPolicies(ctx context.Context) ([]*common.SecretPolicy, error)
 	GrantPermission(ctx context.Context, req *SecretPermissionRequest) error
 	RevokePermission(ctx context.Context, req *SecretPermissionRequest) error
+	GrantPatternPermission(ctx context.Context, req *SecretPatternPermissionRequest) error
+	RevokePatternPermission(ctx context.Context, req *SecretPatternPermissionRequest) error
+
+	// database credentials
+	ListDatabaseRoles(ctx context.Context, req *PaginationRequest) (*common.DatabaseRolePage, error)
+	GetDatabaseRole(ctx context.Context, name string) (*common.DatabaseRole, error)
+	CreateDatabaseRole(ctx context.Context, req *CreateDatabaseRoleRequest) (*common.DatabaseRole, error)
+	DeleteDatabaseRole(ctx context.Context, name string) error
+	GrantDatabaseRolePermission(ctx context.Context, req *DatabaseRolePermissionRequest) error
+	RevokeDatabaseRolePermission(ctx context.Context, req *DatabaseRolePermissionRequest) error
+	GetDatabaseCredentials(ctx context.Context, roleName string) (*common.DatabaseCredentials, error)
+	RenewLease(ctx context.Context, req *RenewLeaseRequest) (*common.Lease, error)
+	RevokeLease(ctx context.Context, leaseId string) error
+
+	// transit encryption
+	ListTransitKeys(ctx context.Context, req *PaginationRequest) (*common.TransitKeyPage, error)
+	GetTransitKey(ctx context.Context, name string) (*common.TransitKey, error)
+	CreateTransitKey(ctx context.Context, req *CreateTransitKeyRequest) (*common.TransitKey, error)
+	RotateTransitKey(ctx context.Context, name string) (*common.TransitKey, error)
+	DeleteTransitKey(ctx context.Context, name string) error
+	GrantTransitKeyPermission(ctx context.Context, req *TransitKeyPermissionRequest) error
+	RevokeTransitKeyPermission(ctx context.Context, req *TransitKeyPermissionRequest) error
+	TransitEncrypt(ctx context.Context, req *TransitEncryptRequest) (*TransitResponse, error)
+	TransitDecrypt(ctx context.Context, req *TransitCiphertextRequest) (*TransitResponse, error)
+	TransitRewrap(ctx context.Context, req *TransitCiphertextRequest) (*TransitResponse, error)
+	TransitDataKey(ctx context.Context, req *TransitDataKeyRequest) (*TransitResponse, error)
+	TransitSign(ctx context.Context, req *TransitSignRequest) (*TransitResponse, error)
+	TransitVerify(ctx context.Context, req *TransitSignRequest) (*TransitVerifyResponse, error)
+	GetTransitPublicKeys(ctx context.Context, name string) (*TransitPublicKeysResponse, error)
+
+	// deleted records
+	ListDeletedSecrets(ctx context.Context, req *PaginationRequest) (*common.DeletedRecordPage, error)
+	RestoreSecret(ctx context.Context, req *RestoreRequest) (*common.Secret, error)
+	ListDeletedUserGroups(ctx context.Context, req *PaginationRequest) (*common.DeletedRecordPage, error)
+	RestoreUserGroup(ctx context.Context, req *RestoreRequest) (*common.UserGroup, error)
+	ListDeletedUsers(ctx context.Context, req *PaginationRequest) (*common.DeletedRecordPage, error)
+	RestoreUser(ctx context.Context, req *RestoreRequest) (*CreateUserResponse, error)
 
 	// access logs
-	ListAccessLogs(ctx context.Context, req *common.ListAccessLogsRequest) ([]*common.AccessLog, error)
+	ListAccessLogs(ctx context.Context, req *common.ListAccessLogsRequest) (*common.AccessLogPage, error)
+	VerifyAccessLogs(ctx context.Context) (*VerifyAccessLogsResponse, error)
 }
 
 type service struct {
@@ -54,24 +116,84 @@ func NewService(version string, deps *dependencies.Dependencies) Service {
 
 }
 
+// auditLog writes the access log for an action. Secrets and patterns outside the default namespace are logged as
+// namespace:name.
+func (s *service) auditLog(ctx context.Context, userId, actionType, targetType, targetId string, actionErr error) error {
+	if targetType == common.TARGET_TYPE_SECRET || targetType == common.TARGET_TYPE_SECRET_PATTERN {
+		namespace, err := common.FetchNamespaceFromContext(ctx)
+		if err == nil {
+			targetId = common.QualifiedName(namespace.Name, targetId)
+		}
+	}
+	err := s.deps.SecretsManager.LogAccess(ctx, common.NewAuditLog(ctx, userId, actionType, targetType, targetId, actionErr))
+	if err != nil {
+		s.deps.Logger.Errorf("Error logging %s on %s %s: %v", actionType, targetType, targetId, err)
+	}
+	return err
+}
+
+// logAction records the outcome of a change in the access log and returns actionErr. By the time it runs the change is
+// already made, so a log that can't be written is only reported in the server log: failing the request would discard
+// a result, like a new user's secret, that can't be fetched again.
+func (s *service) logAction(ctx context.Context, userId, actionType, targetType, targetId string, actionErr error) error {
+	s.auditLog(ctx, userId, actionType, targetType, targetId, actionErr)
+	return actionErr
+}
+
+// logRead records the outcome of a read, or of an operation that changes nothing, in the access log. It returns
+// actionErr, unless the log can't be written, in which case the logging error is returned so the caller never gets an
+// unaudited response.
+func (s *service) logRead(ctx context.Context, userId, actionType, targetType, targetId string, actionErr error) error {
+	err := s.auditLog(ctx, userId, actionType, targetType, targetId, actionErr)
+	if err != nil {
+		return err
+	}
+	return actionErr
+}
+
 func (s *service) Version() string {
 	return s.version
 }
 
-func (s *service) ListUsers(ctx context.Context, req *PaginationRequest) ([]*common.User, error) {
-	return database.ListUsers(ctx, s.deps.Database, req.PageSize, req.Offset)
+func (s *service) ListUsers(ctx context.Context, req *PaginationRequest) (*common.UserPage, error) {
+	after, err := common.DecodeCursor("ListUsers", req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	users, err := database.ListUsers(ctx, s.deps.Database, pageLimit(req.PageSize), req.Offset, after)
+	if err != nil {
+		return nil, err
+	}
+	return newUserPage(users, req.PageSize, req.IncludeTotal, func() (int, error) {
+		return database.CountUsers(ctx, s.deps.Database)
+	})
+}
+
+// newUserPage builds a page out of users fetched with pageLimit
+func newUserPage(users []*common.User, pageSize int, includeTotal bool, count func() (int, error)) (*common.UserPage, error) {
+	page := &common.UserPage{Items: users[:pageSlice(len(users), pageSize)]}
+	cursorAt := func(idx int) *common.PageCursor {
+		return &common.PageCursor{Value: users[idx].Name, Id: users[idx].Id}
+	}
+	info, err := newPageInfo(len(users), pageSize, cursorAt, includeTotal, count)
+	if err != nil {
+		return nil, err
+	}
+	page.PageInfo = info
+	return page, nil
 }
 
 func (s *service) GetUser(ctx context.Context, userId string) (*common.User, error) {
 	return database.GetUserById(ctx, s.deps.Database, userId)
 }
 
-func (s *service) CreateUser(ctx context.Context, req *CreateUserRequest) (*CreateUserResponse, error) {
+func (s *service) CreateUser(ctx context.Context, req *CreateUserRequest) (_ *CreateUserResponse, err error) {
 	callingUser, err := common.FetchUserFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
 	userId := common.GenUuid()
+	defer func() { err = s.logAction(ctx, callingUser.Id, "CreateUser", common.TARGET_TYPE_USER, userId, err) }()
 	userSecret := common.GenUuid()
 	user, err := database.CreateUser(ctx, s.deps.Database, callingUser.Id, userId, req.Name, req.Type, common.HashSha256(userSecret))
 	if err != nil {
@@ -86,11 +208,12 @@ func (s *service) CreateUser(ctx context.Context, req *CreateUserRequest) (*Crea
 	}, nil
 }
 
-func (s *service) RotateUserSecret(ctx context.Context) (*CreateUserResponse, error) {
+func (s *service) RotateUserSecret(ctx context.Context) (_ *CreateUserResponse, err error) {
 	user, err := common.FetchUserFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer func() { err = s.logAction(ctx, user.Id, "RotateUserSecret", common.TARGET_TYPE_USER, user.Id, err) }()
 	userSecret := common.GenUuid()
 	tx, err := s.deps.Database.StartTransaction(ctx)
 	if err != nil {
@@ -117,11 +240,12 @@ func (s *service) RotateUserSecret(ctx context.Context) (*CreateUserResponse, er
 	}, nil
 }
 
-func (s *service) DeleteUser(ctx context.Context, userId string) error {
+func (s *service) DeleteUser(ctx context.Context, userId string) (err error) {
 	callingUser, err := common.FetchUserFromContext(ctx)
 	if err != nil {
 		return err
 	}
+	defer func() { err = s.logAction(ctx, callingUser.Id, "DeleteUser", common.TARGET_TYPE_USER, userId, err) }()
 	tx, err := s.deps.Database.StartTransaction(ctx)
 	if err != nil {
 		return err
@@ -145,11 +269,12 @@ func (s *service) DeleteUser(ctx context.Context, userId string) error {
 	return nil
 }
 
-func (s *service) GetAccessToken(ctx context.Context) (*common.AccessToken, error) {
+func (s *service) GetAccessToken(ctx context.Context) (_ *common.AccessToken, err error) {
 	user, err := common.FetchUserFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer func() { err = s.logAction(ctx, user.Id, "GetAccessToken", common.TARGET_TYPE_USER, user.Id, err) }()
 	tx, err := s.deps.Database.StartTransaction(ctx)
 	if err != nil {
 		return nil, err
@@ -183,36 +308,198 @@ func (s *service) GetAccessToken(ctx context.Context) (*common.AccessToken, erro
 	return token, nil
 }
 
-func (s *service) ListUserGroups(ctx context.Context, req *PaginationRequest) ([]*common.UserGroup, error) {
-	return database.ListUserGroups(ctx, s.deps.Database, req.PageSize, req.Offset)
+func (s *service) ListNamespaces(ctx context.Context, req *PaginationRequest) (*common.NamespacePage, error) {
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	after, err := common.DecodeCursor("ListNamespaces", req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	namespaces, err := database.ListNamespaces(ctx, s.deps.Database, user, pageLimit(req.PageSize), req.Offset, after)
+	if err != nil {
+		return nil, err
+	}
+	page := &common.NamespacePage{Items: namespaces[:pageSlice(len(namespaces), req.PageSize)]}
+	cursorAt := func(idx int) *common.PageCursor {
+		return &common.PageCursor{Value: namespaces[idx].Name, Id: namespaces[idx].Id}
+	}
+	page.PageInfo, err = newPageInfo(len(namespaces), req.PageSize, cursorAt, req.IncludeTotal, func() (int, error) {
+		return database.CountNamespaces(ctx, s.deps.Database)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (s *service) GetNamespace(ctx context.Context, name string) (*common.Namespace, error) {
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return database.GetNamespace(ctx, s.deps.Database, user, name)
+}
+
+func (s *service) CreateNamespace(ctx context.Context, req *CreateNamespaceRequest) (_ *common.Namespace, err error) {
+	op := "CreateNamespace"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_NAMESPACE, req.Name, err) }()
+	err = common.ValidateNamespaceName(op, req.Name)
+	if err != nil {
+		return nil, err
+	}
+
+	namespace, err := database.CreateNamespace(ctx, s.deps.Database, user.Id, common.GenUuid(), req.Name, req.Description)
+	if err != nil {
+		return nil, err
+	}
+	namespace.StatusCode = 201
+	return namespace, nil
+}
+
+// DeleteNamespace deletes an empty namespace. The default namespace can't be deleted.
+func (s *service) DeleteNamespace(ctx context.Context, name string) (err error) {
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, "DeleteNamespace", common.TARGET_TYPE_NAMESPACE, name, err) }()
+
+	namespace, err := database.GetNamespace(ctx, s.deps.Database, user, name)
+	if err != nil {
+		return err
+	}
+	return database.DeleteNamespace(ctx, s.deps.Database, user.Id, namespace.Id)
+}
+
+func (s *service) AddNamespaceAdmin(ctx context.Context, req *NamespaceAdminRequest) (err error) {
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = s.logAction(ctx, user.Id, "AddNamespaceAdmin", common.TARGET_TYPE_NAMESPACE, req.Namespace, err)
+	}()
+
+	namespace, err := database.GetNamespace(ctx, s.deps.Database, user, req.Namespace)
+	if err != nil {
+		return err
+	}
+	return database.CreateNamespaceAdmin(ctx, s.deps.Database, user.Id, namespace.Id, req.UserId)
+}
+
+func (s *service) RemoveNamespaceAdmin(ctx context.Context, req *NamespaceAdminRequest) (err error) {
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = s.logAction(ctx, user.Id, "RemoveNamespaceAdmin", common.TARGET_TYPE_NAMESPACE, req.Namespace, err)
+	}()
+
+	namespace, err := database.GetNamespace(ctx, s.deps.Database, user, req.Namespace)
+	if err != nil {
+		return err
+	}
+	return database.DeleteNamespaceAdmin(ctx, s.deps.Database, user.Id, namespace.Id, req.UserId)
+}
+
+func (s *service) ListUserGroups(ctx context.Context, req *PaginationRequest) (*common.UserGroupPage, error) {
+	namespace, err := common.FetchNamespaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	after, err := common.DecodeCursor("ListUserGroups", req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	userGroups, err := database.ListUserGroups(ctx, s.deps.Database, namespace.Id, pageLimit(req.PageSize), req.Offset, after)
+	if err != nil {
+		return nil, err
+	}
+	page := &common.UserGroupPage{Items: userGroups[:pageSlice(len(userGroups), req.PageSize)]}
+	cursorAt := func(idx int) *common.PageCursor {
+		return &common.PageCursor{Value: userGroups[idx].Name, Id: userGroups[idx].Id}
+	}
+	page.PageInfo, err = newPageInfo(len(userGroups), req.PageSize, cursorAt, req.IncludeTotal, func() (int, error) {
+		return database.CountUserGroups(ctx, s.deps.Database, namespace.Id)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
 }
 
 func (s *service) GetUserGroup(ctx context.Context, userGroupId string) (*common.UserGroup, error) {
-	return database.GetUserGroup(ctx, s.deps.Database, userGroupId)
+	namespace, err := common.FetchNamespaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return database.GetUserGroup(ctx, s.deps.Database, namespace.Id, userGroupId)
+}
+
+// checkUserGroupInNamespace returns a not found error if the user group isn't in the request's namespace
+func (s *service) checkUserGroupInNamespace(ctx context.Context, userGroupId string) error {
+	_, err := s.GetUserGroup(ctx, userGroupId)
+	return err
 }
 
-func (s *service) ListUsersInGroup(ctx context.Context, req *ListUsersInGroupRequest) ([]*common.User, error) {
-	return database.ListUsersInGroup(ctx, s.deps.Database, req.UserGroupId, req.PageSize, req.Offset)
+func (s *service) ListUsersInGroup(ctx context.Context, req *ListUsersInGroupRequest) (*common.UserPage, error) {
+	err := s.checkUserGroupInNamespace(ctx, req.UserGroupId)
+	if err != nil {
+		return nil, err
+	}
+	after, err := common.DecodeCursor("ListUsersInGroup", req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	users, err := database.ListUsersInGroup(ctx, s.deps.Database, req.UserGroupId, pageLimit(req.PageSize), req.Offset, after)
+	if err != nil {
+		return nil, err
+	}
+	return newUserPage(users, req.PageSize, req.IncludeTotal, func() (int, error) {
+		return database.CountUsersInGroup(ctx, s.deps.Database, req.UserGroupId)
+	})
 }
 
-func (s *service) DeleteUserGroup(ctx context.Context, userGroupId string) error {
+func (s *service) DeleteUserGroup(ctx context.Context, userGroupId string) (err error) {
 	user, err := common.FetchUserFromContext(ctx)
 	if err != nil {
 		return err
 	}
-	err = database.DeleteUserGroup(ctx, s.deps.Database, user.Id, userGroupId)
+	namespace, err := common.FetchNamespaceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		err = s.logAction(ctx, user.Id, "DeleteUserGroup", common.TARGET_TYPE_USER_GROUP, userGroupId, err)
+	}()
+	err = database.DeleteUserGroup(ctx, s.deps.Database, user.Id, namespace.Id, userGroupId)
 	if err != nil {
 		return err
 	}
 	return nil
 }
 
-func (s *service) CreateUserGroup(ctx context.Context, req *CreateUserGroupRequest) (*common.UserGroup, error) {
+func (s *service) CreateUserGroup(ctx context.Context, req *CreateUserGroupRequest) (_ *common.UserGroup, err error) {
 	user, err := common.FetchUserFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	userGroup, err := database.CreateUserGroup(ctx, s.deps.Database, user.Id, common.GenUuid(), req.Name)
+	namespace, err := common.FetchNamespaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userGroupId := common.GenUuid()
+	defer func() {
+		err = s.logAction(ctx, user.Id, "CreateUserGroup", common.TARGET_TYPE_USER_GROUP, userGroupId, err)
+	}()
+	userGroup, err := database.CreateUserGroup(ctx, s.deps.Database, user.Id, namespace.Id, userGroupId, req.Name)
 	if err != nil {
 		return nil, err
 	}
@@ -220,11 +507,18 @@ func (s *service) CreateUserGroup(ctx context.Context, req *CreateUserGroupReque
 	return userGroup, nil
 }
 
-func (s *service) AddUserToGroup(ctx context.Context, req *UserGroupMemberRequest) error {
+func (s *service) AddUserToGroup(ctx context.Context, req *UserGroupMemberRequest) (err error) {
 	user, err := common.FetchUserFromContext(ctx)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		err = s.logAction(ctx, user.Id, "AddUserToGroup", common.TARGET_TYPE_USER_GROUP, req.UserGroupId, err)
+	}()
+	err = s.checkUserGroupInNamespace(ctx, req.UserGroupId)
+	if err != nil {
+		return err
+	}
 	err = database.CreateUserGroupMember(ctx, s.deps.Database, user.Id, req.UserGroupId, req.UserId)
 	if err != nil {
 		return err
@@ -232,11 +526,18 @@ func (s *service) AddUserToGroup(ctx context.Context, req *UserGroupMemberReques
 	return nil
 }
 
-func (s *service) RemoveUserFromGroup(ctx context.Context, req *UserGroupMemberRequest) error {
+func (s *service) RemoveUserFromGroup(ctx context.Context, req *UserGroupMemberRequest) (err error) {
 	user, err := common.FetchUserFromContext(ctx)
 	if err != nil {
 		return err
 	}
+	defer func() {
+		err = s.logAction(ctx, user.Id, "RemoveUserFromGroup", common.TARGET_TYPE_USER_GROUP, req.UserGroupId, err)
+	}()
+	err = s.checkUserGroupInNamespace(ctx, req.UserGroupId)
+	if err != nil {
+		return err
+	}
 	err = database.DeleteUserGroupMember(ctx, s.deps.Database, user.Id, req.UserGroupId, req.UserId)
 	if err != nil {
 		return err
@@ -244,150 +545,1465 @@ func (s *service) RemoveUserFromGroup(ctx context.Context, req *UserGroupMemberR
 	return nil
 }
 
-func (s *service) ListSecrets(ctx context.Context, req *PaginationRequest) ([]*common.Secret, error) {
+func (s *service) ListSecrets(ctx context.Context, req *common.ListSecretsRequest) (*common.SecretPage, error) {
+	op := "ListSecrets"
 	user, err := common.FetchUserFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	return database.ListSecrets(ctx, s.deps.Database, user, req.PageSize, req.Offset)
-}
-
-func (s *service) CreateSecret(ctx context.Context, createArgs *CreateSecretRequest) (*common.Secret, error) {
-	user, err := common.FetchUserFromContext(ctx)
+	namespace, err := common.FetchNamespaceFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	err = s.deps.SecretsManager.LogAccess(ctx, common.NewAccessLog(user.Id, "CreateSecret", createArgs.Name))
+	selector, err := common.ParseLabelSelectors(op, req.Labels)
 	if err != nil {
 		return nil, err
 	}
-	secretId := common.GenUuid()
-	ciphertext, encryptedSecret, err := common.EncryptSecret(secretId, createArgs.Value, common.KEY_SIZE)
+	after, err := common.DecodeSortedCursor(op, req.Cursor, req.Sort())
 	if err != nil {
 		return nil, err
 	}
+	secrets, err := database.ListSecrets(ctx, s.deps.Database, user, namespace, req, selector, pageLimit(req.PageSize), after)
+	if err != nil {
+		return nil, err
+	}
+	page := &common.SecretPage{Items: secrets[:pageSlice(len(secrets), req.PageSize)]}
+	cursorAt := func(idx int) *common.PageCursor {
+		return common.SecretCursor(req, secrets[idx])
+	}
+	page.PageInfo, err = newPageInfo(len(secrets), req.PageSize, cursorAt, req.IncludeTotal, func() (int, error) {
+		return database.CountSecrets(ctx, s.deps.Database, user, namespace, req, selector)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// createSecret encrypts plaintext under a new data key and stores it as the first version of secret, which sets
+// everything but the value and its audit fields
+func (s *service) createSecret(ctx context.Context, op string, user *common.User, secret *common.Secret, plaintext []byte) error {
+	if secret.ExpiresAt != nil && !secret.ExpiresAt.After(time.Now()) {
+		return common.NewInvalidParamsError(op, "Expected expires_at to be in the future. Got %v", secret.ExpiresAt)
+	}
+	err := common.ValidateLabels(op, secret.Labels)
+	if err != nil {
+		return err
+	}
+	namespace, err := common.FetchNamespaceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	secretId := common.GenUuid()
+	ciphertext, encryptedSecret, err := common.EncryptSecretBytes(secretId, plaintext, common.KEY_SIZE)
+	if err != nil {
+		return err
+	}
 
 	err = s.deps.SecretsManager.CreateSecret(ctx, encryptedSecret)
+	if err != nil {
+		return err
+	}
+
+	secret.Id = secretId
+	secret.Value = ciphertext
+	secret.CreatedBy = user.Id
+	secret.UpdatedBy = user.Id
+	secret.Version = 1
+	secret.VersionId = secretId
+	secret.StatusCode = 201
+	err = database.CreateSecret(ctx, s.deps.Database, namespace.Id, secret)
+	if err != nil {
+		return err
+	}
+	secret.Value = ""
+	secret.CreatedBy = user.Name
+	secret.UpdatedBy = user.Name
+	return nil
+}
+
+// secretInput returns the value or fields of a create or update request, generated from the named policy if there is
+// one
+func (s *service) secretInput(op, value string, fields map[string]string, policyName string) (string, map[string]string, error) {
+	if policyName == "" {
+		return value, fields, nil
+	}
+	if value != "" || fields != nil {
+		return "", nil, common.NewInvalidParamsError(op, "Expected either generate, value or fields. Got more than one")
+	}
+	policy, ok := s.deps.SecretPolicies[policyName]
+	if !ok {
+		return "", nil, common.NewInvalidParamsError(op, "Unknown secret policy %s", policyName)
+	}
+	return common.GenerateSecret(op, policy)
+}
+
+func (s *service) ListSecretPolicies(_ context.Context) ([]*common.SecretPolicy, error) {
+	return common.SortedSecretPolicies(s.deps.SecretPolicies), nil
+}
+
+func (s *service) CreateSecret(ctx context.Context, createArgs *CreateSecretRequest) (_ *common.Secret, err error) {
+	op := "CreateSecret"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_SECRET, createArgs.Name, err) }()
+	value, fields, err := s.secretInput(op, createArgs.Value, createArgs.Fields, createArgs.Generate)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, valueType, err := common.SecretPlaintext(op, value, fields)
 	if err != nil {
 		return nil, err
 	}
 
 	secret := &common.Secret{
-		Id:          secretId,
-		Value:       ciphertext,
 		Name:        createArgs.Name,
+		ValueType:   valueType,
 		Description: createArgs.Description,
-		CreatedBy:   user.Id,
-		UpdatedBy:   user.Id,
-		StatusCode:  201,
+		Labels:      createArgs.Labels,
+		ExpiresAt:   createArgs.ExpiresAt,
 	}
-	err = database.CreateSecret(ctx, s.deps.Database, secret)
+	err = s.createSecret(ctx, op, user, secret, []byte(plaintext))
 	if err != nil {
 		return nil, err
 	}
+	// generated values are only returned when the secret is read
 	secret.Value = createArgs.Value
-	secret.CreatedBy = user.Name
-	secret.UpdatedBy = user.Name
+	secret.Fields = createArgs.Fields
 	return secret, nil
 }
 
-func (s *service) GetSecret(ctx context.Context, secretName string) (*common.Secret, error) {
+// CreateSecretFile stores an uploaded file as a binary secret. The response doesn't include the file.
+func (s *service) CreateSecretFile(ctx context.Context, req *CreateSecretFileRequest) (_ *common.Secret, err error) {
+	op := "CreateSecretFile"
 	user, err := common.FetchUserFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
-	err = s.deps.SecretsManager.LogAccess(ctx, common.NewAccessLog(user.Id, "GetSecret", secretName))
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_SECRET, req.Name, err) }()
+	contentType, err := common.ValidateSecretFile(op, req.ContentType, req.Filename, len(req.Data), s.deps.ServerConfigs.SecretFileLimit())
 	if err != nil {
 		return nil, err
 	}
 
-	dbSecret, err := database.GetSecretByName(ctx, s.deps.Database, user, secretName)
+	secret := &common.Secret{
+		Name:        req.Name,
+		ValueType:   common.SECRET_VALUE_TYPE_BINARY,
+		ContentType: contentType,
+		Filename:    req.Filename,
+		Description: req.Description,
+		ExpiresAt:   req.ExpiresAt,
+	}
+	err = s.createSecret(ctx, op, user, secret, req.Data)
 	if err != nil {
 		return nil, err
 	}
+	return secret, nil
+}
 
-	encryptedSecret, err := s.deps.SecretsManager.GetSecret(ctx, dbSecret.Id)
+func (s *service) GetSecret(ctx context.Context, req *GetSecretRequest) (_ *common.Secret, err error) {
+	user, err := common.FetchUserFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
+	defer func() { err = s.logRead(ctx, user.Id, "GetSecret", common.TARGET_TYPE_SECRET, req.Name, err) }()
+
+	return s.getDecryptedSecret(ctx, user, req.Name, req.Version)
+}
 
-	plaintext, err := common.DecryptSecret(dbSecret.Value, encryptedSecret)
+// getSecretByName fetches a version of a secret in the request's namespace that the user can read
+func (s *service) getSecretByName(ctx context.Context, user *common.User, name string, version int) (*common.Secret, error) {
+	namespace, err := common.FetchNamespaceFromContext(ctx)
 	if err != nil {
 		return nil, err
 	}
+	return database.GetSecretByName(ctx, s.deps.Database, user, namespace, name, version)
+}
 
-	dbSecret.Value = plaintext
-	return dbSecret, nil
+// getSecretIdWithAccess returns the id of a secret in the request's namespace that the user has level access to
+func (s *service) getSecretIdWithAccess(ctx context.Context, user *common.User, name, level string) (string, error) {
+	namespace, err := common.FetchNamespaceFromContext(ctx)
+	if err != nil {
+		return "", err
+	}
+	return database.GetSecretIdWithAccess(ctx, s.deps.Database, user, namespace, name, level)
 }
 
-func (s *service) DeleteSecret(ctx context.Context, secretName string) error {
-	user, err := common.FetchUserFromContext(ctx)
+// decryptSecret fetches a version of a secret the user can read, and decrypts its value
+func (s *service) decryptSecret(ctx context.Context, user *common.User, name string, version int) (*common.Secret, []byte, error) {
+	dbSecret, err := s.getSecretByName(ctx, user, name, version)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	err = s.deps.SecretsManager.LogAccess(ctx, common.NewAccessLog(user.Id, "DeleteSecret", secretName))
+
+	encryptedSecret, err := s.deps.SecretsManager.GetSecret(ctx, dbSecret.VersionId)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
 
-	err = database.DeleteSecret(ctx, s.deps.Database, user.Id, secretName)
+	plaintext, err := common.DecryptSecretBytes(dbSecret.Value, encryptedSecret)
 	if err != nil {
-		return err
+		return nil, nil, err
 	}
-	return nil
+	return dbSecret, plaintext, nil
 }
 
-func (s *service) GrantPermission(ctx context.Context, req *SecretPermissionRequest) error {
-	op := "GrantPermission"
-	user, err := common.FetchUserFromContext(ctx)
+func (s *service) getDecryptedSecret(ctx context.Context, user *common.User, name string, version int) (*common.Secret, error) {
+	dbSecret, plaintext, err := s.decryptSecret(ctx, user, name, version)
 	if err != nil {
-		return err
+		return nil, err
 	}
 
-	err = s.deps.SecretsManager.LogAccess(ctx, common.NewAccessLog(user.Id, op, req.SecretName))
+	err = common.SetSecretPlaintext(dbSecret, string(plaintext))
 	if err != nil {
-		return err
+		return nil, err
 	}
+	return dbSecret, nil
+}
 
-	secretId, err := database.GetSecretIdWithWriteAccess(ctx, s.deps.Database, user, req.SecretName)
+// GetSecretFile returns the decrypted content of a binary secret
+func (s *service) GetSecretFile(ctx context.Context, req *GetSecretRequest) (_ *common.SecretFile, err error) {
+	op := "GetSecretFile"
+	user, err := common.FetchUserFromContext(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer func() { err = s.logRead(ctx, user.Id, op, common.TARGET_TYPE_SECRET, req.Name, err) }()
 
-	if req.UserId != "" && req.UserGroupId != "" {
-		return common.NewInvalidParamsError(op, "Expected either user id or user group id. Got both: %+v", req)
+	secret, plaintext, err := s.decryptSecret(ctx, user, req.Name, req.Version)
+	if err != nil {
+		return nil, err
 	}
-	if req.UserId != "" {
-		return database.CreateSecretPermission(ctx, s.deps.Database, user.Id, req.UserId, secretId)
+	if secret.ValueType != common.SECRET_VALUE_TYPE_BINARY {
+		return nil, common.NewInvalidParamsError(op, "Secret %s is not a file", req.Name)
 	}
-	return database.CreateSecretGroupPermission(ctx, s.deps.Database, user.Id, req.UserGroupId, secretId)
+	return &common.SecretFile{
+		Name:        secret.Name,
+		Version:     secret.Version,
+		ContentType: secret.ContentType,
+		Filename:    secret.Filename,
+		Data:        plaintext,
+	}, nil
 }
 
-func (s *service) RevokePermission(ctx context.Context, req *SecretPermissionRequest) error {
-	op := "RevokePermission"
+// GetSecretField returns a single field of a multi-field secret. Access is checked against the whole secret.
+func (s *service) GetSecretField(ctx context.Context, req *GetSecretFieldRequest) (_ *common.SecretField, err error) {
+	op := "GetSecretField"
 	user, err := common.FetchUserFromContext(ctx)
 	if err != nil {
-		return err
+		return nil, err
 	}
+	defer func() { err = s.logRead(ctx, user.Id, op, common.TARGET_TYPE_SECRET, req.Name, err) }()
 
-	err = s.deps.SecretsManager.LogAccess(ctx, common.NewAccessLog(user.Id, op, req.SecretName))
+	secret, err := s.getDecryptedSecret(ctx, user, req.Name, req.Version)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if secret.ValueType != common.SECRET_VALUE_TYPE_FIELDS {
+		return nil, common.NewInvalidParamsError(op, "Secret %s does not have fields", req.Name)
 	}
+	value, ok := secret.Fields[req.Field]
+	if !ok {
+		return nil, common.NewResourceNotFoundError(op, "field", req.Field)
+	}
+	return &common.SecretField{
+		SecretName: secret.Name,
+		Field:      req.Field,
+		Value:      value,
+		Version:    secret.Version,
+	}, nil
+}
 
-	secretId, err := database.GetSecretIdWithWriteAccess(ctx, s.deps.Database, user, req.SecretName)
+// updateSecret encrypts plaintext under a new data key and stores it as the new current version of a secret the user
+// can write to. The version sets the value type, content type and filename.
+func (s *service) updateSecret(ctx context.Context, user *common.User, name string, version *common.Secret, plaintext []byte) (*common.Secret, error) {
+	secretId, err := s.getSecretIdWithAccess(ctx, user, name, common.PERMISSION_LEVEL_WRITE)
 	if err != nil {
-		return err
+		return nil, err
 	}
-	if req.UserId != "" && req.UserGroupId != "" {
-		return common.NewInvalidParamsError(op, "Expected either user id or user group id. Got both: %+v", req)
+
+	versionId := common.GenUuid()
+	ciphertext, encryptedSecret, err := common.EncryptSecretBytes(versionId, plaintext, common.KEY_SIZE)
+	if err != nil {
+		return nil, err
 	}
-	if req.UserId != "" {
-		return database.DeleteSecretPermission(ctx, s.deps.Database, user.Id, req.UserId, secretId)
+
+	err = s.deps.SecretsManager.CreateSecret(ctx, encryptedSecret)
+	if err != nil {
+		return nil, err
 	}
-	return database.DeleteSecretGroupPermission(ctx, s.deps.Database, user.Id, req.UserGroupId, secretId)
+
+	version.VersionId = versionId
+	version.Value = ciphertext
+	versionNumber, err := database.CreateSecretVersion(ctx, s.deps.Database, user.Id, secretId, version)
+	if err != nil {
+		return nil, err
+	}
+
+	secret, err := s.getSecretByName(ctx, user, name, versionNumber)
+	if err != nil {
+		return nil, err
+	}
+	secret.Value = ""
+	return secret, nil
 }
 
-func (s *service) ListAccessLogs(ctx context.Context, req *common.ListAccessLogsRequest) ([]*common.AccessLog, error) {
-	return s.deps.SecretsManager.ListAccessLogs(ctx, req)
+func (s *service) UpdateSecret(ctx context.Context, req *UpdateSecretRequest) (_ *common.Secret, err error) {
+	op := "UpdateSecret"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_SECRET, req.Name, err) }()
+
+	value, fields, err := s.secretInput(op, req.Value, req.Fields, req.Generate)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, valueType, err := common.SecretPlaintext(op, value, fields)
+	if err != nil {
+		return nil, err
+	}
+	secret, err := s.updateSecret(ctx, user, req.Name, &common.Secret{ValueType: valueType}, []byte(plaintext))
+	if err != nil {
+		return nil, err
+	}
+	secret.Value = req.Value
+	secret.Fields = req.Fields
+	return secret, nil
+}
+
+// UpdateSecretFile stores an uploaded file as a new version of a secret. The response doesn't include the file.
+func (s *service) UpdateSecretFile(ctx context.Context, req *UpdateSecretFileRequest) (_ *common.Secret, err error) {
+	op := "UpdateSecretFile"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_SECRET, req.Name, err) }()
+	contentType, err := common.ValidateSecretFile(op, req.ContentType, req.Filename, len(req.Data), s.deps.ServerConfigs.SecretFileLimit())
+	if err != nil {
+		return nil, err
+	}
+
+	version := &common.Secret{
+		ValueType:   common.SECRET_VALUE_TYPE_BINARY,
+		ContentType: contentType,
+		Filename:    req.Filename,
+	}
+	return s.updateSecret(ctx, user, req.Name, version, req.Data)
+}
+
+func (s *service) ListSecretVersions(ctx context.Context, secretName string) (_ []*common.SecretVersion, err error) {
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = s.logRead(ctx, user.Id, "ListSecretVersions", common.TARGET_TYPE_SECRET, secretName, err)
+	}()
+
+	secret, err := s.getSecretByName(ctx, user, secretName, 0)
+	if err != nil {
+		return nil, err
+	}
+	return database.ListSecretVersions(ctx, s.deps.Database, secret.Id)
+}
+
+func (s *service) RollbackSecret(ctx context.Context, req *RollbackSecretRequest) (err error) {
+	op := "RollbackSecret"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_SECRET, req.Name, err) }()
+	if req.Version <= 0 {
+		return common.NewInvalidParamsError(op, "Expected positive version. Got %d", req.Version)
+	}
+
+	secretId, err := s.getSecretIdWithAccess(ctx, user, req.Name, common.PERMISSION_LEVEL_WRITE)
+	if err != nil {
+		return err
+	}
+	return database.SetSecretCurrentVersion(ctx, s.deps.Database, user.Id, secretId, req.Version)
+}
+
+// UpdateSecretLabels replaces every label on a secret the user can write to
+func (s *service) UpdateSecretLabels(ctx context.Context, req *UpdateSecretLabelsRequest) (err error) {
+	op := "UpdateSecretLabels"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_SECRET, req.Name, err) }()
+	err = common.ValidateLabels(op, req.Labels)
+	if err != nil {
+		return err
+	}
+
+	secretId, err := s.getSecretIdWithAccess(ctx, user, req.Name, common.PERMISSION_LEVEL_WRITE)
+	if err != nil {
+		return err
+	}
+	return database.SetSecretLabels(ctx, s.deps.Database, user.Id, secretId, req.Labels)
+}
+
+func (s *service) DeleteSecret(ctx context.Context, secretName string) (err error) {
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, "DeleteSecret", common.TARGET_TYPE_SECRET, secretName, err) }()
+	namespace, err := common.FetchNamespaceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	err = database.DeleteSecret(ctx, s.deps.Database, user.Id, namespace.Id, secretName)
+	if err != nil {
+		return err
+	}
+	return nil
+}
+
+// RewrapSecrets re-wraps every stored data key under the current key encryption key, so an old KEK can be retired
+func (s *service) RewrapSecrets(ctx context.Context) (_ *RewrapSecretsResponse, err error) {
+	op := "RewrapSecrets"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_KEY, "", err) }()
+	rewrapper, ok := s.deps.SecretsManager.(secrets.KeyRewrapper)
+	if !ok {
+		return nil, common.NewInvalidParamsError(op, "No key encryption key is configured for the secrets manager")
+	}
+
+	versionIds, err := database.ListSecretVersionIds(ctx, s.deps.Database)
+	if err != nil {
+		return nil, err
+	}
+	// database role connection URLs are encrypted like secret values, under keys stored by role id
+	roleIds, err := database.ListDatabaseRoleIds(ctx, s.deps.Database)
+	if err != nil {
+		return nil, err
+	}
+	// transit key material is stored by transit key version id
+	transitVersionIds, err := database.ListTransitKeyVersionIds(ctx, s.deps.Database)
+	if err != nil {
+		return nil, err
+	}
+	keyIds := append(append(versionIds, roleIds...), transitVersionIds...)
+	resp := &RewrapSecretsResponse{KekId: rewrapper.CurrentKeyId(), Total: len(keyIds)}
+	for _, keyId := range keyIds {
+		rewrapped, err := rewrapper.RewrapSecret(ctx, keyId)
+		if err != nil {
+			return nil, err
+		}
+		if rewrapped {
+			resp.Rewrapped++
+		}
+	}
+	return resp, nil
+}
+
+// RekeySecret re-encrypts every version of a secret under fresh data keys and deletes the old keys
+func (s *service) RekeySecret(ctx context.Context, secretName string) (_ *RekeySecretResponse, err error) {
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, "RekeySecret", common.TARGET_TYPE_SECRET, secretName, err) }()
+
+	secretId, err := s.getSecretIdWithAccess(ctx, user, secretName, common.PERMISSION_LEVEL_MANAGE)
+	if err != nil {
+		return nil, err
+	}
+	rekeyed, err := s.deps.SecretRekeyer.RekeySecret(ctx, secretId)
+	if err != nil {
+		return nil, err
+	}
+	return &RekeySecretResponse{Name: secretName, Rekeyed: rekeyed}, nil
+}
+
+func (s *service) GetSecretRekeyStatus(ctx context.Context) (_ *common.SecretRekeyStatus, err error) {
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logRead(ctx, user.Id, "GetSecretRekeyStatus", common.TARGET_TYPE_KEY, "", err) }()
+
+	return s.deps.SecretRekeyer.Status(ctx)
+}
+
+// PurgeSecrets deletes the data keys of deleted secrets and clears their ciphertext, so they can never be recovered
+func (s *service) PurgeSecrets(ctx context.Context, req *PurgeSecretsRequest) (_ *PurgeSecretsResponse, err error) {
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, "PurgeSecrets", common.TARGET_TYPE_KEY, req.SecretId, err) }()
+
+	if req.SecretId != "" {
+		err = s.deps.SecretPurger.PurgeSecret(ctx, user.Id, req.SecretId)
+		if err != nil {
+			return nil, err
+		}
+		return &PurgeSecretsResponse{Purged: 1}, nil
+	}
+	purged, err := s.deps.SecretPurger.PurgeDeleted(ctx, user.Id)
+	if err != nil {
+		return nil, err
+	}
+	return &PurgeSecretsResponse{Purged: purged}, nil
+}
+
+func (s *service) GrantPermission(ctx context.Context, req *SecretPermissionRequest) (err error) {
+	op := "GrantPermission"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_SECRET, req.SecretName, err) }()
+
+	secretId, err := s.getSecretIdWithAccess(ctx, user, req.SecretName, common.PERMISSION_LEVEL_MANAGE)
+	if err != nil {
+		return err
+	}
+
+	if req.UserId != "" && req.UserGroupId != "" {
+		return common.NewInvalidParamsError(op, "Expected either user id or user group id. Got both: %+v", req)
+	}
+	level, err := common.ParsePermissionLevel(op, req.Level)
+	if err != nil {
+		return err
+	}
+	if req.UserId != "" {
+		return database.CreateSecretPermission(ctx, s.deps.Database, user.Id, req.UserId, secretId, level)
+	}
+	err = s.checkUserGroupInNamespace(ctx, req.UserGroupId)
+	if err != nil {
+		return err
+	}
+	return database.CreateSecretGroupPermission(ctx, s.deps.Database, user.Id, req.UserGroupId, secretId, level)
+}
+
+func (s *service) RevokePermission(ctx context.Context, req *SecretPermissionRequest) (err error) {
+	op := "RevokePermission"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_SECRET, req.SecretName, err) }()
+
+	secretId, err := s.getSecretIdWithAccess(ctx, user, req.SecretName, common.PERMISSION_LEVEL_MANAGE)
+	if err != nil {
+		return err
+	}
+	if req.UserId != "" && req.UserGroupId != "" {
+		return common.NewInvalidParamsError(op, "Expected either user id or user group id. Got both: %+v", req)
+	}
+
+	// revoking a level above read leaves the grant in place at the level below it
+	remainingLevel := ""
+	if req.Level != "" {
+		level, err := common.ParsePermissionLevel(op, req.Level)
+		if err != nil {
+			return err
+		}
+		remainingLevel = common.PermissionLevelBelow(level)
+	}
+	if req.UserId != "" {
+		if remainingLevel != "" {
+			return database.DowngradeSecretPermission(ctx, s.deps.Database, user.Id, req.UserId, secretId, remainingLevel)
+		}
+		return database.DeleteSecretPermission(ctx, s.deps.Database, user.Id, req.UserId, secretId)
+	}
+	if remainingLevel != "" {
+		return database.DowngradeSecretGroupPermission(ctx, s.deps.Database, user.Id, req.UserGroupId, secretId, remainingLevel)
+	}
+	return database.DeleteSecretGroupPermission(ctx, s.deps.Database, user.Id, req.UserGroupId, secretId)
+}
+
+func (s *service) GrantPatternPermission(ctx context.Context, req *SecretPatternPermissionRequest) (err error) {
+	op := "GrantPatternPermission"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_SECRET_PATTERN, req.Pattern, err) }()
+	namespace, err := common.FetchNamespaceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	likePattern, err := common.SecretPatternToLike(req.Pattern)
+	if err != nil {
+		return err
+	}
+	if req.UserId != "" && req.UserGroupId != "" {
+		return common.NewInvalidParamsError(op, "Expected either user id or user group id. Got both: %+v", req)
+	}
+	level, err := common.ParsePermissionLevel(op, req.Level)
+	if err != nil {
+		return err
+	}
+	if req.UserId != "" {
+		return database.CreateSecretPatternPermission(ctx, s.deps.Database, user.Id, req.UserId, namespace.Id, req.Pattern, likePattern, level)
+	}
+	err = s.checkUserGroupInNamespace(ctx, req.UserGroupId)
+	if err != nil {
+		return err
+	}
+	return database.CreateSecretGroupPatternPermission(ctx, s.deps.Database, user.Id, req.UserGroupId, namespace.Id, req.Pattern, likePattern, level)
+}
+
+func (s *service) RevokePatternPermission(ctx context.Context, req *SecretPatternPermissionRequest) (err error) {
+	op := "RevokePatternPermission"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_SECRET_PATTERN, req.Pattern, err) }()
+	namespace, err := common.FetchNamespaceFromContext(ctx)
+	if err != nil {
+		return err
+	}
+
+	if req.UserId != "" && req.UserGroupId != "" {
+		return common.NewInvalidParamsError(op, "Expected either user id or user group id. Got both: %+v", req)
+	}
+	if req.UserId != "" {
+		return database.DeleteSecretPatternPermission(ctx, s.deps.Database, user.Id, req.UserId, namespace.Id, req.Pattern)
+	}
+	return database.DeleteSecretGroupPatternPermission(ctx, s.deps.Database, user.Id, req.UserGroupId, namespace.Id, req.Pattern)
+}
+
+func (s *service) ListDatabaseRoles(ctx context.Context, req *PaginationRequest) (*common.DatabaseRolePage, error) {
+	after, err := common.DecodeCursor("ListDatabaseRoles", req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	roles, err := database.ListDatabaseRoles(ctx, s.deps.Database, pageLimit(req.PageSize), req.Offset, after)
+	if err != nil {
+		return nil, err
+	}
+	page := &common.DatabaseRolePage{Items: roles[:pageSlice(len(roles), req.PageSize)]}
+	cursorAt := func(idx int) *common.PageCursor {
+		return &common.PageCursor{Value: roles[idx].Name, Id: roles[idx].Id}
+	}
+	page.PageInfo, err = newPageInfo(len(roles), req.PageSize, cursorAt, req.IncludeTotal, func() (int, error) {
+		return database.CountDatabaseRoles(ctx, s.deps.Database)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (s *service) GetDatabaseRole(ctx context.Context, name string) (*common.DatabaseRole, error) {
+	return database.GetDatabaseRole(ctx, s.deps.Database, name)
+}
+
+// CreateDatabaseRole stores a role that issues credentials on a database. The connection URL is encrypted like a
+// secret value and never returned.
+func (s *service) CreateDatabaseRole(ctx context.Context, req *CreateDatabaseRoleRequest) (_ *common.DatabaseRole, err error) {
+	op := "CreateDatabaseRole"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_DATABASE_ROLE, req.Name, err) }()
+
+	role := &common.DatabaseRole{
+		Id:                   common.GenUuid(),
+		Name:                 req.Name,
+		ConnectionUrl:        req.ConnectionUrl,
+		CreationStatements:   req.CreationStatements,
+		RenewStatements:      req.RenewStatements,
+		RevocationStatements: req.RevocationStatements,
+		DefaultTtlSeconds:    req.DefaultTtlSeconds,
+		MaxTtlSeconds:        req.MaxTtlSeconds,
+	}
+	err = common.ValidateDatabaseRole(op, role)
+	if err != nil {
+		return nil, err
+	}
+	err = credentials.EncryptConnectionUrl(ctx, s.deps.SecretsManager, role)
+	if err != nil {
+		return nil, err
+	}
+	err = database.CreateDatabaseRole(ctx, s.deps.Database, user.Id, role)
+	if err != nil {
+		return nil, err
+	}
+	role.ConnectionUrl = ""
+	role.CreatedBy = user.Name
+	role.StatusCode = 201
+	return role, nil
+}
+
+// DeleteDatabaseRole stops a role issuing credentials. Its outstanding leases are left to expire.
+func (s *service) DeleteDatabaseRole(ctx context.Context, name string) (err error) {
+	op := "DeleteDatabaseRole"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_DATABASE_ROLE, name, err) }()
+	return database.DeleteDatabaseRole(ctx, s.deps.Database, user.Id, name)
+}
+
+// databaseRolePermissionRole validates a database role permission request and returns the id of its role
+func (s *service) databaseRolePermissionRole(ctx context.Context, op string, req *DatabaseRolePermissionRequest) (string, error) {
+	if (req.UserId == "") == (req.UserGroupId == "") {
+		return "", common.NewInvalidParamsError(op, "Expected either user id or user group id. Got: %+v", req)
+	}
+	role, err := database.GetDatabaseRole(ctx, s.deps.Database, req.RoleName)
+	if err != nil {
+		return "", err
+	}
+	return role.Id, nil
+}
+
+func (s *service) GrantDatabaseRolePermission(ctx context.Context, req *DatabaseRolePermissionRequest) (err error) {
+	op := "GrantDatabaseRolePermission"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_DATABASE_ROLE, req.RoleName, err) }()
+
+	roleId, err := s.databaseRolePermissionRole(ctx, op, req)
+	if err != nil {
+		return err
+	}
+	return database.CreateDatabaseRolePermission(ctx, s.deps.Database, user.Id, roleId, req.UserId, req.UserGroupId)
+}
+
+func (s *service) RevokeDatabaseRolePermission(ctx context.Context, req *DatabaseRolePermissionRequest) (err error) {
+	op := "RevokeDatabaseRolePermission"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_DATABASE_ROLE, req.RoleName, err) }()
+
+	roleId, err := s.databaseRolePermissionRole(ctx, op, req)
+	if err != nil {
+		return err
+	}
+	return database.DeleteDatabaseRolePermission(ctx, s.deps.Database, user.Id, roleId, req.UserId, req.UserGroupId)
+}
+
+// GetDatabaseCredentials creates a new database user for a role, under a lease that expires after the role's
+// default TTL. The password is only ever returned here.
+func (s *service) GetDatabaseCredentials(ctx context.Context, roleName string) (_ *common.DatabaseCredentials, err error) {
+	op := "GetDatabaseCredentials"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_DATABASE_ROLE, roleName, err) }()
+
+	role, err := database.GetDatabaseRoleWithAccess(ctx, s.deps.Database, user, roleName)
+	if err != nil {
+		return nil, err
+	}
+	connectionUrl, err := credentials.DecryptConnectionUrl(ctx, s.deps.SecretsManager, role)
+	if err != nil {
+		return nil, err
+	}
+	username, err := common.GenDatabaseUsername(role.Name)
+	if err != nil {
+		return nil, err
+	}
+	password, err := common.GenDatabasePassword()
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now()
+	lease := &common.Lease{
+		Id:           common.GenUuid(),
+		RoleId:       role.Id,
+		RoleName:     role.Name,
+		UserId:       user.Id,
+		Username:     username,
+		MaxExpiresAt: now.Add(time.Duration(role.MaxTtlSeconds) * time.Second),
+	}
+	lease.ExpiresAt = common.LeaseExpiry(now, role.DefaultTtlSeconds, lease.MaxExpiresAt)
+
+	err = s.deps.Credentials.Execute(ctx, connectionUrl, common.RenderStatements(role.CreationStatements, username, password, lease.ExpiresAt))
+	if err != nil {
+		return nil, err
+	}
+	err = database.CreateLease(ctx, s.deps.Database, lease)
+	if err != nil {
+		// without a lease nothing would ever revoke the credentials, so they're dropped straight away
+		revokeErr := s.deps.Credentials.Execute(ctx, connectionUrl, common.RenderStatements(role.RevocationStatements, username, "", lease.ExpiresAt))
+		if revokeErr != nil {
+			s.deps.Logger.Errorf("Error dropping credentials %s after failing to create lease: %v", username, revokeErr)
+		}
+		return nil, err
+	}
+
+	return &common.DatabaseCredentials{
+		LeaseId:      lease.Id,
+		RoleName:     role.Name,
+		Username:     username,
+		Password:     password,
+		ExpiresAt:    lease.ExpiresAt,
+		MaxExpiresAt: lease.MaxExpiresAt,
+	}, nil
+}
+
+// getOwnedLease returns an unrevoked lease, if user holds it or is an admin
+func (s *service) getOwnedLease(ctx context.Context, op string, user *common.User, leaseId string) (*common.Lease, error) {
+	lease, err := database.GetLease(ctx, s.deps.Database, leaseId)
+	if err != nil {
+		return nil, err
+	}
+	if lease.UserId != user.Id && !user.IsAdmin() {
+		return nil, common.NewResourceAccessDeniedError(op, "id", leaseId)
+	}
+	return lease, nil
+}
+
+// RenewLease extends a lease from now, up to its max expiry. Expired leases can't be renewed.
+func (s *service) RenewLease(ctx context.Context, req *RenewLeaseRequest) (_ *common.Lease, err error) {
+	op := "RenewLease"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_LEASE, req.LeaseId, err) }()
+
+	if req.IncrementSeconds < 0 {
+		return nil, common.NewInvalidParamsError(op, "Expected positive increment_seconds. Got %d", req.IncrementSeconds)
+	}
+	lease, err := s.getOwnedLease(ctx, op, user, req.LeaseId)
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	if !lease.ExpiresAt.After(now) {
+		return nil, common.NewInvalidParamsError(op, "Lease %s has expired", lease.Id)
+	}
+	role, err := database.GetDatabaseRoleById(ctx, s.deps.Database, lease.RoleId)
+	if err != nil {
+		return nil, err
+	}
+	increment := req.IncrementSeconds
+	if increment == 0 {
+		increment = role.DefaultTtlSeconds
+	}
+	expiresAt := common.LeaseExpiry(now, increment, lease.MaxExpiresAt)
+
+	connectionUrl, err := credentials.DecryptConnectionUrl(ctx, s.deps.SecretsManager, role)
+	if err != nil {
+		return nil, err
+	}
+	err = s.deps.Credentials.Execute(ctx, connectionUrl, common.RenderStatements(role.RenewStatements, lease.Username, "", expiresAt))
+	if err != nil {
+		return nil, err
+	}
+	err = database.RenewLease(ctx, s.deps.Database, lease.Id, expiresAt)
+	if err != nil {
+		return nil, err
+	}
+	lease.ExpiresAt = expiresAt
+	return lease, nil
+}
+
+// RevokeLease drops a lease's credentials before it expires
+func (s *service) RevokeLease(ctx context.Context, leaseId string) (err error) {
+	op := "RevokeLease"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_LEASE, leaseId, err) }()
+
+	lease, err := s.getOwnedLease(ctx, op, user, leaseId)
+	if err != nil {
+		return err
+	}
+	return s.deps.LeaseRevoker.Revoke(ctx, lease)
+}
+
+func (s *service) ListTransitKeys(ctx context.Context, req *PaginationRequest) (*common.TransitKeyPage, error) {
+	after, err := common.DecodeCursor("ListTransitKeys", req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	keys, err := database.ListTransitKeys(ctx, s.deps.Database, pageLimit(req.PageSize), req.Offset, after)
+	if err != nil {
+		return nil, err
+	}
+	page := &common.TransitKeyPage{Items: keys[:pageSlice(len(keys), req.PageSize)]}
+	cursorAt := func(idx int) *common.PageCursor {
+		return &common.PageCursor{Value: keys[idx].Name, Id: keys[idx].Id}
+	}
+	page.PageInfo, err = newPageInfo(len(keys), req.PageSize, cursorAt, req.IncludeTotal, func() (int, error) {
+		return database.CountTransitKeys(ctx, s.deps.Database)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (s *service) GetTransitKey(ctx context.Context, name string) (*common.TransitKey, error) {
+	return database.GetTransitKey(ctx, s.deps.Database, name)
+}
+
+// createTransitKeyVersion generates the key material of a new transit key version, and stores it in the secrets
+// manager before the version is written, like a secret's data key
+func (s *service) createTransitKeyVersion(ctx context.Context, keyType string) (string, error) {
+	versionId := common.GenUuid()
+	keyMaterial, err := common.NewTransitKeyMaterial(versionId, keyType)
+	if err != nil {
+		return "", err
+	}
+	err = s.deps.SecretsManager.CreateSecret(ctx, keyMaterial)
+	if err != nil {
+		return "", err
+	}
+	return versionId, nil
+}
+
+// getTransitKeyVersion returns the key material of a version of a transit key
+func (s *service) getTransitKeyVersion(ctx context.Context, key *common.TransitKey, version int) (*common.EncryptedSecret, error) {
+	versionId, err := database.GetTransitKeyVersionId(ctx, s.deps.Database, key.Id, version)
+	if err != nil {
+		return nil, err
+	}
+	return s.deps.SecretsManager.GetSecret(ctx, versionId)
+}
+
+// getTransitKeyForUse returns a transit key the user has access to, if its type can be used for use
+func (s *service) getTransitKeyForUse(ctx context.Context, op string, user *common.User, name, use string) (*common.TransitKey, error) {
+	key, err := database.GetTransitKeyWithAccess(ctx, s.deps.Database, user, name)
+	if err != nil {
+		return nil, err
+	}
+	err = common.CheckTransitKeyUse(op, key, use)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// CreateTransitKey creates a transit key at version 1. Its key material is generated on the server and never returned.
+func (s *service) CreateTransitKey(ctx context.Context, req *CreateTransitKeyRequest) (_ *common.TransitKey, err error) {
+	op := "CreateTransitKey"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_TRANSIT_KEY, req.Name, err) }()
+
+	if !common.TRANSIT_KEY_NAME_REGEX.MatchString(req.Name) {
+		return nil, common.NewInvalidParamsError(op, "Expected name of up to 64 lowercase letters, digits, _ or -. Got %s", req.Name)
+	}
+	if req.Type == "" {
+		req.Type = common.TRANSIT_KEY_TYPE_AES256_GCM
+	}
+	err = common.ValidateTransitKeyType(op, req.Type)
+	if err != nil {
+		return nil, err
+	}
+	key := &common.TransitKey{Id: common.GenUuid(), Name: req.Name, Type: req.Type, LatestVersion: 1}
+	versionId, err := s.createTransitKeyVersion(ctx, key.Type)
+	if err != nil {
+		return nil, err
+	}
+	err = database.CreateTransitKey(ctx, s.deps.Database, user.Id, key, versionId)
+	if err != nil {
+		return nil, err
+	}
+	key.CreatedBy = user.Name
+	key.StatusCode = 201
+	return key, nil
+}
+
+// RotateTransitKey adds a new version to a transit key, which encrypts from then on. Earlier versions still decrypt.
+func (s *service) RotateTransitKey(ctx context.Context, name string) (_ *common.TransitKey, err error) {
+	op := "RotateTransitKey"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_TRANSIT_KEY, name, err) }()
+
+	key, err := database.GetTransitKey(ctx, s.deps.Database, name)
+	if err != nil {
+		return nil, err
+	}
+	versionId, err := s.createTransitKeyVersion(ctx, key.Type)
+	if err != nil {
+		return nil, err
+	}
+	key.LatestVersion, err = database.RotateTransitKey(ctx, s.deps.Database, user.Id, key.Id, versionId)
+	if err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// DeleteTransitKey stops a transit key encrypting or decrypting. Anything still encrypted under it can't be recovered.
+func (s *service) DeleteTransitKey(ctx context.Context, name string) (err error) {
+	op := "DeleteTransitKey"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_TRANSIT_KEY, name, err) }()
+	return database.DeleteTransitKey(ctx, s.deps.Database, user.Id, name)
+}
+
+// transitKeyPermissionKey validates a transit key permission request and returns the id of its key
+func (s *service) transitKeyPermissionKey(ctx context.Context, op string, req *TransitKeyPermissionRequest) (string, error) {
+	if (req.UserId == "") == (req.UserGroupId == "") {
+		return "", common.NewInvalidParamsError(op, "Expected either user id or user group id. Got: %+v", req)
+	}
+	key, err := database.GetTransitKey(ctx, s.deps.Database, req.KeyName)
+	if err != nil {
+		return "", err
+	}
+	return key.Id, nil
+}
+
+func (s *service) GrantTransitKeyPermission(ctx context.Context, req *TransitKeyPermissionRequest) (err error) {
+	op := "GrantTransitKeyPermission"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_TRANSIT_KEY, req.KeyName, err) }()
+
+	keyId, err := s.transitKeyPermissionKey(ctx, op, req)
+	if err != nil {
+		return err
+	}
+	return database.CreateTransitKeyPermission(ctx, s.deps.Database, user.Id, keyId, req.UserId, req.UserGroupId)
+}
+
+func (s *service) RevokeTransitKeyPermission(ctx context.Context, req *TransitKeyPermissionRequest) (err error) {
+	op := "RevokeTransitKeyPermission"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return err
+	}
+	defer func() { err = s.logAction(ctx, user.Id, op, common.TARGET_TYPE_TRANSIT_KEY, req.KeyName, err) }()
+
+	keyId, err := s.transitKeyPermissionKey(ctx, op, req)
+	if err != nil {
+		return err
+	}
+	return database.DeleteTransitKeyPermission(ctx, s.deps.Database, user.Id, keyId, req.UserId, req.UserGroupId)
+}
+
+// TransitEncrypt encrypts base64 plaintext under the latest version of a transit key. Nothing is stored.
+func (s *service) TransitEncrypt(ctx context.Context, req *TransitEncryptRequest) (_ *TransitResponse, err error) {
+	op := "TransitEncrypt"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logRead(ctx, user.Id, op, common.TARGET_TYPE_TRANSIT_KEY, req.KeyName, err) }()
+
+	key, err := s.getTransitKeyForUse(ctx, op, user, req.KeyName, common.TRANSIT_KEY_USE_ENCRYPT)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := common.DecodeTransitPlaintext(op, req.Plaintext)
+	if err != nil {
+		return nil, err
+	}
+	keyMaterial, err := s.getTransitKeyVersion(ctx, key, key.LatestVersion)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := common.TransitEncrypt(op, key.LatestVersion, keyMaterial, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return &TransitResponse{Ciphertext: ciphertext, KeyVersion: key.LatestVersion}, nil
+}
+
+// transitDecrypt decrypts a ciphertext with the version of key it names
+func (s *service) transitDecrypt(ctx context.Context, op string, key *common.TransitKey, ciphertext string) ([]byte, error) {
+	version, sealed, err := common.ParseTransitCiphertext(op, ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	keyMaterial, err := s.getTransitKeyVersion(ctx, key, version)
+	if err != nil {
+		return nil, err
+	}
+	return common.TransitDecrypt(op, keyMaterial, sealed)
+}
+
+// TransitDecrypt decrypts a ciphertext from TransitEncrypt or TransitRewrap, returning base64 plaintext
+func (s *service) TransitDecrypt(ctx context.Context, req *TransitCiphertextRequest) (_ *TransitResponse, err error) {
+	op := "TransitDecrypt"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logRead(ctx, user.Id, op, common.TARGET_TYPE_TRANSIT_KEY, req.KeyName, err) }()
+
+	key, err := s.getTransitKeyForUse(ctx, op, user, req.KeyName, common.TRANSIT_KEY_USE_ENCRYPT)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := s.transitDecrypt(ctx, op, key, req.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	return &TransitResponse{Plaintext: base64.StdEncoding.EncodeToString(plaintext)}, nil
+}
+
+// TransitRewrap re-encrypts a ciphertext under the latest version of its transit key, without returning the
+// plaintext, so stored ciphertexts can be moved off old versions after a rotation
+func (s *service) TransitRewrap(ctx context.Context, req *TransitCiphertextRequest) (_ *TransitResponse, err error) {
+	op := "TransitRewrap"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logRead(ctx, user.Id, op, common.TARGET_TYPE_TRANSIT_KEY, req.KeyName, err) }()
+
+	key, err := s.getTransitKeyForUse(ctx, op, user, req.KeyName, common.TRANSIT_KEY_USE_ENCRYPT)
+	if err != nil {
+		return nil, err
+	}
+	plaintext, err := s.transitDecrypt(ctx, op, key, req.Ciphertext)
+	if err != nil {
+		return nil, err
+	}
+	keyMaterial, err := s.getTransitKeyVersion(ctx, key, key.LatestVersion)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := common.TransitEncrypt(op, key.LatestVersion, keyMaterial, plaintext)
+	if err != nil {
+		return nil, err
+	}
+	return &TransitResponse{Ciphertext: ciphertext, KeyVersion: key.LatestVersion}, nil
+}
+
+// TransitDataKey generates a random data key for the caller to encrypt data with locally, returned along with the
+// key encrypted under the latest version of a transit key. The encrypted key can be stored with the data and sent to
+// TransitDecrypt when it's needed again.
+func (s *service) TransitDataKey(ctx context.Context, req *TransitDataKeyRequest) (_ *TransitResponse, err error) {
+	op := "TransitDataKey"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logRead(ctx, user.Id, op, common.TARGET_TYPE_TRANSIT_KEY, req.KeyName, err) }()
+
+	key, err := s.getTransitKeyForUse(ctx, op, user, req.KeyName, common.TRANSIT_KEY_USE_ENCRYPT)
+	if err != nil {
+		return nil, err
+	}
+	size, err := common.TransitDataKeySize(op, req.Bits)
+	if err != nil {
+		return nil, err
+	}
+	dataKey, err := common.GenRandBytes(size)
+	if err != nil {
+		return nil, common.NewInternalServerErrorFromError(op, err)
+	}
+	keyMaterial, err := s.getTransitKeyVersion(ctx, key, key.LatestVersion)
+	if err != nil {
+		return nil, err
+	}
+	ciphertext, err := common.TransitEncrypt(op, key.LatestVersion, keyMaterial, dataKey)
+	if err != nil {
+		return nil, err
+	}
+	resp := &TransitResponse{Ciphertext: ciphertext, KeyVersion: key.LatestVersion}
+	if !req.WrappedOnly {
+		resp.Plaintext = base64.StdEncoding.EncodeToString(dataKey)
+	}
+	return resp, nil
+}
+
+// TransitSign signs base64 input with the latest version of a signing transit key. The private key never leaves the
+// server.
+func (s *service) TransitSign(ctx context.Context, req *TransitSignRequest) (_ *TransitResponse, err error) {
+	op := "TransitSign"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logRead(ctx, user.Id, op, common.TARGET_TYPE_TRANSIT_KEY, req.KeyName, err) }()
+
+	key, err := s.getTransitKeyForUse(ctx, op, user, req.KeyName, common.TRANSIT_KEY_USE_SIGN)
+	if err != nil {
+		return nil, err
+	}
+	input, err := common.DecodeTransitInput(op, "input", req.Input)
+	if err != nil {
+		return nil, err
+	}
+	keyMaterial, err := s.getTransitKeyVersion(ctx, key, key.LatestVersion)
+	if err != nil {
+		return nil, err
+	}
+	signature, err := common.TransitSign(op, key.Type, key.LatestVersion, keyMaterial, input, req.Prehashed)
+	if err != nil {
+		return nil, err
+	}
+	return &TransitResponse{Signature: signature, KeyVersion: key.LatestVersion}, nil
+}
+
+// TransitVerify checks a signature from TransitSign with the key version it names. A signature that doesn't match
+// isn't an error; Valid is false.
+func (s *service) TransitVerify(ctx context.Context, req *TransitSignRequest) (_ *TransitVerifyResponse, err error) {
+	op := "TransitVerify"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logRead(ctx, user.Id, op, common.TARGET_TYPE_TRANSIT_KEY, req.KeyName, err) }()
+
+	key, err := s.getTransitKeyForUse(ctx, op, user, req.KeyName, common.TRANSIT_KEY_USE_SIGN)
+	if err != nil {
+		return nil, err
+	}
+	input, err := common.DecodeTransitInput(op, "input", req.Input)
+	if err != nil {
+		return nil, err
+	}
+	version, signature, err := common.ParseTransitSignature(op, req.Signature)
+	if err != nil {
+		return nil, err
+	}
+	keyMaterial, err := s.getTransitKeyVersion(ctx, key, version)
+	if err != nil {
+		return nil, err
+	}
+	valid, err := common.TransitVerify(op, key.Type, keyMaterial, input, signature, req.Prehashed)
+	if err != nil {
+		return nil, err
+	}
+	return &TransitVerifyResponse{Valid: valid}, nil
+}
+
+// GetTransitPublicKeys returns the public key of every version of an asymmetric transit key
+func (s *service) GetTransitPublicKeys(ctx context.Context, name string) (_ *TransitPublicKeysResponse, err error) {
+	op := "GetTransitPublicKeys"
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logRead(ctx, user.Id, op, common.TARGET_TYPE_TRANSIT_KEY, name, err) }()
+
+	key, err := s.getTransitKeyForUse(ctx, op, user, name, common.TRANSIT_KEY_USE_EXPORT)
+	if err != nil {
+		return nil, err
+	}
+	versions, err := database.ListTransitKeyVersions(ctx, s.deps.Database, key.Id)
+	if err != nil {
+		return nil, err
+	}
+	for _, version := range versions {
+		keyMaterial, err := s.deps.SecretsManager.GetSecret(ctx, version.Id)
+		if err != nil {
+			return nil, err
+		}
+		version.PublicKey, err = common.TransitPublicKey(op, keyMaterial)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return &TransitPublicKeysResponse{Name: key.Name, Type: key.Type, Keys: versions}, nil
+}
+
+// newDeletedRecordPage builds a page out of deleted records fetched with pageLimit
+func newDeletedRecordPage(records []*common.DeletedRecord, pageSize int, includeTotal bool, count func() (int, error)) (*common.DeletedRecordPage, error) {
+	page := &common.DeletedRecordPage{Items: records[:pageSlice(len(records), pageSize)]}
+	cursorAt := func(idx int) *common.PageCursor {
+		return &common.PageCursor{Value: records[idx].Name, Id: records[idx].Id}
+	}
+	info, err := newPageInfo(len(records), pageSize, cursorAt, includeTotal, count)
+	if err != nil {
+		return nil, err
+	}
+	page.PageInfo = info
+	return page, nil
+}
+
+// ListDeletedSecrets lists the deleted secrets in the namespace, including purged ones, which can't be restored
+func (s *service) ListDeletedSecrets(ctx context.Context, req *PaginationRequest) (*common.DeletedRecordPage, error) {
+	namespace, err := common.FetchNamespaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	after, err := common.DecodeCursor("ListDeletedSecrets", req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	deletedSecrets, err := database.ListDeletedSecrets(ctx, s.deps.Database, namespace.Id, pageLimit(req.PageSize), req.Offset, after)
+	if err != nil {
+		return nil, err
+	}
+	return newDeletedRecordPage(deletedSecrets, req.PageSize, req.IncludeTotal, func() (int, error) {
+		return database.CountDeletedSecrets(ctx, s.deps.Database, namespace.Id)
+	})
+}
+
+// RestoreSecret restores a deleted secret, with its versions and permissions. It is logged under the name it was
+// restored with.
+func (s *service) RestoreSecret(ctx context.Context, req *RestoreRequest) (_ *common.Secret, err error) {
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	namespace, err := common.FetchNamespaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	targetId := req.Id
+	defer func() { err = s.logAction(ctx, user.Id, "RestoreSecret", common.TARGET_TYPE_SECRET, targetId, err) }()
+	secret, err := database.RestoreSecret(ctx, s.deps.Database, user.Id, namespace.Id, req.Id, req.Name)
+	if err != nil {
+		return nil, err
+	}
+	targetId = secret.Name
+	return secret, nil
+}
+
+func (s *service) ListDeletedUserGroups(ctx context.Context, req *PaginationRequest) (*common.DeletedRecordPage, error) {
+	namespace, err := common.FetchNamespaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	after, err := common.DecodeCursor("ListDeletedUserGroups", req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	deletedUserGroups, err := database.ListDeletedUserGroups(ctx, s.deps.Database, namespace.Id, pageLimit(req.PageSize), req.Offset, after)
+	if err != nil {
+		return nil, err
+	}
+	return newDeletedRecordPage(deletedUserGroups, req.PageSize, req.IncludeTotal, func() (int, error) {
+		return database.CountDeletedUserGroups(ctx, s.deps.Database, namespace.Id)
+	})
+}
+
+// RestoreUserGroup restores a deleted group, with its members and permissions
+func (s *service) RestoreUserGroup(ctx context.Context, req *RestoreRequest) (_ *common.UserGroup, err error) {
+	user, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	namespace, err := common.FetchNamespaceFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		err = s.logAction(ctx, user.Id, "RestoreUserGroup", common.TARGET_TYPE_USER_GROUP, req.Id, err)
+	}()
+	return database.RestoreUserGroup(ctx, s.deps.Database, user.Id, namespace.Id, req.Id, req.Name)
+}
+
+func (s *service) ListDeletedUsers(ctx context.Context, req *PaginationRequest) (*common.DeletedRecordPage, error) {
+	after, err := common.DecodeCursor("ListDeletedUsers", req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	deletedUsers, err := database.ListDeletedUsers(ctx, s.deps.Database, pageLimit(req.PageSize), req.Offset, after)
+	if err != nil {
+		return nil, err
+	}
+	return newDeletedRecordPage(deletedUsers, req.PageSize, req.IncludeTotal, func() (int, error) {
+		return database.CountDeletedUsers(ctx, s.deps.Database)
+	})
+}
+
+// RestoreUser restores a deleted user, with its group memberships and permissions. Its client secret is rotated, as
+// whoever held the old one may no longer be trusted, and the new one is returned like RotateUserSecret. It has to
+// request a new access token.
+func (s *service) RestoreUser(ctx context.Context, req *RestoreRequest) (_ *CreateUserResponse, err error) {
+	callingUser, err := common.FetchUserFromContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer func() { err = s.logAction(ctx, callingUser.Id, "RestoreUser", common.TARGET_TYPE_USER, req.Id, err) }()
+	userSecret := common.GenUuid()
+	user, err := database.RestoreUser(ctx, s.deps.Database, callingUser.Id, req.Id, req.Name, common.HashSha256(userSecret))
+	if err != nil {
+		return nil, err
+	}
+	s.deps.AuthUsers.Add(user.Id, user)
+	return &CreateUserResponse{
+		UserId:     user.Id,
+		UserSecret: userSecret,
+	}, nil
+}
+
+func (s *service) ListAccessLogs(ctx context.Context, req *common.ListAccessLogsRequest) (*common.AccessLogPage, error) {
+	after, err := common.DecodeCursor("ListAccessLogs", req.Cursor)
+	if err != nil {
+		return nil, err
+	}
+	logs, err := s.deps.SecretsManager.ListAccessLogs(ctx, req, pageLimit(req.PageSize), after)
+	if err != nil {
+		return nil, err
+	}
+	page := &common.AccessLogPage{Items: logs[:pageSlice(len(logs), req.PageSize)]}
+	cursorAt := func(idx int) *common.PageCursor {
+		return common.AccessLogCursor(logs[idx])
+	}
+	page.PageInfo, err = newPageInfo(len(logs), req.PageSize, cursorAt, req.IncludeTotal, func() (int, error) {
+		return s.deps.SecretsManager.CountAccessLogs(ctx, req)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+// verifyAccessLogsPageSize is the number of chained logs read from the secrets manager at a time during verification
+const verifyAccessLogsPageSize = 1000
+
+// VerifyAccessLogs walks the access log hash chain from the start and reports the first log that doesn't follow from the
+// one before it, and the last log that does. Deleting logs from the end of the chain can only be detected by comparing
+// the last log with one recorded outside the vault.
+func (s *service) VerifyAccessLogs(ctx context.Context) (*VerifyAccessLogsResponse, error) {
+	resp := &VerifyAccessLogsResponse{Valid: true}
+	var prev *common.AccessLog
+	for {
+		afterSequence := int64(0)
+		if prev != nil {
+			afterSequence = prev.Sequence
+		}
+		logs, err := s.deps.SecretsManager.ListAccessLogChain(ctx, afterSequence, verifyAccessLogsPageSize)
+		if err != nil {
+			return nil, err
+		}
+		verified, chainBreak := common.VerifyAccessLogChain(s.deps.AccessLogChainKey, prev, logs)
+		resp.Verified += verified
+		if verified > 0 {
+			resp.LastSequence = logs[verified-1].Sequence
+			resp.LastHash = logs[verified-1].Hash
+		}
+		if chainBreak != nil {
+			resp.Valid = false
+			resp.FirstBreak = chainBreak
+			return resp, nil
+		}
+		if len(logs) < verifyAccessLogsPageSize {
+			return resp, nil
+		}
+		prev = logs[len(logs)-1]
+	}
 }
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/emarcey/data-vault/common"
+	"github.com/emarcey/data-vault/dependencies"
+	"github.com/emarcey/data-vault/dependencies/secrets"
+)
+
+// fakeAuditLog records access logs, or fails to write them if logErr is set
+type fakeAuditLog struct {
+	secrets.SecretsManager
+	logs   []*common.AccessLog
+	logErr error
+}
+
+func (f *fakeAuditLog) LogAccess(_ context.Context, log *common.AccessLog) error {
+	if f.logErr != nil {
+		return f.logErr
+	}
+	f.logs = append(f.logs, log)
+	return nil
+}
+
+func newAuditTestService(secretsManager secrets.SecretsManager) *service {
+	logger := logrus.New()
+	logger.SetOutput(ioutil.Discard)
+	return &service{deps: &dependencies.Dependencies{Logger: logger, SecretsManager: secretsManager}}
+}
+
+func TestLogAction(t *testing.T) {
+	actionErr := common.NewResourceNotFoundError("DeleteUser", "id", "userId")
+	logErr := fmt.Errorf("Oh no!")
+	var tests = []struct {
+		testName  string
+		actionErr error
+		logErr    error
+		expected  error
+	}{
+		{testName: "allowed", actionErr: nil, logErr: nil, expected: nil},
+		{testName: "failed", actionErr: actionErr, logErr: nil, expected: actionErr},
+		// the change is already made, so a failed log doesn't fail the request
+		{testName: "allowed, log failed", actionErr: nil, logErr: logErr, expected: nil},
+		{testName: "failed, log failed", actionErr: actionErr, logErr: logErr, expected: actionErr},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("logAction - %v", given.testName), func(t *testing.T) {
+			auditLog := &fakeAuditLog{logErr: given.logErr}
+			s := newAuditTestService(auditLog)
+			err := s.logAction(context.Background(), "userId", "DeleteUser", common.TARGET_TYPE_USER, "targetId", given.actionErr)
+			require.Equal(t, err, given.expected, "Error %v did not equal expected %v", err, given.expected)
+		})
+	}
+}
+
+func TestLogRead(t *testing.T) {
+	actionErr := common.NewResourceNotFoundError("GetSecret", "name", "secret")
+	logErr := fmt.Errorf("Oh no!")
+	var tests = []struct {
+		testName  string
+		actionErr error
+		logErr    error
+		expected  error
+	}{
+		{testName: "allowed", actionErr: nil, logErr: nil, expected: nil},
+		{testName: "failed", actionErr: actionErr, logErr: nil, expected: actionErr},
+		// nothing was changed, so an unaudited read fails
+		{testName: "allowed, log failed", actionErr: nil, logErr: logErr, expected: logErr},
+		{testName: "failed, log failed", actionErr: actionErr, logErr: logErr, expected: logErr},
+	}
+
+	for _, given := range tests {
+		t.Run(fmt.Sprintf("logRead - %v", given.testName), func(t *testing.T) {
+			auditLog := &fakeAuditLog{logErr: given.logErr}
+			s := newAuditTestService(auditLog)
+			err := s.logRead(context.Background(), "userId", "GetSecret", common.TARGET_TYPE_SECRET, "secret", given.actionErr)
+			require.Equal(t, err, given.expected, "Error %v did not equal expected %v", err, given.expected)
+		})
+	}
+}
@@ -1,14 +1,25 @@
 package server
 
+import (
+	"time"
+
+	"github.com/emarcey/data-vault/common"
+)
+
 type PaginationRequest struct {
 	PageSize int `json:"page_size"`
 	Offset   int `json:"offset"`
+	// Cursor continues from the NextCursor of an earlier page. It can't be combined with Offset.
+	Cursor       string `json:"cursor"`
+	IncludeTotal bool   `json:"include_total"`
 }
 
 type ListUsersInGroupRequest struct {
-	UserGroupId string
-	PageSize    int `json:"page_size"`
-	Offset      int `json:"offset"`
+	UserGroupId  string
+	PageSize     int    `json:"page_size"`
+	Offset       int    `json:"offset"`
+	Cursor       string `json:"cursor"`
+	IncludeTotal bool   `json:"include_total"`
 }
 
 type CreateUserRequest struct {
@@ -34,15 +45,141 @@ func (c *CreateUserResponse) GetStatusCode() int {
 }
 
 type CreateSecretRequest struct {
-	Name        string `json:"name"`
-	Value       string `json:"value"`
-	Description string `json:"description"`
+	Name        string            `json:"name"`
+	Value       string            `json:"value"`
+	Fields      map[string]string `json:"fields"`
+	Description string            `json:"description"`
+	Labels      map[string]string `json:"labels"`
+	ExpiresAt   *time.Time        `json:"expires_at"`
+	// Generate names a secret policy to generate the value from, instead of sending Value or Fields
+	Generate string `json:"generate"`
+}
+
+type GetSecretRequest struct {
+	Name    string
+	Version int
+}
+
+type GetSecretFieldRequest struct {
+	Name    string
+	Field   string
+	Version int
+}
+
+type UpdateSecretRequest struct {
+	Name     string            `json:"-"`
+	Value    string            `json:"value"`
+	Fields   map[string]string `json:"fields"`
+	Generate string            `json:"generate"`
+}
+
+// CreateSecretFileRequest is decoded from a multipart or raw upload, rather than from JSON
+type CreateSecretFileRequest struct {
+	Name        string
+	Description string
+	ExpiresAt   *time.Time
+	ContentType string
+	Filename    string
+	Data        []byte
+}
+
+type UpdateSecretFileRequest struct {
+	Name        string
+	ContentType string
+	Filename    string
+	Data        []byte
+}
+
+type RollbackSecretRequest struct {
+	Name    string `json:"-"`
+	Version int    `json:"version"`
+}
+
+type UpdateSecretLabelsRequest struct {
+	Name   string            `json:"-"`
+	Labels map[string]string `json:"labels"`
+}
+
+type RewrapSecretsResponse struct {
+	KekId      string `json:"kek_id"`
+	Total      int    `json:"total"`
+	Rewrapped  int    `json:"rewrapped"`
+	StatusCode int    `json:"-"`
+}
+
+func (r *RewrapSecretsResponse) GetStatusCode() int {
+	if r.StatusCode == 0 {
+		return 200
+	}
+	return r.StatusCode
+}
+
+type RekeySecretResponse struct {
+	Name       string `json:"name"`
+	Rekeyed    int    `json:"rekeyed"`
+	StatusCode int    `json:"-"`
+}
+
+func (r *RekeySecretResponse) GetStatusCode() int {
+	if r.StatusCode == 0 {
+		return 200
+	}
+	return r.StatusCode
+}
+
+// PurgeSecretsRequest purges a single deleted secret if SecretId is set, and otherwise every secret deleted more than
+// serverConfigs.secretPurgeDays ago
+type PurgeSecretsRequest struct {
+	SecretId string `json:"secret_id"`
+}
+
+type PurgeSecretsResponse struct {
+	Purged     int `json:"purged"`
+	StatusCode int `json:"-"`
+}
+
+// RestoreRequest restores a deleted secret, user or user group. Name renames it as it is restored, for when an active
+// record has since taken its name.
+type RestoreRequest struct {
+	Id   string `json:"-"`
+	Name string `json:"name"`
+}
+
+func (r *PurgeSecretsResponse) GetStatusCode() int {
+	if r.StatusCode == 0 {
+		return 200
+	}
+	return r.StatusCode
+}
+
+type VerifyAccessLogsResponse struct {
+	Verified     int                         `json:"verified"`
+	Valid        bool                        `json:"valid"`
+	FirstBreak   *common.AccessLogChainBreak `json:"first_break,omitempty"`
+	LastSequence int64                       `json:"last_sequence,omitempty"`
+	LastHash     string                      `json:"last_hash,omitempty"`
+	StatusCode   int                         `json:"-"`
+}
+
+func (r *VerifyAccessLogsResponse) GetStatusCode() int {
+	if r.StatusCode == 0 {
+		return 200
+	}
+	return r.StatusCode
 }
 
 type SecretPermissionRequest struct {
 	SecretName  string `json:"-"`
 	UserId      string `json:"user_id"`
 	UserGroupId string `json:"user_group_id"`
+	Level       string `json:"level"`
+}
+
+type SecretPatternPermissionRequest struct {
+	Pattern     string `json:"pattern"`
+	UserId      string `json:"user_id"`
+	UserGroupId string `json:"user_group_id"`
+	Level       string `json:"level"`
 }
 
 type UserGroupMemberRequest struct {
@@ -50,6 +187,100 @@ type UserGroupMemberRequest struct {
 	UserId      string `json:"user_id"`
 }
 
+type CreateNamespaceRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type NamespaceAdminRequest struct {
+	Namespace string `json:"-"`
+	UserId    string `json:"user_id"`
+}
+
+type CreateDatabaseRoleRequest struct {
+	Name                 string   `json:"name"`
+	ConnectionUrl        string   `json:"connection_url"`
+	CreationStatements   []string `json:"creation_statements"`
+	RenewStatements      []string `json:"renew_statements"`
+	RevocationStatements []string `json:"revocation_statements"`
+	DefaultTtlSeconds    int      `json:"default_ttl_seconds"`
+	MaxTtlSeconds        int      `json:"max_ttl_seconds"`
+}
+
+type DatabaseRolePermissionRequest struct {
+	RoleName    string `json:"-"`
+	UserId      string `json:"user_id"`
+	UserGroupId string `json:"user_group_id"`
+}
+
+type RenewLeaseRequest struct {
+	LeaseId string `json:"-"`
+	// IncrementSeconds extends the lease from now. Zero uses the role's default TTL.
+	IncrementSeconds int `json:"increment_seconds"`
+}
+
+type CreateTransitKeyRequest struct {
+	Name string `json:"name"`
+	// Type is one of common.TRANSIT_KEY_TYPES. Defaults to aes256-gcm.
+	Type string `json:"type"`
+}
+
+type TransitKeyPermissionRequest struct {
+	KeyName     string `json:"-"`
+	UserId      string `json:"user_id"`
+	UserGroupId string `json:"user_group_id"`
+}
+
+type TransitEncryptRequest struct {
+	KeyName string `json:"-"`
+	// Plaintext is base64 encoded, so any bytes can be encrypted
+	Plaintext string `json:"plaintext"`
+}
+
+// TransitCiphertextRequest is the body of the transit decrypt and rewrap endpoints
+type TransitCiphertextRequest struct {
+	KeyName    string `json:"-"`
+	Ciphertext string `json:"ciphertext"`
+}
+
+type TransitDataKeyRequest struct {
+	KeyName string `json:"-"`
+	// Bits is the size of the data key, 128 or 256. Zero generates a 256 bit key.
+	Bits int `json:"bits"`
+	// WrappedOnly leaves the plaintext data key out of the response, for callers that store it to decrypt later
+	WrappedOnly bool `json:"wrapped_only"`
+}
+
+// TransitSignRequest is the body of the transit sign and verify endpoints. Signature is only used to verify.
+type TransitSignRequest struct {
+	KeyName string `json:"-"`
+	// Input is base64 encoded. If Prehashed is set, it's the SHA-256 digest of the data to sign.
+	Input     string `json:"input"`
+	Prehashed bool   `json:"prehashed"`
+	Signature string `json:"signature,omitempty"`
+}
+
+// TransitResponse is returned by the transit encrypt, decrypt, rewrap, datakey and sign endpoints. Plaintext is
+// base64 encoded, and each endpoint only sets the fields it returns.
+type TransitResponse struct {
+	Ciphertext string `json:"ciphertext,omitempty"`
+	Plaintext  string `json:"plaintext,omitempty"`
+	Signature  string `json:"signature,omitempty"`
+	KeyVersion int    `json:"key_version,omitempty"`
+}
+
+type TransitVerifyResponse struct {
+	Valid bool `json:"valid"`
+}
+
+// TransitPublicKeysResponse holds the public key of every version of an asymmetric transit key, so signatures from
+// any version can be verified outside the vault
+type TransitPublicKeysResponse struct {
+	Name string                      `json:"name"`
+	Type string                      `json:"type"`
+	Keys []*common.TransitKeyVersion `json:"keys"`
+}
+
 type StatusResponse struct {
 	StatusCode int `json:"-"`
 }
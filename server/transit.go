@@ -0,0 +1,353 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+
+	httptransport "github.com/go-kit/kit/transport/http"
+
+	"github.com/emarcey/data-vault/common"
+)
+
+func listTransitKeysEndpoint(s Service) endpointBuilder {
+	op := "ListTransitKeys"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*PaginationRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *PaginationRequest. Got %T", reqInterface)
+		}
+		return s.ListTransitKeys(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodePaginationRequest(op),
+		method:   HTTP_GET,
+		path:     "/transit",
+	}
+}
+
+func getTransitKeyEndpoint(s Service) endpointBuilder {
+	op := "GetTransitKey"
+	e := func(ctx context.Context, nameInterface interface{}) (interface{}, error) {
+		name, ok := nameInterface.(string)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected transit key name of type string. Got %T", nameInterface)
+		}
+		return s.GetTransitKey(ctx, name)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeRequestUrlName(op),
+		method:   HTTP_GET,
+		path:     "/transit/{name}",
+	}
+}
+
+func decodeCreateTransitKeyRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	var req CreateTransitKeyRequest
+	err = json.Unmarshal(data, &req)
+	if err != nil {
+		return nil, common.NewInvalidParamsError("CreateTransitKey", "Could not unmarshal request: %v", string(data))
+	}
+	return &req, nil
+}
+
+func createTransitKeyEndpoint(s Service) endpointBuilder {
+	op := "CreateTransitKey"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*CreateTransitKeyRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *CreateTransitKeyRequest. Got %T", reqInterface)
+		}
+		return s.CreateTransitKey(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeCreateTransitKeyRequest,
+		method:   HTTP_POST,
+		path:     "/transit",
+	}
+}
+
+func rotateTransitKeyEndpoint(s Service) endpointBuilder {
+	op := "RotateTransitKey"
+	e := func(ctx context.Context, nameInterface interface{}) (interface{}, error) {
+		name, ok := nameInterface.(string)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected transit key name of type string. Got %T", nameInterface)
+		}
+		return s.RotateTransitKey(ctx, name)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeRequestUrlName(op),
+		method:   HTTP_POST,
+		path:     "/transit/{name}/rotate",
+	}
+}
+
+func deleteTransitKeyEndpoint(s Service) endpointBuilder {
+	op := "DeleteTransitKey"
+	e := func(ctx context.Context, nameInterface interface{}) (interface{}, error) {
+		name, ok := nameInterface.(string)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected transit key name of type string. Got %T", nameInterface)
+		}
+		return nil, s.DeleteTransitKey(ctx, name)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeRequestUrlName(op),
+		method:   HTTP_DELETE,
+		path:     "/transit/{name}",
+	}
+}
+
+var decodeTransitKeyPermissionUrl = decodeRequestUrlName("TransitKeyPermission")
+
+func decodeTransitKeyPermissionRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	op := "TransitKeyPermission"
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return nil, err
+	}
+	var req TransitKeyPermissionRequest
+	err = json.Unmarshal(data, &req)
+	if err != nil {
+		return nil, common.NewInvalidParamsError(op, "Could not unmarshal request: %v", string(data))
+	}
+	keyName, err := decodeTransitKeyPermissionUrl(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	req.KeyName = keyName.(string)
+	return &req, nil
+}
+
+func createTransitKeyPermissionEndpoint(s Service) endpointBuilder {
+	op := "GrantTransitKeyPermission"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*TransitKeyPermissionRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *TransitKeyPermissionRequest. Got %T", reqInterface)
+		}
+		err := s.GrantTransitKeyPermission(ctx, req)
+		if err != nil {
+			return nil, err
+		}
+		return NewStatusResponse(), nil
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeTransitKeyPermissionRequest,
+		method:   HTTP_POST,
+		path:     "/transit/{name}/permissions",
+	}
+}
+
+func deleteTransitKeyPermissionEndpoint(s Service) endpointBuilder {
+	op := "RevokeTransitKeyPermission"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*TransitKeyPermissionRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *TransitKeyPermissionRequest. Got %T", reqInterface)
+		}
+		return nil, s.RevokeTransitKeyPermission(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeTransitKeyPermissionRequest,
+		method:   HTTP_DELETE,
+		path:     "/transit/{name}/permissions",
+	}
+}
+
+// decodeTransitBody unmarshals the body of a transit request into req, and returns the key name from the URL. The
+// body holds plaintext or ciphertext, so it isn't echoed back. An empty body is allowed if allowEmpty is set.
+func decodeTransitBody(ctx context.Context, r *http.Request, op string, req interface{}, allowEmpty bool) (string, error) {
+	data, err := ioutil.ReadAll(r.Body)
+	if err != nil {
+		return "", err
+	}
+	if len(data) > 0 || !allowEmpty {
+		err = json.Unmarshal(data, req)
+		if err != nil {
+			return "", common.NewInvalidParamsError(op, "Could not unmarshal request")
+		}
+	}
+	keyName, err := decodeRequestUrlName(op)(ctx, r)
+	if err != nil {
+		return "", err
+	}
+	return keyName.(string), nil
+}
+
+func decodeTransitEncryptRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req TransitEncryptRequest
+	keyName, err := decodeTransitBody(ctx, r, "TransitEncrypt", &req, false)
+	if err != nil {
+		return nil, err
+	}
+	req.KeyName = keyName
+	return &req, nil
+}
+
+func decodeTransitCiphertextRequest(op string) httptransport.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		var req TransitCiphertextRequest
+		keyName, err := decodeTransitBody(ctx, r, op, &req, false)
+		if err != nil {
+			return nil, err
+		}
+		req.KeyName = keyName
+		return &req, nil
+	}
+}
+
+// decodeTransitDataKeyRequest accepts an empty body, which generates a 256 bit key
+func decodeTransitDataKeyRequest(ctx context.Context, r *http.Request) (interface{}, error) {
+	var req TransitDataKeyRequest
+	keyName, err := decodeTransitBody(ctx, r, "TransitDataKey", &req, true)
+	if err != nil {
+		return nil, err
+	}
+	req.KeyName = keyName
+	return &req, nil
+}
+
+func transitEncryptEndpoint(s Service) endpointBuilder {
+	op := "TransitEncrypt"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*TransitEncryptRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *TransitEncryptRequest. Got %T", reqInterface)
+		}
+		return s.TransitEncrypt(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeTransitEncryptRequest,
+		method:   HTTP_POST,
+		path:     "/transit/{name}/encrypt",
+	}
+}
+
+func transitDecryptEndpoint(s Service) endpointBuilder {
+	op := "TransitDecrypt"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*TransitCiphertextRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *TransitCiphertextRequest. Got %T", reqInterface)
+		}
+		return s.TransitDecrypt(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeTransitCiphertextRequest(op),
+		method:   HTTP_POST,
+		path:     "/transit/{name}/decrypt",
+	}
+}
+
+func transitRewrapEndpoint(s Service) endpointBuilder {
+	op := "TransitRewrap"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*TransitCiphertextRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *TransitCiphertextRequest. Got %T", reqInterface)
+		}
+		return s.TransitRewrap(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeTransitCiphertextRequest(op),
+		method:   HTTP_POST,
+		path:     "/transit/{name}/rewrap",
+	}
+}
+
+func transitDataKeyEndpoint(s Service) endpointBuilder {
+	op := "TransitDataKey"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*TransitDataKeyRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *TransitDataKeyRequest. Got %T", reqInterface)
+		}
+		return s.TransitDataKey(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeTransitDataKeyRequest,
+		method:   HTTP_POST,
+		path:     "/transit/{name}/datakey",
+	}
+}
+
+func decodeTransitSignRequest(op string) httptransport.DecodeRequestFunc {
+	return func(ctx context.Context, r *http.Request) (interface{}, error) {
+		var req TransitSignRequest
+		keyName, err := decodeTransitBody(ctx, r, op, &req, false)
+		if err != nil {
+			return nil, err
+		}
+		req.KeyName = keyName
+		return &req, nil
+	}
+}
+
+func transitSignEndpoint(s Service) endpointBuilder {
+	op := "TransitSign"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*TransitSignRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *TransitSignRequest. Got %T", reqInterface)
+		}
+		return s.TransitSign(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeTransitSignRequest(op),
+		method:   HTTP_POST,
+		path:     "/transit/{name}/sign",
+	}
+}
+
+func transitVerifyEndpoint(s Service) endpointBuilder {
+	op := "TransitVerify"
+	e := func(ctx context.Context, reqInterface interface{}) (interface{}, error) {
+		req, ok := reqInterface.(*TransitSignRequest)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected request of type *TransitSignRequest. Got %T", reqInterface)
+		}
+		return s.TransitVerify(ctx, req)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeTransitSignRequest(op),
+		method:   HTTP_POST,
+		path:     "/transit/{name}/verify",
+	}
+}
+
+func getTransitPublicKeysEndpoint(s Service) endpointBuilder {
+	op := "GetTransitPublicKeys"
+	e := func(ctx context.Context, nameInterface interface{}) (interface{}, error) {
+		name, ok := nameInterface.(string)
+		if !ok {
+			return nil, common.NewInvalidParamsError(op, "Expected transit key name of type string. Got %T", nameInterface)
+		}
+		return s.GetTransitPublicKeys(ctx, name)
+	}
+	return endpointBuilder{
+		endpoint: e,
+		decoder:  decodeRequestUrlName(op),
+		method:   HTTP_GET,
+		path:     "/transit/{name}/public-keys",
+	}
+}
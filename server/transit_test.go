@@ -0,0 +1,87 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeTransitEncryptRequest(t *testing.T) {
+	r := httptest.NewRequest(HTTP_POST, "/transit/pii/encrypt", strings.NewReader(`{"plaintext": "c2VjcmV0"}`))
+	r = mux.SetURLVars(r, map[string]string{"name": "pii"})
+	result, err := decodeTransitEncryptRequest(context.Background(), r)
+	require.Nil(t, err, "Unexpected error in decodeTransitEncryptRequest: %v", err)
+	expected := &TransitEncryptRequest{KeyName: "pii", Plaintext: "c2VjcmV0"}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+
+	r = httptest.NewRequest(HTTP_POST, "/transit/pii/encrypt", strings.NewReader(`{"plaintext": "c2VjcmV0"`))
+	r = mux.SetURLVars(r, map[string]string{"name": "pii"})
+	result, err = decodeTransitEncryptRequest(context.Background(), r)
+	require.NotNil(t, err, "no error in decodeTransitEncryptRequest")
+	require.Nil(t, result, "Result was not nil: %v", result)
+	require.NotContains(t, err.Error(), "c2VjcmV0", "Error echoed the plaintext: %v", err)
+}
+
+func TestDecodeTransitCiphertextRequest(t *testing.T) {
+	r := httptest.NewRequest(HTTP_POST, "/transit/pii/decrypt", strings.NewReader(`{"ciphertext": "vault:v1:AAAA"}`))
+	r = mux.SetURLVars(r, map[string]string{"name": "pii"})
+	result, err := decodeTransitCiphertextRequest("TransitDecrypt")(context.Background(), r)
+	require.Nil(t, err, "Unexpected error in decodeTransitCiphertextRequest: %v", err)
+	expected := &TransitCiphertextRequest{KeyName: "pii", Ciphertext: "vault:v1:AAAA"}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+
+	r = httptest.NewRequest(HTTP_POST, "/transit/pii/decrypt", strings.NewReader(""))
+	r = mux.SetURLVars(r, map[string]string{"name": "pii"})
+	result, err = decodeTransitCiphertextRequest("TransitDecrypt")(context.Background(), r)
+	require.NotNil(t, err, "no error in decodeTransitCiphertextRequest with an empty body")
+	require.Nil(t, result, "Result was not nil: %v", result)
+}
+
+func TestDecodeTransitDataKeyRequest(t *testing.T) {
+	var tests = []struct {
+		body     string
+		expected *TransitDataKeyRequest
+	}{
+		{
+			body:     "",
+			expected: &TransitDataKeyRequest{KeyName: "pii"},
+		},
+		{
+			body:     `{"bits": 128, "wrapped_only": true}`,
+			expected: &TransitDataKeyRequest{KeyName: "pii", Bits: 128, WrappedOnly: true},
+		},
+	}
+
+	for idx, given := range tests {
+		t.Run(fmt.Sprintf("decodeTransitDataKeyRequest - Successes - %v", idx), func(t *testing.T) {
+			r := httptest.NewRequest(HTTP_POST, "/transit/pii/datakey", strings.NewReader(given.body))
+			r = mux.SetURLVars(r, map[string]string{"name": "pii"})
+			result, err := decodeTransitDataKeyRequest(context.Background(), r)
+			require.Nil(t, err, "Unexpected error in decodeTransitDataKeyRequest: %v", err)
+			require.Equal(t, result, given.expected, "Result %+v did not equal expected %+v", result, given.expected)
+		})
+	}
+}
+
+func TestDecodeTransitKeyPermissionRequest(t *testing.T) {
+	r := httptest.NewRequest(HTTP_POST, "/transit/pii/permissions", strings.NewReader(`{"user_id": "userId"}`))
+	r = mux.SetURLVars(r, map[string]string{"name": "pii"})
+	result, err := decodeTransitKeyPermissionRequest(context.Background(), r)
+	require.Nil(t, err, "Unexpected error in decodeTransitKeyPermissionRequest: %v", err)
+	expected := &TransitKeyPermissionRequest{KeyName: "pii", UserId: "userId"}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+}
+
+func TestDecodeTransitSignRequest(t *testing.T) {
+	r := httptest.NewRequest(HTTP_POST, "/transit/releases/verify", strings.NewReader(`{"input": "ZGlnZXN0", "prehashed": true, "signature": "vault:v1:AAAA"}`))
+	r = mux.SetURLVars(r, map[string]string{"name": "releases"})
+	result, err := decodeTransitSignRequest("TransitVerify")(context.Background(), r)
+	require.Nil(t, err, "Unexpected error in decodeTransitSignRequest: %v", err)
+	expected := &TransitSignRequest{KeyName: "releases", Input: "ZGlnZXN0", Prehashed: true, Signature: "vault:v1:AAAA"}
+	require.Equal(t, result, expected, "Result %+v did not equal expected %+v", result, expected)
+}
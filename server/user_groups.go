@@ -79,9 +79,11 @@ func decodeListUsersInGroupRequest(ctx context.Context, r *http.Request) (interf
 		return nil, common.NewInvalidParamsError(op, "Expected pagination of type *PaginationRequest, got %T", paginationInterface)
 	}
 	return &ListUsersInGroupRequest{
-		UserGroupId: id,
-		PageSize:    pagination.PageSize,
-		Offset:      pagination.Offset,
+		UserGroupId:  id,
+		PageSize:     pagination.PageSize,
+		Offset:       pagination.Offset,
+		Cursor:       pagination.Cursor,
+		IncludeTotal: pagination.IncludeTotal,
 	}, nil
 }
 